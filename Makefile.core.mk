@@ -336,6 +336,30 @@ go-gen:
 	@mkdir -p /tmp/bin
 	@PATH="${PATH}":/tmp/bin go generate ./...
 
+# Generate the Go types of the RateLimit API, along with its clientset, informers and listers. The API is
+# defined in this repository rather than in istio/api and istio/client-go, the generators are the same.
+RATELIMIT_CLIENT := istio.io/istio/pkg/config/apis/ratelimit/client
+CODE_GENERATOR_VERSION := v0.20.0
+ratelimit-gen:
+	@protoc -Icommon-protos -I. --gogofast_out=paths=source_relative:. --deepcopy_out=paths=source_relative:. \
+		--jsonshim_out=paths=source_relative:. pkg/config/apis/ratelimit/v1alpha1/ratelimit.proto
+	@mkdir -p /tmp/bin
+	@cd /tmp && GO111MODULE=on GOBIN=/tmp/bin go get k8s.io/code-generator/cmd/deepcopy-gen@$(CODE_GENERATOR_VERSION) \
+		k8s.io/code-generator/cmd/client-gen@$(CODE_GENERATOR_VERSION) \
+		k8s.io/code-generator/cmd/lister-gen@$(CODE_GENERATOR_VERSION) \
+		k8s.io/code-generator/cmd/informer-gen@$(CODE_GENERATOR_VERSION)
+	@rm -rf /tmp/ratelimit-gen
+	@/tmp/bin/deepcopy-gen --input-dirs $(RATELIMIT_CLIENT)/apis/ratelimit/v1alpha1 -O zz_generated.deepcopy \
+		--go-header-file common/scripts/copyright-banner-go.txt --output-base /tmp/ratelimit-gen
+	@/tmp/bin/client-gen --clientset-name versioned --input-base $(RATELIMIT_CLIENT)/apis --input ratelimit/v1alpha1 \
+		--output-package $(RATELIMIT_CLIENT)/clientset --go-header-file common/scripts/copyright-banner-go.txt --output-base /tmp/ratelimit-gen
+	@/tmp/bin/lister-gen --input-dirs $(RATELIMIT_CLIENT)/apis/ratelimit/v1alpha1 --output-package $(RATELIMIT_CLIENT)/listers \
+		--go-header-file common/scripts/copyright-banner-go.txt --output-base /tmp/ratelimit-gen
+	@/tmp/bin/informer-gen --input-dirs $(RATELIMIT_CLIENT)/apis/ratelimit/v1alpha1 \
+		--versioned-clientset-package $(RATELIMIT_CLIENT)/clientset/versioned --listers-package $(RATELIMIT_CLIENT)/listers \
+		--output-package $(RATELIMIT_CLIENT)/informers --go-header-file common/scripts/copyright-banner-go.txt --output-base /tmp/ratelimit-gen
+	@cp -r /tmp/ratelimit-gen/$(RATELIMIT_CLIENT)/. pkg/config/apis/ratelimit/client/
+
 gen-charts:
 	@operator/scripts/create_assets_gen.sh
 
@@ -346,7 +370,7 @@ refresh-goldens:
 
 update-golden: refresh-goldens
 
-gen: mod-download-go go-gen mirror-licenses format update-crds operator-proto sync-configs-from-istiod gen-kustomize update-golden ## Update all generated code.
+gen: mod-download-go go-gen mirror-licenses format update-crds operator-proto ratelimit-gen sync-configs-from-istiod gen-kustomize update-golden ## Update all generated code.
 
 check-no-modify:
 	@bin/check_no_modify.sh
@@ -51,3 +51,5 @@ if [ ! -f "${API_TMP}/kubernetes/customresourcedefinitions.gen.yaml" ]; then
 fi
 rm -f "${ROOTDIR}/manifests/charts/base/crds/crd-all.gen.yaml"
 cp "${API_TMP}/kubernetes/customresourcedefinitions.gen.yaml" "${ROOTDIR}/manifests/charts/base/crds/crd-all.gen.yaml"
+# The RateLimit API is defined in this repository, its CRD is appended to the ones of istio/api.
+cat "${ROOTDIR}/pkg/config/apis/ratelimit/v1alpha1/crd.yaml" >> "${ROOTDIR}/manifests/charts/base/crds/crd-all.gen.yaml"
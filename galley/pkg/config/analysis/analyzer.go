@@ -57,6 +57,9 @@ func (c *CombinedAnalyzer) Analyze(ctx Context) {
 			scope.Analysis.Debugf("Analyzer %q has been cancelled...", c.Metadata().Name)
 			return
 		}
+		if t, ok := ctx.(AnalyzerTracker); ok {
+			t.SetAnalyzer(a.Metadata().Name)
+		}
 		a.Analyze(ctx)
 		scope.Analysis.Debugf("Completed analyzer %q...", a.Metadata().Name)
 	}
@@ -24,6 +24,7 @@ import (
 	"istio.io/istio/galley/pkg/config/analysis/analyzers/gateway"
 	"istio.io/istio/galley/pkg/config/analysis/analyzers/injection"
 	"istio.io/istio/galley/pkg/config/analysis/analyzers/multicluster"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/ratelimit"
 	"istio.io/istio/galley/pkg/config/analysis/analyzers/schema"
 	"istio.io/istio/galley/pkg/config/analysis/analyzers/service"
 	"istio.io/istio/galley/pkg/config/analysis/analyzers/serviceentry"
@@ -44,6 +45,7 @@ func All() []analysis.Analyzer {
 		&injection.Analyzer{},
 		&injection.ImageAnalyzer{},
 		&multicluster.MeshNetworksAnalyzer{},
+		&ratelimit.ServiceAnalyzer{},
 		&service.PortNameAnalyzer{},
 		&sidecar.DefaultSelectorAnalyzer{},
 		&sidecar.SelectorAnalyzer{},
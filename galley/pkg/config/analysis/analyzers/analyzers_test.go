@@ -33,6 +33,7 @@ import (
 	"istio.io/istio/galley/pkg/config/analysis/analyzers/gateway"
 	"istio.io/istio/galley/pkg/config/analysis/analyzers/injection"
 	"istio.io/istio/galley/pkg/config/analysis/analyzers/multicluster"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/ratelimit"
 	"istio.io/istio/galley/pkg/config/analysis/analyzers/service"
 	"istio.io/istio/galley/pkg/config/analysis/analyzers/serviceentry"
 	"istio.io/istio/galley/pkg/config/analysis/analyzers/sidecar"
@@ -311,6 +312,16 @@ var testGrid = []testCase{
 			{msg.UnknownMeshNetworksServiceRegistry, "MeshNetworks meshnetworks.istio-system"},
 		},
 	},
+	{
+		name:       "rate limit services",
+		inputFiles: []string{"testdata/ratelimit-services.yaml"},
+		analyzer:   &ratelimit.ServiceAnalyzer{},
+		expected: []message{
+			{msg.ReferencedResourceNotFound, "RateLimit unknown-host.default"},
+			{msg.ReferencedResourceNotFound, "RateLimit unknown-service.default"},
+			{msg.ReferencedResourceNotFound, "RateLimit unknown-port.default"},
+		},
+	},
 	{
 		name: "authorizationpolicies",
 		inputFiles: []string{
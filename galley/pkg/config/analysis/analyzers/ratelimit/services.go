@@ -0,0 +1,101 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"fmt"
+	"strings"
+
+	"istio.io/api/networking/v1alpha3"
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/util"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	ratelimit "istio.io/istio/pkg/config/apis/ratelimit/v1alpha1"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+// ServiceAnalyzer checks that the hosts and the rate limit service referenced by each rate limit exist
+type ServiceAnalyzer struct{}
+
+var _ analysis.Analyzer = &ServiceAnalyzer{}
+
+// Metadata implements Analyzer
+func (a *ServiceAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:        "ratelimit.ServiceAnalyzer",
+		Description: "Checks the hosts and the rate limit service referenced by each rate limit",
+		Inputs: collection.Names{
+			collections.IstioNetworkingV1Alpha3Serviceentries.Name(),
+			collections.IstioRatelimitV1Alpha1Ratelimits.Name(),
+			collections.K8SCoreV1Services.Name(),
+		},
+	}
+}
+
+// Analyze implements Analyzer
+func (a *ServiceAnalyzer) Analyze(ctx analysis.Context) {
+	serviceEntryHosts := util.InitServiceEntryHostMap(ctx)
+
+	ctx.ForEach(collections.IstioRatelimitV1Alpha1Ratelimits.Name(), func(r *resource.Instance) bool {
+		a.analyzeRateLimit(r, ctx, serviceEntryHosts)
+		return true
+	})
+}
+
+func (a *ServiceAnalyzer) analyzeRateLimit(r *resource.Instance, ctx analysis.Context,
+	serviceEntryHosts map[util.ScopedFqdn]*v1alpha3.ServiceEntry) {
+	rl := r.Message.(*ratelimit.RateLimit)
+	ns := r.Metadata.FullName.Namespace
+
+	for i, h := range rl.Hosts {
+		// Wildcard hosts may match hosts that are not in the registry, such as the ones of a gateway
+		if strings.HasPrefix(h, util.Wildcard) {
+			continue
+		}
+		if util.GetDestinationHost(ns, h, serviceEntryHosts) != nil {
+			continue
+		}
+		m := msg.NewReferencedResourceNotFound(r, "host", h)
+		if line, ok := util.ErrorLine(r, fmt.Sprintf(util.RateLimitHost, i)); ok {
+			m.Line = line
+		}
+		ctx.Report(collections.IstioRatelimitV1Alpha1Ratelimits.Name(), m)
+	}
+
+	if rl.Global == nil {
+		return
+	}
+	s := util.GetDestinationHost(ns, rl.Global.Service, serviceEntryHosts)
+	if s == nil {
+		m := msg.NewReferencedResourceNotFound(r, "rate limit service", rl.Global.Service)
+		if line, ok := util.ErrorLine(r, util.RateLimitService); ok {
+			m.Line = line
+		}
+		ctx.Report(collections.IstioRatelimitV1Alpha1Ratelimits.Name(), m)
+		return
+	}
+	for _, p := range s.GetPorts() {
+		if p.GetNumber() == rl.Global.Port {
+			return
+		}
+	}
+	m := msg.NewReferencedResourceNotFound(r, "rate limit service port", fmt.Sprintf("%s:%d", rl.Global.Service, rl.Global.Port))
+	if line, ok := util.ErrorLine(r, util.RateLimitService); ok {
+		m.Line = line
+	}
+	ctx.Report(collections.IstioRatelimitV1Alpha1Ratelimits.Name(), m)
+}
@@ -91,6 +91,13 @@ const (
 	// Path for Port in ServiceEntry.
 	// Required parameters: port index.
 	ServiceEntryPort = "{.spec.ports[%d].name}"
+
+	// Path for host in RateLimit.
+	// Required parameters: host index.
+	RateLimitHost = "{.spec.hosts[%d]}"
+
+	// Path for the rate limit service in RateLimit.
+	RateLimitService = "{.spec.global.service}"
 )
 
 // ErrorLine returns the line number of the input path key in the resource
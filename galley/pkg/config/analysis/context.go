@@ -40,3 +40,10 @@ type Context interface {
 	// Canceled indicates that the context has been canceled. The analyzer should stop executing as soon as possible.
 	Canceled() bool
 }
+
+// AnalyzerTracker is optionally implemented by a Context that attributes the reported messages to the
+// analyzer which reported them.
+type AnalyzerTracker interface {
+	// SetAnalyzer is called with the name of the analyzer before it is run
+	SetAnalyzer(name string)
+}
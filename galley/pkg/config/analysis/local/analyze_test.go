@@ -104,6 +104,8 @@ func TestAnalyzersRun(t *testing.T) {
 
 	result, err := sa.Analyze(cancel)
 	g.Expect(err).To(BeNil())
+	// Messages are attributed to the analyzer which reported them
+	m.Analyzer = a.Metadata().Name
 	g.Expect(result.Messages).To(ConsistOf(m))
 	g.Expect(collectionAccessed).To(Equal(basicmeta.K8SCollection1.Name()))
 	g.Expect(result.ExecutedAnalyzers).To(ConsistOf(a.Metadata().Name))
@@ -131,6 +133,7 @@ func TestFilterOutputByNamespace(t *testing.T) {
 
 	result, err := sa.Analyze(cancel)
 	g.Expect(err).To(BeNil())
+	msg1.Analyzer = a.Metadata().Name
 	g.Expect(result.Messages).To(ConsistOf(msg1))
 }
 
@@ -241,15 +241,25 @@ type context struct {
 	cancelCh           chan struct{}
 	messages           diag.Messages
 	collectionReporter CollectionReporterFn
+	analyzer           string
 }
 
 var _ analysis.Context = &context{}
+var _ analysis.AnalyzerTracker = &context{}
 
 // Report implements analysis.Context
 func (c *context) Report(_ collection.Name, m diag.Message) {
+	if m.Analyzer == "" {
+		m.Analyzer = c.analyzer
+	}
 	c.messages.Add(m)
 }
 
+// SetAnalyzer implements analysis.AnalyzerTracker
+func (c *context) SetAnalyzer(name string) {
+	c.analyzer = name
+}
+
 // Find implements analysis.Context
 func (c *context) Find(col collection.Name, name resource.FullName) *resource.Instance {
 	c.collectionReporter(col)
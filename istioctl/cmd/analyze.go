@@ -101,6 +101,9 @@ func Analyze() *cobra.Command {
   # and suppress MisplacedAnnotation on deployment foobar in namespace default.
   istioctl analyze -S "IST0103=Pod *.testing" -S "IST0107=Deployment foobar.default"
 
+  # Analyze yaml files in CI, reporting the messages as code scanning annotations
+  istioctl analyze --use-kube=false -o sarif my-app-config/ > analyze.sarif
+
   # List available analyzers
   istioctl analyze -L`,
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -227,7 +230,13 @@ func Analyze() *cobra.Command {
 			outputMessages := result.Messages.SetDocRef("istioctl-analyze").FilterOutLowerThan(outputThreshold.Level)
 
 			// Print all the messages to stdout in the specified format
-			output, err := formatting.Print(outputMessages, msgOutputFormat, colorize)
+			var output string
+			if msgOutputFormat == formatting.JUnitFormat {
+				// Analyzers which ran without reporting anything are listed as passed test cases
+				output, err = formatting.PrintJUnit(outputMessages, result.ExecutedAnalyzers)
+			} else {
+				output, err = formatting.Print(outputMessages, msgOutputFormat, colorize)
+			}
 			if err != nil {
 				return err
 			}
@@ -300,7 +309,7 @@ func gatherFiles(cmd *cobra.Command, args []string) ([]local.ReaderSource, error
 
 		// Handle "-" as stdin as a special case.
 		if f == "-" {
-			if isatty.IsTerminal(os.Stdin.Fd()) && !isJSONorYAMLOutputFormat() {
+			if isatty.IsTerminal(os.Stdin.Fd()) && !isMachineReadableOutputFormat() {
 				fmt.Fprint(cmd.OutOrStdout(), "Reading from stdin:\n")
 			}
 			r = os.Stdin
@@ -428,6 +437,6 @@ func analyzeTargetAsString() string {
 }
 
 // TODO: Refactor output writer so that it is smart enough to know when to output what.
-func isJSONorYAMLOutputFormat() bool {
-	return msgOutputFormat == formatting.JSONFormat || msgOutputFormat == formatting.YAMLFormat
+func isMachineReadableOutputFormat() bool {
+	return msgOutputFormat != formatting.LogFormat
 }
@@ -18,23 +18,47 @@ import (
 	"context"
 	"fmt"
 	"io/ioutil"
+	"net"
+	"net/http"
 	"os"
+	"strings"
 
 	"github.com/spf13/cobra"
 
 	"istio.io/istio/istioctl/pkg/authz"
 	"istio.io/istio/istioctl/pkg/util/configdump"
 	"istio.io/istio/istioctl/pkg/util/handlers"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/constants"
 	"istio.io/istio/pkg/kube"
+	"istio.io/istio/pkg/spiffe"
 	"istio.io/pkg/log"
 )
 
-var (
-	configDumpFile string
-)
+type authzCheckArgs struct {
+	configDumpFile  string
+	policyFiles     []string
+	labels          map[string]string
+	sourcePrincipal string
+	sourceNamespace string
+	sourceIP        string
+	remoteIP        string
+	port            int
+	method          string
+	host            string
+	path            string
+	headers         []string
+	claims          []string
+	tcp             bool
+}
+
+// requestFlags are the flags describing a request to check against the authorization policies.
+var requestFlags = []string{"source-principal", "source-namespace", "source-ip", "remote-ip", "port", "method",
+	"host", "path", "header", "claim", "policy-file", "tcp"}
 
-var (
-	checkCmd = &cobra.Command{
+func checkCmd() *cobra.Command {
+	args := authzCheckArgs{}
+	cmd := &cobra.Command{
 		Use:   "check [<type>/]<name>[.<namespace>]",
 		Short: "Check AuthorizationPolicy applied in the pod.",
 		Long: `Check prints the AuthorizationPolicy applied to a pod by directly checking
@@ -42,7 +66,26 @@ the Envoy configuration of the pod. The command is especially useful for inspect
 the policy propagation from Istiod to Envoy and the final AuthorizationPolicy list merged 
 from multiple sources (mesh-level, namespace-level and workload-level).
 
-The command also supports reading from a standalone config dump file with flag -f.`,
+The command also supports reading from a standalone config dump file with flag -f.
+
+When a request is described with the request flags, like --method or --source-principal, the
+request is checked against the RBAC filters the same way Envoy would, and the command reports
+the AuthorizationPolicy rule that allowed or denied the request and why the other rules did not
+match. The policies can also be read from files with --policy-file, in which case the RBAC
+filters are generated the same way Istiod does for a workload with the --labels in the namespace.
+The HTTP filters are generated, or the network filters of the TCP filter chains with --tcp.
+
+The check has the following limits:
+  - The CUSTOM action is delegated to an external authorizer and is not checked, its rules are
+    only reported.
+  - The RBAC network filters only see the connection, so the source principal, the IP addresses,
+    the port and the SNI of the request. Their rules never match on HTTP attributes.
+  - The attributes of the request are used as given: the JWT claims are not validated, and the
+    request is authenticated with mTLS if it has a source principal.
+  - Rules with conditions, and metadata other than that of the Istio authn filter, never match.
+  - With a pod or a config dump, the filter chain of --port is checked, or the first filter chain
+    with RBAC filters if none matches. The HTTP route and the TLS inspection are not simulated.
+  - With --policy-file, the default mesh config is used and trust domain aliases are not applied.`,
 		Example: `  # Check AuthorizationPolicy applied to pod httpbin-88ddbcfdd-nt5jb:
   istioctl x authz check httpbin-88ddbcfdd-nt5jb
 
@@ -50,28 +93,65 @@ The command also supports reading from a standalone config dump file with flag -
   istioctl proxy-status deployment/productpage-v1
 
   # Check AuthorizationPolicy from Envoy config dump file:
-  istioctl x authz check -f httpbin_config_dump.json`,
-		Args: func(cmd *cobra.Command, args []string) error {
-			if len(args) > 1 {
+  istioctl x authz check -f httpbin_config_dump.json
+
+  # Check whether a request from the sleep service account is allowed by the policies of a pod:
+  istioctl x authz check httpbin-88ddbcfdd-nt5jb --source-principal cluster.local/ns/default/sa/sleep \
+    --port 8000 --method POST --path /post --header x-token=admin
+
+  # Check a request with a JWT against policy files, for a workload in the foo namespace:
+  istioctl x authz check --policy-file policies.yaml --labels app=httpbin -n foo \
+    --claim iss=https://accounts.example.com --claim sub=alice --claim groups=admin --path /admin`,
+		Args: func(cmd *cobra.Command, a []string) error {
+			if len(a) > 1 {
 				cmd.Println(cmd.UsageString())
 				return fmt.Errorf("check requires only <pod-name>[.<pod-namespace>]")
 			}
+			if len(args.policyFiles) > 0 && (len(a) == 1 || args.configDumpFile != "") {
+				cmd.Println(cmd.UsageString())
+				return fmt.Errorf("--policy-file cannot be used with a pod or --file")
+			}
+			if args.tcp && len(args.policyFiles) == 0 {
+				cmd.Println(cmd.UsageString())
+				return fmt.Errorf("--tcp can only be used with --policy-file")
+			}
 			return nil
 		},
-		RunE: func(cmd *cobra.Command, args []string) error {
+		RunE: func(cmd *cobra.Command, a []string) error {
+			checkRequest := false
+			for _, flag := range requestFlags {
+				checkRequest = checkRequest || cmd.Flags().Changed(flag)
+			}
+			var req *authz.Request
+			if checkRequest {
+				var err error
+				if req, err = args.request(); err != nil {
+					return err
+				}
+			}
+
+			if len(args.policyFiles) > 0 {
+				filters, err := args.generateFilters()
+				if err != nil {
+					return err
+				}
+				authz.Check(filters, req).Print(cmd.OutOrStdout())
+				return nil
+			}
+
 			var configDump *configdump.Wrapper
 			var err error
-			if configDumpFile != "" {
-				configDump, err = getConfigDumpFromFile(configDumpFile)
+			if args.configDumpFile != "" {
+				configDump, err = getConfigDumpFromFile(args.configDumpFile)
 				if err != nil {
-					return fmt.Errorf("failed to get config dump from file %s: %s", configDumpFile, err)
+					return fmt.Errorf("failed to get config dump from file %s: %s", args.configDumpFile, err)
 				}
-			} else if len(args) == 1 {
+			} else if len(a) == 1 {
 				kubeClient, err := kubeClient(kubeconfig, configContext)
 				if err != nil {
 					return fmt.Errorf("failed to create k8s client: %w", err)
 				}
-				podName, podNamespace, err := handlers.InferPodInfoFromTypedResource(args[0],
+				podName, podNamespace, err := handlers.InferPodInfoFromTypedResource(a[0],
 					handlers.HandleNamespace(namespace, defaultNamespace),
 					kubeClient.UtilFactory())
 				if err != nil {
@@ -82,18 +162,108 @@ The command also supports reading from a standalone config dump file with flag -
 					return fmt.Errorf("failed to get config dump from pod %s in %s", podName, podNamespace)
 				}
 			} else {
-				return fmt.Errorf("expecting pod name or config dump, found: %d", len(args))
+				return fmt.Errorf("expecting pod name or config dump, found: %d", len(a))
 			}
 
 			analyzer, err := authz.NewAnalyzer(configDump)
 			if err != nil {
 				return err
 			}
-			analyzer.Print(cmd.OutOrStdout())
+			if req == nil {
+				analyzer.Print(cmd.OutOrStdout())
+				return nil
+			}
+			filters, err := analyzer.Filters(req.DestinationPort)
+			if err != nil {
+				return err
+			}
+			authz.Check(filters, req).Print(cmd.OutOrStdout())
 			return nil
 		},
 	}
-)
+	cmd.PersistentFlags().StringVarP(&args.configDumpFile, "file", "f", "",
+		"The json file with Envoy config dump to be checked")
+	cmd.PersistentFlags().StringSliceVar(&args.policyFiles, "policy-file", nil,
+		"AuthorizationPolicy YAML files to check the request against, instead of the policies applied to a pod")
+	cmd.PersistentFlags().StringToStringVar(&args.labels, "labels", nil,
+		"Labels of the workload the policy files are checked for, in the form key=value")
+	cmd.PersistentFlags().StringVar(&args.sourcePrincipal, "source-principal", "",
+		"Identity of the source of the request, like cluster.local/ns/default/sa/sleep. "+
+			"Empty for plain text requests")
+	cmd.PersistentFlags().StringVar(&args.sourceNamespace, "source-namespace", "",
+		"Namespace of the source of the request, used for its identity with the default service account "+
+			"when --source-principal is not set")
+	cmd.PersistentFlags().StringVar(&args.sourceIP, "source-ip", "", "IP address of the source of the request")
+	cmd.PersistentFlags().StringVar(&args.remoteIP, "remote-ip", "",
+		"Original client IP address of the request, defaults to --source-ip")
+	cmd.PersistentFlags().IntVar(&args.port, "port", 0, "Destination port of the request")
+	cmd.PersistentFlags().StringVar(&args.method, "method", http.MethodGet, "Method of the request")
+	cmd.PersistentFlags().StringVar(&args.host, "host", "", "Host header of the request")
+	cmd.PersistentFlags().StringVar(&args.path, "path", "/", "Path of the request")
+	cmd.PersistentFlags().StringArrayVar(&args.headers, "header", nil,
+		"Header of the request in the form key=value. May be repeated")
+	cmd.PersistentFlags().StringArrayVar(&args.claims, "claim", nil,
+		"Claim of the JWT of the request in the form key=value, like iss=issuer or groups=admin. "+
+			"Nested claims are keyed like in the policies, e.g. a[b]=value. May be repeated")
+	cmd.PersistentFlags().BoolVar(&args.tcp, "tcp", false,
+		"Check the request against the RBAC network filters generated from --policy-file for TCP traffic, "+
+			"instead of the HTTP filters")
+	return cmd
+}
+
+func (a authzCheckArgs) request() (*authz.Request, error) {
+	req := &authz.Request{
+		SourcePrincipal: strings.TrimPrefix(a.sourcePrincipal, spiffe.URIPrefix),
+		SourceIP:        a.sourceIP,
+		RemoteIP:        a.remoteIP,
+		DestinationPort: uint32(a.port),
+		Method:          a.method,
+		Host:            a.host,
+		Path:            a.path,
+		Headers:         http.Header{},
+		Claims:          map[string][]string{},
+	}
+	if req.SourcePrincipal == "" && a.sourceNamespace != "" {
+		req.SourcePrincipal = fmt.Sprintf("%s/ns/%s/sa/default", constants.DefaultKubernetesDomain, a.sourceNamespace)
+	}
+	for _, ip := range []string{a.sourceIP, a.remoteIP} {
+		if ip != "" && net.ParseIP(ip) == nil {
+			return nil, fmt.Errorf("invalid IP address %q", ip)
+		}
+	}
+	for _, h := range a.headers {
+		kv := strings.SplitN(h, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, fmt.Errorf("invalid header %q, expected key=value", h)
+		}
+		req.Headers.Add(kv[0], kv[1])
+	}
+	for _, c := range a.claims {
+		kv := strings.SplitN(c, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, fmt.Errorf("invalid claim %q, expected key=value", c)
+		}
+		req.Claims[kv[0]] = append(req.Claims[kv[0]], kv[1])
+	}
+	return req, nil
+}
+
+func (a authzCheckArgs) generateFilters() ([]authz.Filter, error) {
+	var policies []config.Config
+	for _, f := range a.policyFiles {
+		data, err := ioutil.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		filePolicies, err := authz.ParsePolicies(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %v", f, err)
+		}
+		policies = append(policies, filePolicies...)
+	}
+	return authz.GenerateFilters(policies, istioNamespace, handlers.HandleNamespace(namespace, defaultNamespace),
+		a.labels, a.tcp)
+}
 
 func getConfigDumpFromFile(filename string) (*configdump.Wrapper, error) {
 	file, err := os.Open(filename)
@@ -152,12 +322,7 @@ func AuthZ() *cobra.Command {
 		Short: "Inspect Istio AuthorizationPolicy",
 	}
 
-	cmd.AddCommand(checkCmd)
+	cmd.AddCommand(checkCmd())
 	cmd.Long += "\n\n" + ExperimentalMsg
 	return cmd
 }
-
-func init() {
-	checkCmd.PersistentFlags().StringVarP(&configDumpFile, "file", "f", "",
-		"The json file with Envoy config dump to be checked")
-}
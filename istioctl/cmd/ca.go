@@ -0,0 +1,188 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/rest"
+
+	"istio.io/istio/istioctl/pkg/clioptions"
+	"istio.io/istio/pkg/kube"
+	"istio.io/istio/security/pkg/pki/ca"
+	"istio.io/istio/security/pkg/pki/util"
+)
+
+func caCmd() *cobra.Command {
+	caCmd := &cobra.Command{
+		Use:   "ca",
+		Short: "Manage the certificates issued by the Istiod CA",
+	}
+	caCmd.AddCommand(caRevokeCmd())
+	caCmd.AddCommand(caAuditCmd())
+	return caCmd
+}
+
+func caRevokeCmd() *cobra.Command {
+	var opts clioptions.ControlPlaneOptions
+	var certFiles []string
+	var token string
+	cmd := &cobra.Command{
+		Use:   "revoke [<serial-number>...]",
+		Short: "Revokes certificates issued by the Istiod CA",
+		Long: `Adds certificates to the certificate revocation list (CRL) of the Istiod CA. The request is sent to the
+admin server of an Istiod instance, which stores the revoked certificates in the istio-ca-crl secret of its
+namespace. Istiod pushes the updated CRL to the proxies, which then reject the mTLS connections using these
+certificates.
+
+The request is authenticated with the Kubernetes credentials of the current context, or with --token, and
+the user must be allowed to update the istio-ca-crl secret.
+
+Certificates are identified by their hex encoded serial number, as printed by "openssl x509 -noout -serial",
+or by their PEM encoded certificate chain.
+
+Revocation is only supported when the workload certificates are signed by Istiod with a root certificate
+allowed to sign CRLs: the command fails with an intermediate CA certificate. The revoked certificates are
+listed by the /debug/crlz endpoint of Istiod.`,
+		Example: `  # Revoke a certificate by its serial number
+  istioctl experimental ca revoke 5d:6e:41:9f:0b:2e:c3:71:8a:01:9c:5e:22:48:0d:b4
+
+  # Revoke the certificate of a certificate chain file
+  istioctl experimental ca revoke --cert cert-chain.pem`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var serials []*big.Int
+			for _, arg := range args {
+				serial, err := ca.ParseSerialNumber(arg)
+				if err != nil {
+					return err
+				}
+				serials = append(serials, serial)
+			}
+			for _, f := range certFiles {
+				certPEM, err := ioutil.ReadFile(f)
+				if err != nil {
+					return err
+				}
+				// The first certificate of a chain is the leaf certificate.
+				cert, err := util.ParsePemEncodedCertificate(certPEM)
+				if err != nil {
+					return fmt.Errorf("failed to parse the certificate %s: %v", f, err)
+				}
+				serials = append(serials, cert.SerialNumber)
+			}
+			if len(serials) == 0 {
+				return fmt.Errorf("no certificate to revoke: expecting a serial number or a --cert file")
+			}
+
+			kubeClient, err := kubeClientWithRevision(kubeconfig, configContext, opts.Revision)
+			if err != nil {
+				return err
+			}
+			resp, err := revokeCertificates(kubeClient, serials, token)
+			if err != nil {
+				return fmt.Errorf("failed to revoke the certificates: %v", err)
+			}
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "%d certificate(s) revoked", resp.Revoked)
+			if resp.AlreadyRevoked > 0 {
+				_, _ = fmt.Fprintf(cmd.OutOrStdout(), ", %d already revoked", resp.AlreadyRevoked)
+			}
+			_, _ = fmt.Fprintln(cmd.OutOrStdout())
+			return nil
+		},
+	}
+	cmd.PersistentFlags().StringSliceVar(&certFiles, "cert", nil,
+		"PEM encoded certificate or certificate chain files of the certificates to revoke")
+	cmd.PersistentFlags().StringVar(&token, "token", "",
+		"Kubernetes bearer token authenticating the request, instead of the credentials of the current context")
+	opts.AttachControlPlaneFlags(cmd)
+	return cmd
+}
+
+// revokeCertificates sends the revocation request to the admin server of an Istiod instance, through a
+// port forward.
+func revokeCertificates(kubeClient kube.ExtendedClient, serials []*big.Int, token string) (*ca.RevokeResponse, error) {
+	pods, err := kubeClient.GetIstioPods(context.TODO(), istioNamespace, map[string]string{
+		"labelSelector": "app=istiod",
+		"fieldSelector": "status.phase=Running",
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(pods) == 0 {
+		return nil, fmt.Errorf("unable to find any Istiod instances")
+	}
+	fw, err := kubeClient.NewPortForwarder(pods[0].Name, pods[0].Namespace, "127.0.0.1", 0, 15014)
+	if err != nil {
+		return nil, err
+	}
+	if err := fw.Start(); err != nil {
+		return nil, fmt.Errorf("failure running port forward process: %v", err)
+	}
+	defer fw.Close()
+
+	revokeReq := ca.RevokeRequest{}
+	for _, serial := range serials {
+		revokeReq.SerialNumbers = append(revokeReq.SerialNumbers, ca.FormatSerialNumber(serial))
+	}
+	body, err := json.Marshal(revokeReq)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodPost, "http://"+fw.Address()+ca.RevokePath, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	// The transport of the Kubernetes client adds its bearer token, including the tokens of the exec and
+	// auth provider plugins.
+	config := rest.CopyConfig(kubeClient.RESTConfig())
+	if token != "" {
+		config.BearerToken = token
+		config.BearerTokenFile = ""
+	}
+	transport, err := rest.TransportFor(config)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := (&http.Client{Transport: transport}).Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusUnauthorized:
+		return nil, fmt.Errorf("%s: set --token to a valid Kubernetes bearer token", strings.TrimSpace(string(respBody)))
+	default:
+		return nil, fmt.Errorf("%s", strings.TrimSpace(string(respBody)))
+	}
+	out := &ca.RevokeResponse{}
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return nil, fmt.Errorf("unexpected response from %s: %v", pods[0].Name, err)
+	}
+	return out, nil
+}
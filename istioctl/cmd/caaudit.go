@@ -0,0 +1,229 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"istio.io/istio/istioctl/pkg/clioptions"
+	"istio.io/istio/security/pkg/server/ca/audit"
+)
+
+const tableOutput = "table"
+
+func caAuditCmd() *cobra.Command {
+	var opts clioptions.ControlPlaneOptions
+	var files []string
+	var since, until, outcome, output, keyFile string
+	var verify bool
+	filter := audit.Filter{}
+	cmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Queries the audit log of the certificates signed by the Istiod CA",
+		Long: `Lists the audit records of the certificate signing requests handled by the Istiod CA: who requested
+a certificate, from which address, with which SANs and TTL, and the serial number of the issued certificate.
+
+The records are read from all the Istiod instances, which must be started with CA_AUDIT_LOG_FILE, or from
+audit log files copied from them with --file.
+
+Each Istiod instance chains its records by their HMACs, keyed with the secret of CA_AUDIT_LOG_KEY_FILE. With
+--verify and that key, the chains are checked, reporting the records that were modified, removed or reordered,
+and the records Istiod dropped because its audit log queue was full.`,
+		Example: `  # List the certificates issued to a service account during the last day
+  istioctl experimental ca audit --identity ns/default/sa/reviews --since 24h
+
+  # Find who requested a certificate
+  istioctl experimental ca audit --serial 5d:6e:41:9f:0b:2e:c3:71:8a:01:9c:5e:22:48:0d:b4
+
+  # Verify the chains of a copied audit log
+  istioctl experimental ca audit --file ca-audit.log --verify --key-file ca-audit.key`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var err error
+			now := time.Now()
+			if filter.Since, err = parseAuditTime(since, now); err != nil {
+				return err
+			}
+			if filter.Until, err = parseAuditTime(until, now); err != nil {
+				return err
+			}
+			filter.Outcome = audit.Outcome(strings.ToUpper(outcome))
+			if output != tableOutput && output != jsonOutput {
+				return fmt.Errorf("unknown output format %q, expected %s or %s", output, tableOutput, jsonOutput)
+			}
+			var key []byte
+			if verify {
+				if keyFile == "" {
+					return fmt.Errorf("--verify requires the key the records are authenticated with: set --key-file")
+				}
+				if key, err = ioutil.ReadFile(keyFile); err != nil {
+					return fmt.Errorf("failed to read the audit log key: %v", err)
+				}
+			}
+
+			// The chains can only be verified with all their records, the filter is then applied locally.
+			query := filter
+			if verify || len(files) > 0 {
+				query = audit.Filter{}
+			}
+			var records []audit.Record
+			if len(files) > 0 {
+				for _, f := range files {
+					fileRecords, err := audit.ReadFile(f)
+					if err != nil {
+						return err
+					}
+					records = append(records, fileRecords...)
+				}
+			} else {
+				if records, err = queryCAAuditLogs(opts, query); err != nil {
+					return err
+				}
+			}
+
+			if verify {
+				errs := audit.Verify(records, key)
+				for _, err := range errs {
+					_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Error: %v\n", err)
+				}
+				if len(errs) > 0 {
+					return fmt.Errorf("the audit log failed verification with %d error(s)", len(errs))
+				}
+				_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Verified %d audit record(s)\n", len(records))
+			}
+
+			audit.SortByTime(records)
+			records = filterAuditRecords(records, filter)
+			if output == jsonOutput {
+				return audit.Write(cmd.OutOrStdout(), records)
+			}
+			return printAuditRecords(cmd.OutOrStdout(), records)
+		},
+	}
+	cmd.PersistentFlags().StringSliceVar(&files, "file", nil,
+		"Audit log files to read instead of querying Istiod, ordered from the oldest to the newest")
+	cmd.PersistentFlags().StringVar(&filter.Identity, "identity", "",
+		"Only show the records whose caller identities or SANs contain this string")
+	cmd.PersistentFlags().StringVar(&filter.SerialNumber, "serial", "",
+		"Only show the record of the certificate with this hex encoded serial number")
+	cmd.PersistentFlags().StringVar(&outcome, "outcome", "",
+		"Only show the records with this outcome: issued, unauthenticated, rejected or failed")
+	cmd.PersistentFlags().StringVar(&filter.ClientAddress, "client", "",
+		"Only show the records of the requests from this client IP")
+	cmd.PersistentFlags().StringVar(&since, "since", "",
+		"Only show the records newer than a relative duration like 1h, or an RFC3339 time")
+	cmd.PersistentFlags().StringVar(&until, "until", "",
+		"Only show the records older than a relative duration like 1h, or an RFC3339 time")
+	cmd.PersistentFlags().IntVar(&filter.Limit, "limit", 0,
+		"Max number of records to show, the most recent ones")
+	cmd.PersistentFlags().BoolVar(&verify, "verify", false,
+		"Verify the HMAC chains of the audit records")
+	cmd.PersistentFlags().StringVar(&keyFile, "key-file", "",
+		"File containing the key the audit records are authenticated with, the CA_AUDIT_LOG_KEY_FILE of Istiod")
+	cmd.PersistentFlags().StringVarP(&output, "output", "o", tableOutput, "Output format: table or json")
+	opts.AttachControlPlaneFlags(cmd)
+	return cmd
+}
+
+// queryCAAuditLogs returns the audit records of all the Istiod instances.
+func queryCAAuditLogs(opts clioptions.ControlPlaneOptions, filter audit.Filter) ([]audit.Record, error) {
+	kubeClient, err := kubeClientWithRevision(kubeconfig, configContext, opts.Revision)
+	if err != nil {
+		return nil, err
+	}
+	responses, err := kubeClient.AllDiscoveryDo(context.TODO(), istioNamespace, "/debug/ca_auditz?"+filter.Values().Encode())
+	if err != nil {
+		return nil, fmt.Errorf("unable to query Istiod for the CA audit log: %v", err)
+	}
+	istiods := make([]string, 0, len(responses))
+	for istiod := range responses {
+		istiods = append(istiods, istiod)
+	}
+	sort.Strings(istiods)
+	var records []audit.Record
+	for _, istiod := range istiods {
+		istiodRecords, err := audit.Read(bytes.NewReader(responses[istiod]))
+		if err != nil {
+			return nil, fmt.Errorf("unexpected response from %s (is the CA audit log enabled?): %v", istiod, err)
+		}
+		records = append(records, istiodRecords...)
+	}
+	return records, nil
+}
+
+// filterAuditRecords returns the records matching the filter, keeping the most recent ones up to its limit.
+func filterAuditRecords(records []audit.Record, filter audit.Filter) []audit.Record {
+	out := make([]audit.Record, 0, len(records))
+	for i := range records {
+		if filter.Match(&records[i]) {
+			out = append(out, records[i])
+		}
+	}
+	if filter.Limit > 0 && len(out) > filter.Limit {
+		out = out[len(out)-filter.Limit:]
+	}
+	return out
+}
+
+// parseAuditTime parses a time relative to now, like 1h, or an RFC3339 time.
+func parseAuditTime(s string, now time.Time) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q, expected a duration like 1h or an RFC3339 time", s)
+	}
+	return t, nil
+}
+
+func printAuditRecords(out io.Writer, records []audit.Record) error {
+	w := new(tabwriter.Writer).Init(out, 0, 8, 3, ' ', 0)
+	_, _ = fmt.Fprintln(w, "TIME\tINSTANCE\tOUTCOME\tCALLER\tSANS\tSERIAL\tTTL\tCLIENT")
+	for _, r := range records {
+		sans := r.GrantedSANs
+		if len(sans) == 0 {
+			sans = r.RequestedSANs
+		}
+		ttl := "-"
+		if r.GrantedTTLSeconds > 0 {
+			ttl = (time.Duration(r.GrantedTTLSeconds) * time.Second).String()
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			r.Time.Format(time.RFC3339), r.Instance, r.Outcome, orDash(strings.Join(r.CallerIdentities, ",")),
+			orDash(strings.Join(sans, ",")), orDash(r.SerialNumber), ttl, orDash(r.ClientAddress))
+	}
+	return w.Flush()
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -51,6 +51,8 @@ func configCmd() *cobra.Command {
   istioctl config list`,
 	}
 	configCmd.AddCommand(listCommand())
+	configCmd.AddCommand(configHistoryCmd())
+	configCmd.AddCommand(configRollbackCmd())
 	return configCmd
 }
 
@@ -0,0 +1,299 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+
+	"istio.io/istio/istioctl/pkg/clioptions"
+	"istio.io/istio/istioctl/pkg/util/handlers"
+	"istio.io/istio/pilot/pkg/xds"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/schema/collection"
+	"istio.io/istio/pkg/config/schema/gvk"
+)
+
+// rollbackKinds are the kinds which can be rolled back to a previous version.
+var rollbackKinds = map[config.GroupVersionKind]bool{
+	gvk.VirtualService:  true,
+	gvk.DestinationRule: true,
+}
+
+type configHistoryTarget struct {
+	schema    collection.Schema
+	name      string
+	namespace string
+}
+
+func (t configHistoryTarget) key() string {
+	return config.Key(t.schema.Resource().Kind(), t.name, t.namespace)
+}
+
+func parseConfigHistoryTarget(args []string) (configHistoryTarget, error) {
+	if len(args) != 2 {
+		return configHistoryTarget{}, fmt.Errorf("expecting a type and a resource name, got %d arguments", len(args))
+	}
+	s, err := findPilotSchema(args[0])
+	if err != nil {
+		return configHistoryTarget{}, err
+	}
+	name, ns := handlers.InferPodInfo(args[1], handlers.HandleNamespace(namespace, defaultNamespace))
+	return configHistoryTarget{schema: s, name: name, namespace: ns}, nil
+}
+
+func configHistoryCmd() *cobra.Command {
+	var opts clioptions.ControlPlaneOptions
+	var diffFrom, diffTo string
+	cmd := &cobra.Command{
+		Use:   "history <type> <name>[.<namespace>]",
+		Short: "Lists the previous versions of an Istio resource known by Istiod",
+		Long: `Lists the versions of an Istio resource recorded by each Istiod instance, with the proxies each
+version was acknowledged by. The versions are identified by their config ledger version, which prefixes the
+nonces of the configuration sent to the proxies, or by their Kubernetes resource version.
+
+Istiod only keeps the last PILOT_CONFIG_HISTORY_REVISIONS versions observed since it started.`,
+		Example: `  # List the versions of the reviews virtual service
+  istioctl experimental config history virtualservice reviews.default
+
+  # Show the changes made to the reviews virtual service since a version
+  istioctl experimental config history virtualservice reviews.default --diff-from 6Q1RW7kh1P8A
+`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			target, err := parseConfigHistoryTarget(args)
+			if err != nil {
+				return err
+			}
+			kubeClient, err := kubeClientWithRevision(kubeconfig, configContext, opts.Revision)
+			if err != nil {
+				return err
+			}
+			if diffFrom != "" {
+				path := fmt.Sprintf("/debug/config_diff?resource=%s&from=%s&to=%s",
+					url.QueryEscape(target.key()), url.QueryEscape(diffFrom), url.QueryEscape(diffTo))
+				responses, err := kubeClient.AllDiscoveryDo(context.TODO(), istioNamespace, path)
+				if err != nil {
+					return fmt.Errorf("unable to query Istiod for the config diff: %v", err)
+				}
+				diff, err := configDiffResponse(responses)
+				if err != nil {
+					return fmt.Errorf("no Istiod instance returned a diff for %s: %v", target.key(), err)
+				}
+				_, _ = fmt.Fprint(cmd.OutOrStdout(), diff)
+				return nil
+			}
+
+			path := fmt.Sprintf("/debug/config_history?resource=%s", url.QueryEscape(target.key()))
+			responses, err := kubeClient.AllDiscoveryDo(context.TODO(), istioNamespace, path)
+			if err != nil {
+				return fmt.Errorf("unable to query Istiod for the config history: %v", err)
+			}
+			histories := map[string]xds.ConfigHistoryStatus{}
+			for istiod, response := range responses {
+				h := xds.ConfigHistoryStatus{}
+				if err := json.Unmarshal(response, &h); err != nil {
+					return fmt.Errorf("unexpected response from %s (is config history enabled?): %s", istiod, string(response))
+				}
+				histories[istiod] = h
+			}
+			return printConfigHistory(cmd.OutOrStdout(), histories)
+		},
+	}
+	cmd.PersistentFlags().StringVar(&diffFrom, "diff-from", "",
+		"Show the diff between this version and the --diff-to version instead of listing the versions")
+	cmd.PersistentFlags().StringVar(&diffTo, "diff-to", "",
+		"The version the --diff-from version is compared to. Defaults to the latest version")
+	opts.AttachControlPlaneFlags(cmd)
+	return cmd
+}
+
+func printConfigHistory(out io.Writer, histories map[string]xds.ConfigHistoryStatus) error {
+	w := new(tabwriter.Writer).Init(out, 0, 8, 3, ' ', 0)
+	istiods := make([]string, 0, len(histories))
+	for istiod := range histories {
+		istiods = append(istiods, istiod)
+	}
+	sort.Strings(istiods)
+	_, _ = fmt.Fprintln(w, "ISTIOD\tLEDGER VERSION\tRESOURCE VERSION\tTIMESTAMP\tPROXIES")
+	for _, istiod := range istiods {
+		for _, rev := range histories[istiod].Revisions {
+			rv := rev.ResourceVersion
+			if rev.Deleted {
+				rv = "<deleted>"
+			}
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\n",
+				istiod, rev.LedgerVersion, rv, rev.Timestamp.Format(time.RFC3339), len(rev.Proxies))
+		}
+	}
+	return w.Flush()
+}
+
+func configRollbackCmd() *cobra.Command {
+	var opts clioptions.ControlPlaneOptions
+	var toVersion string
+	var dryRun bool
+	cmd := &cobra.Command{
+		Use:   "rollback <type> <name>[.<namespace>] --to <version>",
+		Short: "Re-applies a previous version of a VirtualService or DestinationRule",
+		Long: `Re-applies the spec of a previous version of a VirtualService or DestinationRule, as recorded by Istiod.
+The version is either a config ledger version or a Kubernetes resource version, as listed by
+'istioctl experimental config history'. The resource is re-created if it was deleted.`,
+		Example: `  # Roll back the reviews virtual service to the version with resource version 1234
+  istioctl experimental config rollback virtualservice reviews.default --to 1234
+
+  # Print the resource the rollback would apply, without applying it
+  istioctl experimental config rollback destinationrule reviews.default --to 6Q1RW7kh1P8A --dry-run
+`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if toVersion == "" {
+				return errors.New("the version to roll back to must be set with --to")
+			}
+			target, err := parseConfigHistoryTarget(args)
+			if err != nil {
+				return err
+			}
+			if !rollbackKinds[target.schema.Resource().GroupVersionKind()] {
+				return fmt.Errorf("rollback is not supported for %s, only for VirtualService and DestinationRule",
+					target.schema.Resource().Kind())
+			}
+			kubeClient, err := kubeClientWithRevision(kubeconfig, configContext, opts.Revision)
+			if err != nil {
+				return err
+			}
+			path := fmt.Sprintf("/debug/config_history?resource=%s&version=%s",
+				url.QueryEscape(target.key()), url.QueryEscape(toVersion))
+			responses, err := kubeClient.AllDiscoveryDo(context.TODO(), istioNamespace, path)
+			if err != nil {
+				return fmt.Errorf("unable to query Istiod for the config history: %v", err)
+			}
+			previous := previousConfigVersion(responses)
+			if previous == nil {
+				return fmt.Errorf("version %s of %s was not found in the Istiod config history", toVersion, target.key())
+			}
+
+			obj, err := rollbackConfig(target, previous, dryRun)
+			if err != nil {
+				return err
+			}
+			if dryRun {
+				out, err := yaml.Marshal(obj.Object)
+				if err != nil {
+					return err
+				}
+				_, _ = fmt.Fprint(cmd.OutOrStdout(), string(out))
+				return nil
+			}
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "%s %s.%s rolled back to version %s\n",
+				target.schema.Resource().Kind(), target.name, target.namespace, toVersion)
+			return nil
+		},
+	}
+	cmd.PersistentFlags().StringVar(&toVersion, "to", "",
+		"The ledger or resource version to roll back to")
+	cmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false,
+		"Print the resource which would be applied instead of applying it")
+	opts.AttachControlPlaneFlags(cmd)
+	return cmd
+}
+
+// previousConfigVersion returns the first resource returned by the Istiod instances, in name order.
+func previousConfigVersion(responses map[string][]byte) *unstructured.Unstructured {
+	for _, istiod := range sortedDiscoveryResponses(responses) {
+		obj := &unstructured.Unstructured{}
+		if err := json.Unmarshal(responses[istiod], &obj.Object); err != nil {
+			continue
+		}
+		if _, f := obj.Object["spec"]; f {
+			return obj
+		}
+	}
+	return nil
+}
+
+// configDiffResponse returns the first diff returned by the Istiod instances, in name order. The other
+// responses are errors, such as an unknown version, which are returned if no instance returned a diff.
+func configDiffResponse(responses map[string][]byte) (string, error) {
+	var errs []string
+	for _, istiod := range sortedDiscoveryResponses(responses) {
+		response := string(responses[istiod])
+		if strings.HasPrefix(response, "--- ") {
+			return response, nil
+		}
+		errs = append(errs, fmt.Sprintf("%s: %s", istiod, strings.TrimSpace(response)))
+	}
+	if len(errs) == 0 {
+		return "", errors.New("no response")
+	}
+	return "", errors.New(strings.Join(errs, "; "))
+}
+
+// rollbackConfig applies the spec of the previous version to the resource, and returns the applied resource.
+func rollbackConfig(target configHistoryTarget, previous *unstructured.Unstructured, dryRun bool) (*unstructured.Unstructured, error) {
+	dclient, err := clientGetter(kubeconfig, configContext)
+	if err != nil {
+		return nil, err
+	}
+	r := dclient.Resource(target.schema.Resource().GroupVersionResource()).Namespace(target.namespace)
+	current, err := r.Get(context.TODO(), target.name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		// The resource was deleted, re-create it
+		obj := &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": previous.GetAPIVersion(),
+			"kind":       previous.GetKind(),
+			"spec":       previous.Object["spec"],
+		}}
+		obj.SetName(target.name)
+		obj.SetNamespace(target.namespace)
+		obj.SetLabels(previous.GetLabels())
+		obj.SetAnnotations(previous.GetAnnotations())
+		if dryRun {
+			return obj, nil
+		}
+		return r.Create(context.TODO(), obj, metav1.CreateOptions{})
+	} else if err != nil {
+		return nil, err
+	}
+	current.Object["spec"] = previous.Object["spec"]
+	if dryRun {
+		return current, nil
+	}
+	return r.Update(context.TODO(), current, metav1.UpdateOptions{})
+}
+
+// sortedDiscoveryResponses returns the names of the Istiod instances which responded, sorted.
+func sortedDiscoveryResponses(responses map[string][]byte) []string {
+	istiods := make([]string, 0, len(responses))
+	for istiod := range responses {
+		istiods = append(istiods, istiod)
+	}
+	sort.Strings(istiods)
+	return istiods
+}
@@ -0,0 +1,198 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/fake"
+
+	"istio.io/istio/pilot/pkg/xds"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+func TestConfigHistory(t *testing.T) {
+	ts := time.Date(2020, 11, 1, 10, 0, 0, 0, time.UTC)
+	history, err := json.Marshal(xds.ConfigHistoryStatus{
+		Resource: "VirtualService/default/reviews",
+		Revisions: []xds.ConfigRevisionStatus{
+			{ResourceVersion: "1", LedgerVersion: "ledger1", Timestamp: ts, Proxies: []string{"a.default", "b.default"}},
+			{LedgerVersion: "ledger2", Timestamp: ts, Deleted: true},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []execTestCase{
+		{
+			execClientConfig: map[string][]byte{"istiod-1": history},
+			args:             []string{"x", "config", "history", "virtualservice", "reviews.default"},
+			expectedOutput: `ISTIOD     LEDGER VERSION   RESOURCE VERSION   TIMESTAMP              PROXIES
+istiod-1   ledger1          1                  2020-11-01T10:00:00Z   2
+istiod-1   ledger2          <deleted>          2020-11-01T10:00:00Z   0
+`,
+		},
+		{
+			execClientConfig: map[string][]byte{"istiod-1": []byte("--- a\n+++ b\n")},
+			args:             []string{"x", "config", "history", "virtualservice", "reviews.default", "--diff-from", "ledger1"},
+			expectedOutput:   "--- a\n+++ b\n",
+		},
+		{
+			execClientConfig: map[string][]byte{
+				"istiod-1": []byte("version ledger1 of VirtualService/default/reviews not found"),
+				"istiod-2": []byte("--- a\n+++ b\n"),
+			},
+			args:           []string{"x", "config", "history", "virtualservice", "reviews.default", "--diff-from", "ledger1"},
+			expectedOutput: "--- a\n+++ b\n",
+		},
+		{
+			execClientConfig: map[string][]byte{"istiod-1": []byte("version ledger1 of VirtualService/default/reviews not found")},
+			args:             []string{"x", "config", "history", "virtualservice", "reviews.default", "--diff-from", "ledger1"},
+			expectedString:   "istiod-1: version ledger1 of VirtualService/default/reviews not found",
+			wantException:    true,
+		},
+		{
+			execClientConfig: map[string][]byte{"istiod-1": []byte("Pilot config history is disabled.")},
+			args:             []string{"x", "config", "history", "virtualservice", "reviews.default"},
+			expectedString:   "is config history enabled?",
+			wantException:    true,
+		},
+		{
+			args:           []string{"x", "config", "history", "unknown", "reviews.default"},
+			expectedString: "type unknown is not recognized",
+			wantException:  true,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(fmt.Sprintf("case %d %v", i, c.args), func(t *testing.T) {
+			verifyExecTestOutput(t, c)
+		})
+	}
+}
+
+func TestConfigRollback(t *testing.T) {
+	previous, err := json.Marshal(map[string]interface{}{
+		"apiVersion": "networking.istio.io/v1alpha3",
+		"kind":       "VirtualService",
+		"metadata":   map[string]interface{}{"name": "reviews", "namespace": "default"},
+		"spec":       map[string]interface{}{"hosts": []interface{}{"reviews-v1"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	responses := map[string][]byte{"istiod-1": previous}
+
+	cases := []struct {
+		name      string
+		objs      []runtime.Object
+		execTest  execTestCase
+		wantHosts []interface{}
+	}{
+		{
+			name: "update",
+			objs: []runtime.Object{configRollbackObject("reviews-v2")},
+			execTest: execTestCase{
+				execClientConfig: responses,
+				args:             []string{"x", "config", "rollback", "virtualservice", "reviews.default", "--to", "1"},
+				expectedOutput:   "VirtualService reviews.default rolled back to version 1\n",
+			},
+			wantHosts: []interface{}{"reviews-v1"},
+		},
+		{
+			name: "re-create",
+			execTest: execTestCase{
+				execClientConfig: responses,
+				args:             []string{"x", "config", "rollback", "virtualservice", "reviews.default", "--to", "1"},
+				expectedOutput:   "VirtualService reviews.default rolled back to version 1\n",
+			},
+			wantHosts: []interface{}{"reviews-v1"},
+		},
+		{
+			name: "dry run",
+			objs: []runtime.Object{configRollbackObject("reviews-v2")},
+			execTest: execTestCase{
+				execClientConfig: responses,
+				args:             []string{"x", "config", "rollback", "virtualservice", "reviews.default", "--to", "1", "--dry-run"},
+				expectedString:   "- reviews-v1",
+			},
+			wantHosts: []interface{}{"reviews-v2"},
+		},
+		{
+			name: "unknown version",
+			objs: []runtime.Object{configRollbackObject("reviews-v2")},
+			execTest: execTestCase{
+				execClientConfig: map[string][]byte{"istiod-1": []byte("version 7 of VirtualService/default/reviews not found")},
+				args:             []string{"x", "config", "rollback", "virtualservice", "reviews.default", "--to", "7"},
+				expectedString:   "was not found in the Istiod config history",
+				wantException:    true,
+			},
+			wantHosts: []interface{}{"reviews-v2"},
+		},
+		{
+			name: "unsupported kind",
+			execTest: execTestCase{
+				args:           []string{"x", "config", "rollback", "gateway", "ingress.default", "--to", "1"},
+				expectedString: "rollback is not supported for Gateway",
+				wantException:  true,
+			},
+		},
+		{
+			name: "missing version",
+			execTest: execTestCase{
+				args:           []string{"x", "config", "rollback", "virtualservice", "reviews.default"},
+				expectedString: "must be set with --to",
+				wantException:  true,
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			client := fake.NewSimpleDynamicClient(runtime.NewScheme(), c.objs...)
+			clientGetter = func(_, _ string) (dynamic.Interface, error) {
+				return client, nil
+			}
+			verifyExecTestOutput(t, c.execTest)
+			if c.wantHosts == nil {
+				return
+			}
+			got, err := client.Resource(collections.IstioNetworkingV1Alpha3Virtualservices.Resource().GroupVersionResource()).Namespace("default").
+				Get(context.TODO(), "reviews", metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			hosts, _, _ := unstructured.NestedSlice(got.Object, "spec", "hosts")
+			if fmt.Sprint(hosts) != fmt.Sprint(c.wantHosts) {
+				t.Fatalf("expected hosts %v, got %v", c.wantHosts, hosts)
+			}
+		})
+	}
+}
+
+func configRollbackObject(host string) *unstructured.Unstructured {
+	obj := newUnstructured("networking.istio.io/v1alpha3", "VirtualService", "default", "reviews", "2")
+	obj.Object["spec"] = map[string]interface{}{"hosts": []interface{}{host}}
+	return obj
+}
@@ -53,6 +53,9 @@ var (
 
 	// output format (yaml or short)
 	outputFormat string
+
+	// Envoy config dump file, read instead of the config dump of a pod
+	configDumpFile string
 )
 
 // Level is an enumeration of all supported log levels.
@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"time"
 
 	envoy_corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	xdsapi "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
@@ -30,6 +31,7 @@ import (
 	"istio.io/istio/istioctl/pkg/writer/compare"
 	"istio.io/istio/istioctl/pkg/writer/pilot"
 	pilotxds "istio.io/istio/pilot/pkg/xds"
+	"istio.io/istio/pkg/cmd"
 	"istio.io/istio/pkg/kube"
 	"istio.io/pkg/log"
 )
@@ -148,6 +150,8 @@ func newKubeClient(kubeconfig, configContext string) (kube.ExtendedClient, error
 func xdsStatusCommand() *cobra.Command {
 	var opts clioptions.ControlPlaneOptions
 	var centralOpts clioptions.CentralControlPlaneOptions
+	var watch bool
+	var watchInterval time.Duration
 
 	statusCmd := &cobra.Command{
 		Use:   "proxy-status [<type>/]<name>[.<namespace>]",
@@ -161,6 +165,10 @@ Retrieves last sent and last acknowledged xDS sync from Istiod to each Envoy in
   # Retrieve sync diff for a single Envoy and Istiod
   istioctl x proxy-status istio-egressgateway-59585c5b9c-ndc59.istio-system
 
+  # Watch the sync status transitions of all Envoys during a rollout, with the time they took to
+  # acknowledge each push. Interrupt to print the p50/p99 convergence times.
+  istioctl x proxy-status --watch
+
   # SECURITY OPTIONS
 
   # Retrieve proxy status information directly from the control plane, using token security
@@ -186,6 +194,13 @@ Retrieves last sent and last acknowledged xDS sync from Istiod to each Envoy in
 				return err
 			}
 
+			if watch {
+				if len(args) > 0 {
+					return fmt.Errorf("--watch can only be used for all proxies")
+				}
+				return watchXdsStatus(c, &centralOpts, kubeClient, watchInterval)
+			}
+
 			if len(args) > 0 {
 				podName, ns, err := handlers.InferPodInfoFromTypedResource(args[0],
 					handlers.HandleNamespace(namespace, defaultNamespace),
@@ -234,6 +249,46 @@ Retrieves last sent and last acknowledged xDS sync from Istiod to each Envoy in
 
 	opts.AttachControlPlaneFlags(statusCmd)
 	centralOpts.AttachControlPlaneFlags(statusCmd)
+	statusCmd.PersistentFlags().BoolVarP(&watch, "watch", "w", false,
+		"Watch the sync status transitions of the proxies until interrupted, then summarize the convergence times")
+	statusCmd.PersistentFlags().DurationVar(&watchInterval, "watch-interval", 2*time.Second,
+		"The interval at which Istiod is polled with --watch")
 
 	return statusCmd
 }
+
+// watchXdsStatus polls the sync details of the proxies from all Istiod instances and prints the
+// transitions until interrupted.
+func watchXdsStatus(c *cobra.Command, centralOpts *clioptions.CentralControlPlaneOptions,
+	kubeClient kube.ExtendedClient, interval time.Duration) error {
+	xdsRequest := xdsapi.DiscoveryRequest{
+		Node: &envoy_corev3.Node{
+			Id: "debug~0.0.0.0~istioctl~cluster.local",
+		},
+		TypeUrl: pilotxds.TypeDebugSyncDetails,
+	}
+	watcher := pilot.NewXdsSyncWatcher(c.OutOrStdout())
+
+	stop := make(chan struct{})
+	go cmd.WaitSignal(stop)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for polls := 0; ; polls++ {
+		xdsResponses, err := multixds.AllRequestAndProcessXds(&xdsRequest, centralOpts, istioNamespace, kubeClient)
+		if err == nil {
+			err = watcher.Update(xdsResponses, time.Now())
+		}
+		if err != nil {
+			if polls == 0 {
+				return err
+			}
+			// Istiod instances may be restarted during a rollout
+			_, _ = fmt.Fprintf(c.ErrOrStderr(), "failed to get the sync status: %v\n", err)
+		}
+		select {
+		case <-stop:
+			return watcher.PrintSummary()
+		case <-ticker.C:
+		}
+	}
+}
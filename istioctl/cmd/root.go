@@ -235,8 +235,10 @@ debug and diagnose their Istio mesh.
 	deprecate(vmBootstrapCmd)
 	experimentalCmd.AddCommand(vmBootstrapCmd)
 	experimentalCmd.AddCommand(waitCmd())
+	experimentalCmd.AddCommand(simulateCmd())
 	experimentalCmd.AddCommand(mesh.UninstallCmd(loggingOptions))
 	experimentalCmd.AddCommand(configCmd())
+	experimentalCmd.AddCommand(caCmd())
 	postInstallWebhookCmd := Webhook()
 	deprecate(postInstallWebhookCmd)
 	postInstallCmd.AddCommand(postInstallWebhookCmd)
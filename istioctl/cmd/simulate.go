@@ -0,0 +1,468 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"text/tabwriter"
+
+	cluster "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+
+	"istio.io/istio/istioctl/pkg/util/configdump"
+	"istio.io/istio/istioctl/pkg/util/handlers"
+	"istio.io/istio/pilot/pkg/config/kube/crd"
+	"istio.io/istio/pilot/pkg/config/kube/crdclient"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/simulation"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/constants"
+	"istio.io/istio/pkg/config/schema/gvk"
+	"istio.io/istio/pkg/kube"
+)
+
+type simulateArgs struct {
+	configDumpFile   string
+	istioConfigFiles []string
+	clusterConfig    bool
+	address          string
+	port             int
+	protocol         string
+	host             string
+	path             string
+	method           string
+	headers          []string
+	tls              string
+	sni              string
+	mode             string
+	labels           map[string]string
+}
+
+func simulateCmd() *cobra.Command {
+	args := simulateArgs{}
+	cmd := &cobra.Command{
+		Use:   "simulate [<type>/]<name>[.<namespace>]",
+		Short: "Simulate a request against the Envoy configuration of a pod.",
+		Long: `Simulate walks the Envoy configuration of a pod the same way Envoy would for the given request,
+and prints the listener, filter chain, route and cluster that would be selected. When the request does
+not match, the reason and the candidates that were considered are printed instead. This is useful to
+debug why a VirtualService or other configuration does not apply to a request.
+
+The configuration is read from the pod, or from a standalone config dump file with flag -f. Alternatively,
+the configuration is generated the way Istiod would from Istio configuration, such as VirtualServices and
+DestinationRules, and the Kubernetes Services they refer to. This allows testing configuration changes before
+applying them. The Istio configuration is read from files with flag --istio-config, from the cluster with flag
+--cluster-config, or from both, the files replacing the resources of the cluster with the same name. The
+configuration is generated for the given pod, or else for a sidecar in the namespace, or a gateway with
+--mode gateway, with the labels of flag --labels.`,
+		Example: `  # Simulate an outbound HTTP request from a pod to the reviews service
+  istioctl x simulate productpage-v1-7d6f8b8b8b-abcde --port 9080 --host reviews:9080 --path /reviews/0
+
+  # Simulate a request with a header from an Envoy config dump file
+  istioctl x simulate -f productpage_config_dump.json --port 9080 --host reviews:9080 --header end-user=jason
+
+  # Simulate an inbound mTLS request to a pod
+  istioctl x simulate reviews-v1-5b7f94f9bc-wp5tb --mode inbound --address 10.0.0.1 --port 9080 --tls mtls
+
+  # Simulate a request from a pod with a VirtualService which is not applied yet
+  istioctl x simulate productpage-v1-7d6f8b8b8b-abcde --cluster-config --istio-config reviews-vs.yaml \
+    --port 9080 --host reviews:9080 --header end-user=jason`,
+		Args: func(cmd *cobra.Command, a []string) error {
+			generate := len(args.istioConfigFiles) > 0 || args.clusterConfig
+			if args.configDumpFile != "" && (len(a) == 1 || generate) {
+				cmd.Println(cmd.UsageString())
+				return fmt.Errorf("--file cannot be used with a pod, --istio-config or --cluster-config")
+			}
+			if len(a) == 0 && args.configDumpFile == "" && !generate {
+				cmd.Println(cmd.UsageString())
+				return fmt.Errorf("simulate requires <pod-name>[.<pod-namespace>], --file or --istio-config parameter")
+			}
+			if args.port == 0 {
+				cmd.Println(cmd.UsageString())
+				return fmt.Errorf("simulate requires --port")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, a []string) error {
+			call, err := args.call()
+			if err != nil {
+				return err
+			}
+			if len(args.istioConfigFiles) > 0 || args.clusterConfig {
+				sim, err := args.simulationFromIstioConfig(a, call.CallMode)
+				if err != nil {
+					return err
+				}
+				printSimulation(cmd.OutOrStdout(), sim, call)
+				return nil
+			}
+			var configDump *configdump.Wrapper
+			if args.configDumpFile != "" {
+				configDump, err = getConfigDumpFromFile(args.configDumpFile)
+				if err != nil {
+					return fmt.Errorf("failed to get config dump from file %s: %s", args.configDumpFile, err)
+				}
+			} else {
+				kubeClient, err := kubeClient(kubeconfig, configContext)
+				if err != nil {
+					return fmt.Errorf("failed to create k8s client: %w", err)
+				}
+				podName, podNamespace, err := handlers.InferPodInfoFromTypedResource(a[0],
+					handlers.HandleNamespace(namespace, defaultNamespace),
+					kubeClient.UtilFactory())
+				if err != nil {
+					return err
+				}
+				configDump, err = getConfigDumpFromPod(podName, podNamespace)
+				if err != nil {
+					return fmt.Errorf("failed to get config dump from pod %s in %s: %v", podName, podNamespace, err)
+				}
+			}
+			sim, err := simulationFromConfigDump(configDump)
+			if err != nil {
+				return err
+			}
+			printSimulation(cmd.OutOrStdout(), sim, call)
+			return nil
+		},
+	}
+	cmd.PersistentFlags().StringVarP(&args.configDumpFile, "file", "f", "",
+		"Envoy config dump JSON file")
+	cmd.PersistentFlags().StringSliceVar(&args.istioConfigFiles, "istio-config", nil,
+		"Files with Istio configuration and Kubernetes Services to generate the configuration from")
+	cmd.PersistentFlags().BoolVar(&args.clusterConfig, "cluster-config", false,
+		"Generate the configuration from the Istio configuration and Services of the cluster")
+	cmd.PersistentFlags().StringVar(&args.address, "address", "",
+		"Destination address of the request. Defaults to an arbitrary address")
+	cmd.PersistentFlags().IntVar(&args.port, "port", 0, "Destination port of the request")
+	cmd.PersistentFlags().StringVar(&args.protocol, "protocol", string(simulation.HTTP),
+		"Protocol of the request: one of http|http2|tcp")
+	cmd.PersistentFlags().StringVar(&args.host, "host", "", "Host header of the request")
+	cmd.PersistentFlags().StringVar(&args.path, "path", "/", "Path of the request")
+	cmd.PersistentFlags().StringVar(&args.method, "method", http.MethodGet, "Method of the request")
+	cmd.PersistentFlags().StringArrayVar(&args.headers, "header", nil,
+		"Header of the request in the form key=value. May be repeated")
+	cmd.PersistentFlags().StringVar(&args.tls, "tls", string(simulation.Plaintext),
+		"TLS mode of the request: one of plaintext|tls|mtls")
+	cmd.PersistentFlags().StringVar(&args.sni, "sni", "", "SNI of the request. Defaults to the host for tls requests")
+	cmd.PersistentFlags().StringToStringVar(&args.labels, "labels", nil,
+		"Labels of the proxy the configuration is generated for when no pod is given, such as istio=ingressgateway "+
+			"to select the Gateways of the default ingress gateway with --mode gateway")
+	cmd.PersistentFlags().StringVar(&args.mode, "mode", string(simulation.CallModeOutbound),
+		"Type of request to simulate: outbound for requests sent by the application, inbound for requests "+
+			"received by the application, or gateway for requests to a gateway")
+	return cmd
+}
+
+func (a simulateArgs) call() (simulation.Call, error) {
+	call := simulation.Call{
+		Address:    a.address,
+		Port:       a.port,
+		Path:       a.path,
+		Method:     a.method,
+		Protocol:   simulation.Protocol(strings.ToLower(a.protocol)),
+		TLS:        simulation.TLSMode(strings.ToLower(a.tls)),
+		HostHeader: a.host,
+		Headers:    http.Header{},
+		Sni:        a.sni,
+		CallMode:   simulation.CallMode(strings.ToLower(a.mode)),
+	}
+	switch call.Protocol {
+	case simulation.HTTP, simulation.HTTP2, simulation.TCP:
+	default:
+		return simulation.Call{}, fmt.Errorf("protocol %q not supported", a.protocol)
+	}
+	switch call.TLS {
+	case simulation.Plaintext, simulation.TLS, simulation.MTLS:
+	default:
+		return simulation.Call{}, fmt.Errorf("tls mode %q not supported", a.tls)
+	}
+	switch call.CallMode {
+	case simulation.CallModeOutbound, simulation.CallModeInbound, simulation.CallModeGateway:
+	default:
+		return simulation.Call{}, fmt.Errorf("mode %q not supported", a.mode)
+	}
+	for _, h := range a.headers {
+		kv := strings.SplitN(h, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return simulation.Call{}, fmt.Errorf("invalid header %q, expected key=value", h)
+		}
+		call.Headers.Add(kv[0], kv[1])
+	}
+	return call, nil
+}
+
+// simulationFromConfigDump builds a simulation from the dynamic listeners, clusters and routes of a config dump
+func simulationFromConfigDump(dump *configdump.Wrapper) (*simulation.Simulation, error) {
+	listenerDump, err := dump.GetDynamicListenerDump(false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read listeners: %v", err)
+	}
+	listeners := make([]*listener.Listener, 0, len(listenerDump.DynamicListeners))
+	for _, l := range listenerDump.DynamicListeners {
+		ll := &listener.Listener{}
+		if err := ptypes.UnmarshalAny(l.ActiveState.Listener, ll); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal listener: %v", err)
+		}
+		listeners = append(listeners, ll)
+	}
+
+	clusterDump, err := dump.GetDynamicClusterDump(false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read clusters: %v", err)
+	}
+	clusters := make([]*cluster.Cluster, 0, len(clusterDump.DynamicActiveClusters))
+	for _, c := range clusterDump.DynamicActiveClusters {
+		cc := &cluster.Cluster{}
+		if err := ptypes.UnmarshalAny(c.Cluster, cc); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal cluster: %v", err)
+		}
+		clusters = append(clusters, cc)
+	}
+
+	routeDump, err := dump.GetDynamicRouteDump(false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read routes: %v", err)
+	}
+	routes := make([]*route.RouteConfiguration, 0, len(routeDump.DynamicRouteConfigs))
+	for _, r := range routeDump.DynamicRouteConfigs {
+		rc := &route.RouteConfiguration{}
+		if err := ptypes.UnmarshalAny(r.RouteConfig, rc); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal route: %v", err)
+		}
+		routes = append(routes, rc)
+	}
+	return simulation.NewSimulationFromConfig(listeners, clusters, routes), nil
+}
+
+// simulationFromIstioConfig builds a simulation from the configuration Istiod would generate from the Istio configs
+// and Services of the cluster and of the --istio-config files. The configuration is generated for the pod in podArgs,
+// if any, or for a proxy of the mode in the namespace, with the --labels.
+func (a simulateArgs) simulationFromIstioConfig(podArgs []string, mode simulation.CallMode) (*simulation.Simulation, error) {
+	ns := handlers.HandleNamespace(namespace, defaultNamespace)
+	proxy := &model.Proxy{
+		ConfigNamespace: ns,
+		Metadata:        &model.NodeMetadata{Labels: a.labels, Namespace: ns},
+	}
+	if mode == simulation.CallModeGateway {
+		proxy.Type = model.Router
+	}
+
+	istioConfig := simulation.IstioConfig{}
+	if len(podArgs) == 1 || a.clusterConfig {
+		client, err := kubeClient(kubeconfig, configContext)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create k8s client: %w", err)
+		}
+		if a.clusterConfig {
+			if istioConfig.Configs, istioConfig.Services, err = clusterIstioConfig(client); err != nil {
+				return nil, fmt.Errorf("failed to read the Istio configuration of the cluster: %v", err)
+			}
+		}
+		if len(podArgs) == 1 {
+			podName, podNamespace, err := handlers.InferPodInfoFromTypedResource(podArgs[0], ns, client.UtilFactory())
+			if err != nil {
+				return nil, err
+			}
+			pod, err := client.Kube().CoreV1().Pods(podNamespace).Get(context.TODO(), podName, metav1.GetOptions{})
+			if err != nil {
+				return nil, err
+			}
+			istioConfig.Pod = pod
+			proxy = proxyForPod(pod, mode)
+		}
+	}
+	if mode == simulation.CallModeGateway && len(proxy.Metadata.Labels) == 0 {
+		return nil, fmt.Errorf("the gateway mode requires a gateway pod or --labels selecting the Gateways")
+	}
+
+	for _, f := range a.istioConfigFiles {
+		data, err := ioutil.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		fileConfigs, fileServices, err := parseIstioConfigFile(string(data), ns)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %v", f, err)
+		}
+		istioConfig.Configs = overlayConfigs(istioConfig.Configs, fileConfigs)
+		istioConfig.Services = append(istioConfig.Services, fileServices...)
+	}
+
+	return simulation.NewSimulationFromIstioConfig(istioConfig, proxy)
+}
+
+// clusterIstioConfig returns the networking configs and the Services of the cluster.
+func clusterIstioConfig(client kube.ExtendedClient) ([]config.Config, []*corev1.Service, error) {
+	var configs []config.Config
+	add := func(kind config.GroupVersionKind, obj runtime.Object) {
+		if c := crdclient.TranslateObject(obj, kind, constants.DefaultKubernetesDomain); c != nil {
+			configs = append(configs, *c)
+		}
+	}
+	ctx := context.TODO()
+	nc := client.Istio().NetworkingV1alpha3()
+	vs, err := nc.VirtualServices(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := range vs.Items {
+		add(gvk.VirtualService, &vs.Items[i])
+	}
+	dr, err := nc.DestinationRules(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := range dr.Items {
+		add(gvk.DestinationRule, &dr.Items[i])
+	}
+	gw, err := nc.Gateways(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := range gw.Items {
+		add(gvk.Gateway, &gw.Items[i])
+	}
+	se, err := nc.ServiceEntries(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := range se.Items {
+		add(gvk.ServiceEntry, &se.Items[i])
+	}
+	sc, err := nc.Sidecars(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := range sc.Items {
+		add(gvk.Sidecar, &sc.Items[i])
+	}
+
+	services, err := client.Kube().CoreV1().Services(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, nil, err
+	}
+	out := make([]*corev1.Service, 0, len(services.Items))
+	for i := range services.Items {
+		out = append(out, &services.Items[i])
+	}
+	return configs, out, nil
+}
+
+// parseIstioConfigFile returns the Istio configs and the Kubernetes Services in the YAML documents. Resources
+// without a namespace are in ns. The other Kubernetes resources are ignored.
+func parseIstioConfigFile(data, ns string) ([]config.Config, []*corev1.Service, error) {
+	var configs []config.Config
+	var services []*corev1.Service
+	decode := scheme.Codecs.UniversalDeserializer().Decode
+	for _, doc := range strings.Split(data, "\n---") {
+		if strings.TrimSpace(doc) == "" {
+			continue
+		}
+		// Istio resources are not in the Kubernetes scheme.
+		if obj, _, err := decode([]byte(doc), nil, nil); err == nil {
+			if svc, ok := obj.(*corev1.Service); ok {
+				if svc.Namespace == "" {
+					svc.Namespace = ns
+				}
+				services = append(services, svc)
+			}
+			continue
+		}
+		docConfigs, _, err := crd.ParseInputs(doc)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, c := range docConfigs {
+			if c.Namespace == "" {
+				c.Namespace = ns
+			}
+			configs = append(configs, c)
+		}
+	}
+	return configs, services, nil
+}
+
+// overlayConfigs returns the configs in base, replacing those with the kind, name and namespace of a config in
+// overlay, and the other configs in overlay.
+func overlayConfigs(base, overlay []config.Config) []config.Config {
+	key := func(c config.Config) string {
+		return fmt.Sprintf("%v/%s/%s", c.GroupVersionKind, c.Namespace, c.Name)
+	}
+	replaced := make(map[string]bool, len(overlay))
+	for _, c := range overlay {
+		replaced[key(c)] = true
+	}
+	out := make([]config.Config, 0, len(base)+len(overlay))
+	for _, c := range base {
+		if !replaced[key(c)] {
+			out = append(out, c)
+		}
+	}
+	return append(out, overlay...)
+}
+
+// proxyForPod returns the proxy of a pod, a gateway for the gateway mode or else a sidecar.
+func proxyForPod(pod *corev1.Pod, mode simulation.CallMode) *model.Proxy {
+	proxy := &model.Proxy{
+		ID:              pod.Name + "." + pod.Namespace,
+		ConfigNamespace: pod.Namespace,
+		Metadata:        &model.NodeMetadata{Labels: pod.Labels, Namespace: pod.Namespace},
+	}
+	if pod.Status.PodIP != "" {
+		proxy.IPAddresses = []string{pod.Status.PodIP}
+	}
+	if mode == simulation.CallModeGateway {
+		proxy.Type = model.Router
+	}
+	return proxy
+}
+
+func printSimulation(writer io.Writer, sim *simulation.Simulation, call simulation.Call) {
+	result := sim.Run(call)
+	w := new(tabwriter.Writer).Init(writer, 0, 8, 1, ' ', 0)
+	for _, kv := range [][]string{
+		{"LISTENER", result.ListenerMatched},
+		{"FILTER CHAIN", result.FilterChainMatched},
+		{"ROUTE CONFIG", result.RouteConfigMatched},
+		{"VIRTUAL HOST", result.VirtualHostMatched},
+		{"ROUTE", result.RouteMatched},
+		{"CLUSTER", result.ClusterMatched},
+	} {
+		if kv[1] == "" {
+			continue
+		}
+		_, _ = fmt.Fprintf(w, "%s:\t%s\n", kv[0], kv[1])
+	}
+	_ = w.Flush()
+	if result.Error != nil {
+		_, _ = fmt.Fprintf(writer, "\nNo match: %v\n", result.Error)
+		if reason := sim.Explain(call, result); reason != "" {
+			_, _ = fmt.Fprintln(writer, reason)
+		}
+	}
+}
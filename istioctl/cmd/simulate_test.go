@@ -0,0 +1,101 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestSimulate(t *testing.T) {
+	configDump := "-f testdata/simulate/config_dump.json"
+	istioConfig := "--istio-config testdata/simulate/reviews.yaml"
+	cases := []execTestCase{
+		{ // pod or file is required
+			args:           strings.Split("x simulate --port 9080", " "),
+			expectedString: "simulate requires <pod-name>[.<pod-namespace>], --file or --istio-config parameter",
+			wantException:  true,
+		},
+		{
+			args:           strings.Split("x simulate "+configDump+" "+istioConfig+" --port 9080", " "),
+			expectedString: "--file cannot be used with a pod, --istio-config or --cluster-config",
+			wantException:  true,
+		},
+		{ // port is required
+			args:           strings.Split("x simulate "+configDump, " "),
+			expectedString: "simulate requires --port",
+			wantException:  true,
+		},
+		{
+			args:           strings.Split("x simulate "+configDump+" --port 9080 --protocol udp", " "),
+			expectedString: `protocol "udp" not supported`,
+			wantException:  true,
+		},
+		{
+			args:           strings.Split("x simulate "+configDump+" --port 9080 --header end-user", " "),
+			expectedString: `invalid header "end-user", expected key=value`,
+			wantException:  true,
+		},
+		{ // header match selects the v2 route
+			args: strings.Split("x simulate "+configDump+" --port 9080 --host reviews:9080 --header end-user=jason", " "),
+			expectedOutput: `LISTENER:     0.0.0.0_9080
+ROUTE CONFIG: 9080
+VIRTUAL HOST: reviews.default.svc.cluster.local:9080
+ROUTE:        jason
+CLUSTER:      outbound|9080|v2|reviews.default.svc.cluster.local
+`,
+		},
+		{ // path match selects the v1 route
+			args:           strings.Split("x simulate "+configDump+" --port 9080 --host reviews --path /reviews/1", " "),
+			expectedString: "CLUSTER:      outbound|9080|v1|reviews.default.svc.cluster.local",
+		},
+		{ // no route matches
+			args:           strings.Split("x simulate "+configDump+" --port 9080 --host reviews --path /ratings", " "),
+			expectedString: `no route in virtual host "reviews.default.svc.cluster.local:9080" matches`,
+		},
+		{ // no virtual host matches
+			args:           strings.Split("x simulate "+configDump+" --port 9080 --host ratings", " "),
+			expectedString: `no virtual host matches host "ratings"`,
+		},
+		{ // tcp
+			args:           strings.Split("x simulate "+configDump+" --port 3306 --address 10.0.0.10 --protocol tcp", " "),
+			expectedString: "CLUSTER:  outbound|3306||mysql.default.svc.cluster.local",
+		},
+		{ // configuration generated from the Istio config, header match selects the v2 subset
+			args:           strings.Split("x simulate "+istioConfig+" --port 9080 --host reviews:9080 --header end-user=jason", " "),
+			expectedString: "CLUSTER:      outbound|9080|v2|reviews.default.svc.cluster.local",
+		},
+		{ // configuration generated from the Istio config, default route
+			args:           strings.Split("x simulate "+istioConfig+" --port 9080 --host reviews:9080", " "),
+			expectedString: "CLUSTER:      outbound|9080|v1|reviews.default.svc.cluster.local",
+		},
+		{ // the gateway the configuration is generated for must be selected
+			args:           strings.Split("x simulate "+istioConfig+" --port 80 --mode gateway", " "),
+			expectedString: "the gateway mode requires a gateway pod or --labels selecting the Gateways",
+			wantException:  true,
+		},
+		{ // no listener
+			args:           strings.Split("x simulate "+configDump+" --port 8080", " "),
+			expectedString: "No match: no listener matched",
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(fmt.Sprintf("case %d %s", i, strings.Join(c.args, " ")), func(t *testing.T) {
+			verifyExecTestOutput(t, c)
+		})
+	}
+}
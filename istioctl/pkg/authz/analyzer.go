@@ -46,6 +46,39 @@ func NewAnalyzer(envoyConfig *configdump.Wrapper) (*Analyzer, error) {
 
 // Print print sthe analyze results.
 func (a *Analyzer) Print(writer io.Writer) {
+	listeners, err := a.listeners()
+	if err != nil {
+		return
+	}
+	Print(writer, listeners)
+}
+
+// Filters returns the RBAC filters that apply to the requests received on the port, in the order of the filter
+// chain: the network filters, then the HTTP filters. The filters of the first filter chain with RBAC filters are
+// returned when none matches the port, as the authorization policies of a workload apply to all its ports.
+func (a *Analyzer) Filters(port uint32) ([]Filter, error) {
+	listeners, err := a.listeners()
+	if err != nil {
+		return nil, err
+	}
+	var filters []Filter
+	for _, parsed := range parse(listeners) {
+		for _, fc := range parsed.filterChains {
+			if len(fc.rbacTCP) == 0 && len(fc.rbacHTTP) == 0 {
+				continue
+			}
+			if port != 0 && fc.destinationPort == port {
+				return fc.filters(), nil
+			}
+			if filters == nil {
+				filters = fc.filters()
+			}
+		}
+	}
+	return filters, nil
+}
+
+func (a *Analyzer) listeners() ([]*listener.Listener, error) {
 	var listeners []*listener.Listener
 	for _, l := range a.listenerDump.DynamicListeners {
 		listenerTyped := &listener.Listener{}
@@ -53,9 +86,9 @@ func (a *Analyzer) Print(writer io.Writer) {
 		l.ActiveState.Listener.TypeUrl = v3.ListenerType
 		err := ptypes.UnmarshalAny(l.ActiveState.Listener, listenerTyped)
 		if err != nil {
-			return
+			return nil, fmt.Errorf("failed to parse listener %s: %v", l.Name, err)
 		}
 		listeners = append(listeners, listenerTyped)
 	}
-	Print(writer, listeners)
+	return listeners, nil
 }
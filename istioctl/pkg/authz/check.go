@@ -0,0 +1,570 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authz
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	corepb "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	rbacpb "github.com/envoyproxy/go-control-plane/envoy/config/rbac/v3"
+	routepb "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	rbac_tcp_filter "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/rbac/v3"
+	matcherpb "github.com/envoyproxy/go-control-plane/envoy/type/matcher/v3"
+
+	sm "istio.io/istio/pilot/pkg/security/model"
+	"istio.io/istio/pkg/spiffe"
+)
+
+const (
+	// The prefix of the rules generated for the CUSTOM action, see builder/extauthz.go.
+	extAuthzMatchPrefix = "istio-ext-authz"
+
+	attrSrcPrincipal     = "source.principal"
+	attrRequestPrincipal = "request.auth.principal"
+	attrRequestAudiences = "request.auth.audiences"
+	attrRequestPresenter = "request.auth.presenter"
+	attrRequestClaims    = "request.auth.claims"
+)
+
+// Request is the attributes of a request checked against the authorization policies of a workload.
+type Request struct {
+	// SourcePrincipal is the identity of the peer, like cluster.local/ns/default/sa/sleep. Empty for
+	// plain text requests.
+	SourcePrincipal string
+	// SourceIP is the address of the peer, and RemoteIP the original client address. RemoteIP defaults to
+	// SourceIP.
+	SourceIP string
+	RemoteIP string
+
+	DestinationIP   string
+	DestinationPort uint32
+	SNI             string
+
+	Method  string
+	Host    string
+	Path    string
+	Headers http.Header
+
+	// Claims are the claims of the JWT of the request. The iss and sub claims make the request principal,
+	// the aud claim its audiences and the azp claim its presenter. Nested claims are keyed by their path
+	// like in the policies, e.g. a[b].
+	Claims map[string][]string
+}
+
+// RuleResult is the result of checking a request against a rule of an authorization policy.
+type RuleResult struct {
+	// Action is ALLOW, DENY, AUDIT or CUSTOM.
+	Action string
+	// DryRun is true if the policy is annotated as dry-run, its result is then not enforced.
+	DryRun bool
+	// Policy is the policy in the format name.namespace, and Rule the index of the rule in the policy.
+	Policy  string
+	Rule    string
+	Matched bool
+	// Reason explains why the rule matched or not.
+	Reason string
+}
+
+// Filter is an RBAC HTTP filter or an RBAC network filter.
+type Filter interface {
+	GetRules() *rbacpb.RBAC
+	GetShadowRules() *rbacpb.RBAC
+}
+
+// Decision is the result of checking a request against the authorization policies of a workload.
+type Decision struct {
+	Allowed bool
+	// Reason explains the decision, e.g. the policy that denied the request.
+	Reason string
+	// Rules are the results of all the rules, in the order Envoy evaluates them.
+	Rules []RuleResult
+}
+
+// Check evaluates the request against the RBAC filters generated from the authorization policies, in the order of
+// the filter chain, the same way Envoy would. The RBAC network filters only see the connection of the request, so
+// their rules on HTTP attributes never match. The CUSTOM action is delegated to an external authorizer and is not
+// enforced by Check, only its rules are reported.
+func Check(filters []Filter, req *Request) *Decision {
+	d := &Decision{Allowed: true, Reason: "no authorization policy applies to the request"}
+	decided := false
+	var allowedBy []string
+	// The shadow rules of the dry-run ALLOW policies include the enforced ALLOW policies, which are only reported once.
+	enforced := map[string]bool{}
+	for _, filter := range filters {
+		for name := range filter.GetRules().GetPolicies() {
+			enforced[name] = true
+		}
+	}
+	for _, filter := range filters {
+		r := req
+		if _, ok := filter.(*rbac_tcp_filter.RBAC); ok {
+			r = req.connection()
+		}
+		if rules := filter.GetRules(); rules != nil {
+			matched := checkRules(d, rules, r, false, nil)
+			switch rules.GetAction() {
+			case rbacpb.RBAC_DENY:
+				if len(matched) > 0 && !decided {
+					d.Allowed, d.Reason, decided = false, fmt.Sprintf("denied by DENY policy %s", matched[0]), true
+				}
+			case rbacpb.RBAC_ALLOW:
+				if len(matched) == 0 && !decided {
+					d.Allowed, d.Reason, decided = false, "denied as no ALLOW policy matches the request", true
+				}
+				allowedBy = append(allowedBy, matched...)
+			}
+		}
+		if shadowRules := filter.GetShadowRules(); shadowRules != nil {
+			checkRules(d, shadowRules, r, true, enforced)
+		}
+	}
+	if !decided && len(allowedBy) > 0 {
+		d.Reason = fmt.Sprintf("allowed by ALLOW policy %s", allowedBy[0])
+	} else if !decided && len(d.Rules) > 0 {
+		d.Reason = "allowed as no policy denies the request"
+	}
+	return d
+}
+
+// checkRules appends the result of each policy of the rules, except the skipped ones, to the decision, and returns
+// the matched ones in the order Envoy evaluates them.
+func checkRules(d *Decision, rules *rbacpb.RBAC, req *Request, shadow bool, skip map[string]bool) []string {
+	names := make([]string, 0, len(rules.GetPolicies()))
+	for name := range rules.GetPolicies() {
+		if !skip[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var matched []string
+	for _, name := range names {
+		policy, rule := extractName(name)
+		result := RuleResult{
+			Action: actionName(rules.GetAction()),
+			DryRun: shadow,
+			Policy: policy,
+			Rule:   rule,
+		}
+		if strings.HasPrefix(name, extAuthzMatchPrefix) {
+			result.Action, result.DryRun = "CUSTOM", false
+		}
+		result.Matched, result.Reason = matchPolicy(rules.GetPolicies()[name], req)
+		if result.Matched {
+			matched = append(matched, fmt.Sprintf("%s rule %s", policy, rule))
+		}
+		d.Rules = append(d.Rules, result)
+	}
+	return matched
+}
+
+func actionName(action rbacpb.RBAC_Action) string {
+	if action == rbacpb.RBAC_LOG {
+		return "AUDIT"
+	}
+	return action.String()
+}
+
+// Print prints the decision and the result of each rule.
+func (d *Decision) Print(writer io.Writer) {
+	w := new(tabwriter.Writer).Init(writer, 0, 8, 3, ' ', 0)
+	_, _ = fmt.Fprintln(w, "ACTION\tAuthorizationPolicy\tRULE\tMATCHED\tREASON")
+	for _, r := range d.Rules {
+		action := r.Action
+		if r.DryRun {
+			action += dryRunSuffix
+		}
+		matched := "no"
+		if r.Matched {
+			matched = "yes"
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", action, r.Policy, r.Rule, matched, r.Reason)
+	}
+	_ = w.Flush()
+	decision := "ALLOWED"
+	if !d.Allowed {
+		decision = "DENIED"
+	}
+	_, _ = fmt.Fprintf(writer, "\nThe request is %s: %s.\n", decision, d.Reason)
+}
+
+// matchPolicy returns true if any of the permissions and any of the principals of the policy match the request,
+// with the reason.
+func matchPolicy(policy *rbacpb.Policy, req *Request) (bool, string) {
+	if policy.GetCondition() != nil || policy.GetCheckedCondition() != nil {
+		return false, "conditions are not supported"
+	}
+	var reasons []string
+	permissionMatched, permissionReason := false, ""
+	for _, p := range policy.GetPermissions() {
+		matched, reason := matchPermission(p, req)
+		if matched {
+			permissionMatched, permissionReason = true, reason
+			break
+		}
+		reasons = append(reasons, reason)
+	}
+	if !permissionMatched {
+		return false, strings.Join(reasons, "; ")
+	}
+	reasons = nil
+	for _, p := range policy.GetPrincipals() {
+		matched, reason := matchPrincipal(p, req)
+		if matched {
+			return true, permissionReason + ", " + reason
+		}
+		reasons = append(reasons, reason)
+	}
+	return false, strings.Join(reasons, "; ")
+}
+
+func matchPermission(p *rbacpb.Permission, req *Request) (bool, string) {
+	switch rule := p.GetRule().(type) {
+	case *rbacpb.Permission_Any:
+		return rule.Any, "any request"
+	case *rbacpb.Permission_AndRules:
+		return matchAll(len(rule.AndRules.GetRules()), func(i int) (bool, string) {
+			return matchPermission(rule.AndRules.GetRules()[i], req)
+		})
+	case *rbacpb.Permission_OrRules:
+		return matchAny(len(rule.OrRules.GetRules()), func(i int) (bool, string) {
+			return matchPermission(rule.OrRules.GetRules()[i], req)
+		})
+	case *rbacpb.Permission_NotRule:
+		matched, reason := matchPermission(rule.NotRule, req)
+		return !matched, negate(matched, reason)
+	case *rbacpb.Permission_Header:
+		return matchHeader(rule.Header, req)
+	case *rbacpb.Permission_UrlPath:
+		path := req.Path
+		if i := strings.IndexAny(path, "?#"); i >= 0 {
+			path = path[:i]
+		}
+		return matchString("path", path, true, rule.UrlPath.GetPath())
+	case *rbacpb.Permission_DestinationIp:
+		return matchCIDR("destination.ip", req.DestinationIP, rule.DestinationIp)
+	case *rbacpb.Permission_DestinationPort:
+		if req.DestinationPort == rule.DestinationPort {
+			return true, fmt.Sprintf("destination.port %d is %d", req.DestinationPort, rule.DestinationPort)
+		}
+		return false, fmt.Sprintf("destination.port %d is not %d", req.DestinationPort, rule.DestinationPort)
+	case *rbacpb.Permission_RequestedServerName:
+		return matchString("connection.sni", req.SNI, req.SNI != "", rule.RequestedServerName)
+	case *rbacpb.Permission_Metadata:
+		return matchMetadata(rule.Metadata, req)
+	default:
+		return false, fmt.Sprintf("unsupported permission %T", rule)
+	}
+}
+
+func matchPrincipal(p *rbacpb.Principal, req *Request) (bool, string) {
+	switch id := p.GetIdentifier().(type) {
+	case *rbacpb.Principal_Any:
+		return id.Any, "any source"
+	case *rbacpb.Principal_AndIds:
+		return matchAll(len(id.AndIds.GetIds()), func(i int) (bool, string) {
+			return matchPrincipal(id.AndIds.GetIds()[i], req)
+		})
+	case *rbacpb.Principal_OrIds:
+		return matchAny(len(id.OrIds.GetIds()), func(i int) (bool, string) {
+			return matchPrincipal(id.OrIds.GetIds()[i], req)
+		})
+	case *rbacpb.Principal_NotId:
+		matched, reason := matchPrincipal(id.NotId, req)
+		return !matched, negate(matched, reason)
+	case *rbacpb.Principal_Authenticated_:
+		if req.SourcePrincipal == "" {
+			return false, "the request is not authenticated with mTLS"
+		}
+		if id.Authenticated.GetPrincipalName() == nil {
+			return true, "the request is authenticated with mTLS"
+		}
+		return matchString("source principal", spiffe.URIPrefix+req.SourcePrincipal, true, id.Authenticated.GetPrincipalName())
+	case *rbacpb.Principal_SourceIp:
+		return matchCIDR("source.ip", req.SourceIP, id.SourceIp)
+	case *rbacpb.Principal_DirectRemoteIp:
+		return matchCIDR("source.ip", req.SourceIP, id.DirectRemoteIp)
+	case *rbacpb.Principal_RemoteIp:
+		remoteIP := req.RemoteIP
+		if remoteIP == "" {
+			remoteIP = req.SourceIP
+		}
+		return matchCIDR("remote.ip", remoteIP, id.RemoteIp)
+	case *rbacpb.Principal_Header:
+		return matchHeader(id.Header, req)
+	case *rbacpb.Principal_UrlPath:
+		return matchString("path", req.Path, true, id.UrlPath.GetPath())
+	case *rbacpb.Principal_Metadata:
+		return matchMetadata(id.Metadata, req)
+	default:
+		return false, fmt.Sprintf("unsupported principal %T", id)
+	}
+}
+
+// matchAll returns true if all the n matchers match, with the reason of the first one that does not.
+func matchAll(n int, match func(i int) (bool, string)) (bool, string) {
+	var reasons []string
+	for i := 0; i < n; i++ {
+		matched, reason := match(i)
+		if !matched {
+			return false, reason
+		}
+		reasons = append(reasons, reason)
+	}
+	return true, strings.Join(reasons, ", ")
+}
+
+// matchAny returns true if any of the n matchers match, with its reason, or the reasons of all of them.
+func matchAny(n int, match func(i int) (bool, string)) (bool, string) {
+	var reasons []string
+	for i := 0; i < n; i++ {
+		matched, reason := match(i)
+		if matched {
+			return true, reason
+		}
+		reasons = append(reasons, reason)
+	}
+	if len(reasons) == 1 {
+		return false, reasons[0]
+	}
+	return false, "none of: " + strings.Join(reasons, ", ")
+}
+
+func negate(matched bool, reason string) string {
+	if matched {
+		return "excluded as " + reason
+	}
+	return reason
+}
+
+func matchHeader(h *routepb.HeaderMatcher, req *Request) (bool, string) {
+	value, present := req.header(h.GetName())
+	name := fmt.Sprintf("header %s", h.GetName())
+	var matched bool
+	var reason string
+	switch hm := h.GetHeaderMatchSpecifier().(type) {
+	case *routepb.HeaderMatcher_ExactMatch:
+		matched, reason = stringResult(name, value, present, present && value == hm.ExactMatch, "exact", hm.ExactMatch)
+	case *routepb.HeaderMatcher_PrefixMatch:
+		matched, reason = stringResult(name, value, present,
+			present && strings.HasPrefix(value, hm.PrefixMatch), "prefix", hm.PrefixMatch)
+	case *routepb.HeaderMatcher_SuffixMatch:
+		matched, reason = stringResult(name, value, present,
+			present && strings.HasSuffix(value, hm.SuffixMatch), "suffix", hm.SuffixMatch)
+	case *routepb.HeaderMatcher_ContainsMatch:
+		matched, reason = stringResult(name, value, present,
+			present && strings.Contains(value, hm.ContainsMatch), "contains", hm.ContainsMatch)
+	case *routepb.HeaderMatcher_SafeRegexMatch:
+		regex := hm.SafeRegexMatch.GetRegex()
+		matched, reason = stringResult(name, value, present, present && fullMatch(regex, value), "regex", regex)
+	case *routepb.HeaderMatcher_PresentMatch:
+		matched = present == hm.PresentMatch
+		if present {
+			reason = fmt.Sprintf("%s is present", name)
+		} else {
+			reason = fmt.Sprintf("%s is not present", name)
+		}
+	case nil:
+		matched = present
+		if present {
+			reason = fmt.Sprintf("%s is present", name)
+		} else {
+			reason = fmt.Sprintf("%s is not present", name)
+		}
+	default:
+		return false, fmt.Sprintf("unsupported header matcher %T", hm)
+	}
+	if h.GetInvertMatch() {
+		return !matched, negate(matched, reason)
+	}
+	return matched, reason
+}
+
+// connection returns the attributes of the connection of the request, which are all the RBAC network filters see.
+func (req *Request) connection() *Request {
+	return &Request{
+		SourcePrincipal: req.SourcePrincipal,
+		SourceIP:        req.SourceIP,
+		RemoteIP:        req.RemoteIP,
+		DestinationIP:   req.DestinationIP,
+		DestinationPort: req.DestinationPort,
+		SNI:             req.SNI,
+	}
+}
+
+// header returns the value of the header as Envoy sees it, with the HTTP/2 pseudo headers.
+func (req *Request) header(name string) (string, bool) {
+	switch strings.ToLower(name) {
+	case ":method":
+		return req.Method, req.Method != ""
+	case ":authority", "host":
+		return req.Host, req.Host != ""
+	case ":path":
+		return req.Path, req.Path != ""
+	}
+	v, ok := req.Headers[http.CanonicalHeaderKey(name)]
+	if !ok || len(v) == 0 {
+		return "", false
+	}
+	return strings.Join(v, ","), true
+}
+
+func matchString(name, value string, present bool, m *matcherpb.StringMatcher) (bool, string) {
+	if m == nil {
+		return present, fmt.Sprintf("%s is set", name)
+	}
+	fold := func(s string) string {
+		if m.GetIgnoreCase() {
+			return strings.ToLower(s)
+		}
+		return s
+	}
+	v := fold(value)
+	switch p := m.GetMatchPattern().(type) {
+	case *matcherpb.StringMatcher_Exact:
+		return stringResult(name, value, present, present && v == fold(p.Exact), "exact", p.Exact)
+	case *matcherpb.StringMatcher_Prefix:
+		return stringResult(name, value, present, present && strings.HasPrefix(v, fold(p.Prefix)), "prefix", p.Prefix)
+	case *matcherpb.StringMatcher_Suffix:
+		return stringResult(name, value, present, present && strings.HasSuffix(v, fold(p.Suffix)), "suffix", p.Suffix)
+	case *matcherpb.StringMatcher_Contains:
+		return stringResult(name, value, present, present && strings.Contains(v, fold(p.Contains)), "contains", p.Contains)
+	case *matcherpb.StringMatcher_SafeRegex:
+		regex := p.SafeRegex.GetRegex()
+		return stringResult(name, value, present, present && fullMatch(regex, value), "regex", regex)
+	default:
+		return false, fmt.Sprintf("unsupported string matcher %T", p)
+	}
+}
+
+func stringResult(name, value string, present, matched bool, kind, pattern string) (bool, string) {
+	switch {
+	case !present:
+		return false, fmt.Sprintf("%s is not set, expected %s %q", name, kind, pattern)
+	case matched:
+		return true, fmt.Sprintf("%s %q matches %s %q", name, value, kind, pattern)
+	default:
+		return false, fmt.Sprintf("%s %q does not match %s %q", name, value, kind, pattern)
+	}
+}
+
+// fullMatch mirrors Envoy's RE2 semantics, where the regex must match the entire value.
+func fullMatch(regex, value string) bool {
+	r, err := regexp.Compile("^(?:" + regex + ")$")
+	return err == nil && r.MatchString(value)
+}
+
+func matchCIDR(name, ip string, cidr *corepb.CidrRange) (bool, string) {
+	want := fmt.Sprintf("%s/%d", cidr.GetAddressPrefix(), cidr.GetPrefixLen().GetValue())
+	if ip == "" {
+		return false, fmt.Sprintf("%s is not set, expected %s", name, want)
+	}
+	_, network, err := net.ParseCIDR(want)
+	if err != nil {
+		return false, fmt.Sprintf("invalid CIDR %s", want)
+	}
+	if network.Contains(net.ParseIP(ip)) {
+		return true, fmt.Sprintf("%s %s is in %s", name, ip, want)
+	}
+	return false, fmt.Sprintf("%s %s is not in %s", name, ip, want)
+}
+
+// matchMetadata matches the dynamic metadata set by the Istio authn filter from the peer and JWT authentication.
+// The metadata of other filters is never present.
+func matchMetadata(m *matcherpb.MetadataMatcher, req *Request) (bool, string) {
+	var keys []string
+	for _, segment := range m.GetPath() {
+		keys = append(keys, segment.GetKey())
+	}
+	name := m.GetFilter()
+	for _, key := range keys {
+		name += "[" + key + "]"
+	}
+	var values []string
+	if m.GetFilter() == sm.AuthnFilterName && len(keys) > 0 {
+		// Displayed like the attributes of the policy, e.g. request.auth.claims[iss].
+		name = keys[0]
+		for _, key := range keys[1:] {
+			name += "[" + key + "]"
+		}
+		values = req.authnMetadata(keys)
+	}
+
+	value := m.GetValue()
+	if list := value.GetListMatch(); list != nil {
+		value = list.GetOneOf()
+	}
+	if value.GetPresentMatch() {
+		if len(values) > 0 {
+			return true, fmt.Sprintf("%s is set", name)
+		}
+		return false, fmt.Sprintf("%s is not set", name)
+	}
+	if value.GetStringMatch() == nil {
+		return false, fmt.Sprintf("unsupported metadata matcher for %s", name)
+	}
+	if len(values) == 0 {
+		return matchString(name, "", false, value.GetStringMatch())
+	}
+	var reason string
+	for _, v := range values {
+		var matched bool
+		if matched, reason = matchString(name, v, true, value.GetStringMatch()); matched {
+			return true, reason
+		}
+	}
+	return false, reason
+}
+
+// authnMetadata returns the values of the metadata the Istio authn filter sets for the request.
+func (req *Request) authnMetadata(keys []string) []string {
+	claim := func(name string) []string {
+		return req.Claims[name]
+	}
+	switch keys[0] {
+	case attrSrcPrincipal:
+		if req.SourcePrincipal == "" {
+			return nil
+		}
+		return []string{req.SourcePrincipal}
+	case attrRequestPrincipal:
+		iss, sub := claim("iss"), claim("sub")
+		if len(iss) == 0 || len(sub) == 0 {
+			return nil
+		}
+		return []string{iss[0] + "/" + sub[0]}
+	case attrRequestAudiences:
+		return claim("aud")
+	case attrRequestPresenter:
+		return claim("azp")
+	case attrRequestClaims:
+		if len(keys) < 2 {
+			return nil
+		}
+		name := keys[1]
+		for _, key := range keys[2:] {
+			name += "[" + key + "]"
+		}
+		return claim(name)
+	}
+	return nil
+}
@@ -0,0 +1,325 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authz
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	rbac_http_filter "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/rbac/v3"
+	hcm_filter "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	rbac_tcp_filter "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/rbac/v3"
+
+	"istio.io/istio/pkg/util/protomarshal"
+)
+
+const policies = `
+apiVersion: security.istio.io/v1beta1
+kind: AuthorizationPolicy
+metadata:
+  name: deny-admin
+  namespace: foo
+spec:
+  selector:
+    matchLabels:
+      app: httpbin
+  action: DENY
+  rules:
+  - from:
+    - source:
+        notPrincipals: ["cluster.local/ns/foo/sa/admin"]
+    to:
+    - operation:
+        paths: ["/admin*"]
+---
+apiVersion: security.istio.io/v1beta1
+kind: AuthorizationPolicy
+metadata:
+  name: allow-get
+  namespace: foo
+spec:
+  selector:
+    matchLabels:
+      app: httpbin
+  rules:
+  - from:
+    - source:
+        principals: ["cluster.local/ns/default/sa/sleep"]
+    to:
+    - operation:
+        methods: ["GET"]
+---
+apiVersion: security.istio.io/v1beta1
+kind: AuthorizationPolicy
+metadata:
+  name: allow-jwt
+  namespace: foo
+spec:
+  selector:
+    matchLabels:
+      app: httpbin
+  rules:
+  - when:
+    - key: request.auth.claims[groups]
+      values: ["admin"]
+---
+apiVersion: security.istio.io/v1beta1
+kind: AuthorizationPolicy
+metadata:
+  name: deny-post
+  namespace: foo
+  annotations:
+    istio.io/dry-run: "true"
+spec:
+  selector:
+    matchLabels:
+      app: httpbin
+  action: DENY
+  rules:
+  - to:
+    - operation:
+        methods: ["POST"]
+`
+
+func TestCheck(t *testing.T) {
+	configs, err := ParsePolicies(policies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sleep := "cluster.local/ns/default/sa/sleep"
+	cases := []struct {
+		name    string
+		labels  map[string]string
+		req     Request
+		allowed bool
+		reason  string
+		// The result of a rule, in the format policy/rule/matched, and a substring of its reason.
+		rule       string
+		ruleReason string
+	}{
+		{
+			name:    "allowed by principal and method",
+			req:     Request{SourcePrincipal: sleep, Method: "GET", Path: "/ip"},
+			allowed: true,
+			reason:  "allowed by ALLOW policy allow-get.foo rule 0",
+		},
+		{
+			name:       "denied by path",
+			req:        Request{SourcePrincipal: sleep, Method: "GET", Path: "/admin/users?all=true"},
+			allowed:    false,
+			reason:     "denied by DENY policy deny-admin.foo rule 0",
+			rule:       "deny-admin.foo/0/true",
+			ruleReason: `path "/admin/users" matches prefix "/admin"`,
+		},
+		{
+			name:       "denied without matching allow policy",
+			req:        Request{SourcePrincipal: sleep, Method: "POST", Path: "/post"},
+			allowed:    false,
+			reason:     "denied as no ALLOW policy matches the request",
+			rule:       "allow-get.foo/0/false",
+			ruleReason: `header :method "POST" does not match exact "GET"`,
+		},
+		{
+			name: "allowed by JWT claim",
+			req: Request{Method: "POST", Path: "/post", Headers: http.Header{},
+				Claims: map[string][]string{"groups": {"dev", "admin"}}},
+			allowed:    true,
+			reason:     "allowed by ALLOW policy allow-jwt.foo rule 0",
+			rule:       "deny-post.foo/0/true",
+			ruleReason: `header :method "POST" matches exact "POST"`,
+		},
+		{
+			name:       "unauthenticated",
+			req:        Request{Method: "GET", Path: "/ip"},
+			allowed:    false,
+			reason:     "denied as no ALLOW policy matches the request",
+			rule:       "allow-get.foo/0/false",
+			ruleReason: "source.principal is not set",
+		},
+		{
+			name:    "no policy",
+			labels:  map[string]string{"app": "productpage"},
+			req:     Request{Method: "GET", Path: "/"},
+			allowed: true,
+			reason:  "no authorization policy applies to the request",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			labels := tc.labels
+			if labels == nil {
+				labels = map[string]string{"app": "httpbin"}
+			}
+			filters, err := GenerateFilters(configs, "istio-system", "foo", labels, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			d := Check(filters, &tc.req)
+			if d.Allowed != tc.allowed || d.Reason != tc.reason {
+				t.Errorf("got decision %v: %s, want %v: %s", d.Allowed, d.Reason, tc.allowed, tc.reason)
+			}
+			if tc.rule != "" {
+				found := false
+				for _, r := range d.Rules {
+					if strings.Join([]string{r.Policy, r.Rule, boolString(r.Matched)}, "/") == tc.rule {
+						found = true
+						if !strings.Contains(r.Reason, tc.ruleReason) {
+							t.Errorf("got reason %q for rule %s, want it to contain %q", r.Reason, tc.rule, tc.ruleReason)
+						}
+					}
+				}
+				if !found {
+					t.Errorf("rule %s not found in %+v", tc.rule, d.Rules)
+				}
+			}
+			var out bytes.Buffer
+			d.Print(&out)
+			if !strings.Contains(out.String(), tc.reason) {
+				t.Errorf("expected the output to contain the reason %q, got:\n%s", tc.reason, out.String())
+			}
+		})
+	}
+}
+
+// TestCheckBuilderGoldens checks requests against the filters of the golden files of the authz builder.
+func TestCheckBuilderGoldens(t *testing.T) {
+	const goldens = "../../../pilot/pkg/security/authz/builder/testdata/"
+	sleep := "cluster.local/ns/ns-1/sa/sleep"
+	cases := []struct {
+		goldens []string
+		req     Request
+		allowed bool
+		reason  string
+	}{
+		{
+			goldens: []string{"allow-all-out.yaml"},
+			req:     Request{Method: "GET", Path: "/"},
+			allowed: true,
+			reason:  "allowed by ALLOW policy allow-all.foo rule 0",
+		},
+		{
+			goldens: []string{"allow-none-out.yaml"},
+			req:     Request{SourcePrincipal: sleep, Method: "GET", Path: "/"},
+			allowed: false,
+			reason:  "denied as no ALLOW policy matches the request",
+		},
+		{
+			goldens: []string{"deny-all-out.yaml"},
+			req:     Request{SourcePrincipal: sleep, Method: "GET", Path: "/"},
+			allowed: false,
+			reason:  "denied by DENY policy deny-all.foo rule 0",
+		},
+		{
+			goldens: []string{"action-allow-HTTP-for-TCP-filter-out.yaml"},
+			req:     Request{SourcePrincipal: sleep, DestinationPort: 80},
+			allowed: true,
+			reason:  "allowed by ALLOW policy httpbin-deny.foo rule 1",
+		},
+		{
+			goldens: []string{"action-allow-HTTP-for-TCP-filter-out.yaml"},
+			req:     Request{SourcePrincipal: sleep, DestinationPort: 8080},
+			allowed: false,
+			reason:  "denied as no ALLOW policy matches the request",
+		},
+		{
+			goldens: []string{"action-allow-HTTP-for-TCP-filter-out.yaml"},
+			req:     Request{SourcePrincipal: "cluster.local/ns/ns-2/sa/sleep", DestinationPort: 80},
+			allowed: false,
+			reason:  "denied as no ALLOW policy matches the request",
+		},
+		{
+			// The HTTP fields of the rules are removed for TCP, so the first rule denies any connection.
+			goldens: []string{"action-deny-HTTP-for-TCP-filter-out.yaml"},
+			req:     Request{DestinationPort: 8080, Method: "POST"},
+			allowed: false,
+			reason:  "denied by DENY policy httpbin-deny.foo rule 0",
+		},
+		{
+			goldens: []string{"dry-run-deny-tcp-out.yaml", "dry-run-deny-shadow-tcp-out.yaml"},
+			req:     Request{SourceIP: "1.2.3.4"},
+			allowed: false,
+			reason:  "denied by DENY policy deny-ip.foo rule 0",
+		},
+		{
+			goldens: []string{"dry-run-deny-tcp-out.yaml", "dry-run-deny-shadow-tcp-out.yaml"},
+			req:     Request{SourceIP: "5.6.7.8"},
+			allowed: true,
+			reason:  "allowed as no policy denies the request",
+		},
+		{
+			// The enforced ALLOW policy in the shadow rules of the dry-run ALLOW policy is only reported once.
+			goldens: []string{"dry-run-allow-tcp-out.yaml", "dry-run-allow-shadow-tcp-out.yaml"},
+			req:     Request{SourceIP: "10.1.2.3"},
+			allowed: false,
+			reason:  "denied as no ALLOW policy matches the request",
+		},
+		{
+			goldens: []string{"dry-run-allow-out.yaml"},
+			req:     Request{SourceIP: "192.168.1.1", Method: "GET", Path: "/"},
+			allowed: true,
+			reason:  "allowed by ALLOW policy allow-ip.foo rule 0",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(strings.Join(tc.goldens, ","), func(t *testing.T) {
+			var filters []Filter
+			for _, golden := range tc.goldens {
+				filters = append(filters, loadGoldenFilter(t, goldens+golden))
+			}
+			d := Check(filters, &tc.req)
+			if d.Allowed != tc.allowed || d.Reason != tc.reason {
+				t.Errorf("got decision %v: %s, want %v: %s", d.Allowed, d.Reason, tc.allowed, tc.reason)
+			}
+			for _, r := range d.Rules {
+				if r.DryRun != strings.HasSuffix(r.Policy, "-dry-run.foo") {
+					t.Errorf("unexpected dry-run %v of the result of policy %s", r.DryRun, r.Policy)
+				}
+			}
+		})
+	}
+}
+
+// loadGoldenFilter returns the RBAC filter of a golden file of the authz builder.
+func loadGoldenFilter(t *testing.T, file string) Filter {
+	t.Helper()
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "envoy.filters.network.rbac") {
+		f := &listener.Filter{}
+		if err := protomarshal.ApplyYAML(string(data), f); err != nil {
+			t.Fatal(err)
+		}
+		rbac := &rbac_tcp_filter.RBAC{}
+		if err := getFilterConfig(f, rbac); err != nil {
+			t.Fatal(err)
+		}
+		return rbac
+	}
+	f := &hcm_filter.HttpFilter{}
+	if err := protomarshal.ApplyYAML(string(data), f); err != nil {
+		t.Fatal(err)
+	}
+	rbac := &rbac_http_filter.RBAC{}
+	if err := getHTTPFilterConfig(f, rbac); err != nil {
+		t.Fatal(err)
+	}
+	return rbac
+}
@@ -35,6 +35,7 @@ import (
 
 const (
 	anonymousName = "_anonymous_match_nothing_"
+	dryRunSuffix  = " (dry-run)"
 )
 
 var (
@@ -43,8 +44,21 @@ var (
 )
 
 type filterChain struct {
-	rbacHTTP []*rbac_http_filter.RBAC
-	rbacTCP  []*rbac_tcp_filter.RBAC
+	destinationPort uint32
+	rbacHTTP        []*rbac_http_filter.RBAC
+	rbacTCP         []*rbac_tcp_filter.RBAC
+}
+
+// filters returns the RBAC filters of the filter chain, in the order Envoy evaluates them.
+func (fc *filterChain) filters() []Filter {
+	out := make([]Filter, 0, len(fc.rbacTCP)+len(fc.rbacHTTP))
+	for _, f := range fc.rbacTCP {
+		out = append(out, f)
+	}
+	for _, f := range fc.rbacHTTP {
+		out = append(out, f)
+	}
+	return out
 }
 
 type parsedListener struct {
@@ -85,7 +99,7 @@ func parse(listeners []*listener.Listener) []*parsedListener {
 	for _, l := range listeners {
 		parsed := &parsedListener{}
 		for _, fc := range l.FilterChains {
-			parsedFC := &filterChain{}
+			parsedFC := &filterChain{destinationPort: fc.GetFilterChainMatch().GetDestinationPort().GetValue()}
 			for _, filter := range fc.Filters {
 				switch filter.Name {
 				case wellknown.HTTPConnectionManager, "envoy.http_connection_manager":
@@ -150,27 +164,38 @@ func Print(writer io.Writer, listeners []*listener.Listener) {
 		policyToRule[name][rule] = struct{}{}
 	}
 
+	addRules := func(rules *rbacpb.RBAC) {
+		if rules == nil {
+			return
+		}
+		for name := range rules.GetPolicies() {
+			nameOfPolicy, indexOfRule := extractName(name)
+			addPolicy(rules.GetAction(), nameOfPolicy, indexOfRule)
+		}
+		if len(rules.GetPolicies()) == 0 {
+			addPolicy(rules.GetAction(), anonymousName, "0")
+		}
+	}
+	// The shadow rules are generated from the dry-run policies, and for the CUSTOM action which is not reported.
+	addShadowRules := func(rules *rbacpb.RBAC) {
+		for name := range rules.GetPolicies() {
+			if strings.HasPrefix(name, extAuthzMatchPrefix) {
+				continue
+			}
+			nameOfPolicy, indexOfRule := extractName(name)
+			addPolicy(rules.GetAction(), nameOfPolicy+dryRunSuffix, indexOfRule)
+		}
+	}
+
 	for _, parsed := range parsedListeners {
 		for _, fc := range parsed.filterChains {
 			for _, rbacHTTP := range fc.rbacHTTP {
-				action := rbacHTTP.GetRules().GetAction()
-				for name := range rbacHTTP.GetRules().GetPolicies() {
-					nameOfPolicy, indexOfRule := extractName(name)
-					addPolicy(action, nameOfPolicy, indexOfRule)
-				}
-				if len(rbacHTTP.GetRules().GetPolicies()) == 0 {
-					addPolicy(action, anonymousName, "0")
-				}
+				addRules(rbacHTTP.GetRules())
+				addShadowRules(rbacHTTP.GetShadowRules())
 			}
 			for _, rbacTCP := range fc.rbacTCP {
-				action := rbacTCP.GetRules().GetAction()
-				for name := range rbacTCP.GetRules().GetPolicies() {
-					nameOfPolicy, indexOfRule := extractName(name)
-					addPolicy(action, nameOfPolicy, indexOfRule)
-				}
-				if len(rbacTCP.GetRules().GetPolicies()) == 0 {
-					addPolicy(action, anonymousName, "0")
-				}
+				addRules(rbacTCP.GetRules())
+				addShadowRules(rbacTCP.GetShadowRules())
 			}
 		}
 	}
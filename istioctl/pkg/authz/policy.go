@@ -0,0 +1,110 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authz
+
+import (
+	"fmt"
+
+	rbac_http_filter "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/rbac/v3"
+	rbac_tcp_filter "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/rbac/v3"
+
+	"istio.io/istio/pilot/pkg/config/kube/crd"
+	"istio.io/istio/pilot/pkg/config/memory"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/networking/plugin"
+	"istio.io/istio/pilot/pkg/security/authz/builder"
+	"istio.io/istio/pilot/pkg/security/trustdomain"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/mesh"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+// ParsePolicies returns the AuthorizationPolicy in the YAML documents, other resources are ignored.
+func ParsePolicies(yaml string) ([]config.Config, error) {
+	configs, _, err := crd.ParseInputs(yaml)
+	if err != nil {
+		return nil, err
+	}
+	gvk := collections.IstioSecurityV1Beta1Authorizationpolicies.Resource().GroupVersionKind()
+	var policies []config.Config
+	for _, c := range configs {
+		if c.GroupVersionKind == gvk {
+			policies = append(policies, c)
+		}
+	}
+	return policies, nil
+}
+
+// GenerateFilters returns the RBAC filters Istiod generates from the policies for a workload with the labels in the
+// namespace, without connecting to Istiod. The RBAC network filters of the TCP filter chains are returned if tcp is
+// true, otherwise the RBAC HTTP filters.
+func GenerateFilters(policies []config.Config, rootNamespace, namespace string, workloadLabels map[string]string,
+	tcp bool) ([]Filter, error) {
+	store := model.MakeIstioStore(memory.Make(collections.Pilot))
+	for _, p := range policies {
+		if p.Namespace == "" {
+			p.Namespace = namespace
+		}
+		if _, err := store.Create(p); err != nil {
+			return nil, fmt.Errorf("invalid AuthorizationPolicy %s.%s: %v", p.Name, p.Namespace, err)
+		}
+	}
+	meshConfig := mesh.DefaultMeshConfig()
+	meshConfig.RootNamespace = rootNamespace
+	authzPolicies, err := model.GetAuthorizationPolicies(&model.Environment{
+		IstioConfigStore: store,
+		Watcher:          mesh.NewFixedWatcher(&meshConfig),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	in := &plugin.InputParams{
+		Node: &model.Proxy{
+			ID:              "istioctl",
+			ConfigNamespace: namespace,
+			Metadata:        &model.NodeMetadata{Labels: workloadLabels},
+		},
+		Push: &model.PushContext{
+			AuthzPolicies: authzPolicies,
+			Mesh:          &meshConfig,
+		},
+	}
+	option := builder.Option{Logger: &builder.AuthzLogger{}}
+	defer option.Logger.Report(in)
+	b := builder.New(trustdomain.Bundle{}, in, option)
+	if b == nil {
+		return nil, nil
+	}
+	var filters []Filter
+	if tcp {
+		for _, f := range b.BuildTCP() {
+			rbac := &rbac_tcp_filter.RBAC{}
+			if err := getFilterConfig(f, rbac); err != nil {
+				return nil, fmt.Errorf("failed to parse generated RBAC filter: %v", err)
+			}
+			filters = append(filters, rbac)
+		}
+		return filters, nil
+	}
+	for _, f := range b.BuildHTTP() {
+		rbac := &rbac_http_filter.RBAC{}
+		if err := getHTTPFilterConfig(f, rbac); err != nil {
+			return nil, fmt.Errorf("failed to parse generated RBAC filter: %v", err)
+		}
+		filters = append(filters, rbac)
+	}
+	return filters, nil
+}
@@ -30,13 +30,15 @@ import (
 
 // Formatting options for Messages
 const (
-	LogFormat  = "log"
-	JSONFormat = "json"
-	YAMLFormat = "yaml"
+	LogFormat   = "log"
+	JSONFormat  = "json"
+	YAMLFormat  = "yaml"
+	SARIFFormat = "sarif"
+	JUnitFormat = "junit"
 )
 
 var (
-	MsgOutputFormatKeys = []string{LogFormat, JSONFormat, YAMLFormat}
+	MsgOutputFormatKeys = []string{LogFormat, JSONFormat, YAMLFormat, SARIFFormat, JUnitFormat}
 	MsgOutputFormats    = make(map[string]bool)
 	termEnvVar          = env.RegisterStringVar("TERM", "", "Specifies terminal type.  Use 'dumb' to suppress color output")
 )
@@ -56,6 +58,10 @@ func Print(ms diag.Messages, format string, colorize bool) (string, error) {
 		return printJSON(ms)
 	case YAMLFormat:
 		return printYAML(ms)
+	case SARIFFormat:
+		return printSARIF(ms)
+	case JUnitFormat:
+		return PrintJUnit(ms, nil)
 	default:
 		return "", fmt.Errorf("invalid format, expected one of %v but got %q", MsgOutputFormatKeys, format)
 	}
@@ -15,11 +15,14 @@
 package formatting
 
 import (
+	"encoding/json"
 	"testing"
 
 	. "github.com/onsi/gomega"
 
 	"istio.io/istio/galley/pkg/config/analysis/diag"
+	"istio.io/istio/galley/pkg/config/source/kube/rt"
+	"istio.io/istio/pkg/config/resource"
 	"istio.io/istio/pkg/url"
 )
 
@@ -152,3 +155,94 @@ func TestFormatter_PrintEmpty(t *testing.T) {
 	yamlOutput, _ := Print(msgs, YAMLFormat, false)
 	g.Expect(yamlOutput).To(Equal("[]\n"))
 }
+
+func TestFormatter_PrintSARIF(t *testing.T) {
+	g := NewWithT(t)
+
+	fileResource := diag.MockResource("SoapBubble")
+	fileResource.Origin = &rt.Origin{
+		Kind:     "VirtualService",
+		FullName: resource.NewFullName("default", "bubble"),
+		Ref:      &rt.Position{Filename: "config/bubble.yaml", Line: 3},
+	}
+	firstMsg := diag.NewMessage(
+		diag.NewMessageType(diag.Error, "B1", "Explosion accident: %v"),
+		fileResource,
+		"the bubble is too big",
+	)
+	firstMsg.Line = 7
+	secondMsg := diag.NewMessage(
+		diag.NewMessageType(diag.Warning, "C1", "Collapse danger: %v"),
+		diag.MockResource("GrandCastle"),
+		"the castle is too old",
+	)
+	secondMsg.DocRef = "istioctl-analyze"
+	thirdMsg := diag.NewMessage(
+		diag.NewMessageType(diag.Warning, "C1", "Collapse danger: %v"),
+		diag.MockResource("SandCastle"),
+		"the castle is too wet",
+	)
+
+	msgs := diag.Messages{firstMsg, secondMsg, thirdMsg}
+	output, err := Print(msgs, SARIFFormat, false)
+	g.Expect(err).To(BeNil())
+
+	log := sarifLog{}
+	g.Expect(json.Unmarshal([]byte(output), &log)).To(Succeed())
+	g.Expect(log.Version).To(Equal("2.1.0"))
+	g.Expect(log.Runs).To(HaveLen(1))
+	run := log.Runs[0]
+	g.Expect(run.Tool.Driver.Rules).To(Equal([]sarifRule{
+		{ID: "B1", ShortDescription: sarifMessage{Text: "Explosion accident: %v"}, HelpURI: url.ConfigAnalysis + "/b1/"},
+		{ID: "C1", ShortDescription: sarifMessage{Text: "Collapse danger: %v"}, HelpURI: url.ConfigAnalysis + "/c1/?ref=istioctl-analyze"},
+	}))
+	g.Expect(run.Results).To(HaveLen(3))
+	g.Expect(run.Results[0].RuleID).To(Equal("B1"))
+	g.Expect(run.Results[0].Level).To(Equal("error"))
+	g.Expect(run.Results[0].Message.Text).To(Equal("Explosion accident: the bubble is too big"))
+	// The line of the message takes precedence over the line of the resource
+	g.Expect(run.Results[0].Locations[0].PhysicalLocation).To(Equal(&sarifPhysicalLocation{
+		ArtifactLocation: sarifArtifactLocation{URI: "config/bubble.yaml"},
+		Region:           &sarifRegion{StartLine: 7},
+	}))
+	g.Expect(run.Results[0].Locations[0].LogicalLocations[0].FullyQualifiedName).To(Equal("VirtualService bubble.default"))
+	// Resources not read from a file only have a logical location
+	g.Expect(run.Results[1].Level).To(Equal("warning"))
+	g.Expect(run.Results[1].Locations[0].PhysicalLocation).To(BeNil())
+	g.Expect(run.Results[1].Locations[0].LogicalLocations[0].FullyQualifiedName).To(Equal("GrandCastle"))
+}
+
+func TestFormatter_PrintJUnit(t *testing.T) {
+	g := NewWithT(t)
+
+	firstMsg := diag.NewMessage(
+		diag.NewMessageType(diag.Error, "B1", "Explosion accident: %v"),
+		diag.MockResource("SoapBubble"),
+		"the bubble is too big",
+	)
+	firstMsg.Analyzer = "bubble.Analyzer"
+	secondMsg := diag.NewMessage(
+		diag.NewMessageType(diag.Warning, "C1", "Collapse danger: %v"),
+		diag.MockResource("GrandCastle"),
+		"the castle is too old",
+	)
+
+	msgs := diag.Messages{firstMsg, secondMsg}
+	output, err := PrintJUnit(msgs, []string{"bubble.Analyzer", "castle.Analyzer"})
+	g.Expect(err).To(BeNil())
+
+	expectedOutput := `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites>
+	<testsuite name="istioctl analyze" tests="3" failures="2">
+		<testcase name="C1" classname="istioctl.analyze">
+			<failure message="Warning [C1] (GrandCastle) Collapse danger: the castle is too old" type="Warning">Warning [C1] (GrandCastle) Collapse danger: the castle is too old</failure>
+		</testcase>
+		<testcase name="bubble.Analyzer" classname="istioctl.analyze">
+			<failure message="Error [B1] (SoapBubble) Explosion accident: the bubble is too big" type="Error">Error [B1] (SoapBubble) Explosion accident: the bubble is too big</failure>
+		</testcase>
+		<testcase name="castle.Analyzer" classname="istioctl.analyze"></testcase>
+	</testsuite>
+</testsuites>`
+
+	g.Expect(output).To(Equal(expectedOutput))
+}
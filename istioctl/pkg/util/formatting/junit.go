@@ -0,0 +1,93 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package formatting
+
+import (
+	"encoding/xml"
+	"sort"
+	"strings"
+
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+)
+
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// PrintJUnit prints the messages as a JUnit XML report with a test case per analyzer. The analyzers
+// which did not report any message are listed as passed test cases.
+func PrintJUnit(ms diag.Messages, analyzers []string) (string, error) {
+	byAnalyzer := map[string]diag.Messages{}
+	for _, a := range analyzers {
+		byAnalyzer[a] = nil
+	}
+	for _, m := range ms {
+		// Messages which are not attributed to an analyzer are grouped by code
+		name := m.Analyzer
+		if name == "" {
+			name = m.Type.Code()
+		}
+		byAnalyzer[name] = append(byAnalyzer[name], m)
+	}
+	names := make([]string, 0, len(byAnalyzer))
+	for name := range byAnalyzer {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	suite := junitTestSuite{Name: "istioctl analyze", Tests: len(names)}
+	for _, name := range names {
+		tc := junitTestCase{Name: name, ClassName: "istioctl.analyze"}
+		if msgs := byAnalyzer[name]; len(msgs) > 0 {
+			suite.Failures++
+			lines := make([]string, 0, len(msgs))
+			for _, m := range msgs {
+				lines = append(lines, m.String())
+			}
+			tc.Failure = &junitFailure{
+				Message: msgs[0].String(),
+				Type:    msgs[0].Type.Level().String(),
+				Text:    strings.Join(lines, "\n"),
+			}
+		}
+		suite.TestCases = append(suite.TestCases, tc)
+	}
+
+	out, err := xml.MarshalIndent(junitTestSuites{Suites: []junitTestSuite{suite}}, "", "\t")
+	if err != nil {
+		return "", err
+	}
+	return xml.Header + string(out), nil
+}
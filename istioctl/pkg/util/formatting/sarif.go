@@ -0,0 +1,176 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package formatting
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+	"istio.io/istio/galley/pkg/config/source/kube/rt"
+	"istio.io/istio/pkg/url"
+)
+
+// The subset of the SARIF 2.1.0 format (https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html)
+// used to report analysis messages to code scanning tools.
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+)
+
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+	HelpURI          string       `json:"helpUri,omitempty"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation *sarifPhysicalLocation `json:"physicalLocation,omitempty"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+type sarifLogicalLocation struct {
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+var sarifLevels = map[diag.Level]string{
+	diag.Info:    "note",
+	diag.Warning: "warning",
+	diag.Error:   "error",
+}
+
+func printSARIF(ms diag.Messages) (string, error) {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "istioctl analyze",
+			InformationURI: url.ConfigAnalysis,
+			Rules:          []sarifRule{},
+		}},
+		Results: []sarifResult{},
+	}
+	rules := map[string]bool{}
+	for _, m := range ms {
+		code := m.Type.Code()
+		if !rules[code] {
+			rules[code] = true
+			run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{
+				ID:               code,
+				ShortDescription: sarifMessage{Text: m.Type.Template()},
+				HelpURI:          sarifHelpURI(m),
+			})
+		}
+		run.Results = append(run.Results, sarifResult{
+			RuleID:    code,
+			Level:     sarifLevels[m.Type.Level()],
+			Message:   sarifMessage{Text: fmt.Sprintf(m.Type.Template(), m.Parameters...)},
+			Locations: sarifLocations(m),
+		})
+	}
+
+	out, err := json.MarshalIndent(sarifLog{
+		Version: sarifVersion,
+		Schema:  sarifSchema,
+		Runs:    []sarifRun{run},
+	}, "", "\t")
+	return string(out), err
+}
+
+// sarifHelpURI returns the documentation URL of the message type, with the documentation reference of the
+// message if any. It is empty if the message type has no code.
+func sarifHelpURI(m diag.Message) string {
+	code := m.Type.Code()
+	if code == "" {
+		return ""
+	}
+	uri := fmt.Sprintf("%s/%s/", url.ConfigAnalysis, strings.ToLower(code))
+	if m.DocRef != "" {
+		uri += "?ref=" + m.DocRef
+	}
+	return uri
+}
+
+// sarifLocations returns the file and line of the resource the message is about when it was read
+// from a file, and the name of the resource otherwise.
+func sarifLocations(m diag.Message) []sarifLocation {
+	if m.Resource == nil {
+		return nil
+	}
+	loc := sarifLocation{}
+	if pos, ok := m.Resource.Origin.Reference().(*rt.Position); ok && pos.Filename != "" && pos.Filename != "-" {
+		loc.PhysicalLocation = &sarifPhysicalLocation{
+			ArtifactLocation: sarifArtifactLocation{URI: pos.Filename},
+		}
+		// The line of the field the message is about is more precise than the one of the resource
+		line := pos.Line
+		if m.Line != 0 {
+			line = m.Line
+		}
+		if line > 0 {
+			loc.PhysicalLocation.Region = &sarifRegion{StartLine: line}
+		}
+	}
+	loc.LogicalLocations = []sarifLogicalLocation{{
+		FullyQualifiedName: m.Resource.Origin.FriendlyName(),
+		Kind:               "resource",
+	}}
+	return []sarifLocation{loc}
+}
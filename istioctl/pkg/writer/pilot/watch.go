@@ -0,0 +1,196 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pilot
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	xdsapi "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+
+	"istio.io/istio/istioctl/pkg/multixds"
+	"istio.io/istio/pilot/pkg/xds"
+	v3 "istio.io/istio/pilot/pkg/xds/v3"
+)
+
+const (
+	watchSynced = "SYNCED"
+	watchStale  = "STALE"
+	watchNacked = "NACKED"
+)
+
+// XdsSyncWatcher prints the sync status transitions of the proxies between successive sync details
+// responses of the Istiod instances, and tracks the time the proxies took to ack the pushes.
+type XdsSyncWatcher struct {
+	Writer io.Writer
+
+	headerPrinted bool
+	// last known state per proxy and type
+	last map[string]xds.TypeSyncDetails
+	// times to ack the pushes observed while watching, per type
+	timesToAck map[string][]time.Duration
+	nacks      int
+}
+
+// NewXdsSyncWatcher creates a watcher printing the transitions to the writer.
+func NewXdsSyncWatcher(w io.Writer) *XdsSyncWatcher {
+	return &XdsSyncWatcher{
+		Writer:     w,
+		last:       map[string]xds.TypeSyncDetails{},
+		timesToAck: map[string][]time.Duration{},
+	}
+}
+
+type syncTransition struct {
+	proxyID  string
+	typeURL  string
+	status   string
+	ack      time.Duration
+	istiodID string
+	detail   string
+}
+
+// Update compares the sync details returned by the Istiod instances with the previous ones, and prints
+// the transitions. At the first update, only the proxies which are not synced are printed.
+func (s *XdsSyncWatcher) Update(responses map[string]*xdsapi.DiscoveryResponse, now time.Time) error {
+	var transitions []syncTransition
+	for _, dr := range responses {
+		istiodID := multixds.CpInfo(dr).ID
+		for _, resource := range dr.Resources {
+			details, err := xds.ParseSyncDetails(resource)
+			if err != nil {
+				// A resource which cannot be parsed does not prevent watching the other proxies
+				continue
+			}
+			for _, t := range details.Types {
+				if tr, ok := s.observe(details.ProxyID, t); ok {
+					tr.istiodID = istiodID
+					transitions = append(transitions, tr)
+				}
+			}
+		}
+	}
+	if len(transitions) == 0 {
+		return nil
+	}
+
+	sort.Slice(transitions, func(i, j int) bool {
+		if transitions[i].proxyID != transitions[j].proxyID {
+			return transitions[i].proxyID < transitions[j].proxyID
+		}
+		return v3.GetShortType(transitions[i].typeURL) < v3.GetShortType(transitions[j].typeURL)
+	})
+	w := new(tabwriter.Writer).Init(s.Writer, 0, 8, 3, ' ', 0)
+	if !s.headerPrinted {
+		_, _ = fmt.Fprintln(w, "TIME\tNAME\tTYPE\tSTATUS\tTIME TO ACK\tISTIOD\tDETAIL")
+		s.headerPrinted = true
+	}
+	for _, tr := range transitions {
+		ack := ""
+		if tr.status == watchSynced && tr.ack > 0 {
+			ack = tr.ack.Round(time.Millisecond).String()
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", now.Format("15:04:05"),
+			tr.proxyID, v3.GetShortType(tr.typeURL), tr.status, ack, tr.istiodID, tr.detail)
+	}
+	return w.Flush()
+}
+
+// observe records the state of a type of a proxy and returns the transition from the previous state, if any.
+// The states are sampled at each update, so the intermediate states between two updates are not observed: if
+// several pushes are sent and acked between two updates, only the last one is reported, and only its time to
+// ack is counted in the summary. A push sent and acked between two updates is reported as synced without
+// having been reported as stale.
+func (s *XdsSyncWatcher) observe(proxyID string, t xds.TypeSyncDetails) (syncTransition, bool) {
+	key := proxyID + "/" + t.TypeURL
+	prev, seen := s.last[key]
+	s.last[key] = t
+	tr := syncTransition{proxyID: proxyID, typeURL: t.TypeURL}
+
+	switch {
+	case t.Nacked():
+		if seen && prev.NonceNacked == t.NonceNacked {
+			return tr, false
+		}
+		s.nacks++
+		tr.status, tr.detail = watchNacked, t.NackError
+	case t.Synced():
+		if !seen || prev.NonceAcked == t.NonceAcked {
+			return tr, false
+		}
+		tr.status = watchSynced
+		if ack, ok := t.TimeToAck(); ok {
+			tr.ack = ack
+			s.timesToAck[t.TypeURL] = append(s.timesToAck[t.TypeURL], ack)
+		}
+	case t.NonceSent != "":
+		if seen && prev.NonceSent == t.NonceSent {
+			return tr, false
+		}
+		tr.status = watchStale
+		if t.NonceAcked == "" {
+			tr.detail = "never acknowledged"
+		}
+	default:
+		return tr, false
+	}
+	return tr, true
+}
+
+// PrintSummary prints the number of pushes acked while watching with the p50 and p99 of the time to ack them,
+// per type and for all types.
+func (s *XdsSyncWatcher) PrintSummary() error {
+	w := new(tabwriter.Writer).Init(s.Writer, 0, 8, 3, ' ', 0)
+	_, _ = fmt.Fprintf(w, "\nConvergence summary (%d NACKs):\n", s.nacks)
+	_, _ = fmt.Fprintln(w, "TYPE\tACKS\tP50\tP99")
+	types := make([]string, 0, len(s.timesToAck))
+	var all []time.Duration
+	for typeURL, times := range s.timesToAck {
+		types = append(types, typeURL)
+		all = append(all, times...)
+	}
+	sort.Slice(types, func(i, j int) bool {
+		return v3.GetShortType(types[i]) < v3.GetShortType(types[j])
+	})
+	for _, typeURL := range types {
+		printPercentiles(w, v3.GetShortType(typeURL), s.timesToAck[typeURL])
+	}
+	printPercentiles(w, "ALL", all)
+	return w.Flush()
+}
+
+func printPercentiles(w io.Writer, name string, times []time.Duration) {
+	if len(times) == 0 {
+		_, _ = fmt.Fprintf(w, "%s\t0\t-\t-\n", name)
+		return
+	}
+	_, _ = fmt.Fprintf(w, "%s\t%d\t%s\t%s\n", name, len(times),
+		percentile(times, 0.5).Round(time.Millisecond), percentile(times, 0.99).Round(time.Millisecond))
+}
+
+// percentile returns the nearest-rank percentile of the durations.
+func percentile(times []time.Duration, p float64) time.Duration {
+	sorted := append([]time.Duration(nil), times...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	rank := int(math.Ceil(p * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
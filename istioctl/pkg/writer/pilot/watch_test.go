@@ -0,0 +1,161 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pilot
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	xdsapi "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+	"github.com/golang/protobuf/ptypes/any"
+
+	"istio.io/istio/pilot/pkg/networking/util"
+	"istio.io/istio/pilot/pkg/xds"
+	v3 "istio.io/istio/pilot/pkg/xds/v3"
+)
+
+func syncDetailsResponses(t *testing.T, details ...*xds.SyncDetails) map[string]*xdsapi.DiscoveryResponse {
+	t.Helper()
+	dr := &xdsapi.DiscoveryResponse{
+		TypeUrl:      xds.TypeDebugSyncDetails,
+		ControlPlane: &core.ControlPlane{Identifier: `{"Component":"istiod","ID":"istiod-1"}`},
+	}
+	for _, d := range details {
+		pbs, err := d.ToStruct()
+		if err != nil {
+			t.Fatal(err)
+		}
+		dr.Resources = append(dr.Resources, util.MessageToAny(pbs))
+	}
+	return map[string]*xdsapi.DiscoveryResponse{"istiod-1": dr}
+}
+
+func TestXdsSyncWatcher(t *testing.T) {
+	start := time.Date(2021, 1, 1, 10, 0, 0, 0, time.UTC)
+	out := &bytes.Buffer{}
+	w := NewXdsSyncWatcher(out)
+
+	steps := []struct {
+		name    string
+		details []*xds.SyncDetails
+		want    []string
+		lines   int
+	}{
+		{
+			name: "initial state only prints proxies not synced",
+			details: []*xds.SyncDetails{
+				{ProxyID: "a.default", Types: []xds.TypeSyncDetails{
+					{TypeURL: v3.ClusterType, NonceSent: "1", NonceAcked: "1", LastSent: start, LastAcked: start},
+					{TypeURL: v3.ListenerType, NonceSent: "1", LastSent: start},
+				}},
+			},
+			want: []string{
+				"TIME", "NAME", "TYPE", "STATUS", "TIME TO ACK", "ISTIOD", "DETAIL",
+				"10:00:00", "a.default", "LDS", "STALE", "istiod-1", "never acknowledged",
+			},
+			lines: 2,
+		},
+		{
+			name: "acked push is printed with its time to ack",
+			details: []*xds.SyncDetails{
+				{ProxyID: "a.default", Types: []xds.TypeSyncDetails{
+					{TypeURL: v3.ClusterType, NonceSent: "1", NonceAcked: "1", LastSent: start, LastAcked: start},
+					{TypeURL: v3.ListenerType, NonceSent: "1", NonceAcked: "1", LastSent: start, LastAcked: start.Add(120 * time.Millisecond)},
+				}},
+			},
+			want:  []string{"a.default", "LDS", "SYNCED", "120ms"},
+			lines: 1,
+		},
+		{
+			name: "rejected push is printed with the error",
+			details: []*xds.SyncDetails{
+				{ProxyID: "a.default", Types: []xds.TypeSyncDetails{
+					{TypeURL: v3.ClusterType, NonceSent: "2", NonceAcked: "1", NonceNacked: "2", NackError: "bad cluster"},
+					{TypeURL: v3.ListenerType, NonceSent: "1", NonceAcked: "1", LastSent: start, LastAcked: start.Add(120 * time.Millisecond)},
+				}},
+			},
+			want:  []string{"a.default", "CDS", "NACKED", "bad cluster"},
+			lines: 1,
+		},
+	}
+
+	for _, step := range steps {
+		out.Reset()
+		if err := w.Update(syncDetailsResponses(t, step.details...), start); err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		got := out.String()
+		for _, want := range step.want {
+			if !strings.Contains(got, want) {
+				t.Errorf("%s: output does not contain %q:\n%s", step.name, want, got)
+			}
+		}
+		if lines := strings.Count(got, "\n"); lines != step.lines {
+			t.Errorf("%s: unexpected number of transitions:\n%s", step.name, got)
+		}
+	}
+
+	out.Reset()
+	if err := w.Update(syncDetailsResponses(t, steps[len(steps)-1].details...), start); err != nil {
+		t.Fatal(err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("unchanged state should not print anything, got:\n%s", out.String())
+	}
+
+	if err := w.PrintSummary(); err != nil {
+		t.Fatal(err)
+	}
+	summary := out.String()
+	for _, want := range []string{"Convergence summary (1 NACKs)", "LDS", "ALL"} {
+		if !strings.Contains(summary, want) {
+			t.Errorf("summary does not contain %q:\n%s", want, summary)
+		}
+	}
+}
+
+func TestXdsSyncWatcherSkipsInvalidResources(t *testing.T) {
+	out := &bytes.Buffer{}
+	w := NewXdsSyncWatcher(out)
+	responses := syncDetailsResponses(t, &xds.SyncDetails{ProxyID: "a.default", Types: []xds.TypeSyncDetails{
+		{TypeURL: v3.ListenerType, NonceSent: "1"},
+	}})
+	responses["istiod-1"].Resources = append([]*any.Any{{TypeUrl: "invalid"}}, responses["istiod-1"].Resources...)
+	if err := w.Update(responses, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if got := out.String(); !strings.Contains(got, "a.default") {
+		t.Errorf("expected the valid resource to be printed, got:\n%s", got)
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	times := []time.Duration{}
+	for i := 100; i > 0; i-- {
+		times = append(times, time.Duration(i)*time.Millisecond)
+	}
+	if got := percentile(times, 0.5); got != 50*time.Millisecond {
+		t.Errorf("p50: got %v, want 50ms", got)
+	}
+	if got := percentile(times, 0.99); got != 99*time.Millisecond {
+		t.Errorf("p99: got %v, want 99ms", got)
+	}
+	if got := percentile(times[:1], 0.99); got != 100*time.Millisecond {
+		t.Errorf("p99 of a single sample: got %v, want 100ms", got)
+	}
+}
@@ -0,0 +1,483 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package canary upgrades the data plane to a new control plane revision in stages. Once the control plane of the
+// revision is ready, a percentage of the namespaces using the previous revision are relabeled to use it and their
+// workloads restarted. The proxies of the canary revision are then watched for an analysis period: if they do not
+// ack their config or reject it, the namespaces are rolled back to the previous revision, otherwise the revision is
+// promoted by migrating all the remaining namespaces.
+package canary
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+
+	"istio.io/api/label"
+	"istio.io/api/operator/v1alpha1"
+)
+
+const (
+	// FromRevisionAnnotation on an IstioOperator with a revision starts a canary upgrade to that revision of the
+	// namespaces using the revision of the annotation. The "default" revision also selects the namespaces labeled
+	// istio-injection=enabled.
+	FromRevisionAnnotation = "install.istio.io/canary-from-revision"
+	// PercentageAnnotation is the percentage of the namespaces migrated before the analysis. Default is 10.
+	PercentageAnnotation = "install.istio.io/canary-percentage"
+	// AnalysisDurationAnnotation is how long the proxies of the migrated namespaces are watched before the revision
+	// is promoted. Default is 10m.
+	AnalysisDurationAnnotation = "install.istio.io/canary-analysis-duration"
+	// MaxStaleProxiesAnnotation is the maximum percentage of the proxies of the revision which have not acked their
+	// latest config. Default is 10.
+	MaxStaleProxiesAnnotation = "install.istio.io/canary-max-stale-proxies"
+	// MaxRejectsAnnotation is the maximum number of configs rejected by the proxies of the revision during the
+	// analysis. Default is 0.
+	MaxRejectsAnnotation = "install.istio.io/canary-max-rejects"
+	// RestartWorkloadsAnnotation controls whether the deployments of the migrated namespaces are restarted, so that
+	// their proxies are injected by the new revision. Default is true.
+	RestartWorkloadsAnnotation = "install.istio.io/canary-restart-workloads"
+	// StateAnnotation is written by the operator with the State of the upgrade.
+	StateAnnotation = "install.istio.io/canary-state"
+
+	// DefaultRevision is the revision of the namespaces labeled istio-injection=enabled.
+	DefaultRevision = "default"
+
+	injectionLabel = "istio-injection"
+	// restartedAtAnnotation triggers a rollout of a deployment when changed, like kubectl rollout restart.
+	restartedAtAnnotation = "kubectl.kubernetes.io/restartedAt"
+	// staleChecks is the number of consecutive checks with too many stale proxies before rolling back, since the
+	// proxies do not ack a push immediately.
+	staleChecks = 3
+)
+
+// Phase is the phase of a canary upgrade.
+type Phase string
+
+const (
+	// PhaseInstalling waits for the control plane of the revision to be ready.
+	PhaseInstalling Phase = "Installing"
+	// PhaseMigrating relabels a percentage of the namespaces to use the revision.
+	PhaseMigrating Phase = "Migrating"
+	// PhaseAnalyzing watches the proxies of the revision.
+	PhaseAnalyzing Phase = "Analyzing"
+	// PhasePromoting relabels all the remaining namespaces to use the revision.
+	PhasePromoting Phase = "Promoting"
+	// PhasePromoted is the end of a successful upgrade.
+	PhasePromoted Phase = "Promoted"
+	// PhaseRollingBack relabels the migrated namespaces to use the previous revision.
+	PhaseRollingBack Phase = "RollingBack"
+	// PhaseRolledBack is the end of a failed upgrade.
+	PhaseRolledBack Phase = "RolledBack"
+)
+
+// Config is the config of a canary upgrade, from the annotations of the IstioOperator.
+type Config struct {
+	FromRevision     string
+	Percentage       int
+	AnalysisDuration time.Duration
+	MaxStaleProxies  int
+	MaxRejects       float64
+	RestartWorkloads bool
+}
+
+// Requested reports whether the annotations of an IstioOperator request a canary upgrade.
+func Requested(annotations map[string]string) bool {
+	return annotations[FromRevisionAnnotation] != ""
+}
+
+// ParseConfig returns the config of the canary upgrade requested by the annotations of an IstioOperator.
+func ParseConfig(annotations map[string]string) (*Config, error) {
+	cfg := &Config{
+		FromRevision:     annotations[FromRevisionAnnotation],
+		Percentage:       10,
+		AnalysisDuration: 10 * time.Minute,
+		MaxStaleProxies:  10,
+		RestartWorkloads: true,
+	}
+	var err error
+	if v, ok := annotations[PercentageAnnotation]; ok {
+		if cfg.Percentage, err = strconv.Atoi(v); err != nil || cfg.Percentage <= 0 || cfg.Percentage > 100 {
+			return nil, fmt.Errorf("invalid %s %q, must be a percentage between 1 and 100", PercentageAnnotation, v)
+		}
+	}
+	if v, ok := annotations[AnalysisDurationAnnotation]; ok {
+		if cfg.AnalysisDuration, err = time.ParseDuration(v); err != nil || cfg.AnalysisDuration < 0 {
+			return nil, fmt.Errorf("invalid %s %q, must be a duration", AnalysisDurationAnnotation, v)
+		}
+	}
+	if v, ok := annotations[MaxStaleProxiesAnnotation]; ok {
+		if cfg.MaxStaleProxies, err = strconv.Atoi(v); err != nil || cfg.MaxStaleProxies < 0 || cfg.MaxStaleProxies > 100 {
+			return nil, fmt.Errorf("invalid %s %q, must be a percentage between 0 and 100", MaxStaleProxiesAnnotation, v)
+		}
+	}
+	if v, ok := annotations[MaxRejectsAnnotation]; ok {
+		if cfg.MaxRejects, err = strconv.ParseFloat(v, 64); err != nil || cfg.MaxRejects < 0 {
+			return nil, fmt.Errorf("invalid %s %q, must be a positive number", MaxRejectsAnnotation, v)
+		}
+	}
+	if v, ok := annotations[RestartWorkloadsAnnotation]; ok {
+		if cfg.RestartWorkloads, err = strconv.ParseBool(v); err != nil {
+			return nil, fmt.Errorf("invalid %s %q, must be true or false", RestartWorkloadsAnnotation, v)
+		}
+	}
+	return cfg, nil
+}
+
+// State is the state of a canary upgrade, persisted in the StateAnnotation of the IstioOperator between the steps.
+type State struct {
+	Phase Phase `json:"phase,omitempty"`
+	// Message describes the phase, like the reason of a rollback.
+	Message string `json:"message,omitempty"`
+	// Namespaces are the namespaces migrated to the revision before the analysis, with their injection labels
+	// before the migration.
+	Namespaces map[string]map[string]string `json:"namespaces,omitempty"`
+	// AnalysisStart is the start time of the analysis.
+	AnalysisStart *metav1.Time `json:"analysisStart,omitempty"`
+	// Rejects is the number of configs rejected by the proxies of the revision since the start of the analysis.
+	Rejects float64 `json:"rejects,omitempty"`
+	// PodRejects is the last value of the rejects counter of each control plane pod of the revision.
+	PodRejects map[string]float64 `json:"podRejects,omitempty"`
+	// StaleChecks is the number of consecutive checks with too many stale proxies.
+	StaleChecks int `json:"staleChecks,omitempty"`
+}
+
+// ParseState returns the state of the canary upgrade in the annotations of an IstioOperator, which is empty if the
+// upgrade has not started.
+func ParseState(annotations map[string]string) (*State, error) {
+	s := &State{}
+	v := annotations[StateAnnotation]
+	if v == "" {
+		return s, nil
+	}
+	if err := json.Unmarshal([]byte(v), s); err != nil {
+		return nil, fmt.Errorf("invalid %s: %v", StateAnnotation, err)
+	}
+	return s, nil
+}
+
+// Encode returns the state as the value of the StateAnnotation.
+func (s *State) Encode() (string, error) {
+	b, err := json.Marshal(s)
+	return string(b), err
+}
+
+// Done reports whether the upgrade is over.
+func (s *State) Done() bool {
+	return s.Phase == PhasePromoted || s.Phase == PhaseRolledBack
+}
+
+// InstallStatus returns the overall status of the IstioOperator while the upgrade is in this state.
+func (s *State) InstallStatus() v1alpha1.InstallStatus_Status {
+	switch s.Phase {
+	case PhasePromoted:
+		return v1alpha1.InstallStatus_HEALTHY
+	case PhaseRolledBack:
+		return v1alpha1.InstallStatus_ERROR
+	}
+	return v1alpha1.InstallStatus_UPDATING
+}
+
+// Health is the health of the control plane of a revision and of its proxies.
+type Health struct {
+	// Ready is true if an instance of the control plane is ready.
+	Ready bool
+	// Proxies is the number of proxies connected to the control plane.
+	Proxies int
+	// StaleProxies is the number of proxies which have not acked their latest config.
+	StaleProxies int
+	// Rejects is the number of configs rejected by the proxies of each ready control plane pod since it started, by
+	// pod name.
+	Rejects map[string]float64
+}
+
+// Monitor checks the health of the control plane of a revision.
+type Monitor interface {
+	Check(ctx context.Context, namespace, revision string) (*Health, error)
+}
+
+// Upgrade is a canary upgrade of the data plane to the control plane revision installed by an IstioOperator.
+type Upgrade struct {
+	client  kubernetes.Interface
+	monitor Monitor
+	cfg     *Config
+	// namespace of the control plane.
+	namespace string
+	revision  string
+	now       func() time.Time
+}
+
+// NewUpgrade returns the canary upgrade requested by the annotations of an IstioOperator installing the control
+// plane revision in the namespace.
+func NewUpgrade(client kubernetes.Interface, monitor Monitor, annotations map[string]string, namespace, revision string) (*Upgrade, error) {
+	cfg, err := ParseConfig(annotations)
+	if err != nil {
+		return nil, err
+	}
+	if revision == "" || revision == DefaultRevision {
+		return nil, fmt.Errorf("a canary upgrade requires an IstioOperator with a revision")
+	}
+	if revision == cfg.FromRevision {
+		return nil, fmt.Errorf("cannot upgrade revision %s to itself", revision)
+	}
+	return &Upgrade{
+		client:    client,
+		monitor:   monitor,
+		cfg:       cfg,
+		namespace: namespace,
+		revision:  revision,
+		now:       time.Now,
+	}, nil
+}
+
+// Step advances the upgrade from the state and returns the new state. Step must be called again later until the
+// state is done. The phases which change the namespaces are persisted before they are done, so that they are
+// resumed after an error.
+func (u *Upgrade) Step(ctx context.Context, state *State) (*State, error) {
+	s := *state
+	switch s.Phase {
+	case "", PhaseInstalling:
+		h, err := u.monitor.Check(ctx, u.namespace, u.revision)
+		if err != nil {
+			return &s, err
+		}
+		if !h.Ready {
+			s.Phase = PhaseInstalling
+			s.Message = fmt.Sprintf("waiting for the control plane of revision %s to be ready", u.revision)
+			return &s, nil
+		}
+		s.Phase = PhaseMigrating
+		s.Message = ""
+		return &s, nil
+	case PhaseMigrating:
+		if err := u.migrate(ctx, &s); err != nil {
+			return &s, err
+		}
+		h, err := u.monitor.Check(ctx, u.namespace, u.revision)
+		if err != nil {
+			return &s, err
+		}
+		start := metav1.NewTime(u.now())
+		s.Phase = PhaseAnalyzing
+		s.AnalysisStart = &start
+		s.Rejects = 0
+		s.PodRejects = h.Rejects
+		s.Message = fmt.Sprintf("analyzing the proxies of %d namespaces", len(s.Namespaces))
+		return &s, nil
+	case PhaseAnalyzing:
+		return u.analyze(ctx, &s)
+	case PhasePromoting:
+		if err := u.promote(ctx); err != nil {
+			return &s, err
+		}
+		s.Phase = PhasePromoted
+		s.Message = fmt.Sprintf("all the namespaces of revision %s are migrated to revision %s", u.cfg.FromRevision, u.revision)
+		return &s, nil
+	case PhaseRollingBack:
+		if err := u.rollback(ctx, &s); err != nil {
+			return &s, err
+		}
+		s.Phase = PhaseRolledBack
+		return &s, nil
+	}
+	return &s, nil
+}
+
+// analyze checks the health of the revision, and rolls it back if it's unhealthy or promotes it at the end of the
+// analysis.
+func (u *Upgrade) analyze(ctx context.Context, s *State) (*State, error) {
+	h, err := u.monitor.Check(ctx, u.namespace, u.revision)
+	if err != nil {
+		return s, err
+	}
+	rollback := func(reason string) (*State, error) {
+		s.Phase = PhaseRollingBack
+		s.Message = reason
+		return s, nil
+	}
+	if !h.Ready {
+		return rollback(fmt.Sprintf("the control plane of revision %s is not ready", u.revision))
+	}
+	countRejects(s, h)
+	if s.Rejects > u.cfg.MaxRejects {
+		return rollback(fmt.Sprintf("the proxies rejected %v configs, more than %v", s.Rejects, u.cfg.MaxRejects))
+	}
+	if h.Proxies > 0 && h.StaleProxies*100 > u.cfg.MaxStaleProxies*h.Proxies {
+		s.StaleChecks++
+		if s.StaleChecks >= staleChecks {
+			return rollback(fmt.Sprintf("%d of %d proxies have not acked their config, more than %d%%",
+				h.StaleProxies, h.Proxies, u.cfg.MaxStaleProxies))
+		}
+	} else {
+		s.StaleChecks = 0
+	}
+	if s.AnalysisStart != nil && u.now().Sub(s.AnalysisStart.Time) >= u.cfg.AnalysisDuration {
+		s.Phase = PhasePromoting
+		s.Message = fmt.Sprintf("%d proxies are healthy after %v", h.Proxies, u.cfg.AnalysisDuration)
+	}
+	return s, nil
+}
+
+// countRejects adds the configs rejected since the previous check to the state, from the rejects counter of each
+// control plane pod. The counter of a pod which is new or whose counter dropped has been reset by a restart, so all
+// its rejects are new.
+func countRejects(s *State, h *Health) {
+	if s.PodRejects == nil {
+		s.PodRejects = make(map[string]float64)
+	}
+	for pod, rejects := range h.Rejects {
+		if prev, ok := s.PodRejects[pod]; ok && rejects >= prev {
+			s.Rejects += rejects - prev
+		} else {
+			s.Rejects += rejects
+		}
+		// The pods missing from a check, like the pods not ready, keep their last value.
+		s.PodRejects[pod] = rejects
+	}
+}
+
+// migrate relabels the percentage of the namespaces of the previous revision to use the revision, recording their
+// labels in the state.
+func (u *Upgrade) migrate(ctx context.Context, s *State) error {
+	candidates, err := u.namespacesOf(ctx, u.cfg.FromRevision)
+	if err != nil {
+		return err
+	}
+	if s.Namespaces == nil {
+		s.Namespaces = make(map[string]map[string]string)
+	}
+	// The namespaces migrated before an error are not candidates anymore.
+	total := len(candidates) + len(s.Namespaces)
+	target := (total*u.cfg.Percentage + 99) / 100
+	for _, ns := range candidates {
+		if len(s.Namespaces) >= target {
+			break
+		}
+		s.Namespaces[ns.Name] = injectionLabels(ns.Labels)
+		if err := u.relabel(ctx, ns.Name, map[string]string{label.IstioRev: u.revision}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// promote relabels all the remaining namespaces of the previous revision to use the revision.
+func (u *Upgrade) promote(ctx context.Context) error {
+	namespaces, err := u.namespacesOf(ctx, u.cfg.FromRevision)
+	if err != nil {
+		return err
+	}
+	for _, ns := range namespaces {
+		if err := u.relabel(ctx, ns.Name, map[string]string{label.IstioRev: u.revision}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rollback restores the labels of the migrated namespaces.
+func (u *Upgrade) rollback(ctx context.Context, s *State) error {
+	names := make([]string, 0, len(s.Namespaces))
+	for ns := range s.Namespaces {
+		names = append(names, ns)
+	}
+	sort.Strings(names)
+	for _, ns := range names {
+		if err := u.relabel(ctx, ns, s.Namespaces[ns]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// namespacesOf returns the namespaces injected by the revision, sorted by name.
+func (u *Upgrade) namespacesOf(ctx context.Context, revision string) ([]corev1.Namespace, error) {
+	list, err := u.client.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	var out []corev1.Namespace
+	for _, ns := range list.Items {
+		rev, hasRev := ns.Labels[label.IstioRev]
+		// The injection label takes precedence over the revision label.
+		if ns.Labels[injectionLabel] == "enabled" {
+			rev, hasRev = DefaultRevision, true
+		}
+		if hasRev && rev == revision && ns.Labels[injectionLabel] != "disabled" {
+			out = append(out, ns)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Name < out[j].Name
+	})
+	return out, nil
+}
+
+// relabel replaces the injection labels of the namespace, and restarts its deployments.
+func (u *Upgrade) relabel(ctx context.Context, namespace string, labels map[string]string) error {
+	ns, err := u.client.CoreV1().Namespaces().Get(ctx, namespace, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if ns.Labels == nil {
+		ns.Labels = make(map[string]string)
+	}
+	delete(ns.Labels, injectionLabel)
+	delete(ns.Labels, label.IstioRev)
+	for k, v := range labels {
+		ns.Labels[k] = v
+	}
+	if _, err := u.client.CoreV1().Namespaces().Update(ctx, ns, metav1.UpdateOptions{}); err != nil {
+		return err
+	}
+	if !u.cfg.RestartWorkloads {
+		return nil
+	}
+	return u.restartDeployments(ctx, namespace)
+}
+
+// restartDeployments rolls out the deployments of the namespace, so that their proxies are injected again.
+func (u *Upgrade) restartDeployments(ctx context.Context, namespace string) error {
+	deployments, err := u.client.AppsV1().Deployments(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	patch := fmt.Sprintf(`{"spec":{"template":{"metadata":{"annotations":{%q:%q}}}}}`,
+		restartedAtAnnotation, u.now().Format(time.RFC3339))
+	for _, d := range deployments.Items {
+		if _, err := u.client.AppsV1().Deployments(namespace).Patch(ctx, d.Name, types.StrategicMergePatchType,
+			[]byte(patch), metav1.PatchOptions{}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// injectionLabels returns the labels selecting the revision injecting a namespace.
+func injectionLabels(labels map[string]string) map[string]string {
+	out := make(map[string]string)
+	for _, k := range []string{injectionLabel, label.IstioRev} {
+		if v, ok := labels[k]; ok {
+			out[k] = v
+		}
+	}
+	return out
+}
@@ -0,0 +1,262 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package canary
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"istio.io/api/label"
+	"istio.io/api/operator/v1alpha1"
+)
+
+type fakeMonitor struct {
+	health Health
+}
+
+func (m *fakeMonitor) Check(context.Context, string, string) (*Health, error) {
+	h := m.health
+	return &h, nil
+}
+
+func namespace(name string, labels map[string]string) *corev1.Namespace {
+	return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
+}
+
+func newTestUpgrade(t *testing.T, monitor Monitor, objects ...runtime.Object) (*Upgrade, kubernetes.Interface, *time.Time) {
+	t.Helper()
+	client := fake.NewSimpleClientset(append([]runtime.Object{
+		namespace("a", map[string]string{injectionLabel: "enabled"}),
+		namespace("b", map[string]string{injectionLabel: "enabled"}),
+		namespace("c", map[string]string{label.IstioRev: DefaultRevision}),
+		namespace("d", map[string]string{label.IstioRev: "1-7"}),
+		namespace("e", map[string]string{injectionLabel: "disabled", label.IstioRev: DefaultRevision}),
+		namespace("f", nil),
+	}, objects...)...)
+	u, err := NewUpgrade(client, monitor, map[string]string{
+		FromRevisionAnnotation:     DefaultRevision,
+		PercentageAnnotation:       "50",
+		AnalysisDurationAnnotation: "10m",
+	}, "istio-system", "1-9")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Unix(1600000000, 0)
+	u.now = func() time.Time {
+		return now
+	}
+	return u, client, &now
+}
+
+func step(t *testing.T, u *Upgrade, s *State, want Phase) *State {
+	t.Helper()
+	s, err := u.Step(context.Background(), s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Phase != want {
+		t.Fatalf("expected phase %s, got %s: %s", want, s.Phase, s.Message)
+	}
+	// The state is persisted between the steps.
+	v, err := s.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err = ParseState(map[string]string{StateAnnotation: v})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func namespaceLabels(t *testing.T, client kubernetes.Interface) map[string]map[string]string {
+	t.Helper()
+	list, err := client.CoreV1().Namespaces().List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := make(map[string]map[string]string)
+	for _, ns := range list.Items {
+		out[ns.Name] = ns.Labels
+	}
+	return out
+}
+
+func TestUpgradePromoted(t *testing.T) {
+	m := &fakeMonitor{}
+	deployment := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "httpbin", Namespace: "a"}}
+	u, client, now := newTestUpgrade(t, m, deployment)
+
+	s := step(t, u, &State{}, PhaseInstalling)
+	m.health = Health{Ready: true, Rejects: map[string]float64{"istiod-a": 2}}
+	s = step(t, u, s, PhaseMigrating)
+	s = step(t, u, s, PhaseAnalyzing)
+
+	// Half of the 3 namespaces of the default revision are migrated, in order.
+	if want := map[string]map[string]string{
+		"a": {injectionLabel: "enabled"},
+		"b": {injectionLabel: "enabled"},
+	}; !reflect.DeepEqual(s.Namespaces, want) {
+		t.Fatalf("expected migrated namespaces %v, got %v", want, s.Namespaces)
+	}
+	labels := namespaceLabels(t, client)
+	if want := map[string]string{label.IstioRev: "1-9"}; !reflect.DeepEqual(labels["a"], want) || !reflect.DeepEqual(labels["b"], want) {
+		t.Errorf("expected namespaces a and b to use revision 1-9, got %v", labels)
+	}
+	d, err := client.AppsV1().Deployments("a").Get(context.Background(), "httpbin", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Spec.Template.Annotations[restartedAtAnnotation] == "" {
+		t.Errorf("expected the deployments of the migrated namespaces to be restarted")
+	}
+
+	// A stale proxy and the rejects before the analysis do not fail it.
+	m.health = Health{Ready: true, Proxies: 10, StaleProxies: 1, Rejects: map[string]float64{"istiod-a": 2}}
+	s = step(t, u, s, PhaseAnalyzing)
+	*now = now.Add(10 * time.Minute)
+	s = step(t, u, s, PhasePromoting)
+	s = step(t, u, s, PhasePromoted)
+	if !s.Done() || s.InstallStatus() != v1alpha1.InstallStatus_HEALTHY {
+		t.Errorf("expected a healthy promoted upgrade, got %v", s.InstallStatus())
+	}
+
+	labels = namespaceLabels(t, client)
+	want := map[string]map[string]string{
+		"a": {label.IstioRev: "1-9"},
+		"b": {label.IstioRev: "1-9"},
+		"c": {label.IstioRev: "1-9"},
+		"d": {label.IstioRev: "1-7"},
+		"e": {injectionLabel: "disabled", label.IstioRev: DefaultRevision},
+		"f": nil,
+	}
+	if !reflect.DeepEqual(labels, want) {
+		t.Errorf("expected namespace labels %v, got %v", want, labels)
+	}
+}
+
+func TestUpgradeRolledBack(t *testing.T) {
+	cases := []struct {
+		name   string
+		health Health
+		checks int
+	}{
+		{"rejects", Health{Ready: true, Proxies: 10, Rejects: map[string]float64{"istiod-a": 1}}, 1},
+		{"stale proxies", Health{Ready: true, Proxies: 10, StaleProxies: 2}, staleChecks},
+		{"not ready", Health{}, 1},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			m := &fakeMonitor{health: Health{Ready: true}}
+			u, client, _ := newTestUpgrade(t, m)
+			s := step(t, u, &State{}, PhaseMigrating)
+			s = step(t, u, s, PhaseAnalyzing)
+
+			m.health = c.health
+			for i := 1; i < c.checks; i++ {
+				s = step(t, u, s, PhaseAnalyzing)
+			}
+			s = step(t, u, s, PhaseRollingBack)
+			s = step(t, u, s, PhaseRolledBack)
+			if s.InstallStatus() != v1alpha1.InstallStatus_ERROR || s.Message == "" {
+				t.Errorf("expected an error status with the reason of the rollback, got %v: %s", s.InstallStatus(), s.Message)
+			}
+
+			labels := namespaceLabels(t, client)
+			for _, ns := range []string{"a", "b"} {
+				if want := map[string]string{injectionLabel: "enabled"}; !reflect.DeepEqual(labels[ns], want) {
+					t.Errorf("expected the labels of namespace %s to be restored to %v, got %v", ns, want, labels[ns])
+				}
+			}
+			if want := map[string]string{label.IstioRev: DefaultRevision}; !reflect.DeepEqual(labels["c"], want) {
+				t.Errorf("expected namespace c not to be migrated, got %v", labels["c"])
+			}
+		})
+	}
+}
+
+func TestCountRejects(t *testing.T) {
+	s := &State{PodRejects: map[string]float64{"istiod-a": 5}}
+	for _, c := range []struct {
+		name    string
+		rejects map[string]float64
+		want    float64
+	}{
+		{"no new rejects", map[string]float64{"istiod-a": 5}, 0},
+		{"new rejects", map[string]float64{"istiod-a": 7}, 2},
+		{"counter reset", map[string]float64{"istiod-a": 1}, 3},
+		{"new pod", map[string]float64{"istiod-a": 1, "istiod-b": 4}, 7},
+		{"pod not ready", map[string]float64{"istiod-a": 1}, 7},
+		{"pod ready again", map[string]float64{"istiod-a": 1, "istiod-b": 5}, 8},
+	} {
+		countRejects(s, &Health{Rejects: c.rejects})
+		if s.Rejects != c.want {
+			t.Errorf("%s: expected %v rejects, got %v", c.name, c.want, s.Rejects)
+		}
+	}
+}
+
+func TestParseConfig(t *testing.T) {
+	for _, a := range []map[string]string{
+		{PercentageAnnotation: "0"},
+		{PercentageAnnotation: "101"},
+		{AnalysisDurationAnnotation: "soon"},
+		{MaxStaleProxiesAnnotation: "-1"},
+		{MaxRejectsAnnotation: "many"},
+		{RestartWorkloadsAnnotation: "maybe"},
+	} {
+		if _, err := ParseConfig(a); err == nil {
+			t.Errorf("expected an error for annotations %v", a)
+		}
+	}
+	if _, err := NewUpgrade(nil, nil, map[string]string{FromRevisionAnnotation: "1-9"}, "istio-system", "1-9"); err == nil {
+		t.Errorf("expected an error upgrading a revision to itself")
+	}
+	if _, err := NewUpgrade(nil, nil, map[string]string{FromRevisionAnnotation: "1-8"}, "istio-system", ""); err == nil {
+		t.Errorf("expected an error without revision")
+	}
+}
+
+func TestParseIstiodOutput(t *testing.T) {
+	proxies, stale, err := parseSyncz([]byte(`[
+  {"proxy": "a.foo", "cluster_sent": "1", "cluster_acked": "1"},
+  {"proxy": "b.foo", "cluster_sent": "2", "cluster_acked": "1"}
+]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if proxies != 2 || stale != 1 {
+		t.Errorf("expected 1 of 2 proxies stale, got %d of %d", stale, proxies)
+	}
+
+	metrics := `# HELP pilot_total_xds_rejects Total number of XDS responses from pilot rejected by proxy.
+# TYPE pilot_total_xds_rejects counter
+pilot_total_xds_rejects{type="cds"} 2
+pilot_total_xds_rejects{type="lds"} 1.5
+pilot_total_xds_rejects_other 10
+`
+	if got := sumMetric([]byte(metrics), rejectsMetric); got != 3.5 {
+		t.Errorf("expected 3.5 rejects, got %v", got)
+	}
+}
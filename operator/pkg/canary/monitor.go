@@ -0,0 +1,154 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package canary
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"istio.io/api/label"
+)
+
+const (
+	// istiodMonitoringPort serves the metrics and the debug endpoints of Istiod.
+	istiodMonitoringPort = "15014"
+	// rejectsMetric counts the configs rejected by the proxies.
+	rejectsMetric = "pilot_total_xds_rejects"
+)
+
+// syncStatus is the sync status of a proxy in the debug/syncz output of Istiod.
+type syncStatus struct {
+	ProxyID       string `json:"proxy,omitempty"`
+	ClusterSent   string `json:"cluster_sent,omitempty"`
+	ClusterAcked  string `json:"cluster_acked,omitempty"`
+	ListenerSent  string `json:"listener_sent,omitempty"`
+	ListenerAcked string `json:"listener_acked,omitempty"`
+	RouteSent     string `json:"route_sent,omitempty"`
+	RouteAcked    string `json:"route_acked,omitempty"`
+	EndpointSent  string `json:"endpoint_sent,omitempty"`
+	EndpointAcked string `json:"endpoint_acked,omitempty"`
+}
+
+func (s *syncStatus) stale() bool {
+	return s.ClusterSent != s.ClusterAcked || s.ListenerSent != s.ListenerAcked ||
+		s.RouteSent != s.RouteAcked || s.EndpointSent != s.EndpointAcked
+}
+
+type kubeMonitor struct {
+	client kubernetes.Interface
+}
+
+// NewMonitor returns a Monitor which reads the sync status of the proxies and the metrics of the ready Istiod pods
+// of the revision, through the API server proxy.
+func NewMonitor(client kubernetes.Interface) Monitor {
+	return &kubeMonitor{client: client}
+}
+
+func (m *kubeMonitor) Check(ctx context.Context, namespace, revision string) (*Health, error) {
+	pods, err := m.client.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("app=istiod,%s=%s", label.IstioRev, revision),
+	})
+	if err != nil {
+		return nil, err
+	}
+	h := &Health{Rejects: make(map[string]float64)}
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if !podReady(pod) {
+			continue
+		}
+		h.Ready = true
+		syncz, err := m.client.CoreV1().Pods(namespace).ProxyGet("", pod.Name, istiodMonitoringPort, "debug/syncz", nil).DoRaw(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the sync status of %s/%s: %v", namespace, pod.Name, err)
+		}
+		proxies, stale, err := parseSyncz(syncz)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the sync status of %s/%s: %v", namespace, pod.Name, err)
+		}
+		h.Proxies += proxies
+		h.StaleProxies += stale
+		metrics, err := m.client.CoreV1().Pods(namespace).ProxyGet("", pod.Name, istiodMonitoringPort, "metrics", nil).DoRaw(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the metrics of %s/%s: %v", namespace, pod.Name, err)
+		}
+		h.Rejects[pod.Name] = sumMetric(metrics, rejectsMetric)
+	}
+	return h, nil
+}
+
+func podReady(pod *corev1.Pod) bool {
+	if pod.Status.Phase != corev1.PodRunning {
+		return false
+	}
+	for _, c := range pod.Status.Conditions {
+		if c.Type == corev1.PodReady {
+			return c.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// parseSyncz returns the number of proxies in the debug/syncz output, and of those which have not acked their latest
+// config.
+func parseSyncz(b []byte) (int, int, error) {
+	var statuses []syncStatus
+	if err := json.Unmarshal(b, &statuses); err != nil {
+		return 0, 0, err
+	}
+	stale := 0
+	for i := range statuses {
+		if statuses[i].stale() {
+			stale++
+		}
+	}
+	return len(statuses), stale, nil
+}
+
+// sumMetric returns the sum of the samples of the metric, with any labels, in the Prometheus text format.
+func sumMetric(b []byte, name string) float64 {
+	sum := 0.0
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, name) {
+			continue
+		}
+		rest := line[len(name):]
+		if !strings.HasPrefix(rest, " ") && !strings.HasPrefix(rest, "{") {
+			continue
+		}
+		if i := strings.LastIndex(rest, "}"); i >= 0 {
+			rest = rest[i+1:]
+		}
+		fields := strings.Fields(rest)
+		if len(fields) == 0 {
+			continue
+		}
+		if v, err := strconv.ParseFloat(fields[0], 64); err == nil {
+			sum += v
+		}
+	}
+	return sum
+}
@@ -0,0 +1,116 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package istiocontrolplane
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"istio.io/api/operator/v1alpha1"
+	iopv1alpha1 "istio.io/istio/operator/pkg/apis/istio/v1alpha1"
+	"istio.io/istio/operator/pkg/canary"
+	"istio.io/istio/operator/pkg/metrics"
+)
+
+// canaryCheckInterval is the interval between the steps of a canary upgrade in progress.
+const canaryCheckInterval = 30 * time.Second
+
+// reconcileCanary advances the canary upgrade requested by the annotations of iop, after the control plane of its
+// revision is reconciled in namespace, and reports the phase of the upgrade in the message of status. The upgrade is
+// requeued until it's done.
+func (r *ReconcileIstioOperator) reconcileCanary(iop *iopv1alpha1.IstioOperator, namespace string,
+	status *v1alpha1.InstallStatus) (reconcile.Result, error) {
+	state, err := canary.ParseState(iop.Annotations)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if state.Done() {
+		setCanaryStatus(status, state.InstallStatus(), canaryMessage(iop, state))
+		return reconcile.Result{}, nil
+	}
+	cs, err := kubernetes.NewForConfig(r.config)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	u, err := canary.NewUpgrade(cs, canary.NewMonitor(cs), iop.Annotations, namespace, iop.Spec.Revision)
+	if err != nil {
+		// The annotations must be fixed before the upgrade is retried.
+		setCanaryStatus(status, v1alpha1.InstallStatus_ERROR, fmt.Sprintf("invalid canary upgrade: %v", err))
+		return reconcile.Result{}, nil
+	}
+
+	newState, stepErr := u.Step(context.TODO(), state)
+	if newState.Phase != state.Phase {
+		scope.Infof("Canary upgrade of IstioOperator %s to revision %s: %s. %s",
+			iop.Name, iop.Spec.Revision, newState.Phase, newState.Message)
+		metrics.CountCanaryPhase(string(newState.Phase))
+	}
+	if err := r.saveCanaryState(iop, newState); err != nil {
+		return reconcile.Result{}, err
+	}
+	setCanaryStatus(status, newState.InstallStatus(), canaryMessage(iop, newState))
+	if stepErr != nil {
+		return reconcile.Result{}, stepErr
+	}
+	if newState.Done() {
+		return reconcile.Result{}, nil
+	}
+	return reconcile.Result{RequeueAfter: canaryCheckInterval}, nil
+}
+
+// saveCanaryState persists the state of the canary upgrade in the annotations of iop. Annotation changes do not
+// trigger a reconcile.
+func (r *ReconcileIstioOperator) saveCanaryState(iop *iopv1alpha1.IstioOperator, state *canary.State) error {
+	value, err := state.Encode()
+	if err != nil {
+		return err
+	}
+	if iop.Annotations[canary.StateAnnotation] == value {
+		return nil
+	}
+	orig := iop.DeepCopy()
+	if iop.Annotations == nil {
+		iop.Annotations = make(map[string]string)
+	}
+	iop.Annotations[canary.StateAnnotation] = value
+	return r.client.Patch(context.TODO(), iop, client.MergeFrom(orig))
+}
+
+// canaryMessage describes the state of the canary upgrade requested by the annotations of iop.
+func canaryMessage(iop *iopv1alpha1.IstioOperator, state *canary.State) string {
+	msg := fmt.Sprintf("canary upgrade from revision %s to %s: %s", iop.Annotations[canary.FromRevisionAnnotation],
+		iop.Spec.Revision, state.Phase)
+	if state.Message != "" {
+		msg += ", " + state.Message
+	}
+	return msg
+}
+
+// setCanaryStatus adds the message describing the canary upgrade to the message of the overall status. An upgrade in
+// progress or failed overrides a healthy overall status.
+func setCanaryStatus(status *v1alpha1.InstallStatus, st v1alpha1.InstallStatus_Status, msg string) {
+	if status.Message != "" {
+		msg = status.Message + "; " + msg
+	}
+	status.Message = msg
+	if status.Status == v1alpha1.InstallStatus_HEALTHY {
+		status.Status = st
+	}
+}
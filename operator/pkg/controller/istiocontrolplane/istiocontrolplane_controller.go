@@ -20,7 +20,9 @@ import (
 	"os"
 	"reflect"
 	"strings"
+	"time"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -30,6 +32,7 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/workqueue"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/event"
@@ -43,6 +46,7 @@ import (
 	"istio.io/istio/operator/pkg/apis/istio"
 	iopv1alpha1 "istio.io/istio/operator/pkg/apis/istio/v1alpha1"
 	"istio.io/istio/operator/pkg/cache"
+	"istio.io/istio/operator/pkg/canary"
 	"istio.io/istio/operator/pkg/helm"
 	"istio.io/istio/operator/pkg/helmreconciler"
 	"istio.io/istio/operator/pkg/metrics"
@@ -51,6 +55,8 @@ import (
 	"istio.io/istio/operator/pkg/tpath"
 	"istio.io/istio/operator/pkg/translate"
 	"istio.io/istio/operator/pkg/util"
+	"istio.io/istio/operator/pkg/util/clog"
+	"istio.io/istio/operator/pkg/util/progress"
 	"istio.io/istio/pkg/errdict"
 	"istio.io/istio/pkg/url"
 	"istio.io/pkg/log"
@@ -63,6 +69,9 @@ const (
 	finalizerMaxRetries = 1
 	// IgnoreReconcileAnnotation is annotation of IstioOperator CR so it would be ignored during Reconcile loop.
 	IgnoreReconcileAnnotation = "install.istio.io/ignoreReconcile"
+	// driftReconcileDelay is the delay of the reconciles triggered by the modifications of the resources managed by
+	// the operator.
+	driftReconcileDelay = 10 * time.Second
 )
 
 var (
@@ -135,8 +144,13 @@ var (
 			return false
 		},
 		UpdateFunc: func(e event.UpdateEvent) bool {
-			// no action
-			return false
+			obj, err := meta.Accessor(e.ObjectNew)
+			if err != nil || !isOperatorCreatedResource(obj) {
+				return false
+			}
+			// Resources modified outside of the operator are reconciled to detect the drift from the manifest. The
+			// reconcile is delayed by driftReconcileDelay, see debouncedUpdateHandler.
+			return contentChanged(e.ObjectOld, e.ObjectNew)
 		},
 	}
 
@@ -324,7 +338,11 @@ func (r *ReconcileIstioOperator) Reconcile(request reconcile.Request) (reconcile
 		}
 		globalValues["jwtPolicy"] = string(jwtPolicy)
 	}
-	reconciler, err := helmreconciler.NewHelmReconciler(r.client, r.config, iopMerged, nil)
+	reconciler, err := helmreconciler.NewHelmReconciler(r.client, r.config, iopMerged, &helmreconciler.Options{
+		Log:         clog.NewDefaultLogger(),
+		ProgressLog: progress.NewLog(),
+		DetectDrift: true,
+	})
 	if err != nil {
 		return reconcile.Result{}, err
 	}
@@ -335,11 +353,17 @@ func (r *ReconcileIstioOperator) Reconcile(request reconcile.Request) (reconcile
 	if err != nil {
 		scope.Errorf("Error during reconcile: %s", err)
 	}
+	result := reconcile.Result{}
+	if err == nil && canary.Requested(iop.Annotations) {
+		if result, err = r.reconcileCanary(iop, iopv1alpha1.Namespace(iopMerged.Spec), status); err != nil {
+			scope.Errorf("Error during canary upgrade: %s", err)
+		}
+	}
 	if err := reconciler.SetStatusComplete(status); err != nil {
 		return reconcile.Result{}, err
 	}
 
-	return reconcile.Result{}, err
+	return result, err
 }
 
 // mergeIOPSWithProfile overlays the values in iop on top of the defaults for the profile given by iop.profile and
@@ -440,16 +464,19 @@ func watchIstioResources(c controller.Controller) error {
 			Group:   t.Group,
 			Version: t.Version,
 		})
-		err := c.Watch(&source.Kind{Type: u}, &handler.EnqueueRequestsFromMapFunc{
-			ToRequests: handler.ToRequestsFunc(func(a handler.MapObject) []reconcile.Request {
-				scope.Infof("Watching a change for istio resource: %s/%s", a.Meta.GetNamespace(), a.Meta.GetName())
-				return []reconcile.Request{
-					{NamespacedName: types.NamespacedName{
-						Name:      a.Meta.GetLabels()[helmreconciler.OwningResourceName],
-						Namespace: a.Meta.GetLabels()[helmreconciler.OwningResourceNamespace],
-					}},
-				}
-			}),
+		err := c.Watch(&source.Kind{Type: u}, &debouncedUpdateHandler{
+			EnqueueRequestsFromMapFunc: handler.EnqueueRequestsFromMapFunc{
+				ToRequests: handler.ToRequestsFunc(func(a handler.MapObject) []reconcile.Request {
+					scope.Infof("Watching a change for istio resource: %s/%s", a.Meta.GetNamespace(), a.Meta.GetName())
+					return []reconcile.Request{
+						{NamespacedName: types.NamespacedName{
+							Name:      a.Meta.GetLabels()[helmreconciler.OwningResourceName],
+							Namespace: a.Meta.GetLabels()[helmreconciler.OwningResourceNamespace],
+						}},
+					}
+				}),
+			},
+			delay: driftReconcileDelay,
 		}, ownedResourcePredicates)
 		if err != nil {
 			scope.Errorf("Could not create watch for %s/%s/%s: %s.", t.Kind, t.Group, t.Version, err)
@@ -458,6 +485,47 @@ func watchIstioResources(c controller.Controller) error {
 	return nil
 }
 
+// debouncedUpdateHandler enqueues the reconcile requests of the update events after a delay. The requests of an
+// IstioOperator already waiting are not added again, so the updates of its resources within the delay, like an
+// edit and the apply reverting it, are reconciled once. The requests of the other events are enqueued immediately.
+type debouncedUpdateHandler struct {
+	handler.EnqueueRequestsFromMapFunc
+	delay time.Duration
+}
+
+// Update implements handler.EventHandler.
+func (h *debouncedUpdateHandler) Update(evt event.UpdateEvent, q workqueue.RateLimitingInterface) {
+	for _, req := range h.ToRequests.Map(handler.MapObject{Meta: evt.MetaNew, Object: evt.ObjectNew}) {
+		q.AddAfter(req, h.delay)
+	}
+}
+
+// contentChanged reports whether the content of an object changed between old and new, ignoring its status, the
+// metadata other than labels and annotations, which are updated by the cluster, and the annotations set by the
+// operator when it applies or adopts the object.
+func contentChanged(oldObj, newObj runtime.Object) bool {
+	// The content of unstructured objects is not copied, so they are copied before it's modified.
+	oldContent, err := runtime.DefaultUnstructuredConverter.ToUnstructured(oldObj.DeepCopyObject())
+	if err != nil {
+		return false
+	}
+	newContent, err := runtime.DefaultUnstructuredConverter.ToUnstructured(newObj.DeepCopyObject())
+	if err != nil {
+		return false
+	}
+	for _, c := range []map[string]interface{}{oldContent, newContent} {
+		delete(c, "status")
+		if md, ok := c["metadata"].(map[string]interface{}); ok {
+			if annotations, ok := md["annotations"].(map[string]interface{}); ok {
+				delete(annotations, helmreconciler.AdoptedDriftAnnotation)
+				delete(annotations, corev1.LastAppliedConfigAnnotation)
+			}
+			c["metadata"] = map[string]interface{}{"labels": md["labels"], "annotations": md["annotations"]}
+		}
+	}
+	return !reflect.DeepEqual(oldContent, newContent)
+}
+
 // Check if the specified object is created by operator
 func isOperatorCreatedResource(obj metav1.Object) bool {
 	return obj.GetLabels()[helmreconciler.OwningResourceName] != "" &&
@@ -101,6 +101,18 @@ func (h *HelmReconciler) ApplyManifest(manifest name.Manifest, serverSideApply b
 			if err := h.applyLabelsAndAnnotations(obju, cname); err != nil {
 				return nil, 0, err
 			}
+			if h.opts.DetectDrift && h.driftPolicy(manifest.Name) == DriftAdopt {
+				adopted, err := h.liveDriftAdopted(obju)
+				if err != nil {
+					scope.Errorf("failed to check for adopted drift of %s: %v", obj.Hash(), err)
+				} else if adopted {
+					scope.Infof("Not applying %s, its modifications outside of the operator were adopted.", obj.Hash())
+					metrics.AddResource(obj.FullName(), obj.GroupVersionKind().GroupKind())
+					deployedObjects++
+					objectCache.Cache[obj.Hash()] = obj
+					continue
+				}
+			}
 			if err := h.ApplyObject(obj.UnstructuredObject(), serverSideApply); err != nil {
 				scope.Error(err.Error())
 				errs = util.AppendErr(errs, err)
@@ -0,0 +1,389 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helmreconciler
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	errors2 "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	"istio.io/api/operator/v1alpha1"
+	"istio.io/istio/operator/pkg/cache"
+	"istio.io/istio/operator/pkg/compare"
+	"istio.io/istio/operator/pkg/metrics"
+	"istio.io/istio/operator/pkg/name"
+	"istio.io/istio/operator/pkg/object"
+)
+
+const (
+	// DriftPolicyAnnotation on an IstioOperator sets how the resources modified outside of the operator are handled.
+	// The value is either a policy for all the components, e.g. "auto-revert", or a comma separated list of
+	// <component>=<policy>, where the component * sets the default, e.g. "Pilot=auto-revert,*=adopt".
+	DriftPolicyAnnotation = "install.istio.io/drift-policy"
+	// AdoptedDriftAnnotation on a resource records the hash of the rendered resource whose modifications were adopted.
+	// Under the adopt policy, the resource is not applied again until its rendered manifest changes.
+	AdoptedDriftAnnotation = "install.istio.io/adopted-drift"
+
+	lastAppliedConfigAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+)
+
+// DriftPolicy is the handling of the resources modified outside of the operator.
+type DriftPolicy string
+
+const (
+	// DriftReportOnly reports the modified resources in the status message of the IstioOperator. The modifications are
+	// reverted when the manifest of the resource changes or the operator restarts.
+	DriftReportOnly DriftPolicy = "report-only"
+	// DriftAutoRevert applies the rendered resource again.
+	DriftAutoRevert DriftPolicy = "auto-revert"
+	// DriftAdopt keeps the modifications until the manifest of the resource changes.
+	DriftAdopt DriftPolicy = "adopt"
+)
+
+// driftIgnorePaths are the paths of the fields set by other controllers than the operator, like the CA bundle of the
+// webhooks patched by Istiod, which are not drift.
+var driftIgnorePaths = []string{
+	"metadata.annotations." + lastAppliedConfigAnnotation,
+	"status.*",
+	"webhooks.*.clientConfig.caBundle",
+	"webhooks.*.failurePolicy",
+}
+
+// DriftPolicies are the drift policies of the components.
+type DriftPolicies struct {
+	// Default is the policy of the components without their own.
+	Default DriftPolicy
+	// Components are the policies by component.
+	Components map[name.ComponentName]DriftPolicy
+}
+
+// For returns the drift policy of the component.
+func (p *DriftPolicies) For(c name.ComponentName) DriftPolicy {
+	if policy, ok := p.Components[c]; ok {
+		return policy
+	}
+	return p.Default
+}
+
+// ParseDriftPolicies parses the value of DriftPolicyAnnotation. The default policy is report-only.
+func ParseDriftPolicies(value string) (*DriftPolicies, error) {
+	out := &DriftPolicies{Default: DriftReportOnly, Components: make(map[name.ComponentName]DriftPolicy)}
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return out, nil
+	}
+	if !strings.Contains(value, "=") {
+		policy, err := parseDriftPolicy(value)
+		if err != nil {
+			return nil, err
+		}
+		out.Default = policy
+		return out, nil
+	}
+	for _, entry := range strings.Split(value, ",") {
+		kv := strings.SplitN(entry, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid drift policy %q, expected <component>=<policy>", entry)
+		}
+		policy, err := parseDriftPolicy(kv[1])
+		if err != nil {
+			return nil, err
+		}
+		c := strings.TrimSpace(kv[0])
+		if c == "*" {
+			out.Default = policy
+			continue
+		}
+		if !isComponentName(c) {
+			return nil, fmt.Errorf("unknown component %q in drift policy %q", c, entry)
+		}
+		out.Components[name.ComponentName(c)] = policy
+	}
+	return out, nil
+}
+
+func parseDriftPolicy(s string) (DriftPolicy, error) {
+	switch p := DriftPolicy(strings.TrimSpace(s)); p {
+	case DriftReportOnly, DriftAutoRevert, DriftAdopt:
+		return p, nil
+	}
+	return "", fmt.Errorf("unknown drift policy %q, expected one of %s, %s or %s", s, DriftReportOnly, DriftAutoRevert, DriftAdopt)
+}
+
+func isComponentName(s string) bool {
+	for _, c := range name.AllComponentNames {
+		if string(c) == s {
+			return true
+		}
+	}
+	return false
+}
+
+// driftPolicy returns the drift policy of the component, or report-only if DriftPolicyAnnotation is invalid.
+func (h *HelmReconciler) driftPolicy(c name.ComponentName) DriftPolicy {
+	policies, err := ParseDriftPolicies(h.iop.Annotations[DriftPolicyAnnotation])
+	if err != nil {
+		return DriftReportOnly
+	}
+	return policies.For(c)
+}
+
+// Drift is a resource modified outside of the operator.
+type Drift struct {
+	// Object is the resource as last rendered and applied by the operator.
+	Object *object.K8sObject
+	// Live is the resource in the cluster.
+	Live *unstructured.Unstructured
+	// Diff is the tree based diff from the rendered resource to the live one.
+	Diff string
+}
+
+// DetectDrift compares the resources of the component last applied by h with the ones in the cluster, and returns
+// those which were modified. Only the fields set in the rendered resources are compared, so the fields defaulted by
+// the API server are not drift. Deleted resources are not drift, they are created again by the next reconcile.
+func (h *HelmReconciler) DetectDrift(componentName name.ComponentName) ([]*Drift, error) {
+	crHash, err := h.getCRHash(string(componentName))
+	if err != nil {
+		return nil, err
+	}
+	objectCache := cache.GetCache(crHash)
+	objectCache.Mu.RLock()
+	objs := make(object.K8sObjects, 0, len(objectCache.Cache))
+	for _, obj := range objectCache.Cache {
+		objs = append(objs, obj)
+	}
+	objectCache.Mu.RUnlock()
+	objs.Sort(object.DefaultObjectOrder())
+
+	adopt := h.driftPolicy(componentName) == DriftAdopt
+	var drifts []*Drift
+	for _, obj := range objs {
+		rendered := obj.UnstructuredObject()
+		live := &unstructured.Unstructured{}
+		live.SetGroupVersionKind(rendered.GroupVersionKind())
+		key := client.ObjectKey{Namespace: rendered.GetNamespace(), Name: rendered.GetName()}
+		if err := h.client.Get(context.TODO(), key, live); err != nil {
+			if errors2.IsNotFound(err) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to get %s: %v", objectRef(obj), err)
+		}
+		if adopt && driftAdopted(rendered, live) {
+			continue
+		}
+		diff, err := driftDiff(rendered, live)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compare %s: %v", objectRef(obj), err)
+		}
+		if diff != "" {
+			drifts = append(drifts, &Drift{Object: obj, Live: live, Diff: diff})
+		}
+	}
+	return drifts, nil
+}
+
+// reconcileDrift handles the resources of the components in manifests modified outside of the operator with the
+// drift policies of the components. The drift reported, or failed to be resolved, is listed in the status message.
+// It doesn't change the status of the components, which are still installed.
+func (h *HelmReconciler) reconcileDrift(manifests name.ManifestMap, status *v1alpha1.InstallStatus) {
+	var msgs []string
+	policies, err := ParseDriftPolicies(h.iop.Annotations[DriftPolicyAnnotation])
+	if err != nil {
+		scope.Errorf("Invalid %s annotation, only reporting drift: %v", DriftPolicyAnnotation, err)
+		msgs = append(msgs, fmt.Sprintf("invalid %s annotation: %v", DriftPolicyAnnotation, err))
+		policies, _ = ParseDriftPolicies("")
+	}
+	serverSideApply := h.CheckSSAEnabled()
+
+	components := make([]string, 0, len(manifests))
+	for c := range manifests {
+		components = append(components, string(c))
+	}
+	sort.Strings(components)
+	for _, cn := range components {
+		c := name.ComponentName(cn)
+		cs := status.ComponentStatus[cn]
+		if cs == nil || cs.Status != v1alpha1.InstallStatus_HEALTHY {
+			continue
+		}
+		drifts, err := h.DetectDrift(c)
+		if err != nil {
+			scope.Errorf("Failed to detect drift of component %s: %v", c, err)
+			continue
+		}
+		policy := policies.For(c)
+		var unresolved []string
+		for _, d := range drifts {
+			ref := objectRef(d.Object)
+			scope.Infof("%s of component %s was modified outside of the operator, drift policy %s:\n%s", ref, c, policy, d.Diff)
+			if policy == DriftReportOnly {
+				unresolved = append(unresolved, ref)
+				continue
+			}
+			if err := h.resolveDrift(d, policy, serverSideApply); err != nil {
+				scope.Errorf("Failed to resolve drift of %s with policy %s: %v", ref, policy, err)
+				unresolved = append(unresolved, ref)
+				continue
+			}
+			metrics.CountDriftResolved(c, string(policy))
+		}
+		metrics.RecordDriftedResources(c, len(unresolved))
+		if len(unresolved) > 0 {
+			msgs = append(msgs, fmt.Sprintf("resources of component %s modified outside of the operator: %s",
+				c, strings.Join(unresolved, ", ")))
+		}
+	}
+
+	if len(msgs) == 0 {
+		return
+	}
+	if status.Message != "" {
+		msgs = append([]string{status.Message}, msgs...)
+	}
+	status.Message = strings.Join(msgs, "; ")
+}
+
+// resolveDrift reverts or adopts the modifications of a resource.
+func (h *HelmReconciler) resolveDrift(d *Drift, policy DriftPolicy, serverSideApply bool) error {
+	switch policy {
+	case DriftAutoRevert:
+		return h.ApplyObject(d.Object.UnstructuredObject().DeepCopy(), serverSideApply)
+	case DriftAdopt:
+		orig := d.Live.DeepCopy()
+		annotations := d.Live.GetAnnotations()
+		if annotations == nil {
+			annotations = make(map[string]string)
+		}
+		annotations[AdoptedDriftAnnotation] = renderedHash(d.Object.UnstructuredObject())
+		d.Live.SetAnnotations(annotations)
+		return h.client.Patch(context.TODO(), d.Live, client.MergeFrom(orig))
+	}
+	return nil
+}
+
+// liveDriftAdopted reports whether the modifications of the resource rendered as obj in the cluster were adopted.
+func (h *HelmReconciler) liveDriftAdopted(obj *unstructured.Unstructured) (bool, error) {
+	live := &unstructured.Unstructured{}
+	live.SetGroupVersionKind(obj.GroupVersionKind())
+	key := client.ObjectKey{Namespace: obj.GetNamespace(), Name: obj.GetName()}
+	if err := h.client.Get(context.TODO(), key, live); err != nil {
+		if errors2.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return driftAdopted(obj, live), nil
+}
+
+// driftAdopted reports whether the modifications of live were adopted for the rendered resource.
+func driftAdopted(rendered, live *unstructured.Unstructured) bool {
+	hash := renderedHash(rendered)
+	return hash != "" && live.GetAnnotations()[AdoptedDriftAnnotation] == hash
+}
+
+// driftDiff returns the diff from the rendered resource to the fields of the live resource set in the rendered one,
+// or "" if they are the same.
+func driftDiff(rendered, live *unstructured.Unstructured) (string, error) {
+	ry, err := yaml.Marshal(rendered.Object)
+	if err != nil {
+		return "", err
+	}
+	ly, err := yaml.Marshal(project(rendered.Object, live.Object))
+	if err != nil {
+		return "", err
+	}
+	return compare.YAMLCmpWithIgnore(string(ry), string(ly), driftIgnorePaths, ""), nil
+}
+
+// project returns the fields of live which are set in rendered. List items are matched by index, and the extra items
+// of live are kept. Quantities, like "1000m" and "1" CPU, are equal to their rendered form.
+func project(rendered, live interface{}) interface{} {
+	switch r := rendered.(type) {
+	case map[string]interface{}:
+		l, ok := live.(map[string]interface{})
+		if !ok {
+			return live
+		}
+		out := make(map[string]interface{}, len(r))
+		for k, rv := range r {
+			if lv, ok := l[k]; ok {
+				out[k] = project(rv, lv)
+			}
+		}
+		return out
+	case []interface{}:
+		l, ok := live.([]interface{})
+		if !ok {
+			return live
+		}
+		out := make([]interface{}, len(l))
+		for i, lv := range l {
+			if i < len(r) {
+				out[i] = project(r[i], lv)
+			} else {
+				out[i] = lv
+			}
+		}
+		return out
+	}
+	if !reflect.DeepEqual(rendered, live) && sameQuantity(rendered, live) {
+		return rendered
+	}
+	return live
+}
+
+func sameQuantity(a, b interface{}) bool {
+	qa, err := resource.ParseQuantity(fmt.Sprint(a))
+	if err != nil {
+		return false
+	}
+	qb, err := resource.ParseQuantity(fmt.Sprint(b))
+	if err != nil {
+		return false
+	}
+	return qa.Cmp(qb) == 0
+}
+
+// renderedHash returns a hash of the rendered resource, without the last applied configuration which changes with
+// every apply.
+func renderedHash(obj *unstructured.Unstructured) string {
+	u := obj.DeepCopy()
+	annotations := u.GetAnnotations()
+	delete(annotations, lastAppliedConfigAnnotation)
+	delete(annotations, AdoptedDriftAnnotation)
+	u.SetAnnotations(annotations)
+	b, err := json.Marshal(u.Object)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(b))
+}
+
+func objectRef(obj *object.K8sObject) string {
+	if obj.Namespace == "" {
+		return fmt.Sprintf("%s/%s", obj.Kind, obj.Name)
+	}
+	return fmt.Sprintf("%s/%s/%s", obj.Kind, obj.Namespace, obj.Name)
+}
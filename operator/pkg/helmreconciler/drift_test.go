@@ -0,0 +1,263 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helmreconciler
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	v1alpha12 "istio.io/api/operator/v1alpha1"
+	"istio.io/istio/operator/pkg/apis/istio/v1alpha1"
+	"istio.io/istio/operator/pkg/cache"
+	"istio.io/istio/operator/pkg/name"
+	"istio.io/istio/operator/pkg/object"
+	"istio.io/istio/operator/pkg/util/progress"
+)
+
+const driftTestDeployment = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: istiod
+  namespace: istio-system
+spec:
+  replicas: 1
+  template:
+    spec:
+      containers:
+      - name: discovery
+        image: pilot:1.9
+        resources:
+          requests:
+            cpu: 500m
+            memory: 2048Mi
+`
+
+func TestParseDriftPolicies(t *testing.T) {
+	cases := []struct {
+		value   string
+		want    map[name.ComponentName]DriftPolicy
+		wantErr bool
+	}{
+		{
+			value: "",
+			want:  map[name.ComponentName]DriftPolicy{name.PilotComponentName: DriftReportOnly},
+		},
+		{
+			value: "auto-revert",
+			want: map[name.ComponentName]DriftPolicy{
+				name.PilotComponentName:   DriftAutoRevert,
+				name.IngressComponentName: DriftAutoRevert,
+			},
+		},
+		{
+			value: "Pilot=adopt, *=auto-revert",
+			want: map[name.ComponentName]DriftPolicy{
+				name.PilotComponentName:   DriftAdopt,
+				name.IngressComponentName: DriftAutoRevert,
+			},
+		},
+		{value: "revert", wantErr: true},
+		{value: "Pilot=revert", wantErr: true},
+		{value: "Istiod=adopt", wantErr: true},
+		{value: "Pilot=adopt,auto-revert", wantErr: true},
+	}
+	for _, c := range cases {
+		t.Run(c.value, func(t *testing.T) {
+			got, err := ParseDriftPolicies(c.value)
+			if (err != nil) != c.wantErr {
+				t.Fatalf("ParseDriftPolicies(%q) error = %v, wantErr %v", c.value, err, c.wantErr)
+			}
+			for cn, want := range c.want {
+				if p := got.For(cn); p != want {
+					t.Errorf("expected policy %s for %s, got %s", want, cn, p)
+				}
+			}
+		})
+	}
+}
+
+func TestDriftDiff(t *testing.T) {
+	rendered := parseDriftTestObject(t, driftTestDeployment)
+	cases := []struct {
+		name   string
+		modify func(u *unstructured.Unstructured)
+		want   []string
+	}{
+		{
+			name: "defaulted fields",
+			modify: func(u *unstructured.Unstructured) {
+				_ = unstructured.SetNestedField(u.Object, "Always", "spec", "template", "spec", "restartPolicy")
+				_ = unstructured.SetNestedField(u.Object, int64(2), "status", "replicas")
+				u.SetAnnotations(map[string]string{"deployment.kubernetes.io/revision": "1"})
+				setContainerField(u, "resources", map[string]interface{}{
+					"requests": map[string]interface{}{"cpu": "0.5", "memory": "2Gi"},
+				})
+				setContainerField(u, "imagePullPolicy", "IfNotPresent")
+			},
+		},
+		{
+			name: "modified fields",
+			modify: func(u *unstructured.Unstructured) {
+				_ = unstructured.SetNestedField(u.Object, int64(3), "spec", "replicas")
+				setContainerField(u, "image", "pilot:debug")
+			},
+			want: []string{"replicas: 1 -> 3", "image: pilot:1.9 -> pilot:debug"},
+		},
+		{
+			name: "removed field",
+			modify: func(u *unstructured.Unstructured) {
+				setContainerField(u, "resources", map[string]interface{}{
+					"requests": map[string]interface{}{"memory": "2048Mi"},
+				})
+			},
+			want: []string{"cpu: 500m ->"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			live := rendered.DeepCopy()
+			c.modify(live)
+			diff, err := driftDiff(rendered, live)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(c.want) == 0 && diff != "" {
+				t.Errorf("expected no drift, got:\n%s", diff)
+			}
+			for _, want := range c.want {
+				if !strings.Contains(diff, want) {
+					t.Errorf("expected %q in the drift, got:\n%s", want, diff)
+				}
+			}
+		})
+	}
+}
+
+func TestReconcileDrift(t *testing.T) {
+	cases := []struct {
+		policy       string
+		wantReported bool
+		wantReplicas int64
+	}{
+		{policy: "", wantReported: true, wantReplicas: 3},
+		{policy: "Pilot=auto-revert", wantReplicas: 1},
+		{policy: "adopt", wantReplicas: 3},
+	}
+	TestMode = true
+	defer func() {
+		TestMode = false
+	}()
+	for _, c := range cases {
+		t.Run(c.policy, func(t *testing.T) {
+			cache.FlushObjectCaches()
+			cl := &fakeClientWrapper{fake.NewFakeClientWithScheme(runtime.NewScheme())}
+			h := &HelmReconciler{
+				client: cl,
+				opts:   &Options{ProgressLog: progress.NewLog(), DetectDrift: true},
+				iop: &v1alpha1.IstioOperator{
+					ObjectMeta: v1.ObjectMeta{
+						Name:        "test-operator",
+						Namespace:   "istio-operator-test",
+						Annotations: map[string]string{DriftPolicyAnnotation: c.policy},
+					},
+					Spec: &v1alpha12.IstioOperatorSpec{},
+				},
+				countLock:     &sync.Mutex{},
+				prunedKindSet: map[schema.GroupKind]struct{}{},
+			}
+			manifest := name.Manifest{Name: name.PilotComponentName, Content: driftTestDeployment}
+			if _, _, err := h.ApplyManifest(manifest, false); err != nil {
+				t.Fatal(err)
+			}
+			if drifts, err := h.DetectDrift(name.PilotComponentName); err != nil || len(drifts) != 0 {
+				t.Fatalf("expected no drift after applying, got %v, %v", drifts, err)
+			}
+
+			live := getDriftTestDeployment(t, cl)
+			_ = unstructured.SetNestedField(live.Object, int64(3), "spec", "replicas")
+			if err := cl.Update(context.Background(), live); err != nil {
+				t.Fatal(err)
+			}
+
+			status := &v1alpha12.InstallStatus{
+				Status: v1alpha12.InstallStatus_HEALTHY,
+				ComponentStatus: map[string]*v1alpha12.InstallStatus_VersionStatus{
+					string(name.PilotComponentName): {Status: v1alpha12.InstallStatus_HEALTHY},
+				},
+			}
+			h.reconcileDrift(name.ManifestMap{name.PilotComponentName: []string{driftTestDeployment}}, status)
+			if status.Status != v1alpha12.InstallStatus_HEALTHY ||
+				status.ComponentStatus[string(name.PilotComponentName)].Status != v1alpha12.InstallStatus_HEALTHY {
+				t.Errorf("expected the status to stay healthy, got %v", status)
+			}
+			if reported := strings.Contains(status.Message, "Deployment/istio-system/istiod"); reported != c.wantReported {
+				t.Errorf("expected the drifted deployment to be reported %v in the status message, got %q", c.wantReported, status.Message)
+			}
+			replicas, _, _ := unstructured.NestedInt64(getDriftTestDeployment(t, cl).Object, "spec", "replicas")
+			if replicas != c.wantReplicas {
+				t.Errorf("expected %d replicas, got %d", c.wantReplicas, replicas)
+			}
+
+			// Resolved drift is not detected again, and adopted modifications are not applied again.
+			if drifts, err := h.DetectDrift(name.PilotComponentName); err != nil || (len(drifts) != 0) != (c.policy == "") {
+				t.Errorf("expected drift only to be reported again, got %v, %v", drifts, err)
+			}
+			if c.policy == "adopt" {
+				cache.FlushObjectCaches()
+				if _, _, err := h.ApplyManifest(manifest, false); err != nil {
+					t.Fatal(err)
+				}
+				replicas, _, _ := unstructured.NestedInt64(getDriftTestDeployment(t, cl).Object, "spec", "replicas")
+				if replicas != 3 {
+					t.Errorf("expected the adopted replicas to be kept, got %d", replicas)
+				}
+			}
+		})
+	}
+}
+
+func parseDriftTestObject(t *testing.T, y string) *unstructured.Unstructured {
+	t.Helper()
+	obj, err := object.ParseYAMLToK8sObject([]byte(y))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return obj.UnstructuredObject()
+}
+
+func getDriftTestDeployment(t *testing.T, cl client.Client) *unstructured.Unstructured {
+	t.Helper()
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: name.DeploymentStr})
+	if err := cl.Get(context.Background(), client.ObjectKey{Namespace: "istio-system", Name: "istiod"}, u); err != nil {
+		t.Fatal(err)
+	}
+	return u
+}
+
+func setContainerField(u *unstructured.Unstructured, field string, value interface{}) {
+	containers, _, _ := unstructured.NestedSlice(u.Object, "spec", "template", "spec", "containers")
+	containers[0].(map[string]interface{})[field] = value
+	_ = unstructured.SetNestedSlice(u.Object, containers, "spec", "template", "spec", "containers")
+}
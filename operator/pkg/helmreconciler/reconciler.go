@@ -76,6 +76,9 @@ type Options struct {
 	ProgressLog *progress.Log
 	// Force ignores validation errors
 	Force bool
+	// DetectDrift detects the resources modified outside of the operator after reconciling, and handles them with the
+	// policies of DriftPolicyAnnotation.
+	DetectDrift bool
 }
 
 var defaultOptions = &Options{
@@ -147,6 +150,9 @@ func (h *HelmReconciler) Reconcile() (*v1alpha1.InstallStatus, error) {
 	}
 
 	status := h.processRecursive(manifestMap)
+	if h.opts.DetectDrift && !h.opts.DryRun {
+		h.reconcileDrift(manifestMap, status)
+	}
 
 	h.opts.ProgressLog.SetState(progress.StatePruning)
 	pruneErr := h.Prune(manifestMap, false)
@@ -340,6 +346,7 @@ func setStatus(s map[string]*v1alpha1.InstallStatus_VersionStatus, componentName
 // - If one or more components are UPDATING and others are HEALTHY, overall status is UPDATING.
 // - If components are a mix of RECONCILING, UPDATING and HEALTHY, overall status is UPDATING.
 // - If any component is in ERROR state, overall status is ERROR.
+// The result doesn't depend on the order of the components.
 func overallStatus(componentStatus map[string]*v1alpha1.InstallStatus_VersionStatus) v1alpha1.InstallStatus_Status {
 	ret := v1alpha1.InstallStatus_HEALTHY
 	for _, cs := range componentStatus {
@@ -348,10 +355,8 @@ func overallStatus(componentStatus map[string]*v1alpha1.InstallStatus_VersionSta
 			break
 		} else if cs.Status == v1alpha1.InstallStatus_UPDATING {
 			ret = v1alpha1.InstallStatus_UPDATING
-			break
-		} else if cs.Status == v1alpha1.InstallStatus_RECONCILING {
+		} else if cs.Status == v1alpha1.InstallStatus_RECONCILING && ret != v1alpha1.InstallStatus_UPDATING {
 			ret = v1alpha1.InstallStatus_RECONCILING
-			break
 		}
 	}
 	return ret
@@ -0,0 +1,76 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helmreconciler
+
+import (
+	"fmt"
+	"testing"
+
+	"istio.io/api/operator/v1alpha1"
+)
+
+func TestOverallStatus(t *testing.T) {
+	tests := []struct {
+		name       string
+		components []v1alpha1.InstallStatus_Status
+		want       v1alpha1.InstallStatus_Status
+	}{
+		{
+			name: "no components",
+			want: v1alpha1.InstallStatus_HEALTHY,
+		},
+		{
+			name:       "all healthy",
+			components: []v1alpha1.InstallStatus_Status{v1alpha1.InstallStatus_HEALTHY, v1alpha1.InstallStatus_HEALTHY},
+			want:       v1alpha1.InstallStatus_HEALTHY,
+		},
+		{
+			name:       "reconciling",
+			components: []v1alpha1.InstallStatus_Status{v1alpha1.InstallStatus_HEALTHY, v1alpha1.InstallStatus_RECONCILING},
+			want:       v1alpha1.InstallStatus_RECONCILING,
+		},
+		{
+			name:       "updating",
+			components: []v1alpha1.InstallStatus_Status{v1alpha1.InstallStatus_UPDATING, v1alpha1.InstallStatus_HEALTHY},
+			want:       v1alpha1.InstallStatus_UPDATING,
+		},
+		{
+			name: "reconciling and updating",
+			components: []v1alpha1.InstallStatus_Status{v1alpha1.InstallStatus_RECONCILING, v1alpha1.InstallStatus_UPDATING,
+				v1alpha1.InstallStatus_HEALTHY},
+			want: v1alpha1.InstallStatus_UPDATING,
+		},
+		{
+			name: "error",
+			components: []v1alpha1.InstallStatus_Status{v1alpha1.InstallStatus_RECONCILING, v1alpha1.InstallStatus_UPDATING,
+				v1alpha1.InstallStatus_ERROR},
+			want: v1alpha1.InstallStatus_ERROR,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			componentStatus := make(map[string]*v1alpha1.InstallStatus_VersionStatus)
+			for i, s := range tt.components {
+				componentStatus[fmt.Sprintf("component%d", i)] = &v1alpha1.InstallStatus_VersionStatus{Status: s}
+			}
+			// The map iteration order is random, the status is computed several times to cover the orders.
+			for i := 0; i < 20; i++ {
+				if got := overallStatus(componentStatus); got != tt.want {
+					t.Fatalf("overallStatus() = %s, want %s", got, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -41,6 +41,13 @@ var (
 	// ResourceKindLabel indicates the kind of resource owned
 	// or created or updated or deleted or pruned by operator.
 	ResourceKindLabel = monitoring.MustCreateLabel("kind")
+
+	// CanaryPhaseLabel indicates the phase of a canary upgrade.
+	CanaryPhaseLabel = monitoring.MustCreateLabel("phase")
+
+	// DriftPolicyLabel indicates the policy applied to a
+	// resource modified outside of the operator.
+	DriftPolicyLabel = monitoring.MustCreateLabel("policy")
 )
 
 // MergeErrorType describes the class of errors that could
@@ -198,6 +205,31 @@ var (
 		"Number of times a legacy API path is translated",
 	)
 
+	// CanaryPhaseTotal counts the canary upgrades entering
+	// each phase, like the number of upgrades rolled back.
+	CanaryPhaseTotal = monitoring.NewSum(
+		"canary_phase_total",
+		"Number of canary upgrades entering a phase",
+		monitoring.WithLabels(CanaryPhaseLabel),
+	)
+
+	// DriftedResourceTotal indicates the number of resources
+	// of a component modified outside of the operator, which
+	// are reported and not reverted or adopted.
+	DriftedResourceTotal = monitoring.NewGauge(
+		"drifted_resource_total",
+		"Number of resources modified outside of the operator",
+		monitoring.WithLabels(ComponentNameLabel),
+	)
+
+	// DriftResolvedTotal counts the resources modified outside
+	// of the operator which were reverted or adopted.
+	DriftResolvedTotal = monitoring.NewSum(
+		"drift_resolved_total",
+		"Number of resources modified outside of the operator reverted or adopted",
+		monitoring.WithLabels(ComponentNameLabel, DriftPolicyLabel),
+	)
+
 	// CacheFlushTotal counts number of cache flushes.
 	CacheFlushTotal = monitoring.NewSum(
 		"cache_flush_total",
@@ -224,6 +256,9 @@ func init() {
 		ManifestPatchErrorTotal,
 		ManifestRenderErrorTotal,
 		LegacyPathTranslationTotal,
+		CanaryPhaseTotal,
+		DriftedResourceTotal,
+		DriftResolvedTotal,
 		CacheFlushTotal,
 	)
 
@@ -56,3 +56,29 @@ func CountManifestRender(name name.ComponentName) {
 		With(ComponentNameLabel.Value(string(name))).
 		Increment()
 }
+
+// CountCanaryPhase increments the count of canary upgrades
+// entering the given phase.
+func CountCanaryPhase(phase string) {
+	CanaryPhaseTotal.
+		With(CanaryPhaseLabel.Value(phase)).
+		Increment()
+}
+
+// CountDriftResolved increments the count of resources of the
+// component modified outside of the operator and resolved with
+// the given policy.
+func CountDriftResolved(cn name.ComponentName, policy string) {
+	DriftResolvedTotal.
+		With(ComponentNameLabel.Value(string(cn))).
+		With(DriftPolicyLabel.Value(policy)).
+		Increment()
+}
+
+// RecordDriftedResources records the number of resources of the
+// component modified outside of the operator and not resolved.
+func RecordDriftedResources(cn name.ComponentName, count int) {
+	DriftedResourceTotal.
+		With(ComponentNameLabel.Value(string(cn))).
+		Record(float64(count))
+}
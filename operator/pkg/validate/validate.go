@@ -168,6 +168,12 @@ func validateLeaf(validations map[string]ValidatorFunc, path util.Path, val inte
 }
 
 func validateMeshConfig(path util.Path, root interface{}) util.Errors {
+	// The certificate policies are not a MeshConfig field, they are validated by Istiod.
+	if m, ok := root.(map[string]interface{}); ok {
+		if _, ok := m[mesh.CertificatePoliciesKey]; ok {
+			root = withoutKey(m, mesh.CertificatePoliciesKey)
+		}
+	}
 	vs, err := yaml.Marshal(root)
 	if err != nil {
 		return util.Errors{err}
@@ -184,6 +190,17 @@ func validateMeshConfig(path util.Path, root interface{}) util.Errors {
 	return nil
 }
 
+// withoutKey returns a copy of the map without the key.
+func withoutKey(m map[string]interface{}, key string) map[string]interface{} {
+	out := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		if k != key {
+			out[k] = v
+		}
+	}
+	return out
+}
+
 func validateHub(path util.Path, val interface{}) util.Errors {
 	return validateWithRegex(path, val, ReferenceRegexp)
 }
@@ -161,6 +161,15 @@ values:
 meshConfig:
   defaultConfig:
     discoveryAddress: istiod:15012
+`,
+		},
+		{
+			desc: "Mesh config with certificate policies",
+			yamlStr: `
+meshConfig:
+  certificatePolicies:
+  - name: default
+    maxCertTTL: 24h
 `,
 		},
 	}
@@ -113,6 +113,23 @@ var (
 	).Get()
 
 	caProviderEnv = env.RegisterStringVar("CA_PROVIDER", "Citadel", "name of authentication provider").Get()
+
+	vaultAddrEnv = env.RegisterStringVar("VAULT_ADDR", "",
+		"Address of the Vault server, when CA_PROVIDER is VaultCA").Get()
+	vaultAuthMethodEnv = env.RegisterStringVar("VAULT_AUTH_METHOD", "kubernetes",
+		"Vault auth method the workloads log in with, kubernetes or approle").Get()
+	vaultAuthPathEnv = env.RegisterStringVar("VAULT_AUTH_PATH", "",
+		"Mount path of the Vault auth method. Defaults to auth/<VAULT_AUTH_METHOD>").Get()
+	vaultRoleEnv = env.RegisterStringVar("VAULT_ROLE", "",
+		"Vault role the workloads log in with, for the kubernetes auth method").Get()
+	vaultAppRoleRoleIDEnv = env.RegisterStringVar("VAULT_APPROLE_ROLE_ID", "",
+		"Role ID the workloads log in with, for the approle auth method").Get()
+	vaultAppRoleSecretIDPathEnv = env.RegisterStringVar("VAULT_APPROLE_SECRET_ID_PATH", "",
+		"File containing the secret ID the workloads log in with, for the approle auth method").Get()
+	vaultSignCsrPathEnv = env.RegisterStringVar("VAULT_SIGN_CSR_PATH", "pki/sign/istio",
+		"Path of the Vault PKI endpoint signing the workload CSRs").Get()
+	vaultTLSRootCertEnv = env.RegisterStringVar("VAULT_TLS_ROOT_CERT", "",
+		"File containing the root certificate of the Vault server. If unset, the system roots are used").Get()
 	// TODO: default to same as discovery address
 	caEndpointEnv = env.RegisterStringVar("CA_ADDR", "", "Address of the spiffee certificate provider. Defaults to discoveryAddress").Get()
 
@@ -131,10 +148,15 @@ var (
 	pkcs8KeysEnv                = env.RegisterBoolVar("PKCS8_KEY", false,
 		"Whether to generate PKCS#8 private keys").Get()
 	eccSigAlgEnv        = env.RegisterStringVar("ECC_SIGNATURE_ALGORITHM", "", "The type of ECC signature algorithm to use when generating private keys").Get()
+	eccCurveEnv         = env.RegisterStringVar("ECC_CURVE", "P256", "The elliptic curve to use when generating ECC private keys, P256 or P384").Get()
 	fileMountedCertsEnv = env.RegisterBoolVar("FILE_MOUNTED_CERTS", false, "").Get()
 	useTokenForCSREnv   = env.RegisterBoolVar("USE_TOKEN_FOR_CSR", false, "CSR requires a token").Get()
 	credFetcherTypeEnv  = env.RegisterStringVar("CREDENTIAL_FETCHER_TYPE", "",
-		"The type of the credential fetcher. Currently supported types include GoogleComputeEngine").Get()
+		"The type of the credential fetcher. Currently supported types include GoogleComputeEngine, AWS and Azure").Get()
+	credAudienceEnv = env.RegisterStringVar("CREDENTIAL_AUDIENCE", "",
+		"The audience of the platform credential. For AWS, the server ID the sts:GetCallerIdentity request is signed "+
+			"for, the AWS_IAM_SERVER_ID of Istiod. For Azure, the App ID URI of the Azure AD application the managed "+
+			"identity token is requested for. Required for AWS and Azure, defaults to the trust domain for GCE").Get()
 	credIdentityProvider = env.RegisterStringVar("CREDENTIAL_IDENTITY_PROVIDER", "GoogleComputeEngine",
 		"The identity provider for credential. Currently default supported identity provider is GoogleComputeEngine").Get()
 	proxyXDSViaAgent = env.RegisterBoolVar("PROXY_XDS_VIA_AGENT", true,
@@ -258,10 +280,19 @@ var (
 
 			secOpts.EnableWorkloadSDS = true
 			secOpts.CAProviderName = caProviderEnv
+			secOpts.VaultAddress = vaultAddrEnv
+			secOpts.VaultAuthMethod = vaultAuthMethodEnv
+			secOpts.VaultAuthPath = vaultAuthPathEnv
+			secOpts.VaultRole = vaultRoleEnv
+			secOpts.VaultRoleID = vaultAppRoleRoleIDEnv
+			secOpts.VaultSecretIDPath = vaultAppRoleSecretIDPathEnv
+			secOpts.VaultSignCsrPath = vaultSignCsrPathEnv
+			secOpts.VaultTLSRootCert = vaultTLSRootCertEnv
 
 			secOpts.TrustDomain = trustDomainEnv
 			secOpts.Pkcs8Keys = pkcs8KeysEnv
 			secOpts.ECCSigAlg = eccSigAlgEnv
+			secOpts.ECCCurve = eccCurveEnv
 			secOpts.RecycleInterval = staledConnectionRecycleIntervalEnv
 			secOpts.SecretTTL = secretTTLEnv
 			secOpts.SecretRotationGracePeriodRatio = secretRotationGracePeriodRatioEnv
@@ -270,11 +301,16 @@ var (
 			// Disable the secret eviction for istio agent.
 			secOpts.EvictionDuration = 0
 
-			// TODO (liminw): CredFetcher is a general interface. In 1.7, we limit the use on GCE only because
-			// GCE is the only supported plugin at the moment.
-			if credFetcherTypeEnv == security.GCE {
+			// CredFetcher is limited to the platforms with a plugin: GCE, AWS and Azure.
+			if credFetcherTypeEnv == security.GCE || credFetcherTypeEnv == security.AWS || credFetcherTypeEnv == security.Azure {
 				secOpts.CredIdentityProvider = credIdentityProvider
-				credFetcher, err := credentialfetcher.NewCredFetcher(credFetcherTypeEnv, secOpts.TrustDomain, jwtPath, secOpts.CredIdentityProvider)
+				audience := secOpts.TrustDomain
+				if credAudienceEnv != "" {
+					audience = credAudienceEnv
+				} else if credFetcherTypeEnv != security.GCE {
+					return fmt.Errorf("CREDENTIAL_AUDIENCE is required for the %s credential fetcher", credFetcherTypeEnv)
+				}
+				credFetcher, err := credentialfetcher.NewCredFetcher(credFetcherTypeEnv, audience, jwtPath, secOpts.CredIdentityProvider)
 				if err != nil {
 					return fmt.Errorf("failed to create credential fetcher: %v", err)
 				}
@@ -0,0 +1,141 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bootstrap
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	"istio.io/istio/security/pkg/server/ca/audit"
+	"istio.io/pkg/env"
+	"istio.io/pkg/log"
+)
+
+var (
+	caAuditLogKeyFile = env.RegisterStringVar("CA_AUDIT_LOG_KEY_FILE", "",
+		"File containing the secret key the CA audit records are authenticated with, by an HMAC-SHA256 chain. "+
+			"It should be mounted from a Secret only Istiod and the auditors can read, and is required to enable "+
+			"the CA audit log.").Get()
+
+	caAuditLogQueueSize = env.RegisterIntVar("CA_AUDIT_LOG_QUEUE_SIZE", 1000,
+		"Max number of CA audit records queued while the sinks are busy. The records logged when the queue is "+
+			"full are dropped and counted by citadel_server_audit_log_dropped_count.").Get()
+
+	caAuditLogFile = env.RegisterStringVar("CA_AUDIT_LOG_FILE", "",
+		"File the audit records of the certificate signing requests are written to, as JSON lines. "+
+			"If empty, the records are not written to a file.").Get()
+
+	caAuditLogMaxSize = env.RegisterIntVar("CA_AUDIT_LOG_MAX_SIZE_MB", 100,
+		"Size in megabytes at which the CA audit log file is rotated.").Get()
+
+	caAuditLogMaxBackups = env.RegisterIntVar("CA_AUDIT_LOG_MAX_BACKUPS", 10,
+		"Max number of rotated CA audit log files to keep. All are kept if 0.").Get()
+
+	caAuditALSAddress = env.RegisterStringVar("CA_AUDIT_ALS_ADDRESS", "",
+		"Address of a gRPC access log service the audit records of the certificate signing requests are "+
+			"streamed to. If empty, the records are not streamed.").Get()
+
+	caAuditALSRootCert = env.RegisterStringVar("CA_AUDIT_ALS_ROOT_CERT", "",
+		"File containing the root certificate of the CA audit access log service. If empty, the connection "+
+			"is not encrypted.").Get()
+)
+
+// initCAAuditLog creates the audit log of the certificate signing requests, when a sink is configured.
+func (s *Server) initCAAuditLog() error {
+	if caAuditLogFile == "" && caAuditALSAddress == "" {
+		return nil
+	}
+	if caAuditLogKeyFile == "" {
+		return fmt.Errorf("CA_AUDIT_LOG_KEY_FILE is required to enable the CA audit log")
+	}
+	key, err := ioutil.ReadFile(caAuditLogKeyFile)
+	if err != nil {
+		return fmt.Errorf("failed to read the CA audit log key: %v", err)
+	}
+	if len(key) == 0 {
+		return fmt.Errorf("the CA audit log key %s is empty", caAuditLogKeyFile)
+	}
+	instance := podNameVar.Get()
+	if instance == "" {
+		instance, _ = os.Hostname()
+	}
+	var sinks []audit.Sink
+	if caAuditLogFile != "" {
+		sink, err := audit.NewFileSink(caAuditLogFile, caAuditLogMaxSize, caAuditLogMaxBackups)
+		if err != nil {
+			return err
+		}
+		sinks = append(sinks, sink)
+	}
+	if caAuditALSAddress != "" {
+		opt := grpc.WithInsecure()
+		if caAuditALSRootCert != "" {
+			creds, err := credentials.NewClientTLSFromFile(caAuditALSRootCert, "")
+			if err != nil {
+				return fmt.Errorf("failed to load the root certificate of the CA audit access log service: %v", err)
+			}
+			opt = grpc.WithTransportCredentials(creds)
+		}
+		sink, err := audit.NewALSSink(instance, caAuditALSAddress, opt)
+		if err != nil {
+			return fmt.Errorf("failed to connect to the CA audit access log service: %v", err)
+		}
+		sinks = append(sinks, sink)
+	}
+	s.caAuditLog = audit.NewLogger(instance, key, caAuditLogQueueSize, sinks...)
+	if caAuditLogFile != "" {
+		help := "Audit records of the certificates signed by the Istio CA, filtered by the identity, serial, " +
+			"outcome, client, since, until and limit parameters"
+		s.XDSServer.AddDebugHandler(s.monitoringMux, "/debug/ca_auditz", help, s.caAuditz)
+		// The debug handlers are also served by the readiness mux, unless it is the monitoring mux.
+		if s.httpMux != s.monitoringMux {
+			s.XDSServer.AddDebugHandler(s.httpMux, "/debug/ca_auditz", help, s.caAuditz)
+		}
+	}
+	s.addStartFunc(func(stop <-chan struct{}) error {
+		go func() {
+			<-stop
+			s.caAuditLog.Close()
+		}()
+		return nil
+	})
+	log.Infof("CA audit log enabled, instance %s", instance)
+	return nil
+}
+
+// caAuditz returns the audit records of the certificate signing requests handled by this Istiod,
+// as JSON lines ordered by time.
+func (s *Server) caAuditz(w http.ResponseWriter, req *http.Request) {
+	filter, err := audit.ParseFilter(req.URL.Query())
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = fmt.Fprint(w, err.Error())
+		return
+	}
+	records, err := s.caAuditLog.Query(filter)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = fmt.Fprint(w, err.Error())
+		return
+	}
+	if err := audit.Write(w, records); err != nil {
+		log.Warnf("failed to write the CA audit records: %v", err)
+	}
+}
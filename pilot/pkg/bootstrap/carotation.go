@@ -0,0 +1,87 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bootstrap
+
+import (
+	"os"
+	"path"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/xds"
+	v3 "istio.io/istio/pilot/pkg/xds/v3"
+	"istio.io/istio/security/pkg/pki/ca"
+	"istio.io/pkg/log"
+)
+
+// caRotationCheckInterval is the interval between the checks of the phase of a CA rotation.
+const caRotationCheckInterval = time.Minute
+
+// initCARotation distributes the root certs trusted by the CA to the agents and, when the CA certificates
+// are plugged in with the cacerts secret, rotates them when the secret is updated. The root certs of the
+// new CA are distributed before it signs, so the rotation doesn't break the trust between the proxies.
+func (s *Server) initCARotation(namespace string) {
+	trustBundle := &xds.TrustBundleGenerator{CA: s.CA.GetCAKeyCertBundle()}
+	if s.federatedBundles != nil {
+		trustBundle.Federated = s.federatedBundles
+	}
+	s.XDSServer.Generators[v3.TrustBundleType] = trustBundle
+	if s.kubeClient == nil {
+		return
+	}
+	if _, err := os.Stat(path.Join(LocalCertDir.Get(), "ca-key.pem")); err != nil {
+		// The CA certificates are self-signed, and rotated by the self-signed root cert rotator.
+		return
+	}
+
+	rootGracePeriod := caRotationRootGracePeriod.Get()
+	if rootGracePeriod <= 0 {
+		rootGracePeriod = maxWorkloadCertTTL.Get()
+	}
+	rotator := ca.NewPluggedCertRotator(&ca.PluggedCertRotatorConfig{
+		Client:                       s.kubeClient.Kube().CoreV1(),
+		Namespace:                    namespace,
+		CheckInterval:                caRotationCheckInterval,
+		TrustBundlePropagationPeriod: caRotationTrustBundlePropagationPeriod.Get(),
+		RootGracePeriod:              rootGracePeriod,
+	}, s.CA, func() {
+		log.Info("CA certificates updated, pushing the trust bundle")
+		s.XDSServer.ConfigUpdate(&model.PushRequest{
+			Full:   true,
+			Reason: []model.TriggerReason{model.SecretTrigger},
+		})
+	})
+	s.kubeClient.KubeInformer().Core().V1().Secrets().Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: func(obj interface{}) bool {
+			key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+			return err == nil && key == namespace+"/"+ca.CACertsSecret
+		},
+		Handler: cache.ResourceEventHandlerFuncs{
+			AddFunc: func(obj interface{}) {
+				rotator.UpdateCACerts(obj.(*v1.Secret))
+			},
+			UpdateFunc: func(_, obj interface{}) {
+				rotator.UpdateCACerts(obj.(*v1.Secret))
+			},
+		},
+	})
+	s.addStartFunc(func(stop <-chan struct{}) error {
+		go rotator.Run(stop)
+		return nil
+	})
+}
@@ -0,0 +1,78 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bootstrap
+
+import (
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	caserver "istio.io/istio/security/pkg/server/ca"
+	"istio.io/pkg/log"
+)
+
+// initCertPolicies watches the certificate policies in the mesh config ConfigMap. The policies restrict the
+// lifetime, the key algorithm and the SANs of the certificates signed for the workloads they select. They are
+// read from the ConfigMap also when the mesh config is mounted from it, as the mesh config parsing drops them.
+func (s *Server) initCertPolicies(namespace, meshConfigMap string) {
+	if s.kubeClient == nil {
+		return
+	}
+	namespaces := s.kubeClient.KubeInformer().Core().V1().Namespaces()
+	s.certPolicies = caserver.NewCertPolicies(func(name string) (map[string]string, error) {
+		ns, err := namespaces.Lister().Get(name)
+		if err != nil {
+			return nil, err
+		}
+		return ns.Labels, nil
+	})
+	s.kubeClient.KubeInformer().Core().V1().ConfigMaps().Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: func(obj interface{}) bool {
+			key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+			return err == nil && key == namespace+"/"+meshConfigMap
+		},
+		Handler: cache.ResourceEventHandlerFuncs{
+			AddFunc: func(obj interface{}) {
+				s.updateCertPolicies(obj.(*v1.ConfigMap))
+			},
+			UpdateFunc: func(_, obj interface{}) {
+				s.updateCertPolicies(obj.(*v1.ConfigMap))
+			},
+			DeleteFunc: func(interface{}) {
+				s.updateCertPolicies(nil)
+			},
+		},
+	})
+}
+
+// updateCertPolicies sets the certificate policies from the mesh config ConfigMap. Invalid policies are ignored,
+// keeping the previous ones.
+func (s *Server) updateCertPolicies(cm *v1.ConfigMap) {
+	var policies []caserver.CertPolicy
+	if cm != nil {
+		var err error
+		if policies, err = caserver.ParseCertPolicies([]byte(cm.Data[configMapKey])); err != nil {
+			log.Errorf("invalid certificate policies in config map %s/%s: %v", cm.Namespace, cm.Name, err)
+			return
+		}
+		if s.RA != nil {
+			if err := caserver.ValidateExternalCA(policies); err != nil {
+				log.Errorf("invalid certificate policies in config map %s/%s: %v", cm.Namespace, cm.Name, err)
+				return
+			}
+		}
+	}
+	s.certPolicies.Set(policies)
+	log.Infof("%d certificate policies enforced by the CA", len(policies))
+}
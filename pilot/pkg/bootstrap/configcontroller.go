@@ -239,6 +239,9 @@ func (s *Server) initStatusController(args *PilotArgs, writeStatus bool) {
 		PodName:        args.PodName,
 	}
 	s.statusReporter.Init(s.environment.GetLedger())
+	if features.EnableDistributionTracking && features.ConfigHistoryRevisions > 0 {
+		s.statusReporter.History = model.NewConfigHistory(features.ConfigHistoryRevisions, features.ConfigHistoryDeletedRetention)
+	}
 	s.addTerminatingStartFunc(func(stop <-chan struct{}) error {
 		if writeStatus {
 			s.statusReporter.Start(s.kubeClient, args.Namespace, args.PodName, stop)
@@ -246,6 +249,7 @@ func (s *Server) initStatusController(args *PilotArgs, writeStatus bool) {
 		return nil
 	})
 	s.XDSServer.StatusReporter = s.statusReporter
+	s.XDSServer.ConfigHistory = s.statusReporter.History
 	if writeStatus {
 		s.addTerminatingStartFunc(func(stop <-chan struct{}) error {
 			controller := status.NewController(*s.kubeRestConfig, args.Namespace)
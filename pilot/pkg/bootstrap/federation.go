@@ -0,0 +1,41 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bootstrap
+
+import (
+	"crypto/x509"
+
+	"istio.io/istio/pilot/pkg/features"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/pkg/log"
+)
+
+// initFederatedTrustBundles refreshes the root certs of the federated trust domains from their SPIFFE
+// bundle endpoints. When they change, they are updated in the peer cert verifier of Istiod and pushed to
+// the proxies with the trust bundle of the CA.
+func (s *Server) initFederatedTrustBundles() {
+	s.federatedBundles.AddHandler(func(trustDomain string, certs []*x509.Certificate) {
+		s.peerCertVerifier.SetMapping(trustDomain, certs)
+		log.Infof("root certs of trust domain %s updated, pushing the trust bundle", trustDomain)
+		s.XDSServer.ConfigUpdate(&model.PushRequest{
+			Full:   true,
+			Reason: []model.TriggerReason{model.SecretTrigger},
+		})
+	})
+	s.addStartFunc(func(stop <-chan struct{}) error {
+		go s.federatedBundles.Run(features.SpiffeBundleRefreshInterval, stop)
+		return nil
+	})
+}
@@ -32,12 +32,14 @@ import (
 	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 
 	"istio.io/istio/pilot/pkg/features"
+	securityModel "istio.io/istio/pilot/pkg/security/model"
 	"istio.io/istio/pilot/pkg/serviceregistry/kube/controller"
 	"istio.io/istio/pkg/config/constants"
 	kubelib "istio.io/istio/pkg/kube"
 	"istio.io/istio/security/pkg/cmd"
 	"istio.io/istio/security/pkg/pki/ca"
 	"istio.io/istio/security/pkg/pki/ra"
+	"istio.io/istio/security/pkg/pki/vault"
 	caserver "istio.io/istio/security/pkg/server/ca"
 	"istio.io/istio/security/pkg/server/ca/authenticate"
 	"istio.io/pkg/env"
@@ -52,6 +54,8 @@ type caOptions struct {
 	TrustDomain    string
 	Namespace      string
 	Authenticators []authenticate.Authenticator
+	// MeshConfigMap is the name of the ConfigMap with the mesh config, which has the certificate policies.
+	MeshConfigMap string
 }
 
 // Based on istio_ca main - removing creation of Secrets with private keys in all namespaces and install complexity.
@@ -130,14 +134,69 @@ var (
 	caRSAKeySize = env.RegisterIntVar("CITADEL_SELF_SIGNED_CA_RSA_KEY_SIZE", 2048,
 		"Specify the RSA key size to use for self-signed Istio CA certificates.")
 
+	caRotationTrustBundlePropagationPeriod = env.RegisterDurationVar("CA_ROTATION_TRUST_BUNDLE_PROPAGATION_PERIOD",
+		10*time.Minute,
+		"When the plugged-in CA certificates of the cacerts secret are rotated, how long the root certificates "+
+			"of both the old and the new CA are distributed to the proxies before the new CA signs.")
+
+	caRotationRootGracePeriod = env.RegisterDurationVar("CA_ROTATION_ROOT_GRACE_PERIOD", 0,
+		"When the plugged-in CA certificates of the cacerts secret are rotated, how long the old root "+
+			"certificates are trusted once the new CA signs. It defaults to MAX_WORKLOAD_CERT_TTL, "+
+			"the longest lifetime of the certificates signed by the old CA.")
+
 	//TODO: Likely to be removed and added to mesh config
 	externalCaType = env.RegisterStringVar("EXTERNAL_CA", "",
-		"External CA Integration Type. Permitted Values are ISTIOD_RA_KUBERNETES_API or "+
-			"ISTIOD_RA_ISTIO_API").Get()
+		"External CA Integration Type. Permitted Values are ISTIOD_RA_KUBERNETES_API, "+
+			"ISTIOD_RA_ISTIO_API or ISTIOD_RA_VAULT_API").Get()
 
 	//TODO: Likely to be removed and added to mesh config
 	k8sSigner = env.RegisterStringVar("K8S_SIGNER", "",
 		"Kubernates CA Signer type. Valid from Kubernates 1.18").Get()
+
+	vaultAddr = env.RegisterStringVar("VAULT_ADDR", "",
+		"Address of the Vault server signing the workload certificates, when EXTERNAL_CA is ISTIOD_RA_VAULT_API.").Get()
+
+	vaultTLSRootCert = env.RegisterStringVar("VAULT_TLS_ROOT_CERT", "",
+		"File containing the root certificate of the Vault server. If unset, the system roots are used.").Get()
+
+	vaultAuthMethod = env.RegisterStringVar("VAULT_AUTH_METHOD", string(vault.AuthKubernetes),
+		"Vault auth method used by Istiod, kubernetes or approle.").Get()
+
+	vaultAuthPath = env.RegisterStringVar("VAULT_AUTH_PATH", "",
+		"Mount path of the Vault auth method. Defaults to auth/<VAULT_AUTH_METHOD>.").Get()
+
+	vaultRole = env.RegisterStringVar("VAULT_ROLE", "",
+		"Vault role Istiod logs in with, for the kubernetes auth method.").Get()
+
+	vaultAppRoleRoleID = env.RegisterStringVar("VAULT_APPROLE_ROLE_ID", "",
+		"Role ID Istiod logs in with, for the approle auth method.").Get()
+
+	vaultAppRoleSecretIDPath = env.RegisterStringVar("VAULT_APPROLE_SECRET_ID_PATH", "",
+		"File containing the secret ID Istiod logs in with, for the approle auth method.").Get()
+
+	vaultSignCSRPath = env.RegisterStringVar("VAULT_SIGN_CSR_PATH", "pki/sign/istio",
+		"Path of the Vault PKI endpoint signing the workload CSRs.").Get()
+
+	awsIAMServerID = env.RegisterStringVar("AWS_IAM_SERVER_ID", "",
+		"Server ID the EC2 VMs sign their sts:GetCallerIdentity requests for, the CREDENTIAL_AUDIENCE of their agents. "+
+			"If set, EC2 VMs can authenticate with a request signed with the credentials of their IAM role.").Get()
+
+	awsIAMIdentities = env.RegisterStringVar("AWS_IAM_IDENTITIES", "",
+		"Comma separated list of roleArn=namespace/serviceaccount or assumedRoleArn=namespace/serviceaccount, "+
+			"mapping the IAM roles of EC2 VMs, or a single VM with the arn:aws:sts::<account>:assumed-role/<role>/<instanceId> "+
+			"ARN, to workload identities.").Get()
+
+	azureTenantID = env.RegisterStringVar("AZURE_TENANT_ID", "",
+		"Azure AD tenant issuing the managed identity tokens of Azure VMs. "+
+			"If set, Azure VMs can authenticate with the token of their managed identity.").Get()
+
+	azureIdentityAudience = env.RegisterStringVar("AZURE_IDENTITY_AUDIENCE", "",
+		"Audience of the managed identity tokens of Azure VMs, the App ID URI requested by the agents. "+
+			"Required with AZURE_TENANT_ID.").Get()
+
+	azureManagedIdentities = env.RegisterStringVar("AZURE_MANAGED_IDENTITIES", "",
+		"Comma separated list of objectId=namespace/serviceaccount, mapping the managed identities of Azure VMs "+
+			"to workload identities.").Get()
 )
 
 // EnableCA returns whether CA functionality is enabled in istiod.
@@ -217,11 +276,42 @@ func (s *Server) RunCA(grpc *grpc.Server, ca caserver.CertificateAuthority, opts
 		}
 	}
 
+	caServer.Authenticators = append(caServer.Authenticators, platformAuthenticators(opts.TrustDomain)...)
+	caServer.Policies = s.certPolicies
+	caServer.Audit = s.caAuditLog
+
 	caServer.Register(grpc)
 
 	log.Info("Istiod CA has started")
 }
 
+// platformAuthenticators returns the authenticators of the VMs using platform credentials, when configured.
+func platformAuthenticators(trustDomain string) []authenticate.Authenticator {
+	var authenticators []authenticate.Authenticator
+	if awsIAMServerID != "" {
+		identities, err := authenticate.ParsePlatformIdentities(awsIAMIdentities)
+		if err != nil {
+			log.Errorf("failed to create the AWS IAM authenticator: %v", err)
+		} else {
+			authenticators = append(authenticators, authenticate.NewAWSIAMAuthenticator(awsIAMServerID, trustDomain, identities))
+			log.Info("Using AWS IAM authentication")
+		}
+	}
+	if azureTenantID != "" {
+		identities, err := authenticate.ParsePlatformIdentities(azureManagedIdentities)
+		if err != nil {
+			log.Errorf("failed to create the Azure managed identity authenticator: %v", err)
+		} else if azureIdentityAudience == "" {
+			log.Errorf("failed to create the Azure managed identity authenticator: AZURE_IDENTITY_AUDIENCE is not set")
+		} else {
+			authenticators = append(authenticators, authenticate.NewAzureManagedIdentityAuthenticator(
+				azureTenantID, azureIdentityAudience, trustDomain, identities))
+			log.Info("Using Azure managed identity authentication")
+		}
+	}
+	return authenticators
+}
+
 // detectAuthEnv will use the JWT token that is mounted in istiod to set the default audience
 // and trust domain for Istiod, if not explicitly defined.
 // K8S will use the same kind of tokens for the pods, and the value in istiod's own token is
@@ -409,6 +499,28 @@ func (s *Server) createIstioCA(client corev1.CoreV1Interface, opts *caOptions) (
 	return istioCA, nil
 }
 
+// newVaultConfig returns the configuration of the Vault client used by the Vault RA.
+func newVaultConfig() (vault.Config, error) {
+	config := vault.Config{
+		Address:      vaultAddr,
+		AuthMethod:   vault.AuthMethod(vaultAuthMethod),
+		AuthPath:     vaultAuthPath,
+		Role:         vaultRole,
+		JWTPath:      securityModel.K8sSAJwtFileName,
+		RoleID:       vaultAppRoleRoleID,
+		SecretIDPath: vaultAppRoleSecretIDPath,
+		SignCSRPath:  vaultSignCSRPath,
+	}
+	if vaultTLSRootCert != "" {
+		rootCert, err := ioutil.ReadFile(vaultTLSRootCert)
+		if err != nil {
+			return config, fmt.Errorf("failed to read the vault TLS root certificate: %v", err)
+		}
+		config.TLSRootCert = rootCert
+	}
+	return config, nil
+}
+
 // createIstioRA initializes the Istio RA signing functionality.
 // the caOptions defines the external provider
 func (s *Server) createIstioRA(client kubelib.Client,
@@ -427,6 +539,13 @@ func (s *Server) createIstioRA(client kubelib.Client,
 		VerifyAppendCA: true,
 		K8sClient:      client.CertificatesV1beta1(),
 	}
+	if opts.ExternalCAType == ra.ExtCAVault {
+		vaultConfig, err := newVaultConfig()
+		if err != nil {
+			return nil, err
+		}
+		raOpts.Vault = vaultConfig
+	}
 	return ra.NewIstioRA(raOpts)
 
 }
@@ -0,0 +1,128 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bootstrap
+
+import (
+	"context"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	"istio.io/api/security/v1beta1"
+	"istio.io/istio/pilot/pkg/features"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pkg/config/schema/gvk"
+	"istio.io/pkg/log"
+)
+
+// jwksConfigMapKey is the key of the persisted JWKS in the config map.
+const jwksConfigMapKey = "jwks.json"
+
+// configMapJwksStore persists the JWKS fetched by the resolver in a config map.
+type configMapJwksStore struct {
+	client    corev1.ConfigMapInterface
+	namespace string
+	name      string
+}
+
+func (s *configMapJwksStore) Load() (*model.JwksCache, error) {
+	cm, err := s.client.Get(context.TODO(), s.name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	data, found := cm.Data[jwksConfigMapKey]
+	if !found {
+		return nil, nil
+	}
+	return model.DecodeJwksCache([]byte(data))
+}
+
+func (s *configMapJwksStore) Save(c *model.JwksCache) error {
+	data, err := model.EncodeJwksCache(c)
+	if err != nil {
+		return err
+	}
+	cm, err := s.client.Get(context.TODO(), s.name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		_, err = s.client.Create(context.TODO(), &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: s.name, Namespace: s.namespace},
+			Data:       map[string]string{jwksConfigMapKey: string(data)},
+		}, metav1.CreateOptions{})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	if cm.Data[jwksConfigMapKey] == string(data) {
+		return nil
+	}
+	cm = cm.DeepCopy()
+	if cm.Data == nil {
+		cm.Data = map[string]string{}
+	}
+	cm.Data[jwksConfigMapKey] = string(data)
+	_, err = s.client.Update(context.TODO(), cm, metav1.UpdateOptions{})
+	return err
+}
+
+// initJwksCache persists the JWT public keys fetched by the JWKS resolver, and loads the keys persisted by a
+// previous instance, so that RequestAuthentication policies keep working when Istiod restarts while the
+// identity providers are unreachable.
+func (s *Server) initJwksCache(namespace string) {
+	var store model.JwksStore
+	switch {
+	case features.JwksCacheFile != "":
+		store = model.NewFileJwksStore(features.JwksCacheFile)
+	case features.JwksCacheConfigMap != "" && s.kubeClient != nil:
+		store = &configMapJwksStore{
+			client:    s.kubeClient.CoreV1().ConfigMaps(namespace),
+			namespace: namespace,
+			name:      features.JwksCacheConfigMap,
+		}
+	}
+	if store != nil {
+		// Loaded before the first push, which would otherwise fetch the keys from the network.
+		if err := model.GetJwtKeyResolver().SetStore(store); err != nil {
+			log.Errorf("failed to load the persisted JWT public keys: %v", err)
+		}
+	}
+
+	if !features.EnableJwksPrewarm {
+		return
+	}
+	s.addStartFunc(func(stop <-chan struct{}) error {
+		go func() {
+			if !s.waitForCacheSync(stop) {
+				return
+			}
+			configs, err := s.environment.IstioConfigStore.List(gvk.RequestAuthentication, model.NamespaceAll)
+			if err != nil {
+				log.Errorf("failed to list RequestAuthentication policies to prewarm JWT public keys: %v", err)
+				return
+			}
+			policies := make([]*v1beta1.RequestAuthentication, 0, len(configs))
+			for _, c := range configs {
+				policies = append(policies, c.Spec.(*v1beta1.RequestAuthentication))
+			}
+			model.GetJwtKeyResolver().Prewarm(policies)
+		}()
+		return nil
+	})
+}
@@ -40,6 +40,9 @@ type RegistryOptions struct {
 	ClusterRegistriesNamespace string
 	KubeConfig                 string
 
+	// ConsulServerAddr is the address of the Consul agent or server used by the Consul registry
+	ConsulServerAddr string
+
 	// DistributionTracking control
 	DistributionCacheRetention time.Duration
 
@@ -0,0 +1,174 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bootstrap
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/xds"
+	v3 "istio.io/istio/pilot/pkg/xds/v3"
+	"istio.io/istio/security/pkg/pki/ca"
+	"istio.io/pkg/log"
+)
+
+// initCertificateRevocation distributes the certificate revocation list of the Istio CA to the agents,
+// and watches the secret storing the revoked certificates to push it when they change. The secret is
+// written by the revoke endpoint of the admin server, called by `istioctl experimental ca revoke`, so all
+// the Istiod instances share the revoked certificates.
+func (s *Server) initCertificateRevocation(namespace string) {
+	s.XDSServer.Generators[v3.CRLType] = &xds.CrlGenerator{CA: s.CA}
+	if s.kubeClient == nil {
+		return
+	}
+	s.monitoringMux.HandleFunc(ca.RevokePath, func(w http.ResponseWriter, req *http.Request) {
+		s.revokeCertificates(w, req, namespace)
+	})
+	s.kubeClient.KubeInformer().Core().V1().Secrets().Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: func(obj interface{}) bool {
+			key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+			return err == nil && key == namespace+"/"+ca.CRLSecret
+		},
+		Handler: cache.ResourceEventHandlerFuncs{
+			AddFunc: func(obj interface{}) {
+				s.updateRevokedCertificates(obj.(*v1.Secret))
+			},
+			UpdateFunc: func(_, obj interface{}) {
+				s.updateRevokedCertificates(obj.(*v1.Secret))
+			},
+			DeleteFunc: func(interface{}) {
+				s.updateRevokedCertificates(nil)
+			},
+		},
+	})
+}
+
+// updateRevokedCertificates sets the revoked certificates of the CA from the secret, and pushes the new
+// certificate revocation list if they changed.
+func (s *Server) updateRevokedCertificates(secret *v1.Secret) {
+	revoked, err := ca.LoadRevokedCertificates(secret)
+	if err != nil {
+		log.Errorf("failed to load the revoked certificates: %v", err)
+		return
+	}
+	if !s.CA.SetRevokedCertificates(revoked) {
+		return
+	}
+	log.Infof("%d certificates revoked by the CA, pushing the certificate revocation list", len(revoked))
+	s.XDSServer.ConfigUpdate(&model.PushRequest{
+		Full:   true,
+		Reason: []model.TriggerReason{model.SecretTrigger},
+	})
+}
+
+// revokeCertificates handles the POST requests to ca.RevokePath. The caller authenticates with a Kubernetes
+// bearer token, and must be allowed to update the secret storing the revoked certificates.
+func (s *Server) revokeCertificates(w http.ResponseWriter, req *http.Request, namespace string) {
+	if req.Method != http.MethodPost {
+		http.Error(w, "only POST is supported", http.StatusMethodNotAllowed)
+		return
+	}
+	user, status, err := s.authorizeRevocation(req, namespace)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	if err := s.CA.CRLSupported(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var revokeReq ca.RevokeRequest
+	if err := json.NewDecoder(req.Body).Decode(&revokeReq); err != nil {
+		http.Error(w, fmt.Sprintf("invalid revoke request: %v", err), http.StatusBadRequest)
+		return
+	}
+	if len(revokeReq.SerialNumbers) == 0 {
+		http.Error(w, "no certificate to revoke", http.StatusBadRequest)
+		return
+	}
+	serials := make([]*big.Int, 0, len(revokeReq.SerialNumbers))
+	for _, sn := range revokeReq.SerialNumbers {
+		serial, err := ca.ParseSerialNumber(sn)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		serials = append(serials, serial)
+	}
+	added, err := ca.RevokeCertificates(req.Context(), s.kubeClient.CoreV1(), namespace, serials)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to revoke the certificates: %v", err), http.StatusInternalServerError)
+		return
+	}
+	log.Infof("%s revoked %d certificates: %v", user, added, revokeReq.SerialNumbers)
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(ca.RevokeResponse{Revoked: added, AlreadyRevoked: len(serials) - added})
+}
+
+// authorizeRevocation authenticates the bearer token of the request with a TokenReview, and checks with a
+// SubjectAccessReview that its user may update the secret storing the revoked certificates. It returns the
+// user, or the HTTP status and the error.
+func (s *Server) authorizeRevocation(req *http.Request, namespace string) (string, int, error) {
+	token := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+	if token == "" || token == req.Header.Get("Authorization") {
+		return "", http.StatusUnauthorized, fmt.Errorf("a Kubernetes bearer token is required")
+	}
+	review, err := s.kubeClient.AuthenticationV1().TokenReviews().Create(req.Context(), &authenticationv1.TokenReview{
+		Spec: authenticationv1.TokenReviewSpec{Token: token},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return "", http.StatusInternalServerError, fmt.Errorf("failed to review the token: %v", err)
+	}
+	if !review.Status.Authenticated {
+		return "", http.StatusUnauthorized, fmt.Errorf("the token is not authenticated: %s", review.Status.Error)
+	}
+	user := review.Status.User
+	extra := make(map[string]authorizationv1.ExtraValue, len(user.Extra))
+	for k, v := range user.Extra {
+		extra[k] = authorizationv1.ExtraValue(v)
+	}
+	access, err := s.kubeClient.AuthorizationV1().SubjectAccessReviews().Create(req.Context(), &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace: namespace,
+				Verb:      "update",
+				Resource:  "secrets",
+				Name:      ca.CRLSecret,
+			},
+			User:   user.Username,
+			Groups: user.Groups,
+			UID:    user.UID,
+			Extra:  extra,
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return "", http.StatusInternalServerError, fmt.Errorf("failed to review the access: %v", err)
+	}
+	if !access.Status.Allowed {
+		return "", http.StatusForbidden, fmt.Errorf("%s is not allowed to update the secret %s/%s: %s",
+			user.Username, namespace, ca.CRLSecret, access.Status.Reason)
+	}
+	return user.Username, 0, nil
+}
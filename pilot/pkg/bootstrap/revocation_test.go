@@ -0,0 +1,117 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bootstrap
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"istio.io/istio/pkg/kube"
+	"istio.io/istio/security/pkg/pki/ca"
+	"istio.io/istio/security/pkg/pki/util"
+)
+
+func TestRevokeCertificates(t *testing.T) {
+	rootCert, rootKey, err := util.GenCertKeyFromOptions(util.CertOptions{
+		IsCA:         true,
+		IsSelfSigned: true,
+		TTL:          time.Hour,
+		Org:          "Root CA",
+		RSAKeySize:   2048,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	bundle, err := util.NewVerifiedKeyCertBundleFromPem(rootCert, rootKey, nil, rootCert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	istioCA, err := ca.NewIstioCA(&ca.IstioCAOptions{KeyCertBundle: bundle})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := kube.NewFakeClient()
+	clientset := client.Kube().(*fake.Clientset)
+	// The token is the user name, only the admin may update the secret.
+	clientset.PrependReactor("create", "tokenreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authenticationv1.TokenReview)
+		review.Status.Authenticated = review.Spec.Token != "invalid"
+		review.Status.User.Username = review.Spec.Token
+		return true, review, nil
+	})
+	clientset.PrependReactor("create", "subjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+		attrs := review.Spec.ResourceAttributes
+		review.Status.Allowed = review.Spec.User == "admin" && attrs.Verb == "update" && attrs.Name == ca.CRLSecret
+		return true, review, nil
+	})
+	s := &Server{kubeClient: client, CA: istioCA}
+
+	cases := []struct {
+		name   string
+		method string
+		token  string
+		body   string
+		status int
+	}{
+		{name: "get", method: http.MethodGet, token: "admin", status: http.StatusMethodNotAllowed},
+		{name: "no token", method: http.MethodPost, body: `{"serialNumbers":["1f"]}`, status: http.StatusUnauthorized},
+		{name: "invalid token", method: http.MethodPost, token: "invalid", body: `{"serialNumbers":["1f"]}`,
+			status: http.StatusUnauthorized},
+		{name: "forbidden", method: http.MethodPost, token: "alice", body: `{"serialNumbers":["1f"]}`,
+			status: http.StatusForbidden},
+		{name: "invalid serial", method: http.MethodPost, token: "admin", body: `{"serialNumbers":["xyz"]}`,
+			status: http.StatusBadRequest},
+		{name: "revoked", method: http.MethodPost, token: "admin", body: `{"serialNumbers":["1f", "0x2a"]}`,
+			status: http.StatusOK},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, ca.RevokePath, strings.NewReader(tc.body))
+			if tc.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tc.token)
+			}
+			w := httptest.NewRecorder()
+			s.revokeCertificates(w, req, namespace)
+			if w.Code != tc.status {
+				t.Fatalf("got status %d (%s), expected %d", w.Code, w.Body.String(), tc.status)
+			}
+		})
+	}
+
+	secret, err := client.CoreV1().Secrets(namespace).Get(context.TODO(), ca.CRLSecret, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	revoked, err := ca.LoadRevokedCertificates(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revoked) != 2 || revoked[0].SerialNumber != "1f" || revoked[1].SerialNumber != "2a" {
+		t.Errorf("unexpected revoked certificates %v", revoked)
+	}
+}
@@ -24,6 +24,7 @@ import (
 	"net/http"
 	"os"
 	"path"
+	"sort"
 	"sync"
 	"time"
 
@@ -64,6 +65,8 @@ import (
 	"istio.io/istio/security/pkg/k8s/chiron"
 	"istio.io/istio/security/pkg/pki/ca"
 	"istio.io/istio/security/pkg/pki/ra"
+	caserver "istio.io/istio/security/pkg/server/ca"
+	"istio.io/istio/security/pkg/server/ca/audit"
 	"istio.io/istio/security/pkg/server/ca/authenticate"
 	"istio.io/istio/security/pkg/server/ca/authenticate/kubeauth"
 	"istio.io/pkg/ctrlz"
@@ -176,6 +179,12 @@ type Server struct {
 
 	// The SPIFFE based cert verifier
 	peerCertVerifier *spiffe.PeerCertVerifier
+	// federatedBundles are the root certs of the trust domains of the SPIFFE bundle endpoints.
+	federatedBundles *spiffe.FederatedBundles
+	// certPolicies are the certificate policies enforced by the CA server. It may be nil.
+	certPolicies *caserver.CertPolicies
+	// caAuditLog records the certificate signing requests handled by the CA server. It may be nil.
+	caAuditLog *audit.Logger
 }
 
 // NewServer creates a new Server instance based on the provided arguments.
@@ -238,6 +247,7 @@ func NewServer(args *PilotArgs) (*Server, error) {
 		Namespace:        args.Namespace,
 		ExternalCAType:   ra.CaExternalType(externalCaType),
 		ExternalCASigner: k8sSigner,
+		MeshConfigMap:    getMeshConfigMapName(args.Revision),
 	}
 
 	// CA signing certificate must be created first if needed.
@@ -250,6 +260,7 @@ func NewServer(args *PilotArgs) (*Server, error) {
 	}
 
 	s.initJwtPolicy()
+	s.initJwksCache(args.Namespace)
 
 	// Parse and validate Istiod Address.
 	istiodHost, _, err := e.GetDiscoveryAddress()
@@ -308,7 +319,9 @@ func NewServer(args *PilotArgs) (*Server, error) {
 	}
 
 	// Start CA or RA server. This should be called after CA and Istiod certs have been created.
-	s.startCA(caOpts)
+	if err := s.startCA(caOpts); err != nil {
+		return nil, err
+	}
 
 	// TODO: don't run this if galley is started, one ctlz is enough
 	if args.CtrlZOptions != nil {
@@ -986,12 +999,22 @@ func (s *Server) setPeerCertVerifier(tlsOptions TLSOptions) error {
 	}
 
 	if features.SpiffeBundleEndpoints != "" {
-		certMap, err := spiffe.RetrieveSpiffeBundleRootCertsFromStringInput(
-			features.SpiffeBundleEndpoints, []*x509.Certificate{})
+		endpoints, err := spiffe.ParseSpiffeBundleEndpoints(features.SpiffeBundleEndpoints)
 		if err != nil {
+			return fmt.Errorf("invalid SPIFFE_BUNDLE_ENDPOINTS: %v", err)
+		}
+		trustDomains := make([]string, 0, len(endpoints))
+		for trustDomain := range endpoints {
+			trustDomains = append(trustDomains, trustDomain)
+		}
+		sort.Strings(trustDomains)
+		s.environment.FederatedTrustDomains = trustDomains
+		s.federatedBundles = spiffe.NewFederatedBundles(endpoints, []*x509.Certificate{})
+		if err := s.federatedBundles.Refresh(); err != nil {
 			return err
 		}
-		s.peerCertVerifier.AddMappings(certMap)
+		s.peerCertVerifier.AddMappings(s.federatedBundles.RootCerts())
+		s.initFederatedTrustBundles()
 	}
 
 	return nil
@@ -1076,9 +1099,17 @@ func (s *Server) maybeCreateCA(caOpts *caOptions) error {
 }
 
 // StartCA starts the CA or RA server if configured.
-func (s *Server) startCA(caOpts *caOptions) {
+func (s *Server) startCA(caOpts *caOptions) error {
 	if s.CA == nil && s.RA == nil {
-		return
+		return nil
+	}
+	s.initCertPolicies(caOpts.Namespace, caOpts.MeshConfigMap)
+	if err := s.initCAAuditLog(); err != nil {
+		return fmt.Errorf("failed to create the CA audit log: %v", err)
+	}
+	if s.RA == nil {
+		s.initCertificateRevocation(caOpts.Namespace)
+		s.initCARotation(caOpts.Namespace)
 	}
 	s.addStartFunc(func(stop <-chan struct{}) error {
 		grpcServer := s.secureGrpcServer
@@ -1095,6 +1126,7 @@ func (s *Server) startCA(caOpts *caOptions) {
 		}
 		return nil
 	})
+	return nil
 }
 
 func (s *Server) fetchCARoot() map[string]string {
@@ -20,6 +20,7 @@ import (
 	"istio.io/istio/pilot/pkg/model"
 	"istio.io/istio/pilot/pkg/serviceregistry"
 	"istio.io/istio/pilot/pkg/serviceregistry/aggregate"
+	"istio.io/istio/pilot/pkg/serviceregistry/consul"
 	kubecontroller "istio.io/istio/pilot/pkg/serviceregistry/kube/controller"
 	"istio.io/istio/pilot/pkg/serviceregistry/mock"
 	"istio.io/istio/pilot/pkg/serviceregistry/serviceentry"
@@ -52,6 +53,10 @@ func (s *Server) initServiceControllers(args *PilotArgs) error {
 			if err := s.initKubeRegistry(args); err != nil {
 				return err
 			}
+		case serviceregistry.Consul:
+			if err := s.initConsulRegistry(args); err != nil {
+				return err
+			}
 		case serviceregistry.Mock:
 			s.initMockRegistry()
 		default:
@@ -107,6 +112,21 @@ func (s *Server) initKubeRegistry(args *PilotArgs) (err error) {
 	return
 }
 
+// initConsulRegistry creates a service controller backed by the Consul catalog
+func (s *Server) initConsulRegistry(args *PilotArgs) error {
+	log.Infof("Consul url: %v", args.RegistryOptions.ConsulServerAddr)
+	controller, err := consul.NewController(consul.Options{
+		ServerURL:  args.RegistryOptions.ConsulServerAddr,
+		ClusterID:  string(serviceregistry.Consul),
+		XDSUpdater: s.XDSServer,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create Consul controller: %v", err)
+	}
+	s.ServiceController().AddRegistry(controller)
+	return nil
+}
+
 func (s *Server) initMockRegistry() {
 	// MemServiceDiscovery implementation
 	discovery := mock.NewDiscovery(map[host.Name]*model.Service{}, 2)
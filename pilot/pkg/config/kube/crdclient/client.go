@@ -52,6 +52,7 @@ import (
 	"istio.io/istio/pilot/pkg/model"
 	controller2 "istio.io/istio/pilot/pkg/serviceregistry/kube/controller"
 	"istio.io/istio/pkg/config"
+	ratelimitclient "istio.io/istio/pkg/config/apis/ratelimit/client/clientset/versioned"
 	"istio.io/istio/pkg/config/schema/collection"
 	"istio.io/istio/pkg/config/schema/collections"
 	"istio.io/istio/pkg/kube"
@@ -83,6 +84,9 @@ type Client struct {
 
 	// The service-apis client we will use to access objects
 	serviceApisClient serviceapisclient.Interface
+
+	// The RateLimit client we will use to access objects
+	rateLimitClient ratelimitclient.Interface
 }
 
 var _ model.ConfigStoreCache = &Client{}
@@ -146,6 +150,7 @@ func New(client kube.Client, revision string, options controller2.Options) (mode
 		kinds:             map[config.GroupVersionKind]*cacheHandler{},
 		istioClient:       client.Istio(),
 		serviceApisClient: client.ServiceApis(),
+		rateLimitClient:   client.RateLimit(),
 	}
 	known := knownCRDs(client.Ext())
 	for _, s := range out.schemas.All() {
@@ -156,6 +161,8 @@ func New(client kube.Client, revision string, options controller2.Options) (mode
 			var err error
 			if s.Resource().Group() == "networking.x-k8s.io" {
 				i, err = client.ServiceApisInformer().ForResource(s.Resource().GroupVersionResource())
+			} else if s.Resource().Group() == "ratelimit.istio.io" {
+				i, err = client.RateLimitInformer().ForResource(s.Resource().GroupVersionResource())
 			} else {
 				i, err = client.IstioInformer().ForResource(s.Resource().GroupVersionResource())
 			}
@@ -212,7 +219,7 @@ func (cl *Client) Create(cfg config.Config) (string, error) {
 		return "", fmt.Errorf("nil spec for %v/%v", cfg.Name, cfg.Namespace)
 	}
 
-	meta, err := create(cl.istioClient, cl.serviceApisClient, cfg, getObjectMetadata(cfg))
+	meta, err := create(cl.istioClient, cl.serviceApisClient, cl.rateLimitClient, cfg, getObjectMetadata(cfg))
 	if err != nil {
 		return "", err
 	}
@@ -225,7 +232,7 @@ func (cl *Client) Update(cfg config.Config) (string, error) {
 		return "", fmt.Errorf("nil spec for %v/%v", cfg.Name, cfg.Namespace)
 	}
 
-	meta, err := update(cl.istioClient, cl.serviceApisClient, cfg, getObjectMetadata(cfg))
+	meta, err := update(cl.istioClient, cl.serviceApisClient, cl.rateLimitClient, cfg, getObjectMetadata(cfg))
 	if err != nil {
 		return "", err
 	}
@@ -237,7 +244,7 @@ func (cl *Client) UpdateStatus(cfg config.Config) (string, error) {
 		return "", fmt.Errorf("nil status for %v/%v on updateStatus()", cfg.Name, cfg.Namespace)
 	}
 
-	meta, err := updateStatus(cl.istioClient, cl.serviceApisClient, cfg, getObjectMetadata(cfg))
+	meta, err := updateStatus(cl.istioClient, cl.serviceApisClient, cl.rateLimitClient, cfg, getObjectMetadata(cfg))
 	if err != nil {
 		return "", err
 	}
@@ -256,7 +263,7 @@ func (cl *Client) Patch(typ config.GroupVersionKind, name, namespace string, pat
 	modified := patchFn(orig.DeepCopy())
 
 	oo := *orig
-	meta, err := patch(cl.istioClient, cl.serviceApisClient, oo, getObjectMetadata(oo), modified, getObjectMetadata(modified))
+	meta, err := patch(cl.istioClient, cl.serviceApisClient, cl.rateLimitClient, oo, getObjectMetadata(oo), modified, getObjectMetadata(modified))
 	if err != nil {
 		return "", err
 	}
@@ -265,7 +272,7 @@ func (cl *Client) Patch(typ config.GroupVersionKind, name, namespace string, pat
 
 // Delete implements store interface
 func (cl *Client) Delete(typ config.GroupVersionKind, name, namespace string) error {
-	return delete(cl.istioClient, cl.serviceApisClient, typ, name, namespace)
+	return delete(cl.istioClient, cl.serviceApisClient, cl.rateLimitClient, typ, name, namespace)
 }
 
 // List implements store interface
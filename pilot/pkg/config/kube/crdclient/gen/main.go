@@ -44,7 +44,8 @@ type ConfigData struct {
 	StatusAPIImport string
 	StatusKind      string
 
-	// Support service-apis, which require a custom client and the Spec suffix
+	// Support service-apis, which require a custom client and the Spec suffix, and the RateLimit API
+	// of this repository, which has its own client
 	Client     string
 	TypeSuffix string
 }
@@ -67,6 +68,9 @@ func MakeConfigData(schema collection.Schema) ConfigData {
 		out.Client = "sc"
 		out.TypeSuffix = "Spec"
 	}
+	if schema.Resource().Group() == "ratelimit.istio.io" {
+		out.Client = "rc"
+	}
 	log.Printf("Generating Istio type %s for %s/%s CRD\n", out.VariableName, out.APIImport, out.Kind)
 	return out
 }
@@ -74,22 +78,25 @@ func MakeConfigData(schema collection.Schema) ConfigData {
 var (
 	// Mapping from istio/api path import to api import path
 	apiImport = map[string]string{
-		"istio.io/api/networking/v1alpha3":       "networkingv1alpha3",
-		"istio.io/api/security/v1beta1":          "securityv1beta1",
-		"sigs.k8s.io/service-apis/apis/v1alpha1": "servicev1alpha1",
-		"istio.io/api/meta/v1alpha1":             "metav1alpha1",
+		"istio.io/api/networking/v1alpha3":                  "networkingv1alpha3",
+		"istio.io/api/security/v1beta1":                     "securityv1beta1",
+		"sigs.k8s.io/service-apis/apis/v1alpha1":            "servicev1alpha1",
+		"istio.io/api/meta/v1alpha1":                        "metav1alpha1",
+		"istio.io/istio/pkg/config/apis/ratelimit/v1alpha1": "ratelimitv1alpha1",
 	}
 	// Mapping from istio/api path import to client go import path
 	clientGoImport = map[string]string{
-		"istio.io/api/networking/v1alpha3":       "clientnetworkingv1alpha3",
-		"istio.io/api/security/v1beta1":          "clientsecurityv1beta1",
-		"sigs.k8s.io/service-apis/apis/v1alpha1": "servicev1alpha1",
+		"istio.io/api/networking/v1alpha3":                  "clientnetworkingv1alpha3",
+		"istio.io/api/security/v1beta1":                     "clientsecurityv1beta1",
+		"sigs.k8s.io/service-apis/apis/v1alpha1":            "servicev1alpha1",
+		"istio.io/istio/pkg/config/apis/ratelimit/v1alpha1": "clientratelimitv1alpha1",
 	}
 	// Translates an api import path to the top level path in client-go
 	clientGoAccessPath = map[string]string{
-		"istio.io/api/networking/v1alpha3":       "NetworkingV1alpha3",
-		"istio.io/api/security/v1beta1":          "SecurityV1beta1",
-		"sigs.k8s.io/service-apis/apis/v1alpha1": "NetworkingV1alpha1",
+		"istio.io/api/networking/v1alpha3":                  "NetworkingV1alpha3",
+		"istio.io/api/security/v1beta1":                     "SecurityV1beta1",
+		"sigs.k8s.io/service-apis/apis/v1alpha1":            "NetworkingV1alpha1",
+		"istio.io/istio/pkg/config/apis/ratelimit/v1alpha1": "RatelimitV1alpha1",
 	}
 	// Translates a plural type name to the type path in client-go
 	// TODO: can we automatically derive this? I don't think we can, its internal to the kubegen
@@ -110,6 +117,7 @@ var (
 		"tcproutes":              "TCPRoutes",
 		"tlsroutes":              "TLSRoutes",
 		"backendpolicies":        "BackendPolicies",
+		"ratelimits":             "RateLimits",
 	}
 )
 
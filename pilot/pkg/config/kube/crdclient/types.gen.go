@@ -33,6 +33,9 @@ import (
 	serviceapisclient "sigs.k8s.io/service-apis/pkg/client/clientset/versioned"
 
 	"istio.io/istio/pkg/config"
+	clientratelimitv1alpha1 "istio.io/istio/pkg/config/apis/ratelimit/client/apis/ratelimit/v1alpha1"
+	ratelimitclient "istio.io/istio/pkg/config/apis/ratelimit/client/clientset/versioned"
+	ratelimitv1alpha1 "istio.io/istio/pkg/config/apis/ratelimit/v1alpha1"
 	"istio.io/istio/pkg/config/schema/collections"
 
 	networkingv1alpha3 "istio.io/api/networking/v1alpha3"
@@ -43,7 +46,7 @@ import (
 	servicev1alpha1 "sigs.k8s.io/service-apis/apis/v1alpha1"
 )
 
-func create(ic versionedclient.Interface, sc serviceapisclient.Interface, cfg config.Config, objMeta metav1.ObjectMeta) (metav1.Object, error) {
+func create(ic versionedclient.Interface, sc serviceapisclient.Interface, rc ratelimitclient.Interface, cfg config.Config, objMeta metav1.ObjectMeta) (metav1.Object, error) {
 	switch cfg.GroupVersionKind {
 	case collections.IstioNetworkingV1Alpha3Destinationrules.Resource().GroupVersionKind():
 		return ic.NetworkingV1alpha3().DestinationRules(cfg.Namespace).Create(context.TODO(), &clientnetworkingv1alpha3.DestinationRule{
@@ -85,6 +88,11 @@ func create(ic versionedclient.Interface, sc serviceapisclient.Interface, cfg co
 			ObjectMeta: objMeta,
 			Spec:       *(cfg.Spec.(*networkingv1alpha3.WorkloadGroup)),
 		}, metav1.CreateOptions{})
+	case collections.IstioRatelimitV1Alpha1Ratelimits.Resource().GroupVersionKind():
+		return rc.RatelimitV1alpha1().RateLimits(cfg.Namespace).Create(context.TODO(), &clientratelimitv1alpha1.RateLimit{
+			ObjectMeta: objMeta,
+			Spec:       *(cfg.Spec.(*ratelimitv1alpha1.RateLimit)),
+		}, metav1.CreateOptions{})
 	case collections.IstioSecurityV1Beta1Authorizationpolicies.Resource().GroupVersionKind():
 		return ic.SecurityV1beta1().AuthorizationPolicies(cfg.Namespace).Create(context.TODO(), &clientsecurityv1beta1.AuthorizationPolicy{
 			ObjectMeta: objMeta,
@@ -135,7 +143,7 @@ func create(ic versionedclient.Interface, sc serviceapisclient.Interface, cfg co
 	}
 }
 
-func update(ic versionedclient.Interface, sc serviceapisclient.Interface, cfg config.Config, objMeta metav1.ObjectMeta) (metav1.Object, error) {
+func update(ic versionedclient.Interface, sc serviceapisclient.Interface, rc ratelimitclient.Interface, cfg config.Config, objMeta metav1.ObjectMeta) (metav1.Object, error) {
 	switch cfg.GroupVersionKind {
 	case collections.IstioNetworkingV1Alpha3Destinationrules.Resource().GroupVersionKind():
 		return ic.NetworkingV1alpha3().DestinationRules(cfg.Namespace).Update(context.TODO(), &clientnetworkingv1alpha3.DestinationRule{
@@ -177,6 +185,11 @@ func update(ic versionedclient.Interface, sc serviceapisclient.Interface, cfg co
 			ObjectMeta: objMeta,
 			Spec:       *(cfg.Spec.(*networkingv1alpha3.WorkloadGroup)),
 		}, metav1.UpdateOptions{})
+	case collections.IstioRatelimitV1Alpha1Ratelimits.Resource().GroupVersionKind():
+		return rc.RatelimitV1alpha1().RateLimits(cfg.Namespace).Update(context.TODO(), &clientratelimitv1alpha1.RateLimit{
+			ObjectMeta: objMeta,
+			Spec:       *(cfg.Spec.(*ratelimitv1alpha1.RateLimit)),
+		}, metav1.UpdateOptions{})
 	case collections.IstioSecurityV1Beta1Authorizationpolicies.Resource().GroupVersionKind():
 		return ic.SecurityV1beta1().AuthorizationPolicies(cfg.Namespace).Update(context.TODO(), &clientsecurityv1beta1.AuthorizationPolicy{
 			ObjectMeta: objMeta,
@@ -227,7 +240,7 @@ func update(ic versionedclient.Interface, sc serviceapisclient.Interface, cfg co
 	}
 }
 
-func updateStatus(ic versionedclient.Interface, sc serviceapisclient.Interface, cfg config.Config, objMeta metav1.ObjectMeta) (metav1.Object, error) {
+func updateStatus(ic versionedclient.Interface, sc serviceapisclient.Interface, rc ratelimitclient.Interface, cfg config.Config, objMeta metav1.ObjectMeta) (metav1.Object, error) {
 	switch cfg.GroupVersionKind {
 	case collections.IstioNetworkingV1Alpha3Destinationrules.Resource().GroupVersionKind():
 		return ic.NetworkingV1alpha3().DestinationRules(cfg.Namespace).UpdateStatus(context.TODO(), &clientnetworkingv1alpha3.DestinationRule{
@@ -269,6 +282,11 @@ func updateStatus(ic versionedclient.Interface, sc serviceapisclient.Interface,
 			ObjectMeta: objMeta,
 			Status:     *(cfg.Status.(*metav1alpha1.IstioStatus)),
 		}, metav1.UpdateOptions{})
+	case collections.IstioRatelimitV1Alpha1Ratelimits.Resource().GroupVersionKind():
+		return rc.RatelimitV1alpha1().RateLimits(cfg.Namespace).UpdateStatus(context.TODO(), &clientratelimitv1alpha1.RateLimit{
+			ObjectMeta: objMeta,
+			Status:     *(cfg.Status.(*metav1alpha1.IstioStatus)),
+		}, metav1.UpdateOptions{})
 	case collections.IstioSecurityV1Beta1Authorizationpolicies.Resource().GroupVersionKind():
 		return ic.SecurityV1beta1().AuthorizationPolicies(cfg.Namespace).UpdateStatus(context.TODO(), &clientsecurityv1beta1.AuthorizationPolicy{
 			ObjectMeta: objMeta,
@@ -319,7 +337,7 @@ func updateStatus(ic versionedclient.Interface, sc serviceapisclient.Interface,
 	}
 }
 
-func patch(ic versionedclient.Interface, sc serviceapisclient.Interface, orig config.Config, origMeta metav1.ObjectMeta, mod config.Config, modMeta metav1.ObjectMeta) (metav1.Object, error) {
+func patch(ic versionedclient.Interface, sc serviceapisclient.Interface, rc ratelimitclient.Interface, orig config.Config, origMeta metav1.ObjectMeta, mod config.Config, modMeta metav1.ObjectMeta) (metav1.Object, error) {
 	if orig.GroupVersionKind != mod.GroupVersionKind {
 		return nil, fmt.Errorf("gvk mismatch: %v, modified: %v", orig.GroupVersionKind, mod.GroupVersionKind)
 	}
@@ -445,6 +463,21 @@ func patch(ic versionedclient.Interface, sc serviceapisclient.Interface, orig co
 		}
 		return ic.NetworkingV1alpha3().WorkloadGroups(orig.Namespace).
 			Patch(context.TODO(), orig.Name, types.JSONPatchType, patchBytes, metav1.PatchOptions{FieldManager: "pilot-discovery"})
+	case collections.IstioRatelimitV1Alpha1Ratelimits.Resource().GroupVersionKind():
+		oldRes := &clientratelimitv1alpha1.RateLimit{
+			ObjectMeta: origMeta,
+			Spec:       *(orig.Spec.(*ratelimitv1alpha1.RateLimit)),
+		}
+		modRes := &clientratelimitv1alpha1.RateLimit{
+			ObjectMeta: modMeta,
+			Spec:       *(mod.Spec.(*ratelimitv1alpha1.RateLimit)),
+		}
+		patchBytes, err := genPatchBytes(oldRes, modRes)
+		if err != nil {
+			return nil, err
+		}
+		return rc.RatelimitV1alpha1().RateLimits(orig.Namespace).
+			Patch(context.TODO(), orig.Name, types.JSONPatchType, patchBytes, metav1.PatchOptions{FieldManager: "pilot-discovery"})
 	case collections.IstioSecurityV1Beta1Authorizationpolicies.Resource().GroupVersionKind():
 		oldRes := &clientsecurityv1beta1.AuthorizationPolicy{
 			ObjectMeta: origMeta,
@@ -585,7 +618,7 @@ func patch(ic versionedclient.Interface, sc serviceapisclient.Interface, orig co
 	}
 }
 
-func delete(ic versionedclient.Interface, sc serviceapisclient.Interface, typ config.GroupVersionKind, name, namespace string) error {
+func delete(ic versionedclient.Interface, sc serviceapisclient.Interface, rc ratelimitclient.Interface, typ config.GroupVersionKind, name, namespace string) error {
 	switch typ {
 	case collections.IstioNetworkingV1Alpha3Destinationrules.Resource().GroupVersionKind():
 		return ic.NetworkingV1alpha3().DestinationRules(namespace).Delete(context.TODO(), name, metav1.DeleteOptions{})
@@ -603,6 +636,8 @@ func delete(ic versionedclient.Interface, sc serviceapisclient.Interface, typ co
 		return ic.NetworkingV1alpha3().WorkloadEntries(namespace).Delete(context.TODO(), name, metav1.DeleteOptions{})
 	case collections.IstioNetworkingV1Alpha3Workloadgroups.Resource().GroupVersionKind():
 		return ic.NetworkingV1alpha3().WorkloadGroups(namespace).Delete(context.TODO(), name, metav1.DeleteOptions{})
+	case collections.IstioRatelimitV1Alpha1Ratelimits.Resource().GroupVersionKind():
+		return rc.RatelimitV1alpha1().RateLimits(namespace).Delete(context.TODO(), name, metav1.DeleteOptions{})
 	case collections.IstioSecurityV1Beta1Authorizationpolicies.Resource().GroupVersionKind():
 		return ic.SecurityV1beta1().AuthorizationPolicies(namespace).Delete(context.TODO(), name, metav1.DeleteOptions{})
 	case collections.IstioSecurityV1Beta1Peerauthentications.Resource().GroupVersionKind():
@@ -771,6 +806,24 @@ var translationMap = map[config.GroupVersionKind]func(r runtime.Object) *config.
 			Status: &obj.Status,
 		}
 	},
+	collections.IstioRatelimitV1Alpha1Ratelimits.Resource().GroupVersionKind(): func(r runtime.Object) *config.Config {
+		obj := r.(*clientratelimitv1alpha1.RateLimit)
+		return &config.Config{
+			Meta: config.Meta{
+				GroupVersionKind:  collections.IstioRatelimitV1Alpha1Ratelimits.Resource().GroupVersionKind(),
+				Name:              obj.Name,
+				Namespace:         obj.Namespace,
+				Labels:            obj.Labels,
+				Annotations:       obj.Annotations,
+				ResourceVersion:   obj.ResourceVersion,
+				CreationTimestamp: obj.CreationTimestamp.Time,
+				OwnerReferences:   obj.OwnerReferences,
+				UID:               string(obj.UID),
+			},
+			Spec:   &obj.Spec,
+			Status: &obj.Status,
+		}
+	},
 	collections.IstioSecurityV1Beta1Authorizationpolicies.Resource().GroupVersionKind(): func(r runtime.Object) *config.Config {
 		obj := r.(*clientsecurityv1beta1.AuthorizationPolicy)
 		return &config.Config{
@@ -0,0 +1,164 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dns
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/miekg/dns"
+)
+
+const (
+	// defaultCacheSize is the maximum number of upstream responses kept in the cache
+	defaultCacheSize = 10000
+
+	// maxNegativeTTL caps the time NXDOMAIN and empty responses are cached, so that names which
+	// start to exist upstream are picked up quickly even if the upstream advertises a long negative TTL.
+	maxNegativeTTL = 30 * time.Second
+)
+
+// responseCache caches the responses from the upstream resolvers. Positive responses are cached
+// for the lowest TTL of their answers. Negative responses (NXDOMAIN, or a successful response without
+// answers) are cached as described in RFC 2308, for the TTL of the SOA record in the authority section.
+type responseCache struct {
+	entries *lru.Cache
+	// now is overridden in tests
+	now func() time.Time
+}
+
+type cacheEntry struct {
+	msg     *dns.Msg
+	stored  time.Time
+	expires time.Time
+}
+
+func newResponseCache(size int) *responseCache {
+	entries, err := lru.New(size)
+	if err != nil {
+		// only happens with a negative size
+		panic(err)
+	}
+	return &responseCache{
+		entries: entries,
+		now:     time.Now,
+	}
+}
+
+// cacheKey identifies a query. The protocol is part of the key as responses received over
+// TCP may not fit in a UDP response. So is the DNSSEC OK bit of the EDNS0 record, as the
+// upstream only returns the DNSSEC records to the clients asking for them.
+func cacheKey(protocol string, req *dns.Msg) string {
+	q := req.Question[0]
+	do := "0"
+	if opt := req.IsEdns0(); opt != nil && opt.Do() {
+		do = "1"
+	}
+	return protocol + "/" + strings.ToLower(q.Name) + "/" + strconv.Itoa(int(q.Qtype)) + "/" + strconv.Itoa(int(q.Qclass)) + "/" + do
+}
+
+// get returns a cached response to the request, with the TTLs decremented by the time spent in the cache.
+func (c *responseCache) get(protocol string, req *dns.Msg) *dns.Msg {
+	key := cacheKey(protocol, req)
+	v, f := c.entries.Get(key)
+	if !f {
+		cacheMisses.Increment()
+		return nil
+	}
+	entry := v.(*cacheEntry)
+	now := c.now()
+	if !now.Before(entry.expires) {
+		c.entries.Remove(key)
+		cacheMisses.Increment()
+		return nil
+	}
+	cacheHits.Increment()
+
+	response := entry.msg.Copy()
+	response.Id = req.Id
+	// Keep the question as asked, as some clients randomize the case of the name
+	response.Question = req.Question
+	elapsed := uint32(now.Sub(entry.stored) / time.Second)
+	for _, section := range [][]dns.RR{response.Answer, response.Ns, response.Extra} {
+		for _, rr := range section {
+			if rr.Header().Rrtype == dns.TypeOPT {
+				continue
+			}
+			if rr.Header().Ttl > elapsed {
+				rr.Header().Ttl -= elapsed
+			} else {
+				rr.Header().Ttl = 0
+			}
+		}
+	}
+	return response
+}
+
+// add caches the upstream response to the request, if it is cacheable.
+func (c *responseCache) add(protocol string, req *dns.Msg, response *dns.Msg) {
+	ttl, ok := cacheTTL(response)
+	if !ok || ttl == 0 {
+		return
+	}
+	now := c.now()
+	c.entries.Add(cacheKey(protocol, req), &cacheEntry{
+		msg:     response.Copy(),
+		stored:  now,
+		expires: now.Add(ttl),
+	})
+}
+
+// cacheTTL returns how long the response can be cached for.
+func cacheTTL(response *dns.Msg) (time.Duration, bool) {
+	if response.Truncated || len(response.Question) != 1 {
+		return 0, false
+	}
+	switch {
+	case response.Rcode == dns.RcodeSuccess && len(response.Answer) > 0:
+		return minTTL(response.Answer), true
+	case response.Rcode == dns.RcodeNameError || response.Rcode == dns.RcodeSuccess:
+		// negative responses are only cached if the upstream told us for how long
+		for _, rr := range response.Ns {
+			if soa, ok := rr.(*dns.SOA); ok {
+				ttl := time.Duration(soa.Minttl) * time.Second
+				if hdrTTL := time.Duration(soa.Hdr.Ttl) * time.Second; hdrTTL < ttl {
+					ttl = hdrTTL
+				}
+				if ttl > maxNegativeTTL {
+					ttl = maxNegativeTTL
+				}
+				return ttl, true
+			}
+		}
+	}
+	return 0, false
+}
+
+func minTTL(records []dns.RR) time.Duration {
+	var ttl uint32
+	found := false
+	for _, rr := range records {
+		if rr.Header().Rrtype == dns.TypeOPT {
+			continue
+		}
+		if !found || rr.Header().Ttl < ttl {
+			ttl = rr.Header().Ttl
+			found = true
+		}
+	}
+	return time.Duration(ttl) * time.Second
+}
@@ -0,0 +1,205 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dns
+
+import (
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+
+	nds "istio.io/istio/pilot/pkg/proto"
+)
+
+// fakeUpstream is an upstream resolver serving a fixed set of responses, counting the queries it receives.
+type fakeUpstream struct {
+	mu      sync.Mutex
+	queries map[string]int
+}
+
+func (f *fakeUpstream) ServeDNS(w dns.ResponseWriter, req *dns.Msg) {
+	f.mu.Lock()
+	f.queries[req.Question[0].Name]++
+	f.mu.Unlock()
+
+	response := new(dns.Msg)
+	response.SetReply(req)
+	soa := &dns.SOA{
+		Hdr:    dns.RR_Header{Name: "example.", Rrtype: dns.TypeSOA, Class: dns.ClassINET, Ttl: 3600},
+		Ns:     "ns.example.",
+		Mbox:   "admin.example.",
+		Minttl: 10,
+	}
+	switch req.Question[0].Name {
+	case "positive.example.":
+		response.Answer = []dns.RR{&dns.A{
+			Hdr: dns.RR_Header{Name: "positive.example.", Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 60},
+			A:   net.ParseIP("1.2.3.4").To4(),
+		}}
+	case "nxdomain.example.":
+		response.Rcode = dns.RcodeNameError
+		response.Ns = []dns.RR{soa}
+	case "nosoa.example.":
+		response.Rcode = dns.RcodeNameError
+	case "servfail.example.":
+		response.Rcode = dns.RcodeServerFailure
+	}
+	_ = w.WriteMsg(response)
+}
+
+func (f *fakeUpstream) count(name string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.queries[name]
+}
+
+func startFakeUpstream(t *testing.T) (*fakeUpstream, string) {
+	t.Helper()
+	upstream := &fakeUpstream{queries: map[string]int{}}
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	started := make(chan struct{})
+	server := &dns.Server{PacketConn: pc, Handler: upstream, NotifyStartedFunc: func() { close(started) }}
+	go func() {
+		_ = server.ActivateAndServe()
+	}()
+	t.Cleanup(func() { _ = server.Shutdown() })
+	<-started
+	return upstream, pc.LocalAddr().String()
+}
+
+// responseRecorder is a dns.ResponseWriter keeping the last message written.
+type responseRecorder struct {
+	dns.ResponseWriter
+	msg *dns.Msg
+}
+
+func (r *responseRecorder) WriteMsg(m *dns.Msg) error {
+	r.msg = m
+	return nil
+}
+
+func TestUpstreamCache(t *testing.T) {
+	upstream, addr := startFakeUpstream(t)
+	now := time.Now()
+	h := &LocalDNSServer{
+		resolvConfServers: []string{addr},
+		cache:             newResponseCache(defaultCacheSize),
+	}
+	h.cache.now = func() time.Time { return now }
+	h.lookupTable.Store(&LookupTable{allHosts: map[string]struct{}{}})
+	proxy := &dnsProxy{protocol: "udp", upstreamClient: &dns.Client{Net: "udp", Timeout: 3 * time.Second}}
+
+	query := func(name string) *dns.Msg {
+		t.Helper()
+		req := new(dns.Msg)
+		req.SetQuestion(name, dns.TypeA)
+		w := &responseRecorder{}
+		h.ServeDNS(proxy, w, req)
+		if w.msg == nil {
+			t.Fatalf("no response for %s", name)
+		}
+		if w.msg.Id != req.Id {
+			t.Fatalf("response id %d does not match request id %d", w.msg.Id, req.Id)
+		}
+		return w.msg
+	}
+
+	t.Run("positive response", func(t *testing.T) {
+		if res := query("positive.example."); len(res.Answer) != 1 || res.Answer[0].Header().Ttl != 60 {
+			t.Fatalf("unexpected response: %v", res)
+		}
+		now = now.Add(20 * time.Second)
+		res := query("positive.example.")
+		if len(res.Answer) != 1 || res.Answer[0].Header().Ttl != 40 {
+			t.Fatalf("expected cached response with decremented TTL, got %v", res)
+		}
+		if c := upstream.count("positive.example."); c != 1 {
+			t.Fatalf("expected 1 upstream query, got %d", c)
+		}
+		// Once the TTL expires, the upstream is queried again
+		now = now.Add(40 * time.Second)
+		query("positive.example.")
+		if c := upstream.count("positive.example."); c != 2 {
+			t.Fatalf("expected 2 upstream queries, got %d", c)
+		}
+	})
+
+	t.Run("case insensitive", func(t *testing.T) {
+		res := query("POSITIVE.example.")
+		if res.Question[0].Name != "POSITIVE.example." || len(res.Answer) != 1 {
+			t.Fatalf("unexpected response: %v", res)
+		}
+		if c := upstream.count("POSITIVE.example."); c != 0 {
+			t.Fatalf("expected cached response, got %d upstream queries", c)
+		}
+	})
+
+	t.Run("dnssec ok", func(t *testing.T) {
+		req := new(dns.Msg)
+		req.SetQuestion("positive.example.", dns.TypeA)
+		req.SetEdns0(dns.DefaultMsgSize, true)
+		h.ServeDNS(proxy, &responseRecorder{}, req)
+		if c := upstream.count("positive.example."); c != 3 {
+			t.Fatalf("expected the response without DNSSEC records not to be used, got %d upstream queries", c)
+		}
+	})
+
+	t.Run("negative response", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			if res := query("nxdomain.example."); res.Rcode != dns.RcodeNameError || len(res.Ns) != 1 {
+				t.Fatalf("unexpected response: %v", res)
+			}
+		}
+		if c := upstream.count("nxdomain.example."); c != 1 {
+			t.Fatalf("expected 1 upstream query, got %d", c)
+		}
+		// Negative responses are cached for the SOA minimum TTL
+		now = now.Add(10 * time.Second)
+		query("nxdomain.example.")
+		if c := upstream.count("nxdomain.example."); c != 2 {
+			t.Fatalf("expected 2 upstream queries, got %d", c)
+		}
+	})
+
+	t.Run("not cacheable", func(t *testing.T) {
+		for _, name := range []string{"nosoa.example.", "servfail.example."} {
+			for i := 0; i < 2; i++ {
+				if res := query(name); res.Rcode != dns.RcodeNameError {
+					t.Fatalf("unexpected response for %s: %v", name, res)
+				}
+			}
+			if c := upstream.count(name); c != 2 {
+				t.Fatalf("expected %s not to be cached, got %d upstream queries", name, c)
+			}
+		}
+	})
+
+	t.Run("local hosts are not cached", func(t *testing.T) {
+		h.UpdateLookupTable(&nds.NameTable{
+			Table: map[string]*nds.NameTable_NameInfo{
+				"positive.example": {Ips: []string{"5.6.7.8"}, Registry: "External"},
+			},
+		})
+		res := query("positive.example.")
+		if len(res.Answer) != 1 || !res.Answer[0].(*dns.A).A.Equal(net.ParseIP("5.6.7.8")) {
+			t.Fatalf("expected local answer, got %v", res)
+		}
+	})
+}
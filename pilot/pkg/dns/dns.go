@@ -16,17 +16,26 @@ package dns
 
 import (
 	"net"
+	"sort"
 	"strings"
 	"sync/atomic"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/miekg/dns"
 
 	nds "istio.io/istio/pilot/pkg/proto"
+	"istio.io/pkg/env"
 	istiolog "istio.io/pkg/log"
 )
 
-var log = istiolog.RegisterScope("dns", "Istio DNS proxy", 0)
+var (
+	log = istiolog.RegisterScope("dns", "Istio DNS proxy", 0)
+
+	enableUpstreamCache = env.RegisterBoolVar("DNS_UPSTREAM_CACHE", false,
+		"If enabled, the DNS proxy caches the responses of the upstream resolvers for their TTL, "+
+			"and negative responses for the TTL of their SOA record, up to 30s.").Get()
+)
 
 // Holds configurations for the DNS downstreamUDPServer in Istio Agent
 type LocalDNSServer struct {
@@ -36,6 +45,9 @@ type LocalDNSServer struct {
 	udpDNSProxy *dnsProxy
 	tcpDNSProxy *dnsProxy
 
+	// Holds the responses from the upstream resolvers, if the cache is enabled
+	cache *responseCache
+
 	resolvConfServers []string
 	searchNamespaces  []string
 	// The namespace where the proxy resides
@@ -63,6 +75,11 @@ type LookupTable struct {
 	// The cname records here (comprised of different variants of the hosts above,
 	// expanded by the search namespaces) pointing to the actual host.
 	cname map[string][]dns.RR
+	// The SRV records of the hosts, keyed by _port._protocol.host., as well as by the host itself
+	// for queries listing all the ports.
+	srv map[string][]dns.RR
+	// The PTR records for the IPs of the hosts, keyed by the reverse name (like 1.0.0.10.in-addr.arpa.)
+	ptr map[string][]dns.RR
 }
 
 const (
@@ -76,6 +93,9 @@ func NewLocalDNSServer(proxyNamespace, proxyDomain string) (*LocalDNSServer, err
 	h := &LocalDNSServer{
 		proxyNamespace: proxyNamespace,
 	}
+	if enableUpstreamCache {
+		h.cache = newResponseCache(defaultCacheSize)
+	}
 
 	// proxyDomain could contain the namespace making it redundant.
 	// we just need the .svc.cluster.local piece
@@ -131,6 +151,8 @@ func (h *LocalDNSServer) UpdateLookupTable(nt *nds.NameTable) {
 		name4:    map[string][]dns.RR{},
 		name6:    map[string][]dns.RR{},
 		cname:    map[string][]dns.RR{},
+		srv:      map[string][]dns.RR{},
+		ptr:      map[string][]dns.RR{},
 	}
 	for host, ni := range nt.Table {
 		// Given a host
@@ -149,7 +171,9 @@ func (h *LocalDNSServer) UpdateLookupTable(nt *nds.NameTable) {
 			continue
 		}
 		lookupTable.buildDNSAnswers(altHosts, ipv4, ipv6, h.searchNamespaces)
+		lookupTable.buildSRVAndPTRAnswers(host+".", altHosts, ipv4, ipv6, ni.Ports)
 	}
+	lookupTable.sortPTRAnswers()
 	h.lookupTable.Store(lookupTable)
 	log.Debugf("updated lookup table with %d hosts", len(lookupTable.allHosts))
 }
@@ -202,7 +226,12 @@ func (h *LocalDNSServer) ServeDNS(proxy *dnsProxy, w dns.ResponseWriter, req *dn
 		}
 	} else {
 		// We did not find the host in our internal cache. Query upstream and return the response as is.
-		response = h.queryUpstream(proxy.upstreamClient, req)
+		if h.cache == nil {
+			response = h.queryUpstream(proxy.upstreamClient, req)
+		} else if response = h.cache.get(proxy.protocol, req); response == nil {
+			response = h.queryUpstream(proxy.upstreamClient, req)
+			h.cache.add(proxy.protocol, req, response)
+		}
 	}
 	_ = w.WriteMsg(response)
 	log.Debugf("response for hostname %q (found=%v): %v", hostname, hostFound, response)
@@ -215,13 +244,28 @@ func (h *LocalDNSServer) Close() {
 
 // TODO: Figure out how to send parallel queries to all nameservers
 func (h *LocalDNSServer) queryUpstream(upstreamClient *dns.Client, req *dns.Msg) *dns.Msg {
+	start := time.Now()
+	defer func() {
+		upstreamRequestDuration.Record(time.Since(start).Seconds())
+	}()
 	var response *dns.Msg
+	// The first negative response is returned, along with its SOA record, if no upstream has an answer.
+	var negative *dns.Msg
 	for _, upstream := range h.resolvConfServers {
 		cResponse, _, err := upstreamClient.Exchange(req, upstream)
-		if err == nil && len(cResponse.Answer) > 0 {
+		if err != nil {
+			continue
+		}
+		if len(cResponse.Answer) > 0 {
 			response = cResponse
 			break
 		}
+		if negative == nil && (cResponse.Rcode == dns.RcodeNameError || cResponse.Rcode == dns.RcodeSuccess) {
+			negative = cResponse
+		}
+	}
+	if response == nil {
+		response = negative
 	}
 	if response == nil {
 		response = new(dns.Msg)
@@ -292,8 +336,11 @@ func (table *LookupTable) lookupHost(qtype uint16, hostname string) ([]dns.RR, b
 		ipAnswers = table.name4[hostname]
 	case dns.TypeAAAA:
 		ipAnswers = table.name6[hostname]
+	case dns.TypeSRV:
+		ipAnswers = table.srv[hostname]
+	case dns.TypePTR:
+		ipAnswers = table.ptr[hostname]
 	default:
-		// TODO: handle PTR records for reverse dns lookups
 		return nil, false
 	}
 
@@ -353,6 +400,70 @@ func (table *LookupTable) buildDNSAnswers(altHosts map[string]struct{}, ipv4 []n
 	}
 }
 
+// This function stores the SRV records for the ports of a host, and the PTR records for its IPs.
+// The SRV records are stored for every variant of the host, both as _port._protocol.host. for each named
+// port, and as host. listing all the ports. When a host has a single IP per family (the IP of the service),
+// the SRV records point to the host itself. When a host has multiple IPs of the same family, which is the case
+// of headless services, each IP gets its own target named after the IP (like 10-0-0-1.host.), as kubernetes
+// does for the pods of headless services. This allows clients to discover the individual members of a StatefulSet.
+// The PTR records of the IPs point to the same targets.
+func (table *LookupTable) buildSRVAndPTRAnswers(host string, altHosts map[string]struct{}, ipv4 []net.IP, ipv6 []net.IP,
+	ports []*nds.NameTable_NameInfo_Port) {
+	headless := len(ipv4) > 1 || len(ipv6) > 1
+	targetNames := []string{host}
+	if headless {
+		targetNames = targetNames[:0]
+	}
+	for _, ip := range append(append([]net.IP{}, ipv4...), ipv6...) {
+		target := host
+		if headless {
+			target = ipLabel(ip) + "." + host
+			targetNames = append(targetNames, target)
+			table.allHosts[target] = struct{}{}
+			if ip.To4() != nil {
+				table.name4[target] = a(target, []net.IP{ip})
+			} else {
+				table.name6[target] = aaaa(target, []net.IP{ip})
+			}
+		}
+		reverse, err := dns.ReverseAddr(ip.String())
+		if err != nil {
+			continue
+		}
+		table.allHosts[reverse] = struct{}{}
+		table.ptr[reverse] = append(table.ptr[reverse], ptr(reverse, target))
+	}
+	sort.Strings(targetNames)
+	for h := range altHosts {
+		for _, port := range ports {
+			table.srv[h] = append(table.srv[h], srv(h, port.Number, targetNames)...)
+			if port.Name == "" {
+				continue
+			}
+			name := "_" + strings.ToLower(port.Name) + "._" + strings.ToLower(port.Protocol) + "." + h
+			table.allHosts[name] = struct{}{}
+			table.srv[name] = srv(name, port.Number, targetNames)
+		}
+	}
+}
+
+// sortPTRAnswers orders the PTR records of IPs shared by multiple hosts, so that the answers
+// do not depend on the order of the name table.
+func (table *LookupTable) sortPTRAnswers() {
+	for _, answers := range table.ptr {
+		if len(answers) > 1 {
+			sort.Slice(answers, func(i, j int) bool {
+				return answers[i].(*dns.PTR).Ptr < answers[j].(*dns.PTR).Ptr
+			})
+		}
+	}
+}
+
+// ipLabel converts an IP to a DNS label, replacing dots and colons by dashes (like 10-0-0-1).
+func ipLabel(ip net.IP) string {
+	return strings.NewReplacer(".", "-", ":", "-").Replace(ip.String())
+}
+
 // Borrowed from https://github.com/coredns/coredns/blob/master/plugin/hosts/hosts.go
 // a takes a slice of net.IPs and returns a slice of A RRs.
 func a(host string, ips []net.IP) []dns.RR {
@@ -387,3 +498,25 @@ func cname(host string, targetHost string) []dns.RR {
 	answer.Target = targetHost
 	return []dns.RR{answer}
 }
+
+// srv returns the SRV records for a port, one per target. All targets have the same priority and weight.
+func srv(host string, port uint32, targets []string) []dns.RR {
+	answers := make([]dns.RR, len(targets))
+	for i, target := range targets {
+		r := new(dns.SRV)
+		r.Hdr = dns.RR_Header{Name: host, Rrtype: dns.TypeSRV, Class: dns.ClassINET, Ttl: defaultTTLInSeconds}
+		r.Priority = 0
+		r.Weight = uint16(100 / len(targets))
+		r.Port = uint16(port)
+		r.Target = target
+		answers[i] = r
+	}
+	return answers
+}
+
+func ptr(reverse string, host string) dns.RR {
+	r := new(dns.PTR)
+	r.Hdr = dns.RR_Header{Name: reverse, Rrtype: dns.TypePTR, Class: dns.ClassINET, Ttl: defaultTTLInSeconds}
+	r.Ptr = host
+	return r
+}
@@ -54,6 +54,7 @@ func initDNS() error {
 				Registry:  "Kubernetes",
 				Namespace: "ns1",
 				Shortname: "productpage",
+				Ports:     []*nds.NameTable_NameInfo_Port{{Name: "http", Number: 9080, Protocol: "TCP"}},
 			},
 			"reviews.ns2.svc.cluster.local": {
 				Ips:       []string{"10.10.10.10"},
@@ -66,6 +67,7 @@ func initDNS() error {
 				Registry:  "Kubernetes",
 				Namespace: "ns2",
 				Shortname: "details",
+				Ports:     []*nds.NameTable_NameInfo_Port{{Name: "tcp-db", Number: 3306, Protocol: "TCP"}},
 			},
 			"ipv6.localhost": {
 				Ips:      []string{"2001:db8:0:0:0:ff00:42:8329"},
@@ -93,6 +95,7 @@ func TestDNS(t *testing.T) {
 		name                     string
 		host                     string
 		queryAAAA                bool
+		queryType                uint16
 		expected                 []dns.RR
 		expectResolutionFailure  bool
 		expectExternalResolution bool
@@ -193,6 +196,69 @@ func TestDNS(t *testing.T) {
 			queryAAAA:               true,
 			expectResolutionFailure: true,
 		},
+		{
+			name:      "success: SRV query for a named port",
+			host:      "_http._tcp.productpage.ns1.svc.cluster.local.",
+			queryType: dns.TypeSRV,
+			expected:  srv("_http._tcp.productpage.ns1.svc.cluster.local.", 9080, []string{"productpage.ns1.svc.cluster.local."}),
+		},
+		{
+			name:      "success: SRV query for a named port - shortname",
+			host:      "_http._tcp.productpage.",
+			queryType: dns.TypeSRV,
+			expected:  srv("_http._tcp.productpage.", 9080, []string{"productpage.ns1.svc.cluster.local."}),
+		},
+		{
+			name:      "success: SRV query for a host returns all ports",
+			host:      "productpage.ns1.",
+			queryType: dns.TypeSRV,
+			expected:  srv("productpage.ns1.", 9080, []string{"productpage.ns1.svc.cluster.local."}),
+		},
+		{
+			name:      "success: SRV query for a headless service returns one target per endpoint",
+			host:      "_tcp-db._tcp.details.ns2.svc.cluster.remote.",
+			queryType: dns.TypeSRV,
+			expected: srv("_tcp-db._tcp.details.ns2.svc.cluster.remote.", 3306,
+				[]string{"11-11-11-11.details.ns2.svc.cluster.remote.", "12-12-12-12.details.ns2.svc.cluster.remote."}),
+		},
+		{
+			name:     "success: SRV target of a headless service endpoint",
+			host:     "11-11-11-11.details.ns2.svc.cluster.remote.",
+			expected: a("11-11-11-11.details.ns2.svc.cluster.remote.", []net.IP{net.ParseIP("11.11.11.11").To4()}),
+		},
+		{
+			name:                    "failure: SRV query for an unknown port",
+			host:                    "_grpc._tcp.productpage.ns1.svc.cluster.local.",
+			queryType:               dns.TypeSRV,
+			expectResolutionFailure: true,
+		},
+		{
+			name:                    "failure: SRV query for a host without ports",
+			host:                    "www.google.com.",
+			queryType:               dns.TypeSRV,
+			expectResolutionFailure: true,
+		},
+		{
+			name:      "success: PTR query for a service IP",
+			host:      "9.9.9.9.in-addr.arpa.",
+			queryType: dns.TypePTR,
+			expected:  []dns.RR{ptr("9.9.9.9.in-addr.arpa.", "productpage.ns1.svc.cluster.local.")},
+		},
+		{
+			name:      "success: PTR query for a headless service endpoint IP",
+			host:      "12.12.12.12.in-addr.arpa.",
+			queryType: dns.TypePTR,
+			expected:  []dns.RR{ptr("12.12.12.12.in-addr.arpa.", "12-12-12-12.details.ns2.svc.cluster.remote.")},
+		},
+		{
+			name:      "success: PTR query for an IP shared by multiple hosts",
+			host:      "2.2.2.2.in-addr.arpa.",
+			queryType: dns.TypePTR,
+			expected: []dns.RR{
+				ptr("2.2.2.2.in-addr.arpa.", "dual.localhost."),
+				ptr("2.2.2.2.in-addr.arpa.", "ipv4.localhost."),
+			},
+		},
 	}
 
 	clients := []dns.Client{
@@ -214,6 +280,9 @@ func TestDNS(t *testing.T) {
 				if tt.queryAAAA {
 					q = dns.TypeAAAA
 				}
+				if tt.queryType != 0 {
+					q = tt.queryType
+				}
 				m.SetQuestion(tt.host, q)
 				res, _, err := clients[i].Exchange(m, testAgentDNSAddr)
 
@@ -0,0 +1,43 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dns
+
+import "istio.io/pkg/monitoring"
+
+var (
+	typeTag = monitoring.MustCreateLabel("type")
+
+	cacheReads = monitoring.NewSum(
+		"dns_upstream_cache_reads",
+		"Total number of lookups of upstream responses in the DNS proxy cache.",
+		monitoring.WithLabels(typeTag),
+	)
+
+	cacheHits   = cacheReads.With(typeTag.Value("hit"))
+	cacheMisses = cacheReads.With(typeTag.Value("miss"))
+
+	upstreamRequestDuration = monitoring.NewDistribution(
+		"dns_upstream_request_duration_seconds",
+		"Total time in seconds the DNS proxy takes to get a response from the upstream resolvers.",
+		[]float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 5},
+	)
+)
+
+func init() {
+	monitoring.MustRegister(
+		cacheReads,
+		upstreamRequestDuration,
+	)
+}
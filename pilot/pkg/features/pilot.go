@@ -227,6 +227,21 @@ var (
 		"If enabled, Pilot will keep track of old versions of distributed config for this duration.",
 	).Get()
 
+	ConfigHistoryRevisions = env.RegisterIntVar(
+		"PILOT_CONFIG_HISTORY_REVISIONS",
+		10,
+		"The number of revisions of each config resource Pilot keeps in its config history, which can be "+
+			"listed and rolled back to. The history requires config distribution tracking to be enabled, and "+
+			"is disabled if set to 0.",
+	).Get()
+
+	ConfigHistoryDeletedRetention = env.RegisterDurationVar(
+		"PILOT_CONFIG_HISTORY_DELETED_RETENTION",
+		time.Hour,
+		"The duration for which Pilot keeps the config history of a deleted config resource, during which "+
+			"the resource can be rolled back.",
+	).Get()
+
 	EnableEndpointSliceController = env.RegisterBoolVar(
 		"PILOT_USE_ENDPOINT_SLICE",
 		false,
@@ -354,6 +369,26 @@ var (
 			"Use || between <trustdomain, endpoint> tuples. Use | as delimiter between trust domain and endpoint in "+
 			"each tuple. For example: foo|https://url/for/foo||bar|https://url/for/bar").Get()
 
+	SpiffeBundleRefreshInterval = env.RegisterDurationVar("SPIFFE_BUNDLE_REFRESH_INTERVAL", 5*time.Minute,
+		"The interval at which Istiod retrieves the root certificates of the SPIFFE bundle endpoints again. "+
+			"The root certificates of each federated trust domain are distributed to the proxies in their own "+
+			"validation context, used for the upstream clusters whose subject alt names all belong to that trust "+
+			"domain. Inbound connections are only validated with the root certificates of the mesh.").Get()
+
+	JwksCacheFile = env.RegisterStringVar("PILOT_JWKS_CACHE_FILE", "",
+		"If set, the JWT public keys fetched from the jwks_uri of RequestAuthentication policies are persisted to "+
+			"this file, and served from it when Istiod restarts while the identity providers are unreachable. "+
+			"The file should be on a volume that outlives the pod.").Get()
+
+	JwksCacheConfigMap = env.RegisterStringVar("PILOT_JWKS_CACHE_CONFIGMAP", "",
+		"If set, the JWT public keys fetched from the jwks_uri of RequestAuthentication policies are persisted to "+
+			"the ConfigMap with this name in the Istiod namespace, and served from it when Istiod restarts while "+
+			"the identity providers are unreachable. Ignored if PILOT_JWKS_CACHE_FILE is set.").Get()
+
+	EnableJwksPrewarm = env.RegisterBoolVar("PILOT_ENABLE_JWKS_PREWARM", false,
+		"If enabled, Istiod fetches the JWT public keys of all the issuers of RequestAuthentication policies "+
+			"when it starts, instead of when they are first needed by a push.").Get()
+
 	EnableXDSCaching = env.RegisterBoolVar("PILOT_ENABLE_XDS_CACHE", true,
 		"If true, Pilot will cache XDS responses.").Get()
 
@@ -363,6 +398,10 @@ var (
 	XDSCacheMaxSize = env.RegisterIntVar("PILOT_XDS_CACHE_SIZE", 20000,
 		"The maximum number of cache entries for the XDS cache. If the size is <= 0, the cache will have no upper bound.").Get()
 
+	XDSCacheMaxBytes = env.RegisterIntVar("PILOT_XDS_CACHE_MAX_BYTES", 0,
+		"The memory budget, in bytes, for the XDS cache. If set, least recently used entries are evicted once "+
+			"the total size of the cached resources exceeds the budget, and PILOT_XDS_CACHE_SIZE is ignored.").Get()
+
 	AllowMetadataCertsInMutualTLS = env.RegisterBoolVar("PILOT_ALLOW_METADATA_CERTS_DR_MUTUAL_TLS", false,
 		"If true, Pilot will allow certs specified in Metadata to override DR certs in MUTUAL TLS mode. "+
 			"This is only enabled for migration and will be removed soon.").Get()
@@ -396,7 +435,8 @@ var (
 			"associated WorkloadEntry is cleaned up.").Get()
 
 	WorkloadEntryHealthChecks = env.RegisterBoolVar("PILOT_ENABLE_WORKLOAD_ENTRY_HEALTHCHECKS", false,
-		"Enables automatic health checks of WorkloadEntries based on the config provided in the associated WorkloadGroup").Get()
+		"Enables automatic health checks of WorkloadEntries based on the config provided in the associated WorkloadGroup. "+
+			"Endpoints of WorkloadEntries reported as unhealthy are removed from EDS.").Get()
 
 	EnableFlowControl = env.RegisterBoolVar(
 		"PILOT_ENABLE_FLOW_CONTROL",
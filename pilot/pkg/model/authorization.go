@@ -15,7 +15,10 @@
 package model
 
 import (
+	"strconv"
+
 	authpb "istio.io/api/security/v1beta1"
+	"istio.io/istio/pkg/config/constants"
 	"istio.io/istio/pkg/config/labels"
 	"istio.io/istio/pkg/config/schema/collections"
 	istiolog "istio.io/pkg/log"
@@ -26,9 +29,16 @@ var (
 )
 
 type AuthorizationPolicy struct {
-	Name      string                      `json:"name"`
-	Namespace string                      `json:"namespace"`
-	Spec      *authpb.AuthorizationPolicy `json:"spec"`
+	Name        string                      `json:"name"`
+	Namespace   string                      `json:"namespace"`
+	Annotations map[string]string           `json:"annotations"`
+	Spec        *authpb.AuthorizationPolicy `json:"spec"`
+}
+
+// DryRun returns true if the policy is annotated as dry-run, it must then be evaluated but not enforced.
+func (policy AuthorizationPolicy) DryRun() bool {
+	dryRun, _ := strconv.ParseBool(policy.Annotations[constants.AuthorizationPolicyDryRunAnnotation])
+	return dryRun
 }
 
 // AuthorizationPolicies organizes AuthorizationPolicy by namespace.
@@ -54,9 +64,10 @@ func GetAuthorizationPolicies(env *Environment) (*AuthorizationPolicies, error)
 	sortConfigByCreationTime(policies)
 	for _, config := range policies {
 		authzConfig := AuthorizationPolicy{
-			Name:      config.Name,
-			Namespace: config.Namespace,
-			Spec:      config.Spec.(*authpb.AuthorizationPolicy),
+			Name:        config.Name,
+			Namespace:   config.Namespace,
+			Annotations: config.Annotations,
+			Spec:        config.Spec.(*authpb.AuthorizationPolicy),
 		}
 		policy.NamespaceToPolicies[config.Namespace] =
 			append(policy.NamespaceToPolicies[config.Namespace], authzConfig)
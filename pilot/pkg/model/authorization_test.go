@@ -337,6 +337,24 @@ func TestAuthorizationPolicies_ListAuthorizationPolicies(t *testing.T) {
 	}
 }
 
+func TestAuthorizationPolicy_DryRun(t *testing.T) {
+	cases := []struct {
+		annotations map[string]string
+		want        bool
+	}{
+		{annotations: nil, want: false},
+		{annotations: map[string]string{"istio.io/dry-run": "true"}, want: true},
+		{annotations: map[string]string{"istio.io/dry-run": "false"}, want: false},
+		{annotations: map[string]string{"istio.io/dry-run": "invalid"}, want: false},
+	}
+	for _, tc := range cases {
+		policy := AuthorizationPolicy{Name: "httpbin", Namespace: "foo", Annotations: tc.annotations}
+		if got := policy.DryRun(); got != tc.want {
+			t.Errorf("DryRun() with annotations %v: got %v, want %v", tc.annotations, got, tc.want)
+		}
+	}
+}
+
 func createFakeAuthorizationPolicies(configs []config.Config, t *testing.T) *AuthorizationPolicies {
 	store := &authzFakeStore{}
 	for _, cfg := range configs {
@@ -53,6 +53,10 @@ func (key ConfigKey) HashCode() uint32 {
 	return result
 }
 
+func (key ConfigKey) String() string {
+	return key.Kind.Kind + "/" + key.Namespace + "/" + key.Name
+}
+
 // ConfigsOfKind extracts configs of the specified kind.
 func ConfigsOfKind(configs map[ConfigKey]struct{}, kind config.GroupVersionKind) map[ConfigKey]struct{} {
 	ret := make(map[ConfigKey]struct{})
@@ -0,0 +1,134 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"sync"
+	"time"
+
+	"istio.io/istio/pkg/config"
+)
+
+// ConfigRevision is a version of a config resource recorded in the ConfigHistory.
+type ConfigRevision struct {
+	// ResourceVersion is the version of the resource. It is empty if the resource was deleted.
+	ResourceVersion string `json:"resourceVersion,omitempty"`
+	// LedgerVersion is the version of the config ledger right after the change. The nonces of the
+	// config pushed to the proxies after the change are prefixed by this version or a later one.
+	LedgerVersion string `json:"ledgerVersion"`
+	// Timestamp is the time at which the change was observed.
+	Timestamp time.Time `json:"timestamp"`
+	// Deleted is true if the resource was deleted by this change.
+	Deleted bool `json:"deleted,omitempty"`
+	// Config is the content of the resource. It is the last known content for a deletion.
+	Config *config.Config `json:"-"`
+}
+
+// ConfigHistory keeps the last revisions of each config resource, so that they can be listed,
+// compared and re-applied. The ledger only keeps the resource versions, not their content.
+type ConfigHistory struct {
+	mu               sync.RWMutex
+	maxRevisions     int
+	deletedRetention time.Duration
+	// revisions per resource key, oldest first
+	revisions map[string][]ConfigRevision
+	// deletion time of the resources whose latest revision is a deletion
+	deleted map[string]time.Time
+}
+
+// NewConfigHistory creates a ConfigHistory keeping at most maxRevisions revisions of each resource.
+// The history of a deleted resource is dropped once the resource has been deleted for deletedRetention.
+func NewConfigHistory(maxRevisions int, deletedRetention time.Duration) *ConfigHistory {
+	if maxRevisions < 1 {
+		maxRevisions = 1
+	}
+	return &ConfigHistory{
+		maxRevisions:     maxRevisions,
+		deletedRetention: deletedRetention,
+		revisions:        map[string][]ConfigRevision{},
+		deleted:          map[string]time.Time{},
+	}
+}
+
+// Record adds a revision of the resource, observed at the given ledger version.
+func (h *ConfigHistory) Record(cfg config.Config, ledgerVersion string, deleted bool) {
+	key := config.Key(cfg.GroupVersionKind.Kind, cfg.Name, cfg.Namespace)
+	rev := ConfigRevision{
+		LedgerVersion: ledgerVersion,
+		Timestamp:     time.Now(),
+		Deleted:       deleted,
+	}
+	if !deleted {
+		rev.ResourceVersion = cfg.ResourceVersion
+	}
+	c := cfg.DeepCopy()
+	rev.Config = &c
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.dropDeleted(rev.Timestamp)
+	revs := h.revisions[key]
+	// The same version may be received several times, e.g. on resync
+	if n := len(revs); n > 0 && !deleted && !revs[n-1].Deleted && revs[n-1].ResourceVersion == rev.ResourceVersion {
+		return
+	}
+	revs = append(revs, rev)
+	if len(revs) > h.maxRevisions {
+		revs = append([]ConfigRevision(nil), revs[len(revs)-h.maxRevisions:]...)
+	}
+	h.revisions[key] = revs
+	if deleted {
+		h.deleted[key] = rev.Timestamp
+	} else {
+		delete(h.deleted, key)
+	}
+}
+
+// dropDeleted drops the history of the resources deleted for longer than the retention. The lock must be held.
+func (h *ConfigHistory) dropDeleted(now time.Time) {
+	for key, t := range h.deleted {
+		if now.Sub(t) > h.deletedRetention {
+			delete(h.revisions, key)
+			delete(h.deleted, key)
+		}
+	}
+}
+
+// Revisions returns the recorded revisions of the resource with the given key, oldest first.
+func (h *ConfigHistory) Revisions(key string) []ConfigRevision {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return append([]ConfigRevision(nil), h.revisions[key]...)
+}
+
+// Revision returns the revision of the resource matching the version, which is either a ledger version
+// or a resource version. If the version is empty, the latest revision is returned.
+func (h *ConfigHistory) Revision(key, version string) (ConfigRevision, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	revs := h.revisions[key]
+	if len(revs) == 0 {
+		return ConfigRevision{}, false
+	}
+	if version == "" {
+		return revs[len(revs)-1], true
+	}
+	for i := len(revs) - 1; i >= 0; i-- {
+		if revs[i].LedgerVersion == version || (!revs[i].Deleted && revs[i].ResourceVersion == version) {
+			return revs[i], true
+		}
+	}
+	return ConfigRevision{}, false
+}
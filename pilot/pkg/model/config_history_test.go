@@ -0,0 +1,119 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"testing"
+	"time"
+
+	networking "istio.io/api/networking/v1alpha3"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/schema/gvk"
+)
+
+func TestConfigHistory(t *testing.T) {
+	vs := func(rv string, host string) config.Config {
+		return config.Config{
+			Meta: config.Meta{
+				GroupVersionKind: gvk.VirtualService,
+				Name:             "reviews",
+				Namespace:        "default",
+				ResourceVersion:  rv,
+			},
+			Spec: &networking.VirtualService{Hosts: []string{host}},
+		}
+	}
+	key := config.Key(gvk.VirtualService.Kind, "reviews", "default")
+
+	h := NewConfigHistory(3, time.Hour)
+	h.Record(vs("1", "a"), "ledger1", false)
+	// Duplicate events are ignored
+	h.Record(vs("1", "a"), "ledger2", false)
+	h.Record(vs("2", "b"), "ledger3", false)
+
+	revs := h.Revisions(key)
+	if len(revs) != 2 {
+		t.Fatalf("expected 2 revisions, got %v", revs)
+	}
+	if revs[0].ResourceVersion != "1" || revs[1].LedgerVersion != "ledger3" {
+		t.Fatalf("unexpected revisions %v", revs)
+	}
+
+	// The recorded config is a copy
+	orig := vs("3", "c")
+	h.Record(orig, "ledger4", false)
+	orig.Spec.(*networking.VirtualService).Hosts[0] = "changed"
+	rev, f := h.Revision(key, "3")
+	if !f || rev.Config.Spec.(*networking.VirtualService).Hosts[0] != "c" {
+		t.Fatalf("expected a copy of the config, got %v", rev.Config)
+	}
+
+	h.Record(vs("3", "c"), "ledger5", true)
+	revs = h.Revisions(key)
+	if len(revs) != 3 {
+		t.Fatalf("expected revisions to be capped to 3, got %v", revs)
+	}
+	if revs[0].ResourceVersion != "2" {
+		t.Fatalf("expected oldest revision to be dropped, got %v", revs)
+	}
+	latest, f := h.Revision(key, "")
+	if !f || !latest.Deleted || latest.ResourceVersion != "" || latest.Config == nil {
+		t.Fatalf("expected latest revision to be the deletion, got %+v", latest)
+	}
+	if rev, f := h.Revision(key, "ledger4"); !f || rev.ResourceVersion != "3" {
+		t.Fatalf("expected lookup by ledger version, got %+v", rev)
+	}
+	if _, f := h.Revision(key, "1"); f {
+		t.Fatalf("expected dropped revision to be missing")
+	}
+	if _, f := h.Revision("VirtualService/default/other", ""); f {
+		t.Fatalf("expected unknown resource to be missing")
+	}
+}
+
+func TestConfigHistoryDropsDeleted(t *testing.T) {
+	vs := func(name string) config.Config {
+		return config.Config{
+			Meta: config.Meta{
+				GroupVersionKind: gvk.VirtualService,
+				Name:             name,
+				Namespace:        "default",
+				ResourceVersion:  "1",
+			},
+			Spec: &networking.VirtualService{},
+		}
+	}
+	deletedKey := config.Key(gvk.VirtualService.Kind, "deleted", "default")
+	recreatedKey := config.Key(gvk.VirtualService.Kind, "recreated", "default")
+
+	h := NewConfigHistory(3, 100*time.Millisecond)
+	h.Record(vs("deleted"), "ledger1", false)
+	h.Record(vs("deleted"), "ledger2", true)
+	h.Record(vs("recreated"), "ledger3", false)
+	h.Record(vs("recreated"), "ledger4", true)
+	h.Record(vs("recreated"), "ledger5", false)
+	if len(h.Revisions(deletedKey)) != 2 {
+		t.Fatalf("expected the history of the deleted resource to be kept within the retention, got %v", h.Revisions(deletedKey))
+	}
+
+	time.Sleep(200 * time.Millisecond)
+	h.Record(vs("other"), "ledger6", false)
+	if revs := h.Revisions(deletedKey); len(revs) != 0 {
+		t.Fatalf("expected the history of the deleted resource to be dropped, got %v", revs)
+	}
+	if revs := h.Revisions(recreatedKey); len(revs) != 3 {
+		t.Fatalf("expected the history of the recreated resource to be kept, got %v", revs)
+	}
+}
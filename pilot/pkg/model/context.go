@@ -75,6 +75,10 @@ type Environment struct {
 	// DomainSuffix provides a default domain for the Istio server.
 	DomainSuffix string
 
+	// FederatedTrustDomains are the trust domains of other meshes whose root certs are trusted through their
+	// SPIFFE bundle endpoints.
+	FederatedTrustDomains []string
+
 	ledger ledger.Ledger
 }
 
@@ -273,9 +277,16 @@ type WatchedResource struct {
 	// NonceNacked is the last nacked message. This is reset following a successful ACK
 	NonceNacked string
 
+	// NackError is the error detail of the last nacked message. This is reset with NonceNacked.
+	NackError string
+
 	// LastSent tracks the time of the generated push, to determine the time it takes the client to ack.
 	LastSent time.Time
 
+	// LastAcked tracks the time of the last ACK. If NonceAcked is NonceSent, the client took
+	// LastAcked - LastSent to apply the last push.
+	LastAcked time.Time
+
 	// Updates count the number of generated updates for the resource
 	Updates int
 
@@ -287,7 +298,15 @@ type WatchedResource struct {
 	// and may use the information in DiscoveryRequest.
 	// Note that Envoy may send multiple requests for the same type, for
 	// example to update the set of watched resources or to ACK/NACK.
+	// For incremental (Delta) XDS clients this is the equivalent state of the world request,
+	// with ResourceNames holding the full set of subscribed resources.
 	LastRequest *discovery.DiscoveryRequest
+
+	// ResourceVersions tracks the version of each resource last sent to the client, keyed by resource name.
+	// This is only used by incremental (Delta) XDS connections, to determine which resources were added,
+	// changed or removed since the previous response. It is seeded from the initial_resource_versions
+	// sent by a reconnecting client.
+	ResourceVersions map[string]string
 }
 
 var (
@@ -89,6 +89,10 @@ var (
 		"pilot_jwks_resolver_network_fetch_fail_total",
 		"Total number of failed network fetch by pilot jwks resolver",
 	)
+	keyStalenessGauge = monitoring.NewGauge(
+		"pilot_jwks_resolver_key_staleness_seconds",
+		"Seconds since the least recently refreshed cached JWT public key was successfully fetched",
+	)
 
 	// jwtKeyResolverOnce lazy init jwt key resolver
 	jwtKeyResolverOnce sync.Once
@@ -135,10 +139,21 @@ type JwksResolver struct {
 
 	// How many times refresh job failed to fetch the public key from network, used in unit test.
 	refreshJobFetchFailedCount uint64
+
+	// The jwks_uri last resolved through OpenID discovery for each issuer, used when the discovery fails.
+	// map key is issuer, map value is jwksURI.
+	resolvedJwksURIs sync.Map
+
+	// store persists the cached keys, it's nil if persistence is disabled.
+	store      JwksStore
+	storeMutex sync.Mutex
+	// storeDirty is 1 if the cached keys or jwks_uri changed since they were last persisted. They are persisted by
+	// the refresh job, so that the pushes don't write to the store.
+	storeDirty int32
 }
 
 func init() {
-	monitoring.MustRegister(networkFetchSuccessCounter, networkFetchFailCounter)
+	monitoring.MustRegister(networkFetchSuccessCounter, networkFetchFailCounter, keyStalenessGauge)
 }
 
 // NewJwksResolver creates new instance of JwksResolver.
@@ -205,6 +220,34 @@ func newJwksResolverWithCABundlePaths(evictionDuration, refreshInterval, retryIn
 	return ret
 }
 
+// SetStore sets the store used to persist the fetched keys, and loads the keys persisted by a previous Istiod
+// instance into the cache, so that they are served until they can be refreshed from the network.
+func (r *JwksResolver) SetStore(store JwksStore) error {
+	r.storeMutex.Lock()
+	r.store = store
+	r.storeMutex.Unlock()
+
+	c, err := store.Load()
+	if err != nil || c == nil {
+		return err
+	}
+	now := time.Now()
+	for issuer, uri := range c.JwksURIs {
+		r.resolvedJwksURIs.Store(issuer, uri)
+	}
+	for uri, k := range c.Keys {
+		// Keys already fetched by this instance are more recent.
+		r.keyEntries.LoadOrStore(uri, jwtPubKeyEntry{
+			pubKey:            k.PubKey,
+			lastRefreshedTime: k.FetchedTime,
+			lastUsedTime:      now,
+		})
+	}
+	log.Infof("Loaded %d persisted JWT public keys", len(c.Keys))
+	r.updateStaleness()
+	return nil
+}
+
 // ResolveJwksURI sets jwks_uri through openID discovery if it's not set in request authentication policy.
 func (r *JwksResolver) ResolveJwksURI(policy *v1beta1.RequestAuthentication) {
 	for _, rule := range policy.JwtRules {
@@ -242,6 +285,7 @@ func (r *JwksResolver) GetPublicKey(jwksURI string) (string, error) {
 		lastRefreshedTime: now,
 		lastUsedTime:      now,
 	})
+	atomic.StoreInt32(&r.storeDirty, 1)
 
 	return pubKey, nil
 }
@@ -257,6 +301,10 @@ func (r *JwksResolver) resolveJwksURIUsingOpenID(issuer string) (string, error)
 	body, err := r.getRemoteContentWithRetry(issuer+openIDDiscoveryCfgURLSuffix, networkFetchRetryCountOnMainFlow)
 	if err != nil {
 		log.Errorf("Failed to fetch jwks_uri from %q: %v", issuer+openIDDiscoveryCfgURLSuffix, err)
+		if uri, found := r.resolvedJwksURIs.Load(issuer); found {
+			log.Warnf("Using last known jwks_uri %q for issuer %q", uri, issuer)
+			return uri.(string), nil
+		}
 		return "", err
 	}
 	var data map[string]interface{}
@@ -271,6 +319,10 @@ func (r *JwksResolver) resolveJwksURIUsingOpenID(issuer string) (string, error)
 
 	// Set JwksUri in cache.
 	r.JwksURICache.Set(issuer, jwksURI)
+	if old, found := r.resolvedJwksURIs.Load(issuer); !found || old.(string) != jwksURI {
+		r.resolvedJwksURIs.Store(issuer, jwksURI)
+		atomic.StoreInt32(&r.storeDirty, 1)
+	}
 
 	return jwksURI, nil
 }
@@ -364,6 +416,7 @@ func (r *JwksResolver) refresh() {
 			log.Infof("Removed cached JWT public key (lastRefreshed: %s, lastUsed: %s) from %q",
 				e.lastRefreshedTime, e.lastUsedTime, jwksURI)
 			r.keyEntries.Delete(jwksURI)
+			atomic.StoreInt32(&r.storeDirty, 1)
 			return true
 		}
 
@@ -378,7 +431,8 @@ func (r *JwksResolver) refresh() {
 
 			resp, err := r.getRemoteContentWithRetry(jwksURI, networkFetchRetryCountOnRefreshFlow)
 			if err != nil {
-				log.Errorf("Failed to refresh JWT public key from %q: %v", jwksURI, err)
+				log.Errorf("Failed to refresh JWT public key from %q, keep serving the key fetched at %s: %v",
+					jwksURI, e.lastRefreshedTime, err)
 				atomic.AddUint64(&r.refreshJobFetchFailedCount, 1)
 				return
 			}
@@ -388,6 +442,7 @@ func (r *JwksResolver) refresh() {
 				lastRefreshedTime: now,            // update the lastRefreshedTime if we get a success response from the network.
 				lastUsedTime:      e.lastUsedTime, // keep original lastUsedTime.
 			})
+			atomic.StoreInt32(&r.storeDirty, 1)
 			isNewKey, err := compareJWKSResponse(oldPubKey, newPubKey)
 			if err != nil {
 				log.Errorf("Failed to refresh JWT public key from %q: %v", jwksURI, err)
@@ -405,6 +460,9 @@ func (r *JwksResolver) refresh() {
 	// Wait for all go routine to complete.
 	wg.Wait()
 
+	r.updateStaleness()
+	r.flush()
+
 	if hasChange {
 		atomic.AddUint64(&r.refreshJobKeyChangedCount, 1)
 		// Push public key changes to sidecars.
@@ -414,6 +472,107 @@ func (r *JwksResolver) refresh() {
 	}
 }
 
+// Prewarm fetches the public keys of all the issuers of the policies, so that they are cached, and persisted,
+// before they are needed by a push. Keys that cannot be fetched keep being served from the cache.
+func (r *JwksResolver) Prewarm(policies []*v1beta1.RequestAuthentication) {
+	uris := map[string]struct{}{}
+	for _, policy := range policies {
+		for _, rule := range policy.JwtRules {
+			if rule.Jwks != "" {
+				continue
+			}
+			uri := rule.JwksUri
+			if uri == "" {
+				var err error
+				if uri, err = r.resolveJwksURIUsingOpenID(rule.Issuer); err != nil {
+					log.Warnf("Failed to get jwks_uri for issuer %q: %v", rule.Issuer, err)
+					continue
+				}
+			}
+			uris[uri] = struct{}{}
+		}
+	}
+
+	var wg sync.WaitGroup
+	var changed int32
+	for uri := range uris {
+		wg.Add(1)
+		go func(jwksURI string) {
+			defer wg.Done()
+			resp, err := r.getRemoteContentWithRetry(jwksURI, networkFetchRetryCountOnRefreshFlow)
+			if err != nil {
+				log.Warnf("Failed to prewarm JWT public key from %q: %v", jwksURI, err)
+				return
+			}
+			now := time.Now()
+			newPubKey := string(resp)
+			e := jwtPubKeyEntry{pubKey: newPubKey, lastRefreshedTime: now, lastUsedTime: now}
+			if val, found := r.keyEntries.Load(jwksURI); found {
+				old := val.(jwtPubKeyEntry)
+				e.lastUsedTime = old.lastUsedTime
+				if isNewKey, err := compareJWKSResponse(old.pubKey, newPubKey); err != nil {
+					log.Errorf("Failed to prewarm JWT public key from %q: %v", jwksURI, err)
+					return
+				} else if isNewKey {
+					atomic.StoreInt32(&changed, 1)
+				}
+			}
+			r.keyEntries.Store(jwksURI, e)
+			atomic.StoreInt32(&r.storeDirty, 1)
+		}(uri)
+	}
+	wg.Wait()
+	log.Infof("Prewarmed JWT public keys of %d jwks_uri", len(uris))
+
+	r.updateStaleness()
+	r.flush()
+
+	// Keys loaded from the store may have been pushed before they were refreshed.
+	if atomic.LoadInt32(&changed) == 1 && r.PushFunc != nil {
+		r.PushFunc()
+	}
+}
+
+// flush saves the cached keys to the store, if any, when they changed since they were last saved.
+func (r *JwksResolver) flush() {
+	r.storeMutex.Lock()
+	defer r.storeMutex.Unlock()
+	if r.store == nil || !atomic.CompareAndSwapInt32(&r.storeDirty, 1, 0) {
+		return
+	}
+	c := &JwksCache{
+		JwksURIs: map[string]string{},
+		Keys:     map[string]CachedJwks{},
+	}
+	r.resolvedJwksURIs.Range(func(key interface{}, value interface{}) bool {
+		c.JwksURIs[key.(string)] = value.(string)
+		return true
+	})
+	r.keyEntries.Range(func(key interface{}, value interface{}) bool {
+		e := value.(jwtPubKeyEntry)
+		c.Keys[key.(string)] = CachedJwks{PubKey: e.pubKey, FetchedTime: e.lastRefreshedTime}
+		return true
+	})
+	if err := r.store.Save(c); err != nil {
+		log.Errorf("Failed to persist JWT public keys: %v", err)
+		// Retry with the next refresh.
+		atomic.StoreInt32(&r.storeDirty, 1)
+	}
+}
+
+// updateStaleness records the age of the least recently refreshed cached key.
+func (r *JwksResolver) updateStaleness() {
+	now := time.Now()
+	var staleness time.Duration
+	r.keyEntries.Range(func(_ interface{}, value interface{}) bool {
+		if age := now.Sub(value.(jwtPubKeyEntry).lastRefreshedTime); age > staleness {
+			staleness = age
+		}
+		return true
+	})
+	keyStalenessGauge.Record(staleness.Seconds())
+}
+
 // Shut down the refresher job.
 // TODO: may need to figure out the right place to call this function.
 // (right now calls it from initDiscoveryService in pkg/bootstrap/server.go).
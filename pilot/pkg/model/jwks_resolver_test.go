@@ -16,6 +16,9 @@ package model
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"reflect"
 	"sync/atomic"
 	"testing"
@@ -538,6 +541,128 @@ func verifyKeyLastRefreshedTime(t *testing.T, r *JwksResolver, ms *test.MockOpen
 	}
 }
 
+func TestJwksStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jwks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	store := NewFileJwksStore(filepath.Join(dir, "jwks.json"))
+
+	ms := startMockServer(t)
+	mockCertURL := ms.URL + "/oauth2/v3/certs"
+	policy := &v1beta1.RequestAuthentication{
+		JwtRules: []*v1beta1.JWTRule{{Issuer: ms.URL}},
+	}
+
+	r := NewJwksResolver(JwtPubKeyEvictionDuration, JwtPubKeyRefreshInterval, testRetryInterval)
+	defer r.Close()
+	if err := r.SetStore(store); err != nil {
+		t.Fatal(err)
+	}
+	r.Prewarm([]*v1beta1.RequestAuthentication{policy})
+	if got := atomic.LoadUint64(&ms.PubKeyHitNum); got != 1 {
+		t.Errorf("expected the key to be fetched once by Prewarm, got %d", got)
+	}
+
+	// A new instance serves the persisted keys while the identity provider is unreachable.
+	_ = ms.Stop()
+	restarted := NewJwksResolver(JwtPubKeyEvictionDuration, JwtPubKeyRefreshInterval, testRetryInterval)
+	defer restarted.Close()
+	if err := restarted.SetStore(store); err != nil {
+		t.Fatal(err)
+	}
+	restarted.ResolveJwksURI(policy)
+	if policy.JwtRules[0].JwksUri != mockCertURL {
+		t.Errorf("expected the persisted jwks_uri %q, got %q", mockCertURL, policy.JwtRules[0].JwksUri)
+	}
+	pk, err := restarted.GetPublicKey(mockCertURL)
+	if err != nil {
+		t.Fatalf("expected the persisted key, got error %v", err)
+	}
+	if pk != test.JwtPubKey1 {
+		t.Errorf("expected the persisted key %s, got %s", test.JwtPubKey1, pk)
+	}
+}
+
+type memJwksStore struct {
+	cache *JwksCache
+}
+
+func (s *memJwksStore) Load() (*JwksCache, error) {
+	return s.cache, nil
+}
+
+func (s *memJwksStore) Save(c *JwksCache) error {
+	s.cache = c
+	return nil
+}
+
+func TestJwksStoreFlushedByRefresh(t *testing.T) {
+	ms := startMockServer(t)
+	defer ms.Stop()
+	mockCertURL := ms.URL + "/oauth2/v3/certs"
+
+	store := &memJwksStore{}
+	r := NewJwksResolver(JwtPubKeyEvictionDuration, JwtPubKeyRefreshInterval, testRetryInterval)
+	defer r.Close()
+	if err := r.SetStore(store); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.GetPublicKey(mockCertURL); err != nil {
+		t.Fatal(err)
+	}
+	if store.cache != nil {
+		t.Errorf("expected the fetched key not to be persisted by the push, got %+v", store.cache)
+	}
+
+	r.refresh()
+	if got := store.cache; got == nil || got.Keys[mockCertURL].PubKey != test.JwtPubKey1 {
+		t.Fatalf("expected the fetched key to be persisted by the refresh, got %+v", got)
+	}
+
+	// Nothing is written when nothing changed.
+	store.cache = nil
+	r.flush()
+	if store.cache != nil {
+		t.Errorf("expected the unchanged keys not to be persisted again, got %+v", store.cache)
+	}
+}
+
+func TestPrewarmPushesChangedKeys(t *testing.T) {
+	ms := startMockServer(t)
+	defer ms.Stop()
+	mockCertURL := ms.URL + "/oauth2/v3/certs"
+
+	fetched := time.Now().Add(-time.Hour)
+	store := &memJwksStore{cache: &JwksCache{
+		Keys: map[string]CachedJwks{mockCertURL: {PubKey: test.JwtPubKey2, FetchedTime: fetched}},
+	}}
+	r := NewJwksResolver(JwtPubKeyEvictionDuration, JwtPubKeyRefreshInterval, testRetryInterval)
+	defer r.Close()
+	var pushes int32
+	r.PushFunc = func() {
+		atomic.AddInt32(&pushes, 1)
+	}
+	if err := r.SetStore(store); err != nil {
+		t.Fatal(err)
+	}
+	e, _ := r.keyEntries.Load(mockCertURL)
+	if !e.(jwtPubKeyEntry).lastRefreshedTime.Equal(fetched) {
+		t.Errorf("expected the key to be loaded with its fetched time %v, got %v", fetched, e.(jwtPubKeyEntry).lastRefreshedTime)
+	}
+
+	r.Prewarm([]*v1beta1.RequestAuthentication{{
+		JwtRules: []*v1beta1.JWTRule{{Issuer: "https://example.com", JwksUri: mockCertURL}},
+	}})
+	if got := atomic.LoadInt32(&pushes); got != 1 {
+		t.Errorf("expected a push for the changed key, got %d", got)
+	}
+	if got := store.cache.Keys[mockCertURL]; got.PubKey != test.JwtPubKey1 || !got.FetchedTime.After(fetched) {
+		t.Errorf("expected the refreshed key to be persisted, got %+v", got)
+	}
+}
+
 func TestCompareJWKSResponse(t *testing.T) {
 	type args struct {
 		oldKeyString string
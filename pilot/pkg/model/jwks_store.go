@@ -0,0 +1,103 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// JwksStore persists what the JwksResolver fetched from the network, so that Istiod can serve the last known
+// good public keys when it restarts while the identity providers are unreachable.
+type JwksStore interface {
+	// Load returns the persisted cache, or nil if nothing was persisted yet.
+	Load() (*JwksCache, error)
+	// Save persists the cache, replacing the previous one.
+	Save(*JwksCache) error
+}
+
+// JwksCache is the content of a JwksStore.
+type JwksCache struct {
+	// JwksURIs maps the issuers to the jwks_uri resolved through OpenID discovery.
+	JwksURIs map[string]string `json:"jwksUris,omitempty"`
+	// Keys maps the jwks_uri to the public keys fetched from it.
+	Keys map[string]CachedJwks `json:"keys,omitempty"`
+}
+
+// CachedJwks is a JWKS fetched from a jwks_uri.
+type CachedJwks struct {
+	PubKey string `json:"pubKey"`
+	// FetchedTime is the last time the JWKS was successfully fetched.
+	FetchedTime time.Time `json:"fetchedTime"`
+}
+
+// EncodeJwksCache encodes the cache as JSON.
+func EncodeJwksCache(c *JwksCache) ([]byte, error) {
+	return json.Marshal(c)
+}
+
+// DecodeJwksCache decodes a cache encoded by EncodeJwksCache.
+func DecodeJwksCache(data []byte) (*JwksCache, error) {
+	c := &JwksCache{}
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+type fileJwksStore struct {
+	path string
+}
+
+// NewFileJwksStore returns a JwksStore persisting the cache to a local file, which should be on a volume
+// that outlives the Istiod pod.
+func NewFileJwksStore(path string) JwksStore {
+	return &fileJwksStore{path: path}
+}
+
+func (s *fileJwksStore) Load() (*JwksCache, error) {
+	data, err := ioutil.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return DecodeJwksCache(data)
+}
+
+func (s *fileJwksStore) Save(c *JwksCache) error {
+	data, err := EncodeJwksCache(c)
+	if err != nil {
+		return err
+	}
+	// Write to a temporary file first so that a crash never leaves a truncated cache.
+	tmp, err := ioutil.TempFile(filepath.Dir(s.path), filepath.Base(s.path))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
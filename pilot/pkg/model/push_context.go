@@ -182,6 +182,9 @@ type PushContext struct {
 	// envoy filters for each namespace including global config namespace
 	envoyFiltersByNamespace map[string][]*EnvoyFilterWrapper
 
+	// rate limits for each namespace including global config namespace
+	rateLimitsByNamespace map[string][]*RateLimitWrapper
+
 	// The following data is either a global index or used in the inbound path.
 	// Namespace specific views do not apply here.
 
@@ -195,6 +198,9 @@ type PushContext struct {
 	// Networks configuration.
 	MeshNetworks *meshconfig.MeshNetworks `json:"-"`
 
+	// FederatedTrustDomains are the trust domains of other meshes whose root certs are trusted.
+	FederatedTrustDomains []string `json:"-"`
+
 	// Discovery interface for listing services and instances.
 	ServiceDiscovery `json:"-"`
 
@@ -526,6 +532,7 @@ func NewPushContext() *PushContext {
 		destinationRuleIndex:    newDestinationRuleIndex(),
 		sidecarsByNamespace:     map[string][]*SidecarScope{},
 		envoyFiltersByNamespace: map[string][]*EnvoyFilterWrapper{},
+		rateLimitsByNamespace:   map[string][]*RateLimitWrapper{},
 		gatewayIndex:            newGatewayIndex(),
 		ProxyStatus:             map[string]map[string]ProxyPushStatus{},
 		ServiceAccounts:         map[host.Name]map[int][]string{},
@@ -888,6 +895,7 @@ func (ps *PushContext) InitContext(env *Environment, oldPushContext *PushContext
 
 	ps.Mesh = env.Mesh()
 	ps.MeshNetworks = env.Networks()
+	ps.FederatedTrustDomains = env.FederatedTrustDomains
 	ps.ServiceDiscovery = env
 	ps.IstioConfigStore = env
 	ps.Version = env.Version()
@@ -943,6 +951,10 @@ func (ps *PushContext) createNewContext(env *Environment) error {
 		return err
 	}
 
+	if err := ps.initRateLimits(env); err != nil {
+		return err
+	}
+
 	if err := ps.initGateways(env); err != nil {
 		return err
 	}
@@ -960,7 +972,7 @@ func (ps *PushContext) updateContext(
 	pushReq *PushRequest) error {
 
 	var servicesChanged, virtualServicesChanged, destinationRulesChanged, gatewayChanged,
-		authnChanged, authzChanged, envoyFiltersChanged, sidecarsChanged bool
+		authnChanged, authzChanged, envoyFiltersChanged, rateLimitsChanged, sidecarsChanged bool
 
 	for conf := range pushReq.ConfigsUpdated {
 		switch conf.Kind {
@@ -976,6 +988,8 @@ func (ps *PushContext) updateContext(
 			sidecarsChanged = true
 		case gvk.EnvoyFilter:
 			envoyFiltersChanged = true
+		case gvk.RateLimit:
+			rateLimitsChanged = true
 		case gvk.AuthorizationPolicy:
 			authzChanged = true
 		case gvk.RequestAuthentication,
@@ -1039,6 +1053,14 @@ func (ps *PushContext) updateContext(
 		ps.envoyFiltersByNamespace = oldPushContext.envoyFiltersByNamespace
 	}
 
+	if rateLimitsChanged {
+		if err := ps.initRateLimits(env); err != nil {
+			return err
+		}
+	} else {
+		ps.rateLimitsByNamespace = oldPushContext.rateLimitsByNamespace
+	}
+
 	if gatewayChanged {
 		if err := ps.initGateways(env); err != nil {
 			return err
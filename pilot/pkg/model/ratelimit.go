@@ -0,0 +1,87 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"istio.io/istio/pkg/config"
+	ratelimit "istio.io/istio/pkg/config/apis/ratelimit/v1alpha1"
+	"istio.io/istio/pkg/config/labels"
+	"istio.io/istio/pkg/config/schema/gvk"
+)
+
+// RateLimitWrapper is a wrapper for the RateLimit api object with pre-processed data
+type RateLimitWrapper struct {
+	Name             string
+	Namespace        string
+	Spec             *ratelimit.RateLimit
+	workloadSelector labels.Instance
+}
+
+func convertToRateLimitWrapper(cfg config.Config) *RateLimitWrapper {
+	spec := cfg.Spec.(*ratelimit.RateLimit)
+	out := &RateLimitWrapper{
+		Name:      cfg.Name,
+		Namespace: cfg.Namespace,
+		Spec:      spec,
+	}
+	if spec.WorkloadSelector != nil {
+		out.workloadSelector = spec.WorkloadSelector.MatchLabels
+	}
+	return out
+}
+
+// pre computes rate limits per namespace
+func (ps *PushContext) initRateLimits(env *Environment) error {
+	rateLimitConfigs, err := env.List(gvk.RateLimit, NamespaceAll)
+	if err != nil {
+		return err
+	}
+
+	sortConfigByCreationTime(rateLimitConfigs)
+
+	ps.rateLimitsByNamespace = make(map[string][]*RateLimitWrapper)
+	for _, rateLimitConfig := range rateLimitConfigs {
+		ps.rateLimitsByNamespace[rateLimitConfig.Namespace] = append(ps.rateLimitsByNamespace[rateLimitConfig.Namespace],
+			convertToRateLimitWrapper(rateLimitConfig))
+	}
+	return nil
+}
+
+// RateLimits returns the rate limits applied to the proxy: the ones of the config root namespace
+// followed by the ones of the proxy namespace, oldest first.
+func (ps *PushContext) RateLimits(proxy *Proxy) []*RateLimitWrapper {
+	var workloadLabels labels.Collection
+	// This should never happen except in tests.
+	if proxy.Metadata != nil && len(proxy.Metadata.Labels) > 0 {
+		workloadLabels = labels.Collection{proxy.Metadata.Labels}
+	}
+
+	var out []*RateLimitWrapper
+	match := func(namespace string) {
+		for _, rl := range ps.rateLimitsByNamespace[namespace] {
+			if rl.workloadSelector == nil || workloadLabels.IsSupersetOf(rl.workloadSelector) {
+				out = append(out, rl)
+			}
+		}
+	}
+	if ps.Mesh.RootNamespace != "" {
+		match(ps.Mesh.RootNamespace)
+	}
+	// To prevent duplicate rate limits in case root namespace equals proxy's namespace
+	if proxy.ConfigNamespace != ps.Mesh.RootNamespace {
+		match(proxy.ConfigNamespace)
+	}
+	return out
+}
@@ -244,6 +244,16 @@ func (instance *ServiceInstance) DeepCopy() *ServiceInstance {
 	}
 }
 
+const (
+	// WorkloadEntryHealthyCondition is the type of the WorkloadEntry status condition set from the health checks
+	// run by istio-agent. Endpoints of a WorkloadEntry with a false condition are not served.
+	WorkloadEntryHealthyCondition = "Healthy"
+
+	// WorkloadEntryConditionTrue and WorkloadEntryConditionFalse are the possible statuses of a WorkloadEntry condition
+	WorkloadEntryConditionTrue  = "True"
+	WorkloadEntryConditionFalse = "False"
+)
+
 type WorkloadInstance struct {
 	Name      string            `json:"name,omitempty"`
 	Namespace string            `json:"namespace,omitempty"`
@@ -44,6 +44,7 @@ var (
 	clusterScopedConfigTypes = map[config.GroupVersionKind]struct{}{
 		gvk.Sidecar:               {},
 		gvk.EnvoyFilter:           {},
+		gvk.RateLimit:             {},
 		gvk.AuthorizationPolicy:   {},
 		gvk.RequestAuthentication: {},
 	}
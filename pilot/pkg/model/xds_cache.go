@@ -16,6 +16,8 @@ package model
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 	"sync"
 
 	"github.com/golang/protobuf/ptypes/any"
@@ -30,6 +32,9 @@ func init() {
 	monitoring.MustRegister(xdsCacheReads)
 	monitoring.MustRegister(xdsCacheEvictions)
 	monitoring.MustRegister(xdsCacheSize)
+	monitoring.MustRegister(xdsCacheResourceReads)
+	monitoring.MustRegister(xdsCacheResourceEvictions)
+	monitoring.MustRegister(xdsCacheBytes)
 }
 
 var (
@@ -51,32 +56,108 @@ var (
 
 	xdsCacheHits   = xdsCacheReads.With(typeTag.Value("hit"))
 	xdsCacheMisses = xdsCacheReads.With(typeTag.Value("miss"))
+
+	resourceTag = monitoring.MustCreateLabel("resource")
+
+	xdsCacheResourceReads = monitoring.NewSum(
+		"xds_cache_resource_reads",
+		"Total number of xds cache reads, by resource type.",
+		monitoring.WithLabels(typeTag, resourceTag),
+	)
+
+	xdsCacheResourceEvictions = monitoring.NewSum(
+		"xds_cache_resource_evictions",
+		"Total number of xds cache evictions, by resource type.",
+		monitoring.WithLabels(resourceTag),
+	)
+
+	xdsCacheBytes = monitoring.NewGauge(
+		"xds_cache_bytes",
+		"Current size in bytes of the xds cache entries, by resource type",
+		monitoring.WithLabels(resourceTag),
+	)
+)
+
+// xdsCacheTypeMetrics are the cache metrics of a resource type, bound once to avoid building the labels on every read.
+type xdsCacheTypeMetrics struct {
+	hits      monitoring.Metric
+	misses    monitoring.Metric
+	evictions monitoring.Metric
+	bytes     monitoring.Metric
+}
+
+func newXdsCacheTypeMetrics(typeURL string) *xdsCacheTypeMetrics {
+	resource := resourceTag.Value(resourceType(typeURL))
+	return &xdsCacheTypeMetrics{
+		hits:      xdsCacheResourceReads.With(typeTag.Value("hit"), resource),
+		misses:    xdsCacheResourceReads.With(typeTag.Value("miss"), resource),
+		evictions: xdsCacheResourceEvictions.With(resource),
+		bytes:     xdsCacheBytes.With(resource),
+	}
+}
+
+var (
+	xdsCacheMetricsMu     sync.Mutex
+	xdsCacheMetricsByType = map[string]*xdsCacheTypeMetrics{}
 )
 
-func hit() {
+func xdsCacheMetrics(typeURL string) *xdsCacheTypeMetrics {
+	xdsCacheMetricsMu.Lock()
+	defer xdsCacheMetricsMu.Unlock()
+	m, f := xdsCacheMetricsByType[typeURL]
+	if !f {
+		m = newXdsCacheTypeMetrics(typeURL)
+		xdsCacheMetricsByType[typeURL] = m
+	}
+	return m
+}
+
+// resourceType returns the short name of the resource type, used to label the metrics. For example
+// type.googleapis.com/envoy.config.cluster.v3.Cluster is reported as Cluster.
+func resourceType(typeURL string) string {
+	if typeURL == "" {
+		return "unknown"
+	}
+	return typeURL[strings.LastIndex(typeURL, ".")+1:]
+}
+
+func hit(typeURL string) {
 	if features.EnableXDSCacheMetrics {
 		xdsCacheHits.Increment()
+		xdsCacheMetrics(typeURL).hits.Increment()
 	}
 }
 
-func miss() {
+func miss(typeURL string) {
 	if features.EnableXDSCacheMetrics {
 		xdsCacheMisses.Increment()
+		xdsCacheMetrics(typeURL).misses.Increment()
 	}
 }
 
-func evict(k interface{}, v interface{}) {
+func evict(typeURL string) {
 	if features.EnableXDSCacheMetrics {
 		xdsCacheEvictions.Increment()
+		xdsCacheMetrics(typeURL).evictions.Increment()
 	}
 }
 
+func evictLru(k interface{}, v interface{}) {
+	evict(v.(*any.Any).TypeUrl)
+}
+
 func size(cs int) {
 	if features.EnableXDSCacheMetrics {
 		xdsCacheSize.Record(float64(cs))
 	}
 }
 
+func bytesUsed(typeURL string, bytes int) {
+	if features.EnableXDSCacheMetrics {
+		xdsCacheMetrics(typeURL).bytes.Record(float64(bytes))
+	}
+}
+
 func indexConfig(configIndex map[ConfigKey]sets.Set, k string, entry XdsCacheEntry) {
 	for _, config := range entry.DependentConfigs() {
 		if configIndex[config] == nil {
@@ -99,6 +180,90 @@ type XdsCacheEntry interface {
 	Cacheable() bool
 }
 
+// typedXdsCacheEntry is implemented by the cache entries that know the type of the
+// resource they cache, so that misses can be accounted per resource type.
+type typedXdsCacheEntry interface {
+	// TypeURL is the type of the resource cached for this entry.
+	TypeURL() string
+}
+
+// entryTypeURL returns the type of the resource cached for the entry, if it is known.
+func entryTypeURL(entry XdsCacheEntry) string {
+	if typed, ok := entry.(typedXdsCacheEntry); ok {
+		return typed.TypeURL()
+	}
+	return ""
+}
+
+// XdsCacheTypeStats holds the usage of the cache for a single resource type.
+type XdsCacheTypeStats struct {
+	Entries   int    `json:"entries"`
+	Bytes     int    `json:"bytes"`
+	Hits      uint64 `json:"hits,omitempty"`
+	Misses    uint64 `json:"misses,omitempty"`
+	Evictions uint64 `json:"evictions,omitempty"`
+}
+
+// XdsCacheEntryInfo describes a single entry of the cache.
+type XdsCacheEntryInfo struct {
+	Key          string   `json:"key"`
+	TypeURL      string   `json:"typeUrl"`
+	Bytes        int      `json:"bytes"`
+	Dependencies []string `json:"dependencies,omitempty"`
+}
+
+// XdsCacheSnapshot is a point in time view of the content of the cache.
+type XdsCacheSnapshot struct {
+	// MaxBytes is the memory budget of the cache, if it has one.
+	MaxBytes int `json:"maxBytes,omitempty"`
+	// Bytes is the total size of all entries.
+	Bytes int `json:"bytes"`
+	// Types holds the usage of the cache per resource type URL.
+	Types map[string]*XdsCacheTypeStats `json:"types"`
+	// Entries are sorted by size, largest first.
+	Entries []XdsCacheEntryInfo `json:"entries"`
+}
+
+// entrySize approximates the memory used by a cache entry.
+func entrySize(key string, value *any.Any) int {
+	return len(key) + len(value.TypeUrl) + len(value.Value)
+}
+
+// newSnapshot builds the snapshot of a cache that does not track its own usage.
+func newSnapshot(values map[string]*any.Any, configIndex map[ConfigKey]sets.Set) XdsCacheSnapshot {
+	dependencies := map[string][]string{}
+	for config, keys := range configIndex {
+		for k := range keys {
+			dependencies[k] = append(dependencies[k], config.String())
+		}
+	}
+	out := XdsCacheSnapshot{Types: map[string]*XdsCacheTypeStats{}}
+	for k, v := range values {
+		sz := entrySize(k, v)
+		out.Bytes += sz
+		if out.Types[v.TypeUrl] == nil {
+			out.Types[v.TypeUrl] = &XdsCacheTypeStats{}
+		}
+		out.Types[v.TypeUrl].Entries++
+		out.Types[v.TypeUrl].Bytes += sz
+		out.Entries = append(out.Entries, XdsCacheEntryInfo{Key: k, TypeURL: v.TypeUrl, Bytes: sz, Dependencies: dependencies[k]})
+	}
+	sortSnapshot(&out)
+	return out
+}
+
+func sortSnapshot(snapshot *XdsCacheSnapshot) {
+	for _, e := range snapshot.Entries {
+		sort.Strings(e.Dependencies)
+	}
+	sort.Slice(snapshot.Entries, func(i, j int) bool {
+		if snapshot.Entries[i].Bytes != snapshot.Entries[j].Bytes {
+			return snapshot.Entries[i].Bytes > snapshot.Entries[j].Bytes
+		}
+		return snapshot.Entries[i].Key < snapshot.Entries[j].Key
+	})
+}
+
 // XdsCache interface defines a store for caching XDS responses.
 // All operations are thread safe.
 type XdsCache interface {
@@ -113,6 +278,9 @@ type XdsCache interface {
 	ClearAll()
 	// Keys returns all currently configured keys. This is for testing/debug only
 	Keys() []string
+	// Snapshot returns the entries of the cache, largest first, along with the
+	// usage per resource type. This is for testing/debug only
+	Snapshot() XdsCacheSnapshot
 }
 
 // inMemoryCache is a simple implementation of Cache that uses in memory map.
@@ -122,8 +290,14 @@ type inMemoryCache struct {
 	mu          sync.RWMutex
 }
 
-// NewXdsCache returns an instance of a cache.
+// NewXdsCache returns an instance of a cache. The implementation is selected by the
+// features: a cache bounded by a memory budget if PILOT_XDS_CACHE_MAX_BYTES is set,
+// otherwise a cache bounded by the number of entries, or an unbounded one if
+// PILOT_XDS_CACHE_SIZE is <= 0.
 func NewXdsCache() XdsCache {
+	if features.XDSCacheMaxBytes > 0 {
+		return newBudgetCache(features.XDSCacheMaxBytes)
+	}
 	if features.XDSCacheMaxSize <= 0 {
 		return &inMemoryCache{
 			store:       map[string]*any.Any{},
@@ -156,9 +330,9 @@ func (c *inMemoryCache) Get(entry XdsCacheEntry) (*any.Any, bool) {
 	defer c.mu.RUnlock()
 	k, f := c.store[entry.Key()]
 	if f {
-		hit()
+		hit(k.TypeUrl)
 	} else {
-		miss()
+		miss(entryTypeURL(entry))
 	}
 	return k, f
 }
@@ -194,6 +368,12 @@ func (c *inMemoryCache) Keys() []string {
 	return keys
 }
 
+func (c *inMemoryCache) Snapshot() XdsCacheSnapshot {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return newSnapshot(c.store, c.configIndex)
+}
+
 type lruCache struct {
 	store simplelru.LRUCache
 
@@ -204,7 +384,7 @@ type lruCache struct {
 var _ XdsCache = &lruCache{}
 
 func newLru() simplelru.LRUCache {
-	l, err := simplelru.NewLRU(features.XDSCacheMaxSize, evict)
+	l, err := simplelru.NewLRU(features.XDSCacheMaxSize, evictLru)
 	if err != nil {
 		panic(fmt.Errorf("invalid lru configuration: %v", err))
 	}
@@ -231,11 +411,12 @@ func (l *lruCache) Get(entry XdsCacheEntry) (*any.Any, bool) {
 	defer l.mu.Unlock()
 	val, ok := l.store.Get(entry.Key())
 	if !ok {
-		miss()
+		miss(entryTypeURL(entry))
 		return nil, false
 	}
-	hit()
-	return val.(*any.Any), true
+	value := val.(*any.Any)
+	hit(value.TypeUrl)
+	return value, true
 }
 
 func (l *lruCache) Clear(configs map[ConfigKey]struct{}) {
@@ -270,6 +451,18 @@ func (l *lruCache) Keys() []string {
 	return keys
 }
 
+func (l *lruCache) Snapshot() XdsCacheSnapshot {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	values := make(map[string]*any.Any, l.store.Len())
+	for _, ik := range l.store.Keys() {
+		if v, ok := l.store.Peek(ik); ok {
+			values[ik.(string)] = v.(*any.Any)
+		}
+	}
+	return newSnapshot(values, l.configIndex)
+}
+
 // DisabledCache is a cache that is always empty
 type DisabledCache struct{}
 
@@ -286,3 +479,7 @@ func (d DisabledCache) Clear(configsUpdated map[ConfigKey]struct{}) {}
 func (d DisabledCache) ClearAll() {}
 
 func (d DisabledCache) Keys() []string { return nil }
+
+func (d DisabledCache) Snapshot() XdsCacheSnapshot {
+	return XdsCacheSnapshot{Types: map[string]*XdsCacheTypeStats{}}
+}
@@ -0,0 +1,205 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/golang/protobuf/ptypes/any"
+
+	"istio.io/istio/pilot/pkg/util/sets"
+)
+
+// budgetCache is an XdsCache bounded by the total size of the cached resources rather than
+// by the number of entries. Once the budget is exceeded, the least recently used entries are
+// evicted. Usage is tracked per resource type.
+type budgetCache struct {
+	mu sync.Mutex
+
+	maxBytes int
+	bytes    int
+	// lru holds *budgetEntry, most recently used first
+	lru         *list.List
+	store       map[string]*list.Element
+	configIndex map[ConfigKey]sets.Set
+	types       map[string]*XdsCacheTypeStats
+}
+
+type budgetEntry struct {
+	key     string
+	value   *any.Any
+	size    int
+	configs []ConfigKey
+}
+
+var _ XdsCache = &budgetCache{}
+
+func newBudgetCache(maxBytes int) *budgetCache {
+	return &budgetCache{
+		maxBytes:    maxBytes,
+		lru:         list.New(),
+		store:       map[string]*list.Element{},
+		configIndex: map[ConfigKey]sets.Set{},
+		types:       map[string]*XdsCacheTypeStats{},
+	}
+}
+
+func (c *budgetCache) Add(entry XdsCacheEntry, value *any.Any) {
+	if !entry.Cacheable() {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	k := entry.Key()
+	if el, f := c.store[k]; f {
+		c.remove(el)
+	}
+	sz := entrySize(k, value)
+	if sz > c.maxBytes {
+		// Caching this would evict every other entry and then the entry itself.
+		log.Debugf("xds cache: not caching %s, size %d exceeds the budget of %d bytes", k, sz, c.maxBytes)
+		size(len(c.store))
+		return
+	}
+	c.store[k] = c.lru.PushFront(&budgetEntry{key: k, value: value, size: sz, configs: entry.DependentConfigs()})
+	indexConfig(c.configIndex, k, entry)
+	c.bytes += sz
+	stats := c.typeStats(value.TypeUrl)
+	stats.Entries++
+	stats.Bytes += sz
+	bytesUsed(value.TypeUrl, stats.Bytes)
+
+	for c.bytes > c.maxBytes {
+		e := c.remove(c.lru.Back())
+		c.typeStats(e.value.TypeUrl).Evictions++
+		evict(e.value.TypeUrl)
+	}
+	size(len(c.store))
+}
+
+func (c *budgetCache) Get(entry XdsCacheEntry) (*any.Any, bool) {
+	if !entry.Cacheable() {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, f := c.store[entry.Key()]
+	if !f {
+		typeURL := entryTypeURL(entry)
+		c.typeStats(typeURL).Misses++
+		miss(typeURL)
+		return nil, false
+	}
+	value := el.Value.(*budgetEntry).value
+	c.typeStats(value.TypeUrl).Hits++
+	hit(value.TypeUrl)
+	c.lru.MoveToFront(el)
+	return value, true
+}
+
+func (c *budgetCache) Clear(configs map[ConfigKey]struct{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for ckey := range configs {
+		referenced := c.configIndex[ckey]
+		delete(c.configIndex, ckey)
+		for key := range referenced {
+			if el, f := c.store[key]; f {
+				c.remove(el)
+			}
+		}
+	}
+	size(len(c.store))
+}
+
+func (c *budgetCache) ClearAll() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lru.Init()
+	c.store = map[string]*list.Element{}
+	c.configIndex = map[ConfigKey]sets.Set{}
+	c.bytes = 0
+	for typeURL, stats := range c.types {
+		stats.Entries = 0
+		stats.Bytes = 0
+		bytesUsed(typeURL, 0)
+	}
+	size(len(c.store))
+}
+
+func (c *budgetCache) Keys() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	keys := make([]string, 0, len(c.store))
+	for k := range c.store {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+func (c *budgetCache) Snapshot() XdsCacheSnapshot {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := XdsCacheSnapshot{
+		MaxBytes: c.maxBytes,
+		Bytes:    c.bytes,
+		Types:    make(map[string]*XdsCacheTypeStats, len(c.types)),
+		Entries:  make([]XdsCacheEntryInfo, 0, len(c.store)),
+	}
+	for typeURL, stats := range c.types {
+		s := *stats
+		out.Types[typeURL] = &s
+	}
+	for el := c.lru.Front(); el != nil; el = el.Next() {
+		e := el.Value.(*budgetEntry)
+		deps := make([]string, 0, len(e.configs))
+		for _, config := range e.configs {
+			deps = append(deps, config.String())
+		}
+		out.Entries = append(out.Entries, XdsCacheEntryInfo{Key: e.key, TypeURL: e.value.TypeUrl, Bytes: e.size, Dependencies: deps})
+	}
+	sortSnapshot(&out)
+	return out
+}
+
+// remove drops an entry from the cache, along with its references in the config index.
+func (c *budgetCache) remove(el *list.Element) *budgetEntry {
+	e := c.lru.Remove(el).(*budgetEntry)
+	delete(c.store, e.key)
+	for _, config := range e.configs {
+		if referenced, f := c.configIndex[config]; f {
+			delete(referenced, e.key)
+			if len(referenced) == 0 {
+				delete(c.configIndex, config)
+			}
+		}
+	}
+	c.bytes -= e.size
+	stats := c.typeStats(e.value.TypeUrl)
+	stats.Entries--
+	stats.Bytes -= e.size
+	bytesUsed(e.value.TypeUrl, stats.Bytes)
+	return e
+}
+
+func (c *budgetCache) typeStats(typeURL string) *XdsCacheTypeStats {
+	stats, f := c.types[typeURL]
+	if !f {
+		stats = &XdsCacheTypeStats{}
+		c.types[typeURL] = stats
+	}
+	return stats
+}
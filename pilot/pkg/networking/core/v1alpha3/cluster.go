@@ -41,6 +41,7 @@ import (
 	"istio.io/istio/pilot/pkg/util/sets"
 	"istio.io/istio/pkg/config/host"
 	"istio.io/istio/pkg/config/protocol"
+	"istio.io/istio/pkg/spiffe"
 	"istio.io/istio/pkg/util/gogo"
 	"istio.io/pkg/log"
 )
@@ -527,6 +528,8 @@ type buildClusterOpts struct {
 	proxy           *model.Proxy
 	meshExternal    bool
 	serviceMTLSMode model.MutualTLSMode
+	// federatedTrustDomains are the trust domains whose root certs are served in their own validation context.
+	federatedTrustDomains []string
 }
 
 type upgradeTuple struct {
@@ -890,6 +893,29 @@ func applyUpstreamTLSSettings(opts *buildClusterOpts, tls *networking.ClientTLSS
 	}
 }
 
+// rootResourceName returns the sdsconfig name of the root certs validating the subject alt names: the root
+// certs of a federated trust domain if they all belong to it, or the root certs of the CA otherwise.
+func rootResourceName(subjectAltNames, federatedTrustDomains []string) string {
+	if len(subjectAltNames) == 0 || len(federatedTrustDomains) == 0 {
+		return authn_model.SDSRootResourceName
+	}
+	trustDomain, err := spiffe.GetTrustDomainFromURISAN(subjectAltNames[0])
+	if err != nil {
+		return authn_model.SDSRootResourceName
+	}
+	for _, san := range subjectAltNames[1:] {
+		if td, err := spiffe.GetTrustDomainFromURISAN(san); err != nil || td != trustDomain {
+			return authn_model.SDSRootResourceName
+		}
+	}
+	for _, federated := range federatedTrustDomains {
+		if federated == trustDomain {
+			return authn_model.SDSFederatedRootResourceNamePrefix + trustDomain
+		}
+	}
+	return authn_model.SDSRootResourceName
+}
+
 var istioMtlsTransportSocketMatch = &structpb.Struct{
 	Fields: map[string]*structpb.Value{
 		model.TLSModeLabelShortname: {Kind: &structpb.Value_StringValue{StringValue: model.IstioMutualTLSModeLabel}},
@@ -942,7 +968,7 @@ func buildUpstreamClusterTLSContext(opts *buildClusterOpts, tls *networking.Clie
 			CombinedValidationContext: &auth.CommonTlsContext_CombinedCertificateValidationContext{
 				DefaultValidationContext: &auth.CertificateValidationContext{MatchSubjectAltNames: util.StringToExactMatch(tls.SubjectAltNames)},
 				ValidationContextSdsSecretConfig: authn_model.ConstructSdsSecretConfig(model.GetOrDefault(metadataSDS.GetRootResourceName(),
-					authn_model.SDSRootResourceName)),
+					rootResourceName(tls.SubjectAltNames, opts.federatedTrustDomains))),
 			},
 		}
 		// Set default SNI of cluster name for istio_mutual if sni is not set.
@@ -65,6 +65,8 @@ func (cb *ClusterBuilder) applyDestinationRule(c *cluster.Cluster, clusterMode C
 		clusterMode: clusterMode,
 		direction:   model.TrafficDirectionOutbound,
 		proxy:       cb.proxy,
+
+		federatedTrustDomains: cb.push.FederatedTrustDomains,
 	}
 
 	if clusterMode == DefaultClusterMode {
@@ -228,6 +230,8 @@ func (cb *ClusterBuilder) buildDefaultCluster(name string, discoveryType cluster
 		clusterMode:     DefaultClusterMode,
 		direction:       direction,
 		proxy:           cb.proxy,
+
+		federatedTrustDomains: cb.push.FederatedTrustDomains,
 	}
 	// decides whether the cluster corresponds to a service external to mesh or not.
 	if direction == model.TrafficDirectionInbound {
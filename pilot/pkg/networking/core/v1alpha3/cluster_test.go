@@ -3665,3 +3665,50 @@ func TestTelemetryMetadata(t *testing.T) {
 		})
 	}
 }
+
+func TestRootResourceName(t *testing.T) {
+	federated := []string{"partner.com"}
+	cases := []struct {
+		name                  string
+		subjectAltNames       []string
+		federatedTrustDomains []string
+		want                  string
+	}{
+		{
+			name:            "no federation",
+			subjectAltNames: []string{"spiffe://partner.com/ns/foo/sa/bar"},
+			want:            "ROOTCA",
+		},
+		{
+			name:                  "local trust domain",
+			subjectAltNames:       []string{"spiffe://cluster.local/ns/foo/sa/bar"},
+			federatedTrustDomains: federated,
+			want:                  "ROOTCA",
+		},
+		{
+			name:                  "federated trust domain",
+			subjectAltNames:       []string{"spiffe://partner.com/ns/foo/sa/bar", "spiffe://partner.com/ns/foo/sa/baz"},
+			federatedTrustDomains: federated,
+			want:                  "ROOTCA~partner.com",
+		},
+		{
+			name:                  "mixed trust domains",
+			subjectAltNames:       []string{"spiffe://partner.com/ns/foo/sa/bar", "spiffe://cluster.local/ns/foo/sa/bar"},
+			federatedTrustDomains: federated,
+			want:                  "ROOTCA",
+		},
+		{
+			name:                  "not a SPIFFE identity",
+			subjectAltNames:       []string{"partner.com"},
+			federatedTrustDomains: federated,
+			want:                  "ROOTCA",
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rootResourceName(tt.subjectAltNames, tt.federatedTrustDomains); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
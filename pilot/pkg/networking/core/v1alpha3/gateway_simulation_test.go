@@ -15,6 +15,7 @@
 package v1alpha3_test
 
 import (
+	"net/http"
 	"testing"
 
 	"istio.io/istio/pilot/pkg/model"
@@ -134,6 +135,78 @@ spec:
 				},
 			},
 		},
+		simulationTest{
+			name: "header match",
+			config: createGateway("gateway", "", httpServer) + `
+apiVersion: networking.istio.io/v1alpha3
+kind: VirtualService
+metadata:
+  name: bookinfo
+spec:
+  hosts:
+  - "*"
+  gateways:
+  - gateway
+  http:
+  - match:
+    - headers:
+        end-user:
+          exact: jason
+    route:
+    - destination:
+        host: productpage
+        port:
+          number: 9080
+  - route:
+    - destination:
+        host: productpage
+        port:
+          number: 9081
+`,
+			calls: []simulation.Expect{
+				{
+					"header match",
+					simulation.Call{
+						Port:       80,
+						HostHeader: "foo.bar",
+						Headers:    http.Header{"End-User": []string{"jason"}},
+						Protocol:   simulation.HTTP,
+					},
+					simulation.Result{
+						ListenerMatched:    "0.0.0.0_80",
+						VirtualHostMatched: "foo.bar:80",
+						ClusterMatched:     "outbound|9080||productpage.default",
+					},
+				},
+				{
+					"header mismatch",
+					simulation.Call{
+						Port:       80,
+						HostHeader: "foo.bar",
+						Headers:    http.Header{"End-User": []string{"not-jason"}},
+						Protocol:   simulation.HTTP,
+					},
+					simulation.Result{
+						ListenerMatched:    "0.0.0.0_80",
+						VirtualHostMatched: "foo.bar:80",
+						ClusterMatched:     "outbound|9081||productpage.default",
+					},
+				},
+				{
+					"header missing",
+					simulation.Call{
+						Port:       80,
+						HostHeader: "foo.bar",
+						Protocol:   simulation.HTTP,
+					},
+					simulation.Result{
+						ListenerMatched:    "0.0.0.0_80",
+						VirtualHostMatched: "foo.bar:80",
+						ClusterMatched:     "outbound|9081||productpage.default",
+					},
+				},
+			},
+		},
 		simulationTest{
 			name: "virtual service merging",
 			config: createGateway("gateway", "", `port:
@@ -607,8 +680,12 @@ func runSimulationTest(t *testing.T, proxy *model.Proxy, o xds.FakeOptions, tt s
 		o.ConfigString = tt.config
 		o.KubernetesObjectString = tt.kubeConfig
 		s := xds.NewFakeDiscoveryServer(t, o)
-		sim := simulation.NewSimulation(t, s, s.SetupProxy(proxy))
-		sim.RunExpectations(tt.calls)
+		sim := simulation.NewSimulationFromConfigGen(s.ConfigGenTest, s.SetupProxy(proxy))
+		for _, e := range tt.calls {
+			t.Run(e.Name, func(t *testing.T) {
+				sim.Run(e.Call).Matches(t, e.Result)
+			})
+		}
 		if t.Failed() && debugMode {
 			t.Log(xdstest.MapKeys(xdstest.ExtractClusters(sim.Clusters)))
 			t.Log(xdstest.ExtractListenerNames(sim.Listeners))
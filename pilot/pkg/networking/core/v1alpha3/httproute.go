@@ -91,6 +91,7 @@ func (configgen *ConfigGeneratorImpl) buildSidecarInboundHTTPRouteConfig(
 		Domains: []string{"*"},
 		Routes:  []*route.Route{defaultRoute},
 	}
+	applyInboundRateLimits(push, node, inboundVHost, instance.Service.Hostname)
 
 	r := &route.RouteConfiguration{
 		Name:             clusterName,
@@ -156,6 +157,7 @@ func (configgen *ConfigGeneratorImpl) buildSidecarOutboundHTTPRouteConfig(node *
 	}
 	if !cacheHit {
 		virtualHosts = configgen.buildSidecarOutboundVirtualHosts(node, push, routeName, listenerPort)
+		applyRateLimits(push, node, virtualHosts)
 		if listenerPort > 0 {
 			// only cache for tcp ports and not for uds
 			vHostCache[listenerPort] = virtualHosts
@@ -172,7 +174,9 @@ func (configgen *ConfigGeneratorImpl) buildSidecarOutboundHTTPRouteConfig(node *
 	util.SortVirtualHosts(virtualHosts)
 
 	if !useSniffing {
-		virtualHosts = append(virtualHosts, buildCatchAllVirtualHost(node))
+		catchAll := buildCatchAllVirtualHost(node)
+		applyRateLimits(push, node, []*route.VirtualHost{catchAll})
+		virtualHosts = append(virtualHosts, catchAll)
 	}
 
 	out := &route.RouteConfiguration{
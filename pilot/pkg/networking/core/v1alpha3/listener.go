@@ -1657,6 +1657,8 @@ func buildHTTPConnectionManager(listenerOpts buildListenerOpts, httpOpts *httpLi
 		filters = append(filters, xdsfilters.Alpn)
 	}
 
+	filters = append(filters, buildRateLimitFilters(listenerOpts.push, listenerOpts.proxy)...)
+
 	filters = append(filters, xdsfilters.Cors, xdsfilters.Fault, xdsfilters.Router)
 
 	if httpOpts.connectionManager == nil {
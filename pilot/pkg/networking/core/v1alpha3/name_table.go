@@ -19,6 +19,7 @@ import (
 	nds "istio.io/istio/pilot/pkg/proto"
 	"istio.io/istio/pilot/pkg/serviceregistry"
 	"istio.io/istio/pkg/config/constants"
+	"istio.io/istio/pkg/config/protocol"
 )
 
 // BuildNameTable produces a table of hostnames and their associated IPs that can then
@@ -96,6 +97,7 @@ func (configgen *ConfigGeneratorImpl) BuildNameTable(node *model.Proxy, push *mo
 		nameInfo := &nds.NameTable_NameInfo{
 			Ips:      addressList,
 			Registry: svc.Attributes.ServiceRegistry,
+			Ports:    nameTablePorts(svc.Ports),
 		}
 		if svc.Attributes.ServiceRegistry == string(serviceregistry.Kubernetes) {
 			// The agent will take care of resolving a, a.ns, a.ns.svc, etc.
@@ -107,3 +109,20 @@ func (configgen *ConfigGeneratorImpl) BuildNameTable(node *model.Proxy, push *mo
 	}
 	return out
 }
+
+// nameTablePorts converts the service ports to the form used by the agent to answer SRV queries.
+func nameTablePorts(ports model.PortList) []*nds.NameTable_NameInfo_Port {
+	out := make([]*nds.NameTable_NameInfo_Port, 0, len(ports))
+	for _, port := range ports {
+		l4 := "TCP"
+		if port.Protocol == protocol.UDP {
+			l4 = "UDP"
+		}
+		out = append(out, &nds.NameTable_NameInfo_Port{
+			Name:     port.Name,
+			Number:   uint32(port.Port),
+			Protocol: l4,
+		})
+	}
+	return out
+}
@@ -0,0 +1,256 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha3
+
+import (
+	"net"
+	"time"
+
+	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	ratelimit "github.com/envoyproxy/go-control-plane/envoy/config/ratelimit/v3"
+	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	local_ratelimit "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/local_ratelimit/v3"
+	http_ratelimit "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/ratelimit/v3"
+	hcm "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	xdstype "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/any"
+	"github.com/golang/protobuf/ptypes/wrappers"
+
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/networking/util"
+	ratelimitapi "istio.io/istio/pkg/config/apis/ratelimit/v1alpha1"
+	"istio.io/istio/pkg/config/host"
+)
+
+const (
+	// LocalRateLimitFilterName is the name of the Envoy local rate limit HTTP filter
+	LocalRateLimitFilterName = "envoy.filters.http.local_ratelimit"
+
+	localRateLimitStatPrefix = "http_local_rate_limiter"
+
+	// defaultRateLimitServiceTimeout is the timeout of the calls to a rate limit service if not set in the RateLimit
+	defaultRateLimitServiceTimeout = 20 * time.Millisecond
+)
+
+// rateLimitService is a rate limit service and domain, configured as the rate limit HTTP filter.
+type rateLimitService struct {
+	cluster         string
+	domain          string
+	timeout         time.Duration
+	failureModeDeny bool
+}
+
+func newRateLimitService(global *ratelimitapi.GlobalRateLimit) rateLimitService {
+	svc := rateLimitService{
+		cluster:         model.BuildSubsetKey(model.TrafficDirectionOutbound, "", host.Name(global.Service), int(global.Port)),
+		domain:          global.Domain,
+		timeout:         defaultRateLimitServiceTimeout,
+		failureModeDeny: global.FailureModeDeny,
+	}
+	if timeout, err := time.ParseDuration(global.Timeout); err == nil && timeout > 0 {
+		svc.timeout = timeout
+	}
+	return svc
+}
+
+// globalRateLimitService returns the rate limit service of the global rate limits, or nil if there are none.
+// Envoy sends the descriptors of all the virtual hosts to a single rate limit filter, so the service and domain
+// are the ones of the oldest RateLimit. The RateLimits using another service or domain are not applied.
+func globalRateLimitService(rateLimits []*model.RateLimitWrapper) *rateLimitService {
+	for _, rl := range rateLimits {
+		if rl.Spec.Global != nil {
+			svc := newRateLimitService(rl.Spec.Global)
+			return &svc
+		}
+	}
+	return nil
+}
+
+// sameRateLimitService returns true if the global rate limit is sent to the service and domain of the filter.
+func sameRateLimitService(svc *rateLimitService, global *ratelimitapi.GlobalRateLimit) bool {
+	return svc.cluster == model.BuildSubsetKey(model.TrafficDirectionOutbound, "", host.Name(global.Service), int(global.Port)) &&
+		svc.domain == global.Domain
+}
+
+// buildRateLimitFilters builds the rate limit HTTP filters for the RateLimits applied to the proxy.
+// The filters only enforce the limits configured on the virtual hosts, see applyRateLimits.
+func buildRateLimitFilters(push *model.PushContext, node *model.Proxy) []*hcm.HttpFilter {
+	rateLimits := push.RateLimits(node)
+	if len(rateLimits) == 0 {
+		return nil
+	}
+	var filters []*hcm.HttpFilter
+	for _, rl := range rateLimits {
+		if rl.Spec.Local != nil {
+			filters = append(filters, &hcm.HttpFilter{
+				Name: LocalRateLimitFilterName,
+				ConfigType: &hcm.HttpFilter_TypedConfig{TypedConfig: util.MessageToAny(&local_ratelimit.LocalRateLimit{
+					StatPrefix: localRateLimitStatPrefix,
+				})},
+			})
+			break
+		}
+	}
+	svc := globalRateLimitService(rateLimits)
+	if svc == nil {
+		return filters
+	}
+	for _, rl := range rateLimits {
+		if rl.Spec.Global != nil && !sameRateLimitService(svc, rl.Spec.Global) {
+			log.Warnf("ignoring the global rate limits of %s/%s on %s: their service or domain differs from the one of "+
+				"the oldest RateLimit, %s %s", rl.Namespace, rl.Name, node.ID, svc.cluster, svc.domain)
+		}
+	}
+	filters = append(filters, &hcm.HttpFilter{
+		Name: wellknown.HTTPRateLimit,
+		ConfigType: &hcm.HttpFilter_TypedConfig{TypedConfig: util.MessageToAny(&http_ratelimit.RateLimit{
+			Domain:          svc.domain,
+			Timeout:         ptypes.DurationProto(svc.timeout),
+			FailureModeDeny: svc.failureModeDeny,
+			RateLimitService: &ratelimit.RateLimitServiceConfig{
+				GrpcService: &core.GrpcService{
+					TargetSpecifier: &core.GrpcService_EnvoyGrpc_{
+						EnvoyGrpc: &core.GrpcService_EnvoyGrpc{ClusterName: svc.cluster},
+					},
+				},
+				TransportApiVersion: core.ApiVersion_V3,
+			},
+		})},
+	})
+	return filters
+}
+
+// applyRateLimits configures the RateLimits applied to the proxy on the virtual hosts they match.
+// Local rate limits are configured as the per filter config of the local rate limit filter. If several
+// local rate limits match a virtual host, the oldest one wins. Global rate limits are added as rate limit
+// actions, one per descriptor, sent to the service of the rate limit filter.
+func applyRateLimits(push *model.PushContext, node *model.Proxy, virtualHosts []*route.VirtualHost) {
+	rateLimits := push.RateLimits(node)
+	if len(rateLimits) == 0 {
+		return
+	}
+	svc := globalRateLimitService(rateLimits)
+	for _, vh := range virtualHosts {
+		applyVirtualHostRateLimits(rateLimits, svc, vh, vh.Domains)
+	}
+}
+
+// applyInboundRateLimits configures the RateLimits applied to the proxy on the wildcard virtual host of an
+// inbound listener. The hosts of the RateLimits are matched against the hostname of the service of the listener.
+func applyInboundRateLimits(push *model.PushContext, node *model.Proxy, vh *route.VirtualHost, hostname host.Name) {
+	rateLimits := push.RateLimits(node)
+	if len(rateLimits) == 0 {
+		return
+	}
+	applyVirtualHostRateLimits(rateLimits, globalRateLimitService(rateLimits), vh, []string{string(hostname)})
+}
+
+func applyVirtualHostRateLimits(rateLimits []*model.RateLimitWrapper, svc *rateLimitService,
+	vh *route.VirtualHost, domains []string) {
+	for _, rl := range rateLimits {
+		if !rateLimitMatchesDomains(rl.Spec.Hosts, domains) {
+			continue
+		}
+		if local := rl.Spec.Local; local != nil {
+			if _, f := vh.TypedPerFilterConfig[LocalRateLimitFilterName]; !f {
+				if vh.TypedPerFilterConfig == nil {
+					vh.TypedPerFilterConfig = map[string]*any.Any{}
+				}
+				vh.TypedPerFilterConfig[LocalRateLimitFilterName] = util.MessageToAny(buildLocalRateLimit(local))
+			}
+		}
+		if global := rl.Spec.Global; global != nil && sameRateLimitService(svc, global) {
+			for _, d := range global.Descriptors {
+				vh.RateLimits = append(vh.RateLimits, buildRouteRateLimit(d))
+			}
+		}
+	}
+}
+
+// rateLimitMatchesDomains returns true if any of the virtual host domains is one of the hosts.
+// Wildcard domains, like the one of catch all virtual hosts, only match wider or equal hosts.
+func rateLimitMatchesDomains(hosts []string, domains []string) bool {
+	if len(hosts) == 0 {
+		return true
+	}
+	for _, domain := range domains {
+		if h, _, err := net.SplitHostPort(domain); err == nil {
+			domain = h
+		}
+		for _, h := range hosts {
+			if host.Name(domain).SubsetOf(host.Name(h)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func buildLocalRateLimit(local *ratelimitapi.LocalRateLimit) *local_ratelimit.LocalRateLimit {
+	// validation ensures the interval is valid
+	interval, _ := time.ParseDuration(local.FillInterval)
+	tokensPerFill := local.TokensPerFill
+	if tokensPerFill == 0 {
+		tokensPerFill = 1
+	}
+	return &local_ratelimit.LocalRateLimit{
+		StatPrefix: localRateLimitStatPrefix,
+		TokenBucket: &xdstype.TokenBucket{
+			MaxTokens:     local.MaxTokens,
+			TokensPerFill: &wrappers.UInt32Value{Value: tokensPerFill},
+			FillInterval:  ptypes.DurationProto(interval),
+		},
+		FilterEnabled: &core.RuntimeFractionalPercent{
+			DefaultValue: &xdstype.FractionalPercent{Numerator: 100, Denominator: xdstype.FractionalPercent_HUNDRED},
+			RuntimeKey:   "local_rate_limit_enabled",
+		},
+		FilterEnforced: &core.RuntimeFractionalPercent{
+			DefaultValue: &xdstype.FractionalPercent{Numerator: 100, Denominator: xdstype.FractionalPercent_HUNDRED},
+			RuntimeKey:   "local_rate_limit_enforced",
+		},
+	}
+}
+
+func buildRouteRateLimit(descriptor *ratelimitapi.Descriptor) *route.RateLimit {
+	out := &route.RateLimit{
+		Actions: make([]*route.RateLimit_Action, 0, len(descriptor.Entries)),
+	}
+	for _, e := range descriptor.Entries {
+		var action *route.RateLimit_Action
+		switch {
+		case e.GenericKey != "":
+			action = &route.RateLimit_Action{ActionSpecifier: &route.RateLimit_Action_GenericKey_{
+				GenericKey: &route.RateLimit_Action_GenericKey{DescriptorValue: e.GenericKey},
+			}}
+		case e.RequestHeader != nil:
+			action = &route.RateLimit_Action{ActionSpecifier: &route.RateLimit_Action_RequestHeaders_{
+				RequestHeaders: &route.RateLimit_Action_RequestHeaders{
+					HeaderName:    e.RequestHeader.Name,
+					DescriptorKey: e.RequestHeader.DescriptorKey,
+				},
+			}}
+		case e.RemoteAddress:
+			action = &route.RateLimit_Action{ActionSpecifier: &route.RateLimit_Action_RemoteAddress_{
+				RemoteAddress: &route.RateLimit_Action_RemoteAddress{},
+			}}
+		default:
+			continue
+		}
+		out.Actions = append(out.Actions, action)
+	}
+	return out
+}
@@ -0,0 +1,180 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha3
+
+import (
+	"testing"
+	"time"
+
+	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	http_ratelimit "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/ratelimit/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
+	"github.com/golang/protobuf/ptypes"
+
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/networking/util"
+	"istio.io/istio/pkg/config"
+	ratelimit "istio.io/istio/pkg/config/apis/ratelimit/v1alpha1"
+	"istio.io/istio/pkg/config/host"
+	"istio.io/istio/pkg/config/schema/gvk"
+	"istio.io/istio/pkg/config/visibility"
+)
+
+func TestRateLimit(t *testing.T) {
+	t0 := time.Now()
+	rateLimit := func(name string, spec *ratelimit.RateLimit) config.Config {
+		t0 = t0.Add(time.Minute)
+		return config.Config{
+			Meta: config.Meta{
+				GroupVersionKind:  gvk.RateLimit,
+				Name:              name,
+				Namespace:         "default",
+				CreationTimestamp: t0,
+			},
+			Spec: spec,
+		}
+	}
+	global := func(domain string, key string) *ratelimit.GlobalRateLimit {
+		return &ratelimit.GlobalRateLimit{
+			Service: "ratelimit.istio-system.svc.cluster.local",
+			Port:    8081,
+			Domain:  domain,
+			Descriptors: []*ratelimit.Descriptor{{Entries: []*ratelimit.DescriptorEntry{
+				{GenericKey: key},
+				{RequestHeader: &ratelimit.RequestHeaderDescriptor{Name: "x-user", DescriptorKey: "user"}},
+			}}},
+		}
+	}
+	services := []*model.Service{
+		buildHTTPService("foo.default.svc.cluster.local", visibility.Public, "10.0.0.1", "default", 80),
+		buildHTTPService("bar.default.svc.cluster.local", visibility.Public, "10.0.0.2", "default", 80),
+	}
+
+	cg := NewConfigGenTest(t, TestOptions{
+		Services: services,
+		Configs: []config.Config{
+			rateLimit("local", &ratelimit.RateLimit{
+				Hosts: []string{"foo.default.svc.cluster.local"},
+				Local: &ratelimit.LocalRateLimit{MaxTokens: 10, FillInterval: "1s"},
+			}),
+			rateLimit("global", &ratelimit.RateLimit{Global: global("first", "all")}),
+			rateLimit("global-bar", &ratelimit.RateLimit{
+				Hosts:  []string{"bar.default.svc.cluster.local"},
+				Global: global("first", "bar"),
+			}),
+			rateLimit("other-domain", &ratelimit.RateLimit{
+				Hosts:  []string{"bar.default.svc.cluster.local"},
+				Global: global("second", "bar"),
+			}),
+			rateLimit("other-selector", &ratelimit.RateLimit{
+				WorkloadSelector: &ratelimit.WorkloadSelector{MatchLabels: map[string]string{"app": "other"}},
+				Global:           global("third", "other"),
+			}),
+		},
+	})
+	proxy := cg.SetupProxy(nil)
+
+	filters := buildRateLimitFilters(cg.PushContext(), proxy)
+	if len(filters) != 2 {
+		t.Fatalf("expected a local and a global rate limit filter, got %v", filters)
+	}
+	if filters[0].Name != LocalRateLimitFilterName {
+		t.Fatalf("expected local rate limit filter first, got %v", filters[0].Name)
+	}
+	if filters[1].Name != wellknown.HTTPRateLimit {
+		t.Fatalf("expected global rate limit filter, got %v", filters[1].Name)
+	}
+	rl := &http_ratelimit.RateLimit{}
+	if err := ptypes.UnmarshalAny(filters[1].GetTypedConfig(), rl); err != nil {
+		t.Fatal(err)
+	}
+	// The filter is configured from the oldest RateLimit, other-domain is not applied.
+	if rl.Domain != "first" || rl.Stage != 0 {
+		t.Fatalf("expected domain first at stage 0, got %v at stage %d", rl.Domain, rl.Stage)
+	}
+	if cluster := rl.GetRateLimitService().GetGrpcService().GetEnvoyGrpc().GetClusterName(); cluster != "outbound|8081||ratelimit.istio-system.svc.cluster.local" {
+		t.Fatalf("unexpected rate limit service cluster %v", cluster)
+	}
+
+	routeCfg := cg.ConfigGen.buildSidecarOutboundHTTPRouteConfig(proxy, cg.PushContext(), "80", map[int][]*route.VirtualHost{})
+	if routeCfg == nil {
+		t.Fatalf("got nil route")
+	}
+	expected := map[string]struct {
+		local       bool
+		descriptors []string
+	}{
+		"foo.default.svc.cluster.local:80": {local: true, descriptors: []string{"all"}},
+		"bar.default.svc.cluster.local:80": {descriptors: []string{"all", "bar"}},
+		"allow_any":                        {descriptors: []string{"all"}},
+	}
+	for _, vh := range routeCfg.VirtualHosts {
+		want, f := expected[vh.Name]
+		if !f {
+			continue
+		}
+		delete(expected, vh.Name)
+		if _, local := vh.TypedPerFilterConfig[LocalRateLimitFilterName]; local != want.local {
+			t.Errorf("%v: expected local rate limit %v, got %v", vh.Name, want.local, local)
+		}
+		if len(vh.RateLimits) != len(want.descriptors) {
+			t.Fatalf("%v: expected %d rate limits, got %v", vh.Name, len(want.descriptors), vh.RateLimits)
+		}
+		for i, rl := range vh.RateLimits {
+			if rl.GetStage() != nil {
+				t.Errorf("%v: expected no stage, got %d", vh.Name, rl.GetStage().GetValue())
+			}
+			if len(rl.Actions) != 2 {
+				t.Fatalf("%v: expected 2 actions, got %v", vh.Name, rl.Actions)
+			}
+			if key := rl.Actions[0].GetGenericKey().GetDescriptorValue(); key != want.descriptors[i] {
+				t.Errorf("%v: expected descriptor %v, got %v", vh.Name, want.descriptors[i], key)
+			}
+		}
+	}
+	if len(expected) != 0 {
+		t.Fatalf("virtual hosts not found: %v", expected)
+	}
+
+	for _, svc := range services {
+		instance := &model.ServiceInstance{
+			Service:     svc,
+			ServicePort: svc.Ports[0],
+			Endpoint:    &model.IstioEndpoint{EndpointPort: 8080},
+		}
+		inbound := cg.ConfigGen.buildSidecarInboundHTTPRouteConfig(proxy, cg.PushContext(), instance, "inbound|80||")
+		vh := inbound.VirtualHosts[0]
+		_, local := vh.TypedPerFilterConfig[LocalRateLimitFilterName]
+		if wantLocal := svc.Hostname == "foo.default.svc.cluster.local"; local != wantLocal {
+			t.Errorf("%v inbound: expected local rate limit %v, got %v", svc.Hostname, wantLocal, local)
+		}
+		if wantGlobal := map[host.Name]int{"foo.default.svc.cluster.local": 1, "bar.default.svc.cluster.local": 2}[svc.Hostname]; len(vh.RateLimits) != wantGlobal {
+			t.Errorf("%v inbound: expected %d rate limits, got %v", svc.Hostname, wantGlobal, vh.RateLimits)
+		}
+	}
+}
+
+func TestBuildLocalRateLimit(t *testing.T) {
+	got := buildLocalRateLimit(&ratelimit.LocalRateLimit{MaxTokens: 10, FillInterval: "500ms"})
+	if got.TokenBucket.MaxTokens != 10 || got.TokenBucket.TokensPerFill.GetValue() != 1 {
+		t.Fatalf("unexpected token bucket %v", got.TokenBucket)
+	}
+	if interval, _ := ptypes.Duration(got.TokenBucket.FillInterval); interval != 500*time.Millisecond {
+		t.Fatalf("unexpected fill interval %v", got.TokenBucket.FillInterval)
+	}
+	if util.MessageToAny(got) == nil {
+		t.Fatalf("failed to marshal local rate limit")
+	}
+}
@@ -485,7 +485,7 @@ func TestInboundClusters(t *testing.T) {
 			if tt.legacyProxy {
 				testProxy = proxy180
 			}
-			sim := simulation.NewSimulationFromConfigGen(t, s, s.SetupProxy(testProxy))
+			sim := simulation.NewSimulationFromConfigGen(s, s.SetupProxy(testProxy))
 
 			clusters := xdstest.FilterClusters(sim.Clusters, func(c *cluster.Cluster) bool {
 				return strings.HasPrefix(c.Name, "inbound")
@@ -0,0 +1,74 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	http_conn "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
+	"github.com/golang/protobuf/ptypes"
+)
+
+// ExtractRoutesFromListeners returns the names of the route configurations the HTTP connection managers of
+// the listeners refer to over RDS.
+func ExtractRoutesFromListeners(ll []*listener.Listener) []string {
+	routes := []string{}
+	for _, l := range ll {
+		for _, fc := range l.FilterChains {
+			for _, filter := range fc.Filters {
+				if filter.Name != wellknown.HTTPConnectionManager {
+					continue
+				}
+				hcm := &http_conn.HttpConnectionManager{}
+				if err := ptypes.UnmarshalAny(filter.GetTypedConfig(), hcm); err != nil {
+					continue
+				}
+				if rds, ok := hcm.GetRouteSpecifier().(*http_conn.HttpConnectionManager_Rds); ok {
+					routes = append(routes, rds.Rds.RouteConfigName)
+				}
+			}
+		}
+	}
+	return routes
+}
+
+// ExtractListener returns the listener with the name, or nil if there is none.
+func ExtractListener(name string, ll []*listener.Listener) *listener.Listener {
+	for _, l := range ll {
+		if l.Name == name {
+			return l
+		}
+	}
+	return nil
+}
+
+// ExtractRouteConfigurations returns the route configurations by name.
+func ExtractRouteConfigurations(rc []*route.RouteConfiguration) map[string]*route.RouteConfiguration {
+	res := map[string]*route.RouteConfiguration{}
+	for _, l := range rc {
+		res[l.Name] = l
+	}
+	return res
+}
+
+// ExtractListenerFilters returns the listener filters of the listener by name.
+func ExtractListenerFilters(l *listener.Listener) map[string]*listener.ListenerFilter {
+	res := map[string]*listener.ListenerFilter{}
+	for _, lf := range l.ListenerFilters {
+		res[lf.Name] = lf
+	}
+	return res
+}
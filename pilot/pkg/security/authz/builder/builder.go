@@ -16,6 +16,7 @@ package builder
 
 import (
 	"fmt"
+	"strings"
 
 	tcppb "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
 	rbacpb "github.com/envoyproxy/go-control-plane/envoy/config/rbac/v3"
@@ -162,10 +163,13 @@ func (b Builder) build(policies []model.AuthorizationPolicy, action rbacpb.RBAC_
 		return nil
 	}
 
-	rules := &rbacpb.RBAC{
+	enforced := &rbacpb.RBAC{
 		Action:   action,
 		Policies: map[string]*rbacpb.Policy{},
 	}
+	// The dry-run policies are added to shadow rules, evaluated by Envoy but not enforced.
+	var dryRuns []*dryRunRules
+	hasEnforced := false
 
 	var providers []string
 	filterType := "HTTP"
@@ -176,6 +180,19 @@ func (b Builder) build(policies []model.AuthorizationPolicy, action rbacpb.RBAC_
 		if b.option.IsCustomBuilder {
 			providers = append(providers, policy.Spec.GetProvider().GetName())
 		}
+		rules, dryRun := enforced, false
+		if policy.DryRun() {
+			if b.option.IsCustomBuilder {
+				b.option.Logger.AppendError(fmt.Errorf("ignored dry-run annotation of policy %s.%s, not supported with CUSTOM action",
+					policy.Namespace, policy.Name))
+			} else {
+				rules, dryRun = &rbacpb.RBAC{Action: action, Policies: map[string]*rbacpb.Policy{}}, true
+				dryRuns = append(dryRuns, &dryRunRules{namespace: policy.Namespace, name: policy.Name, rules: rules})
+			}
+		}
+		if !dryRun {
+			hasEnforced = true
+		}
 		for i, rule := range policy.Spec.Rules {
 			// The name will later be used by ext_authz filter to get the evaluation result from dynamic metadata.
 			name := policyName(policy.Namespace, policy.Name, i, b.option)
@@ -199,26 +216,77 @@ func (b Builder) build(policies []model.AuthorizationPolicy, action rbacpb.RBAC_
 			}
 			if generated != nil {
 				rules.Policies[name] = generated
-				b.option.Logger.AppendDebugf("generated config from rule %s on %s filter chain successfully", name, filterType)
+				if dryRun {
+					b.option.Logger.AppendDryRun(name, action, filterType)
+				} else {
+					b.option.Logger.AppendDebugf("generated config from rule %s on %s filter chain successfully", name, filterType)
+				}
 			}
 		}
 		if len(policy.Spec.Rules) == 0 {
 			// Generate an explicit policy that never matches.
 			name := policyName(policy.Namespace, policy.Name, 0, b.option)
-			b.option.Logger.AppendDebugf("generated config from policy %s on %s filter chain successfully", name, filterType)
+			if dryRun {
+				b.option.Logger.AppendDryRun(name, action, filterType)
+			} else {
+				b.option.Logger.AppendDebugf("generated config from policy %s on %s filter chain successfully", name, filterType)
+			}
 			rules.Policies[name] = rbacPolicyMatchNever
 		}
 	}
 
+	// Without enforced policies, the rules must be left empty as an ALLOW action with no policies denies
+	// every request.
+	if !hasEnforced {
+		enforced = nil
+	}
+	// A request is allowed if any ALLOW policy matches it, so the shadow rules of the dry-run ALLOW policies include
+	// the enforced ones. The shadow result is then the result if the dry-run policies were enforced.
+	if action == rbacpb.RBAC_ALLOW && enforced != nil {
+		for _, d := range dryRuns {
+			for name, p := range enforced.Policies {
+				d.rules.Policies[name] = p
+			}
+		}
+	}
 	if forTCP {
-		return &builtConfigs{tcp: b.buildTCP(rules, providers)}
+		return &builtConfigs{tcp: b.buildTCP(enforced, dryRuns, providers)}
+	}
+	return &builtConfigs{http: b.buildHTTP(enforced, mergeDryRuns(action, dryRuns), providers)}
+}
+
+// dryRunRules are the shadow rules generated from a dry-run policy.
+type dryRunRules struct {
+	namespace string
+	name      string
+	rules     *rbacpb.RBAC
+}
+
+// statPrefix returns the stat prefix of the TCP filter of the shadow rules, so that Envoy reports the shadow
+// results of each dry-run policy in its own stats, e.g. tcp.istio_dry_run.allow.foo.httpbin.rbac.shadow_allowed.
+func (d *dryRunRules) statPrefix() string {
+	return fmt.Sprintf("%s%s.%s.%s.", authzmodel.RBACTCPDryRunStatPrefix,
+		strings.ToLower(d.rules.Action.String()), d.namespace, d.name)
+}
+
+// mergeDryRuns returns the shadow rules of all the dry-run policies, or nil if there are none. The HTTP filter has
+// no stat prefix, so the dry-run policies share the shadow rules and the shadow stats of their action.
+func mergeDryRuns(action rbacpb.RBAC_Action, dryRuns []*dryRunRules) *rbacpb.RBAC {
+	if len(dryRuns) == 0 {
+		return nil
+	}
+	merged := &rbacpb.RBAC{Action: action, Policies: map[string]*rbacpb.Policy{}}
+	for _, d := range dryRuns {
+		for name, p := range d.rules.Policies {
+			merged.Policies[name] = p
+		}
 	}
-	return &builtConfigs{http: b.buildHTTP(rules, providers)}
+	return merged
 }
 
-func (b Builder) buildHTTP(rules *rbacpb.RBAC, providers []string) []*httppb.HttpFilter {
+func (b Builder) buildHTTP(rules, shadowRules *rbacpb.RBAC, providers []string) []*httppb.HttpFilter {
 	if !b.option.IsCustomBuilder {
-		rbac := &rbachttppb.RBAC{Rules: rules}
+		rbac := &rbachttppb.RBAC{Rules: rules, ShadowRules: shadowRules}
 		return []*httppb.HttpFilter{
 			{
 				Name:       authzmodel.RBACHTTPFilterName,
@@ -256,15 +324,25 @@ func (b Builder) buildHTTP(rules *rbacpb.RBAC, providers []string) []*httppb.Htt
 	}
 }
 
-func (b Builder) buildTCP(rules *rbacpb.RBAC, providers []string) []*tcppb.Filter {
+func (b Builder) buildTCP(rules *rbacpb.RBAC, dryRuns []*dryRunRules, providers []string) []*tcppb.Filter {
 	if !b.option.IsCustomBuilder {
-		rbac := &rbactcppb.RBAC{Rules: rules, StatPrefix: authzmodel.RBACTCPFilterStatPrefix}
-		return []*tcppb.Filter{
-			{
+		var filters []*tcppb.Filter
+		if rules != nil {
+			rbac := &rbactcppb.RBAC{Rules: rules, StatPrefix: authzmodel.RBACTCPFilterStatPrefix}
+			filters = append(filters, &tcppb.Filter{
 				Name:       authzmodel.RBACTCPFilterName,
 				ConfigType: &tcppb.Filter_TypedConfig{TypedConfig: util.MessageToAny(rbac)},
-			},
+			})
+		}
+		// Each dry-run policy has its own filter, only with shadow rules, for its stat prefix.
+		for _, d := range dryRuns {
+			rbac := &rbactcppb.RBAC{ShadowRules: d.rules, StatPrefix: d.statPrefix()}
+			filters = append(filters, &tcppb.Filter{
+				Name:       authzmodel.RBACTCPFilterName,
+				ConfigType: &tcppb.Filter_TypedConfig{TypedConfig: util.MessageToAny(rbac)},
+			})
 		}
+		return filters
 	}
 
 	if extauthz, err := getExtAuthz(b.extensions, providers); err != nil {
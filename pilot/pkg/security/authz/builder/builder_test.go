@@ -219,6 +219,11 @@ func TestGenerator_GenerateHTTP(t *testing.T) {
 			input: "audit-all-in.yaml",
 			want:  []string{"audit-all-out.yaml"},
 		},
+		{
+			name:  "dry-run",
+			input: "dry-run-in.yaml",
+			want:  []string{"dry-run-deny-out.yaml", "dry-run-allow-out.yaml"},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -274,6 +279,12 @@ func TestGenerator_GenerateTCP(t *testing.T) {
 			input: "action-audit-HTTP-for-TCP-filter-in.yaml",
 			want:  []string{"action-audit-HTTP-for-TCP-filter-out.yaml"},
 		},
+		{
+			name:  "dry-run",
+			input: "dry-run-in.yaml",
+			want: []string{"dry-run-deny-tcp-out.yaml", "dry-run-deny-shadow-tcp-out.yaml",
+				"dry-run-allow-tcp-out.yaml", "dry-run-allow-shadow-tcp-out.yaml"},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"strings"
 
+	rbacpb "github.com/envoyproxy/go-control-plane/envoy/config/rbac/v3"
 	"github.com/hashicorp/go-multierror"
 
 	"istio.io/istio/pilot/pkg/networking/plugin"
@@ -38,6 +39,13 @@ func (al *AuthzLogger) AppendDebugf(format string, args ...interface{}) {
 	al.debugMsg = append(al.debugMsg, fmt.Sprintf(format, args...))
 }
 
+// AppendDryRun traces a rule generated from a dry-run policy in the shadow rules. When the rule matches a request,
+// Envoy reports its name in the shadow_effective_policy_id dynamic metadata and counts the shadow result.
+func (al *AuthzLogger) AppendDryRun(name string, action rbacpb.RBAC_Action, filterType string) {
+	al.AppendDebugf("generated shadow config from dry-run rule %s on %s filter chain, reported as would %s on match",
+		name, filterType, dryRunVerb(action))
+}
+
 func (al *AuthzLogger) AppendError(err error) {
 	al.errMsg = multierror.Append(al.errMsg, err)
 }
@@ -54,3 +62,14 @@ func (al *AuthzLogger) Report(in *plugin.InputParams) {
 		authzLog.Debugf("Processed authorization policy for %s", in.Node.ID)
 	}
 }
+
+func dryRunVerb(action rbacpb.RBAC_Action) string {
+	switch action {
+	case rbacpb.RBAC_ALLOW:
+		return "allow"
+	case rbacpb.RBAC_DENY:
+		return "deny"
+	default:
+		return "audit"
+	}
+}
@@ -31,6 +31,8 @@ const (
 	// RBACTCPFilterName is the name of the RBAC network filter in envoy.
 	RBACTCPFilterName       = "envoy.filters.network.rbac"
 	RBACTCPFilterStatPrefix = "tcp."
+	// RBACTCPDryRunStatPrefix is the prefix of the stat prefix of the RBAC network filters of the dry-run policies.
+	RBACTCPDryRunStatPrefix = "tcp.istio_dry_run."
 
 	attrRequestHeader    = "request.headers"             // header name is surrounded by brackets, e.g. "request.headers[User-Agent]".
 	attrSrcIP            = "source.ip"                   // supports both single ip and cidr, e.g. "10.1.2.3" or "10.1.0.0/16".
@@ -41,6 +41,10 @@ const (
 	// SDSRootResourceName is the sdsconfig name for root CA, used for fetching root cert.
 	SDSRootResourceName = "ROOTCA"
 
+	// SDSFederatedRootResourceNamePrefix is the prefix of the sdsconfig name of the root certs of a federated
+	// trust domain, followed by the trust domain.
+	SDSFederatedRootResourceNamePrefix = "ROOTCA~"
+
 	// K8sSAJwtFileName is the token volume mount file name for k8s jwt token.
 	K8sSAJwtFileName = "/var/run/secrets/kubernetes.io/serviceaccount/token"
 
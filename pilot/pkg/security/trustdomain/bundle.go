@@ -36,6 +36,9 @@ type Bundle struct {
 	// Any service with the identity `td1/ns/foo/sa/a-service-account`, `td2/ns/foo/sa/a-service-account`,
 	// or `td3/ns/foo/sa/a-service-account` will be treated the same in the Istio mesh.
 	TrustDomains []string
+	// FederatedTrustDomains are the trust domains of other meshes whose roots are trusted through their
+	// SPIFFE bundle endpoints. Principals of these trust domains are kept as-is.
+	FederatedTrustDomains []string
 }
 
 // NewBundle returns a new trust domain bundle.
@@ -72,6 +75,8 @@ func (t Bundle) ReplaceTrustDomainAliases(principals []string) []string {
 		if stringMatch(trustDomainFromPrincipal, t.TrustDomains) || trustDomainFromPrincipal == constants.DefaultKubernetesDomain {
 			// Generate configuration for trust domain and trust domain aliases.
 			principalsIncludingAliases = append(principalsIncludingAliases, t.replaceTrustDomains(principal, trustDomainFromPrincipal)...)
+		} else if stringMatch(trustDomainFromPrincipal, t.FederatedTrustDomains) {
+			principalsIncludingAliases = append(principalsIncludingAliases, principal)
 		} else {
 			authzLog.Warnf("Trust domain %s from principal %s does not match the current trust "+
 				"domain or its aliases", trustDomainFromPrincipal, principal)
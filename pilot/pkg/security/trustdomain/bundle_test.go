@@ -44,6 +44,15 @@ func TestReplaceTrustDomainAliases(t *testing.T) {
 			principals:        []string{"*/ns/foo/sa/bar"},
 			expect:            []string{"*/ns/foo/sa/bar"},
 		},
+		{
+			name: "Principal of a federated trust domain",
+			trustDomainBundle: Bundle{
+				TrustDomains:          []string{"td1"},
+				FederatedTrustDomains: []string{"partner.com"},
+			},
+			principals: []string{"partner.com/ns/foo/sa/bar"},
+			expect:     []string{"partner.com/ns/foo/sa/bar"},
+		},
 		{
 			name:              "One trust domain alias, one principal",
 			trustDomainBundle: NewBundle("td2", []string{"td1"}),
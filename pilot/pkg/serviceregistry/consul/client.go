@@ -0,0 +1,153 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package consul
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// consulIndexHeader is the header Consul uses to return the index for blocking queries
+	consulIndexHeader = "X-Consul-Index"
+
+	// healthPassing is the status of a passing health check
+	healthPassing = "passing"
+)
+
+// serviceEntry is a single instance of a service, as returned by the Consul health API.
+type serviceEntry struct {
+	Node    node
+	Service agentService
+	Checks  []healthCheck
+}
+
+type node struct {
+	Node       string
+	Address    string
+	Datacenter string
+}
+
+type agentService struct {
+	ID      string
+	Service string
+	Tags    []string
+	Address string
+	Meta    map[string]string
+	Port    int
+}
+
+type healthCheck struct {
+	CheckID   string
+	ServiceID string
+	Status    string
+}
+
+// healthy returns true if all of the health checks of the instance, including the node checks, are passing.
+// This matches the filtering Consul does for the `passing` query parameter.
+func (e serviceEntry) healthy() bool {
+	for _, c := range e.Checks {
+		if c.Status != healthPassing {
+			return false
+		}
+	}
+	return true
+}
+
+// catalogClient is a minimal client for the parts of the Consul HTTP API used by the registry.
+type catalogClient struct {
+	address string
+	client  *http.Client
+}
+
+func newCatalogClient(address string) (*catalogClient, error) {
+	if !strings.Contains(address, "://") {
+		address = "http://" + address
+	}
+	u, err := url.Parse(address)
+	if err != nil {
+		return nil, fmt.Errorf("invalid consul address %q: %v", address, err)
+	}
+	return &catalogClient{
+		address: strings.TrimSuffix(u.String(), "/"),
+		client:  &http.Client{},
+	}, nil
+}
+
+// services returns the names and tags of all services in the catalog. If index is set, this is a blocking
+// query which returns once the catalog changes past index, or wait elapses.
+func (c *catalogClient) services(ctx context.Context, index uint64, wait time.Duration) (map[string][]string, uint64, error) {
+	out := map[string][]string{}
+	newIndex, err := c.get(ctx, "/v1/catalog/services", blockingParams(index, wait), &out)
+	if err != nil {
+		return nil, 0, err
+	}
+	return out, newIndex, nil
+}
+
+// instances returns all instances of the named service, along with their health checks. If index is set, this
+// is a blocking query which returns once the instances or their health checks change past index, or wait elapses.
+func (c *catalogClient) instances(ctx context.Context, name string, index uint64, wait time.Duration) ([]serviceEntry, uint64, error) {
+	out := []serviceEntry{}
+	newIndex, err := c.get(ctx, "/v1/health/service/"+url.PathEscape(name), blockingParams(index, wait), &out)
+	if err != nil {
+		return nil, 0, err
+	}
+	return out, newIndex, nil
+}
+
+func blockingParams(index uint64, wait time.Duration) url.Values {
+	params := url.Values{}
+	if index > 0 {
+		params.Set("index", strconv.FormatUint(index, 10))
+		params.Set("wait", wait.String())
+	}
+	return params
+}
+
+func (c *catalogClient) get(ctx context.Context, path string, params url.Values, into interface{}) (uint64, error) {
+	u := c.address + path
+	if len(params) > 0 {
+		u += "?" + params.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("GET %s: unexpected status %s", path, resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(into); err != nil {
+		return 0, fmt.Errorf("GET %s: failed to decode response: %v", path, err)
+	}
+	var index uint64
+	if h := resp.Header.Get(consulIndexHeader); h != "" {
+		if index, err = strconv.ParseUint(h, 10, 64); err != nil {
+			return 0, fmt.Errorf("GET %s: invalid index %q: %v", path, h, err)
+		}
+	}
+	return index, nil
+}
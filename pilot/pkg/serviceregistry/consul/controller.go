@@ -0,0 +1,372 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package consul
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/atomic"
+
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/serviceregistry"
+	"istio.io/istio/pkg/config/host"
+	"istio.io/istio/pkg/config/labels"
+	"istio.io/pkg/log"
+)
+
+var (
+	_ serviceregistry.Instance = &Controller{}
+)
+
+const (
+	// defaultWaitTime is the default maximum duration of a blocking query
+	defaultWaitTime = 30 * time.Second
+
+	// retryInterval is the time to wait before retrying after a failed query
+	retryInterval = time.Second
+)
+
+// Options stores the configurable attributes of a Controller.
+type Options struct {
+	// ServerURL is the address of the Consul agent or server, for example "http://127.0.0.1:8500"
+	ServerURL string
+	// ClusterID identifies the registry
+	ClusterID string
+	// XDSUpdater is notified of endpoint changes
+	XDSUpdater model.XDSUpdater
+	// WaitTime is the maximum duration of a blocking query, after which it is reissued. Defaults to 30s.
+	WaitTime time.Duration
+}
+
+// Controller communicates with Consul and monitors for changes
+type Controller struct {
+	client  *catalogClient
+	options Options
+
+	// cacheMutex protects services and instances
+	cacheMutex sync.RWMutex
+	services   map[host.Name]*model.Service
+	instances  map[host.Name][]*model.ServiceInstance
+
+	handlers []func(*model.Service, model.Event)
+	synced   *atomic.Bool
+}
+
+// NewController creates a new Consul controller
+func NewController(options Options) (*Controller, error) {
+	client, err := newCatalogClient(options.ServerURL)
+	if err != nil {
+		return nil, err
+	}
+	if options.WaitTime == 0 {
+		options.WaitTime = defaultWaitTime
+	}
+	return &Controller{
+		client:    client,
+		options:   options,
+		services:  map[host.Name]*model.Service{},
+		instances: map[host.Name][]*model.ServiceInstance{},
+		synced:    atomic.NewBool(false),
+	}, nil
+}
+
+func (c *Controller) Provider() serviceregistry.ProviderID {
+	return serviceregistry.Consul
+}
+
+func (c *Controller) Cluster() string {
+	return c.options.ClusterID
+}
+
+// Services list declarations of all services in the system
+func (c *Controller) Services() ([]*model.Service, error) {
+	c.cacheMutex.RLock()
+	defer c.cacheMutex.RUnlock()
+	out := make([]*model.Service, 0, len(c.services))
+	for _, svc := range c.services {
+		out = append(out, svc)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Hostname < out[j].Hostname
+	})
+	return out, nil
+}
+
+// GetService retrieves a service by host name if it exists
+func (c *Controller) GetService(hostname host.Name) (*model.Service, error) {
+	c.cacheMutex.RLock()
+	defer c.cacheMutex.RUnlock()
+	return c.services[hostname], nil
+}
+
+// InstancesByPort retrieves instances for a service on the given ports with labels that
+// match any of the supplied labels. All instances match an empty tag list.
+func (c *Controller) InstancesByPort(svc *model.Service, port int, labelsList labels.Collection) []*model.ServiceInstance {
+	c.cacheMutex.RLock()
+	defer c.cacheMutex.RUnlock()
+	var out []*model.ServiceInstance
+	for _, instance := range c.instances[svc.Hostname] {
+		if instance.ServicePort.Port == port && labelsList.HasSubsetOf(instance.Endpoint.Labels) {
+			out = append(out, instance)
+		}
+	}
+	return out
+}
+
+// GetProxyServiceInstances lists service instances co-located with a given proxy
+func (c *Controller) GetProxyServiceInstances(node *model.Proxy) []*model.ServiceInstance {
+	c.cacheMutex.RLock()
+	defer c.cacheMutex.RUnlock()
+	out := make([]*model.ServiceInstance, 0)
+	for _, instances := range c.instances {
+		for _, instance := range instances {
+			for _, ip := range node.IPAddresses {
+				if instance.Endpoint.Address == ip {
+					out = append(out, instance)
+					break
+				}
+			}
+		}
+	}
+	return out
+}
+
+func (c *Controller) GetProxyWorkloadLabels(proxy *model.Proxy) labels.Collection {
+	c.cacheMutex.RLock()
+	defer c.cacheMutex.RUnlock()
+	out := make(labels.Collection, 0)
+	for _, instances := range c.instances {
+		for _, instance := range instances {
+			for _, ip := range proxy.IPAddresses {
+				if instance.Endpoint.Address == ip {
+					out = append(out, instance.Endpoint.Labels)
+					break
+				}
+			}
+		}
+	}
+	return out
+}
+
+// GetIstioServiceAccounts implements model.ServiceAccounts operation
+func (c *Controller) GetIstioServiceAccounts(svc *model.Service, ports []int) []string {
+	return model.GetServiceAccounts(svc, ports, c)
+}
+
+func (c *Controller) NetworkGateways() map[string][]*model.Gateway {
+	// TODO: implement
+	return nil
+}
+
+// AppendServiceHandler implements a service catalog operation
+func (c *Controller) AppendServiceHandler(f func(*model.Service, model.Event)) {
+	c.handlers = append(c.handlers, f)
+}
+
+// AppendWorkloadHandler implements a service catalog operation
+func (c *Controller) AppendWorkloadHandler(func(*model.WorkloadInstance, model.Event)) {
+	// Consul does not expose workloads outside of services
+}
+
+// HasSynced returns true after the services of the catalog have been fetched once
+func (c *Controller) HasSynced() bool {
+	return c.synced.Load()
+}
+
+// serviceUpdate holds the instances of a service returned by its watch
+type serviceUpdate struct {
+	name    string
+	entries []serviceEntry
+}
+
+// Run watches the Consul catalog until a signal is received. The catalog and each of its services are watched
+// with blocking queries, so that a request is only made to Consul when a service, one of its instances or one of
+// their health checks change. The cache is updated and the handlers are notified from this goroutine only.
+func (c *Controller) Run(stop <-chan struct{}) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-stop
+		cancel()
+	}()
+
+	catalog := make(chan map[string][]string)
+	updates := make(chan serviceUpdate)
+	go c.watch(ctx, "services", func(index uint64) (uint64, error) {
+		services, newIndex, err := c.client.services(ctx, index, c.options.WaitTime)
+		if err != nil || (index > 0 && newIndex == index) {
+			return newIndex, err
+		}
+		select {
+		case catalog <- services:
+		case <-ctx.Done():
+		}
+		return newIndex, nil
+	})
+
+	// watches holds the cancel functions of the service watches, by service name
+	watches := map[string]context.CancelFunc{}
+	// pending holds the services of the first catalog which have not been fetched yet
+	var pending map[string]struct{}
+	for {
+		select {
+		case services := <-catalog:
+			for name := range services {
+				if _, exists := watches[name]; !exists {
+					watches[name] = c.watchService(ctx, name, updates)
+				}
+			}
+			for name, cancelWatch := range watches {
+				if _, exists := services[name]; !exists {
+					cancelWatch()
+					delete(watches, name)
+					delete(pending, name)
+					c.deleteService(name)
+				}
+			}
+			if pending == nil {
+				pending = make(map[string]struct{}, len(services))
+				for name := range services {
+					pending[name] = struct{}{}
+				}
+			}
+		case update := <-updates:
+			// Ignore the last result of a watch cancelled since
+			if _, exists := watches[update.name]; !exists {
+				continue
+			}
+			c.updateService(update.name, update.entries)
+			delete(pending, update.name)
+		case <-ctx.Done():
+			return
+		}
+		if pending != nil && len(pending) == 0 {
+			c.synced.Store(true)
+		}
+	}
+}
+
+// watchService starts watching the instances of a service, which are sent to updates. The returned function
+// stops the watch.
+func (c *Controller) watchService(ctx context.Context, name string, updates chan<- serviceUpdate) context.CancelFunc {
+	ctx, cancel := context.WithCancel(ctx)
+	go c.watch(ctx, "service "+name, func(index uint64) (uint64, error) {
+		entries, newIndex, err := c.client.instances(ctx, name, index, c.options.WaitTime)
+		if err != nil || (index > 0 && newIndex == index) {
+			return newIndex, err
+		}
+		select {
+		case updates <- serviceUpdate{name: name, entries: entries}:
+		case <-ctx.Done():
+		}
+		return newIndex, nil
+	})
+	return cancel
+}
+
+// watch runs a blocking query until the context is cancelled. query is passed the index returned by its
+// previous run, and returns the new index.
+func (c *Controller) watch(ctx context.Context, what string, query func(index uint64) (uint64, error)) {
+	var index uint64
+	for {
+		newIndex, err := query(index)
+		wait := time.Duration(0)
+		switch {
+		case ctx.Err() != nil:
+			return
+		case err != nil:
+			log.Warnf("failed to watch %s in consul %s: %v", what, c.options.ServerURL, err)
+			wait = retryInterval
+		default:
+			// Consul may reset the index, for example when a server restarts. In that case
+			// we start over with a non-blocking query.
+			if newIndex < index {
+				newIndex = 0
+			}
+			index = newIndex
+			if index == 0 {
+				// Without an index we cannot block, so fall back to polling
+				wait = c.options.WaitTime
+			}
+		}
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// updateService updates the cache with the instances of a service and notifies handlers of any changes.
+func (c *Controller) updateService(name string, entries []serviceEntry) {
+	svc := convertService(name, entries)
+	instances := convertInstances(svc, entries)
+
+	c.cacheMutex.Lock()
+	old, exists := c.services[svc.Hostname]
+	oldInstances := c.instances[svc.Hostname]
+	c.services[svc.Hostname] = svc
+	c.instances[svc.Hostname] = instances
+	c.cacheMutex.Unlock()
+
+	if !reflect.DeepEqual(instances, oldInstances) && c.options.XDSUpdater != nil {
+		c.options.XDSUpdater.EDSUpdate(c.Cluster(), string(svc.Hostname), svc.Attributes.Namespace, endpoints(instances))
+	}
+	switch {
+	case !exists:
+		c.notify(svc, model.EventAdd)
+	case !reflect.DeepEqual(old, svc):
+		c.notify(svc, model.EventUpdate)
+	}
+}
+
+// deleteService removes a service from the cache and notifies handlers.
+func (c *Controller) deleteService(name string) {
+	hostname := serviceHostname(name)
+	c.cacheMutex.Lock()
+	svc, exists := c.services[hostname]
+	delete(c.services, hostname)
+	delete(c.instances, hostname)
+	c.cacheMutex.Unlock()
+	if !exists {
+		return
+	}
+
+	if c.options.XDSUpdater != nil {
+		// Clear the endpoints, so that they are not kept by the proxies until the cluster is removed
+		c.options.XDSUpdater.EDSUpdate(c.Cluster(), string(hostname), svc.Attributes.Namespace, nil)
+		c.options.XDSUpdater.SvcUpdate(c.Cluster(), string(hostname), svc.Attributes.Namespace, model.EventDelete)
+	}
+	c.notify(svc, model.EventDelete)
+}
+
+func (c *Controller) notify(svc *model.Service, event model.Event) {
+	log.Debugf("consul service %s: %v", svc.Hostname, event)
+	for _, f := range c.handlers {
+		f(svc, event)
+	}
+}
+
+func endpoints(instances []*model.ServiceInstance) []*model.IstioEndpoint {
+	out := make([]*model.IstioEndpoint, 0, len(instances))
+	for _, instance := range instances {
+		out = append(out, instance.Endpoint)
+	}
+	return out
+}
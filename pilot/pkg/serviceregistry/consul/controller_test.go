@@ -0,0 +1,357 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package consul
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pkg/config/labels"
+	"istio.io/istio/pkg/spiffe"
+	"istio.io/istio/pkg/test/util/retry"
+)
+
+// fakeCatalog is a fake Consul server, serving the subset of the HTTP API used by the registry.
+type fakeCatalog struct {
+	mu       sync.Mutex
+	index    uint64
+	changed  chan struct{}
+	services map[string][]serviceEntry
+}
+
+func newFakeCatalog() *fakeCatalog {
+	return &fakeCatalog{
+		index:   1,
+		changed: make(chan struct{}),
+		services: map[string][]serviceEntry{
+			"productpage": {
+				{
+					Node:    node{Node: "node1", Address: "172.19.0.5", Datacenter: "dc1"},
+					Service: agentService{ID: "productpage-1", Service: "productpage", Port: 9080, Tags: []string{"version|v1"}},
+					Checks:  []healthCheck{{CheckID: "serfHealth", Status: "passing"}},
+				},
+				{
+					Node: node{Node: "node2", Address: "172.19.0.6", Datacenter: "dc1"},
+					Service: agentService{
+						ID:      "productpage-2",
+						Service: "productpage",
+						Port:    9080,
+						Tags:    []string{"version|v2"},
+						Meta:    map[string]string{serviceAccountMetaKey: "productpage"},
+					},
+					Checks: []healthCheck{{CheckID: "serfHealth", Status: "passing"}},
+				},
+			},
+			"reviews": {
+				{
+					Node:    node{Node: "node1", Address: "172.19.0.5", Datacenter: "dc1"},
+					Service: agentService{ID: "reviews-1", Service: "reviews", Port: 9081, Meta: map[string]string{protocolMetaKey: "http"}},
+					Checks: []healthCheck{
+						{CheckID: "serfHealth", Status: "passing"},
+						{CheckID: "service:reviews-1", ServiceID: "reviews-1", Status: "critical"},
+					},
+				},
+			},
+		},
+	}
+}
+
+// update modifies the catalog and wakes up blocking queries
+func (f *fakeCatalog) update(fn func(services map[string][]serviceEntry)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	fn(f.services)
+	f.index++
+	close(f.changed)
+	f.changed = make(chan struct{})
+}
+
+// wait blocks the query until the catalog changes past the index of the query, or its wait time elapses
+func (f *fakeCatalog) wait(r *http.Request) {
+	f.mu.Lock()
+	index, changed := f.index, f.changed
+	f.mu.Unlock()
+	if i, _ := strconv.ParseUint(r.URL.Query().Get("index"), 10, 64); i >= index {
+		wait, _ := time.ParseDuration(r.URL.Query().Get("wait"))
+		select {
+		case <-changed:
+		case <-time.After(wait):
+		case <-r.Context().Done():
+		}
+	}
+}
+
+func (f *fakeCatalog) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == "/v1/catalog/services":
+		f.wait(r)
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		out := map[string][]string{}
+		for name := range f.services {
+			out[name] = []string{}
+		}
+		w.Header().Set(consulIndexHeader, strconv.FormatUint(f.index, 10))
+		_ = json.NewEncoder(w).Encode(out)
+	case strings.HasPrefix(r.URL.Path, "/v1/health/service/"):
+		f.wait(r)
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		entries := f.services[strings.TrimPrefix(r.URL.Path, "/v1/health/service/")]
+		if entries == nil {
+			entries = []serviceEntry{}
+		}
+		w.Header().Set(consulIndexHeader, strconv.FormatUint(f.index, 10))
+		_ = json.NewEncoder(w).Encode(entries)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+type xdsEvent struct {
+	kind      string
+	host      string
+	endpoints int
+}
+
+// fakeXdsUpdater records the endpoint and service updates, including the updates clearing the endpoints.
+type fakeXdsUpdater struct {
+	events chan xdsEvent
+}
+
+var _ model.XDSUpdater = &fakeXdsUpdater{}
+
+func (fx *fakeXdsUpdater) EDSUpdate(_, hostname string, _ string, entry []*model.IstioEndpoint) {
+	fx.events <- xdsEvent{kind: "eds", host: hostname, endpoints: len(entry)}
+}
+
+func (fx *fakeXdsUpdater) EDSCacheUpdate(_, _, _ string, _ []*model.IstioEndpoint) {
+}
+
+func (fx *fakeXdsUpdater) ConfigUpdate(*model.PushRequest) {
+}
+
+func (fx *fakeXdsUpdater) ProxyUpdate(_, _ string) {
+}
+
+func (fx *fakeXdsUpdater) SvcUpdate(_, hostname string, _ string, _ model.Event) {
+	fx.events <- xdsEvent{kind: "service", host: hostname}
+}
+
+// wait returns the next event of the given kind
+func (fx *fakeXdsUpdater) wait(t *testing.T, kind string) xdsEvent {
+	t.Helper()
+	for {
+		select {
+		case ev := <-fx.events:
+			if ev.kind == kind {
+				return ev
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for %s event", kind)
+		}
+	}
+}
+
+func (fx *fakeXdsUpdater) clear() {
+	for {
+		select {
+		case <-fx.events:
+		default:
+			return
+		}
+	}
+}
+
+func newTestController(t *testing.T) (*Controller, *fakeCatalog, *fakeXdsUpdater, chan model.Event) {
+	t.Helper()
+	catalog := newFakeCatalog()
+	ts := httptest.NewServer(catalog)
+	t.Cleanup(ts.Close)
+
+	xdsUpdater := &fakeXdsUpdater{events: make(chan xdsEvent, 100)}
+	c, err := NewController(Options{
+		ServerURL:  ts.URL,
+		ClusterID:  "consul",
+		XDSUpdater: xdsUpdater,
+		WaitTime:   time.Second,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := make(chan model.Event, 10)
+	c.AppendServiceHandler(func(_ *model.Service, event model.Event) {
+		events <- event
+	})
+	stop := make(chan struct{})
+	t.Cleanup(func() { close(stop) })
+	go c.Run(stop)
+	retry.UntilSuccessOrFail(t, func() error {
+		if !c.HasSynced() {
+			return fmt.Errorf("not synced")
+		}
+		return nil
+	}, retry.Timeout(5*time.Second))
+	return c, catalog, xdsUpdater, events
+}
+
+func waitEvent(t *testing.T, events chan model.Event, want model.Event) {
+	t.Helper()
+	select {
+	case ev := <-events:
+		if ev != want {
+			t.Fatalf("expected %v event, got %v", want, ev)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for %v event", want)
+	}
+}
+
+func TestServices(t *testing.T) {
+	c, _, _, _ := newTestController(t)
+
+	services, err := c.Services()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(services) != 2 {
+		t.Fatalf("Services() returned wrong # of services: %d, want 2", len(services))
+	}
+	if services[0].Hostname != serviceHostname("productpage") || services[1].Hostname != serviceHostname("reviews") {
+		t.Fatalf("Services() returned wrong services: %v", services)
+	}
+
+	svc, err := c.GetService(serviceHostname("productpage"))
+	if err != nil || svc == nil {
+		t.Fatalf("GetService() failed: %v %v", svc, err)
+	}
+	if svc, _ := c.GetService("unknown.service.consul"); svc != nil {
+		t.Fatalf("GetService() found unknown service: %v", svc)
+	}
+}
+
+func TestInstancesByPort(t *testing.T) {
+	c, _, _, _ := newTestController(t)
+
+	productpage, _ := c.GetService(serviceHostname("productpage"))
+	if instances := c.InstancesByPort(productpage, 9080, nil); len(instances) != 2 {
+		t.Fatalf("InstancesByPort() returned wrong # of instances: %v, want 2", instances)
+	}
+	instances := c.InstancesByPort(productpage, 9080, labels.Collection{{"version": "v1"}})
+	if len(instances) != 1 || instances[0].Endpoint.Address != "172.19.0.5" {
+		t.Fatalf("InstancesByPort() returned wrong instances: %v", instances)
+	}
+	if instances := c.InstancesByPort(productpage, 1234, nil); len(instances) != 0 {
+		t.Fatalf("InstancesByPort() returned instances on unknown port: %v", instances)
+	}
+
+	// Instances failing their health checks are not returned
+	reviews, _ := c.GetService(serviceHostname("reviews"))
+	if instances := c.InstancesByPort(reviews, 9081, nil); len(instances) != 0 {
+		t.Fatalf("InstancesByPort() returned unhealthy instances: %v", instances)
+	}
+}
+
+func TestGetProxyServiceInstances(t *testing.T) {
+	c, _, _, _ := newTestController(t)
+
+	instances := c.GetProxyServiceInstances(&model.Proxy{IPAddresses: []string{"172.19.0.6"}})
+	if len(instances) != 1 || instances[0].Service.Hostname != serviceHostname("productpage") {
+		t.Fatalf("GetProxyServiceInstances() returned wrong instances: %v", instances)
+	}
+	workloadLabels := c.GetProxyWorkloadLabels(&model.Proxy{IPAddresses: []string{"172.19.0.6"}})
+	if len(workloadLabels) != 1 || workloadLabels[0]["version"] != "v2" {
+		t.Fatalf("GetProxyWorkloadLabels() returned wrong labels: %v", workloadLabels)
+	}
+}
+
+func TestGetIstioServiceAccounts(t *testing.T) {
+	c, _, _, _ := newTestController(t)
+
+	productpage, _ := c.GetService(serviceHostname("productpage"))
+	accounts := c.GetIstioServiceAccounts(productpage, []int{9080})
+	sort.Strings(accounts)
+	want := []string{
+		spiffe.MustGenSpiffeURI("default", "default"),
+		spiffe.MustGenSpiffeURI("default", "productpage"),
+	}
+	if !reflect.DeepEqual(accounts, want) {
+		t.Fatalf("GetIstioServiceAccounts() => %v, want %v", accounts, want)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	c, catalog, xdsUpdater, events := newTestController(t)
+	drain := func() {
+		for {
+			select {
+			case <-events:
+			default:
+				return
+			}
+		}
+	}
+	drain()
+	xdsUpdater.clear()
+
+	// A new service is picked up
+	catalog.update(func(services map[string][]serviceEntry) {
+		services["ratings"] = []serviceEntry{{
+			Node:    node{Node: "node3", Address: "172.19.0.7"},
+			Service: agentService{ID: "ratings-1", Service: "ratings", Port: 9082},
+		}}
+	})
+	if ev := xdsUpdater.wait(t, "eds"); ev.host != string(serviceHostname("ratings")) || ev.endpoints != 1 {
+		t.Fatalf("expected eds update for ratings, got %v", ev)
+	}
+	waitEvent(t, events, model.EventAdd)
+
+	// Health check changes update the endpoints
+	catalog.update(func(services map[string][]serviceEntry) {
+		services["reviews"][0].Checks[1].Status = "passing"
+	})
+	if ev := xdsUpdater.wait(t, "eds"); ev.host != string(serviceHostname("reviews")) || ev.endpoints != 1 {
+		t.Fatalf("expected eds update for reviews, got %v", ev)
+	}
+	reviews, _ := c.GetService(serviceHostname("reviews"))
+	if instances := c.InstancesByPort(reviews, 9081, nil); len(instances) != 1 {
+		t.Fatalf("InstancesByPort() returned wrong # of instances: %v, want 1", instances)
+	}
+
+	// Deleted services are removed, and their endpoints cleared
+	catalog.update(func(services map[string][]serviceEntry) {
+		delete(services, "ratings")
+	})
+	if ev := xdsUpdater.wait(t, "eds"); ev.host != string(serviceHostname("ratings")) || ev.endpoints != 0 {
+		t.Fatalf("expected empty eds update for ratings, got %v", ev)
+	}
+	if ev := xdsUpdater.wait(t, "service"); ev.host != string(serviceHostname("ratings")) {
+		t.Fatalf("expected service delete for ratings, got %v", ev)
+	}
+	waitEvent(t, events, model.EventDelete)
+	if svc, _ := c.GetService(serviceHostname("ratings")); svc != nil {
+		t.Fatalf("GetService() found deleted service: %v", svc)
+	}
+}
@@ -0,0 +1,158 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package consul
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/serviceregistry"
+	"istio.io/istio/pkg/config/constants"
+	"istio.io/istio/pkg/config/host"
+	"istio.io/istio/pkg/config/labels"
+	"istio.io/istio/pkg/config/protocol"
+	"istio.io/istio/pkg/spiffe"
+	"istio.io/pkg/log"
+)
+
+// Service metadata keys read by the registry
+const (
+	// protocolMetaKey is the protocol of the service port, TCP if unset
+	protocolMetaKey = "protocol"
+	// externalMetaKey marks the instance as outside of the mesh
+	externalMetaKey = "external"
+	// serviceAccountMetaKey is the service account the instance runs as, "default" if unset
+	serviceAccountMetaKey = "service-account"
+)
+
+// convertLabels converts the tags of an instance to labels. Tags not of the form "key|value" are ignored.
+func convertLabels(tags []string) labels.Instance {
+	out := make(labels.Instance, len(tags))
+	for _, tag := range tags {
+		if kv := strings.SplitN(tag, "|", 2); len(kv) == 2 {
+			out[kv[0]] = kv[1]
+		}
+	}
+	return out
+}
+
+// convertPort converts the port of an instance. The port is named after its protocol.
+func convertPort(entry serviceEntry) *model.Port {
+	name := entry.Service.Meta[protocolMetaKey]
+	if name == "" {
+		name = "tcp"
+	}
+	p := protocol.Parse(name)
+	if p == protocol.Unsupported {
+		p = protocol.TCP
+	}
+	return &model.Port{
+		Name:     name,
+		Port:     entry.Service.Port,
+		Protocol: p,
+	}
+}
+
+// convertService converts the instances of a service to a service. The service has a port for each distinct port
+// of its instances, and is outside of the mesh if all of its instances are.
+func convertService(name string, entries []serviceEntry) *model.Service {
+	ports := make(map[int]*model.Port)
+	meshExternal := len(entries) > 0
+	for _, entry := range entries {
+		port := convertPort(entry)
+		if svcPort, exists := ports[port.Port]; exists && svcPort.Protocol != port.Protocol {
+			log.Warnf("Service %v has two instances on same port %v but different protocols (%v, %v)",
+				name, port.Port, svcPort.Protocol, port.Protocol)
+		} else {
+			ports[port.Port] = port
+		}
+		if entry.Service.Meta[externalMetaKey] == "" {
+			meshExternal = false
+		}
+	}
+
+	svcPorts := make(model.PortList, 0, len(ports))
+	for _, port := range ports {
+		svcPorts = append(svcPorts, port)
+	}
+	sort.Slice(svcPorts, func(i, j int) bool {
+		return svcPorts[i].Port < svcPorts[j].Port
+	})
+
+	resolution := model.ClientSideLB
+	if meshExternal {
+		resolution = model.Passthrough
+	}
+	return &model.Service{
+		Hostname:     serviceHostname(name),
+		Address:      constants.UnspecifiedIP,
+		Ports:        svcPorts,
+		MeshExternal: meshExternal,
+		Resolution:   resolution,
+		Attributes: model.ServiceAttributes{
+			ServiceRegistry: string(serviceregistry.Consul),
+			Name:            name,
+			Namespace:       model.IstioDefaultConfigNamespace,
+		},
+	}
+}
+
+// convertInstances converts the instances of a service passing their health checks.
+func convertInstances(service *model.Service, entries []serviceEntry) []*model.ServiceInstance {
+	var out []*model.ServiceInstance
+	for _, entry := range entries {
+		if entry.healthy() {
+			out = append(out, convertInstance(service, entry))
+		}
+	}
+	return out
+}
+
+func convertInstance(service *model.Service, entry serviceEntry) *model.ServiceInstance {
+	svcLabels := convertLabels(entry.Service.Tags)
+	port := convertPort(entry)
+	addr := entry.Service.Address
+	if addr == "" {
+		addr = entry.Node.Address
+	}
+	sa := entry.Service.Meta[serviceAccountMetaKey]
+	if sa == "" {
+		sa = "default"
+	}
+
+	return &model.ServiceInstance{
+		Endpoint: &model.IstioEndpoint{
+			Address:         addr,
+			EndpointPort:    uint32(port.Port),
+			ServicePortName: port.Name,
+			Locality: model.Locality{
+				Label: entry.Node.Datacenter,
+			},
+			Labels:         svcLabels,
+			ServiceAccount: spiffe.MustGenSpiffeURI(service.Attributes.Namespace, sa),
+			TLSMode:        model.GetTLSModeFromEndpointLabels(svcLabels),
+			Namespace:      service.Attributes.Namespace,
+		},
+		ServicePort: port,
+		Service:     service,
+	}
+}
+
+// serviceHostname returns the hostname of a service, as resolved by the Consul DNS interface.
+func serviceHostname(name string) host.Name {
+	return host.Name(fmt.Sprintf("%s.service.consul", name))
+}
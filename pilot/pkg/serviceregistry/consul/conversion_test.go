@@ -0,0 +1,186 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package consul
+
+import (
+	"testing"
+
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/serviceregistry"
+	"istio.io/istio/pkg/config/constants"
+	"istio.io/istio/pkg/config/host"
+	"istio.io/istio/pkg/config/protocol"
+	"istio.io/istio/pkg/spiffe"
+)
+
+var (
+	protocols = []struct {
+		name string
+		port int
+		out  protocol.Instance
+	}{
+		{"tcp", 80, protocol.TCP},
+		{"http", 81, protocol.HTTP},
+		{"https", 443, protocol.HTTPS},
+		{"http2", 83, protocol.HTTP2},
+		{"grpc", 84, protocol.GRPC},
+		{"udp", 85, protocol.UDP},
+		{"", 86, protocol.TCP},
+	}
+
+	goodLabels = []string{
+		"key1|val1",
+		"version|v1",
+	}
+
+	badLabels = []string{
+		"badtag",
+		"goodtag|goodvalue",
+	}
+)
+
+func TestConvertProtocol(t *testing.T) {
+	for _, tt := range protocols {
+		entry := serviceEntry{Service: agentService{Port: tt.port, Meta: map[string]string{protocolMetaKey: tt.name}}}
+		out := convertPort(entry)
+		if out.Protocol != tt.out {
+			t.Errorf("convertProtocol(%v, %q) => %q, want %q", tt.port, tt.name, out.Protocol, tt.out)
+		}
+	}
+}
+
+func TestConvertLabels(t *testing.T) {
+	out := convertLabels(goodLabels)
+	if len(out) != len(goodLabels) {
+		t.Errorf("convertLabels(%q) => length %v, want %v", goodLabels, len(out), len(goodLabels))
+	}
+
+	out = convertLabels(badLabels)
+	if len(out) == len(badLabels) {
+		t.Errorf("convertLabels(%q) => length %v, want %v", badLabels, len(out), len(badLabels)-1)
+	}
+}
+
+func TestConvertInstance(t *testing.T) {
+	name := "productpage"
+	ip := "172.19.0.11"
+	port := 9080
+	entry := serviceEntry{
+		Node: node{
+			Node:       "istio-node",
+			Address:    "172.19.0.5",
+			Datacenter: "dc1",
+		},
+		Service: agentService{
+			ID:      "istio-node-id",
+			Service: name,
+			Tags:    goodLabels,
+			Address: ip,
+			Port:    port,
+			Meta:    map[string]string{protocolMetaKey: "http", serviceAccountMetaKey: "productpage"},
+		},
+	}
+	svc := convertService(name, []serviceEntry{entry})
+	out := convertInstance(svc, entry)
+
+	if out.ServicePort.Protocol != protocol.HTTP {
+		t.Errorf("convertInstance() => %v, want %v", out.ServicePort.Protocol, protocol.HTTP)
+	}
+	if out.ServicePort.Name != "http" {
+		t.Errorf("convertInstance() => %v, want %v", out.ServicePort.Name, "http")
+	}
+	if out.ServicePort.Port != port || out.Endpoint.EndpointPort != uint32(port) {
+		t.Errorf("convertInstance() => %v, want %v", out.ServicePort.Port, port)
+	}
+	if out.Endpoint.Locality.Label != "dc1" {
+		t.Errorf("convertInstance() => %v, want %v", out.Endpoint.Locality.Label, "dc1")
+	}
+	if out.Endpoint.Address != ip {
+		t.Errorf("convertInstance() => %v, want %v", out.Endpoint.Address, ip)
+	}
+	if !out.Endpoint.Labels.Equals(convertLabels(goodLabels)) {
+		t.Errorf("convertInstance() => %v, want %v", out.Endpoint.Labels, goodLabels)
+	}
+	if out.Service != svc {
+		t.Errorf("convertInstance() => %v, want %v", out.Service, svc)
+	}
+	if sa := spiffe.MustGenSpiffeURI("default", "productpage"); out.Endpoint.ServiceAccount != sa {
+		t.Errorf("convertInstance() => %v, want %v", out.Endpoint.ServiceAccount, sa)
+	}
+
+	// The node address is used if the service does not have its own address
+	entry.Service.Address = ""
+	if out := convertInstance(svc, entry); out.Endpoint.Address != entry.Node.Address {
+		t.Errorf("convertInstance() => %v, want %v", out.Endpoint.Address, entry.Node.Address)
+	}
+
+	// Instances without a service account run as the default service account
+	entry.Service.Meta = nil
+	if out, sa := convertInstance(svc, entry), spiffe.MustGenSpiffeURI("default", "default"); out.Endpoint.ServiceAccount != sa {
+		t.Errorf("convertInstance() => %v, want %v", out.Endpoint.ServiceAccount, sa)
+	}
+}
+
+func TestConvertService(t *testing.T) {
+	name := "productpage"
+	entries := []serviceEntry{
+		{
+			Node:    node{Address: "172.19.0.5"},
+			Service: agentService{Service: name, Port: 9080, Meta: map[string]string{protocolMetaKey: "http"}},
+		},
+		{
+			Node:    node{Address: "172.19.0.6"},
+			Service: agentService{Service: name, Port: 9080, Meta: map[string]string{protocolMetaKey: "http"}},
+		},
+		{
+			Node:    node{Address: "172.19.0.6"},
+			Service: agentService{Service: name, Port: 9090, Meta: map[string]string{protocolMetaKey: "grpc"}},
+		},
+	}
+
+	out := convertService(name, entries)
+
+	if out.Hostname != serviceHostname(name) {
+		t.Errorf("convertService() bad hostname => %q, want %q", out.Hostname, serviceHostname(name))
+	}
+	if out.Hostname != host.Name("productpage.service.consul") {
+		t.Errorf("convertService() bad hostname => %q", out.Hostname)
+	}
+	if out.Address != constants.UnspecifiedIP {
+		t.Errorf("convertService() bad address => %q, want %q", out.Address, constants.UnspecifiedIP)
+	}
+	if len(out.Ports) != 2 || out.Ports[0].Port != 9080 || out.Ports[1].Protocol != protocol.GRPC {
+		t.Errorf("convertService() incorrect ports => %v", out.Ports)
+	}
+	if out.MeshExternal || out.Resolution != model.ClientSideLB {
+		t.Errorf("convertService() should not be external")
+	}
+	if out.Attributes.ServiceRegistry != string(serviceregistry.Consul) {
+		t.Errorf("convertService() bad registry => %q", out.Attributes.ServiceRegistry)
+	}
+
+	// The service is external only if all of its instances are
+	entries[0].Service.Meta[externalMetaKey] = "google.com"
+	if out := convertService(name, entries); out.MeshExternal {
+		t.Errorf("convertService() should not be external")
+	}
+	for _, entry := range entries {
+		entry.Service.Meta[externalMetaKey] = "google.com"
+	}
+	out = convertService(name, entries)
+	if !out.MeshExternal || out.Resolution != model.Passthrough {
+		t.Errorf("convertService() should be external with passthrough resolution, got %v %v", out.MeshExternal, out.Resolution)
+	}
+}
@@ -26,4 +26,6 @@ const (
 	MCP ProviderID = "MCP"
 	// External is a service registry for externally provided ServiceEntries
 	External = "External"
+	// Consul is a service registry backed by the Consul catalog
+	Consul ProviderID = "Consul"
 )
@@ -19,7 +19,9 @@ import (
 	"strings"
 
 	"istio.io/api/label"
+	"istio.io/api/meta/v1alpha1"
 	networking "istio.io/api/networking/v1alpha3"
+	"istio.io/istio/pilot/pkg/features"
 	"istio.io/istio/pilot/pkg/model"
 	"istio.io/istio/pilot/pkg/serviceregistry"
 	"istio.io/istio/pkg/config"
@@ -419,3 +421,21 @@ func convertWorkloadEntryToWorkloadInstance(cfg config.Config) *model.WorkloadIn
 		Name:      cfg.Name,
 	}
 }
+
+// isHealthy returns false if the health checks of the WorkloadEntry, run by istio-agent, report it as
+// unhealthy. Entries without health information are considered healthy.
+func isHealthy(cfg config.Config) bool {
+	if !features.WorkloadEntryHealthChecks {
+		return true
+	}
+	status, ok := cfg.Status.(*v1alpha1.IstioStatus)
+	if !ok || status == nil {
+		return true
+	}
+	for _, cond := range status.Conditions {
+		if cond.Type == model.WorkloadEntryHealthyCondition {
+			return cond.Status != model.WorkloadEntryConditionFalse
+		}
+	}
+	return true
+}
@@ -113,13 +113,19 @@ func (s *ServiceEntryStore) workloadEntryHandler(old, curr config.Config, event
 		name:      curr.Name,
 		namespace: curr.Namespace,
 	}
+	// An unhealthy workload entry is handled as deleted, so that its endpoints are removed
+	healthy := isHealthy(curr)
+	instanceEvent := event
+	if !healthy {
+		instanceEvent = model.EventDelete
+	}
 
 	// fire off the k8s handlers
 	if len(s.workloadHandlers) > 0 {
 		si := convertWorkloadEntryToWorkloadInstance(curr)
 		if si != nil {
 			for _, h := range s.workloadHandlers {
-				h(si, event)
+				h(si, instanceEvent)
 			}
 		}
 	}
@@ -172,7 +178,7 @@ func (s *ServiceEntryStore) workloadEntryHandler(old, curr config.Config, event
 		s.deleteExistingInstances(key, instancesDeleted)
 	}
 
-	if event != model.EventDelete {
+	if instanceEvent != model.EventDelete {
 		s.updateExistingInstances(key, instancesUpdated)
 	} else {
 		s.deleteExistingInstances(key, instancesUpdated)
@@ -662,6 +668,10 @@ func (s *ServiceEntryStore) maybeRefreshIndexes() {
 	}
 
 	for _, wcfg := range wles {
+		if !isHealthy(wcfg) {
+			// Endpoints of unhealthy workload entries are not served
+			continue
+		}
 		wle := wcfg.Spec.(*networking.WorkloadEntry)
 		key := configKey{
 			kind:      workloadEntryConfigType,
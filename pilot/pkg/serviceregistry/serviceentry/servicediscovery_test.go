@@ -23,8 +23,10 @@ import (
 	"time"
 
 	"istio.io/api/label"
+	"istio.io/api/meta/v1alpha1"
 	networking "istio.io/api/networking/v1alpha3"
 	"istio.io/istio/pilot/pkg/config/memory"
+	"istio.io/istio/pilot/pkg/features"
 	"istio.io/istio/pilot/pkg/model"
 	"istio.io/istio/pkg/config"
 	"istio.io/istio/pkg/config/constants"
@@ -751,6 +753,72 @@ func TestServiceDiscoveryWorkloadChangeLabel(t *testing.T) {
 	})
 }
 
+func TestServiceDiscoveryWorkloadHealth(t *testing.T) {
+	original := features.WorkloadEntryHealthChecks
+	features.WorkloadEntryHealthChecks = true
+	defer func() { features.WorkloadEntryHealthChecks = original }()
+
+	store, sd, events, stopFn := initServiceDiscovery()
+	defer stopFn()
+
+	wle := createWorkloadEntry("wl", selector.Name,
+		&networking.WorkloadEntry{
+			Address:        "2.2.2.2",
+			Labels:         map[string]string{"app": "wle"},
+			ServiceAccount: "default",
+		})
+	withHealth := func(status string) config.Config {
+		cfg := store.Get(gvk.WorkloadEntry, wle.Name, wle.Namespace).DeepCopy()
+		cfg.Status = &v1alpha1.IstioStatus{
+			Conditions: []*v1alpha1.IstioCondition{{Type: model.WorkloadEntryHealthyCondition, Status: status}},
+		}
+		return cfg
+	}
+	instances := []*model.ServiceInstance{
+		makeInstanceWithServiceAccount(selector, "2.2.2.2", 444,
+			selector.Spec.(*networking.ServiceEntry).Ports[0],
+			map[string]string{"app": "wle"}, "default"),
+		makeInstanceWithServiceAccount(selector, "2.2.2.2", 445,
+			selector.Spec.(*networking.ServiceEntry).Ports[1],
+			map[string]string{"app": "wle"}, "default"),
+	}
+	for _, i := range instances {
+		i.Endpoint.WorkloadName = "wl"
+		i.Endpoint.Namespace = selector.Name
+	}
+
+	createConfigs([]*config.Config{selector, wle}, store, t)
+	expectEvents(t, events,
+		Event{kind: "svcupdate", host: "selector.com", namespace: selector.Namespace},
+		Event{kind: "xds"},
+		Event{kind: "eds", host: "selector.com", namespace: selector.Namespace, endpoints: 2})
+	expectServiceInstances(t, sd, selector, 0, instances)
+
+	t.Run("unhealthy", func(t *testing.T) {
+		if _, err := store.UpdateStatus(withHealth(model.WorkloadEntryConditionFalse)); err != nil {
+			t.Fatal(err)
+		}
+		expectEvents(t, events, Event{kind: "eds", host: "selector.com", namespace: selector.Namespace, endpoints: 0})
+		expectServiceInstances(t, sd, selector, 0, []*model.ServiceInstance{})
+		expectProxyInstances(t, sd, []*model.ServiceInstance{}, "2.2.2.2")
+	})
+
+	t.Run("unhealthy on refresh", func(t *testing.T) {
+		// A full refresh of the indexes must not add the unhealthy endpoints back
+		sd.refreshIndexes.Store(true)
+		expectServiceInstances(t, sd, selector, 0, []*model.ServiceInstance{})
+	})
+
+	t.Run("healthy again", func(t *testing.T) {
+		if _, err := store.UpdateStatus(withHealth(model.WorkloadEntryConditionTrue)); err != nil {
+			t.Fatal(err)
+		}
+		expectEvents(t, events, Event{kind: "eds", host: "selector.com", namespace: selector.Namespace, endpoints: 2})
+		expectServiceInstances(t, sd, selector, 0, instances)
+		expectProxyInstances(t, sd, instances, "2.2.2.2")
+	})
+}
+
 func TestServiceDiscoveryWorkloadInstance(t *testing.T) {
 	store, sd, events, stopFn := initServiceDiscovery()
 	defer stopFn()
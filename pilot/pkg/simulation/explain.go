@@ -0,0 +1,94 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simulation
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+
+	"istio.io/istio/pilot/pkg/networking/util"
+)
+
+// Explain describes why a simulated call did not match, based on the Result of Run. This is intended for
+// users debugging their configuration, so it lists the candidates that were considered at the step
+// that failed. An empty string is returned if the call was successful or there is nothing to add to the error.
+func (sim *Simulation) Explain(input Call, result Result) string {
+	input = input.FillDefaults()
+	switch {
+	case result.Error == nil:
+		return ""
+	case errors.Is(result.Error, ErrNoListener):
+		ports := []string{}
+		for _, l := range sim.Listeners {
+			sa := l.GetAddress().GetSocketAddress()
+			if sa == nil {
+				continue
+			}
+			ports = append(ports, fmt.Sprintf("%s:%d", sa.GetAddress(), sa.GetPortValue()))
+		}
+		sort.Strings(ports)
+		return fmt.Sprintf("no listener is bound to %s:%d and there is no virtual listener to fall back to; listeners: %s",
+			input.Address, input.Port, strings.Join(ports, ", "))
+	case errors.Is(result.Error, ErrNoFilterChain), errors.Is(result.Error, ErrMultipleFilterChain):
+		l := util.ExtractListener(result.ListenerMatched, sim.Listeners)
+		chains := []string{}
+		for _, fc := range l.GetFilterChains() {
+			chains = append(chains, fmt.Sprintf("%q {%v}", fc.Name, fc.GetFilterChainMatch()))
+		}
+		return fmt.Sprintf("listener %q has %d filter chains; for port=%d address=%s sni=%q tls=%s protocol=%s, %v: %s",
+			result.ListenerMatched, len(chains), input.Port, input.Address, input.Sni, input.TLS, input.Protocol,
+			result.Error, strings.Join(chains, ", "))
+	case errors.Is(result.Error, ErrTLSError):
+		return fmt.Sprintf("filter chain %q terminates TLS, but the request was %s", result.FilterChainMatched, input.TLS)
+	case errors.Is(result.Error, ErrProtocolError):
+		return fmt.Sprintf("filter chain %q expects plaintext HTTP, but the request was %s", result.FilterChainMatched, input.TLS)
+	case errors.Is(result.Error, ErrNoRouteConfig):
+		return fmt.Sprintf("route configuration %q referenced by filter chain %q was not found",
+			result.RouteConfigMatched, result.FilterChainMatched)
+	case errors.Is(result.Error, ErrNoVirtualHost):
+		domains := []string{}
+		if rc := util.ExtractRouteConfigurations(sim.Routes)[result.RouteConfigMatched]; rc != nil {
+			for _, vh := range rc.VirtualHosts {
+				domains = append(domains, vh.Domains...)
+			}
+		}
+		return fmt.Sprintf("no virtual host matches host %q; domains: %s", input.Headers.Get("Host"), strings.Join(domains, ", "))
+	case errors.Is(result.Error, ErrNoRoute):
+		routes := []string{}
+		if vh := sim.virtualHost(result.RouteConfigMatched, result.VirtualHostMatched); vh != nil {
+			for _, r := range vh.Routes {
+				routes = append(routes, fmt.Sprintf("%q {%v}", r.Name, r.GetMatch()))
+			}
+		}
+		return fmt.Sprintf("no route in virtual host %q matches method=%s path=%q headers=%v; routes: %s",
+			result.VirtualHostMatched, input.Method, input.Path, input.Headers, strings.Join(routes, ", "))
+	default:
+		return ""
+	}
+}
+
+func (sim *Simulation) virtualHost(routeConfig, name string) *route.VirtualHost {
+	rc := util.ExtractRouteConfigurations(sim.Routes)[routeConfig]
+	for _, vh := range rc.GetVirtualHosts() {
+		if vh.Name == name {
+			return vh
+		}
+	}
+	return nil
+}
@@ -0,0 +1,203 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simulation
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	klabels "k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	meshconfig "istio.io/api/mesh/v1alpha1"
+	"istio.io/istio/pilot/pkg/config/memory"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/networking/core/v1alpha3"
+	"istio.io/istio/pilot/pkg/networking/plugin"
+	"istio.io/istio/pilot/pkg/networking/plugin/registry"
+	"istio.io/istio/pilot/pkg/networking/util"
+	"istio.io/istio/pilot/pkg/serviceregistry"
+	"istio.io/istio/pilot/pkg/serviceregistry/aggregate"
+	"istio.io/istio/pilot/pkg/serviceregistry/kube"
+	memregistry "istio.io/istio/pilot/pkg/serviceregistry/memory"
+	"istio.io/istio/pilot/pkg/serviceregistry/serviceentry"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/constants"
+	"istio.io/istio/pkg/config/mesh"
+	"istio.io/istio/pkg/config/schema/collections"
+)
+
+// IstioConfig is the configuration Istiod generates the xDS resources of a proxy from.
+type IstioConfig struct {
+	// Configs are the Istio configs, such as VirtualServices and DestinationRules. They are validated.
+	Configs []config.Config
+	// Services are the Kubernetes Services the configs refer to.
+	Services []*corev1.Service
+	// Pod is the pod of the proxy, if any. The proxy is an instance of the Services selecting it, which
+	// gives it its inbound listeners.
+	Pod *corev1.Pod
+	// MeshConfig is the mesh config. The default mesh config is used if it is nil.
+	MeshConfig *meshconfig.MeshConfig
+}
+
+// NewSimulationFromIstioConfig builds a simulation from the xDS resources Istiod would generate for the proxy from
+// the configuration. The configs are held in an in-memory config store and the Services in an in-memory registry,
+// and the xDS resources are generated from their push context, as Istiod does.
+func NewSimulationFromIstioConfig(cfg IstioConfig, proxy *model.Proxy) (*Simulation, error) {
+	store := memory.Make(collections.Pilot)
+	for _, c := range cfg.Configs {
+		if _, err := store.Create(c); err != nil {
+			return nil, fmt.Errorf("invalid %s %s/%s: %v", c.GroupVersionKind.Kind, c.Namespace, c.Name, err)
+		}
+	}
+	configStore := model.MakeIstioStore(store)
+
+	m := cfg.MeshConfig
+	if m == nil {
+		def := mesh.DefaultMeshConfig()
+		m = &def
+	}
+
+	setProxyDefaults(proxy)
+	services := make([]*model.Service, 0, len(cfg.Services))
+	for _, svc := range cfg.Services {
+		services = append(services, kube.ConvertService(*svc, constants.DefaultKubernetesDomain, string(serviceregistry.Kubernetes)))
+	}
+	kubeRegistry := memregistry.NewServiceDiscovery(services)
+	kubeRegistry.ClusterID = string(serviceregistry.Kubernetes)
+	if cfg.Pod != nil {
+		for _, instance := range podServiceInstances(cfg.Pod, cfg.Services, proxy) {
+			kubeRegistry.AddInstance(instance.Service.Hostname, instance)
+		}
+	}
+
+	serviceDiscovery := aggregate.NewController(aggregate.Options{})
+	// The ServiceEntries are read from the store, no event handler is needed as the configs do not change.
+	serviceDiscovery.AddRegistry(serviceentry.NewServiceDiscovery(nil, configStore, nil))
+	serviceDiscovery.AddRegistry(serviceregistry.Simple{
+		ClusterID:        string(serviceregistry.Kubernetes),
+		ProviderID:       serviceregistry.Kubernetes,
+		ServiceDiscovery: kubeRegistry,
+		Controller:       kubeRegistry.Controller,
+	})
+
+	env := &model.Environment{
+		ServiceDiscovery: serviceDiscovery,
+		IstioConfigStore: configStore,
+		Watcher:          mesh.NewFixedWatcher(m),
+		NetworksWatcher:  mesh.NewFixedNetworksWatcher(nil),
+		PushContext:      model.NewPushContext(),
+		DomainSuffix:     constants.DefaultKubernetesDomain,
+	}
+	push := env.PushContext
+	if err := push.InitContext(env, nil, nil); err != nil {
+		return nil, fmt.Errorf("failed to initialize the push context: %v", err)
+	}
+
+	proxy.SetSidecarScope(push)
+	proxy.SetGatewaysForProxy(push)
+	proxy.SetServiceInstances(env.ServiceDiscovery)
+	proxy.DiscoverIPVersions()
+
+	gen := v1alpha3.NewConfigGenerator(registry.NewPlugins([]string{plugin.AuthzCustom, plugin.Authn, plugin.Authz}),
+		&model.DisabledCache{})
+	listeners := gen.BuildListeners(proxy, push)
+	clusters := gen.BuildClusters(proxy, push)
+	routes := gen.BuildHTTPRoutes(proxy, push, util.ExtractRoutesFromListeners(listeners))
+	return NewSimulationFromConfig(listeners, clusters, routes), nil
+}
+
+// setProxyDefaults sets the fields of the proxy Istiod gets from its bootstrap, when they are not set.
+func setProxyDefaults(p *model.Proxy) {
+	if p.Metadata == nil {
+		p.Metadata = &model.NodeMetadata{}
+	}
+	if p.Metadata.IstioVersion == "" {
+		p.Metadata.IstioVersion = "1.9.0"
+	}
+	if p.IstioVersion == nil {
+		p.IstioVersion = model.ParseIstioVersion(p.Metadata.IstioVersion)
+	}
+	if p.Type == "" {
+		p.Type = model.SidecarProxy
+	}
+	if p.ConfigNamespace == "" {
+		p.ConfigNamespace = "default"
+	}
+	if p.Metadata.Namespace == "" {
+		p.Metadata.Namespace = p.ConfigNamespace
+	}
+	if p.ID == "" {
+		p.ID = "simulation." + p.ConfigNamespace
+	}
+	if p.DNSDomain == "" {
+		p.DNSDomain = p.ConfigNamespace + ".svc." + constants.DefaultKubernetesDomain
+	}
+	if len(p.IPAddresses) == 0 {
+		p.IPAddresses = []string{"1.1.1.1"}
+	}
+}
+
+// podServiceInstances returns the service instances of the pod, for the ports of the Services selecting it.
+func podServiceInstances(pod *corev1.Pod, services []*corev1.Service, proxy *model.Proxy) []*model.ServiceInstance {
+	var out []*model.ServiceInstance
+	for _, svc := range services {
+		if svc.Namespace != pod.Namespace || len(svc.Spec.Selector) == 0 ||
+			!klabels.SelectorFromSet(svc.Spec.Selector).Matches(klabels.Set(pod.Labels)) {
+			continue
+		}
+		service := kube.ConvertService(*svc, constants.DefaultKubernetesDomain, string(serviceregistry.Kubernetes))
+		for _, port := range svc.Spec.Ports {
+			servicePort, ok := service.Ports.GetByPort(int(port.Port))
+			if !ok {
+				continue
+			}
+			out = append(out, &model.ServiceInstance{
+				Service:     service,
+				ServicePort: servicePort,
+				Endpoint: &model.IstioEndpoint{
+					Address:         proxy.IPAddresses[0],
+					EndpointPort:    uint32(targetPort(pod, port)),
+					ServicePortName: servicePort.Name,
+					Labels:          pod.Labels,
+					ServiceAccount:  kube.SecureNamingSAN(pod),
+					Namespace:       pod.Namespace,
+					WorkloadName:    pod.Name,
+					TLSMode:         model.GetTLSModeFromEndpointLabels(pod.Labels),
+				},
+			})
+		}
+	}
+	return out
+}
+
+// targetPort returns the port of the pod the Service port targets.
+func targetPort(pod *corev1.Pod, port corev1.ServicePort) int {
+	switch port.TargetPort.Type {
+	case intstr.String:
+		for _, c := range pod.Spec.Containers {
+			for _, p := range c.Ports {
+				if p.Name == port.TargetPort.StrVal {
+					return int(p.ContainerPort)
+				}
+			}
+		}
+	case intstr.Int:
+		if port.TargetPort.IntVal != 0 {
+			return int(port.TargetPort.IntVal)
+		}
+	}
+	return int(port.Port)
+}
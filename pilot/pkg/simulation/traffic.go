@@ -21,24 +21,25 @@ import (
 	"net/http"
 	"regexp"
 	"strings"
-	"testing"
 
 	cluster "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
 	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
 	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	hcm "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	tcpproxy "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/tcp_proxy/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
+	"github.com/golang/protobuf/ptypes"
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/yl2chen/cidranger"
 
 	"istio.io/istio/pilot/pkg/model"
 	"istio.io/istio/pilot/pkg/networking/core/v1alpha3"
+	"istio.io/istio/pilot/pkg/networking/util"
 	"istio.io/istio/pilot/pkg/util/sets"
-	"istio.io/istio/pilot/pkg/xds"
 	xdsfilters "istio.io/istio/pilot/pkg/xds/filters"
-	"istio.io/istio/pilot/test/xdstest"
 	"istio.io/istio/pkg/config/host"
-	"istio.io/istio/pkg/test"
 )
 
 type Protocol string
@@ -74,6 +75,10 @@ var (
 	// ErrProtocolError happens when sending TLS/TCP request to HCM, for example
 	ErrProtocolError = errors.New("protocol error")
 	ErrTLSError      = errors.New("invalid TLS")
+	// ErrNoRouteConfig happens when the route configuration referenced by RDS is not present
+	ErrNoRouteConfig = errors.New("no route configuration found")
+	// ErrInvalidConfig happens when the configuration cannot be interpreted, such as an invalid regex
+	ErrInvalidConfig = errors.New("invalid configuration")
 )
 
 type Expect struct {
@@ -97,6 +102,7 @@ type Call struct {
 	Address string
 	Port    int
 	Path    string
+	Method  string
 
 	// Protocol describes the protocol type. TLS encapsulation is separate
 	Protocol Protocol
@@ -129,6 +135,9 @@ func (c Call) FillDefaults() Call {
 	if c.Path == "" {
 		c.Path = "/"
 	}
+	if c.Method == "" {
+		c.Method = http.MethodGet
+	}
 	if c.TLS == "" {
 		c.TLS = Plaintext
 	}
@@ -152,10 +161,16 @@ type Result struct {
 	// allows asserting that the result is *exactly* equal, allowing asserting a
 	// field is empty
 	StrictMatch bool
-	t           test.Failer
 }
 
-func (r Result) Matches(t *testing.T, want Result) {
+// Failer reports the mismatches of a Result, as testing.T does.
+type Failer interface {
+	Errorf(format string, args ...interface{})
+	Logf(format string, args ...interface{})
+	Failed() bool
+}
+
+func (r Result) Matches(t Failer, want Result) {
 	r.StrictMatch = want.StrictMatch // to make diff pass
 	diff := cmp.Diff(want, r, cmpopts.IgnoreUnexported(Result{}), cmpopts.EquateErrors())
 	if want.StrictMatch && diff != "" {
@@ -189,43 +204,28 @@ func (r Result) Matches(t *testing.T, want Result) {
 }
 
 type Simulation struct {
-	t         *testing.T
 	Listeners []*listener.Listener
 	Clusters  []*cluster.Cluster
 	Routes    []*route.RouteConfiguration
 }
 
-func NewSimulationFromConfigGen(t *testing.T, s *v1alpha3.ConfigGenTest, proxy *model.Proxy) *Simulation {
-	sim := &Simulation{
-		t:         t,
-		Listeners: s.Listeners(proxy),
-		Clusters:  s.Clusters(proxy),
-		Routes:    s.Routes(proxy),
+// NewSimulationFromConfig builds a simulation directly from xDS resources, such as those read from an
+// Envoy config dump.
+func NewSimulationFromConfig(listeners []*listener.Listener, clusters []*cluster.Cluster,
+	routes []*route.RouteConfiguration) *Simulation {
+	return &Simulation{
+		Listeners: listeners,
+		Clusters:  clusters,
+		Routes:    routes,
 	}
-	return sim
 }
 
-func NewSimulation(t *testing.T, s *xds.FakeDiscoveryServer, proxy *model.Proxy) *Simulation {
-	return NewSimulationFromConfigGen(t, s.ConfigGenTest, proxy)
-}
-
-// withT swaps out the testing struct. This allows executing sub tests.
-func (sim *Simulation) withT(t *testing.T) *Simulation {
-	cpy := *sim
-	cpy.t = t
-	return &cpy
-}
-
-func (sim *Simulation) RunExpectations(es []Expect) {
-	for _, e := range es {
-		sim.t.Run(e.Name, func(t *testing.T) {
-			sim.withT(t).Run(e.Call).Matches(t, e.Result)
-		})
-	}
+func NewSimulationFromConfigGen(s *v1alpha3.ConfigGenTest, proxy *model.Proxy) *Simulation {
+	return NewSimulationFromConfig(s.Listeners(proxy), s.Clusters(proxy), s.Routes(proxy))
 }
 
 func (sim *Simulation) Run(input Call) (result Result) {
-	result = Result{t: sim.t}
+	result = Result{}
 	input = input.FillDefaults()
 
 	// First we will match a listener
@@ -237,12 +237,12 @@ func (sim *Simulation) Run(input Call) (result Result) {
 	result.ListenerMatched = l.Name
 
 	// Apply listener filters. This will likely need the TLS inspector in the future as well
-	if _, f := xdstest.ExtractListenerFilters(l)[xdsfilters.HTTPInspector.Name]; f {
+	if _, f := util.ExtractListenerFilters(l)[xdsfilters.HTTPInspector.Name]; f {
 		if alpn := protocolToAlpn(input.Protocol); alpn != "" && input.TLS == Plaintext {
 			input.Alpn = alpn
 		}
 	}
-	_, hasTLSInspector := xdstest.ExtractListenerFilters(l)[xdsfilters.TLSInspector.Name]
+	_, hasTLSInspector := util.ExtractListenerFilters(l)[xdsfilters.TLSInspector.Name]
 	fc, err := sim.matchFilterChain(l.FilterChains, l.DefaultFilterChain, input, hasTLSInspector)
 	if err != nil {
 		result.Error = err
@@ -254,7 +254,17 @@ func (sim *Simulation) Run(input Call) (result Result) {
 		return
 	}
 
-	if hcm := xdstest.ExtractHTTPConnectionManager(sim.t, fc); hcm != nil {
+	hcm, err := extractHTTPConnectionManager(fc)
+	if err != nil {
+		result.Error = err
+		return
+	}
+	tcp, err := extractTCPProxy(fc)
+	if err != nil {
+		result.Error = err
+		return
+	}
+	if hcm != nil {
 		if input.TLS != Plaintext && fc.TransportSocket == nil {
 			result.Error = ErrProtocolError
 			return
@@ -266,7 +276,11 @@ func (sim *Simulation) Run(input Call) (result Result) {
 			// If not set, fallback to RDS
 			routeName := hcm.GetRds().RouteConfigName
 			result.RouteConfigMatched = routeName
-			rc = xdstest.ExtractRouteConfigurations(sim.Routes)[routeName]
+			rc = util.ExtractRouteConfigurations(sim.Routes)[routeName]
+		}
+		if rc == nil {
+			result.Error = ErrNoRouteConfig
+			return
 		}
 		hostHeader := ""
 		if len(input.Headers["Host"]) > 0 {
@@ -278,8 +292,11 @@ func (sim *Simulation) Run(input Call) (result Result) {
 			return
 		}
 		result.VirtualHostMatched = vh.Name
-		r := sim.matchRoute(vh, input)
-
+		r, err := sim.matchRoute(vh, input)
+		if err != nil {
+			result.Error = err
+			return
+		}
 		if r == nil {
 			result.Error = ErrNoRoute
 			return
@@ -289,13 +306,43 @@ func (sim *Simulation) Run(input Call) (result Result) {
 		case *route.Route_Route:
 			result.ClusterMatched = t.Route.GetCluster()
 		}
-	} else if tcp := xdstest.ExtractTCPProxy(sim.t, fc); tcp != nil {
+	} else if tcp != nil {
 		result.ClusterMatched = tcp.GetCluster()
 	}
 	return
 }
 
-func (sim *Simulation) matchRoute(vh *route.VirtualHost, input Call) *route.Route {
+func extractHTTPConnectionManager(fcs *listener.FilterChain) (*hcm.HttpConnectionManager, error) {
+	for _, fc := range fcs.Filters {
+		if fc.Name == wellknown.HTTPConnectionManager {
+			h := &hcm.HttpConnectionManager{}
+			if fc.GetTypedConfig() != nil {
+				if err := ptypes.UnmarshalAny(fc.GetTypedConfig(), h); err != nil {
+					return nil, fmt.Errorf("%w: failed to unmarshal hcm: %v", ErrInvalidConfig, err)
+				}
+			}
+			return h, nil
+		}
+	}
+	return nil, nil
+}
+
+func extractTCPProxy(fcs *listener.FilterChain) (*tcpproxy.TcpProxy, error) {
+	for _, fc := range fcs.Filters {
+		if fc.Name == wellknown.TCPProxy {
+			tcpProxy := &tcpproxy.TcpProxy{}
+			if fc.GetTypedConfig() != nil {
+				if err := ptypes.UnmarshalAny(fc.GetTypedConfig(), tcpProxy); err != nil {
+					return nil, fmt.Errorf("%w: failed to unmarshal tcp proxy: %v", ErrInvalidConfig, err)
+				}
+			}
+			return tcpProxy, nil
+		}
+	}
+	return nil, nil
+}
+
+func (sim *Simulation) matchRoute(vh *route.VirtualHost, input Call) (*route.Route, error) {
 	for _, r := range vh.Routes {
 		// check path
 		switch pt := r.Match.GetPathSpecifier().(type) {
@@ -310,20 +357,87 @@ func (sim *Simulation) matchRoute(vh *route.VirtualHost, input Call) *route.Rout
 		case *route.RouteMatch_SafeRegex:
 			r, err := regexp.Compile(pt.SafeRegex.GetRegex())
 			if err != nil {
-				sim.t.Fatalf("invalid regex %v: %v", r, err)
+				return nil, fmt.Errorf("%w: invalid regex %v: %v", ErrInvalidConfig, pt.SafeRegex.GetRegex(), err)
 			}
-			if !r.MatchString(input.Path) {
+			if !fullMatch(r, input.Path) {
 				continue
 			}
 		default:
-			sim.t.Fatalf("unknown route path type")
+			return nil, fmt.Errorf("%w: unknown route path type %T", ErrInvalidConfig, pt)
 		}
 
-		// TODO this only handles path - we need to add headers, query params, etc to be complete.
+		matched, err := matchHeaders(r.Match.GetHeaders(), input)
+		if err != nil {
+			return nil, err
+		}
+		if !matched {
+			continue
+		}
 
-		return r
+		// TODO this only handles path and headers - we need to add query params, etc to be complete.
+
+		return r, nil
 	}
-	return nil
+	return nil, nil
+}
+
+// matchHeaders returns true if all of the header matchers match the input.
+func matchHeaders(matchers []*route.HeaderMatcher, input Call) (bool, error) {
+	for _, h := range matchers {
+		value, present := headerValue(h.GetName(), input)
+		var matched bool
+		switch hm := h.GetHeaderMatchSpecifier().(type) {
+		case *route.HeaderMatcher_PresentMatch:
+			matched = present == hm.PresentMatch
+		case *route.HeaderMatcher_ExactMatch:
+			matched = present && value == hm.ExactMatch
+		case *route.HeaderMatcher_PrefixMatch:
+			matched = present && strings.HasPrefix(value, hm.PrefixMatch)
+		case *route.HeaderMatcher_SuffixMatch:
+			matched = present && strings.HasSuffix(value, hm.SuffixMatch)
+		case *route.HeaderMatcher_SafeRegexMatch:
+			r, err := regexp.Compile(hm.SafeRegexMatch.GetRegex())
+			if err != nil {
+				return false, fmt.Errorf("%w: invalid regex %v: %v", ErrInvalidConfig, hm.SafeRegexMatch.GetRegex(), err)
+			}
+			matched = present && fullMatch(r, value)
+		case nil:
+			// No specifier means the header only needs to be present
+			matched = present
+		default:
+			return false, fmt.Errorf("%w: unknown header match type %T", ErrInvalidConfig, hm)
+		}
+		if h.GetInvertMatch() {
+			matched = !matched
+		}
+		if !matched {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// fullMatch mirrors Envoy's RE2 semantics, where the regex must match the entire value.
+func fullMatch(r *regexp.Regexp, value string) bool {
+	loc := r.FindStringIndex(value)
+	return loc != nil && loc[0] == 0 && loc[1] == len(value)
+}
+
+// headerValue looks up a header as Envoy would see it, including the HTTP/2 style pseudo headers.
+func headerValue(name string, input Call) (string, bool) {
+	switch name {
+	case ":authority":
+		name = "Host"
+	case ":path":
+		return input.Path, true
+	case ":method":
+		return input.Method, true
+	}
+	v, f := input.Headers[http.CanonicalHeaderKey(name)]
+	if !f || len(v) == 0 {
+		return "", false
+	}
+	return strings.Join(v, ","), true
 }
 
 func (sim *Simulation) matchVirtualHost(rc *route.RouteConfiguration, host string) *route.VirtualHost {
@@ -387,6 +501,7 @@ func (sim *Simulation) matchVirtualHost(rc *route.RouteConfiguration, host strin
 // matches one criteria but not another.
 func (sim *Simulation) matchFilterChain(chains []*listener.FilterChain, defaultChain *listener.FilterChain,
 	input Call, hasTLSInspector bool) (*listener.FilterChain, error) {
+	var configErr error
 	chains = filter(chains, func(fc *listener.FilterChainMatch) bool {
 		return fc.GetDestinationPort() == nil
 	}, func(fc *listener.FilterChainMatch) bool {
@@ -400,18 +515,24 @@ func (sim *Simulation) matchFilterChain(chains []*listener.FilterChain, defaultC
 			s := fmt.Sprintf("%s/%d", a.AddressPrefix, a.GetPrefixLen().GetValue())
 			_, cidr, err := net.ParseCIDR(s)
 			if err != nil {
-				sim.t.Fatalf("failed to parse cidr %v: %v", s, err)
+				configErr = fmt.Errorf("%w: failed to parse cidr %v: %v", ErrInvalidConfig, s, err)
+				return false
 			}
 			if err := ranger.Insert(cidranger.NewBasicRangerEntry(*cidr)); err != nil {
-				sim.t.Fatalf("failed to insert cidr %v: %v", cidr, err)
+				configErr = fmt.Errorf("%w: failed to insert cidr %v: %v", ErrInvalidConfig, cidr, err)
+				return false
 			}
 		}
 		f, err := ranger.Contains(net.ParseIP(input.Address))
 		if err != nil {
-			sim.t.Fatalf("cidr containers %v failed: %v", input.Address, err)
+			configErr = fmt.Errorf("cidr containers %v failed: %v", input.Address, err)
+			return false
 		}
 		return f
 	})
+	if configErr != nil {
+		return nil, configErr
+	}
 	chains = filter(chains, func(fc *listener.FilterChainMatch) bool {
 		return fc.GetServerNames() == nil
 	}, func(fc *listener.FilterChainMatch) bool {
@@ -501,7 +622,7 @@ func protocolToAlpn(s Protocol) string {
 
 func matchListener(listeners []*listener.Listener, input Call) *listener.Listener {
 	if input.CallMode == CallModeInbound {
-		return xdstest.ExtractListener(v1alpha3.VirtualInboundListenerName, listeners)
+		return util.ExtractListener(v1alpha3.VirtualInboundListenerName, listeners)
 	}
 	// First find exact match for the IP/Port, then fallback to wildcard IP/Port
 	// There is no wildcard port
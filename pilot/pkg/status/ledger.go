@@ -19,16 +19,21 @@ import (
 	"istio.io/pkg/ledger"
 )
 
-func tryLedgerPut(configLedger ledger.Ledger, obj config.Config) {
+// tryLedgerPut records the resource in the ledger, and returns the resulting ledger version.
+func tryLedgerPut(configLedger ledger.Ledger, obj config.Config) string {
 	key := config.Key(obj.GroupVersionKind.Kind, obj.Name, obj.Namespace)
-	if _, err := configLedger.Put(key, obj.ResourceVersion); err != nil {
+	version, err := configLedger.Put(key, obj.ResourceVersion)
+	if err != nil {
 		scope.Errorf("Failed to update %s in ledger, status will be out of date.", key)
 	}
+	return version
 }
 
-func tryLedgerDelete(configLedger ledger.Ledger, obj config.Config) {
+// tryLedgerDelete removes the resource from the ledger, and returns the resulting ledger version.
+func tryLedgerDelete(configLedger ledger.Ledger, obj config.Config) string {
 	key := config.Key(obj.GroupVersionKind.Kind, obj.Name, obj.Namespace)
 	if err := configLedger.Delete(key); err != nil {
 		scope.Errorf("Failed to delete %s in ledger, status will be out of date.", key)
 	}
+	return configLedger.RootHash()
 }
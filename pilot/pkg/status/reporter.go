@@ -29,6 +29,7 @@ import (
 	v1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/utils/clock"
 
+	"istio.io/istio/pilot/pkg/model"
 	"istio.io/istio/pilot/pkg/xds"
 	"istio.io/istio/pkg/config"
 	"istio.io/pkg/ledger"
@@ -66,6 +67,8 @@ type Reporter struct {
 	clock                  clock.Clock
 	ledger                 ledger.Ledger
 	distributionEventQueue chan distributionEvent
+	// History, if set, records the content of each version of the resources written to the ledger.
+	History *model.ConfigHistory
 }
 
 var _ xds.DistributionStatusCache = &Reporter{}
@@ -204,7 +207,10 @@ func (r *Reporter) removeCompletedResource(completedResources []Resource) {
 // This function must be called every time a resource change is detected by pilot.  This allows us to lookup
 // only the resources we expect to be in flight, not the ones that have already distributed
 func (r *Reporter) AddInProgressResource(res config.Config) {
-	tryLedgerPut(r.ledger, res)
+	version := tryLedgerPut(r.ledger, res)
+	if r.History != nil {
+		r.History.Record(res, version, false)
+	}
 	myRes := ResourceFromModelConfig(res)
 	if myRes == nil {
 		scope.Errorf("Unable to locate schema for %v, will not update status.", res)
@@ -219,7 +225,10 @@ func (r *Reporter) AddInProgressResource(res config.Config) {
 }
 
 func (r *Reporter) DeleteInProgressResource(res config.Config) {
-	tryLedgerDelete(r.ledger, res)
+	version := tryLedgerDelete(r.ledger, res)
+	if r.History != nil {
+		r.History.Record(res, version, true)
+	}
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	delete(r.inProgressResources, res.Key())
@@ -15,6 +15,7 @@
 package xds
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -77,6 +78,9 @@ type Connection struct {
 	// Both ADS and SDS streams implement this interface
 	stream DiscoveryStream
 
+	// deltaStream is set instead of stream for incremental (Delta) ADS connections.
+	deltaStream DeltaDiscoveryStream
+
 	// Original node metadata, to avoid unmarshal/marshal.
 	// This is included in internal events.
 	node *core.Node
@@ -185,11 +189,17 @@ func (s *DiscoveryServer) processRequest(req *discovery.DiscoveryRequest, con *C
 	}
 	shouldRespond := s.shouldRespond(con, req)
 
+	return s.pushRequested(con, req.TypeUrl, shouldRespond)
+}
+
+// pushRequested sends a response for typeURL following a request from the client. This is a full push if
+// the request requires a response, or the push that was blocked waiting for an ACK, if there is one.
+func (s *DiscoveryServer) pushRequested(con *Connection, typeURL string, shouldRespond bool) error {
 	// Check if we have a blocked push. If this was an ACK, we will send it. Either way we remove the blocked push
 	// as we will send a push.
 	con.proxy.Lock()
-	request, haveBlockedPush := con.blockedPushes[req.TypeUrl]
-	delete(con.blockedPushes, req.TypeUrl)
+	request, haveBlockedPush := con.blockedPushes[typeURL]
+	delete(con.blockedPushes, typeURL)
 	con.proxy.Unlock()
 
 	if shouldRespond {
@@ -203,51 +213,20 @@ func (s *DiscoveryServer) processRequest(req *discovery.DiscoveryRequest, con *C
 		return nil
 	} else {
 		// we have a blocked push which we will use
-		adsLog.Debugf("%s: DEQUEUE for node:%s", v3.GetShortType(req.TypeUrl), con.proxy.ID)
+		adsLog.Debugf("%s: DEQUEUE for node:%s", v3.GetShortType(typeURL), con.proxy.ID)
 	}
 
 	push := s.globalPushContext()
 
-	return s.pushXds(con, push, versionInfo(), con.Watched(req.TypeUrl), request)
+	return s.pushXds(con, push, versionInfo(), con.Watched(typeURL), request)
 }
 
 // StreamAggregatedResources implements the ADS interface.
 func (s *DiscoveryServer) StreamAggregatedResources(stream discovery.AggregatedDiscoveryService_StreamAggregatedResourcesServer) error {
-	// Check if server is ready to accept clients and process new requests.
-	// Currently ready means caches have been synced and hence can build
-	// clusters correctly. Without this check, InitContext() call below would
-	// initialize with empty config, leading to reconnected Envoys loosing
-	// configuration. This is an additional safety check inaddition to adding
-	// cachesSynced logic to readiness probe to handle cases where kube-proxy
-	// ip tables update latencies.
-	// See https://github.com/istio/istio/issues/25495.
-	if !s.IsServerReady() {
-		return errors.New("server is not ready to serve discovery information")
-	}
-
-	ctx := stream.Context()
-	peerAddr := "0.0.0.0"
-	if peerInfo, ok := peer.FromContext(ctx); ok {
-		peerAddr = peerInfo.Addr.String()
-	}
-
-	ids, err := s.authenticate(ctx)
+	peerAddr, ids, err := s.initStream(stream.Context())
 	if err != nil {
 		return err
 	}
-	if ids != nil {
-		adsLog.Debugf("Authenticated XDS: %v with identity %v", peerAddr, ids)
-	} else {
-		adsLog.Debug("Unauthenticated XDS: ", peerAddr)
-	}
-
-	// InitContext returns immediately if the context was already initialized.
-	if err = s.globalPushContext().InitContext(s.Env, nil, nil); err != nil {
-		// Error accessing the data - log and close, maybe a different pilot replica
-		// has more luck
-		adsLog.Warnf("Error reading config %v", err)
-		return err
-	}
 	con := newConnection(peerAddr, stream)
 	con.Identities = ids
 
@@ -301,6 +280,46 @@ func (s *DiscoveryServer) StreamAggregatedResources(stream discovery.AggregatedD
 	}
 }
 
+// initStream runs the checks shared by state of the world and delta ADS streams before any request is
+// read, returning the peer address and the authenticated identities of the client.
+func (s *DiscoveryServer) initStream(ctx context.Context) (string, []string, error) {
+	// Check if server is ready to accept clients and process new requests.
+	// Currently ready means caches have been synced and hence can build
+	// clusters correctly. Without this check, InitContext() call below would
+	// initialize with empty config, leading to reconnected Envoys loosing
+	// configuration. This is an additional safety check inaddition to adding
+	// cachesSynced logic to readiness probe to handle cases where kube-proxy
+	// ip tables update latencies.
+	// See https://github.com/istio/istio/issues/25495.
+	if !s.IsServerReady() {
+		return "", nil, errors.New("server is not ready to serve discovery information")
+	}
+
+	peerAddr := "0.0.0.0"
+	if peerInfo, ok := peer.FromContext(ctx); ok {
+		peerAddr = peerInfo.Addr.String()
+	}
+
+	ids, err := s.authenticate(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+	if ids != nil {
+		adsLog.Debugf("Authenticated XDS: %v with identity %v", peerAddr, ids)
+	} else {
+		adsLog.Debug("Unauthenticated XDS: ", peerAddr)
+	}
+
+	// InitContext returns immediately if the context was already initialized.
+	if err = s.globalPushContext().InitContext(s.Env, nil, nil); err != nil {
+		// Error accessing the data - log and close, maybe a different pilot replica
+		// has more luck
+		adsLog.Warnf("Error reading config %v", err)
+		return "", nil, err
+	}
+	return peerAddr, ids, nil
+}
+
 // shouldRespond determines whether this request needs to be responded back. It applies the ack/nack rules as per xds protocol
 // using WatchedResource for previous state and discovery request for the current state.
 func (s *DiscoveryServer) shouldRespond(con *Connection, request *discovery.DiscoveryRequest) bool {
@@ -318,6 +337,7 @@ func (s *DiscoveryServer) shouldRespond(con *Connection, request *discovery.Disc
 		}
 		con.proxy.Lock()
 		con.proxy.WatchedResources[request.TypeUrl].NonceNacked = request.ResponseNonce
+		con.proxy.WatchedResources[request.TypeUrl].NackError = request.ErrorDetail.GetMessage()
 		con.proxy.Unlock()
 		return false
 	}
@@ -363,6 +383,7 @@ func (s *DiscoveryServer) shouldRespond(con *Connection, request *discovery.Disc
 		xdsExpiredNonce.With(typeTag.Value(v3.GetMetricType(request.TypeUrl))).Increment()
 		con.proxy.Lock()
 		con.proxy.WatchedResources[request.TypeUrl].NonceNacked = ""
+		con.proxy.WatchedResources[request.TypeUrl].NackError = ""
 		con.proxy.WatchedResources[request.TypeUrl].LastRequest = request
 		con.proxy.Unlock()
 		return false
@@ -374,7 +395,9 @@ func (s *DiscoveryServer) shouldRespond(con *Connection, request *discovery.Disc
 	previousResources := con.proxy.WatchedResources[request.TypeUrl].ResourceNames
 	con.proxy.WatchedResources[request.TypeUrl].VersionAcked = request.VersionInfo
 	con.proxy.WatchedResources[request.TypeUrl].NonceAcked = request.ResponseNonce
+	con.proxy.WatchedResources[request.TypeUrl].LastAcked = time.Now()
 	con.proxy.WatchedResources[request.TypeUrl].NonceNacked = ""
+	con.proxy.WatchedResources[request.TypeUrl].NackError = ""
 	con.proxy.WatchedResources[request.TypeUrl].ResourceNames = request.ResourceNames
 	con.proxy.WatchedResources[request.TypeUrl].LastRequest = request
 	con.proxy.Unlock()
@@ -584,18 +607,6 @@ func (s *DiscoveryServer) preProcessRequest(proxy *model.Proxy, req *discovery.D
 	return true
 }
 
-// DeltaAggregatedResources is not implemented.
-// Instead, Generators may send only updates/add, with Delete indicated by an empty spec.
-// This works if both ends follow this model. For example EDS and the API generator follow this
-// pattern.
-//
-// The delta protocol changes the request, adding unsubscribe/subscribe instead of sending full
-// list of resources. On the response it adds 'removed resources' and sends changes for everything.
-// TODO: we could implement this method if needed, the change is not very big.
-func (s *DiscoveryServer) DeltaAggregatedResources(stream discovery.AggregatedDiscoveryService_DeltaAggregatedResourcesServer) error {
-	return status.Errorf(codes.Unimplemented, "not implemented")
-}
-
 // Compute and send the new configuration for a connection. This is blocking and may be slow
 // for large configs. The method will hold a lock on con.pushMutex.
 func (s *DiscoveryServer) pushConnection(con *Connection, pushEv *Event) error {
@@ -821,6 +832,31 @@ func (s *DiscoveryServer) removeCon(conID string) {
 
 // Send with timeout
 func (conn *Connection) send(res *discovery.DiscoveryResponse) error {
+	err := conn.sendWithTimeout(func() error {
+		return conn.stream.Send(res)
+	})
+	if err == nil {
+		sz := 0
+		for _, rc := range res.Resources {
+			sz += len(rc.Value)
+		}
+		conn.proxy.Lock()
+		if res.Nonce != "" {
+			if conn.proxy.WatchedResources[res.TypeUrl] == nil {
+				conn.proxy.WatchedResources[res.TypeUrl] = &model.WatchedResource{TypeUrl: res.TypeUrl}
+			}
+			conn.proxy.WatchedResources[res.TypeUrl].NonceSent = res.Nonce
+			conn.proxy.WatchedResources[res.TypeUrl].VersionSent = res.VersionInfo
+			conn.proxy.WatchedResources[res.TypeUrl].LastSent = time.Now()
+			conn.proxy.WatchedResources[res.TypeUrl].LastSize = sz
+		}
+		conn.proxy.Unlock()
+	}
+	return err
+}
+
+// sendWithTimeout runs sendFunc, giving up if it does not complete within sendTimeout.
+func (conn *Connection) sendWithTimeout(sendFunc func() error) error {
 	errChan := make(chan error, 1)
 
 	// sendTimeout may be modified via environment
@@ -828,7 +864,7 @@ func (conn *Connection) send(res *discovery.DiscoveryResponse) error {
 	go func() {
 		start := time.Now()
 		defer func() { recordSendTime(time.Since(start)) }()
-		errChan <- conn.stream.Send(res)
+		errChan <- sendFunc()
 		close(errChan)
 	}()
 
@@ -838,23 +874,6 @@ func (conn *Connection) send(res *discovery.DiscoveryResponse) error {
 		xdsResponseWriteTimeouts.Increment()
 		return status.Errorf(codes.DeadlineExceeded, "timeout sending")
 	case err := <-errChan:
-		if err == nil {
-			sz := 0
-			for _, rc := range res.Resources {
-				sz += len(rc.Value)
-			}
-			conn.proxy.Lock()
-			if res.Nonce != "" {
-				if conn.proxy.WatchedResources[res.TypeUrl] == nil {
-					conn.proxy.WatchedResources[res.TypeUrl] = &model.WatchedResource{TypeUrl: res.TypeUrl}
-				}
-				conn.proxy.WatchedResources[res.TypeUrl].NonceSent = res.Nonce
-				conn.proxy.WatchedResources[res.TypeUrl].VersionSent = res.VersionInfo
-				conn.proxy.WatchedResources[res.TypeUrl].LastSent = time.Now()
-				conn.proxy.WatchedResources[res.TypeUrl].LastSize = sz
-			}
-			conn.proxy.Unlock()
-		}
 		// To ensure the channel is empty after a call to Stop, check the
 		// return value and drain the channel (from Stop docs).
 		if !t.Stop() {
@@ -864,6 +883,14 @@ func (conn *Connection) send(res *discovery.DiscoveryResponse) error {
 	}
 }
 
+// streamContext returns the context of the underlying gRPC stream, for either state of the world or delta ADS.
+func (conn *Connection) streamContext() context.Context {
+	if conn.deltaStream != nil {
+		return conn.deltaStream.Context()
+	}
+	return conn.stream.Context()
+}
+
 // nolint
 // Synced checks if the type has been synced, meaning the most recent push was ACKed
 func (conn *Connection) Synced(typeUrl string) (bool, bool) {
@@ -0,0 +1,223 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"sigs.k8s.io/yaml"
+
+	"istio.io/istio/pilot/pkg/model"
+	v3 "istio.io/istio/pilot/pkg/xds/v3"
+)
+
+// ConfigHistoryStatus lists the recorded revisions of a config resource.
+type ConfigHistoryStatus struct {
+	Resource  string                 `json:"resource"`
+	Revisions []ConfigRevisionStatus `json:"revisions"`
+}
+
+// ConfigRevisionStatus is a revision of a config resource, with the proxies it is applied to.
+type ConfigRevisionStatus struct {
+	ResourceVersion string    `json:"resourceVersion,omitempty"`
+	LedgerVersion   string    `json:"ledgerVersion"`
+	Timestamp       time.Time `json:"timestamp"`
+	Deleted         bool      `json:"deleted,omitempty"`
+	// Proxies that acked a config containing this revision for all of their cluster, listener and route configs.
+	Proxies []string `json:"proxies,omitempty"`
+}
+
+func (s *DiscoveryServer) checkConfigHistory(w http.ResponseWriter, req *http.Request) (string, bool) {
+	if s.ConfigHistory == nil {
+		w.WriteHeader(http.StatusConflict)
+		_, _ = fmt.Fprint(w, "Pilot config history is disabled. Please set the "+
+			"PILOT_ENABLE_CONFIG_DISTRIBUTION_TRACKING environment variable to true and "+
+			"PILOT_CONFIG_HISTORY_REVISIONS to a positive value to enable.")
+		return "", false
+	}
+	resourceID := req.URL.Query().Get("resource")
+	if resourceID == "" {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = fmt.Fprintf(w, "querystring parameter 'resource' is required")
+		return "", false
+	}
+	return resourceID, true
+}
+
+// configHistory lists the revisions of a resource, or returns the content of one of them if a version is given.
+func (s *DiscoveryServer) configHistory(w http.ResponseWriter, req *http.Request) {
+	resourceID, ok := s.checkConfigHistory(w, req)
+	if !ok {
+		return
+	}
+
+	if version := req.URL.Query().Get("version"); version != "" {
+		rev, f := s.findConfigRevision(resourceID, version)
+		if !f {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = fmt.Fprintf(w, "version %s of %s not found", version, resourceID)
+			return
+		}
+		writeJSON(w, kubernetesConfig{*rev.Config})
+		return
+	}
+
+	acks := s.configRevisionAcks(resourceID)
+	out := ConfigHistoryStatus{Resource: resourceID, Revisions: []ConfigRevisionStatus{}}
+	for _, rev := range s.ConfigHistory.Revisions(resourceID) {
+		status := ConfigRevisionStatus{
+			ResourceVersion: rev.ResourceVersion,
+			LedgerVersion:   rev.LedgerVersion,
+			Timestamp:       rev.Timestamp,
+			Deleted:         rev.Deleted,
+		}
+		if !rev.Deleted {
+			status.Proxies = acks[rev.ResourceVersion]
+		}
+		out.Revisions = append(out.Revisions, status)
+	}
+	writeJSON(w, out)
+}
+
+// configDiff returns the unified diff between two versions of a resource. The versions are either
+// ledger versions, including the ones of config nonces, or resource versions. The to version defaults
+// to the latest revision.
+func (s *DiscoveryServer) configDiff(w http.ResponseWriter, req *http.Request) {
+	resourceID, ok := s.checkConfigHistory(w, req)
+	if !ok {
+		return
+	}
+	from, to := req.URL.Query().Get("from"), req.URL.Query().Get("to")
+	if from == "" {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = fmt.Fprintf(w, "querystring parameter 'from' is required")
+		return
+	}
+	var texts [2]string
+	for i, version := range []string{from, to} {
+		rev, f := s.findConfigRevision(resourceID, version)
+		if !f {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = fmt.Fprintf(w, "version %s of %s not found", version, resourceID)
+			return
+		}
+		if rev.Deleted {
+			continue
+		}
+		text, err := configRevisionYAML(rev)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = fmt.Fprintf(w, "unable to marshal %s: %v", resourceID, err)
+			return
+		}
+		texts[i] = text
+	}
+	if to == "" {
+		to = "latest"
+	}
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(texts[0]),
+		B:        difflib.SplitLines(texts[1]),
+		FromFile: resourceID + "@" + from,
+		ToFile:   resourceID + "@" + to,
+		Context:  3,
+	})
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = fmt.Fprintf(w, "unable to diff %s: %v", resourceID, err)
+		return
+	}
+	w.Header().Add("Content-Type", "text/plain")
+	_, _ = fmt.Fprint(w, diff)
+}
+
+// findConfigRevision returns the revision of the resource at the given version. If the version is a
+// ledger version at which the resource did not change, the ledger is used to find the resource version.
+func (s *DiscoveryServer) findConfigRevision(resourceID, version string) (model.ConfigRevision, bool) {
+	if rev, f := s.ConfigHistory.Revision(resourceID, version); f {
+		return rev, true
+	}
+	l := s.Env.GetLedger()
+	if l == nil || version == "" {
+		return model.ConfigRevision{}, false
+	}
+	resourceVersion, err := l.GetPreviousValue(version, resourceID)
+	if err != nil || resourceVersion == "" {
+		return model.ConfigRevision{}, false
+	}
+	return s.ConfigHistory.Revision(resourceID, resourceVersion)
+}
+
+// configRevisionAcks returns the IDs of the proxies per resource version they acked.
+func (s *DiscoveryServer) configRevisionAcks(resourceID string) map[string][]string {
+	out := map[string][]string{}
+	if s.StatusReporter == nil {
+		return out
+	}
+	knownVersions := make(map[string]string)
+	for _, con := range s.Clients() {
+		if con.proxy == nil {
+			continue
+		}
+		acked := ""
+		for _, typeURL := range []string{v3.ClusterType, v3.ListenerType, v3.RouteType} {
+			nonce := s.StatusReporter.QueryLastNonce(con.ConID, typeURL)
+			if nonce == "" {
+				continue
+			}
+			version := s.getResourceVersion(nonce, resourceID, knownVersions)
+			if version == "" || (acked != "" && acked != version) {
+				acked = ""
+				break
+			}
+			acked = version
+		}
+		if acked != "" {
+			out[acked] = append(out[acked], con.proxy.ID)
+		}
+	}
+	for _, proxies := range out {
+		sort.Strings(proxies)
+	}
+	return out
+}
+
+func configRevisionYAML(rev model.ConfigRevision) (string, error) {
+	js, err := json.Marshal(kubernetesConfig{*rev.Config})
+	if err != nil {
+		return "", err
+	}
+	out, err := yaml.JSONToYAML(js)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+func writeJSON(w http.ResponseWriter, obj interface{}) {
+	out, err := json.MarshalIndent(obj, "", "    ")
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = fmt.Fprintf(w, "unable to marshal response: %v", err)
+		return
+	}
+	w.Header().Add("Content-Type", "application/json")
+	_, _ = w.Write(out)
+}
@@ -0,0 +1,114 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"istio.io/api/networking/v1alpha3"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/schema/gvk"
+	"istio.io/pkg/ledger"
+)
+
+func TestConfigHistory(t *testing.T) {
+	s := NewFakeDiscoveryServer(t, FakeOptions{})
+	l := ledger.Make(time.Minute)
+	s.Discovery.Env.SetLedger(l)
+	s.Discovery.ConfigHistory = model.NewConfigHistory(5, time.Hour)
+
+	key := config.Key(gvk.VirtualService.Kind, "reviews", "default")
+	var ledgerVersions []string
+	for _, rv := range []string{"1", "2"} {
+		cfg := config.Config{
+			Meta: config.Meta{
+				GroupVersionKind: gvk.VirtualService,
+				Name:             "reviews",
+				Namespace:        "default",
+				ResourceVersion:  rv,
+			},
+			Spec: &v1alpha3.VirtualService{Hosts: []string{"reviews-v" + rv}},
+		}
+		version, err := l.Put(key, rv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ledgerVersions = append(ledgerVersions, version)
+		s.Discovery.ConfigHistory.Record(cfg, version, false)
+	}
+
+	get := func(handler http.HandlerFunc, url string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest("GET", url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("list", func(t *testing.T) {
+		rr := get(s.Discovery.configHistory, "/debug/config_history?resource="+key)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("unexpected status %d: %s", rr.Code, rr.Body.String())
+		}
+		got := ConfigHistoryStatus{}
+		if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+			t.Fatal(err)
+		}
+		if len(got.Revisions) != 2 || got.Revisions[1].ResourceVersion != "2" || got.Revisions[1].LedgerVersion != ledgerVersions[1] {
+			t.Fatalf("unexpected revisions %+v", got.Revisions)
+		}
+	})
+
+	t.Run("get version", func(t *testing.T) {
+		rr := get(s.Discovery.configHistory, "/debug/config_history?resource="+key+"&version="+ledgerVersions[0])
+		if rr.Code != http.StatusOK {
+			t.Fatalf("unexpected status %d: %s", rr.Code, rr.Body.String())
+		}
+		if !strings.Contains(rr.Body.String(), "reviews-v1") {
+			t.Fatalf("expected first revision, got %s", rr.Body.String())
+		}
+		rr = get(s.Discovery.configHistory, "/debug/config_history?resource="+key+"&version=unknown")
+		if rr.Code != http.StatusNotFound {
+			t.Fatalf("expected not found, got %d", rr.Code)
+		}
+	})
+
+	t.Run("diff", func(t *testing.T) {
+		rr := get(s.Discovery.configDiff, "/debug/config_diff?resource="+key+"&from=1")
+		if rr.Code != http.StatusOK {
+			t.Fatalf("unexpected status %d: %s", rr.Code, rr.Body.String())
+		}
+		diff := rr.Body.String()
+		if !strings.Contains(diff, "-  - reviews-v1") || !strings.Contains(diff, "+  - reviews-v2") {
+			t.Fatalf("unexpected diff:\n%s", diff)
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		s.Discovery.ConfigHistory = nil
+		rr := get(s.Discovery.configHistory, "/debug/config_history?resource="+key)
+		if rr.Code != http.StatusConflict {
+			t.Fatalf("expected conflict, got %d", rr.Code)
+		}
+	})
+}
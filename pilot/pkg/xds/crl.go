@@ -0,0 +1,125 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+
+	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	tls "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
+
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/networking/util"
+	v3 "istio.io/istio/pilot/pkg/xds/v3"
+	"istio.io/istio/security/pkg/pki/ca"
+)
+
+// CRLResourceName is the name of the Secret holding the certificate revocation list of the CA.
+const CRLResourceName = "crl"
+
+// RevocationList is the source of the certificate revocation list of the CA.
+type RevocationList interface {
+	// GetCRL returns the PEM encoded CRL of the CA, or nil if no certificate is revoked.
+	GetCRL() ([]byte, error)
+	// RevokedCertificates returns the certificates revoked by the CA.
+	RevokedCertificates() []ca.RevokedCertificate
+}
+
+// CrlGenerator generates the certificate revocation list of the Istio CA. The agents add it to the
+// validation context of the root cert served to Envoy over SDS, so it applies to both the inbound and
+// outbound mTLS connections.
+type CrlGenerator struct {
+	CA RevocationList
+
+	// mutex protects lastError, the last error logged, so that a failure is logged once rather than for
+	// each proxy.
+	mutex     sync.Mutex
+	lastError string
+}
+
+var _ model.XdsResourceGenerator = &CrlGenerator{}
+
+// Generate returns a Secret with the CRL in its validation context, or an empty Secret if no
+// certificate is revoked.
+func (c *CrlGenerator) Generate(proxy *model.Proxy, push *model.PushContext, w *model.WatchedResource, req *model.PushRequest) model.Resources {
+	if req != nil && !req.Full {
+		// The CRL is only updated by full pushes
+		return nil
+	}
+	crl, err := c.CA.GetCRL()
+	c.recordError(err)
+	if err != nil {
+		return nil
+	}
+	secret := &tls.Secret{Name: CRLResourceName}
+	if crl != nil {
+		secret.Type = &tls.Secret_ValidationContext{
+			ValidationContext: &tls.CertificateValidationContext{
+				Crl: &core.DataSource{
+					Specifier: &core.DataSource_InlineBytes{
+						InlineBytes: crl,
+					},
+				},
+			},
+		}
+	}
+	return model.Resources{util.MessageToAny(secret)}
+}
+
+// recordError logs the error of the CRL generation when it changes, and records it in the
+// pilot_xds_crl_error metric.
+func (c *CrlGenerator) recordError(err error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if err == nil {
+		if c.lastError != "" {
+			adsLog.Infof("the certificate revocation list is generated again")
+			c.lastError = ""
+			crlError.Record(0)
+		}
+		return
+	}
+	if err.Error() != c.lastError {
+		adsLog.Warnf("failed to generate the certificate revocation list: %v", err)
+		c.lastError = err.Error()
+		crlError.Record(1)
+	}
+}
+
+// crlzResponse is the response of /debug/crlz.
+type crlzResponse struct {
+	RevokedCertificates []ca.RevokedCertificate `json:"revokedCertificates"`
+	CRL                 string                  `json:"crl,omitempty"`
+	Error               string                  `json:"error,omitempty"`
+}
+
+// crlz lists the certificates revoked by the Istio CA, with the CRL sent to the agents.
+func (s *DiscoveryServer) crlz(w http.ResponseWriter, _ *http.Request) {
+	gen, ok := s.Generators[v3.CRLType].(*CrlGenerator)
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = fmt.Fprint(w, "Certificate revocation is not enabled: Istiod is not the CA of the mesh.")
+		return
+	}
+	out := crlzResponse{RevokedCertificates: gen.CA.RevokedCertificates()}
+	crl, err := gen.CA.GetCRL()
+	if err != nil {
+		out.Error = err.Error()
+	}
+	out.CRL = string(crl)
+	writeJSON(w, out)
+}
@@ -21,6 +21,7 @@ import (
 	"net/http"
 	"net/http/pprof"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -166,11 +167,14 @@ func (s *DiscoveryServer) AddDebugHandlers(mux *http.ServeMux, enableProfiling b
 
 	s.addDebugHandler(mux, "/debug/syncz", "Synchronization status of all Envoys connected to this Pilot instance", s.Syncz)
 	s.addDebugHandler(mux, "/debug/config_distribution", "Version status of all Envoys connected to this Pilot instance", s.distributedVersions)
+	s.addDebugHandler(mux, "/debug/config_history", "Recorded revisions of a config resource, and the Envoys they are applied to", s.configHistory)
+	s.addDebugHandler(mux, "/debug/config_diff", "Diff between two versions of a config resource", s.configDiff)
 
 	s.addDebugHandler(mux, "/debug/registryz", "Debug support for registry", s.registryz)
 	s.addDebugHandler(mux, "/debug/endpointz", "Debug support for endpoints", s.endpointz)
 	s.addDebugHandler(mux, "/debug/endpointShardz", "Info about the endpoint shards", s.endpointShardz)
-	s.addDebugHandler(mux, "/debug/cachez", "Info about the internal XDS caches", s.cachez)
+	s.addDebugHandler(mux, "/debug/cachez", "Info about the internal XDS caches. Pass ?sizes=true for the largest "+
+		"entries and their dependencies, with the usage per type", s.cachez)
 	s.addDebugHandler(mux, "/debug/configz", "Debug support for config", s.configz)
 	s.addDebugHandler(mux, "/debug/resourcesz", "Debug support for watched resources", s.resourcez)
 	s.addDebugHandler(mux, "/debug/instancesz", "Debug support for service instances", s.instancesz)
@@ -181,6 +185,7 @@ func (s *DiscoveryServer) AddDebugHandlers(mux *http.ServeMux, enableProfiling b
 
 	s.addDebugHandler(mux, "/debug/inject", "Active inject template", s.InjectTemplateHandler(webhook))
 	s.addDebugHandler(mux, "/debug/mesh", "Active mesh config", s.MeshHandler)
+	s.addDebugHandler(mux, "/debug/crlz", "Certificates revoked by the Istio CA and their revocation list", s.crlz)
 }
 
 func (s *DiscoveryServer) addDebugHandler(mux *http.ServeMux, path string, help string,
@@ -189,6 +194,12 @@ func (s *DiscoveryServer) addDebugHandler(mux *http.ServeMux, path string, help
 	mux.HandleFunc(path, handler)
 }
 
+// AddDebugHandler adds a debug handler of another component of Istiod to the mux, listed by /debug.
+func (s *DiscoveryServer) AddDebugHandler(mux *http.ServeMux, path string, help string,
+	handler func(http.ResponseWriter, *http.Request)) {
+	s.addDebugHandler(mux, path, help, handler)
+}
+
 // Syncz dumps the synchronization status of all Envoys connected to this Pilot instance
 func (s *DiscoveryServer) Syncz(w http.ResponseWriter, _ *http.Request) {
 	syncz := make([]SyncStatus, 0)
@@ -253,7 +264,15 @@ func (s *DiscoveryServer) endpointShardz(w http.ResponseWriter, req *http.Reques
 	_, _ = w.Write(out)
 }
 
+// defaultCacheSizesLimit is the default number of entries returned by /debug/cachez?sizes=true
+const defaultCacheSizesLimit = 100
+
 func (s *DiscoveryServer) cachez(w http.ResponseWriter, req *http.Request) {
+	_ = req.ParseForm()
+	if req.Form.Get("sizes") != "" {
+		s.cacheSizez(w, req)
+		return
+	}
 	keys := s.Cache.Keys()
 	sort.Strings(keys)
 	bytes, err := json.Marshal(keys)
@@ -265,6 +284,32 @@ func (s *DiscoveryServer) cachez(w http.ResponseWriter, req *http.Request) {
 	_, _ = w.Write(bytes)
 }
 
+// cacheSizez dumps the largest cache entries, along with the configs they depend on. The number of
+// entries can be set with the limit parameter; a limit of 0 returns all the entries.
+func (s *DiscoveryServer) cacheSizez(w http.ResponseWriter, req *http.Request) {
+	limit := defaultCacheSizesLimit
+	if l := req.Form.Get("limit"); l != "" {
+		var err error
+		if limit, err = strconv.Atoi(l); err != nil || limit < 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = fmt.Fprintf(w, "invalid limit %q", l)
+			return
+		}
+	}
+	snapshot := s.Cache.Snapshot()
+	if limit > 0 && len(snapshot.Entries) > limit {
+		snapshot.Entries = snapshot.Entries[:limit]
+	}
+	bytes, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = fmt.Fprintf(w, "unable to marshal cache snapshot: %v", err)
+		return
+	}
+	w.Header().Add("Content-Type", "application/json")
+	_, _ = w.Write(bytes)
+}
+
 // Endpoint debugging
 func (s *DiscoveryServer) endpointz(w http.ResponseWriter, req *http.Request) {
 	_ = req.ParseForm()
@@ -0,0 +1,456 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"errors"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"time"
+
+	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+	"github.com/golang/protobuf/ptypes/any"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/networking/util"
+	"istio.io/istio/pilot/pkg/util/sets"
+	v3 "istio.io/istio/pilot/pkg/xds/v3"
+)
+
+// DeltaDiscoveryStream is a server interface for incremental (Delta) XDS.
+type DeltaDiscoveryStream = discovery.AggregatedDiscoveryService_DeltaAggregatedResourcesServer
+
+// DeltaDiscoveryClient is a client interface for incremental (Delta) XDS.
+type DeltaDiscoveryClient = discovery.AggregatedDiscoveryService_DeltaAggregatedResourcesClient
+
+// nameFirstFieldTypes are the types whose resource name is the first field of the message. This allows reading
+// the name directly from the marshaled resource, without unmarshaling it.
+var nameFirstFieldTypes = map[string]struct{}{
+	v3.ClusterType:  {},
+	v3.EndpointType: {}, // ClusterLoadAssignment.cluster_name
+	v3.ListenerType: {},
+	v3.RouteType:    {},
+	v3.SecretType:   {},
+}
+
+func newDeltaConnection(peerAddr string, stream DeltaDiscoveryStream) *Connection {
+	return &Connection{
+		pushChannel:   make(chan *Event),
+		stop:          make(chan struct{}),
+		PeerAddr:      peerAddr,
+		Connect:       time.Now(),
+		deltaStream:   stream,
+		blockedPushes: map[string]*model.PushRequest{},
+	}
+}
+
+// DeltaAggregatedResources implements the incremental ADS interface.
+// Resources are built by the same generators used for state of the world clients. Each response only
+// includes the resources whose version changed since they were last sent to the client, and on full pushes
+// the resources that were previously sent but are no longer generated are reported as removed.
+func (s *DiscoveryServer) DeltaAggregatedResources(stream DeltaDiscoveryStream) error {
+	peerAddr, ids, err := s.initStream(stream.Context())
+	if err != nil {
+		return err
+	}
+	con := newDeltaConnection(peerAddr, stream)
+	con.Identities = ids
+
+	var receiveError error
+	reqChannel := make(chan *discovery.DeltaDiscoveryRequest, 1)
+	go s.receiveDelta(con, reqChannel, &receiveError)
+
+	for {
+		select {
+		case req, ok := <-reqChannel:
+			if !ok {
+				// Remote side closed connection or error processing the request.
+				return receiveError
+			}
+			err := s.processDeltaRequest(req, con)
+			if err != nil {
+				return err
+			}
+
+		case pushEv := <-con.pushChannel:
+			err := s.pushConnection(con, pushEv)
+			pushEv.done()
+			if err != nil {
+				return err
+			}
+		case <-con.stop:
+			return nil
+		}
+	}
+}
+
+func (s *DiscoveryServer) receiveDelta(con *Connection, reqChannel chan *discovery.DeltaDiscoveryRequest, errP *error) {
+	defer close(reqChannel) // indicates close of the remote side.
+	firstReq := true
+	for {
+		req, err := con.deltaStream.Recv()
+		if err != nil {
+			if isExpectedGRPCError(err) {
+				adsLog.Infof("ADS: %q %s terminated %v", con.PeerAddr, con.ConID, err)
+				return
+			}
+			*errP = err
+			adsLog.Errorf("ADS: %q %s terminated with error: %v", con.PeerAddr, con.ConID, err)
+			totalXDSInternalErrors.Increment()
+			return
+		}
+		// This should be only set for the first request. The node id may not be set - for example malicious clients.
+		if firstReq {
+			firstReq = false
+			if req.Node == nil || req.Node.Id == "" {
+				*errP = errors.New("missing node ID")
+				return
+			}
+			if err := s.initConnection(req.Node, con); err != nil {
+				*errP = err
+				return
+			}
+			adsLog.Infof("ADS: new delta connection for node:%s", con.ConID)
+			defer func() {
+				s.removeCon(con.ConID)
+				if s.InternalGen != nil {
+					s.InternalGen.OnDisconnect(con)
+				}
+			}()
+		}
+
+		select {
+		case reqChannel <- req:
+		case <-con.deltaStream.Context().Done():
+			adsLog.Infof("ADS: %q %s terminated with stream closed", con.PeerAddr, con.ConID)
+			return
+		}
+	}
+}
+
+// processDeltaRequest handles one delta request, the delta equivalent of processRequest.
+func (s *DiscoveryServer) processDeltaRequest(req *discovery.DeltaDiscoveryRequest, con *Connection) error {
+	if !s.preProcessRequest(con.proxy, deltaToSotwRequest(req, nil)) {
+		return nil
+	}
+
+	if s.StatusReporter != nil {
+		s.StatusReporter.RegisterEvent(con.ConID, req.TypeUrl, req.ResponseNonce)
+	}
+	shouldRespond := s.shouldRespondDelta(con, req)
+
+	return s.pushRequested(con, req.TypeUrl, shouldRespond)
+}
+
+// shouldRespondDelta records the ACK/NACK and subscription changes carried by a delta request, and
+// determines whether it needs to be responded to. Unlike state of the world requests, delta requests
+// only carry the changes to the subscribed resources, so a response is needed only when new resources
+// are subscribed to.
+func (s *DiscoveryServer) shouldRespondDelta(con *Connection, request *discovery.DeltaDiscoveryRequest) bool {
+	stype := v3.GetShortType(request.TypeUrl)
+
+	// A NACK rejects the whole response, and the client keeps its previous resources. The versions recorded
+	// when the rejected response was sent are cleared, so the next push of this type sends the resources again.
+	// Their names are kept, so that the removed ones are still reported.
+	if request.ErrorDetail != nil {
+		errCode := codes.Code(request.ErrorDetail.Code)
+		adsLog.Warnf("ADS:%s: ACK ERROR %s %s:%s", stype, con.ConID, errCode.String(), request.ErrorDetail.GetMessage())
+		incrementXDSRejects(request.TypeUrl, con.proxy.ID, errCode.String())
+		if s.InternalGen != nil {
+			s.InternalGen.OnNack(con.proxy, deltaToSotwRequest(request, nil))
+		}
+		con.proxy.Lock()
+		if w := con.proxy.WatchedResources[request.TypeUrl]; w != nil {
+			w.NonceNacked = request.ResponseNonce
+			w.NackError = request.ErrorDetail.GetMessage()
+			clearResourceVersions(w)
+		}
+		con.proxy.Unlock()
+		return false
+	}
+
+	con.proxy.Lock()
+	defer con.proxy.Unlock()
+
+	w := con.proxy.WatchedResources[request.TypeUrl]
+	if w == nil {
+		// This is either the first request for this type, or the client reconnected after Istiod restarted
+		// or the client switched to another Istiod. In the latter case initial_resource_versions tells us
+		// what the client already has, so unchanged resources are not sent again.
+		if len(request.ResourceNamesSubscribe) == 0 && !isWildcardTypeURL(request.TypeUrl) {
+			adsLog.Debugf("ADS:%s: INIT with no resources %s", stype, con.ConID)
+			return false
+		}
+		adsLog.Debugf("ADS:%s: INIT %s %v", stype, con.ConID, request.ResourceNamesSubscribe)
+		w = &model.WatchedResource{TypeUrl: request.TypeUrl, ResourceVersions: map[string]string{}}
+		for name, version := range request.InitialResourceVersions {
+			w.ResourceVersions[name] = version
+		}
+		updateSubscriptions(w, request)
+		w.LastRequest = deltaToSotwRequest(request, w.ResourceNames)
+		con.proxy.WatchedResources[request.TypeUrl] = w
+		return true
+	}
+
+	if request.ResponseNonce != "" {
+		if request.ResponseNonce == w.NonceSent {
+			adsLog.Debugf("ADS:%s: ACK %s %s", stype, con.ConID, request.ResponseNonce)
+			w.VersionAcked = w.VersionSent
+			w.NonceAcked = request.ResponseNonce
+			w.LastAcked = time.Now()
+		} else {
+			adsLog.Debugf("ADS:%s: REQ %s Expired nonce received %s, sent %s", stype,
+				con.ConID, request.ResponseNonce, w.NonceSent)
+			xdsExpiredNonce.With(typeTag.Value(v3.GetMetricType(request.TypeUrl))).Increment()
+		}
+		w.NonceNacked = ""
+		w.NackError = ""
+	}
+
+	subscribed := updateSubscriptions(w, request)
+	w.LastRequest = deltaToSotwRequest(request, w.ResourceNames)
+	if len(w.ResourceNames) == 0 && !isWildcardTypeURL(request.TypeUrl) {
+		adsLog.Debugf("ADS:%s: UNSUBSCRIBE %s", stype, con.ConID)
+		delete(con.proxy.WatchedResources, request.TypeUrl)
+		return false
+	}
+	if subscribed {
+		adsLog.Debugf("ADS:%s: RESOURCE CHANGE subscribe: %v, unsubscribe: %v %s", stype,
+			request.ResourceNamesSubscribe, request.ResourceNamesUnsubscribe, con.ConID)
+	}
+	return subscribed
+}
+
+// updateSubscriptions applies the subscribe and unsubscribe lists of a delta request to the watched resource.
+// It returns true if a resource that was not already watched was subscribed to.
+func updateSubscriptions(w *model.WatchedResource, request *discovery.DeltaDiscoveryRequest) bool {
+	names := sets.NewSet(w.ResourceNames...)
+	subscribed := false
+	for _, name := range request.ResourceNamesSubscribe {
+		if !names.Contains(name) {
+			names.Insert(name)
+			subscribed = true
+		}
+	}
+	for _, name := range request.ResourceNamesUnsubscribe {
+		delete(names, name)
+		delete(w.ResourceVersions, name)
+	}
+	resourceNames := names.UnsortedList()
+	sort.Strings(resourceNames)
+	w.ResourceNames = resourceNames
+	return subscribed
+}
+
+// clearResourceVersions forgets the versions of the resources sent to the client, except for the resources named
+// by their content: sending the same content again would not change the outcome.
+func clearResourceVersions(w *model.WatchedResource) {
+	for name, version := range w.ResourceVersions {
+		if !isContentHashName(name, version) {
+			w.ResourceVersions[name] = ""
+		}
+	}
+}
+
+// isContentHashName returns true for the resources without a well known name field, which are named by the hash of
+// their content. See resourceName.
+func isContentHashName(name, version string) bool {
+	return name == version
+}
+
+// pushDeltaXds sends the resources built by a generator to a delta client, skipping those that did not change
+// since they were last sent. Generators build all watched resources on full pushes, so in that case any
+// resource previously sent but no longer generated has been removed. Incremental pushes may only build the
+// updated subset of the named resources; resources named by their content are always built in full, so a changed
+// one replaces the previous one, which is removed.
+func (s *DiscoveryServer) pushDeltaXds(con *Connection, push *model.PushContext,
+	currentVersion string, w *model.WatchedResource, req *model.PushRequest, cl model.Resources) error {
+	generated := make([]*discovery.Resource, 0, len(cl))
+	for _, r := range cl {
+		generated = append(generated, &discovery.Resource{
+			Name:     resourceName(r),
+			Version:  resourceVersion(r),
+			Resource: r,
+		})
+	}
+
+	resources := make([]*discovery.Resource, 0, len(generated))
+	removed := []string{}
+	con.proxy.RLock()
+	for _, r := range generated {
+		if w.ResourceVersions[r.Name] != r.Version {
+			resources = append(resources, r)
+		}
+	}
+	names := make(map[string]struct{}, len(generated))
+	for _, r := range generated {
+		names[r.Name] = struct{}{}
+	}
+	for name, version := range w.ResourceVersions {
+		if _, f := names[name]; !f && (req.Full || isContentHashName(name, version)) {
+			removed = append(removed, name)
+		}
+	}
+	con.proxy.RUnlock()
+
+	if len(resources) == 0 && len(removed) == 0 {
+		// The client already has all of these resources, report that we got an ACK for this version.
+		if s.StatusReporter != nil {
+			s.StatusReporter.RegisterEvent(con.ConID, w.TypeUrl, push.Version)
+		}
+		return nil
+	}
+	sort.Strings(removed)
+
+	resp := &discovery.DeltaDiscoveryResponse{
+		TypeUrl:           w.TypeUrl,
+		SystemVersionInfo: currentVersion,
+		Nonce:             nonce(push.Version),
+		Resources:         resources,
+		RemovedResources:  removed,
+	}
+
+	size := 0
+	for _, r := range resources {
+		size += len(r.Resource.Value)
+	}
+
+	err := con.sendDelta(resp)
+	if err != nil {
+		recordSendError(w.TypeUrl, con.ConID, err)
+		return err
+	}
+
+	// Some types handle logs inside Generate, skip them here
+	if _, f := SkipLogTypes[w.TypeUrl]; !f {
+		adsLog.Infof("%s: PUSH DELTA for node:%s resources:%d removed:%d size:%s", v3.GetShortType(w.TypeUrl),
+			con.proxy.ID, len(resources), len(removed), util.ByteCount(size))
+	}
+	return nil
+}
+
+// sendDelta sends a delta response with timeout, and records the versions of the resources sent.
+func (conn *Connection) sendDelta(res *discovery.DeltaDiscoveryResponse) error {
+	err := conn.sendWithTimeout(func() error {
+		return conn.deltaStream.Send(res)
+	})
+	if err == nil {
+		sz := 0
+		for _, r := range res.Resources {
+			sz += len(r.Resource.Value)
+		}
+		conn.proxy.Lock()
+		if res.Nonce != "" {
+			w := conn.proxy.WatchedResources[res.TypeUrl]
+			if w == nil {
+				w = &model.WatchedResource{TypeUrl: res.TypeUrl}
+				conn.proxy.WatchedResources[res.TypeUrl] = w
+			}
+			if w.ResourceVersions == nil {
+				w.ResourceVersions = map[string]string{}
+			}
+			for _, r := range res.Resources {
+				w.ResourceVersions[r.Name] = r.Version
+			}
+			for _, name := range res.RemovedResources {
+				delete(w.ResourceVersions, name)
+			}
+			w.NonceSent = res.Nonce
+			w.VersionSent = res.SystemVersionInfo
+			w.LastSent = time.Now()
+			w.LastSize = sz
+		}
+		conn.proxy.Unlock()
+	}
+	return err
+}
+
+// deltaToSotwRequest converts a delta request to the equivalent state of the world request, watching the
+// given resources. This allows NACK and health reporting to be shared with state of the world clients.
+func deltaToSotwRequest(request *discovery.DeltaDiscoveryRequest, resourceNames []string) *discovery.DiscoveryRequest {
+	return &discovery.DiscoveryRequest{
+		Node:          request.Node,
+		ResourceNames: resourceNames,
+		TypeUrl:       request.TypeUrl,
+		ResponseNonce: request.ResponseNonce,
+		ErrorDetail:   request.ErrorDetail,
+	}
+}
+
+// sotwToDeltaResponse converts a state of the world response to a delta response with the same resources.
+// No resources are removed, and the resource versions are not recorded; this is only used for internal
+// events, which are broadcast to watching clients rather than tracked per connection.
+func sotwToDeltaResponse(res *discovery.DiscoveryResponse) *discovery.DeltaDiscoveryResponse {
+	resources := make([]*discovery.Resource, 0, len(res.Resources))
+	for _, r := range res.Resources {
+		resources = append(resources, &discovery.Resource{
+			Name:     resourceName(r),
+			Version:  resourceVersion(r),
+			Resource: r,
+		})
+	}
+	return &discovery.DeltaDiscoveryResponse{
+		TypeUrl:           res.TypeUrl,
+		SystemVersionInfo: res.VersionInfo,
+		Nonce:             res.Nonce,
+		Resources:         resources,
+	}
+}
+
+// resourceName returns the name of a marshaled resource. For types without a well known name field, the
+// resource is identified by its version, so a changed resource is seen as a removal and an addition.
+func resourceName(r *any.Any) string {
+	if _, f := nameFirstFieldTypes[r.TypeUrl]; f {
+		if name, ok := firstStringField(r.Value); ok {
+			return name
+		}
+	}
+	return resourceVersion(r)
+}
+
+// resourceVersion returns the version of a marshaled resource, which is a hash of its content. Resources are
+// marshaled deterministically, so the version only changes when the resource does.
+func resourceVersion(r *any.Any) string {
+	h := fnv.New64a()
+	_, _ = h.Write(r.Value)
+	return strconv.FormatUint(h.Sum64(), 16)
+}
+
+// firstStringField reads the value of field number 1 from a marshaled message, if it is a string.
+func firstStringField(b []byte) (string, bool) {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return "", false
+		}
+		b = b[n:]
+		if num == 1 && typ == protowire.BytesType {
+			v, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return "", false
+			}
+			return string(v), true
+		}
+		n = protowire.ConsumeFieldValue(num, typ, b)
+		if n < 0 {
+			return "", false
+		}
+		b = b[n:]
+	}
+	return "", false
+}
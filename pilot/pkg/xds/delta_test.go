@@ -0,0 +1,163 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"reflect"
+	"testing"
+
+	cluster "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	endpoint "github.com/envoyproxy/go-control-plane/envoy/config/endpoint/v3"
+	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+	"github.com/golang/protobuf/ptypes/any"
+	"github.com/golang/protobuf/ptypes/duration"
+	"google.golang.org/genproto/googleapis/rpc/status"
+
+	"istio.io/istio/pilot/pkg/networking/util"
+	v3 "istio.io/istio/pilot/pkg/xds/v3"
+)
+
+func deltaResourceNames(resp *discovery.DeltaDiscoveryResponse) []string {
+	names := []string{}
+	for _, r := range resp.Resources {
+		names = append(names, r.Name)
+	}
+	return names
+}
+
+func TestDeltaAdsWildcard(t *testing.T) {
+	s := NewFakeDiscoveryServer(t, FakeOptions{})
+	ads := s.ConnectDeltaADS().WithType(v3.ClusterType)
+
+	resp := ads.RequestResponseAck(nil)
+	if len(resp.RemovedResources) != 0 {
+		t.Fatalf("unexpected removed resources: %v", resp.RemovedResources)
+	}
+	for _, r := range resp.Resources {
+		if r.Name == "" || r.Version == "" {
+			t.Fatalf("expected name and version to be set, got %v", r)
+		}
+	}
+
+	// Nothing changed, so nothing should be sent
+	AdsPushAll(s.Discovery)
+	ads.ExpectNoResponse()
+}
+
+func TestDeltaAdsSubscribe(t *testing.T) {
+	s := NewFakeDiscoveryServer(t, FakeOptions{})
+	ads := s.ConnectDeltaADS().WithType(v3.EndpointType)
+
+	resp := ads.RequestResponseAck(&discovery.DeltaDiscoveryRequest{ResourceNamesSubscribe: []string{"fake-cluster-a"}})
+	if got := deltaResourceNames(resp); !reflect.DeepEqual(got, []string{"fake-cluster-a"}) {
+		t.Fatalf("expected fake-cluster-a, got %v", got)
+	}
+
+	// Only the newly subscribed resource is sent
+	resp = ads.RequestResponseAck(&discovery.DeltaDiscoveryRequest{ResourceNamesSubscribe: []string{"fake-cluster-b"}})
+	if got := deltaResourceNames(resp); !reflect.DeepEqual(got, []string{"fake-cluster-b"}) {
+		t.Fatalf("expected fake-cluster-b, got %v", got)
+	}
+
+	// Unsubscribing does not need a response
+	ads.Request(&discovery.DeltaDiscoveryRequest{ResourceNamesUnsubscribe: []string{"fake-cluster-a"}})
+	ads.ExpectNoResponse()
+
+	AdsPushAll(s.Discovery)
+	ads.ExpectNoResponse()
+
+	// Unsubscribing from everything stops the watch
+	ads.Request(&discovery.DeltaDiscoveryRequest{ResourceNamesUnsubscribe: []string{"fake-cluster-b"}})
+	ads.ExpectNoResponse()
+	AdsPushAll(s.Discovery)
+	ads.ExpectNoResponse()
+}
+
+func TestDeltaAdsReconnect(t *testing.T) {
+	s := NewFakeDiscoveryServer(t, FakeOptions{})
+	ads := s.ConnectDeltaADS().WithType(v3.EndpointType)
+	resp := ads.RequestResponseAck(&discovery.DeltaDiscoveryRequest{ResourceNamesSubscribe: []string{"fake-cluster"}})
+	ads.Cleanup()
+
+	// Reconnect, telling the server what we have. The unchanged resource should not be sent again, while
+	// the resource the server does not know about should be removed.
+	ads = s.ConnectDeltaADS().WithType(v3.EndpointType)
+	ads.Request(&discovery.DeltaDiscoveryRequest{
+		ResourceNamesSubscribe: []string{"fake-cluster"},
+		InitialResourceVersions: map[string]string{
+			"fake-cluster": resp.Resources[0].Version,
+			"stale":        "1",
+		},
+	})
+	resp = ads.ExpectResponse()
+	if len(resp.Resources) != 0 {
+		t.Fatalf("expected no resources, got %v", deltaResourceNames(resp))
+	}
+	if !reflect.DeepEqual(resp.RemovedResources, []string{"stale"}) {
+		t.Fatalf("expected stale to be removed, got %v", resp.RemovedResources)
+	}
+}
+
+func TestDeltaAdsNack(t *testing.T) {
+	s := NewFakeDiscoveryServer(t, FakeOptions{})
+	ads := s.ConnectDeltaADS().WithType(v3.ClusterType)
+	ads.Request(nil)
+	resp := ads.ExpectResponse()
+	ads.Request(&discovery.DeltaDiscoveryRequest{ResponseNonce: resp.Nonce, ErrorDetail: &status.Status{Message: "Test request NACK"}})
+	ads.ExpectNoResponse()
+
+	con := s.Discovery.Clients()[0]
+	if con.Watched(v3.ClusterType).NonceNacked != resp.Nonce {
+		t.Fatalf("expected nonce %v to be nacked", resp.Nonce)
+	}
+
+	// The client kept its previous resources, so the rejected ones are sent again on the next push.
+	AdsPushAll(s.Discovery)
+	if got := ads.ExpectResponse(); !reflect.DeepEqual(deltaResourceNames(got), deltaResourceNames(resp)) {
+		t.Fatalf("expected %v to be sent again, got %v", deltaResourceNames(resp), deltaResourceNames(got))
+	}
+}
+
+func TestResourceName(t *testing.T) {
+	cases := []struct {
+		name     string
+		resource *any.Any
+		expected string
+	}{
+		{
+			name:     "cluster",
+			resource: util.MessageToAny(&cluster.Cluster{Name: "outbound|80||foo", ConnectTimeout: &duration.Duration{Seconds: 1}}),
+			expected: "outbound|80||foo",
+		},
+		{
+			name:     "endpoint",
+			resource: util.MessageToAny(&endpoint.ClusterLoadAssignment{ClusterName: "outbound|80||foo"}),
+			expected: "outbound|80||foo",
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resourceName(tt.resource); got != tt.expected {
+				t.Fatalf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+
+	// Types without a known name field are identified by their content
+	nameless := util.MessageToAny(&discovery.DiscoveryRequest{TypeUrl: "foo"})
+	if got := resourceName(nameless); got != resourceVersion(nameless) {
+		t.Fatalf("expected %v, got %v", resourceVersion(nameless), got)
+	}
+}
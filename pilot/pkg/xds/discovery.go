@@ -111,6 +111,9 @@ type DiscoveryServer struct {
 
 	StatusReporter DistributionStatusCache
 
+	// ConfigHistory keeps the previous versions of the config resources, if enabled.
+	ConfigHistory *model.ConfigHistory
+
 	// Authenticators for XDS requests. Should be same/subset of the CA authenticators.
 	Authenticators []authenticate.Authenticator
 
@@ -434,7 +437,7 @@ func doSendPushes(stopCh <-chan struct{}, semaphore chan struct{}, queue *PushQu
 				select {
 				case client.pushChannel <- pushEv:
 					return
-				case <-client.stream.Context().Done(): // grpc stream was closed
+				case <-client.streamContext().Done(): // grpc stream was closed
 					doneFunc()
 					adsLog.Infof("Client closed connection %v", client.ConID)
 				}
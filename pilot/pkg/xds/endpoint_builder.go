@@ -27,6 +27,7 @@ import (
 	"istio.io/istio/pilot/pkg/model"
 	"istio.io/istio/pilot/pkg/networking"
 	"istio.io/istio/pilot/pkg/networking/util"
+	v3 "istio.io/istio/pilot/pkg/xds/v3"
 	"istio.io/istio/pkg/config"
 	"istio.io/istio/pkg/config/host"
 	"istio.io/istio/pkg/config/schema/gvk"
@@ -129,6 +130,10 @@ func (b EndpointBuilder) Cacheable() bool {
 	return b.service != nil
 }
 
+func (b EndpointBuilder) TypeURL() string {
+	return v3.EndpointType
+}
+
 func (b EndpointBuilder) DependentConfigs() []model.ConfigKey {
 	configs := []model.ConfigKey{}
 	if b.destinationRule != nil {
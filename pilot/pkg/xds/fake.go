@@ -280,6 +280,22 @@ func (f *FakeDiscoveryServer) ConnectADS() *AdsTest {
 	return NewAdsTest(f.t, conn, client)
 }
 
+// ConnectDeltaADS starts a Delta ADS connection to the server. It will automatically be cleaned up when the test ends
+func (f *FakeDiscoveryServer) ConnectDeltaADS() *DeltaAdsTest {
+	conn, err := grpc.Dial("buffcon", grpc.WithInsecure(), grpc.WithBlock(), grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+		return f.Listener.Dial()
+	}))
+	if err != nil {
+		f.t.Fatalf("failed to connect: %v", err)
+	}
+	xds := discovery.NewAggregatedDiscoveryServiceClient(conn)
+	client, err := xds.DeltaAggregatedResources(context.Background())
+	if err != nil {
+		f.t.Fatalf("stream resources failed: %s", err)
+	}
+	return NewDeltaAdsTest(f.t, conn, client)
+}
+
 // Connect starts an ADS connection to the server using adsc. It will automatically be cleaned up when the test ends
 // watch can be configured to determine the resources to watch initially, and wait can be configured to determine what
 // resources we should initially wait for.
@@ -517,3 +533,129 @@ func (a *AdsTest) WithType(typeURL string) *AdsTest {
 	a.Type = typeURL
 	return a
 }
+
+func NewDeltaAdsTest(t test.Failer, conn *grpc.ClientConn, client DeltaDiscoveryClient) *DeltaAdsTest {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	resp := &DeltaAdsTest{
+		client:        client,
+		conn:          conn,
+		context:       ctx,
+		cancelContext: cancel,
+		t:             t,
+		ID:            "sidecar~1.1.1.1~test.default~default.svc.cluster.local",
+		Type:          v3.ClusterType,
+		responses:     make(chan *discovery.DeltaDiscoveryResponse),
+	}
+	t.Cleanup(resp.Cleanup)
+
+	go resp.adsReceiveChannel()
+
+	return resp
+}
+
+// DeltaAdsTest is the incremental (Delta) XDS equivalent of AdsTest
+type DeltaAdsTest struct {
+	client    DeltaDiscoveryClient
+	responses chan *discovery.DeltaDiscoveryResponse
+	t         test.Failer
+	conn      *grpc.ClientConn
+
+	ID   string
+	Type string
+
+	cancelOnce    sync.Once
+	context       context.Context
+	cancelContext context.CancelFunc
+}
+
+func (a *DeltaAdsTest) Cleanup() {
+	// Place in once to avoid race when two callers attempt to cleanup
+	a.cancelOnce.Do(func() {
+		a.cancelContext()
+		_ = a.client.CloseSend()
+		_ = a.conn.Close()
+	})
+}
+
+func (a *DeltaAdsTest) adsReceiveChannel() {
+	go func() {
+		<-a.context.Done()
+		a.Cleanup()
+	}()
+	for {
+		resp, err := a.client.Recv()
+		if err != nil {
+			return
+		}
+		a.responses <- resp
+	}
+}
+
+// ExpectResponse waits until a response is received and returns it
+func (a *DeltaAdsTest) ExpectResponse() *discovery.DeltaDiscoveryResponse {
+	a.t.Helper()
+	select {
+	case <-time.After(time.Second):
+		a.t.Fatalf("did not get response in time")
+	case resp := <-a.responses:
+		if resp == nil || (len(resp.Resources) == 0 && len(resp.RemovedResources) == 0) {
+			a.t.Fatalf("got empty response")
+		}
+		return resp
+	}
+	return nil
+}
+
+// ExpectNoResponse waits a short period of time and ensures no response is received
+func (a *DeltaAdsTest) ExpectNoResponse() {
+	a.t.Helper()
+	select {
+	case <-time.After(time.Millisecond * 50):
+		return
+	case resp := <-a.responses:
+		a.t.Fatalf("got unexpected response: %v", resp)
+	}
+}
+
+func (a *DeltaAdsTest) fillInRequestDefaults(req *discovery.DeltaDiscoveryRequest) *discovery.DeltaDiscoveryRequest {
+	if req == nil {
+		req = &discovery.DeltaDiscoveryRequest{}
+	}
+	if req.TypeUrl == "" {
+		req.TypeUrl = a.Type
+	}
+	if req.Node == nil {
+		req.Node = &core.Node{
+			Id: a.ID,
+		}
+	}
+	return req
+}
+
+func (a *DeltaAdsTest) Request(req *discovery.DeltaDiscoveryRequest) {
+	req = a.fillInRequestDefaults(req)
+	if err := a.client.Send(req); err != nil {
+		a.t.Fatal(err)
+	}
+}
+
+// RequestResponseAck does a full XDS exchange: Send a request, get a response, and ACK the response
+func (a *DeltaAdsTest) RequestResponseAck(req *discovery.DeltaDiscoveryRequest) *discovery.DeltaDiscoveryResponse {
+	a.t.Helper()
+	req = a.fillInRequestDefaults(req)
+	a.Request(req)
+	resp := a.ExpectResponse()
+	a.Request(&discovery.DeltaDiscoveryRequest{TypeUrl: req.TypeUrl, ResponseNonce: resp.Nonce})
+	return resp
+}
+
+func (a *DeltaAdsTest) WithID(id string) *DeltaAdsTest {
+	a.ID = id
+	return a
+}
+
+func (a *DeltaAdsTest) WithType(typeURL string) *DeltaAdsTest {
+	a.Type = typeURL
+	return a
+}
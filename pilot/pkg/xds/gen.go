@@ -111,6 +111,10 @@ func (s *DiscoveryServer) pushXds(con *Connection, push *model.PushContext,
 	}
 	defer func() { recordPushTime(w.TypeUrl, time.Since(t0)) }()
 
+	if con.deltaStream != nil {
+		return s.pushDeltaXds(con, push, currentVersion, w, req, cl)
+	}
+
 	resp := &discovery.DiscoveryResponse{
 		TypeUrl:     w.TypeUrl,
 		VersionInfo: currentVersion,
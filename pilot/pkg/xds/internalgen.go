@@ -46,6 +46,9 @@ const (
 	// TypeDebugSyncronization requests Envoy CSDS for proxy sync status
 	TypeDebugSyncronization = "istio.io/debug/syncz"
 
+	// TypeDebugSyncDetails requests the push and ACK times and NACK errors of each proxy
+	TypeDebugSyncDetails = "istio.io/debug/syncz_details"
+
 	// TypeDebugConfigDump requests Envoy configuration for a proxy without creating one
 	TypeDebugConfigDump = "istio.io/debug/config_dump"
 )
@@ -136,7 +139,12 @@ func (s *DiscoveryServer) PushAll(res *discovery.DiscoveryResponse) {
 		// push expects 1000s of envoy connections.
 		con := p
 		go func() {
-			err := con.stream.Send(res)
+			var err error
+			if con.deltaStream != nil {
+				err = con.deltaStream.Send(sotwToDeltaResponse(res))
+			} else {
+				err = con.stream.Send(res)
+			}
 			if err != nil {
 				adsLog.Info("Failed to send internal event ", con.ConID, " ", err)
 			}
@@ -177,6 +185,8 @@ func (sg *InternalGen) Generate(proxy *model.Proxy, push *model.PushContext, w *
 		}
 	case TypeDebugSyncronization:
 		res = sg.debugSyncz()
+	case TypeDebugSyncDetails:
+		res = sg.debugSyncDetails()
 	case TypeDebugConfigDump:
 		if len(w.ResourceNames) == 0 || len(w.ResourceNames) > 1 {
 			// Malformed request from client
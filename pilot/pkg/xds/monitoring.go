@@ -65,6 +65,11 @@ var (
 		monitoring.WithLabels(typeTag),
 	)
 
+	crlError = monitoring.NewGauge(
+		"pilot_xds_crl_error",
+		"Whether the certificate revocation list of the Istio CA failed to be generated (1) or not (0).",
+	)
+
 	// Number of delayed pushes. Currently this happens only when the last push has not been ACKed
 	totalDelayedPushes = monitoring.NewSum(
 		"pilot_xds_delayed_pushes_total",
@@ -252,5 +257,6 @@ func init() {
 		sendTime,
 		totalDelayedPushes,
 		totalDelayedPushTimeouts,
+		crlError,
 	)
 }
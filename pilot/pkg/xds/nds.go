@@ -38,6 +38,7 @@ var skippedNdsConfigs = map[config.GroupVersionKind]struct{}{
 	gvk.VirtualService:        {},
 	gvk.DestinationRule:       {},
 	gvk.EnvoyFilter:           {},
+	gvk.RateLimit:             {},
 	gvk.WorkloadEntry:         {},
 	gvk.WorkloadGroup:         {},
 	gvk.AuthorizationPolicy:   {},
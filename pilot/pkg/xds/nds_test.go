@@ -29,6 +29,7 @@ import (
 )
 
 func TestNDS(t *testing.T) {
+	httpPorts := []*nds.NameTable_NameInfo_Port{{Name: "http", Number: 80, Protocol: "TCP"}}
 	cases := []struct {
 		name     string
 		meta     model.NodeMetadata
@@ -45,14 +46,17 @@ func TestNDS(t *testing.T) {
 					"random-1.host.example": {
 						Ips:      []string{"240.240.0.1"},
 						Registry: "External",
+						Ports:    httpPorts,
 					},
 					"random-2.host.example": {
 						Ips:      []string{"9.9.9.9"},
 						Registry: "External",
+						Ports:    httpPorts,
 					},
 					"random-3.host.example": {
 						Ips:      []string{"240.240.0.2"},
 						Registry: "External",
+						Ports:    httpPorts,
 					},
 				},
 			},
@@ -67,6 +71,7 @@ func TestNDS(t *testing.T) {
 					"random-2.host.example": {
 						Ips:      []string{"9.9.9.9"},
 						Registry: "External",
+						Ports:    httpPorts,
 					},
 				},
 			},
@@ -26,6 +26,7 @@ import (
 	"istio.io/istio/pilot/pkg/networking/util"
 	"istio.io/istio/pilot/pkg/secrets"
 	authnmodel "istio.io/istio/pilot/pkg/security/model"
+	v3 "istio.io/istio/pilot/pkg/xds/v3"
 	"istio.io/istio/pkg/config/schema/gvk"
 )
 
@@ -54,6 +55,10 @@ func (sr SecretResource) Cacheable() bool {
 	return true
 }
 
+func (sr SecretResource) TypeURL() string {
+	return v3.SecretType
+}
+
 var _ model.XdsCacheEntry = SecretResource{}
 
 func parseResourceName(resource, defaultNamespace string) (SecretResource, error) {
@@ -0,0 +1,144 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/any"
+	structpb "github.com/golang/protobuf/ptypes/struct"
+
+	"istio.io/istio/pilot/pkg/networking/util"
+	v3 "istio.io/istio/pilot/pkg/xds/v3"
+)
+
+// SyncDetails shows the xDS sync state of a proxy in more detail than SyncStatus: when each type was
+// last pushed and acked, and why the last push was rejected.
+type SyncDetails struct {
+	ProxyID      string            `json:"proxy"`
+	IstioVersion string            `json:"istio_version,omitempty"`
+	Types        []TypeSyncDetails `json:"types"`
+}
+
+// TypeSyncDetails is the sync state of a type watched by a proxy.
+type TypeSyncDetails struct {
+	TypeURL     string `json:"type_url"`
+	NonceSent   string `json:"nonce_sent,omitempty"`
+	NonceAcked  string `json:"nonce_acked,omitempty"`
+	NonceNacked string `json:"nonce_nacked,omitempty"`
+	// NackError is the error detail sent by the proxy with the NACK of NonceNacked.
+	NackError string    `json:"nack_error,omitempty"`
+	LastSent  time.Time `json:"last_sent"`
+	LastAcked time.Time `json:"last_acked"`
+}
+
+// Synced returns true if the last push was acked.
+func (t TypeSyncDetails) Synced() bool {
+	return t.NonceSent != "" && t.NonceSent == t.NonceAcked
+}
+
+// Nacked returns true if the last push was rejected.
+func (t TypeSyncDetails) Nacked() bool {
+	return t.NonceSent != "" && t.NonceSent == t.NonceNacked
+}
+
+// TimeToAck returns the time the proxy took to ack the last push, if it was acked.
+func (t TypeSyncDetails) TimeToAck() (time.Duration, bool) {
+	if !t.Synced() || t.LastSent.IsZero() || t.LastAcked.Before(t.LastSent) {
+		return 0, false
+	}
+	return t.LastAcked.Sub(t.LastSent), true
+}
+
+// ParseSyncDetails decodes a resource of a TypeDebugSyncDetails response.
+func ParseSyncDetails(resource *any.Any) (*SyncDetails, error) {
+	pbs := &structpb.Struct{}
+	if err := ptypes.UnmarshalAny(resource, pbs); err != nil {
+		return nil, fmt.Errorf("could not unmarshal sync details: %v", err)
+	}
+	j, err := (&jsonpb.Marshaler{}).MarshalToString(pbs)
+	if err != nil {
+		return nil, err
+	}
+	out := &SyncDetails{}
+	if err := json.Unmarshal([]byte(j), out); err != nil {
+		return nil, fmt.Errorf("could not unmarshal sync details: %v", err)
+	}
+	return out, nil
+}
+
+// ToStruct encodes the sync details, to be sent as a TypeDebugSyncDetails resource.
+func (sd *SyncDetails) ToStruct() (*structpb.Struct, error) {
+	j, err := json.Marshal(sd)
+	if err != nil {
+		return nil, err
+	}
+	pbs := &structpb.Struct{}
+	if err := jsonpb.Unmarshal(bytes.NewBuffer(j), pbs); err != nil {
+		return nil, err
+	}
+	return pbs, nil
+}
+
+func (sg *InternalGen) debugSyncDetails() []*any.Any {
+	res := []*any.Any{}
+
+	stypes := []string{
+		v3.ClusterType,
+		v3.ListenerType,
+		v3.RouteType,
+		v3.EndpointType,
+	}
+
+	for _, con := range sg.Server.Clients() {
+		con.proxy.RLock()
+		// Skip "nodes" without metadata (they are probably istioctl queries!)
+		if isProxy(con) {
+			details := &SyncDetails{
+				ProxyID:      con.proxy.ID,
+				IstioVersion: con.proxy.Metadata.IstioVersion,
+				Types:        []TypeSyncDetails{},
+			}
+			for _, stype := range stypes {
+				wr, ok := con.proxy.WatchedResources[stype]
+				if !ok {
+					continue
+				}
+				details.Types = append(details.Types, TypeSyncDetails{
+					TypeURL:     stype,
+					NonceSent:   wr.NonceSent,
+					NonceAcked:  wr.NonceAcked,
+					NonceNacked: wr.NonceNacked,
+					NackError:   wr.NackError,
+					LastSent:    wr.LastSent,
+					LastAcked:   wr.LastAcked,
+				})
+			}
+			if pbs, err := details.ToStruct(); err != nil {
+				log.Warnf("failed to encode sync details of %s: %v", con.proxy.ID, err)
+			} else {
+				res = append(res, util.MessageToAny(pbs))
+			}
+		}
+		con.proxy.RUnlock()
+	}
+
+	return res
+}
@@ -0,0 +1,97 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"sort"
+
+	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	tls "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
+
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/networking/util"
+	v3 "istio.io/istio/pilot/pkg/xds/v3"
+)
+
+// RootCertSource is the source of the root certs trusted by the CA.
+type RootCertSource interface {
+	// GetRootCertPem returns the PEM encoded root certs.
+	GetRootCertPem() []byte
+}
+
+// FederatedRootCertSource is the source of the root certs of the federated trust domains.
+type FederatedRootCertSource interface {
+	// RootCertPems returns the PEM encoded root certs by trust domain.
+	RootCertPems() map[string][]byte
+}
+
+// TrustBundleGenerator generates the root certs trusted by the Istio CA. The agents serve them as the root
+// cert over SDS, so that during a rotation of the CA certificates the proxies trust the new root certs
+// before the CA signs with them.
+type TrustBundleGenerator struct {
+	CA RootCertSource
+	// Federated are the root certs of federated trust domains. Each trust domain is sent in its own Secret
+	// and served in its own validation context, so that a federated CA can not sign certificates trusted
+	// for the identities of another trust domain. It may be nil.
+	Federated FederatedRootCertSource
+}
+
+var _ model.XdsResourceGenerator = &TrustBundleGenerator{}
+
+// Generate returns a Secret with the root certs of the CA as the trusted CA of its validation context,
+// followed by one Secret per federated trust domain, ordered by trust domain.
+func (g *TrustBundleGenerator) Generate(proxy *model.Proxy, push *model.PushContext, w *model.WatchedResource, req *model.PushRequest) model.Resources {
+	if req != nil && !req.Full {
+		// The trust bundle is only updated by full pushes
+		return nil
+	}
+	roots := g.CA.GetRootCertPem()
+	if len(roots) == 0 {
+		return nil
+	}
+	resources := model.Resources{util.MessageToAny(trustBundleSecret(v3.TrustBundleResourceName, roots))}
+	if g.Federated == nil {
+		return resources
+	}
+	federated := g.Federated.RootCertPems()
+	trustDomains := make([]string, 0, len(federated))
+	for trustDomain := range federated {
+		trustDomains = append(trustDomains, trustDomain)
+	}
+	sort.Strings(trustDomains)
+	for _, trustDomain := range trustDomains {
+		if len(federated[trustDomain]) == 0 {
+			continue
+		}
+		name := v3.FederatedTrustBundleResourceNamePrefix + trustDomain
+		resources = append(resources, util.MessageToAny(trustBundleSecret(name, federated[trustDomain])))
+	}
+	return resources
+}
+
+func trustBundleSecret(name string, roots []byte) *tls.Secret {
+	return &tls.Secret{
+		Name: name,
+		Type: &tls.Secret_ValidationContext{
+			ValidationContext: &tls.CertificateValidationContext{
+				TrustedCa: &core.DataSource{
+					Specifier: &core.DataSource_InlineBytes{
+						InlineBytes: roots,
+					},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"reflect"
+	"testing"
+
+	tls "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
+	"github.com/golang/protobuf/ptypes"
+
+	"istio.io/istio/pilot/pkg/model"
+)
+
+type fakeRootCerts []byte
+
+func (r fakeRootCerts) GetRootCertPem() []byte {
+	return r
+}
+
+type fakeFederatedRootCerts map[string][]byte
+
+func (r fakeFederatedRootCerts) RootCertPems() map[string][]byte {
+	return r
+}
+
+func TestTrustBundleGenerator(t *testing.T) {
+	g := &TrustBundleGenerator{
+		CA:        fakeRootCerts("ca-root"),
+		Federated: fakeFederatedRootCerts{"foo.com": []byte("foo-root"), "bar.com": []byte("bar-root")},
+	}
+	resources := g.Generate(nil, nil, nil, &model.PushRequest{Full: true})
+
+	got := map[string]string{}
+	var names []string
+	for _, r := range resources {
+		secret := &tls.Secret{}
+		if err := ptypes.UnmarshalAny(r, secret); err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, secret.Name)
+		got[secret.Name] = string(secret.GetValidationContext().GetTrustedCa().GetInlineBytes())
+	}
+	// The federated root certs are never merged with the root certs of the CA.
+	want := map[string]string{
+		"trust-bundle":         "ca-root",
+		"trust-bundle~bar.com": "bar-root",
+		"trust-bundle~foo.com": "foo-root",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got trust bundles %v, want %v", got, want)
+	}
+	if !reflect.DeepEqual(names, []string{"trust-bundle", "trust-bundle~bar.com", "trust-bundle~foo.com"}) {
+		t.Errorf("unexpected order of the trust bundles %v", names)
+	}
+
+	if resources := g.Generate(nil, nil, nil, &model.PushRequest{Full: false}); resources != nil {
+		t.Errorf("expected no trust bundle on incremental pushes, got %v", resources)
+	}
+}
@@ -26,6 +26,18 @@ const (
 	SecretType     = resource.SecretType
 	NameTableType  = "type.googleapis.com/istio.networking.nds.v1.NameTable"
 	HealthInfoType = "type.googleapis.com/istio.v1.HealthInformation"
+	// CRLType is the type of the certificate revocation list of the Istio CA, sent to the agents as a Secret
+	// with a validation context.
+	CRLType = "type.googleapis.com/istio.security.v1.CertificateRevocationList"
+	// TrustBundleType is the type of the root certs trusted by the Istio CA, sent to the agents as a Secret
+	// with a validation context.
+	TrustBundleType = "type.googleapis.com/istio.security.v1.TrustBundle"
+
+	// TrustBundleResourceName is the name of the trust bundle Secret holding the root certs of the Istio CA.
+	TrustBundleResourceName = "trust-bundle"
+	// FederatedTrustBundleResourceNamePrefix is the prefix of the name of the trust bundle Secrets holding
+	// the root certs of a federated trust domain, followed by the trust domain.
+	FederatedTrustBundleResourceNamePrefix = "trust-bundle~"
 )
 
 // GetShortType returns an abbreviated form of a type, useful for logging or human friendly messages
@@ -43,6 +55,10 @@ func GetShortType(typeURL string) string {
 		return "SDS"
 	case NameTableType:
 		return "NDS"
+	case CRLType:
+		return "CRL"
+	case TrustBundleType:
+		return "TrustBundle"
 	default:
 		return typeURL
 	}
@@ -63,6 +79,10 @@ func GetMetricType(typeURL string) string {
 		return "sds"
 	case NameTableType:
 		return "nds"
+	case CRLType:
+		return "crl"
+	case TrustBundleType:
+		return "trustbundle"
 	default:
 		return typeURL
 	}
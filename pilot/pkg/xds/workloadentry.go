@@ -339,7 +339,7 @@ func UpdateHealthCondition(conditions []*v1alpha1.IstioCondition, event HealthEv
 	foundHealth := false
 	healthIdx := 0
 	for i, cond := range conditions {
-		if cond.Type == "Healthy" {
+		if cond.Type == model.WorkloadEntryHealthyCondition {
 			foundHealth = true
 			healthIdx = i
 			break
@@ -357,17 +357,17 @@ func UpdateHealthCondition(conditions []*v1alpha1.IstioCondition, event HealthEv
 
 func transformHealthEvent(event HealthEvent) *v1alpha1.IstioCondition {
 	cond := &v1alpha1.IstioCondition{
-		Type: "Healthy",
+		Type: model.WorkloadEntryHealthyCondition,
 		// last probe and transition are the same because
 		// we only send on transition in the agent
 		LastProbeTime:      types.TimestampNow(),
 		LastTransitionTime: types.TimestampNow(),
 	}
 	if event.Healthy {
-		cond.Status = "True"
+		cond.Status = model.WorkloadEntryConditionTrue
 		return cond
 	}
-	cond.Status = "False"
+	cond.Status = model.WorkloadEntryConditionFalse
 	cond.Message = event.Message
 	return cond
 }
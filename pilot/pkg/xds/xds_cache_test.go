@@ -17,12 +17,14 @@ package xds
 import (
 	"fmt"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/golang/protobuf/ptypes/any"
 
 	"istio.io/istio/pilot/pkg/features"
 	"istio.io/istio/pilot/pkg/model"
+	v3 "istio.io/istio/pilot/pkg/xds/v3"
 	"istio.io/istio/pkg/config"
 	"istio.io/istio/pkg/config/schema/gvk"
 )
@@ -32,9 +34,10 @@ var (
 	any2 = &any.Any{TypeUrl: "bar"}
 )
 
-var XdsCacheTypes = map[string]int{
-	"InMemory": 0,
-	"Lru":      50,
+var XdsCacheTypes = map[string]struct{ size, bytes int }{
+	"InMemory": {},
+	"Lru":      {size: 50},
+	"Budget":   {bytes: 1 << 20},
 }
 
 func TestXdsCache(t *testing.T) {
@@ -47,9 +50,9 @@ func TestXdsCache(t *testing.T) {
 		service:     &model.Service{Hostname: "foo.com"},
 	}
 	for ct, cs := range XdsCacheTypes {
-		defaultCache := features.XDSCacheMaxSize
-		features.XDSCacheMaxSize = cs
-		defer func() { features.XDSCacheMaxSize = defaultCache }()
+		defaultCache, defaultBytes := features.XDSCacheMaxSize, features.XDSCacheMaxBytes
+		features.XDSCacheMaxSize, features.XDSCacheMaxBytes = cs.size, cs.bytes
+		defer func() { features.XDSCacheMaxSize, features.XDSCacheMaxBytes = defaultCache, defaultBytes }()
 		t.Run(fmt.Sprintf("%s_%s", "simple", ct), func(t *testing.T) {
 			c := model.NewXdsCache()
 			c.Add(ep1, any1)
@@ -138,3 +141,122 @@ func TestXdsCache(t *testing.T) {
 		})
 	}
 }
+
+func TestXdsCacheSnapshot(t *testing.T) {
+	ep1 := EndpointBuilder{
+		clusterName:     "outbound|1||foo.com",
+		service:         &model.Service{Hostname: "foo.com", Attributes: model.ServiceAttributes{Namespace: "ns"}},
+		destinationRule: &config.Config{Meta: config.Meta{Name: "dr", Namespace: "ns"}},
+	}
+	ep2 := EndpointBuilder{
+		clusterName: "outbound|2||foo.com",
+		service:     &model.Service{Hostname: "foo.com", Attributes: model.ServiceAttributes{Namespace: "ns"}},
+	}
+	small := &any.Any{TypeUrl: v3.EndpointType, Value: []byte("small")}
+	large := &any.Any{TypeUrl: v3.EndpointType, Value: []byte(strings.Repeat("large", 10))}
+	for ct, cs := range XdsCacheTypes {
+		defaultCache, defaultBytes := features.XDSCacheMaxSize, features.XDSCacheMaxBytes
+		features.XDSCacheMaxSize, features.XDSCacheMaxBytes = cs.size, cs.bytes
+		defer func() { features.XDSCacheMaxSize, features.XDSCacheMaxBytes = defaultCache, defaultBytes }()
+		t.Run(ct, func(t *testing.T) {
+			c := model.NewXdsCache()
+			c.Add(ep1, small)
+			c.Add(ep2, large)
+
+			snapshot := c.Snapshot()
+			if len(snapshot.Entries) != 2 || snapshot.Entries[0].Key != ep2.Key() || snapshot.Entries[1].Key != ep1.Key() {
+				t.Fatalf("expected entries sorted by size, got %+v", snapshot.Entries)
+			}
+			wantBytes := len(ep1.Key()) + len(v3.EndpointType) + len(small.Value) +
+				len(ep2.Key()) + len(v3.EndpointType) + len(large.Value)
+			if snapshot.Bytes != wantBytes {
+				t.Fatalf("unexpected size: %v, want %v", snapshot.Bytes, wantBytes)
+			}
+			stats := snapshot.Types[v3.EndpointType]
+			if stats == nil || stats.Entries != 2 || stats.Bytes != wantBytes {
+				t.Fatalf("unexpected eds stats: %+v", stats)
+			}
+			wantDeps := []string{"DestinationRule/ns/dr", "ServiceEntry/ns/foo.com"}
+			if !reflect.DeepEqual(snapshot.Entries[1].Dependencies, wantDeps) {
+				t.Fatalf("unexpected dependencies: %v, want %v", snapshot.Entries[1].Dependencies, wantDeps)
+			}
+		})
+	}
+}
+
+func TestXdsCacheMemoryBudget(t *testing.T) {
+	eps := make([]EndpointBuilder, 0, 4)
+	for i := 0; i < 4; i++ {
+		eps = append(eps, EndpointBuilder{
+			clusterName: fmt.Sprintf("outbound|%d||foo.com", i),
+			service:     &model.Service{Hostname: "foo.com"},
+		})
+	}
+	value := &any.Any{TypeUrl: v3.EndpointType, Value: make([]byte, 100)}
+	entrySize := len(eps[0].Key()) + len(value.TypeUrl) + len(value.Value)
+
+	defaultBytes := features.XDSCacheMaxBytes
+	features.XDSCacheMaxBytes = 3 * entrySize
+	defer func() { features.XDSCacheMaxBytes = defaultBytes }()
+
+	t.Run("evict least recently used", func(t *testing.T) {
+		c := model.NewXdsCache()
+		c.Add(eps[0], value)
+		c.Add(eps[1], value)
+		c.Add(eps[2], value)
+		// Access the oldest entry so the second one becomes the least recently used
+		if _, f := c.Get(eps[0]); !f {
+			t.Fatalf("expected %v to be cached", eps[0].Key())
+		}
+		c.Add(eps[3], value)
+		if _, f := c.Get(eps[1]); f {
+			t.Fatalf("expected %v to be evicted, got keys %v", eps[1].Key(), c.Keys())
+		}
+		for _, ep := range []EndpointBuilder{eps[0], eps[2], eps[3]} {
+			if _, f := c.Get(ep); !f {
+				t.Fatalf("expected %v to be cached, got keys %v", ep.Key(), c.Keys())
+			}
+		}
+		snapshot := c.Snapshot()
+		if snapshot.Bytes != 3*entrySize || snapshot.MaxBytes != 3*entrySize {
+			t.Fatalf("unexpected size: %v of %v, want %v", snapshot.Bytes, snapshot.MaxBytes, 3*entrySize)
+		}
+		stats := snapshot.Types[v3.EndpointType]
+		if stats.Evictions != 1 || stats.Hits != 4 || stats.Misses != 1 {
+			t.Fatalf("unexpected stats: %+v", stats)
+		}
+	})
+
+	t.Run("larger values evict more entries", func(t *testing.T) {
+		c := model.NewXdsCache()
+		c.Add(eps[0], value)
+		c.Add(eps[1], value)
+		c.Add(eps[2], value)
+		c.Add(eps[0], &any.Any{TypeUrl: v3.EndpointType, Value: make([]byte, 200)})
+		if keys := c.Keys(); len(keys) != 2 {
+			t.Fatalf("expected 2 entries, got %v", keys)
+		}
+		if _, f := c.Get(eps[1]); f {
+			t.Fatalf("expected %v to be evicted, got keys %v", eps[1].Key(), c.Keys())
+		}
+	})
+
+	t.Run("entry over budget", func(t *testing.T) {
+		c := model.NewXdsCache()
+		c.Add(eps[0], value)
+		c.Add(eps[1], &any.Any{TypeUrl: v3.EndpointType, Value: make([]byte, 4*entrySize)})
+		if !reflect.DeepEqual(c.Keys(), []string{eps[0].Key()}) {
+			t.Fatalf("unexpected keys: %v, want %v", c.Keys(), eps[0].Key())
+		}
+	})
+
+	t.Run("clear releases budget", func(t *testing.T) {
+		c := model.NewXdsCache()
+		c.Add(eps[0], value)
+		c.Add(eps[1], value)
+		c.Clear(map[model.ConfigKey]struct{}{{Kind: gvk.ServiceEntry, Name: "foo.com"}: {}})
+		if snapshot := c.Snapshot(); snapshot.Bytes != 0 || len(snapshot.Entries) != 0 || snapshot.Types[v3.EndpointType].Entries != 0 {
+			t.Fatalf("expected empty cache, got %+v", snapshot)
+		}
+	})
+}
@@ -38,25 +38,7 @@ import (
 )
 
 func ExtractRoutesFromListeners(ll []*listener.Listener) []string {
-	routes := []string{}
-	for _, l := range ll {
-		for _, fc := range l.FilterChains {
-			for _, filter := range fc.Filters {
-				if filter.Name == wellknown.HTTPConnectionManager {
-					filter.GetTypedConfig()
-					hcon := &hcm.HttpConnectionManager{}
-					if err := ptypes.UnmarshalAny(filter.GetTypedConfig(), hcon); err != nil {
-						panic(err)
-					}
-					switch r := hcon.GetRouteSpecifier().(type) {
-					case *hcm.HttpConnectionManager_Rds:
-						routes = append(routes, r.Rds.RouteConfigName)
-					}
-				}
-			}
-		}
-	}
-	return routes
+	return util.ExtractRoutesFromListeners(ll)
 }
 
 func ExtractListenerNames(ll []*listener.Listener) []string {
@@ -68,28 +50,15 @@ func ExtractListenerNames(ll []*listener.Listener) []string {
 }
 
 func ExtractListener(name string, ll []*listener.Listener) *listener.Listener {
-	for _, l := range ll {
-		if l.Name == name {
-			return l
-		}
-	}
-	return nil
+	return util.ExtractListener(name, ll)
 }
 
 func ExtractRouteConfigurations(rc []*route.RouteConfiguration) map[string]*route.RouteConfiguration {
-	res := map[string]*route.RouteConfiguration{}
-	for _, l := range rc {
-		res[l.Name] = l
-	}
-	return res
+	return util.ExtractRouteConfigurations(rc)
 }
 
 func ExtractListenerFilters(l *listener.Listener) map[string]*listener.ListenerFilter {
-	res := map[string]*listener.ListenerFilter{}
-	for _, lf := range l.ListenerFilters {
-		res[lf.Name] = lf
-	}
-	return res
+	return util.ExtractListenerFilters(l)
 }
 
 func ExtractTCPProxy(t test.Failer, fcs *listener.FilterChain) *tcpproxy.TcpProxy {
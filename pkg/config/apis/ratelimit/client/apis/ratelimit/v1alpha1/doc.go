@@ -0,0 +1,22 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package v1alpha1 contains the Kubernetes types of the ratelimit.istio.io/v1alpha1 API, which
+// wrap the RateLimit spec of istio.io/istio/pkg/config/apis/ratelimit/v1alpha1. The clientset,
+// informers and listers of the client directory are generated from them by the
+// ratelimit-gen make target.
+//
+// +k8s:deepcopy-gen=package
+// +groupName=ratelimit.istio.io
+package v1alpha1
@@ -0,0 +1,56 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package v1alpha1 contains the API of the ratelimit.istio.io/v1alpha1 RateLimit resource.
+//
+// A RateLimit configures the rate limits Envoy enforces on the HTTP requests going through the
+// selected sidecars and gateways. The following example limits the requests sent to reviews from
+// the workloads of the default namespace to 10 per second, and sends a descriptor with the value
+// of the x-user header to a global rate limit service:
+//
+//	apiVersion: ratelimit.istio.io/v1alpha1
+//	kind: RateLimit
+//	metadata:
+//	  name: reviews
+//	  namespace: default
+//	spec:
+//	  hosts:
+//	  - reviews.default.svc.cluster.local
+//	  local:
+//	    maxTokens: 10
+//	    tokensPerFill: 10
+//	    fillInterval: 1s
+//	  global:
+//	    service: ratelimit.istio-system.svc.cluster.local
+//	    port: 8081
+//	    domain: reviews
+//	    descriptors:
+//	    - entries:
+//	      - requestHeader:
+//	          name: x-user
+//	          descriptorKey: user
+//
+// A RateLimit in the root namespace applies to every namespace of the mesh. The global rate limits
+// applied to a proxy are sent to a single rate limit service and domain, the ones of the oldest
+// RateLimit.
+package v1alpha1
+
+const (
+	// GroupName is the API group of the RateLimit resource.
+	GroupName = "ratelimit.istio.io"
+	// Version is the API version of the RateLimit resource.
+	Version = "v1alpha1"
+	// Kind is the kind of the RateLimit resource.
+	Kind = "RateLimit"
+)
@@ -109,4 +109,8 @@ const (
 	DefaultConfigServiceAccountName = "istiod-service-account"
 
 	TestVMLabel = "istio.io/test-vm"
+
+	// AuthorizationPolicyDryRunAnnotation marks an authorization policy as dry-run when set to "true": the policy
+	// is evaluated by the proxies, which report its result in their stats and dynamic metadata, but not enforced.
+	AuthorizationPolicyDryRunAnnotation = "istio.io/dry-run"
 )
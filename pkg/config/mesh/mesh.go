@@ -34,6 +34,11 @@ import (
 	"istio.io/pkg/log"
 )
 
+// CertificatePoliciesKey is the key of the certificate policies enforced by the Istiod CA in the mesh config.
+// They are not a field of the MeshConfig proto: Istiod reads them from the mesh config ConfigMap, and they
+// are ignored when the mesh config is parsed.
+const CertificatePoliciesKey = "certificatePolicies"
+
 // DefaultProxyConfig for individual proxies
 func DefaultProxyConfig() meshconfig.ProxyConfig {
 	// TODO: include revision based on REVISION env
@@ -17,6 +17,7 @@ import (
 	istioioapimetav1alpha1 "istio.io/api/meta/v1alpha1"
 	istioioapinetworkingv1alpha3 "istio.io/api/networking/v1alpha3"
 	istioioapisecurityv1beta1 "istio.io/api/security/v1beta1"
+	istioioistiopkgconfigapisratelimitv1alpha1 "istio.io/istio/pkg/config/apis/ratelimit/v1alpha1"
 	"istio.io/istio/pkg/config/schema/collection"
 	"istio.io/istio/pkg/config/schema/resource"
 	"istio.io/istio/pkg/config/validation"
@@ -214,6 +215,25 @@ var (
 		}.MustBuild(),
 	}.MustBuild()
 
+	// IstioRatelimitV1Alpha1Ratelimits describes the collection
+	// istio/ratelimit/v1alpha1/ratelimits
+	IstioRatelimitV1Alpha1Ratelimits = collection.Builder{
+		Name:         "istio/ratelimit/v1alpha1/ratelimits",
+		VariableName: "IstioRatelimitV1Alpha1Ratelimits",
+		Disabled:     false,
+		Resource: resource.Builder{
+			Group:   "ratelimit.istio.io",
+			Kind:    "RateLimit",
+			Plural:  "ratelimits",
+			Version: "v1alpha1",
+			Proto:   "istio.ratelimit.v1alpha1.RateLimit", StatusProto: "istio.meta.v1alpha1.IstioStatus",
+			ReflectType: reflect.TypeOf(&istioioistiopkgconfigapisratelimitv1alpha1.RateLimit{}).Elem(), StatusType: reflect.TypeOf(&istioioapimetav1alpha1.IstioStatus{}).Elem(),
+			ProtoPackage: "istio.io/istio/pkg/config/apis/ratelimit/v1alpha1", StatusPackage: "istio.io/api/meta/v1alpha1",
+			ClusterScoped: false,
+			ValidateProto: validation.ValidateRateLimit,
+		}.MustBuild(),
+	}.MustBuild()
+
 	// IstioSecurityV1Beta1Authorizationpolicies describes the collection
 	// istio/security/v1beta1/authorizationpolicies
 	IstioSecurityV1Beta1Authorizationpolicies = collection.Builder{
@@ -605,6 +625,25 @@ var (
 		}.MustBuild(),
 	}.MustBuild()
 
+	// K8SRatelimitIstioIoV1Alpha1Ratelimits describes the collection
+	// k8s/ratelimit.istio.io/v1alpha1/ratelimits
+	K8SRatelimitIstioIoV1Alpha1Ratelimits = collection.Builder{
+		Name:         "k8s/ratelimit.istio.io/v1alpha1/ratelimits",
+		VariableName: "K8SRatelimitIstioIoV1Alpha1Ratelimits",
+		Disabled:     false,
+		Resource: resource.Builder{
+			Group:   "ratelimit.istio.io",
+			Kind:    "RateLimit",
+			Plural:  "ratelimits",
+			Version: "v1alpha1",
+			Proto:   "istio.ratelimit.v1alpha1.RateLimit", StatusProto: "istio.meta.v1alpha1.IstioStatus",
+			ReflectType: reflect.TypeOf(&istioioistiopkgconfigapisratelimitv1alpha1.RateLimit{}).Elem(), StatusType: reflect.TypeOf(&istioioapimetav1alpha1.IstioStatus{}).Elem(),
+			ProtoPackage: "istio.io/istio/pkg/config/apis/ratelimit/v1alpha1", StatusPackage: "istio.io/api/meta/v1alpha1",
+			ClusterScoped: false,
+			ValidateProto: validation.ValidateRateLimit,
+		}.MustBuild(),
+	}.MustBuild()
+
 	// K8SSecurityIstioIoV1Beta1Authorizationpolicies describes the collection
 	// k8s/security.istio.io/v1beta1/authorizationpolicies
 	K8SSecurityIstioIoV1Beta1Authorizationpolicies = collection.Builder{
@@ -788,6 +827,7 @@ var (
 		MustAdd(IstioNetworkingV1Alpha3Virtualservices).
 		MustAdd(IstioNetworkingV1Alpha3Workloadentries).
 		MustAdd(IstioNetworkingV1Alpha3Workloadgroups).
+		MustAdd(IstioRatelimitV1Alpha1Ratelimits).
 		MustAdd(IstioSecurityV1Beta1Authorizationpolicies).
 		MustAdd(IstioSecurityV1Beta1Peerauthentications).
 		MustAdd(IstioSecurityV1Beta1Requestauthentications).
@@ -809,6 +849,7 @@ var (
 		MustAdd(K8SNetworkingIstioIoV1Alpha3Virtualservices).
 		MustAdd(K8SNetworkingIstioIoV1Alpha3Workloadentries).
 		MustAdd(K8SNetworkingIstioIoV1Alpha3Workloadgroups).
+		MustAdd(K8SRatelimitIstioIoV1Alpha1Ratelimits).
 		MustAdd(K8SSecurityIstioIoV1Beta1Authorizationpolicies).
 		MustAdd(K8SSecurityIstioIoV1Beta1Peerauthentications).
 		MustAdd(K8SSecurityIstioIoV1Beta1Requestauthentications).
@@ -832,6 +873,7 @@ var (
 		MustAdd(IstioNetworkingV1Alpha3Virtualservices).
 		MustAdd(IstioNetworkingV1Alpha3Workloadentries).
 		MustAdd(IstioNetworkingV1Alpha3Workloadgroups).
+		MustAdd(IstioRatelimitV1Alpha1Ratelimits).
 		MustAdd(IstioSecurityV1Beta1Authorizationpolicies).
 		MustAdd(IstioSecurityV1Beta1Peerauthentications).
 		MustAdd(IstioSecurityV1Beta1Requestauthentications).
@@ -857,6 +899,7 @@ var (
 		MustAdd(K8SNetworkingIstioIoV1Alpha3Virtualservices).
 		MustAdd(K8SNetworkingIstioIoV1Alpha3Workloadentries).
 		MustAdd(K8SNetworkingIstioIoV1Alpha3Workloadgroups).
+		MustAdd(K8SRatelimitIstioIoV1Alpha1Ratelimits).
 		MustAdd(K8SSecurityIstioIoV1Beta1Authorizationpolicies).
 		MustAdd(K8SSecurityIstioIoV1Beta1Peerauthentications).
 		MustAdd(K8SSecurityIstioIoV1Beta1Requestauthentications).
@@ -878,6 +921,7 @@ var (
 		MustAdd(IstioNetworkingV1Alpha3Virtualservices).
 		MustAdd(IstioNetworkingV1Alpha3Workloadentries).
 		MustAdd(IstioNetworkingV1Alpha3Workloadgroups).
+		MustAdd(IstioRatelimitV1Alpha1Ratelimits).
 		MustAdd(IstioSecurityV1Beta1Authorizationpolicies).
 		MustAdd(IstioSecurityV1Beta1Peerauthentications).
 		MustAdd(IstioSecurityV1Beta1Requestauthentications).
@@ -893,6 +937,7 @@ var (
 			MustAdd(IstioNetworkingV1Alpha3Virtualservices).
 			MustAdd(IstioNetworkingV1Alpha3Workloadentries).
 			MustAdd(IstioNetworkingV1Alpha3Workloadgroups).
+			MustAdd(IstioRatelimitV1Alpha1Ratelimits).
 			MustAdd(IstioSecurityV1Beta1Authorizationpolicies).
 			MustAdd(IstioSecurityV1Beta1Peerauthentications).
 			MustAdd(IstioSecurityV1Beta1Requestauthentications).
@@ -123,6 +123,11 @@ collections:
     group: "networking.istio.io"
     pilot: true
 
+  - name: "istio/ratelimit/v1alpha1/ratelimits"
+    kind: "RateLimit"
+    group: "ratelimit.istio.io"
+    pilot: true
+
   - name: "istio/security/v1beta1/authorizationpolicies"
     kind: "AuthorizationPolicy"
     group: "security.istio.io"
@@ -238,6 +243,10 @@ collections:
     kind: "VirtualService"
     group: "networking.istio.io"
 
+  - name: "k8s/ratelimit.istio.io/v1alpha1/ratelimits"
+    kind: "RateLimit"
+    group: "ratelimit.istio.io"
+
   - name: "k8s/security.istio.io/v1beta1/authorizationpolicies"
     kind: "AuthorizationPolicy"
     group: "security.istio.io"
@@ -283,6 +292,7 @@ snapshots:
       - "istio/networking/v1alpha3/serviceentries"
       - "istio/networking/v1alpha3/sidecars"
       - "istio/networking/v1alpha3/virtualservices"
+      - "istio/ratelimit/v1alpha1/ratelimits"
       - "istio/security/v1beta1/authorizationpolicies"
       - "k8s/apiextensions.k8s.io/v1beta1/customresourcedefinitions"
       - "k8s/apps/v1/deployments"
@@ -535,6 +545,16 @@ resources:
     statusProto: "istio.meta.v1alpha1.IstioStatus"
     statusProtoPackage: "istio.io/api/meta/v1alpha1"
 
+  - kind: "RateLimit"
+    plural: "ratelimits"
+    group: "ratelimit.istio.io"
+    version: "v1alpha1"
+    proto: "istio.ratelimit.v1alpha1.RateLimit"
+    protoPackage: "istio.io/istio/pkg/config/apis/ratelimit/v1alpha1"
+    description: "describes the rate limits applied by sidecars and gateways."
+    statusProto: "istio.meta.v1alpha1.IstioStatus"
+    statusProtoPackage: "istio.io/api/meta/v1alpha1"
+
   - kind: "PeerAuthentication"
     plural: "peerauthentications"
     group: "security.istio.io"
@@ -559,6 +579,7 @@ transforms:
       "k8s/networking.istio.io/v1alpha3/workloadgroups": "istio/networking/v1alpha3/workloadgroups"
       "k8s/networking.istio.io/v1alpha3/sidecars": "istio/networking/v1alpha3/sidecars"
       "k8s/networking.istio.io/v1alpha3/virtualservices": "istio/networking/v1alpha3/virtualservices"
+      "k8s/ratelimit.istio.io/v1alpha1/ratelimits": "istio/ratelimit/v1alpha1/ratelimits"
       "k8s/security.istio.io/v1beta1/authorizationpolicies": "istio/security/v1beta1/authorizationpolicies"
       "k8s/security.istio.io/v1beta1/requestauthentications": "istio/security/v1beta1/requestauthentications"
       "k8s/security.istio.io/v1beta1/peerauthentications": "istio/security/v1beta1/peerauthentications"
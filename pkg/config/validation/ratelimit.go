@@ -0,0 +1,129 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	type_beta "istio.io/api/type/v1beta1"
+	"istio.io/istio/pkg/config"
+	ratelimit "istio.io/istio/pkg/config/apis/ratelimit/v1alpha1"
+)
+
+// minFillInterval is the smallest token bucket fill interval accepted by Envoy
+const minFillInterval = 50 * time.Millisecond
+
+// ValidateRateLimit checks that a RateLimit is well-formed.
+var ValidateRateLimit = registerValidateFunc("ValidateRateLimit",
+	func(cfg config.Config) (Warning, error) {
+		rl, ok := cfg.Spec.(*ratelimit.RateLimit)
+		if !ok {
+			return nil, fmt.Errorf("cannot cast to rate limit")
+		}
+
+		var errs error
+		if rl.Local == nil && rl.Global == nil {
+			errs = appendErrors(errs, errors.New("at least one of local or global must be set"))
+		}
+		if rl.WorkloadSelector != nil {
+			errs = appendErrors(errs, validateWorkloadSelector(&type_beta.WorkloadSelector{MatchLabels: rl.WorkloadSelector.MatchLabels}))
+		}
+		for _, h := range rl.Hosts {
+			if err := ValidateWildcardDomain(h); err != nil {
+				errs = appendErrors(errs, fmt.Errorf("invalid host: %v", err))
+			}
+		}
+		errs = appendErrors(errs, validateLocalRateLimit(rl.Local), validateGlobalRateLimit(rl.Global))
+		return nil, errs
+	})
+
+func validateLocalRateLimit(local *ratelimit.LocalRateLimit) (errs error) {
+	if local == nil {
+		return nil
+	}
+	if local.MaxTokens == 0 {
+		errs = appendErrors(errs, errors.New("local: maxTokens must be greater than 0"))
+	}
+	if local.TokensPerFill > local.MaxTokens {
+		errs = appendErrors(errs, fmt.Errorf("local: tokensPerFill %d cannot exceed maxTokens %d", local.TokensPerFill, local.MaxTokens))
+	}
+	interval, err := time.ParseDuration(local.FillInterval)
+	if err != nil {
+		errs = appendErrors(errs, fmt.Errorf("local: invalid fillInterval: %v", err))
+	} else if interval < minFillInterval {
+		errs = appendErrors(errs, fmt.Errorf("local: fillInterval must be at least %v", minFillInterval))
+	}
+	return
+}
+
+func validateGlobalRateLimit(global *ratelimit.GlobalRateLimit) (errs error) {
+	if global == nil {
+		return nil
+	}
+	if err := ValidateFQDN(global.Service); err != nil {
+		errs = appendErrors(errs, fmt.Errorf("global: invalid service: %v", err))
+	}
+	errs = appendErrors(errs, ValidatePort(int(global.Port)))
+	if global.Domain == "" {
+		errs = appendErrors(errs, errors.New("global: domain must be set"))
+	}
+	if global.Timeout != "" {
+		timeout, err := time.ParseDuration(global.Timeout)
+		if err != nil {
+			errs = appendErrors(errs, fmt.Errorf("global: invalid timeout: %v", err))
+		} else if timeout < time.Millisecond {
+			errs = appendErrors(errs, errors.New("global: timeout must be at least 1ms"))
+		}
+	}
+	if len(global.Descriptors) == 0 {
+		errs = appendErrors(errs, errors.New("global: at least one descriptor must be set"))
+	}
+	for i, d := range global.Descriptors {
+		if len(d.Entries) == 0 {
+			errs = appendErrors(errs, fmt.Errorf("global: descriptor %d has no entries", i))
+		}
+		for j, e := range d.Entries {
+			if err := validateDescriptorEntry(e); err != nil {
+				errs = appendErrors(errs, fmt.Errorf("global: descriptor %d entry %d: %v", i, j, err))
+			}
+		}
+	}
+	return
+}
+
+func validateDescriptorEntry(e *ratelimit.DescriptorEntry) error {
+	set := 0
+	if e.GenericKey != "" {
+		set++
+	}
+	if e.RemoteAddress {
+		set++
+	}
+	if e.RequestHeader != nil {
+		set++
+		if err := ValidateHTTPHeaderName(e.RequestHeader.Name); err != nil {
+			return err
+		}
+		if e.RequestHeader.DescriptorKey == "" {
+			return errors.New("requestHeader.descriptorKey must be set")
+		}
+	}
+	if set != 1 {
+		return errors.New("exactly one of genericKey, requestHeader or remoteAddress must be set")
+	}
+	return nil
+}
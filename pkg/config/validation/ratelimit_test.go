@@ -0,0 +1,141 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"testing"
+
+	"istio.io/istio/pkg/config"
+	ratelimit "istio.io/istio/pkg/config/apis/ratelimit/v1alpha1"
+)
+
+func TestValidateRateLimit(t *testing.T) {
+	validLocal := &ratelimit.LocalRateLimit{MaxTokens: 10, TokensPerFill: 5, FillInterval: "1s"}
+	validGlobal := func(entries ...*ratelimit.DescriptorEntry) *ratelimit.GlobalRateLimit {
+		return &ratelimit.GlobalRateLimit{
+			Service:     "ratelimit.istio-system.svc.cluster.local",
+			Port:        8081,
+			Domain:      "test",
+			Descriptors: []*ratelimit.Descriptor{{Entries: entries}},
+		}
+	}
+	cases := []struct {
+		name  string
+		in    *ratelimit.RateLimit
+		error string
+	}{
+		{
+			name:  "empty",
+			in:    &ratelimit.RateLimit{},
+			error: "at least one of local or global must be set",
+		},
+		{
+			name: "valid local",
+			in:   &ratelimit.RateLimit{Hosts: []string{"*.example.com", "reviews.default.svc.cluster.local"}, Local: validLocal},
+		},
+		{
+			name:  "invalid host",
+			in:    &ratelimit.RateLimit{Hosts: []string{"foo.*.com"}, Local: validLocal},
+			error: "invalid host",
+		},
+		{
+			name: "invalid selector",
+			in: &ratelimit.RateLimit{
+				WorkloadSelector: &ratelimit.WorkloadSelector{MatchLabels: map[string]string{"app": "*"}},
+				Local:            validLocal,
+			},
+			error: "wildcard is not supported in selector",
+		},
+		{
+			name:  "local without tokens",
+			in:    &ratelimit.RateLimit{Local: &ratelimit.LocalRateLimit{FillInterval: "1s"}},
+			error: "maxTokens must be greater than 0",
+		},
+		{
+			name:  "local tokens per fill over max",
+			in:    &ratelimit.RateLimit{Local: &ratelimit.LocalRateLimit{MaxTokens: 1, TokensPerFill: 2, FillInterval: "1s"}},
+			error: "cannot exceed maxTokens",
+		},
+		{
+			name:  "local invalid interval",
+			in:    &ratelimit.RateLimit{Local: &ratelimit.LocalRateLimit{MaxTokens: 1, FillInterval: "foo"}},
+			error: "invalid fillInterval",
+		},
+		{
+			name:  "local interval too short",
+			in:    &ratelimit.RateLimit{Local: &ratelimit.LocalRateLimit{MaxTokens: 1, FillInterval: "10ms"}},
+			error: "fillInterval must be at least",
+		},
+		{
+			name: "valid global",
+			in: &ratelimit.RateLimit{Global: validGlobal(
+				&ratelimit.DescriptorEntry{GenericKey: "foo"},
+				&ratelimit.DescriptorEntry{RemoteAddress: true},
+				&ratelimit.DescriptorEntry{RequestHeader: &ratelimit.RequestHeaderDescriptor{Name: "x-user", DescriptorKey: "user"}},
+			)},
+		},
+		{
+			name: "global invalid service",
+			in: &ratelimit.RateLimit{Global: &ratelimit.GlobalRateLimit{
+				Service:     "not a host",
+				Port:        8081,
+				Domain:      "test",
+				Descriptors: []*ratelimit.Descriptor{{Entries: []*ratelimit.DescriptorEntry{{GenericKey: "foo"}}}},
+			}},
+			error: "invalid service",
+		},
+		{
+			name: "global without descriptors",
+			in: &ratelimit.RateLimit{Global: &ratelimit.GlobalRateLimit{
+				Service: "ratelimit.istio-system.svc.cluster.local",
+				Port:    8081,
+				Domain:  "test",
+			}},
+			error: "at least one descriptor must be set",
+		},
+		{
+			name: "global invalid timeout",
+			in: &ratelimit.RateLimit{Global: &ratelimit.GlobalRateLimit{
+				Service:     "ratelimit.istio-system.svc.cluster.local",
+				Port:        8081,
+				Domain:      "test",
+				Timeout:     "1us",
+				Descriptors: []*ratelimit.Descriptor{{Entries: []*ratelimit.DescriptorEntry{{GenericKey: "foo"}}}},
+			}},
+			error: "timeout must be at least 1ms",
+		},
+		{
+			name:  "global empty entry",
+			in:    &ratelimit.RateLimit{Global: validGlobal(&ratelimit.DescriptorEntry{})},
+			error: "exactly one of genericKey, requestHeader or remoteAddress must be set",
+		},
+		{
+			name:  "global entry with two fields",
+			in:    &ratelimit.RateLimit{Global: validGlobal(&ratelimit.DescriptorEntry{GenericKey: "foo", RemoteAddress: true})},
+			error: "exactly one of genericKey, requestHeader or remoteAddress must be set",
+		},
+		{
+			name:  "global header without descriptor key",
+			in:    &ratelimit.RateLimit{Global: validGlobal(&ratelimit.DescriptorEntry{RequestHeader: &ratelimit.RequestHeaderDescriptor{Name: "x-user"}})},
+			error: "descriptorKey must be set",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			warn, err := ValidateRateLimit(config.Config{Spec: tc.in})
+			checkValidationMessage(t, warn, err, "", tc.error)
+		})
+	}
+}
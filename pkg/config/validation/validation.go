@@ -1512,6 +1512,16 @@ var ValidateAuthorizationPolicy = registerValidateFunc("ValidateAuthorizationPol
 			errs = appendErrors(errs, err)
 		}
 
+		if value, ok := cfg.Annotations[constants.AuthorizationPolicyDryRunAnnotation]; ok {
+			if dryRun, err := strconv.ParseBool(value); err != nil {
+				errs = appendErrors(errs, fmt.Errorf("invalid annotation %s: %q, must be true or false",
+					constants.AuthorizationPolicyDryRunAnnotation, value))
+			} else if dryRun && in.Action == security_beta.AuthorizationPolicy_CUSTOM {
+				errs = appendErrors(errs, fmt.Errorf("annotation %s is not supported with CUSTOM action",
+					constants.AuthorizationPolicyDryRunAnnotation))
+			}
+		}
+
 		if in.Action == security_beta.AuthorizationPolicy_CUSTOM {
 			if in.Rules == nil {
 				errs = appendErrors(errs, fmt.Errorf("CUSTOM action without `rules` is meaningless as it will never be triggered, "+
@@ -3734,10 +3734,43 @@ func TestValidateServiceEntries(t *testing.T) {
 
 func TestValidateAuthorizationPolicy(t *testing.T) {
 	cases := []struct {
-		name  string
-		in    proto.Message
-		valid bool
+		name        string
+		annotations map[string]string
+		in          proto.Message
+		valid       bool
 	}{
+		{
+			name:        "dry-run",
+			annotations: map[string]string{"istio.io/dry-run": "true"},
+			in: &security_beta.AuthorizationPolicy{
+				Action: security_beta.AuthorizationPolicy_DENY,
+				Rules:  []*security_beta.Rule{{}},
+			},
+			valid: true,
+		},
+		{
+			name:        "dry-run-invalid-value",
+			annotations: map[string]string{"istio.io/dry-run": "yes please"},
+			in: &security_beta.AuthorizationPolicy{
+				Action: security_beta.AuthorizationPolicy_DENY,
+				Rules:  []*security_beta.Rule{{}},
+			},
+			valid: false,
+		},
+		{
+			name:        "dry-run-with-custom-action",
+			annotations: map[string]string{"istio.io/dry-run": "true"},
+			in: &security_beta.AuthorizationPolicy{
+				Action: security_beta.AuthorizationPolicy_CUSTOM,
+				ActionDetail: &security_beta.AuthorizationPolicy_Provider{
+					Provider: &security_beta.AuthorizationPolicy_ExtensionProvider{
+						Name: "my-custom-authz",
+					},
+				},
+				Rules: []*security_beta.Rule{{}},
+			},
+			valid: false,
+		},
 		{
 			name: "good",
 			in: &security_beta.AuthorizationPolicy{
@@ -4511,12 +4544,13 @@ func TestValidateAuthorizationPolicy(t *testing.T) {
 	for _, c := range cases {
 		if _, got := ValidateAuthorizationPolicy(config.Config{
 			Meta: config.Meta{
-				Name:      "name",
-				Namespace: "namespace",
+				Name:        "name",
+				Namespace:   "namespace",
+				Annotations: c.annotations,
 			},
 			Spec: c.in,
 		}); (got == nil) != c.valid {
-			t.Errorf("got: %v\nwant: %v", got, c.valid)
+			t.Errorf("%s: got: %v\nwant: %v", c.name, got, c.valid)
 		}
 	}
 }
@@ -32,9 +32,11 @@ import (
 	"istio.io/istio/security/pkg/nodeagent/cache"
 	citadel "istio.io/istio/security/pkg/nodeagent/caclient/providers/citadel"
 	gca "istio.io/istio/security/pkg/nodeagent/caclient/providers/google"
+	vaultca "istio.io/istio/security/pkg/nodeagent/caclient/providers/vault"
 	"istio.io/istio/security/pkg/nodeagent/plugin"
 	"istio.io/istio/security/pkg/nodeagent/sds"
 	"istio.io/istio/security/pkg/nodeagent/secretfetcher"
+	"istio.io/istio/security/pkg/pki/vault"
 	"istio.io/pkg/log"
 )
 
@@ -256,6 +258,44 @@ func (sa *Agent) Start(isSidecar bool, podNamespace string) (*sds.Server, error)
 	return server, nil
 }
 
+// istiodSignedSecrets returns the cache of the workload secrets if the workload certificates are signed
+// by Istiod, or nil otherwise.
+func (sa *Agent) istiodSignedSecrets() *cache.SecretCache {
+	sc, ok := sa.WorkloadSecrets.(*cache.SecretCache)
+	if !ok || sa.secOpts.FileMountedCerts || sa.secOpts.CAProviderName != "Citadel" ||
+		strings.Contains(sa.secOpts.CAEndpoint, "googleapis.com") {
+		return nil
+	}
+	return sc
+}
+
+// crlUpdater returns the function updating the certificate revocation list served with the root cert,
+// or nil if the workload certificates are not signed by Istiod.
+func (sa *Agent) crlUpdater() func([]byte) {
+	if sc := sa.istiodSignedSecrets(); sc != nil {
+		return sc.UpdateCRL
+	}
+	return nil
+}
+
+// trustBundleUpdater returns the function updating the root certs served over SDS with the ones trusted
+// by Istiod, or nil if the workload certificates are not signed by Istiod.
+func (sa *Agent) trustBundleUpdater() func([]byte) {
+	if sc := sa.istiodSignedSecrets(); sc != nil {
+		return sc.UpdateRootCert
+	}
+	return nil
+}
+
+// federatedTrustBundleUpdater returns the function updating the root certs of the federated trust domains
+// served over SDS, or nil if the workload certificates are not signed by Istiod.
+func (sa *Agent) federatedTrustBundleUpdater() func(map[string][]byte) {
+	if sc := sa.istiodSignedSecrets(); sc != nil {
+		return sc.UpdateFederatedRootCerts
+	}
+	return nil
+}
+
 func (sa *Agent) initLocalDNSServer(isSidecar bool) (err error) {
 	// we dont need dns server on gateways
 	if sa.cfg.DNSCapture && sa.cfg.ProxyXDSViaAgent && isSidecar {
@@ -375,6 +415,27 @@ func (sa *Agent) newWorkloadSecretCache() (workloadSecretCache *cache.SecretCach
 		// used.
 		caClient, err = gca.NewGoogleCAClient(sa.secOpts.CAEndpoint, true)
 		pluginNames = []string{plugin.GoogleTokenExchange}
+	} else if sa.secOpts.CAProviderName == "VaultCA" {
+		// Vault PKI secrets engine. With the kubernetes auth method, the agent logs in with the K8S JWT token
+		// of the workload.
+		var vaultRootCert []byte
+		if sa.secOpts.VaultTLSRootCert != "" {
+			if vaultRootCert, err = ioutil.ReadFile(sa.secOpts.VaultTLSRootCert); err != nil {
+				log.Fatalf("invalid config - failed to read the Vault TLS root certificate %s: %v",
+					sa.secOpts.VaultTLSRootCert, err)
+			}
+		}
+		caClient, err = vaultca.NewVaultCAClient(vault.Config{
+			Address:      sa.secOpts.VaultAddress,
+			TLSRootCert:  vaultRootCert,
+			AuthMethod:   vault.AuthMethod(sa.secOpts.VaultAuthMethod),
+			AuthPath:     sa.secOpts.VaultAuthPath,
+			Role:         sa.secOpts.VaultRole,
+			JWTPath:      sa.secOpts.JWTPath,
+			RoleID:       sa.secOpts.VaultRoleID,
+			SecretIDPath: sa.secOpts.VaultSecretIDPath,
+			SignCSRPath:  sa.secOpts.VaultSignCsrPath,
+		})
 	} else {
 		var rootCert []byte
 		// Special case: if Istiod runs on a secure network, on the default port, don't use TLS
@@ -18,7 +18,6 @@ import (
 	"context"
 	"crypto/tls"
 	"crypto/x509"
-	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -30,8 +29,10 @@ import (
 	"sync"
 	"time"
 
+	auth "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
 	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
 	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/any"
 	"golang.org/x/oauth2"
 	google_rpc "google.golang.org/genproto/googleapis/rpc/status"
 	"google.golang.org/grpc"
@@ -83,6 +84,16 @@ type XdsProxy struct {
 	healthChecker        *health.WorkloadHealthChecker
 	xdsHeaders           map[string]string
 
+	// crlUpdater updates the certificate revocation list served by SDS. It is nil if the
+	// workload certificates are not signed by Istiod.
+	crlUpdater func(crl []byte)
+	// trustBundleUpdater updates the root certs served by SDS. It is nil if the workload certificates
+	// are not signed by Istiod.
+	trustBundleUpdater func(rootCert []byte)
+	// federatedTrustBundleUpdater updates the root certs of the federated trust domains served by SDS. It
+	// is nil if the workload certificates are not signed by Istiod.
+	federatedTrustBundleUpdater func(rootCerts map[string][]byte)
+
 	// connected stores the active gRPC stream. The proxy will only have 1 connection at a time
 	connected      *ProxyConnection
 	connectedMutex sync.RWMutex
@@ -93,12 +104,15 @@ var proxyLog = log.RegisterScope("xdsproxy", "XDS Proxy in Istio Agent", 0)
 func initXdsProxy(ia *Agent) (*XdsProxy, error) {
 	var err error
 	proxy := &XdsProxy{
-		istiodAddress:  ia.proxyConfig.DiscoveryAddress,
-		clusterID:      ia.secOpts.ClusterID,
-		localDNSServer: ia.localDNSServer,
-		stopChan:       make(chan struct{}),
-		healthChecker:  health.NewWorkloadHealthChecker(ia.proxyConfig.ReadinessProbe),
-		xdsHeaders:     ia.cfg.XDSHeaders,
+		istiodAddress:               ia.proxyConfig.DiscoveryAddress,
+		clusterID:                   ia.secOpts.ClusterID,
+		localDNSServer:              ia.localDNSServer,
+		crlUpdater:                  ia.crlUpdater(),
+		trustBundleUpdater:          ia.trustBundleUpdater(),
+		federatedTrustBundleUpdater: ia.federatedTrustBundleUpdater(),
+		stopChan:                    make(chan struct{}),
+		healthChecker:               health.NewWorkloadHealthChecker(ia.proxyConfig.ReadinessProbe),
+		xdsHeaders:                  ia.cfg.XDSHeaders,
 	}
 
 	proxyLog.Infof("Initializing with upstream address %s and cluster %s", proxy.istiodAddress, proxy.clusterID)
@@ -142,6 +156,10 @@ func (p *XdsProxy) SendRequest(req *discovery.DiscoveryRequest) {
 	// TODO especially for health check purposes, we need a way to ensure the send succeeded. Otherwise,
 	// requests send to a disconnecting proxy will be permanently dropped.
 	if p.connected != nil {
+		if p.connected.downstreamDeltas != nil {
+			p.connected.deltaRequestsChan <- sotwToDeltaRequest(req)
+			return
+		}
 		p.connected.requestsChan <- req
 	}
 }
@@ -163,6 +181,12 @@ type ProxyConnection struct {
 	stopChan        chan struct{}
 	downstream      discovery.AggregatedDiscoveryService_StreamAggregatedResourcesServer
 	upstream        discovery.AggregatedDiscoveryService_StreamAggregatedResourcesClient
+
+	// Set instead of the above for incremental (Delta) XDS connections.
+	deltaRequestsChan  chan *discovery.DeltaDiscoveryRequest
+	deltaResponsesChan chan *discovery.DeltaDiscoveryResponse
+	downstreamDeltas   discovery.AggregatedDiscoveryService_DeltaAggregatedResourcesServer
+	upstreamDeltas     discovery.AggregatedDiscoveryService_DeltaAggregatedResourcesClient
 }
 
 // Every time envoy makes a fresh connection to the agent, we reestablish a new connection to the upstream xds
@@ -183,7 +207,7 @@ func (p *XdsProxy) StreamAggregatedResources(downstream discovery.AggregatedDisc
 	p.RegisterStream(con)
 
 	// Handle downstream xds
-	firstNDSSent := false
+	firstAgentRequestsSent := false
 	go func() {
 		for {
 			// From Envoy
@@ -194,33 +218,61 @@ func (p *XdsProxy) StreamAggregatedResources(downstream discovery.AggregatedDisc
 			}
 			// forward to istiod
 			con.requestsChan <- req
-			if p.localDNSServer != nil && !firstNDSSent && req.TypeUrl == v3.ListenerType {
-				// fire off an initial NDS request
-				con.requestsChan <- &discovery.DiscoveryRequest{
-					TypeUrl: v3.NameTableType,
+			if !firstAgentRequestsSent && req.TypeUrl == v3.ListenerType {
+				if p.localDNSServer != nil {
+					// fire off an initial NDS request
+					con.requestsChan <- &discovery.DiscoveryRequest{
+						TypeUrl: v3.NameTableType,
+					}
+				}
+				if p.crlUpdater != nil {
+					// fire off an initial CRL request
+					con.requestsChan <- &discovery.DiscoveryRequest{
+						TypeUrl: v3.CRLType,
+					}
+				}
+				if p.trustBundleUpdater != nil {
+					// fire off an initial trust bundle request
+					con.requestsChan <- &discovery.DiscoveryRequest{
+						TypeUrl: v3.TrustBundleType,
+					}
 				}
-				firstNDSSent = true
+				firstAgentRequestsSent = true
 			}
 		}
 	}()
 
+	upstreamConn, err := p.dialUpstream()
+	if err != nil {
+		return err
+	}
+	defer upstreamConn.Close()
+
+	xds := discovery.NewAggregatedDiscoveryServiceClient(upstreamConn)
+	// We must propagate upstream termination to Envoy. This ensures that we resume the full XDS sequence on new connection
+	return p.HandleUpstream(p.upstreamContext(), con, xds)
+}
+
+// dialUpstream establishes a new connection to istiod.
+func (p *XdsProxy) dialUpstream() (*grpc.ClientConn, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
 	defer cancel()
 	upstreamConn, err := grpc.DialContext(ctx, p.istiodAddress, p.istiodDialOptions...)
 	if err != nil {
 		proxyLog.Errorf("failed to connect to upstream %s: %v", p.istiodAddress, err)
 		metrics.IstiodConnectionFailures.Increment()
-		return err
+		return nil, err
 	}
-	defer upstreamConn.Close()
+	return upstreamConn, nil
+}
 
-	xds := discovery.NewAggregatedDiscoveryServiceClient(upstreamConn)
-	ctx = metadata.AppendToOutgoingContext(context.Background(), "ClusterID", p.clusterID)
+// upstreamContext returns the context for upstream streams, carrying the cluster ID and XDS headers.
+func (p *XdsProxy) upstreamContext() context.Context {
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "ClusterID", p.clusterID)
 	for k, v := range p.xdsHeaders {
 		ctx = metadata.AppendToOutgoingContext(ctx, k, v)
 	}
-	// We must propagate upstream termination to Envoy. This ensures that we resume the full XDS sequence on new connection
-	return p.HandleUpstream(ctx, con, xds)
+	return ctx
 }
 
 func (p *XdsProxy) HandleUpstream(ctx context.Context, con *ProxyConnection, xds discovery.AggregatedDiscoveryServiceClient) error {
@@ -251,6 +303,11 @@ func (p *XdsProxy) HandleUpstream(ctx context.Context, con *ProxyConnection, xds
 	go p.handleUpstreamRequest(ctx, con)
 	go p.handleUpstreamResponse(con)
 
+	return p.handleStreamErrors(con)
+}
+
+// handleStreamErrors blocks until either side of the proxied stream fails, or the connection is stopped.
+func (p *XdsProxy) handleStreamErrors(con *ProxyConnection) error {
 	for {
 		select {
 		case err := <-con.upstreamError:
@@ -323,6 +380,28 @@ func (p *XdsProxy) handleUpstreamResponse(con *ProxyConnection) {
 					TypeUrl:       v3.NameTableType,
 					ResponseNonce: resp.Nonce,
 				}
+			case v3.CRLType:
+				// intercept. This is for the SDS server
+				if len(resp.Resources) > 0 {
+					p.updateCRL(resp.Resources[0])
+				}
+
+				// Send ACK
+				con.requestsChan <- &discovery.DiscoveryRequest{
+					VersionInfo:   resp.VersionInfo,
+					TypeUrl:       v3.CRLType,
+					ResponseNonce: resp.Nonce,
+				}
+			case v3.TrustBundleType:
+				// intercept. This is for the SDS server
+				p.updateTrustBundle(resp.Resources)
+
+				// Send ACK
+				con.requestsChan <- &discovery.DiscoveryRequest{
+					VersionInfo:   resp.VersionInfo,
+					TypeUrl:       v3.TrustBundleType,
+					ResponseNonce: resp.Nonce,
+				}
 			default:
 				// TODO: Validate the known type urls before forwarding them to Envoy.
 				if err := sendDownstreamWithTimeout(con.downstream, resp); err != nil {
@@ -340,8 +419,55 @@ func (p *XdsProxy) handleUpstreamResponse(con *ProxyConnection) {
 	}
 }
 
-func (p *XdsProxy) DeltaAggregatedResources(server discovery.AggregatedDiscoveryService_DeltaAggregatedResourcesServer) error {
-	return errors.New("delta XDS is not implemented")
+// updateCRL updates the certificate revocation list served by SDS from the CRL resource sent by istiod.
+func (p *XdsProxy) updateCRL(resource *any.Any) {
+	if p.crlUpdater == nil {
+		return
+	}
+	secret := &auth.Secret{}
+	if err := ptypes.UnmarshalAny(resource, secret); err != nil {
+		proxyLog.Errorf("failed to unmarshal the certificate revocation list: %v", err)
+		return
+	}
+	// The root cert is pushed to Envoy asynchronously, so the XDS responses are not held up.
+	go p.crlUpdater(secret.GetValidationContext().GetCrl().GetInlineBytes())
+}
+
+// updateTrustBundle updates the root certs served by SDS from the trust bundle resources sent by istiod.
+// The root certs of the CA and of each federated trust domain are sent in separate resources, and served
+// in separate validation contexts.
+func (p *XdsProxy) updateTrustBundle(resources []*any.Any) {
+	if p.trustBundleUpdater == nil || len(resources) == 0 {
+		return
+	}
+	var rootCert []byte
+	federated := map[string][]byte{}
+	for _, resource := range resources {
+		secret := &auth.Secret{}
+		if err := ptypes.UnmarshalAny(resource, secret); err != nil {
+			proxyLog.Errorf("failed to unmarshal the trust bundle: %v", err)
+			return
+		}
+		roots := secret.GetValidationContext().GetTrustedCa().GetInlineBytes()
+		switch {
+		case secret.Name == v3.TrustBundleResourceName:
+			rootCert = roots
+		case strings.HasPrefix(secret.Name, v3.FederatedTrustBundleResourceNamePrefix):
+			federated[strings.TrimPrefix(secret.Name, v3.FederatedTrustBundleResourceNamePrefix)] = roots
+		default:
+			proxyLog.Warnf("ignoring unknown trust bundle %s", secret.Name)
+		}
+	}
+	if len(rootCert) == 0 {
+		return
+	}
+	// The root certs are pushed to Envoy asynchronously, so the XDS responses are not held up.
+	go func() {
+		p.trustBundleUpdater(rootCert)
+		if p.federatedTrustBundleUpdater != nil {
+			p.federatedTrustBundleUpdater(federated)
+		}
+	}()
 }
 
 func (p *XdsProxy) close() {
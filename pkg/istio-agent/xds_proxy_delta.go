@@ -0,0 +1,232 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package istioagent
+
+import (
+	"context"
+
+	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/any"
+	"google.golang.org/grpc"
+
+	nds "istio.io/istio/pilot/pkg/proto"
+	v3 "istio.io/istio/pilot/pkg/xds/v3"
+	"istio.io/istio/pkg/istio-agent/metrics"
+	"istio.io/pkg/log"
+)
+
+// DeltaAggregatedResources proxies incremental (Delta) XDS streams from envoy to istiod.
+// As for state of the world streams, every new connection from envoy gets a new connection to the upstream xds.
+func (p *XdsProxy) DeltaAggregatedResources(downstream discovery.AggregatedDiscoveryService_DeltaAggregatedResourcesServer) error {
+	proxyLog.Infof("Envoy delta ADS stream established")
+
+	con := &ProxyConnection{
+		upstreamError:      make(chan error, 2), // can be produced by recv and send
+		downstreamError:    make(chan error, 2), // can be produced by recv and send
+		deltaRequestsChan:  make(chan *discovery.DeltaDiscoveryRequest, 10),
+		deltaResponsesChan: make(chan *discovery.DeltaDiscoveryResponse, 10),
+		stopChan:           make(chan struct{}),
+		downstreamDeltas:   downstream,
+	}
+
+	p.RegisterStream(con)
+
+	// Handle downstream xds
+	firstAgentRequestsSent := false
+	go func() {
+		for {
+			// From Envoy
+			req, err := downstream.Recv()
+			if err != nil {
+				con.downstreamError <- err
+				return
+			}
+			// forward to istiod
+			con.deltaRequestsChan <- req
+			if !firstAgentRequestsSent && req.TypeUrl == v3.ListenerType {
+				if p.localDNSServer != nil {
+					// fire off an initial NDS request
+					con.deltaRequestsChan <- &discovery.DeltaDiscoveryRequest{
+						TypeUrl: v3.NameTableType,
+					}
+				}
+				if p.crlUpdater != nil {
+					// fire off an initial CRL request
+					con.deltaRequestsChan <- &discovery.DeltaDiscoveryRequest{
+						TypeUrl: v3.CRLType,
+					}
+				}
+				if p.trustBundleUpdater != nil {
+					// fire off an initial trust bundle request
+					con.deltaRequestsChan <- &discovery.DeltaDiscoveryRequest{
+						TypeUrl: v3.TrustBundleType,
+					}
+				}
+				firstAgentRequestsSent = true
+			}
+		}
+	}()
+
+	upstreamConn, err := p.dialUpstream()
+	if err != nil {
+		return err
+	}
+	defer upstreamConn.Close()
+
+	xds := discovery.NewAggregatedDiscoveryServiceClient(upstreamConn)
+	// We must propagate upstream termination to Envoy. This ensures that we resume the full XDS sequence on new connection
+	return p.HandleDeltaUpstream(p.upstreamContext(), con, xds)
+}
+
+func (p *XdsProxy) HandleDeltaUpstream(ctx context.Context, con *ProxyConnection, xds discovery.AggregatedDiscoveryServiceClient) error {
+	proxyLog.Infof("connecting to upstream delta XDS server: %s", p.istiodAddress)
+	defer proxyLog.Infof("disconnected from delta XDS server: %s", p.istiodAddress)
+	upstream, err := xds.DeltaAggregatedResources(ctx,
+		grpc.MaxCallRecvMsgSize(defaultClientMaxReceiveMessageSize))
+	if err != nil {
+		proxyLog.Errorf("failed to create upstream delta grpc client: %v", err)
+		return err
+	}
+
+	con.upstreamDeltas = upstream
+
+	// Handle upstream xds recv
+	go func() {
+		for {
+			// from istiod
+			resp, err := upstream.Recv()
+			if err != nil {
+				con.upstreamError <- err
+				return
+			}
+			con.deltaResponsesChan <- resp
+		}
+	}()
+
+	go p.handleUpstreamDeltaRequest(ctx, con)
+	go p.handleUpstreamDeltaResponse(con)
+
+	return p.handleStreamErrors(con)
+}
+
+func (p *XdsProxy) handleUpstreamDeltaRequest(ctx context.Context, con *ProxyConnection) {
+	defer con.upstreamDeltas.CloseSend() // nolint
+	for {
+		select {
+		case req := <-con.deltaRequestsChan:
+			proxyLog.Debugf("delta request for type url %s", req.TypeUrl)
+			metrics.XdsProxyRequests.Increment()
+			if err := sendUpstreamDeltaWithTimeout(ctx, con.upstreamDeltas, req); err != nil {
+				proxyLog.Errorf("upstream send error for type url %s: %v", req.TypeUrl, err)
+				con.upstreamError <- err
+				return
+			}
+		case <-con.stopChan:
+			return
+		}
+	}
+}
+
+func (p *XdsProxy) handleUpstreamDeltaResponse(con *ProxyConnection) {
+	for {
+		select {
+		case resp := <-con.deltaResponsesChan:
+			proxyLog.Debugf("delta response for type url %s", resp.TypeUrl)
+			metrics.XdsProxyResponses.Increment()
+			switch resp.TypeUrl {
+			case v3.NameTableType:
+				// intercept. This is for the dns server
+				if p.localDNSServer != nil && len(resp.Resources) > 0 {
+					var nt nds.NameTable
+					if err := ptypes.UnmarshalAny(resp.Resources[0].Resource, &nt); err != nil {
+						log.Errorf("failed to unmarshall name table: %v", err)
+					}
+					p.localDNSServer.UpdateLookupTable(&nt)
+				}
+
+				// Send ACK
+				con.deltaRequestsChan <- &discovery.DeltaDiscoveryRequest{
+					TypeUrl:       v3.NameTableType,
+					ResponseNonce: resp.Nonce,
+				}
+			case v3.CRLType:
+				// intercept. This is for the SDS server
+				if len(resp.Resources) > 0 {
+					p.updateCRL(resp.Resources[0].Resource)
+				}
+
+				// Send ACK
+				con.deltaRequestsChan <- &discovery.DeltaDiscoveryRequest{
+					TypeUrl:       v3.CRLType,
+					ResponseNonce: resp.Nonce,
+				}
+			case v3.TrustBundleType:
+				// intercept. This is for the SDS server
+				resources := make([]*any.Any, 0, len(resp.Resources))
+				for _, resource := range resp.Resources {
+					resources = append(resources, resource.Resource)
+				}
+				p.updateTrustBundle(resources)
+
+				// Send ACK
+				con.deltaRequestsChan <- &discovery.DeltaDiscoveryRequest{
+					TypeUrl:       v3.TrustBundleType,
+					ResponseNonce: resp.Nonce,
+				}
+			default:
+				if err := sendDownstreamDeltaWithTimeout(con.downstreamDeltas, resp); err != nil {
+					proxyLog.Errorf("downstream send error: %v", err)
+					// See handleUpstreamResponse: the best course of action is to terminate upstream connection
+					// as well and restart afresh.
+					con.downstreamError <- err
+					return
+				}
+			}
+		case <-con.stopChan:
+			return
+		}
+	}
+}
+
+// sotwToDeltaRequest converts a state of the world request generated by the agent, such as a health
+// update, so it can be sent on a delta stream.
+func sotwToDeltaRequest(req *discovery.DiscoveryRequest) *discovery.DeltaDiscoveryRequest {
+	return &discovery.DeltaDiscoveryRequest{
+		Node:                   req.Node,
+		TypeUrl:                req.TypeUrl,
+		ResourceNamesSubscribe: req.ResourceNames,
+		ResponseNonce:          req.ResponseNonce,
+		ErrorDetail:            req.ErrorDetail,
+	}
+}
+
+// sendUpstreamDeltaWithTimeout sends delta discovery request with default send timeout.
+func sendUpstreamDeltaWithTimeout(ctx context.Context, upstream discovery.AggregatedDiscoveryService_DeltaAggregatedResourcesClient,
+	request *discovery.DeltaDiscoveryRequest) error {
+	return sendWithTimeout(ctx, func(errChan chan error) {
+		errChan <- upstream.Send(request)
+		close(errChan)
+	})
+}
+
+// sendDownstreamDeltaWithTimeout sends delta discovery response with default send timeout.
+func sendDownstreamDeltaWithTimeout(downstream discovery.AggregatedDiscoveryService_DeltaAggregatedResourcesServer,
+	response *discovery.DeltaDiscoveryResponse) error {
+	return sendWithTimeout(context.Background(), func(errChan chan error) {
+		errChan <- downstream.Send(response)
+		close(errChan)
+	})
+}
@@ -43,6 +43,39 @@ func TestXdsProxyBasicFlow(t *testing.T) {
 	sendDownstream(t, downstream)
 }
 
+// Validates basic delta xds proxy flow by proxying one CDS request end to end.
+func TestXdsProxyDeltaFlow(t *testing.T) {
+	proxy := setupXdsProxy(t)
+	f := xds.NewFakeDiscoveryServer(t, xds.FakeOptions{})
+	setDialOptions(proxy, f.Listener)
+	conn := setupDownstreamConnection(t)
+	downstream, err := discovery.NewAggregatedDiscoveryServiceClient(conn).DeltaAggregatedResources(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = downstream.Send(&discovery.DeltaDiscoveryRequest{
+		TypeUrl: v3.ClusterType,
+		Node: &core.Node{
+			Id: "sidecar~0.0.0.0~debug~cluster.local",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := downstream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res == nil || res.TypeUrl != v3.ClusterType || len(res.Resources) == 0 {
+		t.Fatalf("Expected to get cluster response but got %v", res)
+	}
+	for _, r := range res.Resources {
+		if r.Name == "" || r.Version == "" {
+			t.Fatalf("Expected name and version to be set, got %v", r)
+		}
+	}
+}
+
 func setupXdsProxy(t *testing.T) *XdsProxy {
 	secOpts := &security.Options{
 		FileMountedCerts: true,
@@ -73,6 +73,9 @@ import (
 	istioclient "istio.io/client-go/pkg/clientset/versioned"
 	istiofake "istio.io/client-go/pkg/clientset/versioned/fake"
 	istioinformer "istio.io/client-go/pkg/informers/externalversions"
+	ratelimitclient "istio.io/istio/pkg/config/apis/ratelimit/client/clientset/versioned"
+	ratelimitfake "istio.io/istio/pkg/config/apis/ratelimit/client/clientset/versioned/fake"
+	ratelimitinformer "istio.io/istio/pkg/config/apis/ratelimit/client/informers/externalversions"
 	"istio.io/pkg/version"
 )
 
@@ -111,6 +114,9 @@ type Client interface {
 	// ServiceApis returns the service-apis kube client.
 	ServiceApis() serviceapisclient.Interface
 
+	// RateLimit returns the kube client of the RateLimit API.
+	RateLimit() ratelimitclient.Interface
+
 	// KubeInformer returns an informer for core kube client
 	KubeInformer() informers.SharedInformerFactory
 
@@ -126,6 +132,9 @@ type Client interface {
 	// ServiceApisInformer returns an informer for the service-apis client
 	ServiceApisInformer() serviceapisinformer.SharedInformerFactory
 
+	// RateLimitInformer returns an informer for the RateLimit client
+	RateLimitInformer() ratelimitinformer.SharedInformerFactory
+
 	// RunAndWait starts all informers and waits for their caches to sync.
 	// Warning: this must be called AFTER .Informer() is called, which will register the informer.
 	RunAndWait(stop <-chan struct{})
@@ -218,6 +227,10 @@ func NewFakeClient(objects ...runtime.Object) ExtendedClient {
 	c.serviceapis = serviceapisfake.NewSimpleClientset()
 	c.serviceapisInformers = serviceapisinformer.NewSharedInformerFactory(c.serviceapis, resyncInterval)
 
+	rateLimitFake := ratelimitfake.NewSimpleClientset()
+	c.ratelimit = rateLimitFake
+	c.ratelimitInformer = ratelimitinformer.NewSharedInformerFactory(c.ratelimit, resyncInterval)
+
 	c.extSet = extfake.NewSimpleClientset()
 
 	// https://github.com/kubernetes/kubernetes/issues/95372
@@ -248,6 +261,8 @@ func NewFakeClient(objects ...runtime.Object) ExtendedClient {
 	fakeClient.PrependWatchReactor("*", watchReactor(fakeClient.Tracker()))
 	istioFake.PrependReactor("list", "*", listReactor)
 	istioFake.PrependWatchReactor("*", watchReactor(istioFake.Tracker()))
+	rateLimitFake.PrependReactor("list", "*", listReactor)
+	rateLimitFake.PrependWatchReactor("*", watchReactor(rateLimitFake.Tracker()))
 	c.fastSync = true
 
 	return c
@@ -278,6 +293,9 @@ type client struct {
 	serviceapis          serviceapisclient.Interface
 	serviceapisInformers serviceapisinformer.SharedInformerFactory
 
+	ratelimit         ratelimitclient.Interface
+	ratelimitInformer ratelimitinformer.SharedInformerFactory
+
 	// If enable, will wait for cache syncs with extremely short delay. This should be used only for tests
 	fastSync               bool
 	informerWatchesPending *atomic.Int32
@@ -345,6 +363,12 @@ func newClientInternal(clientFactory util.Factory, revision string) (*client, er
 	}
 	c.serviceapisInformers = serviceapisinformer.NewSharedInformerFactory(c.serviceapis, resyncInterval)
 
+	c.ratelimit, err = ratelimitclient.NewForConfig(c.config)
+	if err != nil {
+		return nil, err
+	}
+	c.ratelimitInformer = ratelimitinformer.NewSharedInformerFactory(c.ratelimit, resyncInterval)
+
 	ext, err := kubeExtClient.NewForConfig(c.config)
 	if err != nil {
 		return nil, err
@@ -395,6 +419,10 @@ func (c *client) ServiceApis() serviceapisclient.Interface {
 	return c.serviceapis
 }
 
+func (c *client) RateLimit() ratelimitclient.Interface {
+	return c.ratelimit
+}
+
 func (c *client) KubeInformer() informers.SharedInformerFactory {
 	return c.kubeInformer
 }
@@ -415,6 +443,10 @@ func (c *client) ServiceApisInformer() serviceapisinformer.SharedInformerFactory
 	return c.serviceapisInformers
 }
 
+func (c *client) RateLimitInformer() ratelimitinformer.SharedInformerFactory {
+	return c.ratelimitInformer
+}
+
 // RunAndWait starts all informers and waits for their caches to sync.
 // Warning: this must be called AFTER .Informer() is called, which will register the informer.
 func (c *client) RunAndWait(stop <-chan struct{}) {
@@ -423,6 +455,7 @@ func (c *client) RunAndWait(stop <-chan struct{}) {
 	c.metadataInformer.Start(stop)
 	c.istioInformer.Start(stop)
 	c.serviceapisInformers.Start(stop)
+	c.ratelimitInformer.Start(stop)
 	if c.fastSync {
 		// WaitForCacheSync will virtually never be synced on the first call, as its called immediately after Start()
 		// This triggers a 100ms delay per call, which is often called 2-3 times in a test, delaying tests.
@@ -432,6 +465,7 @@ func (c *client) RunAndWait(stop <-chan struct{}) {
 		fastWaitForCacheSyncDynamic(c.metadataInformer)
 		fastWaitForCacheSync(c.istioInformer)
 		fastWaitForCacheSync(c.serviceapisInformers)
+		fastWaitForCacheSync(c.ratelimitInformer)
 		_ = wait.PollImmediate(time.Microsecond, wait.ForeverTestTimeout, func() (bool, error) {
 			if c.informerWatchesPending.Load() == 0 {
 				return true, nil
@@ -444,6 +478,7 @@ func (c *client) RunAndWait(stop <-chan struct{}) {
 		c.metadataInformer.WaitForCacheSync(stop)
 		c.istioInformer.WaitForCacheSync(stop)
 		c.serviceapisInformers.WaitForCacheSync(stop)
+		c.ratelimitInformer.WaitForCacheSync(stop)
 	}
 }
 
@@ -39,8 +39,10 @@ const (
 	DefaultRootCertFilePath = "./etc/certs/root-cert.pem"
 
 	// Credential fetcher type
-	GCE  = "GoogleComputeEngine"
-	Mock = "Mock" // testing only
+	GCE   = "GoogleComputeEngine"
+	AWS   = "AWS"
+	Azure = "Azure"
+	Mock  = "Mock" // testing only
 )
 
 // TODO: For 1.8, make sure MeshConfig is updated with those settings,
@@ -91,16 +93,25 @@ type Options struct {
 	// The Vault CA address.
 	VaultAddress string
 
+	// The Vault auth method, kubernetes or approle.
+	VaultAuthMethod string
+
 	// The Vault auth path.
 	VaultAuthPath string
 
 	// The Vault role.
 	VaultRole string
 
+	// The Vault AppRole role ID.
+	VaultRoleID string
+
+	// File containing the Vault AppRole secret ID.
+	VaultSecretIDPath string
+
 	// The Vault sign CSR path.
 	VaultSignCsrPath string
 
-	// The Vault TLS root certificate.
+	// File containing the Vault TLS root certificate.
 	VaultTLSRootCert string
 
 	// GrpcServer is an already configured (shared) grpc server. If set, the agent will just register on the server.
@@ -143,6 +154,9 @@ type Options struct {
 	// when generating private keys. Currently only ECDSA is supported.
 	ECCSigAlg string
 
+	// The elliptic curve to use when generating ECC private keys, P256 or P384.
+	ECCCurve string
+
 	// FileMountedCerts indicates whether the proxy is using file
 	// mounted certs created by a foreign CA. Refresh is managed by the external
 	// CA, by updating the Secret or VM file. We will watch the file for changes
@@ -233,6 +247,9 @@ type SecretItem struct {
 
 	RootCert []byte
 
+	// CRL is the PEM encoded certificate revocation list of the CA, validated with the root cert.
+	CRL []byte
+
 	// RootCertOwnedByCompoundSecret is true if this SecretItem was created by a
 	// K8S secret having both server cert/key and client ca and should be deleted
 	// with the secret.
@@ -259,7 +276,8 @@ type CredFetcher interface {
 	// GetPlatformCredential fetches workload credential provided by the platform.
 	GetPlatformCredential() (string, error)
 
-	// GetType returns credential fetcher type. Currently the supported type is "GoogleComputeEngine".
+	// GetType returns credential fetcher type. Currently the supported types are "GoogleComputeEngine",
+	// "AWS" and "Azure".
 	GetType() string
 
 	// The name of the IdentityProvider that can authenticate the workload credential.
@@ -0,0 +1,138 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spiffe
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/pem"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+)
+
+// FederatedBundles holds the root certs of federated trust domains, retrieved from their SPIFFE bundle
+// endpoints and refreshed periodically, so that workloads of a mesh with a different trust domain and
+// root can be authenticated.
+type FederatedBundles struct {
+	endpoints         map[string]string
+	extraTrustedCerts []*x509.Certificate
+
+	mutex     sync.RWMutex
+	rootCerts map[string][]*x509.Certificate
+	handlers  []func(trustDomain string, certs []*x509.Certificate)
+}
+
+// NewFederatedBundles returns the bundles of the trust domains retrieved from the given SPIFFE bundle
+// endpoints. The endpoints are validated with the system cert pool and the extra trusted certs.
+func NewFederatedBundles(endpoints map[string]string, extraTrustedCerts []*x509.Certificate) *FederatedBundles {
+	return &FederatedBundles{
+		endpoints:         endpoints,
+		extraTrustedCerts: extraTrustedCerts,
+		rootCerts:         map[string][]*x509.Certificate{},
+	}
+}
+
+// AddHandler adds a handler called when the root certs of a trust domain change.
+func (b *FederatedBundles) AddHandler(h func(trustDomain string, certs []*x509.Certificate)) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.handlers = append(b.handlers, h)
+}
+
+// Refresh retrieves the bundles of all the trust domains. The last retrieved root certs of a trust
+// domain are kept if its endpoint fails.
+func (b *FederatedBundles) Refresh() error {
+	var errs *multierror.Error
+	for trustDomain, endpoint := range b.endpoints {
+		certMap, err := RetrieveSpiffeBundleRootCerts(map[string]string{trustDomain: endpoint}, b.extraTrustedCerts)
+		if err != nil {
+			errs = multierror.Append(errs, err)
+			continue
+		}
+		b.update(trustDomain, certMap[trustDomain])
+	}
+	return errs.ErrorOrNil()
+}
+
+// Run refreshes the bundles at the given interval until stop is closed.
+func (b *FederatedBundles) Run(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := b.Refresh(); err != nil {
+				spiffeLog.Warnf("failed to refresh the SPIFFE bundles of the federated trust domains: %v", err)
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+func (b *FederatedBundles) update(trustDomain string, certs []*x509.Certificate) {
+	b.mutex.Lock()
+	if equalCerts(b.rootCerts[trustDomain], certs) {
+		b.mutex.Unlock()
+		return
+	}
+	b.rootCerts[trustDomain] = certs
+	handlers := b.handlers
+	b.mutex.Unlock()
+	spiffeLog.Infof("SPIFFE bundle of trust domain %s updated, containing %d certs", trustDomain, len(certs))
+	for _, h := range handlers {
+		h(trustDomain, certs)
+	}
+}
+
+// RootCerts returns the root certs of the federated trust domains.
+func (b *FederatedBundles) RootCerts() map[string][]*x509.Certificate {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	out := make(map[string][]*x509.Certificate, len(b.rootCerts))
+	for trustDomain, certs := range b.rootCerts {
+		out[trustDomain] = certs
+	}
+	return out
+}
+
+// RootCertPems returns the PEM encoded root certs of the federated trust domains, by trust domain.
+func (b *FederatedBundles) RootCertPems() map[string][]byte {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	out := make(map[string][]byte, len(b.rootCerts))
+	for trustDomain, certs := range b.rootCerts {
+		var pems []byte
+		for _, cert := range certs {
+			pems = append(pems, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})...)
+		}
+		out[trustDomain] = pems
+	}
+	return out
+}
+
+func equalCerts(a, b []*x509.Certificate) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !bytes.Equal(a[i].Raw, b[i].Raw) {
+			return false
+		}
+	}
+	return true
+}
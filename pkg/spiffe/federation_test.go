@@ -0,0 +1,68 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spiffe
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFederatedBundles(t *testing.T) {
+	totalRetryTimeout = time.Millisecond * 50
+	var status int32 = http.StatusOK
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(int(atomic.LoadInt32(&status)))
+		_, _ = w.Write([]byte(validSpiffeX509Bundle))
+	}))
+	defer server.Close()
+
+	bundles := NewFederatedBundles(map[string]string{"foo.domain.com": server.Listener.Addr().String()},
+		[]*x509.Certificate{server.Certificate()})
+	updates := 0
+	bundles.AddHandler(func(trustDomain string, certs []*x509.Certificate) {
+		updates++
+		if trustDomain != "foo.domain.com" || len(certs) != 1 {
+			t.Errorf("unexpected update of trust domain %s with %d certs", trustDomain, len(certs))
+		}
+	})
+
+	if err := bundles.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+	if err := bundles.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+	if updates != 1 {
+		t.Errorf("expected 1 update of the bundles, got %d", updates)
+	}
+	block, rest := pem.Decode(bundles.RootCertPems()["foo.domain.com"])
+	if block == nil || len(rest) != 0 {
+		t.Fatalf("expected 1 PEM encoded root cert, got %q", bundles.RootCertPems())
+	}
+
+	// The root certs are kept when the endpoint fails.
+	atomic.StoreInt32(&status, http.StatusServiceUnavailable)
+	if err := bundles.Refresh(); err == nil {
+		t.Errorf("expected the refresh to fail")
+	}
+	if certs := bundles.RootCerts()["foo.domain.com"]; len(certs) != 1 {
+		t.Errorf("expected the root certs to be kept, got %d certs", len(certs))
+	}
+}
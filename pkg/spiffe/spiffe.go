@@ -163,6 +163,17 @@ func GetTrustDomainFromURISAN(uriSan string) (string, error) {
 func RetrieveSpiffeBundleRootCertsFromStringInput(inputString string, extraTrustedCerts []*x509.Certificate) (
 	map[string][]*x509.Certificate, error) {
 	spiffeLog.Infof("Processing SPIFFE bundle configuration: %v", inputString)
+	config, err := ParseSpiffeBundleEndpoints(inputString)
+	if err != nil {
+		return nil, err
+	}
+	return RetrieveSpiffeBundleRootCerts(config, extraTrustedCerts)
+}
+
+// ParseSpiffeBundleEndpoints returns the SPIFFE bundle endpoints of the trust domains, from an input in
+// the format of:
+// "foo|URL1||bar|URL2||baz|URL3..."
+func ParseSpiffeBundleEndpoints(inputString string) (map[string]string, error) {
 	config := make(map[string]string)
 	tuples := strings.Split(inputString, "||")
 	for _, tuple := range tuples {
@@ -174,7 +185,7 @@ func RetrieveSpiffeBundleRootCertsFromStringInput(inputString string, extraTrust
 		endpoint := items[1]
 		config[trustDomain] = endpoint
 	}
-	return RetrieveSpiffeBundleRootCerts(config, extraTrustedCerts)
+	return config, nil
 }
 
 // RetrieveSpiffeBundleRootCerts retrieves the trusted CA certificates from a list of SPIFFE bundle endpoints.
@@ -271,6 +282,9 @@ func RetrieveSpiffeBundleRootCerts(config map[string]string, extraTrustedCerts [
 
 // PeerCertVerifier is an instance to verify the peer certificate in the SPIFFE way using the retrieved root certificates.
 type PeerCertVerifier struct {
+	// mutex protects the cert pools of the trust domains, which are updated when the SPIFFE bundles of
+	// federated trust domains are refreshed.
+	mutex           sync.RWMutex
 	generalCertPool *x509.CertPool
 	certPools       map[string]*x509.CertPool
 }
@@ -290,6 +304,8 @@ func (v *PeerCertVerifier) GetGeneralCertPool() *x509.CertPool {
 
 // AddMapping adds a new trust domain to certificates mapping to the certPools map.
 func (v *PeerCertVerifier) AddMapping(trustDomain string, certs []*x509.Certificate) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
 	if v.certPools[trustDomain] == nil {
 		v.certPools[trustDomain] = x509.NewCertPool()
 	}
@@ -328,6 +344,19 @@ func (v *PeerCertVerifier) AddMappings(certMap map[string][]*x509.Certificate) {
 	}
 }
 
+// SetMapping replaces the certificates of a trust domain, as when its SPIFFE bundle is refreshed. The
+// general cert pool is not updated, as it may be in use by TLS configs already.
+func (v *PeerCertVerifier) SetMapping(trustDomain string, certs []*x509.Certificate) {
+	pool := x509.NewCertPool()
+	for _, cert := range certs {
+		pool.AddCert(cert)
+	}
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	v.certPools[trustDomain] = pool
+	spiffeLog.Infof("Set %d certs for trust domain %s in peer cert verifier", len(certs), trustDomain)
+}
+
 // VerifyPeerCert is an implementation of tls.Config.VerifyPeerCertificate.
 // It verifies the peer certificate using the root certificates associated with its trust domain.
 func (v *PeerCertVerifier) VerifyPeerCert(rawCerts [][]byte, _ [][]*x509.Certificate) error {
@@ -355,7 +384,9 @@ func (v *PeerCertVerifier) VerifyPeerCert(rawCerts [][]byte, _ [][]*x509.Certifi
 	if err != nil {
 		return err
 	}
+	v.mutex.RLock()
 	rootCertPool, ok := v.certPools[trustDomain]
+	v.mutex.RUnlock()
 	if !ok {
 		return fmt.Errorf("no cert pool found for trust domain %s", trustDomain)
 	}
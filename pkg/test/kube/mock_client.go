@@ -39,6 +39,8 @@ import (
 
 	istioclient "istio.io/client-go/pkg/clientset/versioned"
 	istioinformer "istio.io/client-go/pkg/informers/externalversions"
+	ratelimitclient "istio.io/istio/pkg/config/apis/ratelimit/client/clientset/versioned"
+	ratelimitinformer "istio.io/istio/pkg/config/apis/ratelimit/client/informers/externalversions"
 	"istio.io/istio/pkg/kube"
 	"istio.io/pkg/version"
 )
@@ -84,6 +86,10 @@ func (c MockClient) ServiceApis() serviceapisclient.Interface {
 	panic("not used in mock")
 }
 
+func (c MockClient) RateLimit() ratelimitclient.Interface {
+	panic("not used in mock")
+}
+
 func (c MockClient) IstioInformer() istioinformer.SharedInformerFactory {
 	panic("not used in mock")
 }
@@ -92,6 +98,10 @@ func (c MockClient) ServiceApisInformer() serviceapisinformer.SharedInformerFact
 	panic("not used in mock")
 }
 
+func (c MockClient) RateLimitInformer() ratelimitinformer.SharedInformerFactory {
+	panic("not used in mock")
+}
+
 func (c MockClient) Metadata() metadata.Interface {
 	panic("not used in mock")
 }
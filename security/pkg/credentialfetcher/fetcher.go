@@ -22,10 +22,17 @@ import (
 	"istio.io/istio/security/pkg/credentialfetcher/plugin"
 )
 
+// NewCredFetcher creates the credential fetcher of a platform. The audience of the credential is the
+// trust domain for GCE, the server ID the request is signed for for AWS, and the Azure AD application
+// the token is requested for for Azure.
 func NewCredFetcher(credtype, trustdomain, jwtPath, identityProvider string) (security.CredFetcher, error) {
 	switch credtype {
 	case security.GCE:
 		return plugin.CreateGCEPlugin(trustdomain, jwtPath, identityProvider), nil
+	case security.AWS:
+		return plugin.CreateAWSPlugin(trustdomain, jwtPath, identityProvider), nil
+	case security.Azure:
+		return plugin.CreateAzurePlugin(trustdomain, jwtPath, identityProvider), nil
 	case security.Mock: // for test only
 		return plugin.CreateMockPlugin("test_token"), nil
 	default:
@@ -39,6 +39,20 @@ func TestNewCredFetcher(t *testing.T) {
 			expectedToken:    "",
 			expectedIdp:      "GoogleComputeEngine",
 		},
+		"aws test": {
+			fetcherType:      security.AWS,
+			trustdomain:      "cluster.local",
+			jwtPath:          "/var/run/secrets/tokens/istio-token",
+			identityProvider: security.AWS,
+			expectedIdp:      "AWS",
+		},
+		"azure test": {
+			fetcherType:      security.Azure,
+			trustdomain:      "api://istio",
+			jwtPath:          "/var/run/secrets/tokens/istio-token",
+			identityProvider: security.Azure,
+			expectedIdp:      "Azure",
+		},
 		"mock test": {
 			fetcherType:      security.Mock,
 			trustdomain:      "",
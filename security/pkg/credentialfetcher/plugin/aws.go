@@ -0,0 +1,151 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// This is AWS plugin of credentialfetcher.
+package plugin
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sts"
+
+	"istio.io/istio/pkg/security"
+	"istio.io/pkg/log"
+)
+
+var (
+	awscredLog = log.RegisterScope("awscred", "AWS credential fetcher for istio agent", 0)
+)
+
+const (
+	// AWSServerIDHeader is the header binding the signed request to the server it is sent to, so that the
+	// request cannot be replayed against another server.
+	AWSServerIDHeader = "X-Istio-Server-Id"
+	// awsSTSRegion is the region of the global STS endpoint, https://sts.amazonaws.com.
+	awsSTSRegion = "us-east-1"
+)
+
+// AWSCredential is the credential of an EC2 instance: a sts:GetCallerIdentity request signed with the
+// credentials of the IAM role of the instance. The server sends the request to AWS STS, which returns the
+// role. The signature expires after 15 minutes.
+type AWSCredential struct {
+	Headers http.Header `json:"headers"`
+	Body    string      `json:"body"`
+}
+
+// Encode returns the credential as a token which can be sent as a bearer token.
+func (c AWSCredential) Encode() (string, error) {
+	j, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(j), nil
+}
+
+// ParseAWSCredential decodes a token returned by AWSCredential.Encode.
+func ParseAWSCredential(token string) (*AWSCredential, error) {
+	j, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("invalid AWS credential encoding: %v", err)
+	}
+	c := &AWSCredential{}
+	if err := json.Unmarshal(j, c); err != nil {
+		return nil, fmt.Errorf("invalid AWS credential: %v", err)
+	}
+	if c.Headers.Get("Authorization") == "" || c.Body == "" {
+		return nil, fmt.Errorf("invalid AWS credential: missing signature or body")
+	}
+	return c, nil
+}
+
+// The plugin object.
+type AWSPlugin struct {
+	// The location to save the identity token
+	jwtPath string
+
+	// identity provider
+	identityProvider string
+
+	// serverID is the value of the AWSServerIDHeader signed in the requests.
+	serverID string
+
+	// config of the AWS session. The credentials are read from the instance metadata service if unset.
+	config *aws.Config
+}
+
+// CreateAWSPlugin creates an AWS credential fetcher plugin signing requests bound to the server ID.
+// Return the pointer to the created plugin.
+func CreateAWSPlugin(serverID, jwtPath, identityProvider string) *AWSPlugin {
+	p := &AWSPlugin{
+		jwtPath:          jwtPath,
+		identityProvider: identityProvider,
+		serverID:         serverID,
+		config:           aws.NewConfig(),
+	}
+	return p
+}
+
+// GetPlatformCredential signs a sts:GetCallerIdentity request with the credentials of the IAM role of the
+// EC2 instance, and write the encoded request to jwtPath.
+// Note: this function only works in an EC2 instance, or with AWS credentials in the environment.
+func (p *AWSPlugin) GetPlatformCredential() (string, error) {
+	if p.jwtPath == "" {
+		return "", fmt.Errorf("jwtPath is unset")
+	}
+	sess, err := session.NewSession(p.config.Copy().WithRegion(awsSTSRegion))
+	if err != nil {
+		awscredLog.Errorf("Failed to create AWS session: %v", err)
+		return "", err
+	}
+	req, _ := sts.New(sess).GetCallerIdentityRequest(&sts.GetCallerIdentityInput{})
+	req.HTTPRequest.Header.Set(AWSServerIDHeader, p.serverID)
+	if err := req.Sign(); err != nil {
+		awscredLog.Errorf("Failed to sign sts:GetCallerIdentity request: %v", err)
+		return "", err
+	}
+	body, err := ioutil.ReadAll(req.HTTPRequest.Body)
+	if err != nil {
+		return "", err
+	}
+	token, err := AWSCredential{
+		Headers: req.HTTPRequest.Header,
+		Body:    string(body),
+	}.Encode()
+	if err != nil {
+		return "", err
+	}
+	awscredLog.Debugf("Got AWS IAM credential: %d", len(token))
+	err = ioutil.WriteFile(p.jwtPath, []byte(token), 0640)
+	if err != nil {
+		awscredLog.Errorf("Encountered error when writing IAM credential: %v", err)
+		return "", err
+	}
+	return token, nil
+}
+
+// GetType returns credential fetcher type.
+func (p *AWSPlugin) GetType() string {
+	return security.AWS
+}
+
+// GetIdentityProvider returns the name of the identity provider that can authenticate the workload credential.
+func (p *AWSPlugin) GetIdentityProvider() string {
+	return p.identityProvider
+}
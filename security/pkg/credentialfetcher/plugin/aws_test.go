@@ -0,0 +1,70 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+)
+
+func TestAWSPlugin(t *testing.T) {
+	jwtPath := filepath.Join(t.TempDir(), "istio-token")
+	p := CreateAWSPlugin("istiod.example.com", jwtPath, "AWS")
+	p.config = aws.NewConfig().WithCredentials(credentials.NewStaticCredentials("AKIDEXAMPLE", "secret", ""))
+
+	token, err := p.GetPlatformCredential()
+	if err != nil {
+		t.Fatalf("GetPlatformCredential failed: %v", err)
+	}
+	cred, err := ParseAWSCredential(token)
+	if err != nil {
+		t.Fatalf("failed to parse the credential: %v", err)
+	}
+	body, err := url.ParseQuery(cred.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body.Get("Action") != "GetCallerIdentity" {
+		t.Errorf("got body %q, expected a GetCallerIdentity request", cred.Body)
+	}
+	if got := cred.Headers.Get(AWSServerIDHeader); got != "istiod.example.com" {
+		t.Errorf("got server ID %q, expected istiod.example.com", got)
+	}
+	auth := cred.Headers.Get("Authorization")
+	if !strings.Contains(auth, "Credential=AKIDEXAMPLE/") || !strings.Contains(auth, strings.ToLower(AWSServerIDHeader)) {
+		t.Errorf("got Authorization %q, expected a signature of the server ID header", auth)
+	}
+	saved, err := ioutil.ReadFile(jwtPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(saved) != token {
+		t.Errorf("credential saved in %s does not match the returned one", jwtPath)
+	}
+}
+
+func TestParseAWSCredential(t *testing.T) {
+	for _, token := range []string{"", "not base64!", "e30"} {
+		if _, err := ParseAWSCredential(token); err == nil {
+			t.Errorf("expected an error parsing %q", token)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// This is Azure plugin of credentialfetcher.
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+
+	"istio.io/istio/pkg/security"
+	"istio.io/pkg/log"
+)
+
+var (
+	azurecredLog = log.RegisterScope("azurecred", "Azure credential fetcher for istio agent", 0)
+)
+
+const (
+	// azureMetadataEndpoint is the endpoint of the Azure instance metadata service (IMDS).
+	azureMetadataEndpoint = "http://169.254.169.254"
+	azureTokenAPIVersion  = "2018-02-01"
+)
+
+type azureTokenResponse struct {
+	AccessToken string `json:"access_token"`
+}
+
+// The plugin object.
+type AzurePlugin struct {
+	// resource is the App ID URI of the Azure AD application the token is requested for. It is the
+	// audience of the token.
+	resource string
+
+	// The location to save the identity token
+	jwtPath string
+
+	// identity provider
+	identityProvider string
+
+	// endpoint of the instance metadata service
+	endpoint string
+	client   *http.Client
+}
+
+// CreateAzurePlugin creates an Azure credential fetcher plugin. Return the pointer to the created plugin.
+func CreateAzurePlugin(resource, jwtPath, identityProvider string) *AzurePlugin {
+	p := &AzurePlugin{
+		resource:         resource,
+		jwtPath:          jwtPath,
+		identityProvider: identityProvider,
+		endpoint:         azureMetadataEndpoint,
+		client:           &http.Client{Timeout: metadataTimeout},
+	}
+	return p
+}
+
+// GetPlatformCredential fetches a token of the managed identity of the Azure VM from the instance
+// metadata service, and write it to jwtPath.
+// Note: this function only works in an Azure VM with a managed identity.
+func (p *AzurePlugin) GetPlatformCredential() (string, error) {
+	if p.jwtPath == "" {
+		return "", fmt.Errorf("jwtPath is unset")
+	}
+	query := url.Values{}
+	query.Set("api-version", azureTokenAPIVersion)
+	query.Set("resource", p.resource)
+	req, err := http.NewRequest(http.MethodGet, p.endpoint+"/metadata/identity/oauth2/token?"+query.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata", "true")
+	resp, err := p.client.Do(req)
+	if err != nil {
+		azurecredLog.Errorf("Failed to get managed identity token from metadata server: %v", err)
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		azurecredLog.Errorf("Failed to get managed identity token from metadata server: HTTP status %s", resp.Status)
+		return "", fmt.Errorf("metadata server returned HTTP status %s", resp.Status)
+	}
+	tokenResp := &azureTokenResponse{}
+	if err := json.Unmarshal(body, tokenResp); err != nil {
+		return "", fmt.Errorf("failed to unmarshal managed identity token: %v", err)
+	}
+	if tokenResp.AccessToken == "" {
+		return "", fmt.Errorf("metadata server returned an empty managed identity token")
+	}
+	azurecredLog.Debugf("Got Azure managed identity token: %d", len(tokenResp.AccessToken))
+	err = ioutil.WriteFile(p.jwtPath, []byte(tokenResp.AccessToken), 0640)
+	if err != nil {
+		azurecredLog.Errorf("Encountered error when writing managed identity token: %v", err)
+		return "", err
+	}
+	return tokenResp.AccessToken, nil
+}
+
+// GetType returns credential fetcher type.
+func (p *AzurePlugin) GetType() string {
+	return security.Azure
+}
+
+// GetIdentityProvider returns the name of the identity provider that can authenticate the workload credential.
+func (p *AzurePlugin) GetIdentityProvider() string {
+	return p.identityProvider
+}
@@ -0,0 +1,62 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+func TestAzurePlugin(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/metadata/identity/oauth2/token" || r.Header.Get("Metadata") != "true" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if r.URL.Query().Get("resource") != "api://istio" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(`{"access_token":"managed-identity-token","token_type":"Bearer"}`))
+	}))
+	defer server.Close()
+
+	jwtPath := filepath.Join(t.TempDir(), "istio-token")
+	p := CreateAzurePlugin("api://istio", jwtPath, "Azure")
+	p.endpoint = server.URL
+
+	token, err := p.GetPlatformCredential()
+	if err != nil {
+		t.Fatalf("GetPlatformCredential failed: %v", err)
+	}
+	if token != "managed-identity-token" {
+		t.Errorf("got token %q, expected managed-identity-token", token)
+	}
+	saved, err := ioutil.ReadFile(jwtPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(saved) != token {
+		t.Errorf("token saved in %s does not match the returned one", jwtPath)
+	}
+
+	p.resource = "api://other"
+	if _, err := p.GetPlatformCredential(); err == nil {
+		t.Errorf("expected an error when the metadata server rejects the request")
+	}
+}
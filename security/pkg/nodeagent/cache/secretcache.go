@@ -62,6 +62,10 @@ const (
 	// RootCertReqResourceName is resource name of discovery request for root certificate.
 	RootCertReqResourceName = "ROOTCA"
 
+	// FederatedRootCertReqResourceNamePrefix is the prefix of the resource name of discovery requests for
+	// the root certs of a federated trust domain, followed by the trust domain.
+	FederatedRootCertReqResourceNamePrefix = "ROOTCA~"
+
 	// WorkloadKeyCertResourceName is the resource name of the discovery request for workload
 	// identity.
 	// TODO: change all the pilot one reference definition here instead.
@@ -128,6 +132,14 @@ type SecretCache struct {
 	rootCertMutex      *sync.RWMutex
 	rootCert           []byte
 	rootCertExpireTime time.Time
+	// crl is the certificate revocation list of the CA, served with the root cert.
+	crl []byte
+	// rootCertDistributed is true once the root certs are distributed by Istiod. They include the root
+	// cert of the CSR responses, and the new root cert during a rotation of the CA.
+	rootCertDistributed bool
+	// federatedRootCerts are the root certs of the federated trust domains distributed by Istiod, by trust
+	// domain. They are served in their own validation context, never with the root cert.
+	federatedRootCerts map[string][]byte
 
 	// Source of random numbers. It is not concurrency safe, requires lock protected.
 	rand      *rand.Rand
@@ -189,6 +201,114 @@ func (sc *SecretCache) setRootCert(rootCert []byte, rootCertExpr time.Time) {
 	sc.rootCertMutex.Unlock()
 }
 
+// isRootCertDistributed returns true if the root certs are distributed by Istiod. This method is thread safe.
+func (sc *SecretCache) isRootCertDistributed() bool {
+	sc.rootCertMutex.RLock()
+	defer sc.rootCertMutex.RUnlock()
+	return sc.rootCertDistributed
+}
+
+// getCRL returns the cached certificate revocation list. This method is thread safe.
+func (sc *SecretCache) getCRL() []byte {
+	sc.rootCertMutex.RLock()
+	defer sc.rootCertMutex.RUnlock()
+	return sc.crl
+}
+
+// UpdateCRL sets the certificate revocation list of the CA, and pushes it to the proxies with the
+// root cert if it changed. This method is thread safe.
+func (sc *SecretCache) UpdateCRL(crl []byte) {
+	sc.rootCertMutex.Lock()
+	if bytes.Equal(sc.crl, crl) {
+		sc.rootCertMutex.Unlock()
+		return
+	}
+	sc.crl = crl
+	rootCert := sc.rootCert
+	sc.rootCertMutex.Unlock()
+	cacheLog.Infof("certificate revocation list updated (%d bytes)", len(crl))
+	// Without a root cert, no root cert was pushed yet: the CRL is sent with the first one.
+	if rootCert != nil {
+		sc.rotate(true /*updateRootFlag*/)
+	}
+}
+
+// UpdateRootCert sets the root certs trusted by the CA, as distributed by Istiod ahead of a rotation of
+// the CA certificates, and pushes them to the proxies if they changed. This method is thread safe.
+func (sc *SecretCache) UpdateRootCert(rootCert []byte) {
+	rootCertExpireTime, err := nodeagentutil.ParseCertAndGetExpiryTimestamp(rootCert)
+	if err != nil {
+		cacheLog.Errorf("failed to parse the root certificate distributed by the CA: %v", err)
+		return
+	}
+	sc.rootCertMutex.Lock()
+	if bytes.Equal(sc.rootCert, rootCert) {
+		sc.rootCertDistributed = true
+		sc.rootCertMutex.Unlock()
+		return
+	}
+	sc.rootCert = rootCert
+	sc.rootCertExpireTime = rootCertExpireTime
+	sc.rootCertDistributed = true
+	sc.rootCertMutex.Unlock()
+	cacheLog.Info("Root cert distributed by the CA has changed, start rotating root cert for SDS clients")
+	sc.rotate(true /*updateRootFlag*/)
+}
+
+// UpdateFederatedRootCerts updates the root certs of the federated trust domains distributed by Istiod,
+// and pushes the changed ones to the SDS clients.
+func (sc *SecretCache) UpdateFederatedRootCerts(rootCerts map[string][]byte) {
+	sc.rootCertMutex.Lock()
+	changed := map[string]bool{}
+	for trustDomain, rootCert := range rootCerts {
+		if !bytes.Equal(sc.federatedRootCerts[trustDomain], rootCert) {
+			changed[trustDomain] = true
+		}
+	}
+	sc.federatedRootCerts = rootCerts
+	sc.rootCertMutex.Unlock()
+	if len(changed) == 0 {
+		return
+	}
+
+	sc.secrets.Range(func(k interface{}, v interface{}) bool {
+		connKey := k.(ConnKey)
+		trustDomain, ok := federatedTrustDomain(connKey.ResourceName)
+		if !ok || !changed[trustDomain] {
+			return true
+		}
+		cacheLog.Infof("%s root certs of trust domain %s have changed", cacheLogPrefix(connKey.ResourceName), trustDomain)
+		now := time.Now()
+		ns := &security.SecretItem{
+			ResourceName: connKey.ResourceName,
+			RootCert:     rootCerts[trustDomain],
+			Token:        v.(security.SecretItem).Token,
+			CreatedTime:  now,
+			Version:      now.String(),
+		}
+		sc.secrets.Store(connKey, *ns)
+		sc.callbackWithTimeout(connKey, ns)
+		return true
+	})
+}
+
+// getFederatedRootCert returns the root certs of a federated trust domain, or nil if they are not
+// distributed. This method is thread safe.
+func (sc *SecretCache) getFederatedRootCert(trustDomain string) []byte {
+	sc.rootCertMutex.RLock()
+	defer sc.rootCertMutex.RUnlock()
+	return sc.federatedRootCerts[trustDomain]
+}
+
+// federatedTrustDomain returns the trust domain of the resource name of the root certs of a federated trust
+// domain, and false if the resource name is not one of them.
+func federatedTrustDomain(resourceName string) (string, bool) {
+	if !strings.HasPrefix(resourceName, FederatedRootCertReqResourceNamePrefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(resourceName, FederatedRootCertReqResourceNamePrefix), true
+}
+
 // GenerateSecret generates new secret and cache the secret, this function is called by SDS.StreamSecrets
 // and SDS.FetchSecret. Since credential passing from client may change, regenerate secret every time
 // instead of reading from cache.
@@ -215,6 +335,10 @@ func (sc *SecretCache) GenerateSecret(ctx context.Context, connectionID, resourc
 		return ns, nil
 	}
 
+	if trustDomain, ok := federatedTrustDomain(resourceName); ok {
+		return sc.generateFederatedRootCertSecret(connKey, token, trustDomain)
+	}
+
 	if resourceName != RootCertReqResourceName {
 		ns, err := sc.generateSecret(ctx, token, connKey, time.Now())
 		if err != nil {
@@ -253,6 +377,7 @@ func (sc *SecretCache) GenerateSecret(ctx context.Context, connectionID, resourc
 	ns = &security.SecretItem{
 		ResourceName: resourceName,
 		RootCert:     rootCert,
+		CRL:          sc.getCRL(),
 		ExpireTime:   rootCertExpr,
 		Token:        token,
 		CreatedTime:  t,
@@ -264,6 +389,32 @@ func (sc *SecretCache) GenerateSecret(ctx context.Context, connectionID, resourc
 	return ns, nil
 }
 
+// generateFederatedRootCertSecret generates the secret of the root certs of a federated trust domain.
+// It retries since the root certs may be empty until they are distributed by Istiod.
+func (sc *SecretCache) generateFederatedRootCertSecret(connKey ConnKey, token, trustDomain string) (*security.SecretItem, error) {
+	rootCert := sc.getFederatedRootCert(trustDomain)
+	wait := retryWaitDuration
+	for retryNum := 0; rootCert == nil && retryNum < maxRetryNum; retryNum++ {
+		time.Sleep(wait)
+		rootCert = sc.getFederatedRootCert(trustDomain)
+		wait *= 2
+	}
+	if rootCert == nil {
+		return nil, fmt.Errorf("root certs of trust domain %s are not distributed", trustDomain)
+	}
+
+	t := time.Now()
+	ns := &security.SecretItem{
+		ResourceName: connKey.ResourceName,
+		RootCert:     rootCert,
+		Token:        token,
+		CreatedTime:  t,
+		Version:      t.String(),
+	}
+	sc.secrets.Store(connKey, *ns)
+	return ns, nil
+}
+
 func (sc *SecretCache) addFileWatcher(file string, token string, connKey ConnKey) {
 	// TODO(ramaraochavali): add integration test for file watcher functionality.
 	// Check if this file is being already watched, if so ignore it. FileWatcher has the functionality of
@@ -412,6 +563,7 @@ func (sc *SecretCache) rotate(updateRootFlag bool) {
 			ns := &security.SecretItem{
 				ResourceName: connKey.ResourceName,
 				RootCert:     rootCert,
+				CRL:          sc.getCRL(),
 				ExpireTime:   rootCertExpr,
 				Token:        secret.Token,
 				CreatedTime:  now,
@@ -428,6 +580,10 @@ func (sc *SecretCache) rotate(updateRootFlag bool) {
 		if connKey.ResourceName == RootCertReqResourceName {
 			return true
 		}
+		// The root certs of federated trust domains are only updated by Istiod.
+		if _, ok := federatedTrustDomain(connKey.ResourceName); ok {
+			return true
+		}
 
 		now := time.Now()
 
@@ -692,6 +848,7 @@ func (sc *SecretCache) generateSecret(ctx context.Context, token string, connKey
 		RSAKeySize: keySize,
 		PKCS8Key:   sc.configOptions.Pkcs8Keys,
 		ECSigAlg:   pkiutil.SupportedECSignatureAlgorithms(sc.configOptions.ECCSigAlg),
+		ECCCurve:   pkiutil.SupportedEllipticCurves(sc.configOptions.ECCCurve),
 	}
 
 	// Generate the cert/key, send CSR to CA.
@@ -730,7 +887,10 @@ func (sc *SecretCache) generateSecret(ctx context.Context, token string, connKey
 	rootCert, _ := sc.getRootCert()
 	// Leaf cert is element '0'. Root cert is element 'n'.
 	rootCertChanged := !bytes.Equal(rootCert, []byte(certChainPEM[length-1]))
-	if rootCert == nil || rootCertChanged {
+	if sc.isRootCertDistributed() {
+		// The root certs distributed by Istiod take precedence, as they also trust federated trust domains.
+		rootCertChanged = false
+	} else if rootCert == nil || rootCertChanged {
 		rootCertExpireTime, err := nodeagentutil.ParseCertAndGetExpiryTimestamp([]byte(certChainPEM[length-1]))
 		if err == nil {
 			sc.setRootCert([]byte(certChainPEM[length-1]), rootCertExpireTime)
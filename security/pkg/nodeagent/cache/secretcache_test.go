@@ -678,3 +678,37 @@ func TestConcatCerts(t *testing.T) {
 
 	}
 }
+
+func TestFederatedRootCerts(t *testing.T) {
+	var notified []string
+	var mutex sync.Mutex
+	sc := &SecretCache{
+		configOptions: &security.Options{},
+		rootCertMutex: &sync.RWMutex{},
+		notifyCallback: func(connKey ConnKey, secret *security.SecretItem) error {
+			mutex.Lock()
+			defer mutex.Unlock()
+			notified = append(notified, fmt.Sprintf("%s=%s", connKey.ResourceName, secret.RootCert))
+			return nil
+		},
+	}
+	sc.UpdateFederatedRootCerts(map[string][]byte{"foo.com": []byte("foo-root")})
+
+	secret, err := sc.GenerateSecret(context.Background(), "conn", "ROOTCA~foo.com", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(secret.RootCert) != "foo-root" || secret.CertificateChain != nil {
+		t.Errorf("unexpected secret of the federated trust domain: %+v", secret)
+	}
+
+	// Only the validation context of the changed trust domain is pushed.
+	sc.UpdateFederatedRootCerts(map[string][]byte{"foo.com": []byte("foo-root")})
+	sc.UpdateFederatedRootCerts(map[string][]byte{"foo.com": []byte("foo-root-2"), "bar.com": []byte("bar-root")})
+	if !reflect.DeepEqual(notified, []string{"ROOTCA~foo.com=foo-root-2"}) {
+		t.Errorf("unexpected notifications %v", notified)
+	}
+	if root, _ := sc.getRootCert(); root != nil {
+		t.Errorf("expected the root cert not to include the federated root certs, got %q", root)
+	}
+}
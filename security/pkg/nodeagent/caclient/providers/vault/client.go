@@ -0,0 +1,59 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package caclient
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"istio.io/istio/pkg/security"
+	"istio.io/istio/security/pkg/pki/vault"
+	"istio.io/pkg/log"
+)
+
+var vaultCAClientLog = log.RegisterScope("vaultca", "Vault CA client debugging", 0)
+
+type vaultCAClient struct {
+	client *vault.Client
+}
+
+// NewVaultCAClient create a CA client for a Vault PKI secrets engine. With the Kubernetes auth method,
+// the workload token passed to CSRSign is used to log in to Vault. With the AppRole auth method,
+// the configured role ID and secret ID are used.
+func NewVaultCAClient(config vault.Config) (security.Client, error) {
+	client, err := vault.NewClient(config)
+	if err != nil {
+		return nil, err
+	}
+	return &vaultCAClient{client: client}, nil
+}
+
+// CSR Sign calls Vault to sign a CSR.
+func (c *vaultCAClient) CSRSign(ctx context.Context, reqID string, csrPEM []byte, token string,
+	certValidTTLInSec int64) ([]string /*PEM-encoded certificate chain*/, error) {
+	certChain, err := c.client.SignCSR(ctx, csrPEM, time.Duration(certValidTTLInSec)*time.Second, token)
+	if err != nil {
+		vaultCAClientLog.Errorf("Failed to create certificate: %v", err)
+		return nil, err
+	}
+
+	if len(certChain) <= 1 {
+		vaultCAClientLog.Errorf("CertChain length is %d, expected more than 1", len(certChain))
+		return nil, errors.New("invalid response cert chain")
+	}
+
+	return certChain, nil
+}
@@ -0,0 +1,106 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package caclient
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"istio.io/istio/security/pkg/pki/vault"
+	"istio.io/istio/security/pkg/pki/vault/mock"
+)
+
+const fakeToken = "workload-jwt"
+
+func TestVaultCAClient(t *testing.T) {
+	secretIDPath := filepath.Join(t.TempDir(), "secret-id")
+	if err := ioutil.WriteFile(secretIDPath, []byte("secret-id"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := map[string]struct {
+		authMethod   vault.AuthMethod
+		token        string
+		issuingCA    string
+		expectedCert []string
+		expectedErr  bool
+	}{
+		"Valid certs": {
+			authMethod:   vault.AuthKubernetes,
+			token:        fakeToken,
+			issuingCA:    "root",
+			expectedCert: []string{"leaf", "root"},
+		},
+		"Valid certs with approle": {
+			authMethod:   vault.AuthAppRole,
+			token:        "ignored-token",
+			issuingCA:    "root",
+			expectedCert: []string{"leaf", "root"},
+		},
+		"Invalid token": {
+			authMethod:  vault.AuthKubernetes,
+			token:       "bad-token",
+			issuingCA:   "root",
+			expectedErr: true,
+		},
+		"Empty cert chain": {
+			authMethod:  vault.AuthKubernetes,
+			token:       fakeToken,
+			expectedErr: true,
+		},
+	}
+
+	for id, tc := range testCases {
+		t.Run(id, func(t *testing.T) {
+			server := mock.NewVaultServer("pki/sign/istio")
+			defer server.Close()
+			server.Role = "workload"
+			server.JWT = fakeToken
+			server.RoleID = "role-id"
+			server.SecretID = "secret-id"
+			server.Certificate = "leaf"
+			server.IssuingCA = tc.issuingCA
+
+			cli, err := NewVaultCAClient(vault.Config{
+				Address:      server.URL,
+				AuthMethod:   tc.authMethod,
+				Role:         "workload",
+				RoleID:       "role-id",
+				SecretIDPath: secretIDPath,
+				SignCSRPath:  "pki/sign/istio",
+			})
+			if err != nil {
+				t.Fatalf("failed to create the Vault CA client: %v", err)
+			}
+
+			resp, err := cli.CSRSign(context.Background(), "12345678-1234-1234-1234-123456789012", []byte{01}, tc.token, 1)
+			if tc.expectedErr {
+				if err == nil {
+					t.Errorf("expected an error, got certs %v", resp)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CSRSign failed: %v", err)
+			}
+			if !reflect.DeepEqual(resp, tc.expectedCert) {
+				t.Errorf("resp: got %+v, expected %v", resp, tc.expectedCert)
+			}
+		})
+	}
+}
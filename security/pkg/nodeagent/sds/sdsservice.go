@@ -601,6 +601,13 @@ func sdsDiscoveryResponse(s *security.SecretItem, resourceName, typeURL string)
 				},
 			},
 		}
+		if s.CRL != nil {
+			secret.GetValidationContext().Crl = &core.DataSource{
+				Specifier: &core.DataSource_InlineBytes{
+					InlineBytes: s.CRL,
+				},
+			}
+		}
 	} else {
 		secret.Type = &tls.Secret_TlsCertificate{
 			TlsCertificate: &tls.TlsCertificate{
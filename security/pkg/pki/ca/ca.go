@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"sync"
 	"time"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -255,6 +256,16 @@ type IstioCA struct {
 	// rootCertRotator periodically rotates self-signed root cert for CA. It is nil
 	// if CA is not self-signed CA.
 	rootCertRotator *SelfSignedCARootCertRotator
+
+	// crlMutex protects the revoked certificates and their CRLs.
+	crlMutex sync.Mutex
+	revoked  []RevokedCertificate
+	// crl is the PEM encoded CRL of the revoked certificates signed by crlSigner. It is generated on demand.
+	crl       []byte
+	crlSigner *x509.Certificate
+	// previousCRL is the last CRL signed by the signing cert before a rotation, until the cert expires.
+	previousCRL       []byte
+	previousCRLExpiry time.Time
 }
 
 // NewIstioCA returns a new IstioCA instance.
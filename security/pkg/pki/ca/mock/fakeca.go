@@ -30,11 +30,14 @@ type FakeCA struct {
 	SignErr       *caerror.Error
 	KeyCertBundle util.KeyCertBundle
 	ReceivedIDs   []string
+	// ReceivedLifetime is the lifetime of the last signed certificate.
+	ReceivedLifetime time.Duration
 }
 
 // Sign returns the SignErr if SignErr is not nil, otherwise, it returns SignedCert.
 func (ca *FakeCA) Sign(csr []byte, identities []string, lifetime time.Duration, forCA bool) ([]byte, error) {
 	ca.ReceivedIDs = identities
+	ca.ReceivedLifetime = lifetime
 	if ca.SignErr != nil {
 		return nil, ca.SignErr
 	}
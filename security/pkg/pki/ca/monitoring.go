@@ -0,0 +1,42 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ca
+
+import (
+	"istio.io/pkg/monitoring"
+)
+
+var (
+	phaseTag = monitoring.MustCreateLabel("phase")
+
+	caRotationPhase = monitoring.NewGauge(
+		"citadel_server_ca_rotation_phase",
+		"Whether the rotation of the plugged-in CA certificates is in the phase (1) or not (0).",
+		monitoring.WithLabels(phaseTag),
+	)
+
+	caRotationPhaseTimestamp = monitoring.NewGauge(
+		"citadel_server_ca_rotation_phase_timestamp",
+		"The unix timestamp, in seconds, when the rotation of the plugged-in CA certificates entered the phase.",
+		monitoring.WithLabels(phaseTag),
+	)
+)
+
+func init() {
+	monitoring.MustRegister(
+		caRotationPhase,
+		caRotationPhaseTimestamp,
+	)
+}
@@ -0,0 +1,482 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ca
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/util/retry"
+
+	"istio.io/pkg/log"
+)
+
+const (
+	// CACertsSecret is the secret with the plugged-in CA certificates, mounted by Istiod.
+	CACertsSecret = "cacerts"
+	// CARotationSecret stores the state of the rotation of the plugged-in CA certificates, shared by all
+	// the Istiod instances.
+	CARotationSecret = "istio-ca-rotation"
+
+	// rotationStatusID is the JSON encoded PluggedCertRotationStatus in CARotationSecret.
+	rotationStatusID = "rotation-status.json"
+	// The CA certificates used before the rotation, in CARotationSecret.
+	previousCACertID    = "previous-ca-cert.pem"
+	previousCertChainID = "previous-cert-chain.pem"
+	previousRootCertID  = "previous-root-cert.pem"
+	// previousCAKeyID is the optional key of the previous CA certificate in CACertsSecret, along with
+	// previousCACertID. The key is never stored in CARotationSecret.
+	previousCAKeyID = "previous-ca-key.pem"
+)
+
+var pluggedCertRotatorLog = log.RegisterScope("carotation", "Plugged-in CA cert rotator log", 0)
+
+// RotationPhase is a phase of the rotation of the plugged-in CA certificates.
+type RotationPhase string
+
+const (
+	// RotationTrustBundle distributes the trust bundle with both the old and the new root certs, while
+	// the old CA certificate still signs the workload certificates.
+	RotationTrustBundle RotationPhase = "DistributingTrustBundle"
+	// RotationSigning signs the workload certificates with the new CA certificate, while the old root
+	// certs are still trusted by the proxies with a certificate signed by the old one.
+	RotationSigning RotationPhase = "SigningWithNewCA"
+	// RotationCompleted drops the old root certs from the trust bundle.
+	RotationCompleted RotationPhase = "Completed"
+)
+
+var rotationPhases = []RotationPhase{RotationTrustBundle, RotationSigning, RotationCompleted}
+
+// PluggedCertRotationStatus is the state of a rotation of the plugged-in CA certificates.
+type PluggedCertRotationStatus struct {
+	Phase RotationPhase `json:"phase"`
+	// CACertHash is the SHA-256 hash of the CA certificate being rotated to.
+	CACertHash string    `json:"caCertHash"`
+	StartTime  time.Time `json:"startTime"`
+	// SigningSwitchTime is when the new CA certificate starts signing the workload certificates.
+	SigningSwitchTime time.Time `json:"signingSwitchTime"`
+	// RootRemovalTime is when the old root certs are dropped from the trust bundle.
+	RootRemovalTime time.Time `json:"rootRemovalTime"`
+}
+
+// phaseAt returns the phase of the rotation at the given time.
+func (s *PluggedCertRotationStatus) phaseAt(now time.Time) RotationPhase {
+	switch {
+	case now.Before(s.SigningSwitchTime):
+		return RotationTrustBundle
+	case now.Before(s.RootRemovalTime):
+		return RotationSigning
+	default:
+		return RotationCompleted
+	}
+}
+
+// PluggedCertRotatorConfig is the configuration of PluggedCertRotator.
+type PluggedCertRotatorConfig struct {
+	Client    corev1.CoreV1Interface
+	Namespace string
+	// CheckInterval is the interval between the checks of the phase of a rotation.
+	CheckInterval time.Duration
+	// TrustBundlePropagationPeriod is how long the trust bundle with the new root certs is distributed
+	// before the new CA certificate signs.
+	TrustBundlePropagationPeriod time.Duration
+	// RootGracePeriod is how long the old root certs are trusted once the new CA certificate signs. It
+	// should cover the lifetime of the certificates signed by the old one.
+	RootGracePeriod time.Duration
+}
+
+// caCerts are the PEM encoded certificates of a CA.
+type caCerts struct {
+	cert, key, chain, root []byte
+}
+
+// PluggedCertRotator rotates the plugged-in CA certificates when the cacerts secret is updated, without
+// breaking the trust between the proxies:
+//   - the new root certs are added to the trust bundle distributed to the proxies, while the old CA
+//     certificate still signs;
+//   - after TrustBundlePropagationPeriod, the new CA certificate signs;
+//   - after RootGracePeriod, the old root certs are dropped.
+//
+// The state of the rotation and the previous certificates are stored in the CARotationSecret, so that all
+// the Istiod instances rotate at the same time and a restarted instance resumes the rotation. The previous
+// CA key is only kept in memory by the instances which signed with it. An instance restarted during the
+// first phase signs with the previous CA certificate only if its key is kept in the cacerts secret, as
+// previous-ca-key.pem along with previous-ca-cert.pem.
+type PluggedCertRotator struct {
+	config   *PluggedCertRotatorConfig
+	ca       *IstioCA
+	onUpdate func()
+	now      func() time.Time
+
+	mutex sync.Mutex
+	// next is the content of the cacerts secret.
+	next *caCerts
+	// status is the state of the last rotation, or nil if there was none.
+	status *PluggedCertRotationStatus
+	// previous are the CA certificates before the last rotation. Its key is only known if this instance
+	// signed with it, and is dropped once the new CA certificate signs.
+	previous *caCerts
+	// pluggedPrevious is the previous CA certificate and key of the cacerts secret, if any.
+	pluggedPrevious *caCerts
+}
+
+// NewPluggedCertRotator returns a rotator of the plugged-in CA certificates of the CA. onUpdate is called
+// when the signing or root certs of the CA change.
+func NewPluggedCertRotator(config *PluggedCertRotatorConfig, ca *IstioCA, onUpdate func()) *PluggedCertRotator {
+	return &PluggedCertRotator{
+		config:   config,
+		ca:       ca,
+		onUpdate: onUpdate,
+		now:      time.Now,
+	}
+}
+
+// Run resumes the rotation in progress, if any, and moves it through its phases until stopCh is closed.
+func (r *PluggedCertRotator) Run(stopCh <-chan struct{}) {
+	r.restore()
+	ticker := time.NewTicker(r.config.CheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			r.mutex.Lock()
+			r.apply()
+			r.mutex.Unlock()
+		case <-stopCh:
+			return
+		}
+	}
+}
+
+// restore resumes the rotation stored in the CARotationSecret if it is a rotation to the CA certificate
+// loaded at startup.
+func (r *PluggedCertRotator) restore() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	secret, err := r.config.Client.Secrets(r.config.Namespace).Get(context.TODO(), CARotationSecret, metav1.GetOptions{})
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			pluggedCertRotatorLog.Errorf("failed to load the CA rotation secret: %v", err)
+		}
+		return
+	}
+	status, previous, err := loadRotation(secret)
+	if err != nil {
+		pluggedCertRotatorLog.Errorf("failed to load the CA rotation: %v", err)
+		return
+	}
+	cert, key, chain, root := r.ca.GetCAKeyCertBundle().GetAllPem()
+	if r.status != nil || status.CACertHash != certHash(cert) || status.phaseAt(r.now()) == RotationCompleted {
+		return
+	}
+	pluggedCertRotatorLog.Infof("resuming the rotation of the CA certificates started at %v", status.StartTime)
+	if r.next == nil {
+		r.next = &caCerts{cert: cert, key: key, chain: chain, root: root}
+	}
+	r.status = status
+	r.previous = previous
+	r.apply()
+}
+
+// UpdateCACerts starts a rotation to the CA certificates of the cacerts secret if they changed.
+func (r *PluggedCertRotator) UpdateCACerts(secret *v1.Secret) {
+	next := &caCerts{
+		cert:  secret.Data[caCertID],
+		key:   secret.Data[caPrivateKeyID],
+		chain: secret.Data[CertChainID],
+		root:  secret.Data[RootCertID],
+	}
+	if len(next.cert) == 0 || len(next.key) == 0 || len(next.root) == 0 {
+		pluggedCertRotatorLog.Errorf("secret %s/%s is missing %s, %s or %s, skipping the CA rotation",
+			secret.Namespace, secret.Name, caCertID, caPrivateKeyID, RootCertID)
+		return
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.pluggedPrevious = nil
+	if len(secret.Data[previousCACertID]) > 0 && len(secret.Data[previousCAKeyID]) > 0 {
+		r.pluggedPrevious = &caCerts{cert: secret.Data[previousCACertID], key: secret.Data[previousCAKeyID]}
+	}
+	cert, key, chain, root := r.ca.GetCAKeyCertBundle().GetAllPem()
+	if r.next == nil && bytes.Equal(cert, next.cert) && bytes.Equal(key, next.key) {
+		// The CA already uses the certificates loaded at startup.
+		r.next = next
+		return
+	}
+	if r.next != nil && bytes.Equal(r.next.cert, next.cert) && bytes.Equal(r.next.key, next.key) {
+		// Only the chain or the roots changed, which are verified again when applied.
+		r.next = next
+		r.apply()
+		return
+	}
+
+	// The roots of the CA are all the root certs trusted by the proxies, including the ones of a rotation
+	// in progress.
+	previous := &caCerts{cert: cert, key: key, chain: chain, root: root}
+	now := r.now().UTC().Truncate(time.Second)
+	status := &PluggedCertRotationStatus{
+		CACertHash:        certHash(next.cert),
+		StartTime:         now,
+		SigningSwitchTime: now.Add(r.config.TrustBundlePropagationPeriod),
+	}
+	if containsRootCerts(previous.root, next.root) {
+		// The new root certs are already trusted by the proxies.
+		status.SigningSwitchTime = now
+	}
+	status.RootRemovalTime = status.SigningSwitchTime.Add(r.config.RootGracePeriod)
+	status, previous, err := r.saveRotation(status, previous)
+	if err != nil {
+		pluggedCertRotatorLog.Errorf("failed to save the CA rotation, the CA certificates are not rotated: %v", err)
+		return
+	}
+	pluggedCertRotatorLog.Infof("rotating the CA certificates: signing with the new CA certificate at %v, "+
+		"dropping the old root certificates at %v", status.SigningSwitchTime, status.RootRemovalTime)
+	r.next = next
+	r.status = status
+	r.previous = previous
+	r.apply()
+}
+
+// apply sets the signing and root certs of the CA for the current phase of the rotation.
+func (r *PluggedCertRotator) apply() {
+	if r.status == nil || r.next == nil {
+		return
+	}
+	phase := r.status.phaseAt(r.now())
+	signing := r.next
+	roots := r.next.root
+	switch phase {
+	case RotationTrustBundle:
+		if key := r.previousKey(); len(key) > 0 {
+			signing = &caCerts{cert: r.previous.cert, key: key, chain: r.previous.chain, root: r.previous.root}
+		}
+		fallthrough
+	case RotationSigning:
+		if r.previous != nil {
+			roots = mergeRootCerts(r.next.root, r.previous.root)
+		}
+	}
+
+	bundle := r.ca.GetCAKeyCertBundle()
+	cert, key, chain, root := bundle.GetAllPem()
+	if bytes.Equal(cert, signing.cert) && bytes.Equal(key, signing.key) && bytes.Equal(chain, signing.chain) &&
+		bytes.Equal(root, roots) {
+		return
+	}
+	if phase == RotationTrustBundle && signing == r.next {
+		pluggedCertRotatorLog.Warnf("the previous CA key is unknown, signing with the new CA certificate before "+
+			"the trust bundle is distributed: keep the previous CA certificate and key in the cacerts secret as "+
+			"%s and %s", previousCACertID, previousCAKeyID)
+	}
+	if err := bundle.VerifyAndSetAll(signing.cert, signing.key, signing.chain, roots); err != nil {
+		pluggedCertRotatorLog.Errorf("failed to update the CA certificates for phase %s: %v", phase, err)
+		return
+	}
+	pluggedCertRotatorLog.Infof("CA rotation phase %s: the CA certificates are updated", phase)
+	if phase != RotationTrustBundle && r.previous != nil {
+		// The previous CA certificate no longer signs.
+		r.previous.key = nil
+	}
+	r.recordPhase(phase)
+	if phase != r.status.Phase {
+		r.status.Phase = phase
+		if err := r.updatePhase(phase); err != nil {
+			pluggedCertRotatorLog.Warnf("failed to update the phase of the CA rotation secret: %v", err)
+		}
+	}
+	if r.onUpdate != nil {
+		r.onUpdate()
+	}
+}
+
+// previousKey returns the key of the previous CA certificate, kept in memory or in the cacerts secret, or nil
+// if it is unknown.
+func (r *PluggedCertRotator) previousKey() []byte {
+	if r.previous == nil {
+		return nil
+	}
+	if len(r.previous.key) > 0 {
+		return r.previous.key
+	}
+	if r.pluggedPrevious != nil && bytes.Equal(r.pluggedPrevious.cert, r.previous.cert) {
+		return r.pluggedPrevious.key
+	}
+	return nil
+}
+
+func (r *PluggedCertRotator) recordPhase(phase RotationPhase) {
+	for _, p := range rotationPhases {
+		if p == phase {
+			caRotationPhase.With(phaseTag.Value(string(p))).Record(1)
+		} else {
+			caRotationPhase.With(phaseTag.Value(string(p))).Record(0)
+		}
+	}
+	caRotationPhaseTimestamp.With(phaseTag.Value(string(phase))).Record(float64(r.now().Unix()))
+}
+
+// saveRotation stores a new rotation in the CARotationSecret, without the previous CA key. If another Istiod
+// instance already started the rotation to the same CA certificate, its rotation is returned instead, with
+// the previous CA key of this instance if it signed with the same CA certificate.
+func (r *PluggedCertRotator) saveRotation(status *PluggedCertRotationStatus, previous *caCerts) (
+	*PluggedCertRotationStatus, *caCerts, error) {
+	status.Phase = status.phaseAt(r.now())
+	savedStatus, savedPrevious := status, previous
+	secrets := r.config.Client.Secrets(r.config.Namespace)
+	err := retry.OnError(retry.DefaultRetry, func(err error) bool {
+		return apierrors.IsConflict(err) || apierrors.IsAlreadyExists(err)
+	}, func() error {
+		secret, err := secrets.Get(context.TODO(), CARotationSecret, metav1.GetOptions{})
+		create := apierrors.IsNotFound(err)
+		if create {
+			secret = &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: CARotationSecret, Namespace: r.config.Namespace}}
+		} else if err != nil {
+			return err
+		}
+		if !create {
+			if existing, existingPrevious, err := loadRotation(secret); err == nil && existing.CACertHash == status.CACertHash {
+				if bytes.Equal(existingPrevious.cert, previous.cert) {
+					existingPrevious.key = previous.key
+				}
+				savedStatus, savedPrevious = existing, existingPrevious
+				return nil
+			}
+		}
+		data, err := json.Marshal(status)
+		if err != nil {
+			return err
+		}
+		secret.Data = map[string][]byte{
+			rotationStatusID:    data,
+			previousCACertID:    previous.cert,
+			previousCertChainID: previous.chain,
+			previousRootCertID:  previous.root,
+		}
+		if create {
+			_, err = secrets.Create(context.TODO(), secret, metav1.CreateOptions{})
+		} else {
+			_, err = secrets.Update(context.TODO(), secret, metav1.UpdateOptions{})
+		}
+		return err
+	})
+	return savedStatus, savedPrevious, err
+}
+
+// updatePhase updates the phase of the rotation in the CARotationSecret.
+func (r *PluggedCertRotator) updatePhase(phase RotationPhase) error {
+	secrets := r.config.Client.Secrets(r.config.Namespace)
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		secret, err := secrets.Get(context.TODO(), CARotationSecret, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		status, _, err := loadRotation(secret)
+		if err != nil {
+			return err
+		}
+		if status.CACertHash != r.status.CACertHash || status.Phase == phase {
+			return nil
+		}
+		status.Phase = phase
+		data, err := json.Marshal(status)
+		if err != nil {
+			return err
+		}
+		secret.Data[rotationStatusID] = data
+		_, err = secrets.Update(context.TODO(), secret, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+// loadRotation returns the rotation stored in a CARotationSecret. The key of the previous certificates is
+// nil.
+func loadRotation(secret *v1.Secret) (*PluggedCertRotationStatus, *caCerts, error) {
+	status := &PluggedCertRotationStatus{}
+	if err := json.Unmarshal(secret.Data[rotationStatusID], status); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse %s of secret %s/%s: %v", rotationStatusID, secret.Namespace, secret.Name, err)
+	}
+	previous := &caCerts{
+		cert:  secret.Data[previousCACertID],
+		chain: secret.Data[previousCertChainID],
+		root:  secret.Data[previousRootCertID],
+	}
+	return status, previous, nil
+}
+
+// certHash returns the hex encoded SHA-256 hash of a PEM encoded certificate.
+func certHash(cert []byte) string {
+	hash := sha256.Sum256(cert)
+	return hex.EncodeToString(hash[:])
+}
+
+// mergeRootCerts returns the PEM encoded certificates of a followed by the ones of b not in a.
+func mergeRootCerts(a, b []byte) []byte {
+	if containsRootCerts(a, b) {
+		return a
+	}
+	var merged []byte
+	seen := map[string]bool{}
+	for _, certs := range [][]byte{a, b} {
+		for _, block := range pemCertificates(certs) {
+			if seen[string(block.Bytes)] {
+				continue
+			}
+			seen[string(block.Bytes)] = true
+			merged = append(merged, pem.EncodeToMemory(block)...)
+		}
+	}
+	return merged
+}
+
+// containsRootCerts returns true if all the PEM encoded certificates of want are in have.
+func containsRootCerts(have, want []byte) bool {
+	certs := map[string]bool{}
+	for _, block := range pemCertificates(have) {
+		certs[string(block.Bytes)] = true
+	}
+	for _, block := range pemCertificates(want) {
+		if !certs[string(block.Bytes)] {
+			return false
+		}
+	}
+	return true
+}
+
+// pemCertificates returns the certificate blocks of PEM encoded data.
+func pemCertificates(data []byte) []*pem.Block {
+	var blocks []*pem.Block
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return blocks
+		}
+		if block.Type == "CERTIFICATE" {
+			blocks = append(blocks, block)
+		}
+	}
+}
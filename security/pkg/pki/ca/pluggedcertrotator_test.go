@@ -0,0 +1,176 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ca
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"istio.io/istio/security/pkg/pki/util"
+)
+
+func genRootCA(t *testing.T, org string) (cert, key []byte) {
+	t.Helper()
+	cert, key, err := util.GenCertKeyFromOptions(util.CertOptions{
+		IsCA:         true,
+		IsSelfSigned: true,
+		TTL:          24 * time.Hour,
+		Org:          org,
+		RSAKeySize:   2048,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, key
+}
+
+func newPluggedCertCA(t *testing.T, cert, key []byte) *IstioCA {
+	t.Helper()
+	bundle, err := util.NewVerifiedKeyCertBundleFromPem(cert, key, nil, cert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, err := NewIstioCA(&IstioCAOptions{
+		CAType:         pluggedCertCA,
+		DefaultCertTTL: time.Hour,
+		MaxCertTTL:     time.Hour,
+		KeyCertBundle:  bundle,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ca
+}
+
+func TestPluggedCertRotator(t *testing.T) {
+	const caNamespace = "istio-system"
+	oldCert, oldKey := genRootCA(t, "old root")
+	newCert, newKey := genRootCA(t, "new root")
+	client := fake.NewSimpleClientset()
+	config := &PluggedCertRotatorConfig{
+		Client:                       client.CoreV1(),
+		Namespace:                    caNamespace,
+		CheckInterval:                time.Minute,
+		TrustBundlePropagationPeriod: time.Hour,
+		RootGracePeriod:              2 * time.Hour,
+	}
+	now := time.Now()
+	clock := func() time.Time { return now }
+
+	ca := newPluggedCertCA(t, oldCert, oldKey)
+	updates := 0
+	rotator := NewPluggedCertRotator(config, ca, func() { updates++ })
+	rotator.now = clock
+
+	expectBundle := func(r *PluggedCertRotator, signingCert []byte, roots ...[]byte) {
+		t.Helper()
+		cert, _, _, root := r.ca.GetCAKeyCertBundle().GetAllPem()
+		if !bytes.Equal(cert, signingCert) {
+			t.Errorf("unexpected signing cert %s", cert)
+		}
+		if len(pemCertificates(root)) != len(roots) {
+			t.Errorf("expected %d root certs, got %s", len(roots), root)
+		}
+		for _, want := range roots {
+			if !containsRootCerts(root, want) {
+				t.Errorf("root cert %s is not trusted", want)
+			}
+		}
+	}
+
+	// The initial content of the secret is the one loaded at startup.
+	rotator.UpdateCACerts(&v1.Secret{Data: map[string][]byte{
+		caCertID: oldCert, caPrivateKeyID: oldKey, RootCertID: oldCert,
+	}})
+	expectBundle(rotator, oldCert, oldCert)
+	if updates != 0 {
+		t.Fatalf("unexpected CA update")
+	}
+
+	rotator.UpdateCACerts(&v1.Secret{Data: map[string][]byte{
+		caCertID: newCert, caPrivateKeyID: newKey, RootCertID: newCert,
+	}})
+	expectBundle(rotator, oldCert, oldCert, newCert)
+	if updates != 1 {
+		t.Fatalf("expected the trust bundle to be updated")
+	}
+	secret, err := client.CoreV1().Secrets(caNamespace).Get(context.TODO(), CARotationSecret, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	status, _, err := loadRotation(secret)
+	if err != nil || status.Phase != RotationTrustBundle {
+		t.Fatalf("unexpected rotation status %+v: %v", status, err)
+	}
+	for id, data := range secret.Data {
+		if bytes.Contains(data, []byte("PRIVATE KEY")) {
+			t.Errorf("the CA rotation secret stores a private key in %s", id)
+		}
+	}
+
+	// A restarted instance loads the new certificates and resumes the rotation. It does not know the
+	// previous CA key, unless it is kept in the cacerts secret.
+	restarted := NewPluggedCertRotator(config, newPluggedCertCA(t, newCert, newKey), nil)
+	restarted.now = clock
+	restarted.UpdateCACerts(&v1.Secret{Data: map[string][]byte{
+		caCertID: newCert, caPrivateKeyID: newKey, RootCertID: newCert,
+	}})
+	restarted.restore()
+	expectBundle(restarted, newCert, oldCert, newCert)
+
+	restarted = NewPluggedCertRotator(config, newPluggedCertCA(t, newCert, newKey), nil)
+	restarted.now = clock
+	restarted.UpdateCACerts(&v1.Secret{Data: map[string][]byte{
+		caCertID: newCert, caPrivateKeyID: newKey, RootCertID: newCert,
+		previousCACertID: oldCert, previousCAKeyID: oldKey,
+	}})
+	restarted.restore()
+	expectBundle(restarted, oldCert, oldCert, newCert)
+
+	now = now.Add(time.Hour)
+	rotator.apply()
+	expectBundle(rotator, newCert, oldCert, newCert)
+	if rotator.previousKey() != nil {
+		t.Errorf("expected the previous CA key to be dropped once the new CA certificate signs")
+	}
+
+	now = now.Add(2 * time.Hour)
+	rotator.apply()
+	expectBundle(rotator, newCert, newCert)
+	if updates != 3 {
+		t.Errorf("expected 3 CA updates, got %d", updates)
+	}
+}
+
+func TestMergeRootCerts(t *testing.T) {
+	a, _ := genRootCA(t, "a")
+	b, _ := genRootCA(t, "b")
+	if merged := mergeRootCerts(a, a); !bytes.Equal(merged, a) {
+		t.Errorf("expected merging the same root certs to be a no-op")
+	}
+	merged := mergeRootCerts(a, b)
+	if len(pemCertificates(merged)) != 2 || !containsRootCerts(merged, a) || !containsRootCerts(merged, b) {
+		t.Errorf("unexpected merged root certs %s", merged)
+	}
+	if containsRootCerts(a, merged) {
+		t.Errorf("expected %s not to contain both root certs", a)
+	}
+}
@@ -0,0 +1,265 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ca
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/util/retry"
+
+	caerror "istio.io/istio/security/pkg/pki/error"
+)
+
+const (
+	// CRLSecret stores the certificates revoked by the CA.
+	CRLSecret = "istio-ca-crl"
+	// revokedCertsID is the JSON encoded list of revoked certificates in CRLSecret.
+	revokedCertsID = "revoked-certs.json"
+	// RevokePath is the path of the Istiod admin endpoint revoking certificates.
+	RevokePath = "/ca/revoke"
+)
+
+// RevokeRequest is the body of a POST request to RevokePath.
+type RevokeRequest struct {
+	// SerialNumbers are the hex encoded serial numbers of the certificates to revoke.
+	SerialNumbers []string `json:"serialNumbers"`
+}
+
+// RevokeResponse is the response of RevokePath.
+type RevokeResponse struct {
+	// Revoked is the number of certificates revoked by the request.
+	Revoked int `json:"revoked"`
+	// AlreadyRevoked is the number of certificates of the request which were already revoked.
+	AlreadyRevoked int `json:"alreadyRevoked"`
+}
+
+// RevokedCertificate is a certificate revoked by the CA.
+type RevokedCertificate struct {
+	// SerialNumber is the hex encoded serial number of the certificate.
+	SerialNumber   string    `json:"serialNumber"`
+	RevocationTime time.Time `json:"revocationTime"`
+}
+
+// ParseSerialNumber parses a hex encoded certificate serial number. The bytes may be separated by
+// colons, as printed by openssl.
+func ParseSerialNumber(s string) (*big.Int, error) {
+	hex := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(s), ":", ""))
+	hex = strings.TrimPrefix(hex, "0x")
+	serial, ok := new(big.Int).SetString(hex, 16)
+	if !ok || serial.Sign() <= 0 {
+		return nil, fmt.Errorf("invalid certificate serial number %q", s)
+	}
+	return serial, nil
+}
+
+// FormatSerialNumber returns the hex encoding of a certificate serial number.
+func FormatSerialNumber(serial *big.Int) string {
+	return serial.Text(16)
+}
+
+// LoadRevokedCertificates returns the revoked certificates stored in a CRLSecret.
+func LoadRevokedCertificates(secret *v1.Secret) ([]RevokedCertificate, error) {
+	if secret == nil || len(secret.Data[revokedCertsID]) == 0 {
+		return nil, nil
+	}
+	var revoked []RevokedCertificate
+	if err := json.Unmarshal(secret.Data[revokedCertsID], &revoked); err != nil {
+		return nil, fmt.Errorf("failed to parse %s of secret %s/%s: %v", revokedCertsID, secret.Namespace, secret.Name, err)
+	}
+	for _, r := range revoked {
+		if _, err := ParseSerialNumber(r.SerialNumber); err != nil {
+			return nil, fmt.Errorf("invalid revoked certificate in secret %s/%s: %v", secret.Namespace, secret.Name, err)
+		}
+	}
+	return revoked, nil
+}
+
+// RevokeCertificates adds the certificates with the given serial numbers to the CRLSecret in the
+// namespace of the CA, creating it if needed. It returns the number of certificates which were not
+// already revoked.
+func RevokeCertificates(ctx context.Context, client corev1.CoreV1Interface, namespace string, serials []*big.Int) (int, error) {
+	added := 0
+	err := retry.OnError(retry.DefaultRetry, func(err error) bool {
+		return apierrors.IsConflict(err) || apierrors.IsAlreadyExists(err)
+	}, func() error {
+		secret, err := client.Secrets(namespace).Get(ctx, CRLSecret, metav1.GetOptions{})
+		create := apierrors.IsNotFound(err)
+		if create {
+			secret = &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: CRLSecret, Namespace: namespace}}
+		} else if err != nil {
+			return err
+		}
+		revoked, err := LoadRevokedCertificates(secret)
+		if err != nil {
+			return err
+		}
+		revoked, added = addRevokedCertificates(revoked, serials, time.Now())
+		if added == 0 {
+			return nil
+		}
+		data, err := json.Marshal(revoked)
+		if err != nil {
+			return err
+		}
+		if secret.Data == nil {
+			secret.Data = map[string][]byte{}
+		}
+		secret.Data[revokedCertsID] = data
+		if create {
+			_, err = client.Secrets(namespace).Create(ctx, secret, metav1.CreateOptions{})
+		} else {
+			_, err = client.Secrets(namespace).Update(ctx, secret, metav1.UpdateOptions{})
+		}
+		return err
+	})
+	return added, err
+}
+
+// addRevokedCertificates adds the serials which are not revoked yet to revoked, sorted by serial.
+func addRevokedCertificates(revoked []RevokedCertificate, serials []*big.Int, now time.Time) ([]RevokedCertificate, int) {
+	existing := make(map[string]bool, len(revoked))
+	for _, r := range revoked {
+		if serial, err := ParseSerialNumber(r.SerialNumber); err == nil {
+			existing[FormatSerialNumber(serial)] = true
+		}
+	}
+	added := 0
+	for _, serial := range serials {
+		s := FormatSerialNumber(serial)
+		if existing[s] {
+			continue
+		}
+		existing[s] = true
+		revoked = append(revoked, RevokedCertificate{SerialNumber: s, RevocationTime: now.UTC().Truncate(time.Second)})
+		added++
+	}
+	sort.Slice(revoked, func(i, j int) bool {
+		a, b := revoked[i].SerialNumber, revoked[j].SerialNumber
+		return len(a) < len(b) || len(a) == len(b) && a < b
+	})
+	return revoked, added
+}
+
+// SetRevokedCertificates sets the certificates revoked by the CA, as loaded from the CRLSecret. It
+// returns true if they changed.
+func (ca *IstioCA) SetRevokedCertificates(revoked []RevokedCertificate) bool {
+	ca.crlMutex.Lock()
+	defer ca.crlMutex.Unlock()
+	if reflect.DeepEqual(ca.revoked, revoked) {
+		return false
+	}
+	ca.revoked = revoked
+	ca.crl = nil
+	return true
+}
+
+// RevokedCertificates returns the certificates revoked by the CA.
+func (ca *IstioCA) RevokedCertificates() []RevokedCertificate {
+	ca.crlMutex.Lock()
+	defer ca.crlMutex.Unlock()
+	return append([]RevokedCertificate(nil), ca.revoked...)
+}
+
+// CRLSupported returns an error if the CA cannot sign a CRL accepted by the proxies.
+// Once a CRL is configured, Envoy requires one for every CA of a certificate chain, and the CA does not
+// hold the key of the root cert of an intermediate CA, so revocation is only supported when the signing
+// cert is a root cert.
+func (ca *IstioCA) CRLSupported() error {
+	signingCert, _, certChain, _ := ca.keyCertBundle.GetAll()
+	if signingCert == nil {
+		return caerror.NewError(caerror.CANotReady, fmt.Errorf("Istio CA is not ready")) // nolint
+	}
+	if len(certChain) > 0 {
+		return fmt.Errorf("certificate revocation is not supported with an intermediate CA certificate")
+	}
+	if signingCert.KeyUsage != 0 && signingCert.KeyUsage&x509.KeyUsageCRLSign == 0 {
+		return fmt.Errorf("the CA certificate is not allowed to sign CRLs")
+	}
+	return nil
+}
+
+// GetCRL returns the PEM encoded CRL of the revoked certificates, signed by the signing cert of the
+// CA, followed by the CRL signed by the previous signing cert if it was rotated since. It returns nil
+// if no certificate is revoked, and an error if CRLSupported does.
+func (ca *IstioCA) GetCRL() ([]byte, error) {
+	ca.crlMutex.Lock()
+	defer ca.crlMutex.Unlock()
+	if len(ca.revoked) == 0 {
+		return nil, nil
+	}
+	if err := ca.CRLSupported(); err != nil {
+		return nil, err
+	}
+	signingCert, signingKey, _, _ := ca.keyCertBundle.GetAll()
+
+	now := time.Now()
+	if ca.crlSigner != nil && !bytes.Equal(ca.crlSigner.Raw, signingCert.Raw) {
+		// The signing cert was rotated. Keep the last CRL of the previous one, which still applies to the
+		// certificates it signed.
+		if ca.crl != nil {
+			ca.previousCRL = ca.crl
+			ca.previousCRLExpiry = ca.crlSigner.NotAfter
+		}
+		ca.crl = nil
+	}
+	if ca.crl == nil {
+		crl, err := generateCRL(ca.revoked, signingCert, *signingKey, now)
+		if err != nil {
+			return nil, caerror.NewError(caerror.CertGenError, err)
+		}
+		ca.crl = crl
+		ca.crlSigner = signingCert
+	}
+	if ca.previousCRL != nil && now.After(ca.previousCRLExpiry) {
+		ca.previousCRL = nil
+	}
+	return append(append([]byte(nil), ca.crl...), ca.previousCRL...), nil
+}
+
+// generateCRL returns a PEM encoded CRL of the revoked certificates, signed by the signing cert. It is
+// valid as long as the signing cert is, as a new one is generated when the revoked certificates change.
+func generateCRL(revoked []RevokedCertificate, signingCert *x509.Certificate, signingKey crypto.PrivateKey,
+	now time.Time) ([]byte, error) {
+	entries := make([]pkix.RevokedCertificate, 0, len(revoked))
+	for _, r := range revoked {
+		serial, err := ParseSerialNumber(r.SerialNumber)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, pkix.RevokedCertificate{SerialNumber: serial, RevocationTime: r.RevocationTime})
+	}
+	der, err := signingCert.CreateCRL(rand.Reader, signingKey, entries, now, signingCert.NotAfter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the CRL: %v", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: der}), nil
+}
@@ -0,0 +1,149 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ca
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"istio.io/istio/security/pkg/pki/util"
+)
+
+func TestParseSerialNumber(t *testing.T) {
+	cases := []struct {
+		in   string
+		want int64
+	}{
+		{"1f", 31},
+		{"0x1F", 31},
+		{"01:00", 256},
+		{" ab:CD ", 0xabcd},
+	}
+	for _, tc := range cases {
+		got, err := ParseSerialNumber(tc.in)
+		if err != nil {
+			t.Errorf("ParseSerialNumber(%q): %v", tc.in, err)
+			continue
+		}
+		if got.Int64() != tc.want {
+			t.Errorf("ParseSerialNumber(%q): got %v, want %v", tc.in, got, tc.want)
+		}
+	}
+	for _, in := range []string{"", "xyz", "0", "-1"} {
+		if _, err := ParseSerialNumber(in); err == nil {
+			t.Errorf("ParseSerialNumber(%q): expected an error", in)
+		}
+	}
+}
+
+func TestRevokeCertificates(t *testing.T) {
+	const caNamespace = "istio-system"
+	client := fake.NewSimpleClientset()
+
+	added, err := RevokeCertificates(context.Background(), client.CoreV1(), caNamespace, []*big.Int{big.NewInt(0x20), big.NewInt(0x10)})
+	if err != nil || added != 2 {
+		t.Fatalf("RevokeCertificates: got %d, %v; want 2 revoked certificates", added, err)
+	}
+	added, err = RevokeCertificates(context.Background(), client.CoreV1(), caNamespace, []*big.Int{big.NewInt(0x10), big.NewInt(0x30)})
+	if err != nil || added != 1 {
+		t.Fatalf("RevokeCertificates: got %d, %v; want 1 revoked certificate", added, err)
+	}
+
+	secret, err := client.CoreV1().Secrets(caNamespace).Get(context.Background(), CRLSecret, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	revoked, err := LoadRevokedCertificates(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var serials []string
+	for _, r := range revoked {
+		serials = append(serials, r.SerialNumber)
+		if r.RevocationTime.IsZero() {
+			t.Errorf("missing revocation time of %s", r.SerialNumber)
+		}
+	}
+	if len(serials) != 3 || serials[0] != "10" || serials[1] != "20" || serials[2] != "30" {
+		t.Errorf("unexpected revoked certificates %v", serials)
+	}
+}
+
+func TestGetCRL(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	caopts, err := NewSelfSignedIstioCAOptions(context.Background(),
+		0, time.Hour, time.Hour, 30*time.Minute, time.Hour, "test.ca.Org", false, "default", -1,
+		client.CoreV1(), "", false, 2048)
+	if err != nil {
+		t.Fatalf("Failed to create a self-signed CA Options: %v", err)
+	}
+	ca, err := NewIstioCA(caopts)
+	if err != nil {
+		t.Fatalf("Failed to create a self-signed CA: %v", err)
+	}
+
+	if crl, err := ca.GetCRL(); err != nil || crl != nil {
+		t.Fatalf("expected no CRL without revoked certificates, got %q, %v", crl, err)
+	}
+	revoked := []RevokedCertificate{{SerialNumber: "1f", RevocationTime: time.Now().UTC().Truncate(time.Second)}}
+	if !ca.SetRevokedCertificates(revoked) {
+		t.Fatalf("expected the revoked certificates to change")
+	}
+	if ca.SetRevokedCertificates(revoked) {
+		t.Fatalf("expected the revoked certificates not to change")
+	}
+
+	crlPEM, err := ca.GetCRL()
+	if err != nil {
+		t.Fatalf("GetCRL: %v", err)
+	}
+	block, rest := pem.Decode(crlPEM)
+	if block == nil || block.Type != "X509 CRL" || len(rest) != 0 {
+		t.Fatalf("unexpected CRL %q", crlPEM)
+	}
+	crl, err := x509.ParseCRL(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signingCert, _, _, _ := ca.GetCAKeyCertBundle().GetAll()
+	if err := signingCert.CheckCRLSignature(crl); err != nil {
+		t.Errorf("CRL is not signed by the CA: %v", err)
+	}
+	entries := crl.TBSCertList.RevokedCertificates
+	if len(entries) != 1 || entries[0].SerialNumber.Int64() != 0x1f {
+		t.Errorf("unexpected revoked certificates %v", entries)
+	}
+}
+
+func TestGetCRLIntermediateCA(t *testing.T) {
+	ca, err := createCA(time.Hour, util.EcdsaSigAlg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ca.CRLSupported(); err == nil {
+		t.Errorf("expected revocation to be unsupported with an intermediate CA")
+	}
+	ca.SetRevokedCertificates([]RevokedCertificate{{SerialNumber: "1f", RevocationTime: time.Now()}})
+	if _, err := ca.GetCRL(); err == nil {
+		t.Errorf("expected revocation to be rejected with an intermediate CA")
+	}
+}
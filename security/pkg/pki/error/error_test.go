@@ -52,6 +52,12 @@ func TestError(t *testing.T) {
 			message: "CERT_GEN_ERROR",
 			code:    codes.Internal,
 		},
+		"CERT_POLICY_ERROR": {
+			eType:   CertPolicyError,
+			err:     fmt.Errorf("test error6"),
+			message: "CERT_POLICY_ERROR",
+			code:    codes.InvalidArgument,
+		},
 		"UNKNOWN": {
 			eType:   -1,
 			err:     fmt.Errorf("test error5"),
@@ -20,6 +20,7 @@ import (
 	certificatesv1beta1 "k8s.io/client-go/kubernetes/typed/certificates/v1beta1"
 
 	"istio.io/istio/security/pkg/pki/util"
+	"istio.io/istio/security/pkg/pki/vault"
 	caserver "istio.io/istio/security/pkg/server/ca"
 )
 
@@ -47,6 +48,8 @@ type IstioRAOptions struct {
 	VerifyAppendCA bool
 	// K8sClient : K8s API client
 	K8sClient certificatesv1beta1.CertificatesV1beta1Interface
+	// Vault : Vault client configuration when using an external Vault PKI CA
+	Vault vault.Config
 }
 
 const (
@@ -56,6 +59,9 @@ const (
 	// ExtCAGrpc : Integration with external CA using Istio CA gRPC API
 	ExtCAGrpc CaExternalType = "ISTIOD_RA_ISTIO_API"
 
+	// ExtCAVault : Integration with external CA using a HashiCorp Vault PKI secrets engine
+	ExtCAVault CaExternalType = "ISTIOD_RA_VAULT_API"
+
 	// DefaultExtCACertDir : Location of external CA certificate
 	DefaultExtCACertDir string = "./etc/external-ca-cert"
 )
@@ -96,5 +102,12 @@ func NewIstioRA(opts *IstioRAOptions) (RegistrationAuthority, error) {
 		}
 		return istioRA, err
 	}
+	if opts.ExternalCAType == ExtCAVault {
+		istioRA, err := NewVaultRA(opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create a Vault CA: %v", err)
+		}
+		return istioRA, err
+	}
 	return nil, fmt.Errorf("invalid CA Name %s", opts.ExternalCAType)
 }
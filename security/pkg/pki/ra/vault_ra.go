@@ -0,0 +1,115 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ra
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	raerror "istio.io/istio/security/pkg/pki/error"
+	"istio.io/istio/security/pkg/pki/util"
+	"istio.io/istio/security/pkg/pki/vault"
+)
+
+// vaultSignTimeout is the timeout of a CSR signing by Vault, including the login.
+const vaultSignTimeout = 30 * time.Second
+
+// VaultRA integrated with an external CA using a HashiCorp Vault PKI secrets engine
+type VaultRA struct {
+	client        *vault.Client
+	keyCertBundle util.KeyCertBundle
+	raOpts        *IstioRAOptions
+}
+
+// NewVaultRA : Create a RA that forwards CSRs to a Vault PKI secrets engine
+func NewVaultRA(raOpts *IstioRAOptions) (*VaultRA, error) {
+	keyCertBundle, err := util.NewKeyCertBundleWithRootCertFromFile(raOpts.CaCertFile)
+	if err != nil {
+		return nil, raerror.NewError(raerror.CAInitFail, fmt.Errorf("error processing Certificate Bundle for Vault RA"))
+	}
+	client, err := vault.NewClient(raOpts.Vault)
+	if err != nil {
+		return nil, raerror.NewError(raerror.CAIllegalConfig, err)
+	}
+	istioRA := &VaultRA{client: client,
+		raOpts:        raOpts,
+		keyCertBundle: keyCertBundle}
+	return istioRA, nil
+}
+
+// Sign takes a PEM-encoded CSR, subject IDs and lifetime, and returns a certificate signed by Vault,
+// followed by the intermediate CAs returned by Vault.
+func (r *VaultRA) Sign(csrPEM []byte, subjectIDs []string, requestedLifetime time.Duration, forCA bool) ([]byte, error) {
+	if forCA {
+		return nil, raerror.NewError(raerror.CSRError, fmt.Errorf(
+			"unable to generate CA certifificates"))
+	}
+
+	if !ValidateCSR(csrPEM, subjectIDs) {
+		return nil, raerror.NewError(raerror.CSRError, fmt.Errorf(
+			"unable to validate SAN Identities in CSR"))
+	}
+
+	// If the requested lifetime is non-positive, apply the default TTL.
+	lifetime := requestedLifetime
+	if requestedLifetime.Seconds() <= 0 {
+		lifetime = r.raOpts.DefaultCertTTL
+	}
+	// If the requested TTL is greater than maxCertTTL, return an error
+	if lifetime.Seconds() > r.raOpts.MaxCertTTL.Seconds() {
+		return nil, raerror.NewError(raerror.TTLError, fmt.Errorf(
+			"requested TTL %s is greater than the max allowed TTL %s", lifetime, r.raOpts.MaxCertTTL))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), vaultSignTimeout)
+	defer cancel()
+	chain, err := r.client.SignCSR(ctx, csrPEM, lifetime, "")
+	if err != nil {
+		return nil, raerror.NewError(raerror.CertGenError, err)
+	}
+
+	// The root cert is appended by the caller, from the key cert bundle.
+	rootCert := bytes.TrimSpace(r.GetCAKeyCertBundle().GetRootCertPem())
+	var cert []byte
+	for _, c := range chain {
+		c = strings.TrimSpace(c)
+		if len(rootCert) > 0 && c == string(rootCert) {
+			continue
+		}
+		cert = append(cert, []byte(c+"\n")...)
+	}
+	return cert, nil
+}
+
+// SignWithCertChain is similar to Sign but returns the leaf cert and the entire cert chain.
+func (r *VaultRA) SignWithCertChain(csrPEM []byte, subjectIDs []string, ttl time.Duration, forCA bool) ([]byte, error) {
+	cert, err := r.Sign(csrPEM, subjectIDs, ttl, forCA)
+	if err != nil {
+		return nil, err
+	}
+	chainPem := r.GetCAKeyCertBundle().GetCertChainPem()
+	if len(chainPem) > 0 {
+		cert = append(cert, chainPem...)
+	}
+	return cert, nil
+}
+
+// GetCAKeyCertBundle returns the KeyCertBundle for the CA.
+func (r *VaultRA) GetCAKeyCertBundle() util.KeyCertBundle {
+	return r.keyCertBundle
+}
@@ -0,0 +1,91 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ra
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"istio.io/istio/security/pkg/pki/vault"
+	"istio.io/istio/security/pkg/pki/vault/mock"
+)
+
+func createFakeVaultRA(t *testing.T, server *mock.VaultServer) *VaultRA {
+	secretIDPath := filepath.Join(t.TempDir(), "secret-id")
+	if err := ioutil.WriteFile(secretIDPath, []byte("secret-id"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	raOpts := &IstioRAOptions{
+		ExternalCAType: ExtCAVault,
+		DefaultCertTTL: 30 * time.Minute,
+		MaxCertTTL:     time.Hour,
+		CaCertFile:     TestCACertFile,
+		Vault: vault.Config{
+			Address:      server.URL,
+			AuthMethod:   vault.AuthAppRole,
+			RoleID:       "role-id",
+			SecretIDPath: secretIDPath,
+			SignCSRPath:  "pki/sign/istio",
+		},
+	}
+	r, err := NewIstioRA(raOpts)
+	if err != nil {
+		t.Fatalf("failed to create the Vault RA: %v", err)
+	}
+	return r.(*VaultRA)
+}
+
+// TestVaultSign : Verify that the Vault RA forwards the CSR to Vault and returns the signed cert
+// without the root cert
+func TestVaultSign(t *testing.T) {
+	rootCert, err := ioutil.ReadFile(TestCACertFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := mock.NewVaultServer("pki/sign/istio")
+	defer server.Close()
+	server.RoleID = "role-id"
+	server.SecretID = "secret-id"
+	server.Certificate = TestCertificatePEM
+	server.CAChain = []string{string(rootCert)}
+
+	r := createFakeVaultRA(t, server)
+	csrPEM := createFakeCsr(t)
+
+	cert, err := r.Sign(csrPEM, []string{testCsrHostName}, 0, false)
+	if err != nil {
+		t.Fatalf("Vault CA Signing CSR failed: %v", err)
+	}
+	if strings.TrimSpace(string(cert)) != strings.TrimSpace(TestCertificatePEM) {
+		t.Errorf("unexpected signed cert:\n%s", cert)
+	}
+	requests := server.SignRequests()
+	if len(requests) != 1 || requests[0].CSR != string(csrPEM) || requests[0].TTL != "1800s" {
+		t.Errorf("unexpected sign requests %+v", requests)
+	}
+
+	if _, err := r.Sign(csrPEM, []string{"Random-Host-Name"}, 0, false); err == nil {
+		t.Errorf("expected the CSR with unauthenticated identities to be rejected")
+	}
+	if _, err := r.Sign(csrPEM, []string{testCsrHostName}, 2*time.Hour, false); err == nil {
+		t.Errorf("expected the TTL greater than the max TTL to be rejected")
+	}
+	if _, err := r.Sign(csrPEM, []string{testCsrHostName}, 0, true); err == nil {
+		t.Errorf("expected the CA cert request to be rejected")
+	}
+}
@@ -47,6 +47,16 @@ const (
 	EcdsaSigAlg SupportedECSignatureAlgorithms = "ECDSA"
 )
 
+// SupportedEllipticCurves are the elliptic curves of the ECC keys.
+type SupportedEllipticCurves string
+
+const (
+	// P256Curve is the NIST P-256 curve, used by default.
+	P256Curve SupportedEllipticCurves = "P256"
+	// P384Curve is the NIST P-384 curve.
+	P384Curve SupportedEllipticCurves = "P384"
+)
+
 // CertOptions contains options for generating a new certificate.
 type CertOptions struct {
 	// Comma-separated hostnames and IPs to generate a certificate for.
@@ -97,6 +107,9 @@ type CertOptions struct {
 	// when generating private keys. Currently only ECDSA is supported.
 	// If empty, RSA is used, otherwise ECC is used.
 	ECSigAlg SupportedECSignatureAlgorithms
+
+	// The elliptic curve of the ECC keys. If empty, P256 is used.
+	ECCCurve SupportedEllipticCurves
 }
 
 // GenCertKeyFromOptions generates a X.509 certificate and a private key with the given options.
@@ -111,7 +124,11 @@ func GenCertKeyFromOptions(options CertOptions) (pemCert []byte, pemKey []byte,
 
 		switch options.ECSigAlg {
 		case EcdsaSigAlg:
-			ecPriv, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+			curve, curveErr := ellipticCurve(options.ECCCurve)
+			if curveErr != nil {
+				return nil, nil, fmt.Errorf("cert generation fails at EC key generation (%v)", curveErr)
+			}
+			ecPriv, err = ecdsa.GenerateKey(curve, rand.Reader)
 			if err != nil {
 				return nil, nil, fmt.Errorf("cert generation fails at EC key generation (%v)", err)
 			}
@@ -131,6 +148,18 @@ func GenCertKeyFromOptions(options CertOptions) (pemCert []byte, pemKey []byte,
 	return genCert(options, rsaPriv, &rsaPriv.PublicKey)
 }
 
+// ellipticCurve returns the elliptic curve of the given name, P256 if empty.
+func ellipticCurve(name SupportedEllipticCurves) (elliptic.Curve, error) {
+	switch name {
+	case "", P256Curve:
+		return elliptic.P256(), nil
+	case P384Curve:
+		return elliptic.P384(), nil
+	default:
+		return nil, fmt.Errorf("unsupported elliptic curve %s", name)
+	}
+}
+
 func genCert(options CertOptions, priv interface{}, key interface{}) ([]byte, []byte, error) {
 	template, err := genCertTemplateFromOptions(options)
 	if err != nil {
@@ -261,8 +290,9 @@ func genCertTemplateFromCSR(csr *x509.CertificateRequest, subjectIDs []string, t
 	var keyUsage x509.KeyUsage
 	extKeyUsages := []x509.ExtKeyUsage{}
 	if isCA {
-		// If the cert is a CA cert, the private key is allowed to sign other certificates.
-		keyUsage = x509.KeyUsageCertSign
+		// If the cert is a CA cert, the private key is allowed to sign other certificates, and the
+		// revocation lists of the certificates it signed.
+		keyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign
 	} else {
 		// Otherwise the private key is allowed for digital signature and key encipherment.
 		keyUsage = x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment
@@ -313,8 +343,9 @@ func genCertTemplateFromCSR(csr *x509.CertificateRequest, subjectIDs []string, t
 func genCertTemplateFromOptions(options CertOptions) (*x509.Certificate, error) {
 	var keyUsage x509.KeyUsage
 	if options.IsCA {
-		// If the cert is a CA cert, the private key is allowed to sign other certificates.
-		keyUsage = x509.KeyUsageCertSign
+		// If the cert is a CA cert, the private key is allowed to sign other certificates, and the
+		// revocation lists of the certificates it signed.
+		keyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign
 	} else {
 		// Otherwise the private key is allowed for digital signature and key encipherment.
 		keyUsage = x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment
@@ -0,0 +1,267 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package vault is a minimal client of the HashiCorp Vault HTTP API, to sign CSRs with a Vault PKI
+// secrets engine.
+package vault
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"istio.io/pkg/log"
+)
+
+var vaultClientLog = log.RegisterScope("vault", "Vault client debugging", 0)
+
+// AuthMethod is the Vault auth method used to get a Vault token.
+type AuthMethod string
+
+const (
+	// AuthKubernetes logs in with a Kubernetes service account token.
+	AuthKubernetes AuthMethod = "kubernetes"
+	// AuthAppRole logs in with an AppRole role ID and secret ID.
+	AuthAppRole AuthMethod = "approle"
+
+	httpTimeout = 10 * time.Second
+	// tokenExpiryMargin is how long before its expiration a cached Vault token is renewed by logging in again.
+	tokenExpiryMargin = 30 * time.Second
+)
+
+// Config is the configuration of a Vault client.
+type Config struct {
+	// Address is the address of the Vault server, e.g. https://vault.example.com:8200.
+	Address string
+	// TLSRootCert is the PEM encoded root certificate used to verify the Vault server. If empty,
+	// the system roots are used.
+	TLSRootCert []byte
+	// AuthMethod is the auth method to log in with.
+	AuthMethod AuthMethod
+	// AuthPath is the mount path of the auth method. Defaults to auth/<AuthMethod>.
+	AuthPath string
+	// Role is the Vault role to log in with, for the Kubernetes auth method.
+	Role string
+	// JWTPath is the file containing the service account token, for the Kubernetes auth method.
+	// Not needed if the token is passed when signing.
+	JWTPath string
+	// RoleID is the role ID, for the AppRole auth method.
+	RoleID string
+	// SecretIDPath is the file containing the secret ID, for the AppRole auth method.
+	SecretIDPath string
+	// SignCSRPath is the path of the PKI sign endpoint, e.g. pki/sign/istio.
+	SignCSRPath string
+}
+
+// Client signs CSRs with a Vault PKI secrets engine.
+type Client struct {
+	config     Config
+	httpClient *http.Client
+
+	mutex       sync.Mutex
+	token       string
+	tokenExpiry time.Time
+}
+
+// NewClient creates a Vault client.
+func NewClient(config Config) (*Client, error) {
+	if config.Address == "" {
+		return nil, fmt.Errorf("vault address is not set")
+	}
+	if config.SignCSRPath == "" {
+		return nil, fmt.Errorf("vault sign CSR path is not set")
+	}
+	switch config.AuthMethod {
+	case AuthKubernetes:
+		if config.Role == "" {
+			return nil, fmt.Errorf("vault role is required by the %s auth method", config.AuthMethod)
+		}
+	case AuthAppRole:
+		if config.RoleID == "" || config.SecretIDPath == "" {
+			return nil, fmt.Errorf("role ID and secret ID are required by the %s auth method", config.AuthMethod)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported vault auth method %q", config.AuthMethod)
+	}
+	if config.AuthPath == "" {
+		config.AuthPath = "auth/" + string(config.AuthMethod)
+	}
+
+	tlsConfig := &tls.Config{}
+	if len(config.TLSRootCert) > 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(config.TLSRootCert) {
+			return nil, fmt.Errorf("failed to append the vault TLS root certificate")
+		}
+		tlsConfig.RootCAs = pool
+	}
+	return &Client{
+		config: config,
+		httpClient: &http.Client{
+			Timeout:   httpTimeout,
+			Transport: &http.Transport{TLSClientConfig: tlsConfig},
+		},
+	}, nil
+}
+
+type loginResponse struct {
+	Auth struct {
+		ClientToken   string `json:"client_token"`
+		LeaseDuration int64  `json:"lease_duration"`
+	} `json:"auth"`
+}
+
+type signResponse struct {
+	Data struct {
+		Certificate string   `json:"certificate"`
+		IssuingCA   string   `json:"issuing_ca"`
+		CAChain     []string `json:"ca_chain"`
+	} `json:"data"`
+}
+
+type errorResponse struct {
+	Errors []string `json:"errors"`
+}
+
+// SignCSR signs a PEM encoded CSR and returns the PEM encoded certificate chain, starting with the
+// signed certificate. If jwt is set and the auth method is Kubernetes, it is used to log in instead of
+// the configured token file.
+func (c *Client) SignCSR(ctx context.Context, csrPEM []byte, ttl time.Duration, jwt string) ([]string, error) {
+	token, err := c.getToken(ctx, jwt)
+	if err != nil {
+		return nil, err
+	}
+	req := map[string]interface{}{
+		"csr":    string(csrPEM),
+		"format": "pem",
+	}
+	if ttl > 0 {
+		req["ttl"] = fmt.Sprintf("%ds", int64(ttl.Seconds()))
+	}
+	resp := &signResponse{}
+	if err := c.call(ctx, c.config.SignCSRPath, token, req, resp); err != nil {
+		return nil, fmt.Errorf("failed to sign CSR with vault: %v", err)
+	}
+	if resp.Data.Certificate == "" {
+		return nil, fmt.Errorf("vault returned an empty certificate")
+	}
+	chain := []string{resp.Data.Certificate}
+	if len(resp.Data.CAChain) > 0 {
+		chain = append(chain, resp.Data.CAChain...)
+	} else if resp.Data.IssuingCA != "" {
+		chain = append(chain, resp.Data.IssuingCA)
+	}
+	return chain, nil
+}
+
+// getToken returns a Vault token, logging in with jwt if set and the auth method is Kubernetes, or with
+// the configured credentials if the cached token is missing or about to expire.
+func (c *Client) getToken(ctx context.Context, jwt string) (string, error) {
+	if jwt != "" && c.config.AuthMethod == AuthKubernetes {
+		token, _, err := c.login(ctx, map[string]interface{}{"role": c.config.Role, "jwt": jwt})
+		return token, err
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	// A zero expiry is a token which never expires.
+	if c.token != "" && (c.tokenExpiry.IsZero() || time.Now().Add(tokenExpiryMargin).Before(c.tokenExpiry)) {
+		return c.token, nil
+	}
+	var req map[string]interface{}
+	switch c.config.AuthMethod {
+	case AuthKubernetes:
+		if c.config.JWTPath == "" {
+			return "", fmt.Errorf("no token to log in to vault")
+		}
+		jwt, err := ioutil.ReadFile(c.config.JWTPath)
+		if err != nil {
+			return "", fmt.Errorf("failed to read the service account token: %v", err)
+		}
+		req = map[string]interface{}{"role": c.config.Role, "jwt": strings.TrimSpace(string(jwt))}
+	case AuthAppRole:
+		secretID, err := ioutil.ReadFile(c.config.SecretIDPath)
+		if err != nil {
+			return "", fmt.Errorf("failed to read the approle secret ID: %v", err)
+		}
+		req = map[string]interface{}{"role_id": c.config.RoleID, "secret_id": strings.TrimSpace(string(secretID))}
+	}
+	token, lease, err := c.login(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	c.token = token
+	c.tokenExpiry = time.Time{}
+	if lease > 0 {
+		c.tokenExpiry = time.Now().Add(lease)
+	}
+	return token, nil
+}
+
+func (c *Client) login(ctx context.Context, req map[string]interface{}) (string, time.Duration, error) {
+	resp := &loginResponse{}
+	if err := c.call(ctx, c.config.AuthPath+"/login", "", req, resp); err != nil {
+		return "", 0, fmt.Errorf("failed to log in to vault: %v", err)
+	}
+	if resp.Auth.ClientToken == "" {
+		return "", 0, fmt.Errorf("failed to log in to vault: empty client token")
+	}
+	vaultClientLog.Debugf("logged in to vault with %s, lease duration %ds", c.config.AuthPath, resp.Auth.LeaseDuration)
+	return resp.Auth.ClientToken, time.Duration(resp.Auth.LeaseDuration) * time.Second, nil
+}
+
+// call sends a request to a Vault API path and decodes the response into out.
+func (c *Client) call(ctx context.Context, path, token string, in, out interface{}) error {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	url := strings.TrimSuffix(c.config.Address, "/") + "/v1/" + strings.TrimPrefix(path, "/")
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		errResp := &errorResponse{}
+		if err := json.Unmarshal(respBody, errResp); err == nil && len(errResp.Errors) > 0 {
+			return fmt.Errorf("HTTP status %s: %s", resp.Status, strings.Join(errResp.Errors, "; "))
+		}
+		return fmt.Errorf("HTTP status %s", resp.Status)
+	}
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("failed to unmarshal response: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,184 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vault
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"istio.io/istio/security/pkg/pki/vault/mock"
+)
+
+func newFakeVault() *mock.VaultServer {
+	s := mock.NewVaultServer("pki/sign/istio")
+	s.Role = "istiod"
+	s.JWT = "k8s-jwt"
+	s.RoleID = "role-id"
+	s.SecretID = "secret-id"
+	s.Certificate = "leaf"
+	s.IssuingCA = "intermediate"
+	return s
+}
+
+func writeFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "file")
+	if err := ioutil.WriteFile(path, []byte(content+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestSignCSR(t *testing.T) {
+	cases := []struct {
+		name      string
+		config    Config
+		jwt       string
+		caChain   []string
+		wantChain []string
+		wantErr   string
+	}{
+		{
+			name: "approle",
+			config: Config{
+				AuthMethod:   AuthAppRole,
+				RoleID:       "role-id",
+				SecretIDPath: writeFile(t, "secret-id"),
+			},
+			wantChain: []string{"leaf", "intermediate"},
+		},
+		{
+			name: "kubernetes with token file",
+			config: Config{
+				AuthMethod: AuthKubernetes,
+				Role:       "istiod",
+				JWTPath:    writeFile(t, "k8s-jwt"),
+			},
+			caChain:   []string{"intermediate", "root"},
+			wantChain: []string{"leaf", "intermediate", "root"},
+		},
+		{
+			name: "kubernetes with token of the request",
+			config: Config{
+				AuthMethod: AuthKubernetes,
+				Role:       "istiod",
+			},
+			jwt:       "k8s-jwt",
+			wantChain: []string{"leaf", "intermediate"},
+		},
+		{
+			name: "wrong credentials",
+			config: Config{
+				AuthMethod:   AuthAppRole,
+				RoleID:       "role-id",
+				SecretIDPath: writeFile(t, "wrong"),
+			},
+			wantErr: "invalid role or secret ID",
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newFakeVault()
+			defer server.Close()
+			server.CAChain = tt.caChain
+			tt.config.Address = server.URL
+			tt.config.SignCSRPath = "pki/sign/istio"
+			c, err := NewClient(tt.config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			chain, err := c.SignCSR(context.Background(), []byte("csr"), time.Hour, tt.jwt)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(chain, tt.wantChain) {
+				t.Errorf("got chain %v, want %v", chain, tt.wantChain)
+			}
+			requests := server.SignRequests()
+			if last := requests[len(requests)-1]; last.CSR != "csr" || last.TTL != "3600s" {
+				t.Errorf("unexpected sign request %+v", last)
+			}
+		})
+	}
+}
+
+func TestTokenCache(t *testing.T) {
+	cases := []struct {
+		name          string
+		leaseDuration int64
+		wantLogins    int
+	}{
+		{"token is cached", 3600, 1},
+		{"token without lease never expires", 0, 1},
+		{"token about to expire is renewed", 1, 3},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newFakeVault()
+			defer server.Close()
+			server.LeaseDuration = tt.leaseDuration
+
+			c, err := NewClient(Config{
+				Address:      server.URL,
+				AuthMethod:   AuthAppRole,
+				RoleID:       "role-id",
+				SecretIDPath: writeFile(t, "secret-id"),
+				SignCSRPath:  "pki/sign/istio",
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := 0; i < 3; i++ {
+				if _, err := c.SignCSR(context.Background(), []byte("csr"), 0, ""); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got := server.Logins(); got != tt.wantLogins {
+				t.Errorf("got %d logins, want %d", got, tt.wantLogins)
+			}
+		})
+	}
+}
+
+func TestNewClientValidation(t *testing.T) {
+	cases := []struct {
+		name   string
+		config Config
+	}{
+		{"no address", Config{SignCSRPath: "pki/sign/istio", AuthMethod: AuthKubernetes, Role: "r"}},
+		{"no sign path", Config{Address: "http://vault", AuthMethod: AuthKubernetes, Role: "r"}},
+		{"no role", Config{Address: "http://vault", SignCSRPath: "pki/sign/istio", AuthMethod: AuthKubernetes}},
+		{"no secret ID", Config{Address: "http://vault", SignCSRPath: "pki/sign/istio", AuthMethod: AuthAppRole, RoleID: "id"}},
+		{"unknown auth method", Config{Address: "http://vault", SignCSRPath: "pki/sign/istio", AuthMethod: "ldap"}},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewClient(tt.config); err == nil {
+				t.Errorf("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,162 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mock
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+)
+
+// SignRequest is a sign request received by the fake Vault server.
+type SignRequest struct {
+	CSR   string `json:"csr"`
+	TTL   string `json:"ttl"`
+	Token string `json:"-"`
+}
+
+// VaultServer is a fake Vault server, with the Kubernetes and AppRole auth methods mounted at their
+// default paths and a PKI sign endpoint returning a fixed certificate chain.
+type VaultServer struct {
+	URL string
+
+	// Role and JWT are the credentials accepted by the Kubernetes auth method.
+	Role string
+	JWT  string
+	// RoleID and SecretID are the credentials accepted by the AppRole auth method.
+	RoleID   string
+	SecretID string
+	// LeaseDuration is the lifetime in seconds of the tokens issued.
+	LeaseDuration int64
+
+	// Certificate, IssuingCA and CAChain are returned by the sign endpoint.
+	Certificate string
+	IssuingCA   string
+	CAChain     []string
+
+	signPath string
+	server   *httptest.Server
+
+	mutex    sync.Mutex
+	tokens   map[string]bool
+	logins   int
+	requests []SignRequest
+}
+
+// NewVaultServer starts a fake Vault server serving the PKI sign endpoint at signPath, e.g. pki/sign/istio.
+func NewVaultServer(signPath string) *VaultServer {
+	s := &VaultServer{
+		LeaseDuration: 3600,
+		signPath:      "/v1/" + strings.TrimPrefix(signPath, "/"),
+		tokens:        map[string]bool{},
+	}
+	s.server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	s.URL = s.server.URL
+	return s
+}
+
+// Close stops the server.
+func (s *VaultServer) Close() {
+	s.server.Close()
+}
+
+// Logins returns the number of successful logins.
+func (s *VaultServer) Logins() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.logins
+}
+
+// SignRequests returns the sign requests received with a valid token.
+func (s *VaultServer) SignRequests() []SignRequest {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]SignRequest(nil), s.requests...)
+}
+
+func (s *VaultServer) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "unsupported method")
+		return
+	}
+	body := map[string]string{}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	switch r.URL.Path {
+	case "/v1/auth/kubernetes/login":
+		if body["role"] != s.Role || body["jwt"] != s.JWT {
+			writeError(w, http.StatusForbidden, "permission denied")
+			return
+		}
+		s.login(w)
+	case "/v1/auth/approle/login":
+		if body["role_id"] != s.RoleID || body["secret_id"] != s.SecretID {
+			writeError(w, http.StatusBadRequest, "invalid role or secret ID")
+			return
+		}
+		s.login(w)
+	case s.signPath:
+		token := r.Header.Get("X-Vault-Token")
+		if !s.tokens[token] {
+			writeError(w, http.StatusForbidden, "permission denied")
+			return
+		}
+		if body["csr"] == "" {
+			writeError(w, http.StatusBadRequest, "missing csr")
+			return
+		}
+		s.requests = append(s.requests, SignRequest{CSR: body["csr"], TTL: body["ttl"], Token: token})
+		writeJSON(w, map[string]interface{}{
+			"data": map[string]interface{}{
+				"certificate": s.Certificate,
+				"issuing_ca":  s.IssuingCA,
+				"ca_chain":    s.CAChain,
+			},
+		})
+	default:
+		writeError(w, http.StatusNotFound, "no handler for route "+r.URL.Path)
+	}
+}
+
+func (s *VaultServer) login(w http.ResponseWriter) {
+	s.logins++
+	token := fmt.Sprintf("token-%d", s.logins)
+	s.tokens[token] = true
+	writeJSON(w, map[string]interface{}{
+		"auth": map[string]interface{}{
+			"client_token":   token,
+			"lease_duration": s.LeaseDuration,
+		},
+	})
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, code int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(map[string][]string{"errors": {msg}})
+}
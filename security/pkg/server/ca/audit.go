@@ -0,0 +1,91 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ca
+
+import (
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+
+	pb "istio.io/api/security/v1alpha1"
+	caerror "istio.io/istio/security/pkg/pki/error"
+	"istio.io/istio/security/pkg/pki/util"
+	"istio.io/istio/security/pkg/server/ca/audit"
+	"istio.io/istio/security/pkg/server/ca/authenticate"
+)
+
+// auditRecord returns the audit record of the request, or nil if the audit log is disabled.
+func (s *Server) auditRecord(ctx context.Context, request *pb.IstioCertificateRequest) *audit.Record {
+	if s.Audit == nil {
+		return nil
+	}
+	record := &audit.Record{
+		Time:                time.Now(),
+		ClientAddress:       getConnectionAddress(ctx),
+		RequestedTTLSeconds: request.ValidityDuration,
+	}
+	if csr, err := util.ParsePemEncodedCSR([]byte(request.Csr)); err == nil {
+		record.RequestedSANs, _ = util.ExtractIDs(csr.Extensions)
+	}
+	return record
+}
+
+// logAudit completes the audit record with the outcome of the request and writes it. The caller is nil if
+// the request failed to authenticate, and the cert is the issued certificate if it succeeded.
+func (s *Server) logAudit(record *audit.Record, caller *authenticate.Caller, cert []byte, err error) {
+	if record == nil {
+		return
+	}
+	switch {
+	case caller == nil:
+		record.Outcome = audit.OutcomeUnauthenticated
+		record.Error = "request authenticate failure"
+	case err != nil:
+		record.Outcome = audit.OutcomeFailed
+		if caErr, ok := err.(*caerror.Error); ok && caErr.HTTPErrorCode() == codes.InvalidArgument {
+			record.Outcome = audit.OutcomeRejected
+		}
+		record.Error = err.Error()
+	default:
+		record.Outcome = audit.OutcomeIssued
+	}
+	if caller != nil {
+		record.AuthSource = authSourceName(caller.AuthSource)
+		record.CallerIdentities = caller.Identities
+	}
+	if len(cert) > 0 {
+		if parsed, parseErr := util.ParsePemEncodedCertificate(cert); parseErr == nil {
+			// Hex encoded, as accepted by istioctl experimental ca revoke.
+			record.SerialNumber = parsed.SerialNumber.Text(16)
+			notAfter := parsed.NotAfter.UTC()
+			record.NotAfter = &notAfter
+			record.GrantedTTLSeconds = int64(parsed.NotAfter.Sub(parsed.NotBefore).Seconds())
+			record.GrantedSANs, _ = util.ExtractIDs(parsed.Extensions)
+		}
+	}
+	s.Audit.Log(record)
+}
+
+func authSourceName(source authenticate.AuthSource) string {
+	switch source {
+	case authenticate.AuthSourceClientCertificate:
+		return "ClientCertificate"
+	case authenticate.AuthSourceIDToken:
+		return "IDToken"
+	default:
+		return "Unknown"
+	}
+}
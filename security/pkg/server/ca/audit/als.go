@@ -0,0 +1,162 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net"
+	"strconv"
+
+	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	accesslogdata "github.com/envoyproxy/go-control-plane/envoy/data/accesslog/v3"
+	accesslog "github.com/envoyproxy/go-control-plane/envoy/service/accesslog/v3"
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/ptypes"
+	structpb "github.com/golang/protobuf/ptypes/struct"
+	"google.golang.org/grpc"
+)
+
+const (
+	// ALSLogName is the log name identifying the audit records streamed to an access log service.
+	ALSLogName = "istio-ca-audit"
+	// ALSMetadataKey is the filter metadata key of the audit records in the access log entries.
+	ALSMetadataKey = "istio.ca.audit"
+)
+
+// ALSSink streams the audit records to a gRPC access log service, the service Envoy streams its access
+// logs to. Each record is sent as a TCP access log entry, with the client address and the time of the
+// request as common properties, and the whole record as filter metadata under ALSMetadataKey. A batch
+// of records is sent as one message of a long lived stream.
+type ALSSink struct {
+	instance string
+	conn     *grpc.ClientConn
+	client   accesslog.AccessLogServiceClient
+	// ctx of the stream, canceled when the sink is closed.
+	ctx    context.Context
+	cancel context.CancelFunc
+	// stream is opened on the first write, and reopened on the next write after a failure.
+	stream accesslog.AccessLogService_StreamAccessLogsClient
+}
+
+var _ Sink = &ALSSink{}
+
+// NewALSSink returns a sink streaming the records of the CA server instance to the access log service
+// at address. The connection is established in the background.
+func NewALSSink(instance, address string, opts ...grpc.DialOption) (*ALSSink, error) {
+	conn, err := grpc.Dial(address, opts...)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &ALSSink{
+		instance: instance,
+		conn:     conn,
+		client:   accesslog.NewAccessLogServiceClient(conn),
+		ctx:      ctx,
+		cancel:   cancel,
+	}, nil
+}
+
+// Write sends the records in a message of the stream, opening it if needed.
+func (s *ALSSink) Write(records []*Record) error {
+	entries := make([]*accesslogdata.TCPAccessLogEntry, 0, len(records))
+	for _, r := range records {
+		entry, err := toAccessLogEntry(r)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, entry)
+	}
+	msg := &accesslog.StreamAccessLogsMessage{
+		LogEntries: &accesslog.StreamAccessLogsMessage_TcpLogs{
+			TcpLogs: &accesslog.StreamAccessLogsMessage_TCPAccessLogEntries{
+				LogEntry: entries,
+			},
+		},
+	}
+	if s.stream == nil {
+		stream, err := s.client.StreamAccessLogs(s.ctx)
+		if err != nil {
+			return err
+		}
+		s.stream = stream
+		// The identifier is only sent with the first message of a stream.
+		msg.Identifier = &accesslog.StreamAccessLogsMessage_Identifier{
+			Node:    &core.Node{Id: s.instance},
+			LogName: ALSLogName,
+		}
+	}
+	if err := s.stream.Send(msg); err != nil {
+		s.stream = nil
+		return err
+	}
+	return nil
+}
+
+// Close closes the stream and the connection.
+func (s *ALSSink) Close() error {
+	if s.stream != nil {
+		_, _ = s.stream.CloseAndRecv()
+		s.stream = nil
+	}
+	s.cancel()
+	return s.conn.Close()
+}
+
+func toAccessLogEntry(r *Record) (*accesslogdata.TCPAccessLogEntry, error) {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+	metadata := &structpb.Struct{}
+	if err := jsonpb.Unmarshal(bytes.NewReader(b), metadata); err != nil {
+		return nil, err
+	}
+	startTime, err := ptypes.TimestampProto(r.Time)
+	if err != nil {
+		return nil, err
+	}
+	return &accesslogdata.TCPAccessLogEntry{
+		CommonProperties: &accesslogdata.AccessLogCommon{
+			StartTime:               startTime,
+			DownstreamRemoteAddress: socketAddress(r.ClientAddress),
+			Metadata: &core.Metadata{
+				FilterMetadata: map[string]*structpb.Struct{ALSMetadataKey: metadata},
+			},
+		},
+	}, nil
+}
+
+// socketAddress returns the socket address of a host:port address, or nil if it is not one.
+func socketAddress(address string) *core.Address {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil
+	}
+	portValue, err := strconv.ParseUint(port, 10, 32)
+	if err != nil {
+		return nil
+	}
+	return &core.Address{
+		Address: &core.Address_SocketAddress{
+			SocketAddress: &core.SocketAddress{
+				Address:       host,
+				PortSpecifier: &core.SocketAddress_PortValue{PortValue: uint32(portValue)},
+			},
+		},
+	}
+}
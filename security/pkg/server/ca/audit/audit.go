@@ -0,0 +1,260 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package audit records the certificate signing operations of the Istio CA in a tamper-evident log.
+//
+// Each record is authenticated by an HMAC, keyed with a secret kept outside of the log, which covers the
+// HMAC of the previous record of the same chain, a chain being the records written by one CA server since
+// it started. Modifying, removing or reordering records breaks the chain, which is checked by Verify with
+// the key. Without the key, the records cannot be rewritten with a valid chain.
+package audit
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"istio.io/pkg/log"
+	"istio.io/pkg/monitoring"
+)
+
+var auditLog = log.RegisterScope("caaudit", "CA audit log", 0)
+
+var (
+	writeErrorCounts = monitoring.NewSum(
+		"citadel_server_audit_log_write_err_count",
+		"The number of audit records of the certificate signing operations that failed to be written.",
+	)
+
+	droppedCounts = monitoring.NewSum(
+		"citadel_server_audit_log_dropped_count",
+		"The number of audit records of the certificate signing operations dropped because the queue "+
+			"of the audit log sinks was full.",
+	)
+)
+
+func init() {
+	monitoring.MustRegister(writeErrorCounts, droppedCounts)
+}
+
+// maxBatchSize is the max number of queued records written to the sinks at once.
+const maxBatchSize = 100
+
+// Outcome is the outcome of a certificate signing request.
+type Outcome string
+
+const (
+	// OutcomeIssued means the certificate was issued.
+	OutcomeIssued Outcome = "ISSUED"
+	// OutcomeUnauthenticated means the caller failed to authenticate.
+	OutcomeUnauthenticated Outcome = "UNAUTHENTICATED"
+	// OutcomeRejected means the request was invalid, or violated the certificate policy of the caller.
+	OutcomeRejected Outcome = "REJECTED"
+	// OutcomeFailed means the CA failed to sign the certificate.
+	OutcomeFailed Outcome = "FAILED"
+)
+
+// Record is the audit record of a certificate signing request.
+type Record struct {
+	// Time is when the request was handled.
+	Time time.Time `json:"time"`
+	// Instance is the CA server that handled the request.
+	Instance string `json:"instance,omitempty"`
+	// Chain identifies the chain of the record, started by the CA server when it started.
+	Chain string `json:"chain"`
+	// Sequence is the position of the record in its chain, starting at 1.
+	Sequence uint64 `json:"sequence"`
+
+	Outcome Outcome `json:"outcome"`
+	// Error is why the certificate was not issued.
+	Error string `json:"error,omitempty"`
+	// ClientAddress is the address the request was received from.
+	ClientAddress string `json:"clientAddress,omitempty"`
+	// AuthSource is how the caller authenticated.
+	AuthSource string `json:"authSource,omitempty"`
+	// CallerIdentities are the identities of the authenticated caller.
+	CallerIdentities []string `json:"callerIdentities,omitempty"`
+	// RequestedSANs are the SANs of the CSR.
+	RequestedSANs []string `json:"requestedSANs,omitempty"`
+	// RequestedTTLSeconds is the TTL requested by the caller, 0 for the default TTL.
+	RequestedTTLSeconds int64 `json:"requestedTTLSeconds,omitempty"`
+	// GrantedSANs are the SANs of the issued certificate.
+	GrantedSANs []string `json:"grantedSANs,omitempty"`
+	// SerialNumber is the hex encoded serial number of the issued certificate.
+	SerialNumber string `json:"serialNumber,omitempty"`
+	// NotAfter is the expiration time of the issued certificate.
+	NotAfter *time.Time `json:"notAfter,omitempty"`
+	// GrantedTTLSeconds is the lifetime of the issued certificate.
+	GrantedTTLSeconds int64 `json:"grantedTTLSeconds,omitempty"`
+
+	// PrevMAC is the HMAC of the previous record of the chain, empty for the first record.
+	PrevMAC string `json:"prevMac,omitempty"`
+	// MAC is the HMAC of the record, including PrevMAC.
+	MAC string `json:"mac"`
+}
+
+// ComputeMAC returns the hex encoded HMAC-SHA256 of the record with the key, excluding its MAC.
+func (r Record) ComputeMAC(key []byte) string {
+	r.MAC = ""
+	b, err := json.Marshal(r)
+	if err != nil {
+		// Cannot happen, all the fields can be marshaled.
+		return ""
+	}
+	mac := hmac.New(sha256.New, key)
+	_, _ = mac.Write(b)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Sink stores the audit records. It is only called by the goroutine of the Logger.
+type Sink interface {
+	// Write stores a batch of records, in order.
+	Write(records []*Record) error
+	// Close releases the resources of the sink.
+	Close() error
+}
+
+// Querier returns the stored audit records matching a filter.
+type Querier interface {
+	Query(f Filter) ([]Record, error)
+}
+
+// Logger chains the audit records and writes them to the sinks in the background, so that a slow sink
+// does not slow down the signing operations.
+type Logger struct {
+	instance string
+	key      []byte
+	sinks    []Sink
+	// queue holds the records not written yet. The records logged when it is full are dropped, which
+	// Verify reports as missing records.
+	queue chan *Record
+	done  chan struct{}
+
+	mutex    sync.Mutex
+	closed   bool
+	chain    string
+	sequence uint64
+	lastMAC  string
+}
+
+// NewLogger returns a logger authenticating the records of the given CA server instance with the key, and
+// writing them to the sinks. Up to queueSize records are queued while the sinks are busy.
+func NewLogger(instance string, key []byte, queueSize int, sinks ...Sink) *Logger {
+	l := &Logger{
+		instance: instance,
+		key:      key,
+		sinks:    sinks,
+		queue:    make(chan *Record, queueSize),
+		done:     make(chan struct{}),
+		chain:    newChainID(),
+	}
+	go l.run()
+	return l
+}
+
+// Log chains the record to the previous one and queues it for the sinks. Failures to write are logged
+// and counted, they don't fail the signing operation.
+func (l *Logger) Log(r *Record) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.closed {
+		droppedCounts.Increment()
+		return
+	}
+	if r.Time.IsZero() {
+		r.Time = time.Now()
+	}
+	r.Time = r.Time.UTC()
+	r.Instance = l.instance
+	r.Chain = l.chain
+	l.sequence++
+	r.Sequence = l.sequence
+	r.PrevMAC = l.lastMAC
+	r.MAC = r.ComputeMAC(l.key)
+	l.lastMAC = r.MAC
+	select {
+	case l.queue <- r:
+	default:
+		droppedCounts.Increment()
+		auditLog.Debugf("dropped the audit record %d of chain %s, the queue is full", r.Sequence, r.Chain)
+	}
+}
+
+// run writes the queued records to the sinks, in batches, until the queue is closed.
+func (l *Logger) run() {
+	defer close(l.done)
+	for r := range l.queue {
+		batch := []*Record{r}
+	drain:
+		for len(batch) < maxBatchSize {
+			select {
+			case next, ok := <-l.queue:
+				if !ok {
+					break drain
+				}
+				batch = append(batch, next)
+			default:
+				break drain
+			}
+		}
+		for _, s := range l.sinks {
+			if err := s.Write(batch); err != nil {
+				writeErrorCounts.Record(float64(len(batch)))
+				auditLog.Errorf("failed to write the audit records %d to %d of chain %s: %v",
+					batch[0].Sequence, batch[len(batch)-1].Sequence, batch[0].Chain, err)
+			}
+		}
+	}
+}
+
+// Query returns the records matching the filter from the first sink supporting queries.
+func (l *Logger) Query(f Filter) ([]Record, error) {
+	for _, s := range l.sinks {
+		if q, ok := s.(Querier); ok {
+			return q.Query(f)
+		}
+	}
+	return nil, fmt.Errorf("no audit log sink supports queries")
+}
+
+// Close writes the queued records and closes the sinks.
+func (l *Logger) Close() {
+	l.mutex.Lock()
+	if l.closed {
+		l.mutex.Unlock()
+		return
+	}
+	l.closed = true
+	close(l.queue)
+	l.mutex.Unlock()
+	<-l.done
+	for _, s := range l.sinks {
+		if err := s.Close(); err != nil {
+			auditLog.Warnf("failed to close the audit log sink: %v", err)
+		}
+	}
+}
+
+func newChainID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,213 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFileSink(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ca-audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "ca-audit.log")
+	sink, err := NewFileSink(path, 1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := NewLogger("istiod-1", testKey, 10, sink)
+	start := time.Now()
+	logger.Log(&Record{
+		Outcome:          OutcomeIssued,
+		ClientAddress:    "10.0.0.1:43210",
+		CallerIdentities: []string{"spiffe://cluster.local/ns/default/sa/reviews"},
+		GrantedSANs:      []string{"spiffe://cluster.local/ns/default/sa/reviews"},
+		SerialNumber:     "5d6e419f",
+	})
+	logger.Log(&Record{
+		Outcome:       OutcomeUnauthenticated,
+		ClientAddress: "10.0.0.2:43210",
+		Error:         "request authenticate failure",
+	})
+	logger.Log(&Record{
+		Outcome:          OutcomeRejected,
+		ClientAddress:    "10.0.0.3:43210",
+		CallerIdentities: []string{"spiffe://cluster.local/ns/payments/sa/default"},
+		Error:            "RSA key of 2048 bits is not allowed by certificate policy pci",
+	})
+	logger.Close()
+
+	all, err := sink.Query(Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 3 {
+		t.Fatalf("expected 3 records, got %d", len(all))
+	}
+	if errs := Verify(all, testKey); len(errs) != 0 {
+		t.Errorf("unexpected verification errors %v", errs)
+	}
+	if all[0].Instance != "istiod-1" || all[0].Sequence != 1 || all[2].Sequence != 3 || all[1].PrevMAC != all[0].MAC {
+		t.Errorf("records are not chained: %+v", all)
+	}
+
+	for name, tc := range map[string]struct {
+		filter Filter
+		want   []uint64
+	}{
+		"identity":       {Filter{Identity: "ns/default/sa/reviews"}, []uint64{1}},
+		"serial":         {Filter{SerialNumber: "5d:6e:41:9f"}, []uint64{1}},
+		"outcome":        {Filter{Outcome: OutcomeRejected}, []uint64{3}},
+		"client":         {Filter{ClientAddress: "10.0.0.2"}, []uint64{2}},
+		"since":          {Filter{Since: start.Add(-time.Minute)}, []uint64{1, 2, 3}},
+		"until":          {Filter{Until: start.Add(-time.Minute)}, nil},
+		"limit":          {Filter{Limit: 2}, []uint64{2, 3}},
+		"no match":       {Filter{Identity: "ns/other"}, nil},
+		"identity+limit": {Filter{Identity: "cluster.local", Limit: 1}, []uint64{3}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			// The filter must survive the query parameters of the debug endpoint.
+			filter, err := ParseFilter(tc.filter.Values())
+			if err != nil {
+				t.Fatal(err)
+			}
+			records, err := sink.Query(filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []uint64
+			for _, r := range records {
+				got = append(got, r.Sequence)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("expected records %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewLogger("istiod-1", testKey, 10, &writerSink{buf: &buf})
+	for i := 0; i < 4; i++ {
+		logger.Log(&Record{Outcome: OutcomeIssued, SerialNumber: string(rune('a' + i))})
+	}
+	logger.Close()
+	records, err := Read(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if errs := Verify(records, testKey); len(errs) != 0 {
+		t.Fatalf("unexpected verification errors %v", errs)
+	}
+
+	modified := append([]Record{}, records...)
+	modified[1].SerialNumber = "ff"
+	expectVerifyError(t, modified, "record 2 of chain "+records[0].Chain+" was modified")
+
+	// Without the key, a modified record cannot be authenticated again.
+	rewritten := append([]Record{}, records...)
+	rewritten[1].SerialNumber = "ff"
+	rewritten[1].MAC = rewritten[1].ComputeMAC([]byte("guessed-key"))
+	expectVerifyError(t, rewritten, "record 2 of chain "+records[0].Chain+" was modified")
+
+	removed := append(append([]Record{}, records[:1]...), records[2:]...)
+	expectVerifyError(t, removed, "records 2 to 2 of chain")
+
+	reordered := []Record{records[0], records[2], records[1], records[3]}
+	expectVerifyError(t, reordered, "records 2 to 2 of chain")
+
+	// The beginning of a chain may have been rotated out of the log.
+	if errs := Verify(records[2:], testKey); len(errs) != 0 {
+		t.Errorf("unexpected verification errors %v", errs)
+	}
+}
+
+func expectVerifyError(t *testing.T, records []Record, msg string) {
+	t.Helper()
+	for _, err := range Verify(records, testKey) {
+		if strings.Contains(err.Error(), msg) {
+			return
+		}
+	}
+	t.Errorf("expected a verification error containing %q, got %v", msg, Verify(records, testKey))
+}
+
+func TestLoggerQueueFull(t *testing.T) {
+	var buf bytes.Buffer
+	sink := &writerSink{buf: &buf, writing: make(chan struct{}), release: make(chan struct{})}
+	logger := NewLogger("istiod-1", testKey, 1, sink)
+	logger.Log(&Record{Outcome: OutcomeIssued})
+	// The first record is being written, the second one is queued and the third one is dropped.
+	<-sink.writing
+	logger.Log(&Record{Outcome: OutcomeIssued})
+	logger.Log(&Record{Outcome: OutcomeIssued})
+	close(sink.release)
+	logger.Log(&Record{Outcome: OutcomeIssued})
+	logger.Close()
+
+	records, err := Read(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []uint64
+	for _, r := range records {
+		got = append(got, r.Sequence)
+	}
+	// The record 4 is dropped too if it is logged before the record 2 is dequeued.
+	if len(got) < 2 || len(got) > 3 || !reflect.DeepEqual(got[:2], []uint64{1, 2}) {
+		t.Fatalf("expected the records 1 and 2 to be written and the record 3 to be dropped, got %v", got)
+	}
+	if len(got) == 3 {
+		expectVerifyError(t, records, "records 3 to 3 of chain")
+	}
+}
+
+var testKey = []byte("test-key")
+
+// writerSink writes the records to a buffer. If writing is set, it is signaled by the first write, which
+// waits for release.
+type writerSink struct {
+	buf     *bytes.Buffer
+	writing chan struct{}
+	release chan struct{}
+}
+
+func (s *writerSink) Write(records []*Record) error {
+	if s.writing != nil {
+		close(s.writing)
+		s.writing = nil
+		<-s.release
+	}
+	for _, r := range records {
+		if err := Write(s.buf, []Record{*r}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *writerSink) Close() error {
+	return nil
+}
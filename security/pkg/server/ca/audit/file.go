@@ -0,0 +1,159 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/natefinch/lumberjack"
+)
+
+// FileSink writes the audit records as JSON lines to a file, rotated when it reaches a max size.
+type FileSink struct {
+	path   string
+	writer *lumberjack.Logger
+}
+
+var _ Sink = &FileSink{}
+var _ Querier = &FileSink{}
+
+// NewFileSink returns a sink writing to the file at path, rotated when it reaches maxSizeMB and keeping
+// maxBackups rotated files. All rotated files are kept if maxBackups is 0.
+func NewFileSink(path string, maxSizeMB, maxBackups int) (*FileSink, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create the directory of the audit log %s: %v", path, err)
+	}
+	return &FileSink{
+		path: path,
+		writer: &lumberjack.Logger{
+			Filename:   path,
+			MaxSize:    maxSizeMB,
+			MaxBackups: maxBackups,
+		},
+	}, nil
+}
+
+// Write appends the records to the file.
+func (s *FileSink) Write(records []*Record) error {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, r := range records {
+		if err := encoder.Encode(r); err != nil {
+			return err
+		}
+	}
+	_, err := s.writer.Write(buf.Bytes())
+	return err
+}
+
+// Close closes the file.
+func (s *FileSink) Close() error {
+	return s.writer.Close()
+}
+
+// Query returns the records matching the filter, from the rotated files and the current file.
+func (s *FileSink) Query(f Filter) ([]Record, error) {
+	files, err := LogFiles(s.path)
+	if err != nil {
+		return nil, err
+	}
+	var out []Record
+	for _, file := range files {
+		records, err := ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		for i := range records {
+			if f.Match(&records[i]) {
+				out = append(out, records[i])
+			}
+		}
+	}
+	if f.Limit > 0 && len(out) > f.Limit {
+		out = out[len(out)-f.Limit:]
+	}
+	return out, nil
+}
+
+// LogFiles returns the rotated files of the audit log at path, from the oldest to the newest, followed by
+// the current file.
+func LogFiles(path string) ([]string, error) {
+	ext := filepath.Ext(path)
+	prefix := strings.TrimSuffix(path, ext) + "-"
+	backups, err := filepath.Glob(prefix + "*" + ext)
+	if err != nil {
+		return nil, err
+	}
+	// The timestamps of the rotated files sort in chronological order.
+	sort.Strings(backups)
+	files := backups
+	if _, err := os.Stat(path); err == nil {
+		files = append(files, path)
+	}
+	return files, nil
+}
+
+// ReadFile returns the records of an audit log file.
+func ReadFile(path string) ([]Record, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	records, err := Read(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the audit log %s: %v", path, err)
+	}
+	return records, nil
+}
+
+// Read returns the records of JSON lines.
+func Read(r io.Reader) ([]Record, error) {
+	var records []Record
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		var record Record
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, fmt.Errorf("invalid record at line %d: %v", line, err)
+		}
+		records = append(records, record)
+	}
+	return records, scanner.Err()
+}
+
+// Write writes the records as JSON lines.
+func Write(w io.Writer, records []Record) error {
+	encoder := json.NewEncoder(w)
+	for i := range records {
+		if err := encoder.Encode(&records[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
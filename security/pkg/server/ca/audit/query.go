@@ -0,0 +1,174 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"crypto/hmac"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Filter selects audit records. Empty fields match all the records.
+type Filter struct {
+	// Identity is contained in the caller identities, the requested or the granted SANs.
+	Identity string
+	// SerialNumber is the hex encoded serial number of the issued certificate, with or without colons.
+	SerialNumber string
+	Outcome      Outcome
+	// ClientAddress is the client IP, or a prefix of the client address.
+	ClientAddress string
+	Since         time.Time
+	Until         time.Time
+	// Limit is the max number of records, the most recent ones are kept.
+	Limit int
+}
+
+// Match returns whether the record matches the filter.
+func (f Filter) Match(r *Record) bool {
+	if f.Outcome != "" && f.Outcome != r.Outcome {
+		return false
+	}
+	if f.SerialNumber != "" && normalizeSerialNumber(f.SerialNumber) != normalizeSerialNumber(r.SerialNumber) {
+		return false
+	}
+	if f.ClientAddress != "" && !strings.HasPrefix(r.ClientAddress, f.ClientAddress) {
+		return false
+	}
+	if !f.Since.IsZero() && r.Time.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && r.Time.After(f.Until) {
+		return false
+	}
+	if f.Identity != "" && !containsSubstring(r.CallerIdentities, f.Identity) &&
+		!containsSubstring(r.RequestedSANs, f.Identity) && !containsSubstring(r.GrantedSANs, f.Identity) {
+		return false
+	}
+	return true
+}
+
+// Values encodes the filter as URL query parameters.
+func (f Filter) Values() url.Values {
+	v := url.Values{}
+	if f.Identity != "" {
+		v.Set("identity", f.Identity)
+	}
+	if f.SerialNumber != "" {
+		v.Set("serial", f.SerialNumber)
+	}
+	if f.Outcome != "" {
+		v.Set("outcome", string(f.Outcome))
+	}
+	if f.ClientAddress != "" {
+		v.Set("client", f.ClientAddress)
+	}
+	if !f.Since.IsZero() {
+		v.Set("since", f.Since.Format(time.RFC3339))
+	}
+	if !f.Until.IsZero() {
+		v.Set("until", f.Until.Format(time.RFC3339))
+	}
+	if f.Limit > 0 {
+		v.Set("limit", strconv.Itoa(f.Limit))
+	}
+	return v
+}
+
+// ParseFilter decodes a filter from URL query parameters.
+func ParseFilter(v url.Values) (Filter, error) {
+	f := Filter{
+		Identity:      v.Get("identity"),
+		SerialNumber:  v.Get("serial"),
+		Outcome:       Outcome(strings.ToUpper(v.Get("outcome"))),
+		ClientAddress: v.Get("client"),
+	}
+	var err error
+	if s := v.Get("since"); s != "" {
+		if f.Since, err = time.Parse(time.RFC3339, s); err != nil {
+			return f, fmt.Errorf("invalid since %q: %v", s, err)
+		}
+	}
+	if s := v.Get("until"); s != "" {
+		if f.Until, err = time.Parse(time.RFC3339, s); err != nil {
+			return f, fmt.Errorf("invalid until %q: %v", s, err)
+		}
+	}
+	if s := v.Get("limit"); s != "" {
+		if f.Limit, err = strconv.Atoi(s); err != nil {
+			return f, fmt.Errorf("invalid limit %q: %v", s, err)
+		}
+	}
+	return f, nil
+}
+
+// Verify checks the chains of the records with the key they are authenticated with, and returns the errors
+// found. The records of a chain must be ordered by sequence; records of different chains may be interleaved.
+// Only complete chains can be verified: records filtered out of a chain, or dropped because the queue of
+// the audit log was full, are reported as missing.
+func Verify(records []Record, key []byte) []error {
+	var errs []error
+	last := map[string]*Record{}
+	for i := range records {
+		r := &records[i]
+		if mac := r.ComputeMAC(key); !hmac.Equal([]byte(mac), []byte(r.MAC)) {
+			errs = append(errs, fmt.Errorf("record %d of chain %s was modified, or is authenticated with another key",
+				r.Sequence, r.Chain))
+		}
+		prev, ok := last[r.Chain]
+		switch {
+		case !ok && r.Sequence != 1 && r.PrevMAC != "":
+			// The beginning of the chain was rotated out of the log, the record can't be linked.
+		case r.Sequence == 1 && r.PrevMAC != "":
+			errs = append(errs, fmt.Errorf("first record of chain %s has a previous MAC", r.Chain))
+		case r.Sequence != 1 && r.PrevMAC == "":
+			errs = append(errs, fmt.Errorf("record %d of chain %s has no previous MAC", r.Sequence, r.Chain))
+		case ok && r.Sequence != prev.Sequence+1:
+			errs = append(errs, fmt.Errorf("records %d to %d of chain %s are missing",
+				prev.Sequence+1, r.Sequence-1, r.Chain))
+		case ok && r.PrevMAC != prev.MAC:
+			errs = append(errs, fmt.Errorf("record %d of chain %s does not follow record %d",
+				r.Sequence, r.Chain, prev.Sequence))
+		}
+		last[r.Chain] = r
+	}
+	return errs
+}
+
+// SortByTime orders the records by time, keeping the order of the records of a chain.
+func SortByTime(records []Record) {
+	sort.SliceStable(records, func(i, j int) bool {
+		if records[i].Chain == records[j].Chain {
+			return records[i].Sequence < records[j].Sequence
+		}
+		return records[i].Time.Before(records[j].Time)
+	})
+}
+
+func normalizeSerialNumber(s string) string {
+	return strings.TrimLeft(strings.ToLower(strings.ReplaceAll(s, ":", "")), "0")
+}
+
+func containsSubstring(list []string, s string) bool {
+	for _, e := range list {
+		if strings.Contains(e, s) {
+			return true
+		}
+	}
+	return false
+}
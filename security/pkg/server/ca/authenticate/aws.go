@@ -0,0 +1,195 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authenticate
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"istio.io/istio/security/pkg/credentialfetcher/plugin"
+)
+
+const (
+	AWSIAMAuthenticatorType = "AWSIAMAuthenticator"
+
+	// awsSTSEndpoint is the global AWS STS endpoint the signed requests are sent to.
+	awsSTSEndpoint = "https://sts.amazonaws.com/"
+	// awsRequestMaxAge is the maximum age of a signed request, the lifetime of the AWS signatures.
+	awsRequestMaxAge = 15 * time.Minute
+	awsDateFormat    = "20060102T150405Z"
+	awsSTSTimeout    = 10 * time.Second
+)
+
+// awsGetCallerIdentityResponse is the subset of the sts:GetCallerIdentity response used for authentication.
+type awsGetCallerIdentityResponse struct {
+	ARN string `xml:"GetCallerIdentityResult>Arn"`
+}
+
+// AWSIAMAuthenticator authenticates EC2 instances with a sts:GetCallerIdentity request signed with the
+// credentials of their IAM role, as sent by the AWS credential fetcher of the agent. The request is bound to
+// the server ID and is only accepted for 15 minutes after it was signed. AWS STS returns the assumed role ARN
+// of the instance, arn:aws:sts::<account>:assumed-role/<role>/<instance ID>, which is mapped to a workload
+// identity, or else the ARN of its role, arn:aws:iam::<account>:role/<role>.
+type AWSIAMAuthenticator struct {
+	serverID    string
+	trustDomain string
+	identities  PlatformIdentities
+
+	// endpoint of AWS STS
+	endpoint string
+	client   *http.Client
+}
+
+var _ Authenticator = &AWSIAMAuthenticator{}
+
+// NewAWSIAMAuthenticator creates an authenticator for the requests signed for the server ID.
+func NewAWSIAMAuthenticator(serverID, trustDomain string, identities PlatformIdentities) *AWSIAMAuthenticator {
+	return newAWSIAMAuthenticator(awsSTSEndpoint, &http.Client{Timeout: awsSTSTimeout}, serverID, trustDomain, identities)
+}
+
+func newAWSIAMAuthenticator(endpoint string, client *http.Client, serverID, trustDomain string,
+	identities PlatformIdentities) *AWSIAMAuthenticator {
+	return &AWSIAMAuthenticator{
+		serverID:    serverID,
+		trustDomain: trustDomain,
+		identities:  identities,
+		endpoint:    endpoint,
+		client:      client,
+	}
+}
+
+// Authenticate checks the signed request in the bearer token, sends it to AWS STS and returns the workload
+// identity mapped to the IAM role returned.
+func (a *AWSIAMAuthenticator) Authenticate(ctx context.Context) (*Caller, error) {
+	bearerToken, err := ExtractBearerToken(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("AWS credential extraction error: %v", err)
+	}
+	cred, err := plugin.ParseAWSCredential(bearerToken)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.checkRequest(cred, time.Now()); err != nil {
+		return nil, err
+	}
+	arn, err := a.getCallerIdentity(ctx, cred)
+	if err != nil {
+		return nil, err
+	}
+	id, ok := a.identities.identity(a.trustDomain, arn, awsRoleARN(arn))
+	if !ok {
+		return nil, fmt.Errorf("no identity mapped to AWS IAM identity %s", arn)
+	}
+	return &Caller{
+		AuthSource: AuthSourceIDToken,
+		Identities: []string{id},
+	}, nil
+}
+
+// checkRequest checks that the request is a sts:GetCallerIdentity request signed for the server ID, and
+// that it is fresh, so that neither a request for another action nor a request captured long ago is replayed.
+func (a *AWSIAMAuthenticator) checkRequest(cred *plugin.AWSCredential, now time.Time) error {
+	body, err := url.ParseQuery(cred.Body)
+	if err != nil {
+		return fmt.Errorf("invalid AWS request body: %v", err)
+	}
+	if len(body) != 2 || body.Get("Action") != "GetCallerIdentity" || body.Get("Version") == "" {
+		return fmt.Errorf("invalid AWS request: not a sts:GetCallerIdentity request")
+	}
+	if serverID := cred.Headers.Get(plugin.AWSServerIDHeader); serverID != a.serverID {
+		return fmt.Errorf("invalid AWS request: signed for server ID %q", serverID)
+	}
+	if !awsSignedHeader(cred.Headers.Get("Authorization"), plugin.AWSServerIDHeader) {
+		return fmt.Errorf("invalid AWS request: the server ID header is not signed")
+	}
+	date, err := time.Parse(awsDateFormat, cred.Headers.Get("X-Amz-Date"))
+	if err != nil {
+		return fmt.Errorf("invalid AWS request date: %v", err)
+	}
+	if now.Sub(date) > awsRequestMaxAge || date.Sub(now) > awsRequestMaxAge {
+		return fmt.Errorf("AWS request signed at %v is expired", date)
+	}
+	return nil
+}
+
+// awsSignedHeader returns whether the header is in the signed headers of the Authorization header of an AWS
+// signature version 4.
+func awsSignedHeader(authorization, header string) bool {
+	for _, field := range strings.Split(authorization, ",") {
+		field = strings.TrimSpace(field)
+		if !strings.HasPrefix(field, "SignedHeaders=") {
+			continue
+		}
+		for _, h := range strings.Split(strings.TrimPrefix(field, "SignedHeaders="), ";") {
+			if h == strings.ToLower(header) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// getCallerIdentity sends the signed request to AWS STS and returns the ARN of the caller.
+func (a *AWSIAMAuthenticator) getCallerIdentity(ctx context.Context, cred *plugin.AWSCredential) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.endpoint, strings.NewReader(cred.Body))
+	if err != nil {
+		return "", err
+	}
+	req.Header = cred.Headers.Clone()
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to call AWS STS: %v", err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read the AWS STS response: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("AWS STS rejected the request with HTTP status %s", resp.Status)
+	}
+	result := &awsGetCallerIdentityResponse{}
+	if err := xml.Unmarshal(body, result); err != nil {
+		return "", fmt.Errorf("invalid AWS STS response: %v", err)
+	}
+	if result.ARN == "" {
+		return "", fmt.Errorf("invalid AWS STS response: missing ARN")
+	}
+	return result.ARN, nil
+}
+
+// awsRoleARN returns the ARN of the role of an assumed role ARN, or "" for other ARNs.
+func awsRoleARN(arn string) string {
+	// arn:aws:sts::123456789012:assumed-role/role-name/session-name
+	parts := strings.SplitN(arn, ":", 6)
+	if len(parts) != 6 || parts[2] != "sts" {
+		return ""
+	}
+	resource := strings.Split(parts[5], "/")
+	if len(resource) != 3 || resource[0] != "assumed-role" {
+		return ""
+	}
+	return fmt.Sprintf("arn:%s:iam::%s:role/%s", parts[1], parts[4], resource[1])
+}
+
+func (a *AWSIAMAuthenticator) AuthenticatorType() string {
+	return AWSIAMAuthenticatorType
+}
@@ -0,0 +1,174 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authenticate
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/metadata"
+
+	"istio.io/istio/security/pkg/credentialfetcher/plugin"
+)
+
+const getCallerIdentityBody = "Action=GetCallerIdentity&Version=2011-06-15"
+
+// fakeSTS returns the ARN of the access key in the signature of the requests.
+func fakeSTS(arns map[string]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for key, arn := range arns {
+			if strings.Contains(r.Header.Get("Authorization"), "Credential="+key+"/") {
+				fmt.Fprintf(w, `<GetCallerIdentityResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+  <GetCallerIdentityResult><Arn>%s</Arn><Account>123456789012</Account></GetCallerIdentityResult>
+</GetCallerIdentityResponse>`, arn)
+				return
+			}
+		}
+		w.WriteHeader(http.StatusForbidden)
+	}))
+}
+
+func awsCredential(t *testing.T, key, serverID string, signedHeaders string, date time.Time, body string) string {
+	t.Helper()
+	headers := http.Header{}
+	headers.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/20210101/us-east-1/sts/aws4_request, "+
+		"SignedHeaders=%s, Signature=0123456789abcdef", key, signedHeaders))
+	headers.Set("X-Amz-Date", date.UTC().Format(awsDateFormat))
+	headers.Set(plugin.AWSServerIDHeader, serverID)
+	token, err := plugin.AWSCredential{Headers: headers, Body: body}.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestAWSIAMAuthenticator(t *testing.T) {
+	server := fakeSTS(map[string]string{
+		"INSTANCE": "arn:aws:sts::123456789012:assumed-role/vm/i-0123456789abcdef0",
+		"ROLE":     "arn:aws:sts::123456789012:assumed-role/reviews/i-1",
+		"USER":     "arn:aws:iam::123456789012:user/alice",
+	})
+	defer server.Close()
+
+	identities, err := ParsePlatformIdentities("arn:aws:iam::123456789012:role/reviews=vm/reviews," +
+		"arn:aws:sts::123456789012:assumed-role/vm/i-0123456789abcdef0=vm/ratings")
+	if err != nil {
+		t.Fatal(err)
+	}
+	authn := newAWSIAMAuthenticator(server.URL, server.Client(), "istiod.example.com", "cluster.local", identities)
+
+	signed := "content-type;host;x-amz-date;x-istio-server-id"
+	now := time.Now()
+	cases := []struct {
+		name     string
+		token    string
+		expected string
+	}{
+		{
+			name:     "instance mapping",
+			token:    awsCredential(t, "INSTANCE", "istiod.example.com", signed, now, getCallerIdentityBody),
+			expected: "spiffe://cluster.local/ns/vm/sa/ratings",
+		},
+		{
+			name:     "role mapping",
+			token:    awsCredential(t, "ROLE", "istiod.example.com", signed, now, getCallerIdentityBody),
+			expected: "spiffe://cluster.local/ns/vm/sa/reviews",
+		},
+		{
+			name:  "unmapped user",
+			token: awsCredential(t, "USER", "istiod.example.com", signed, now, getCallerIdentityBody),
+		},
+		{
+			name:  "rejected by STS",
+			token: awsCredential(t, "UNKNOWN", "istiod.example.com", signed, now, getCallerIdentityBody),
+		},
+		{
+			name:  "signed for another server",
+			token: awsCredential(t, "ROLE", "other.example.com", signed, now, getCallerIdentityBody),
+		},
+		{
+			name:  "server ID not signed",
+			token: awsCredential(t, "ROLE", "istiod.example.com", "content-type;host;x-amz-date", now, getCallerIdentityBody),
+		},
+		{
+			name:  "expired request",
+			token: awsCredential(t, "ROLE", "istiod.example.com", signed, now.Add(-time.Hour), getCallerIdentityBody),
+		},
+		{
+			name: "other action",
+			token: awsCredential(t, "ROLE", "istiod.example.com", signed, now,
+				"Action=AssumeRole&Version=2011-06-15&RoleArn=arn:aws:iam::123456789012:role/admin"),
+		},
+		{
+			name:  "not an AWS credential",
+			token: "some-jwt",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := metadata.NewIncomingContext(context.Background(), metadata.MD{
+				"authorization": []string{BearerTokenPrefix + tc.token},
+			})
+			caller, err := authn.Authenticate(ctx)
+			if tc.expected == "" {
+				if err == nil {
+					t.Errorf("expected an error, got %v", caller)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(caller.Identities) != 1 || caller.Identities[0] != tc.expected {
+				t.Errorf("got identities %v, expected %s", caller.Identities, tc.expected)
+			}
+		})
+	}
+}
+
+func TestAWSRoleARN(t *testing.T) {
+	cases := map[string]string{
+		"arn:aws:sts::123456789012:assumed-role/reviews/i-1":    "arn:aws:iam::123456789012:role/reviews",
+		"arn:aws-cn:sts::123456789012:assumed-role/reviews/i-1": "arn:aws-cn:iam::123456789012:role/reviews",
+		"arn:aws:iam::123456789012:user/alice":                  "",
+		"arn:aws:sts::123456789012:federated-user/alice":        "",
+		"not-an-arn": "",
+	}
+	for arn, expected := range cases {
+		if got := awsRoleARN(arn); got != expected {
+			t.Errorf("awsRoleARN(%q) = %q, expected %q", arn, got, expected)
+		}
+	}
+}
+
+func TestParsePlatformIdentities(t *testing.T) {
+	identities, err := ParsePlatformIdentities(" a=ns1/sa1 , b/c=ns2/sa2,")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(identities) != 2 || identities["a"] != "ns1/sa1" || identities["b/c"] != "ns2/sa2" {
+		t.Errorf("unexpected identities %v", identities)
+	}
+	for _, invalid := range []string{"a", "=ns/sa", "a=ns", "a=ns/sa/x", "a=/sa"} {
+		if _, err := ParsePlatformIdentities(invalid); err == nil {
+			t.Errorf("expected an error parsing %q", invalid)
+		}
+	}
+}
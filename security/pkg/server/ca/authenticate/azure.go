@@ -0,0 +1,92 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authenticate
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/coreos/go-oidc"
+)
+
+const (
+	AzureManagedIdentityAuthenticatorType = "AzureManagedIdentityAuthenticator"
+)
+
+// AzureManagedIdentityAuthenticator authenticates Azure VMs with the token of their managed identity issued by
+// Azure AD, as sent by the Azure credential fetcher of the agent. The VM is mapped to a workload identity by
+// the object ID of the managed identity, or else by its resource ID.
+type AzureManagedIdentityAuthenticator struct {
+	verifier    *oidc.IDTokenVerifier
+	trustDomain string
+	identities  PlatformIdentities
+}
+
+var _ Authenticator = &AzureManagedIdentityAuthenticator{}
+
+type azureManagedIdentityClaims struct {
+	// ObjectID is the object ID of the managed identity.
+	ObjectID string `json:"oid"`
+	// ResourceID is the resource ID of the managed identity.
+	ResourceID string `json:"xms_mirid"`
+}
+
+// NewAzureManagedIdentityAuthenticator creates an authenticator for the tokens issued by the Azure AD tenant
+// for the audience, the App ID URI of the application requested by the agents.
+func NewAzureManagedIdentityAuthenticator(tenantID, audience, trustDomain string,
+	identities PlatformIdentities) *AzureManagedIdentityAuthenticator {
+	issuer := fmt.Sprintf("https://sts.windows.net/%s/", tenantID)
+	keySet := oidc.NewRemoteKeySet(context.Background(),
+		fmt.Sprintf("https://login.microsoftonline.com/%s/discovery/keys", tenantID))
+	return newAzureManagedIdentityAuthenticator(issuer, keySet, audience, trustDomain, identities)
+}
+
+func newAzureManagedIdentityAuthenticator(issuer string, keySet oidc.KeySet, audience, trustDomain string,
+	identities PlatformIdentities) *AzureManagedIdentityAuthenticator {
+	return &AzureManagedIdentityAuthenticator{
+		verifier:    oidc.NewVerifier(issuer, keySet, &oidc.Config{ClientID: audience}),
+		trustDomain: trustDomain,
+		identities:  identities,
+	}
+}
+
+// Authenticate verifies the managed identity token in the bearer token, and returns the workload identity
+// mapped to the managed identity.
+func (a *AzureManagedIdentityAuthenticator) Authenticate(ctx context.Context) (*Caller, error) {
+	bearerToken, err := ExtractBearerToken(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("managed identity token extraction error: %v", err)
+	}
+	idToken, err := a.verifier.Verify(ctx, bearerToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify the managed identity token (error %v)", err)
+	}
+	claims := &azureManagedIdentityClaims{}
+	if err := idToken.Claims(claims); err != nil {
+		return nil, fmt.Errorf("failed to extract the managed identity claims: %v", err)
+	}
+	id, ok := a.identities.identity(a.trustDomain, claims.ObjectID, claims.ResourceID)
+	if !ok {
+		return nil, fmt.Errorf("no identity mapped to Azure managed identity %s", claims.ObjectID)
+	}
+	return &Caller{
+		AuthSource: AuthSourceIDToken,
+		Identities: []string{id},
+	}, nil
+}
+
+func (a *AzureManagedIdentityAuthenticator) AuthenticatorType() string {
+	return AzureManagedIdentityAuthenticatorType
+}
@@ -0,0 +1,125 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authenticate
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/coreos/go-oidc"
+	"google.golang.org/grpc/metadata"
+	"gopkg.in/square/go-jose.v2"
+)
+
+const fakeAzureIssuer = "https://sts.windows.net/tenant/"
+
+func signedAzureToken(t *testing.T, key *rsa.PrivateKey, claims map[string]interface{}) string {
+	t.Helper()
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: key},
+		(&jose.SignerOptions{}).WithType("JWT").WithHeader("kid", "key-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jws, err := signer.Sign(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := jws.CompactSerialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestAzureManagedIdentityAuthenticator(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwks := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+			{Key: &key.PublicKey, KeyID: "key-1", Algorithm: string(jose.RS256), Use: "sig"},
+		}})
+	}))
+	defer jwks.Close()
+
+	identities, err := ParsePlatformIdentities("object-1=vm/reviews")
+	if err != nil {
+		t.Fatal(err)
+	}
+	authn := newAzureManagedIdentityAuthenticator(fakeAzureIssuer, oidc.NewRemoteKeySet(context.Background(), jwks.URL),
+		"api://istio", "cluster.local", identities)
+
+	validClaims := func(oid string) map[string]interface{} {
+		return map[string]interface{}{
+			"iss": fakeAzureIssuer,
+			"aud": "api://istio",
+			"exp": time.Now().Add(time.Hour).Unix(),
+			"oid": oid,
+		}
+	}
+	wrongAudience := validClaims("object-1")
+	wrongAudience["aud"] = "api://other"
+
+	cases := []struct {
+		name     string
+		token    string
+		expected string
+	}{
+		{
+			name:     "mapped managed identity",
+			token:    signedAzureToken(t, key, validClaims("object-1")),
+			expected: "spiffe://cluster.local/ns/vm/sa/reviews",
+		},
+		{
+			name:  "unmapped managed identity",
+			token: signedAzureToken(t, key, validClaims("object-2")),
+		},
+		{
+			name:  "wrong audience",
+			token: signedAzureToken(t, key, wrongAudience),
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := metadata.NewIncomingContext(context.Background(), metadata.MD{
+				"authorization": []string{BearerTokenPrefix + tc.token},
+			})
+			caller, err := authn.Authenticate(ctx)
+			if tc.expected == "" {
+				if err == nil {
+					t.Errorf("expected an error, got %v", caller)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(caller.Identities) != 1 || caller.Identities[0] != tc.expected {
+				t.Errorf("got identities %v, expected %s", caller.Identities, tc.expected)
+			}
+		})
+	}
+}
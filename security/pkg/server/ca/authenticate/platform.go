@@ -0,0 +1,59 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authenticate
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PlatformIdentities maps the platform identities of VMs, such as an AWS IAM role or instance, or an Azure
+// managed identity, to the namespace and service account of the workload, in the "namespace/serviceaccount" format.
+type PlatformIdentities map[string]string
+
+// ParsePlatformIdentities parses a comma separated list of "platform-identity=namespace/serviceaccount".
+func ParsePlatformIdentities(s string) (PlatformIdentities, error) {
+	identities := PlatformIdentities{}
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		kv := strings.SplitN(entry, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, fmt.Errorf("invalid platform identity %q, expected platform-identity=namespace/serviceaccount", entry)
+		}
+		parts := strings.Split(kv[1], "/")
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid workload identity %q, expected namespace/serviceaccount", kv[1])
+		}
+		identities[kv[0]] = kv[1]
+	}
+	return identities, nil
+}
+
+// identity returns the SPIFFE identity mapped to the first of the platform identities found.
+func (p PlatformIdentities) identity(trustDomain string, platformIDs ...string) (string, bool) {
+	for _, id := range platformIDs {
+		if id == "" {
+			continue
+		}
+		if workload, ok := p[id]; ok {
+			parts := strings.Split(workload, "/")
+			return fmt.Sprintf(IdentityTemplate, trustDomain, parts[0], parts[1]), true
+		}
+	}
+	return "", false
+}
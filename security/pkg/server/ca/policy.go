@@ -0,0 +1,312 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ca
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/yaml"
+
+	"istio.io/istio/pkg/config/mesh"
+	"istio.io/istio/pkg/spiffe"
+	caerror "istio.io/istio/security/pkg/pki/error"
+	"istio.io/istio/security/pkg/pki/util"
+)
+
+const (
+	// KeyAlgorithmRSA is the type of the RSA keys.
+	KeyAlgorithmRSA = "RSA"
+	// KeyAlgorithmECDSA is the type of the ECDSA keys.
+	KeyAlgorithmECDSA = "ECDSA"
+)
+
+// CertPolicy restricts the certificates signed for the workloads it selects. The policies are set in the
+// certificatePolicies list of the mesh config, ordered by precedence: the first policy selecting a workload
+// applies to it. For example:
+//
+//   certificatePolicies:
+//   - name: pci
+//     selector:
+//       namespaceSelector:
+//         matchLabels:
+//           compliance: pci
+//     maxCertTTL: 1h
+//     keyAlgorithms:
+//     - type: ECDSA
+//       minSize: 384
+//   - name: default
+//     maxCertTTL: 720h
+//     keyAlgorithms:
+//     - type: RSA
+//       minSize: 2048
+type CertPolicy struct {
+	// Name identifies the policy in the errors of the rejected CSRs.
+	Name string `json:"name"`
+	// Selector selects the workloads the policy applies to. An empty selector selects all the workloads.
+	Selector CertPolicySelector `json:"selector,omitempty"`
+	// DefaultCertTTL is the TTL of the certificates when the CSR doesn't request one. It defaults to MaxCertTTL.
+	DefaultCertTTL *metav1.Duration `json:"defaultCertTTL,omitempty"`
+	// MaxCertTTL is the max TTL of the certificates. CSRs requesting a longer TTL are rejected.
+	MaxCertTTL *metav1.Duration `json:"maxCertTTL,omitempty"`
+	// KeyAlgorithms are the allowed algorithms of the keys of the CSRs. All algorithms are allowed if empty.
+	KeyAlgorithms []KeyAlgorithm `json:"keyAlgorithms,omitempty"`
+	// ExtraSANs are added to the SANs of the certificates, in addition to the identity of the workload.
+	ExtraSANs []string `json:"extraSANs,omitempty"`
+}
+
+// CertPolicySelector selects workloads by their identity. All the set fields must match.
+type CertPolicySelector struct {
+	// Namespaces are the namespaces of the workloads.
+	Namespaces []string `json:"namespaces,omitempty"`
+	// NamespaceSelector selects the namespaces of the workloads by their labels.
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+	// ServiceAccounts are the service accounts of the workloads.
+	ServiceAccounts []string `json:"serviceAccounts,omitempty"`
+}
+
+// KeyAlgorithm is an algorithm of the keys of the CSRs.
+type KeyAlgorithm struct {
+	// Type is RSA or ECDSA.
+	Type string `json:"type"`
+	// MinSize is the min size in bits of the RSA modulus or of the ECDSA curve.
+	MinSize int `json:"minSize,omitempty"`
+}
+
+func (a KeyAlgorithm) String() string {
+	if a.MinSize == 0 {
+		return a.Type
+	}
+	return fmt.Sprintf("%s of at least %d bits", a.Type, a.MinSize)
+}
+
+// ParseCertPolicies parses and validates the certificate policies of the mesh config YAML.
+func ParseCertPolicies(meshConfig []byte) ([]CertPolicy, error) {
+	fields := map[string]json.RawMessage{}
+	if err := yaml.Unmarshal(meshConfig, &fields); err != nil {
+		return nil, fmt.Errorf("failed to parse the mesh config: %v", err)
+	}
+	raw, ok := fields[mesh.CertificatePoliciesKey]
+	if !ok || string(raw) == "null" {
+		return nil, nil
+	}
+	var policies []CertPolicy
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&policies); err != nil {
+		return nil, fmt.Errorf("failed to parse the certificate policies: %v", err)
+	}
+	names := map[string]bool{}
+	for _, p := range policies {
+		if p.Name == "" {
+			return nil, fmt.Errorf("certificate policy without name")
+		}
+		if names[p.Name] {
+			return nil, fmt.Errorf("duplicate certificate policy %s", p.Name)
+		}
+		names[p.Name] = true
+		if p.Selector.NamespaceSelector != nil {
+			if _, err := metav1.LabelSelectorAsSelector(p.Selector.NamespaceSelector); err != nil {
+				return nil, fmt.Errorf("invalid namespace selector of certificate policy %s: %v", p.Name, err)
+			}
+		}
+		if p.MaxCertTTL != nil && p.MaxCertTTL.Duration <= 0 {
+			return nil, fmt.Errorf("max cert TTL of certificate policy %s must be positive", p.Name)
+		}
+		if p.DefaultCertTTL != nil {
+			if p.DefaultCertTTL.Duration <= 0 {
+				return nil, fmt.Errorf("default cert TTL of certificate policy %s must be positive", p.Name)
+			}
+			if p.MaxCertTTL != nil && p.DefaultCertTTL.Duration > p.MaxCertTTL.Duration {
+				return nil, fmt.Errorf("default cert TTL of certificate policy %s is greater than its max cert TTL", p.Name)
+			}
+		}
+		for _, alg := range p.KeyAlgorithms {
+			if alg.Type != KeyAlgorithmRSA && alg.Type != KeyAlgorithmECDSA {
+				return nil, fmt.Errorf("unsupported key algorithm %q of certificate policy %s, must be %s or %s",
+					alg.Type, p.Name, KeyAlgorithmRSA, KeyAlgorithmECDSA)
+			}
+		}
+	}
+	return policies, nil
+}
+
+// ValidateExternalCA returns an error if the policies can't be enforced when the certificates are signed by an
+// external CA through an RA. The RA only signs the SANs of the CSRs, so it rejects the extra SANs of the policies.
+func ValidateExternalCA(policies []CertPolicy) error {
+	for _, p := range policies {
+		if len(p.ExtraSANs) > 0 {
+			return fmt.Errorf("extra SANs of certificate policy %s are not supported with an external CA", p.Name)
+		}
+	}
+	return nil
+}
+
+// CertPolicies are the certificate policies enforced by the CA server.
+type CertPolicies struct {
+	// namespaceLabels returns the labels of a namespace, for the namespace selectors.
+	namespaceLabels func(namespace string) (map[string]string, error)
+
+	mutex    sync.RWMutex
+	policies []CertPolicy
+}
+
+// NewCertPolicies returns certificate policies selecting the namespaces by the labels returned by namespaceLabels.
+func NewCertPolicies(namespaceLabels func(namespace string) (map[string]string, error)) *CertPolicies {
+	return &CertPolicies{namespaceLabels: namespaceLabels}
+}
+
+// Set replaces the enforced policies.
+func (c *CertPolicies) Set(policies []CertPolicy) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.policies = policies
+}
+
+// Apply enforces the policy selecting the workload with the given identities on its CSR and requested TTL.
+// It returns the SANs and the TTL of the certificate, or an error if the CSR violates the policy.
+func (c *CertPolicies) Apply(csrPEM []byte, identities []string, ttl time.Duration) ([]string, time.Duration, error) {
+	policy, err := c.selectPolicy(identities)
+	if err != nil {
+		return nil, 0, caerror.NewError(caerror.CertPolicyError, err)
+	}
+	if policy == nil {
+		return identities, ttl, nil
+	}
+	if ttl <= 0 {
+		if policy.DefaultCertTTL != nil {
+			ttl = policy.DefaultCertTTL.Duration
+		} else if policy.MaxCertTTL != nil {
+			ttl = policy.MaxCertTTL.Duration
+		}
+	}
+	if policy.MaxCertTTL != nil && ttl > policy.MaxCertTTL.Duration {
+		return nil, 0, caerror.NewError(caerror.CertPolicyError, fmt.Errorf(
+			"requested TTL %s is greater than the max TTL %s of certificate policy %s",
+			ttl, policy.MaxCertTTL.Duration, policy.Name))
+	}
+	if len(policy.KeyAlgorithms) > 0 {
+		csr, err := util.ParsePemEncodedCSR(csrPEM)
+		if err != nil {
+			return nil, 0, caerror.NewError(caerror.CSRError, err)
+		}
+		alg, size := keyAlgorithm(csr.PublicKey)
+		if !allowedKeyAlgorithm(policy.KeyAlgorithms, alg, size) {
+			return nil, 0, caerror.NewError(caerror.CertPolicyError, fmt.Errorf(
+				"%s key of %d bits is not allowed by certificate policy %s, allowed key algorithms are %v",
+				alg, size, policy.Name, policy.KeyAlgorithms))
+		}
+	}
+	sans := append(append([]string{}, identities...), policy.ExtraSANs...)
+	return sans, ttl, nil
+}
+
+// selectPolicy returns the first policy selecting the workload with the given identities, or nil.
+func (c *CertPolicies) selectPolicy(identities []string) (*CertPolicy, error) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	if len(c.policies) == 0 {
+		return nil, nil
+	}
+	var id *spiffe.Identity
+	for _, identity := range identities {
+		if parsed, err := spiffe.ParseIdentity(identity); err == nil {
+			id = &parsed
+			break
+		}
+	}
+	for i := range c.policies {
+		policy := &c.policies[i]
+		selected, err := c.selects(policy.Selector, id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to evaluate certificate policy %s: %v", policy.Name, err)
+		}
+		if selected {
+			return policy, nil
+		}
+	}
+	return nil, nil
+}
+
+// selects returns whether the selector selects the workload with the given identity. Only empty selectors
+// select workloads without a SPIFFE identity.
+func (c *CertPolicies) selects(selector CertPolicySelector, id *spiffe.Identity) (bool, error) {
+	if len(selector.Namespaces) == 0 && selector.NamespaceSelector == nil && len(selector.ServiceAccounts) == 0 {
+		return true, nil
+	}
+	if id == nil {
+		return false, nil
+	}
+	if len(selector.Namespaces) > 0 && !contains(selector.Namespaces, id.Namespace) {
+		return false, nil
+	}
+	if len(selector.ServiceAccounts) > 0 && !contains(selector.ServiceAccounts, id.ServiceAccount) {
+		return false, nil
+	}
+	if selector.NamespaceSelector != nil {
+		s, err := metav1.LabelSelectorAsSelector(selector.NamespaceSelector)
+		if err != nil {
+			return false, err
+		}
+		if c.namespaceLabels == nil {
+			return false, fmt.Errorf("namespace labels are not available")
+		}
+		nsLabels, err := c.namespaceLabels(id.Namespace)
+		if err != nil {
+			return false, fmt.Errorf("failed to get the labels of namespace %s: %v", id.Namespace, err)
+		}
+		if !s.Matches(labels.Set(nsLabels)) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// keyAlgorithm returns the algorithm and the size in bits of the public key.
+func keyAlgorithm(publicKey interface{}) (string, int) {
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		return KeyAlgorithmRSA, key.N.BitLen()
+	case *ecdsa.PublicKey:
+		return KeyAlgorithmECDSA, key.Curve.Params().BitSize
+	default:
+		return fmt.Sprintf("%T", publicKey), 0
+	}
+}
+
+func allowedKeyAlgorithm(allowed []KeyAlgorithm, alg string, size int) bool {
+	for _, a := range allowed {
+		if a.Type == alg && size >= a.MinSize {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,229 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ca
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	caerror "istio.io/istio/security/pkg/pki/error"
+	"istio.io/istio/security/pkg/pki/util"
+)
+
+const testCertPolicies = `
+trustDomain: cluster.local
+certificatePolicies:
+- name: pci
+  selector:
+    namespaceSelector:
+      matchLabels:
+        compliance: pci
+  maxCertTTL: 1h
+  keyAlgorithms:
+  - type: ECDSA
+    minSize: 384
+- name: ingress
+  selector:
+    namespaces: [ingress]
+    serviceAccounts: [gateway]
+  extraSANs: [gateway.example.com]
+- name: default
+  defaultCertTTL: 24h
+  maxCertTTL: 720h
+  keyAlgorithms:
+  - type: RSA
+    minSize: 2048
+`
+
+func TestParseCertPolicies(t *testing.T) {
+	policies, err := ParseCertPolicies([]byte(testCertPolicies))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(policies) != 3 || policies[0].MaxCertTTL.Duration != time.Hour {
+		t.Errorf("unexpected policies %+v", policies)
+	}
+	if policies, err := ParseCertPolicies([]byte("trustDomain: cluster.local\n")); err != nil || policies != nil {
+		t.Errorf("expected no policies, got %v %v", policies, err)
+	}
+
+	for name, config := range map[string]string{
+		"missing name":          "certificatePolicies:\n- maxCertTTL: 1h\n",
+		"duplicate name":        "certificatePolicies:\n- name: a\n- name: a\n",
+		"unknown field":         "certificatePolicies:\n- name: a\n  maxTTL: 1h\n",
+		"unsupported algorithm": "certificatePolicies:\n- name: a\n  keyAlgorithms:\n  - type: DSA\n",
+		"default above max":     "certificatePolicies:\n- name: a\n  defaultCertTTL: 2h\n  maxCertTTL: 1h\n",
+		"invalid selector": "certificatePolicies:\n- name: a\n  selector:\n    namespaceSelector:\n" +
+			"      matchExpressions:\n      - {key: a, operator: Bad}\n",
+	} {
+		if _, err := ParseCertPolicies([]byte(config)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestValidateExternalCA(t *testing.T) {
+	policies, err := ParseCertPolicies([]byte(testCertPolicies))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateExternalCA(policies); err == nil || !strings.Contains(err.Error(), "certificate policy ingress") {
+		t.Errorf("expected an error for the extra SANs of the ingress policy, got %v", err)
+	}
+	if err := ValidateExternalCA(append(policies[:1:1], policies[2])); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestCertPoliciesApply(t *testing.T) {
+	policies, err := ParseCertPolicies([]byte(testCertPolicies))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := NewCertPolicies(func(namespace string) (map[string]string, error) {
+		switch namespace {
+		case "payments":
+			return map[string]string{"compliance": "pci"}, nil
+		case "missing":
+			return nil, fmt.Errorf("namespace %s not found", namespace)
+		}
+		return nil, nil
+	})
+	c.Set(policies)
+
+	rsa2048 := genCSR(t, util.CertOptions{RSAKeySize: 2048})
+	p256 := genCSR(t, util.CertOptions{ECSigAlg: util.EcdsaSigAlg})
+	p384 := genCSR(t, util.CertOptions{ECSigAlg: util.EcdsaSigAlg, ECCCurve: util.P384Curve})
+
+	cases := []struct {
+		name     string
+		csr      []byte
+		identity string
+		ttl      time.Duration
+		sans     []string
+		wantTTL  time.Duration
+		errMsg   string
+	}{
+		{
+			name:     "pci ECDSA P-384",
+			csr:      p384,
+			identity: "spiffe://cluster.local/ns/payments/sa/default",
+			ttl:      30 * time.Minute,
+			wantTTL:  30 * time.Minute,
+		},
+		{
+			name:     "pci default TTL",
+			csr:      p384,
+			identity: "spiffe://cluster.local/ns/payments/sa/default",
+			wantTTL:  time.Hour,
+		},
+		{
+			name:     "pci ECDSA P-256",
+			csr:      p256,
+			identity: "spiffe://cluster.local/ns/payments/sa/default",
+			errMsg:   "ECDSA key of 256 bits is not allowed by certificate policy pci",
+		},
+		{
+			name:     "pci RSA",
+			csr:      rsa2048,
+			identity: "spiffe://cluster.local/ns/payments/sa/default",
+			errMsg:   "RSA key of 2048 bits is not allowed by certificate policy pci",
+		},
+		{
+			name:     "pci TTL too long",
+			csr:      p384,
+			identity: "spiffe://cluster.local/ns/payments/sa/default",
+			ttl:      2 * time.Hour,
+			errMsg:   "requested TTL 2h0m0s is greater than the max TTL 1h0m0s of certificate policy pci",
+		},
+		{
+			name:     "extra SANs",
+			csr:      p256,
+			identity: "spiffe://cluster.local/ns/ingress/sa/gateway",
+			ttl:      time.Hour,
+			sans:     []string{"spiffe://cluster.local/ns/ingress/sa/gateway", "gateway.example.com"},
+			wantTTL:  time.Hour,
+		},
+		{
+			name:     "default RSA",
+			csr:      rsa2048,
+			identity: "spiffe://cluster.local/ns/default/sa/default",
+			wantTTL:  24 * time.Hour,
+		},
+		{
+			name:     "default ECDSA",
+			csr:      p384,
+			identity: "spiffe://cluster.local/ns/default/sa/default",
+			errMsg:   "ECDSA key of 384 bits is not allowed by certificate policy default",
+		},
+		{
+			name:     "unknown namespace",
+			csr:      rsa2048,
+			identity: "spiffe://cluster.local/ns/missing/sa/default",
+			errMsg:   "failed to evaluate certificate policy pci",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			sans, ttl, err := c.Apply(tc.csr, []string{tc.identity}, tc.ttl)
+			if tc.errMsg != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.errMsg) {
+					t.Fatalf("expected error %q, got %v", tc.errMsg, err)
+				}
+				if err.(*caerror.Error).ErrorType() != "CERT_POLICY_ERROR" {
+					t.Errorf("unexpected error type %s", err.(*caerror.Error).ErrorType())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			wantSANs := tc.sans
+			if wantSANs == nil {
+				wantSANs = []string{tc.identity}
+			}
+			if !reflect.DeepEqual(sans, wantSANs) {
+				t.Errorf("expected SANs %v, got %v", wantSANs, sans)
+			}
+			if ttl != tc.wantTTL {
+				t.Errorf("expected TTL %v, got %v", tc.wantTTL, ttl)
+			}
+		})
+	}
+}
+
+func TestCertPoliciesNoPolicy(t *testing.T) {
+	c := NewCertPolicies(nil)
+	sans, ttl, err := c.Apply([]byte("dumb CSR"), []string{"spiffe://cluster.local/ns/default/sa/default"}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sans) != 1 || ttl != time.Hour {
+		t.Errorf("expected the request to be unchanged, got %v %v", sans, ttl)
+	}
+}
+
+func genCSR(t *testing.T, options util.CertOptions) []byte {
+	t.Helper()
+	options.Host = "spiffe://cluster.local/ns/default/sa/default"
+	csr, _, err := util.GenCSR(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return csr
+}
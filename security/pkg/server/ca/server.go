@@ -27,6 +27,7 @@ import (
 	pb "istio.io/api/security/v1alpha1"
 	caerror "istio.io/istio/security/pkg/pki/error"
 	"istio.io/istio/security/pkg/pki/util"
+	"istio.io/istio/security/pkg/server/ca/audit"
 	"istio.io/istio/security/pkg/server/ca/authenticate"
 	"istio.io/pkg/log"
 )
@@ -49,8 +50,12 @@ type CertificateAuthority interface {
 type Server struct {
 	monitoring     monitoringMetrics
 	Authenticators []authenticate.Authenticator
-	ca             CertificateAuthority
-	serverCertTTL  time.Duration
+	// Policies restrict the certificates signed for the workloads. It may be nil.
+	Policies *CertPolicies
+	// Audit records the certificate signing requests. It may be nil.
+	Audit         *audit.Logger
+	ca            CertificateAuthority
+	serverCertTTL time.Duration
 }
 
 func getConnectionAddress(ctx context.Context) string {
@@ -68,25 +73,41 @@ func getConnectionAddress(ctx context.Context) string {
 // the subject public key is the public key in the CSR.
 // the validity duration is the ValidityDuration in request, or default value if the given duration is invalid.
 // it is signed by the CA signing key.
+// If a certificate policy selects the caller, CSRs violating it are rejected.
 func (s *Server) CreateCertificate(ctx context.Context, request *pb.IstioCertificateRequest) (
 	*pb.IstioCertificateResponse, error) {
 	s.monitoring.CSR.Increment()
+	record := s.auditRecord(ctx, request)
 	caller := s.authenticate(ctx)
 	if caller == nil {
 		s.monitoring.AuthnError.Increment()
+		s.logAudit(record, nil, nil, nil)
 		return nil, status.Error(codes.Unauthenticated, "request authenticate failure")
 	}
 
 	// TODO: Call authorizer.
 
+	sans, ttl := caller.Identities, time.Duration(request.ValidityDuration)*time.Second
+	if s.Policies != nil {
+		var policyErr error
+		sans, ttl, policyErr = s.Policies.Apply([]byte(request.Csr), caller.Identities, ttl)
+		if policyErr != nil {
+			serverCaLog.Warnf("CSR of %v rejected (%v)", caller.Identities, policyErr)
+			s.monitoring.GetCertSignError(policyErr.(*caerror.Error).ErrorType()).Increment()
+			s.logAudit(record, caller, nil, policyErr)
+			return nil, status.Errorf(policyErr.(*caerror.Error).HTTPErrorCode(), "CSR rejected (%v)", policyErr)
+		}
+	}
+
 	_, _, certChainBytes, rootCertBytes := s.ca.GetCAKeyCertBundle().GetAll()
-	cert, signErr := s.ca.Sign(
-		[]byte(request.Csr), caller.Identities, time.Duration(request.ValidityDuration)*time.Second, false)
+	cert, signErr := s.ca.Sign([]byte(request.Csr), sans, ttl, false)
 	if signErr != nil {
 		serverCaLog.Errorf("CSR signing error (%v)", signErr.Error())
 		s.monitoring.GetCertSignError(signErr.(*caerror.Error).ErrorType()).Increment()
+		s.logAudit(record, caller, nil, signErr)
 		return nil, status.Errorf(signErr.(*caerror.Error).HTTPErrorCode(), "CSR signing error (%v)", signErr.(*caerror.Error))
 	}
+	s.logAudit(record, caller, cert, nil)
 	respCertChain := []string{string(cert)}
 	if len(certChainBytes) != 0 {
 		respCertChain = append(respCertChain, string(certChainBytes))
@@ -19,20 +19,27 @@ import (
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"fmt"
+	"io/ioutil"
 	"net"
+	"os"
+	"path/filepath"
+	"reflect"
 	"testing"
+	"time"
 
 	"golang.org/x/net/context"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	pb "istio.io/api/security/v1alpha1"
 	mockca "istio.io/istio/security/pkg/pki/ca/mock"
 	caerror "istio.io/istio/security/pkg/pki/error"
 	"istio.io/istio/security/pkg/pki/util"
 	mockutil "istio.io/istio/security/pkg/pki/util/mock"
+	"istio.io/istio/security/pkg/server/ca/audit"
 	"istio.io/istio/security/pkg/server/ca/authenticate"
 )
 
@@ -263,3 +270,88 @@ func TestCreateCertificate(t *testing.T) {
 		}
 	}
 }
+
+func TestCreateCertificateWithPolicy(t *testing.T) {
+	policies := NewCertPolicies(nil)
+	policies.Set([]CertPolicy{{
+		Name:      "short-lived",
+		Selector:  CertPolicySelector{Namespaces: []string{"foo"}},
+		ExtraSANs: []string{"foo.example.com"},
+		MaxCertTTL: &metav1.Duration{
+			Duration: time.Hour,
+		},
+	}})
+	ca := &mockca.FakeCA{SignedCert: []byte("cert")}
+	server := &Server{
+		ca: ca,
+		Authenticators: []authenticate.Authenticator{&mockAuthenticator{
+			identities: []string{"spiffe://cluster.local/ns/foo/sa/bar"},
+		}},
+		Policies:   policies,
+		monitoring: newMonitoringMetrics(),
+	}
+
+	_, err := server.CreateCertificate(context.Background(), &pb.IstioCertificateRequest{
+		Csr:              "dumb CSR",
+		ValidityDuration: int64((2 * time.Hour).Seconds()),
+	})
+	if s, _ := status.FromError(err); s.Code() != codes.InvalidArgument {
+		t.Errorf("expected the CSR requesting a TTL above the max TTL of the policy to be rejected, got %v", err)
+	}
+
+	if _, err := server.CreateCertificate(context.Background(), &pb.IstioCertificateRequest{Csr: "dumb CSR"}); err != nil {
+		t.Fatal(err)
+	}
+	if ca.ReceivedLifetime != time.Hour {
+		t.Errorf("expected the max TTL of the policy, got %v", ca.ReceivedLifetime)
+	}
+	if !reflect.DeepEqual(ca.ReceivedIDs, []string{"spiffe://cluster.local/ns/foo/sa/bar", "foo.example.com"}) {
+		t.Errorf("unexpected SANs %v", ca.ReceivedIDs)
+	}
+}
+
+func TestCreateCertificateAudit(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ca-audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	sink, err := audit.NewFileSink(filepath.Join(dir, "ca-audit.log"), 1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	authn := &mockAuthenticator{identities: []string{"spiffe://cluster.local/ns/foo/sa/bar"}}
+	key := []byte("audit-key")
+	server := &Server{
+		ca:             &mockca.FakeCA{SignedCert: []byte("cert")},
+		Authenticators: []authenticate.Authenticator{authn},
+		Audit:          audit.NewLogger("istiod", key, 10, sink),
+		monitoring:     newMonitoringMetrics(),
+	}
+	if _, err := server.CreateCertificate(context.Background(), &pb.IstioCertificateRequest{Csr: "dumb CSR"}); err != nil {
+		t.Fatal(err)
+	}
+	authn.errMsg = "not authorized"
+	if _, err := server.CreateCertificate(context.Background(), &pb.IstioCertificateRequest{Csr: "dumb CSR"}); err == nil {
+		t.Fatal("expected the request to fail to authenticate")
+	}
+	server.Audit.Close()
+
+	records, err := sink.Query(audit.Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("expected 2 audit records, got %d", len(records))
+	}
+	if records[0].Outcome != audit.OutcomeIssued ||
+		!reflect.DeepEqual(records[0].CallerIdentities, []string{"spiffe://cluster.local/ns/foo/sa/bar"}) {
+		t.Errorf("unexpected audit record of the issued certificate %+v", records[0])
+	}
+	if records[1].Outcome != audit.OutcomeUnauthenticated || len(records[1].CallerIdentities) != 0 {
+		t.Errorf("unexpected audit record of the unauthenticated request %+v", records[1])
+	}
+	if errs := audit.Verify(records, key); len(errs) != 0 {
+		t.Errorf("unexpected verification errors %v", errs)
+	}
+}
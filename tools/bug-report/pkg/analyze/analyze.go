@@ -0,0 +1,281 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package analyze triages a bug report archive offline: it runs the config analyzers on the collected cluster
+// resources, checks that the config of the proxies is synced with Istiod and summarizes the errors of the logs.
+package analyze
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ghodss/yaml"
+
+	"istio.io/istio/galley/pkg/config/analysis/analyzers"
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+	"istio.io/istio/galley/pkg/config/analysis/local"
+	"istio.io/istio/istioctl/pkg/util/formatting"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema"
+	"istio.io/istio/tools/bug-report/pkg/archive"
+	"istio.io/istio/tools/bug-report/pkg/config"
+	"istio.io/istio/tools/bug-report/pkg/processlog"
+)
+
+const (
+	analysisTimeout = 5 * time.Minute
+	// topErrors is the number of most frequent errors printed for each log.
+	topErrors = 3
+)
+
+// clusterResourceFiles are the files of the cluster resources collected by GetK8sResources and GetCRs, which
+// contain the output of kubectl get -o yaml.
+var clusterResourceFiles = []string{"k8s-resources", "crs"}
+
+// LogSummary summarizes the errors of a log of the archive.
+type LogSummary struct {
+	// Path is the path of the log, relative to the root dir of the archive.
+	Path  string
+	Stats *processlog.Stats
+}
+
+// Report is the result of the analysis of an archive.
+type Report struct {
+	// Messages are the messages of the config analyzers.
+	Messages diag.Messages
+	// Proxies are the results of the comparison of the config of the proxies with Istiod.
+	Proxies []*ProxySync
+	// Logs are the logs with errors or warnings, from the most important one.
+	Logs []*LogSummary
+}
+
+// RootDir returns the root dir of the extracted archive in dir, in which Create puts the output root dir.
+func RootDir(dir string) string {
+	if _, err := os.Stat(filepath.Join(dir, "bug-report")); err == nil {
+		return filepath.Join(dir, "bug-report")
+	}
+	return dir
+}
+
+// Run analyzes the archive with the given root dir.
+func Run(root string, cfg *config.BugReportConfig) (*Report, error) {
+	messages, err := runAnalyzers(root, cfg.IstioNamespace)
+	if err != nil {
+		return nil, err
+	}
+	logs, err := summarizeLogs(root, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &Report{
+		Messages: messages,
+		Proxies:  CheckProxySync(root),
+		Logs:     logs,
+	}, nil
+}
+
+// runAnalyzers runs all the config analyzers on the cluster resources of the archive.
+func runAnalyzers(root, istioNamespace string) (diag.Messages, error) {
+	sa := local.NewSourceAnalyzer(schema.MustGet(), analyzers.AllCombined(),
+		"", resource.Namespace(istioNamespace), nil, true, analysisTimeout)
+
+	var readers []local.ReaderSource
+	var meshConfig string
+	for _, name := range clusterResourceFiles {
+		b, err := ioutil.ReadFile(filepath.Join(archive.ClusterInfoPath(root), name))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		docs, mesh, err := splitList(b, istioNamespace)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %v", name, err)
+		}
+		if mesh != "" {
+			meshConfig = mesh
+		}
+		readers = append(readers, local.ReaderSource{Name: name, Reader: bytes.NewReader(docs)})
+	}
+	if len(readers) == 0 {
+		return nil, fmt.Errorf("no cluster resources in %s", archive.ClusterInfoPath(root))
+	}
+
+	if meshConfig != "" {
+		f, err := ioutil.TempFile("", "mesh")
+		if err != nil {
+			return nil, err
+		}
+		defer os.Remove(f.Name())
+		_, err = f.WriteString(meshConfig)
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+		if err := sa.AddFileKubeMeshConfig(f.Name()); err != nil {
+			return nil, fmt.Errorf("invalid mesh config in the archive: %v", err)
+		}
+	}
+	if err := sa.AddReaderKubeSource(readers); err != nil {
+		return nil, err
+	}
+
+	result, err := sa.Analyze(make(chan struct{}))
+	if err != nil {
+		return nil, err
+	}
+	messages := result.Messages.SetDocRef("istioctl-analyze").SortedDedupedCopy()
+	return messages, nil
+}
+
+// splitList returns the items of the List output by kubectl get -o yaml as YAML documents, and the mesh config
+// of the istio config map in the Istio namespace, if it's in the list.
+func splitList(b []byte, istioNamespace string) ([]byte, string, error) {
+	list := struct {
+		Items []json.RawMessage `json:"items"`
+	}{}
+	if err := yaml.Unmarshal(b, &list); err != nil {
+		return nil, "", err
+	}
+	var docs [][]byte
+	mesh := ""
+	for _, item := range list.Items {
+		docs = append(docs, item)
+		cm := struct {
+			Kind     string `json:"kind"`
+			Metadata struct {
+				Name      string `json:"name"`
+				Namespace string `json:"namespace"`
+			} `json:"metadata"`
+			Data map[string]string `json:"data"`
+		}{}
+		if err := json.Unmarshal(item, &cm); err != nil {
+			return nil, "", err
+		}
+		if cm.Kind == "ConfigMap" && cm.Metadata.Name == "istio" && cm.Metadata.Namespace == istioNamespace {
+			mesh = cm.Data["mesh"]
+		}
+	}
+	// JSON documents are YAML documents.
+	return bytes.Join(docs, []byte("\n---\n")), mesh, nil
+}
+
+// summarizeLogs returns the logs of the archive with errors or warnings, from the most important one.
+func summarizeLogs(root string, cfg *config.BugReportConfig) ([]*LogSummary, error) {
+	var out []*LogSummary
+	err := filepath.Walk(root, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		// Skip the log of the bug-report command itself, at the root.
+		if !fi.Mode().IsRegular() || !strings.HasSuffix(path, ".log") || filepath.Dir(path) == root {
+			return nil
+		}
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		stats := processlog.GetStats(cfg, string(b))
+		if stats.Importance() == 0 {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		out = append(out, &LogSummary{Path: rel, Stats: stats})
+		return nil
+	})
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].Stats.Importance() > out[j].Stats.Importance()
+	})
+	return out, err
+}
+
+// Print writes the report in a human readable format.
+func (r *Report) Print(w io.Writer) error {
+	fmt.Fprintf(w, "Config analysis:\n\n")
+	if len(r.Messages) == 0 {
+		fmt.Fprintf(w, "No validation issues found.\n")
+	} else {
+		out, err := formatting.Print(r.Messages, formatting.LogFormat, false)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(w, out)
+	}
+
+	fmt.Fprintf(w, "\nProxy sync status:\n\n")
+	synced := 0
+	tw := tabwriter.NewWriter(w, 0, 8, 1, ' ', 0)
+	fmt.Fprintf(tw, "PROXY\t%s\tISTIOD\n", strings.Join(xdsTypes, "\t"))
+	for _, p := range r.Proxies {
+		if p.Synced() {
+			synced++
+			continue
+		}
+		var status []string
+		for _, t := range xdsTypes {
+			status = append(status, p.Status[t])
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", p.Proxy, strings.Join(status, "\t"), p.Istiod)
+	}
+	if synced != len(r.Proxies) {
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+		for _, p := range r.Proxies {
+			for _, d := range p.Details {
+				fmt.Fprintf(w, "  %s: %s\n", p.Proxy, d)
+			}
+		}
+	}
+	fmt.Fprintf(w, "%d of %d checked proxies are synced.\n", synced, len(r.Proxies))
+
+	fmt.Fprintf(w, "\nLog errors:\n\n")
+	if len(r.Logs) == 0 {
+		fmt.Fprintf(w, "No errors or warnings found in the logs.\n")
+		return nil
+	}
+	tw = tabwriter.NewWriter(w, 0, 8, 1, ' ', 0)
+	fmt.Fprintf(tw, "LOG\tCRITICAL\tFATAL\tERROR\tWARNING\n")
+	for _, l := range r.Logs {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\n", l.Path,
+			l.Stats.CriticalErrors(), l.Stats.Fatals(), l.Stats.Errors(), l.Stats.Warnings())
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	for _, l := range r.Logs {
+		top := l.Stats.TopErrors(topErrors)
+		if len(top) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "\nMost frequent errors in %s:\n", l.Path)
+		for _, e := range top {
+			fmt.Fprintf(w, "  %6d %s\n", e.Count, e.Message)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,252 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package analyze
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"istio.io/istio/tools/bug-report/pkg/archive"
+	"istio.io/pkg/log"
+)
+
+const (
+	// configDumpFile is the file of the config dump of a proxy, from the config_dump?include_eds debug URL.
+	configDumpFile = "config_dump?include_eds"
+
+	Synced       = "SYNCED"
+	NotSent      = "NOT SENT"
+	Stale        = "STALE"
+	NotConnected = "NOT CONNECTED"
+)
+
+// xdsTypes are the xDS types compared between the proxies and Istiod. The config dump of the proxies has the
+// version of the endpoints of each cluster, pushed separately, so EDS is only compared with the ACK of the proxy.
+var xdsTypes = []string{"CDS", "LDS", "RDS", "EDS"}
+
+// SyncStatus is the sync status of a proxy reported by Istiod in debug/syncz.
+type SyncStatus struct {
+	ProxyID       string `json:"proxy,omitempty"`
+	ClusterSent   string `json:"cluster_sent,omitempty"`
+	ClusterAcked  string `json:"cluster_acked,omitempty"`
+	ListenerSent  string `json:"listener_sent,omitempty"`
+	ListenerAcked string `json:"listener_acked,omitempty"`
+	RouteSent     string `json:"route_sent,omitempty"`
+	RouteAcked    string `json:"route_acked,omitempty"`
+	EndpointSent  string `json:"endpoint_sent,omitempty"`
+	EndpointAcked string `json:"endpoint_acked,omitempty"`
+}
+
+// Stale reports whether the proxy has not acked the latest config sent by Istiod.
+func (s *SyncStatus) Stale() bool {
+	return s.ClusterSent != s.ClusterAcked || s.ListenerSent != s.ListenerAcked ||
+		s.RouteSent != s.RouteAcked || s.EndpointSent != s.EndpointAcked
+}
+
+// nonces returns the nonces sent and acked of the xDS type.
+func (s *SyncStatus) nonces(xdsType string) (sent string, acked string) {
+	switch xdsType {
+	case "CDS":
+		return s.ClusterSent, s.ClusterAcked
+	case "LDS":
+		return s.ListenerSent, s.ListenerAcked
+	case "RDS":
+		return s.RouteSent, s.RouteAcked
+	default:
+		return s.EndpointSent, s.EndpointAcked
+	}
+}
+
+// ProxyStatus is the sync status of a proxy reported by an Istiod instance.
+type ProxyStatus struct {
+	SyncStatus
+	// Istiod is the namespace/pod of the Istiod instance the proxy is connected to.
+	Istiod string
+}
+
+// ReadProxyStatus returns the sync status of the proxies reported by the Istiod instances in their debug/syncz
+// output in the archive with the given root dir, keyed by the namespace/pod of the proxies.
+func ReadProxyStatus(root string) map[string]*ProxyStatus {
+	out := make(map[string]*ProxyStatus)
+	files, err := filepath.Glob(filepath.Join(archive.IstiodPath(root, "*", "*"), "debug", "syncz"))
+	if err != nil {
+		log.Error(err.Error())
+		return out
+	}
+	for _, f := range files {
+		b, err := ioutil.ReadFile(f)
+		if err != nil {
+			log.Error(err.Error())
+			continue
+		}
+		var statuses []SyncStatus
+		if err := json.Unmarshal(b, &statuses); err != nil {
+			log.Errorf("failed to parse %s: %v", f, err)
+			continue
+		}
+		// The file is in istio/namespace/pod/debug.
+		podDir := filepath.Dir(filepath.Dir(f))
+		istiod := filepath.Base(filepath.Dir(podDir)) + "/" + filepath.Base(podDir)
+		for _, s := range statuses {
+			if key, ok := proxyKey(s.ProxyID); ok {
+				out[key] = &ProxyStatus{SyncStatus: s, Istiod: istiod}
+			}
+		}
+	}
+	return out
+}
+
+// proxyKey returns the namespace/pod of the proxy ID, which is pod.namespace. Namespaces cannot have dots.
+func proxyKey(proxyID string) (string, bool) {
+	idx := strings.LastIndex(proxyID, ".")
+	if idx < 0 {
+		return "", false
+	}
+	return proxyID[idx+1:] + "/" + proxyID[:idx], true
+}
+
+// ProxySync compares the config dump of a proxy with the config Istiod reports it sent to the proxy.
+type ProxySync struct {
+	// Proxy is the namespace/pod of the proxy.
+	Proxy string
+	// Istiod is the namespace/pod of the Istiod instance the proxy is connected to.
+	Istiod string
+	// Status is the sync status of each xDS type.
+	Status map[string]string
+	// Details explain why the proxy is not synced.
+	Details []string
+}
+
+// Synced reports whether the config of all the xDS types of the proxy is synced.
+func (p *ProxySync) Synced() bool {
+	for _, s := range p.Status {
+		if s != Synced {
+			return false
+		}
+	}
+	return true
+}
+
+// CheckProxySync compares the config dumps of the proxies in the archive with the root dir with the config the
+// Istiod instances report they sent, for the proxies with a config dump and those Istiod reports as stale.
+func CheckProxySync(root string) []*ProxySync {
+	statuses := ReadProxyStatus(root)
+	proxies := make(map[string]bool)
+	dumps, err := filepath.Glob(filepath.Join(archive.ProxyOutputPath(root, "*", "*"), configDumpFile))
+	if err != nil {
+		log.Error(err.Error())
+	}
+	for _, d := range dumps {
+		podDir := filepath.Dir(d)
+		proxies[filepath.Base(filepath.Dir(podDir))+"/"+filepath.Base(podDir)] = true
+	}
+	for key, s := range statuses {
+		if s.Stale() {
+			proxies[key] = true
+		}
+	}
+
+	var out []*ProxySync
+	for key := range proxies {
+		parts := strings.SplitN(key, "/", 2)
+		ps := &ProxySync{Proxy: key, Status: make(map[string]string)}
+		versions, hasDump, err := dumpVersions(filepath.Join(archive.ProxyOutputPath(root, parts[0], parts[1]), configDumpFile))
+		if err != nil {
+			ps.Details = append(ps.Details, fmt.Sprintf("cannot parse the config dump: %v", err))
+		}
+		status, found := statuses[key]
+		if !found {
+			for _, t := range xdsTypes {
+				ps.Status[t] = NotConnected
+			}
+			ps.Details = append(ps.Details, "not connected to any Istiod instance of the archive")
+			out = append(out, ps)
+			continue
+		}
+		ps.Istiod = status.Istiod
+		for _, t := range xdsTypes {
+			sent, acked := status.nonces(t)
+			switch {
+			case sent == "":
+				ps.Status[t] = NotSent
+			case sent != acked:
+				ps.Status[t] = Stale
+				ps.Details = append(ps.Details, fmt.Sprintf("%s nonce %s sent by Istiod is not acked, last acked is %q", t, sent, acked))
+			default:
+				ps.Status[t] = Synced
+				if !hasDump {
+					continue
+				}
+				// The nonces sent by Istiod start with the version of the config.
+				for _, v := range versions[t] {
+					if !strings.HasPrefix(sent, v) {
+						ps.Status[t] = Stale
+						ps.Details = append(ps.Details,
+							fmt.Sprintf("%s version %q in the config dump does not match nonce %s sent by Istiod", t, v, sent))
+						break
+					}
+				}
+			}
+		}
+		out = append(out, ps)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Proxy < out[j].Proxy
+	})
+	return out
+}
+
+// dumpVersions returns the versions of the dynamic config of the CDS, LDS and RDS types in the config dump file of
+// a proxy. It returns false if the file does not exist.
+func dumpVersions(file string) (map[string][]string, bool, error) {
+	b, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	var dump struct {
+		Configs []struct {
+			Type                string `json:"@type"`
+			VersionInfo         string `json:"version_info"`
+			DynamicRouteConfigs []struct {
+				VersionInfo string `json:"version_info"`
+			} `json:"dynamic_route_configs"`
+		} `json:"configs"`
+	}
+	if err := json.Unmarshal(b, &dump); err != nil {
+		return nil, false, err
+	}
+	out := make(map[string][]string)
+	for _, c := range dump.Configs {
+		switch c.Type[strings.LastIndex(c.Type, ".")+1:] {
+		case "ClustersConfigDump":
+			out["CDS"] = append(out["CDS"], c.VersionInfo)
+		case "ListenersConfigDump":
+			out["LDS"] = append(out["LDS"], c.VersionInfo)
+		case "RoutesConfigDump":
+			for _, r := range c.DynamicRouteConfigs {
+				out["RDS"] = append(out["RDS"], r.VersionInfo)
+			}
+		}
+	}
+	return out, true, nil
+}
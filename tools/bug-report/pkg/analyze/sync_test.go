@@ -0,0 +1,93 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package analyze
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"istio.io/istio/tools/bug-report/pkg/archive"
+)
+
+const syncz = `[
+  {"proxy": "httpbin-1.foo", "cluster_sent": "v2/1-a", "cluster_acked": "v2/1-a", "listener_sent": "v2/1-b",
+   "listener_acked": "v2/1-b", "route_sent": "v2/1-c", "route_acked": "v2/1-c", "endpoint_sent": "v2/1-d",
+   "endpoint_acked": "v2/1-d"},
+  {"proxy": "sleep-1.foo", "cluster_sent": "v2/1-a", "cluster_acked": "v2/1-a", "listener_sent": "v2/1-b",
+   "listener_acked": "v2/1-b", "route_sent": "v2/1-c", "route_acked": "v2/1-c", "endpoint_sent": "v2/1-d",
+   "endpoint_acked": "v2/1-d"},
+  {"proxy": "reviews-1.bar", "cluster_sent": "v2/1-a", "cluster_acked": "v1/1-a", "listener_sent": "v2/1-b",
+   "listener_acked": "v2/1-b"}
+]`
+
+func configDump(cds, lds, rds string) string {
+	return fmt.Sprintf(`{"configs": [
+  {"@type": "type.googleapis.com/envoy.admin.v3.BootstrapConfigDump"},
+  {"@type": "type.googleapis.com/envoy.admin.v3.ClustersConfigDump", "version_info": %q},
+  {"@type": "type.googleapis.com/envoy.admin.v3.ListenersConfigDump", "version_info": %q},
+  {"@type": "type.googleapis.com/envoy.admin.v3.RoutesConfigDump", "dynamic_route_configs": [{"version_info": %q}]}
+]}`, cds, lds, rds)
+}
+
+func TestCheckProxySync(t *testing.T) {
+	root, err := ioutil.TempDir("", "bug-report")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	writeTestFile(t, filepath.Join(archive.IstiodPath(root, "istio-system", "istiod-1"), "debug", "syncz"), syncz)
+	writeTestFile(t, filepath.Join(archive.ProxyOutputPath(root, "foo", "httpbin-1"), configDumpFile), configDump("v2", "v2", "v2"))
+	writeTestFile(t, filepath.Join(archive.ProxyOutputPath(root, "foo", "sleep-1"), configDumpFile), configDump("v2", "v2", "v1"))
+	writeTestFile(t, filepath.Join(archive.ProxyOutputPath(root, "baz", "ratings-1"), configDumpFile), configDump("v2", "v2", "v2"))
+
+	got := map[string]string{}
+	for _, p := range CheckProxySync(root) {
+		var status []string
+		for _, t := range xdsTypes {
+			status = append(status, p.Status[t])
+		}
+		got[p.Proxy] = p.Istiod + " " + strings.Join(status, ",")
+		if !p.Synced() && len(p.Details) == 0 {
+			t.Errorf("expected details for proxy %s", p.Proxy)
+		}
+	}
+	want := map[string]string{
+		"foo/httpbin-1": "istio-system/istiod-1 SYNCED,SYNCED,SYNCED,SYNCED",
+		// The routes in the config dump are older than the ones acked.
+		"foo/sleep-1": "istio-system/istiod-1 SYNCED,SYNCED,STALE,SYNCED",
+		// Without config dump, only the ACK is checked.
+		"bar/reviews-1": "istio-system/istiod-1 STALE,SYNCED,NOT SENT,NOT SENT",
+		"baz/ratings-1": " NOT CONNECTED,NOT CONNECTED,NOT CONNECTED,NOT CONNECTED",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got sync status %v, want %v", got, want)
+	}
+}
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,230 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package archive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+const (
+	// ManifestFileName is the name of the manifest of the truncated and dropped files, in the output root dir.
+	ManifestFileName = "manifest.json"
+
+	// tarHeaderSize is added to the estimated size of each file, uncompressed to keep the estimate conservative.
+	tarHeaderSize = 512
+	// minTruncatedSize is the smallest budget worth keeping the end of a truncated file for.
+	minTruncatedSize = 4 * 1024
+	truncatedMarker  = "========= Truncated %d bytes to fit in the maximum archive size =========\n"
+)
+
+// Artifact describes how a file of the archive is ranked against the size budget.
+type Artifact struct {
+	// Importance ranks the files, the most important ones are included first.
+	Importance int
+	// Required files are always included, whatever their size.
+	Required bool
+	// Truncatable files, like logs, keep their most recent lines when they do not fit in the budget.
+	Truncatable bool
+}
+
+// ManifestEntry is a file that was truncated or dropped from the archive.
+type ManifestEntry struct {
+	Path       string `json:"path"`
+	Importance int    `json:"importance"`
+	// Size is the estimated compressed size of the file, in bytes.
+	Size int64 `json:"size"`
+	// KeptSize is the estimated compressed size of the part of a truncated file kept in the archive.
+	KeptSize int64 `json:"keptSize,omitempty"`
+}
+
+// Manifest records the files that did not fit in the maximum size of the archive.
+type Manifest struct {
+	MaxSize int64 `json:"maxSize"`
+	// Size is the estimated size of the archive, without the manifest.
+	Size      int64           `json:"size"`
+	Truncated []ManifestEntry `json:"truncated,omitempty"`
+	Dropped   []ManifestEntry `json:"dropped,omitempty"`
+}
+
+type budgetFile struct {
+	Artifact
+	// path is relative to the dir of the archive.
+	path string
+	// size is the estimated compressed size of the file in the archive.
+	size int64
+	// keptSize is the size kept in the archive, it's smaller than size if the file is truncated.
+	keptSize int64
+}
+
+func (f *budgetFile) entry() ManifestEntry {
+	e := ManifestEntry{Path: f.path, Importance: f.Importance, Size: f.size}
+	if f.keptSize != f.size {
+		e.KeptSize = f.keptSize
+	}
+	return e
+}
+
+// FitToSize truncates or removes the least important files of srcDir, so that the archive created from it is
+// estimated to be smaller than maxSize bytes, and writes the manifest of the truncated and removed files to
+// ManifestFileName in rootDir. rank returns how a file, identified by its path relative to srcDir, is ranked.
+func FitToSize(srcDir, rootDir string, maxSize int64, rank func(path string) Artifact) (*Manifest, error) {
+	var files []*budgetFile
+	err := filepath.Walk(srcDir, func(file string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !fi.Mode().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(srcDir, file)
+		if err != nil {
+			return err
+		}
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		size := compressedSize(data) + tarHeaderSize
+		files = append(files, &budgetFile{Artifact: rank(rel), path: rel, size: size, keptSize: size})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(files, func(i, j int) bool {
+		a, b := files[i], files[j]
+		if a.Required != b.Required {
+			return a.Required
+		}
+		if a.Importance != b.Importance {
+			return a.Importance > b.Importance
+		}
+		// Many small files are more useful than a large one of the same importance.
+		if a.size != b.size {
+			return a.size < b.size
+		}
+		return a.path < b.path
+	})
+
+	m := &Manifest{MaxSize: maxSize}
+	var included []*budgetFile
+	for _, f := range files {
+		remaining := maxSize - m.Size
+		if f.Required || f.size <= remaining {
+			m.Size += f.size
+			included = append(included, f)
+			continue
+		}
+		if f.Truncatable && remaining >= minTruncatedSize {
+			kept, err := truncateToSize(filepath.Join(srcDir, f.path), remaining-tarHeaderSize)
+			if err != nil {
+				return nil, err
+			}
+			if kept > 0 {
+				f.keptSize = kept + tarHeaderSize
+				m.Size += f.keptSize
+				included = append(included, f)
+				continue
+			}
+		}
+		if err := os.Remove(filepath.Join(srcDir, f.path)); err != nil {
+			return nil, err
+		}
+		m.Dropped = append(m.Dropped, f.entry())
+	}
+
+	// The manifest must fit too, drop the least important files until it does.
+	for {
+		for _, f := range included {
+			if f.keptSize != f.size {
+				m.Truncated = append(m.Truncated, f.entry())
+			}
+		}
+		data, err := json.MarshalIndent(m, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		last := len(included) - 1
+		if m.Size+compressedSize(data)+tarHeaderSize <= maxSize || last < 0 || included[last].Required {
+			if err := ioutil.WriteFile(filepath.Join(rootDir, ManifestFileName), data, 0644); err != nil {
+				return nil, err
+			}
+			return m, nil
+		}
+		f := included[last]
+		included = included[:last]
+		if err := os.Remove(filepath.Join(srcDir, f.path)); err != nil {
+			return nil, err
+		}
+		m.Size -= f.keptSize
+		f.keptSize = f.size
+		m.Dropped = append(m.Dropped, f.entry())
+		m.Truncated = nil
+	}
+}
+
+// truncateToSize keeps the most recent lines of the file at the end of it, so that its compressed size is smaller
+// than maxSize. It returns the compressed size of the truncated file, or 0 if nothing could be kept.
+func truncateToSize(file string, maxSize int64) (int64, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil || len(data) == 0 {
+		return 0, err
+	}
+	ratio := float64(compressedSize(data)) / float64(len(data))
+	keep := int(float64(maxSize) / ratio * 0.9)
+	// The compression ratio of the end of the file may differ, retry with a smaller part of it.
+	for i := 0; i < 5 && keep > 0; i++ {
+		if keep > len(data) {
+			keep = len(data)
+		}
+		tail := data[len(data)-keep:]
+		// Start at a line boundary.
+		if idx := bytes.IndexByte(tail, '\n'); idx >= 0 && idx+1 < len(tail) {
+			tail = tail[idx+1:]
+		}
+		out := append([]byte(fmt.Sprintf(truncatedMarker, len(data)-len(tail))), tail...)
+		size := compressedSize(out)
+		if size <= maxSize {
+			return size, ioutil.WriteFile(file, out, 0644)
+		}
+		keep = int(float64(keep) * float64(maxSize) / float64(size) * 0.9)
+	}
+	return 0, nil
+}
+
+// compressedSize returns the size of the data compressed with gzip.
+func compressedSize(data []byte) int64 {
+	var c counter
+	gzw := gzip.NewWriter(&c)
+	_, _ = gzw.Write(data)
+	_ = gzw.Close()
+	return c.n
+}
+
+type counter struct {
+	n int64
+}
+
+func (c *counter) Write(p []byte) (int, error) {
+	c.n += int64(len(p))
+	return len(p), nil
+}
@@ -0,0 +1,145 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package archive
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// randomLines returns n log lines that do not compress much.
+func randomLines(r *rand.Rand, n int) string {
+	var sb strings.Builder
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&sb, "line %d %x\n", i, r.Int63())
+	}
+	return sb.String()
+}
+
+func TestFitToSize(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bug-report")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	rootDir := filepath.Join(dir, "bug-report")
+
+	r := rand.New(rand.NewSource(1))
+	files := map[string]struct {
+		content  string
+		artifact Artifact
+	}{
+		"bug-report/versions":                             {"1.9.0", Artifact{Required: true}},
+		"bug-report/proxies/foo/httpbin/istio-proxy.log":  {randomLines(r, 20000), Artifact{Importance: 100, Truncatable: true}},
+		"bug-report/proxies/foo/httpbin/config_dump":      {randomLines(r, 1000), Artifact{Importance: 100}},
+		"bug-report/cluster/crs":                          {randomLines(r, 2000), Artifact{Importance: 50}},
+		"bug-report/proxies/bar/productpage/config_dump":  {randomLines(r, 100000), Artifact{Importance: 10}},
+		"bug-report/proxies/bar/productpage/cores/0.core": {randomLines(r, 100), Artifact{Importance: 1}},
+	}
+	for path, f := range files {
+		writeTestFile(t, filepath.Join(dir, path), f.content)
+	}
+
+	maxSize := int64(200 * 1024)
+	m, err := FitToSize(dir, rootDir, maxSize, func(path string) Artifact {
+		return files[path].artifact
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if m.Size > maxSize {
+		t.Errorf("expected an estimated size below %d, got %d", maxSize, m.Size)
+	}
+	if len(m.Truncated) != 1 || m.Truncated[0].Path != "bug-report/proxies/foo/httpbin/istio-proxy.log" {
+		t.Errorf("expected the proxy log to be truncated, got %+v", m.Truncated)
+	}
+	var dropped []string
+	for _, e := range m.Dropped {
+		dropped = append(dropped, e.Path)
+	}
+	// The small core dump still fits after the log is truncated.
+	if want := []string{"bug-report/cluster/crs",
+		"bug-report/proxies/bar/productpage/config_dump"}; strings.Join(dropped, ",") != strings.Join(want, ",") {
+		t.Errorf("expected dropped files %v, got %v", want, dropped)
+	}
+	for _, path := range dropped {
+		if _, err := os.Stat(filepath.Join(dir, path)); !os.IsNotExist(err) {
+			t.Errorf("expected %s to be removed", path)
+		}
+	}
+
+	// The most recent lines of the log are kept.
+	log, err := ioutil.ReadFile(filepath.Join(dir, "bug-report/proxies/foo/httpbin/istio-proxy.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(log), "========= Truncated ") || !strings.Contains(string(log), "\nline 19999 ") {
+		t.Errorf("expected the end of the log to be kept after the truncation marker, got %.100q...", log)
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(rootDir, ManifestFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := &Manifest{}
+	if err := json.Unmarshal(data, got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Dropped) != len(m.Dropped) || len(got.Truncated) != len(m.Truncated) {
+		t.Errorf("expected the manifest %+v, got %+v", m, got)
+	}
+
+	outDir, err := ioutil.TempDir("", "bug-report-out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(outDir)
+	out := filepath.Join(outDir, "bug-report.tgz")
+	if err := Create(dir, out); err != nil {
+		t.Fatal(err)
+	}
+	fi, err := os.Stat(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Size() > maxSize {
+		t.Errorf("expected an archive smaller than %d, got %d", maxSize, fi.Size())
+	}
+
+	extracted := filepath.Join(outDir, "extracted")
+	if err := Extract(out, extracted); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(extracted, "bug-report", ManifestFileName)); err != nil {
+		t.Errorf("expected the manifest in the extracted archive: %v", err)
+	}
+}
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
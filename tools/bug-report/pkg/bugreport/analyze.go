@@ -0,0 +1,70 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bugreport
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"istio.io/istio/tools/bug-report/pkg/analyze"
+	"istio.io/istio/tools/bug-report/pkg/archive"
+)
+
+func analyzeCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "analyze <archive>",
+		Short: "Analyzes a bug report archive offline.",
+		Long: `analyze triages an archive created by bug-report, without access to the cluster. It runs the Istio config
+analyzers on the collected cluster resources, compares the config dump of each proxy with the config Istiod reports
+it sent to the proxy, and summarizes the errors of the collected logs.
+The archive can be the bug-report.tgz file or the directory it was extracted to.
+The --istio-namespace, --critical-errs, --ignore-errs and --filename flags apply to the analysis.`,
+		Example: `  bug-report analyze bug-report.tgz
+  bug-report analyze --critical-errs "*certificate*" ./bug-report`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runAnalyzeArchive(cmd.OutOrStdout(), args[0])
+		},
+	}
+}
+
+func runAnalyzeArchive(w io.Writer, path string) error {
+	config, err := parseConfig()
+	if err != nil {
+		return err
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	dir := path
+	if !fi.IsDir() {
+		if dir, err = ioutil.TempDir("", "bug-report-analyze"); err != nil {
+			return err
+		}
+		defer os.RemoveAll(dir)
+		if err := archive.Extract(path, dir); err != nil {
+			return err
+		}
+	}
+	report, err := analyze.Run(analyze.RootDir(dir), config)
+	if err != nil {
+		return err
+	}
+	return report.Print(w)
+}
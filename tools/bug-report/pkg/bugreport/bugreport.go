@@ -46,6 +46,7 @@ import (
 	"istio.io/istio/tools/bug-report/pkg/kubeclient"
 	"istio.io/istio/tools/bug-report/pkg/kubectlcmd"
 	"istio.io/istio/tools/bug-report/pkg/processlog"
+	"istio.io/istio/tools/bug-report/pkg/redact"
 	"istio.io/pkg/log"
 	"istio.io/pkg/version"
 )
@@ -79,12 +80,26 @@ All names except label and annotation keys support '*' glob matching pattern.
 
 e.g.
 --include ns1,ns2 (only namespaces ns1 and ns2)
---include n*//p*/l=v* (pods with name beginning with 'p' in namespaces beginning with 'n' and having label 'l' with value beginning with 'v'.)`,
+--include n*//p*/l=v* (pods with name beginning with 'p' in namespaces beginning with 'n' and having label 'l' with value beginning with 'v'.)
+
+JWTs, authorization credentials, private keys and email addresses are redacted from the archive, unless
+--disable-redaction is set. More content can be redacted with regex or JSONPath rules in the redaction section of the
+config file, e.g.
+
+redaction:
+  rules:
+  - name: host
+    regex: '[a-z0-9.-]+\.example\.com'
+    pseudonymize: true
+
+Pseudonymized values are replaced by the same token in all the files of the archive. The archive includes a summary
+of what was redacted in redaction.json.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return runBugReportCommand(cmd, logOpts)
 		},
 	}
 	rootCmd.AddCommand(version.CobraCommand())
+	rootCmd.AddCommand(analyzeCmd())
 	addFlags(rootCmd, gConfig)
 
 	return rootCmd
@@ -109,6 +124,13 @@ func runBugReportCommand(_ *cobra.Command, logOpts *log.Options) error {
 	if err != nil {
 		return err
 	}
+	// Check the redaction rules before capturing anything.
+	var redactor *redact.Redactor
+	if !config.Redaction.Disabled {
+		if redactor, err = redact.New(&config.Redaction); err != nil {
+			return err
+		}
+	}
 
 	clusterCtxStr, err := content.GetClusterContext()
 	if err != nil {
@@ -146,7 +168,6 @@ func runBugReportCommand(_ *cobra.Command, logOpts *log.Options) error {
 		log.Error(gErrors.ToError())
 	}
 
-	// TODO: sort by importance and discard any over the size limit.
 	for path, text := range logs {
 		namespace, _, pod, _, err := cluster2.ParsePath(path)
 		if err != nil {
@@ -156,6 +177,18 @@ func runBugReportCommand(_ *cobra.Command, logOpts *log.Options) error {
 		writeFile(filepath.Join(archive.ProxyOutputPath(tempDir, namespace, pod), common.ProxyContainerName+".log"), text)
 	}
 
+	if redactor != nil {
+		if err := redactContent(redactor); err != nil {
+			return err
+		}
+	}
+
+	if config.MaxArchiveSizeMb > 0 {
+		if err := fitToSize(int64(config.MaxArchiveSizeMb) * 1024 * 1024); err != nil {
+			return err
+		}
+	}
+
 	outDir, err := os.Getwd()
 	if err != nil {
 		log.Errorf("using ./ to write archive: %s", err.Error())
@@ -176,6 +209,21 @@ func runBugReportCommand(_ *cobra.Command, logOpts *log.Options) error {
 	return nil
 }
 
+// redactContent redacts the captured content, except the log of the command which is still being written.
+func redactContent(redactor *redact.Redactor) error {
+	root := archive.OutputRootDir(tempDir)
+	commandLog := filepath.Join(root, "bug-report.log")
+	common.LogAndPrintf("Redacting the captured content.\n")
+	if err := redactor.RedactDir(root, func(path string) bool {
+		return path == commandLog
+	}); err != nil {
+		return err
+	}
+	s := redactor.Summary()
+	common.LogAndPrintf("Redacted %d values in %d files, see %s in the archive.\n", s.Matches(), s.Files, redact.SummaryFileName)
+	return nil
+}
+
 func dumpRevisionsAndVersions(resources *cluster2.Resources, kubeconfig, configContext, istioNamespace string) {
 	text := ""
 	revisions := getIstioRevisions(resources)
@@ -55,6 +55,13 @@ func addFlags(cmd *cobra.Command, args *config2.BugReportConfig) {
 	cmd.PersistentFlags().BoolVarP(&args.FullSecrets, "full-secrets", "", false,
 		"If set, secret contents are included in output.")
 
+	// redaction
+	cmd.PersistentFlags().BoolVar(&args.Redaction.Disabled, "disable-redaction", false,
+		"If set, JWTs, authorization credentials, private keys, email addresses and the content matching the "+
+			"redaction rules of the config file are not redacted from the archive.")
+	cmd.PersistentFlags().BoolVar(&args.Redaction.RedactIPs, "redact-ips", false,
+		"If set, IPv4 addresses are replaced by pseudonyms, which are the same in all the files of the archive.")
+
 	// istio namespaces
 	cmd.PersistentFlags().StringVar(&args.IstioNamespace, "istio-namespace", bugReportDefaultIstioNamespace,
 		"Namespace where Istio control plane is installed.")
@@ -63,6 +70,10 @@ func addFlags(cmd *cobra.Command, args *config2.BugReportConfig) {
 	cmd.PersistentFlags().DurationVar(&commandTimeout, "timeout", bugReportDefaultTimeout,
 		"Maximum amount of time to spend fetching logs. When timeout is reached "+
 			"only the logs captured so far are saved to the archive.")
+	cmd.PersistentFlags().Int32Var(&args.MaxArchiveSizeMb, "max-size", 0,
+		"Maximum size of the compressed archive in MB. When the captured content is larger, logs, config dumps "+
+			"and cluster resources are ranked by importance and the least important ones are truncated or dropped. "+
+			"The archive includes a manifest of what was truncated or dropped. Default is unlimited.")
 	// include / exclude specs
 	cmd.PersistentFlags().StringSliceVar(&included, "include", bugReportDefaultInclude,
 		"Spec for which pods' proxy logs to include in the archive. See above for format and examples.")
@@ -0,0 +1,110 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bugreport
+
+import (
+	"path/filepath"
+	"strings"
+
+	"istio.io/istio/tools/bug-report/pkg/analyze"
+	"istio.io/istio/tools/bug-report/pkg/archive"
+	cluster2 "istio.io/istio/tools/bug-report/pkg/cluster"
+	"istio.io/istio/tools/bug-report/pkg/common"
+)
+
+// Importance of the artifacts, relative to the importance of the proxy logs computed from their errors by
+// processlog.Stats.Importance. Logs with critical errors come first, then Istiod, stale proxies and the cluster
+// resources.
+const (
+	istiodImportance     = 100000
+	staleProxyImportance = 50000
+	clusterImportance    = 10000
+)
+
+// fitToSize truncates or drops the least important artifacts so that the archive fits in maxSize bytes.
+func fitToSize(maxSize int64) error {
+	srcDir := archive.DirToArchive(tempDir)
+	m, err := archive.FitToSize(srcDir, archive.OutputRootDir(tempDir), maxSize, rankArtifacts(srcDir, getProxyImportance()))
+	if err != nil {
+		return err
+	}
+	if len(m.Truncated) != 0 || len(m.Dropped) != 0 {
+		common.LogAndPrintf("Truncated %d and dropped %d files to fit in the maximum archive size, see %s in the archive.\n",
+			len(m.Truncated), len(m.Dropped), archive.ManifestFileName)
+	}
+	return nil
+}
+
+// getProxyImportance returns the importance of the artifacts of the proxies, keyed by their output dir. It's the
+// importance of the log of the proxy, raised if Istiod reports that the proxy has not acked its latest config.
+func getProxyImportance() map[string]int {
+	stale := getStaleProxies()
+	out := make(map[string]int)
+	lock.RLock()
+	for path, imp := range importance {
+		namespace, _, pod, _, err := cluster2.ParsePath(path)
+		if err != nil {
+			continue
+		}
+		out[archive.ProxyOutputPath(tempDir, namespace, pod)] = imp
+	}
+	lock.RUnlock()
+	for dir := range stale {
+		out[dir] += staleProxyImportance
+	}
+	return out
+}
+
+// getStaleProxies returns the output dirs of the proxies which Istiod reports as not having acked their latest
+// config.
+func getStaleProxies() map[string]bool {
+	out := make(map[string]bool)
+	for key, s := range analyze.ReadProxyStatus(archive.OutputRootDir(tempDir)) {
+		if !s.Stale() {
+			continue
+		}
+		parts := strings.SplitN(key, "/", 2)
+		out[archive.ProxyOutputPath(tempDir, parts[0], parts[1])] = true
+	}
+	return out
+}
+
+// rankArtifacts returns the function ranking the files of the archive, identified by their path relative to srcDir.
+func rankArtifacts(srcDir string, proxyImportance map[string]int) func(path string) archive.Artifact {
+	root := archive.OutputRootDir(tempDir)
+	proxiesDir := archive.ProxyOutputPath(tempDir, "", "")
+	return func(path string) archive.Artifact {
+		file := filepath.Join(srcDir, path)
+		isLog := strings.HasSuffix(file, ".log")
+		switch {
+		case filepath.Dir(file) == root:
+			// Versions and the log of the command.
+			return archive.Artifact{Required: true}
+		case strings.HasPrefix(file, proxiesDir+string(filepath.Separator)):
+			// The files of a proxy are in proxies/namespace/pod.
+			parts := strings.SplitN(strings.TrimPrefix(file, proxiesDir+string(filepath.Separator)), string(filepath.Separator), 3)
+			imp := 0
+			if len(parts) == 3 {
+				imp = proxyImportance[archive.ProxyOutputPath(tempDir, parts[0], parts[1])]
+			}
+			return archive.Artifact{Importance: imp, Truncatable: isLog}
+		case strings.HasPrefix(file, archive.IstiodPath(tempDir, "", "")), strings.HasPrefix(file, archive.OperatorPath(tempDir, "", "")):
+			return archive.Artifact{Importance: istiodImportance, Truncatable: isLog}
+		default:
+			// Cluster resources and analyze output.
+			return archive.Artifact{Importance: clusterImportance}
+		}
+	}
+}
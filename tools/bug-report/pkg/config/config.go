@@ -133,6 +133,11 @@ type BugReportConfig struct {
 	// the command creates an archive with only the logs captured so far.
 	CommandTimeout Duration `json:"commandTimeout,omitempty"`
 
+	// MaxArchiveSizeMb is the maximum size of the archive in MB. When the
+	// collected artifacts are larger, the least important ones are truncated
+	// or dropped. Zero means unlimited.
+	MaxArchiveSizeMb int32 `json:"maxArchiveSizeMb,omitempty"`
+
 	// Include is a list of SelectionSpec entries for resources to include.
 	Include SelectionSpecs `json:"include,omitempty"`
 	// Exclude is a list of SelectionSpec entries for resources t0 exclude.
@@ -156,6 +161,60 @@ type BugReportConfig struct {
 	// IgnoredErrors are glob error patterns which are ignored when
 	// calculating the error heuristic for a log.
 	IgnoredErrors []string `json:"ignoredErrors,omitempty"`
+
+	// Redaction controls the redaction of secrets and personal data from the
+	// captured content before it is archived.
+	Redaction RedactionConfig `json:"redaction,omitempty"`
+}
+
+// RedactionConfig controls the redaction of the captured content. The built-in
+// rules redact JWTs, bearer and basic authorization credentials, private keys
+// and email addresses.
+type RedactionConfig struct {
+	// Disabled disables the redaction, including the built-in rules.
+	Disabled bool `json:"disabled,omitempty"`
+	// RedactIPs replaces IPv4 addresses with pseudonyms. Loopback and
+	// unspecified addresses are kept.
+	RedactIPs bool `json:"redactIPs,omitempty"`
+	// DisabledRules are the names of the built-in rules which are not applied:
+	// jwt, authorization, private-key or email.
+	DisabledRules []string `json:"disabledRules,omitempty"`
+	// Rules are additional rules, applied after the built-in ones.
+	Rules []*RedactionRule `json:"rules,omitempty"`
+}
+
+// RedactionRule selects content to redact, with either a regular expression or
+// a JSONPath expression.
+//
+// Examples:
+//
+// 1. Customer hostnames, replaced by the same pseudonym in all the files:
+//      name: host
+//      regex: '[a-z0-9.-]+\.example\.com'
+//      pseudonymize: true
+//
+// 2. The value of the x-api-key header only:
+//      name: api-key
+//      regex: 'x-api-key: (\S+)'
+//
+// 3. The names of the Envoy clusters in the config dumps:
+//      name: cluster
+//      jsonPath: '{..dynamic_active_clusters[*].cluster.name}'
+//      pseudonymize: true
+type RedactionRule struct {
+	// Name identifies the rule in the redaction summary and in the placeholders
+	// or pseudonyms replacing the content.
+	Name string `json:"name,omitempty"`
+	// Regex matches the content to redact in all the files. If the expression
+	// has groups, only the content of the first group is redacted.
+	Regex string `json:"regex,omitempty"`
+	// JSONPath selects the string values to redact in the JSON files, such as
+	// the config dumps, using the kubectl JSONPath syntax. All the
+	// occurrences of the selected values in the file are redacted.
+	JSONPath string `json:"jsonPath,omitempty"`
+	// Pseudonymize replaces each distinct value with a pseudonym, which is the
+	// same in all the files of the archive, instead of a placeholder.
+	Pseudonymize bool `json:"pseudonymize,omitempty"`
 }
 
 func (b *BugReportConfig) String() string {
@@ -169,6 +228,14 @@ func (b *BugReportConfig) String() string {
 	out += fmt.Sprintf("istio-namespace: %s\n", b.IstioNamespace)
 	out += fmt.Sprintf("full-secrets: %v\n", b.FullSecrets)
 	out += fmt.Sprintf("timeout (mins): %v\n", math.Round(float64(int(b.CommandTimeout))/float64(time.Minute)))
+	if b.MaxArchiveSizeMb != 0 {
+		out += fmt.Sprintf("max-size (MB): %d\n", b.MaxArchiveSizeMb)
+	}
+	if b.Redaction.Disabled {
+		out += "redaction: disabled\n"
+	} else {
+		out += fmt.Sprintf("redact-ips: %v\n", b.Redaction.RedactIPs)
+	}
 	out += fmt.Sprintf("include: %s\n", b.Include)
 	out += fmt.Sprintf("exclude: %s\n", b.Exclude)
 	if !b.StartTime.Equal(time.Time{}) {
@@ -46,6 +46,16 @@ criticalErrors:
 ignoredErrors:
   - e3
   - e4
+redaction:
+  redactIPs: true
+  disabledRules:
+  - email
+  rules:
+  - name: host
+    regex: '[a-z]+\.example\.com'
+    pseudonymize: true
+  - name: cluster
+    jsonPath: '{..cluster.name}'
 `
 	wantTime, err := time.Parse(time.RFC3339, "2002-10-02T10:00:00-05:00")
 	if err != nil {
@@ -109,6 +119,14 @@ ignoredErrors:
 		Since:          Duration(time.Minute),
 		CriticalErrors: []string{"e1", "e2"},
 		IgnoredErrors:  []string{"e3", "e4"},
+		Redaction: RedactionConfig{
+			RedactIPs:     true,
+			DisabledRules: []string{"email"},
+			Rules: []*RedactionRule{
+				{Name: "host", Regex: `[a-z]+\.example\.com`, Pseudonymize: true},
+				{Name: "cluster", JSONPath: "{..cluster.name}"},
+			},
+		},
 	}
 
 	got := &BugReportConfig{}
@@ -15,6 +15,7 @@
 package processlog
 
 import (
+	"sort"
 	"strings"
 	"time"
 
@@ -33,24 +34,72 @@ const (
 
 // Stats represents log statistics.
 type Stats struct {
-	numFatals   int
-	numErrors   int
-	numWarnings int
+	// numCriticalErrors is the number of errors matching the critical error patterns of the config.
+	numCriticalErrors int
+	numFatals         int
+	numErrors         int
+	numWarnings       int
+	// errorCounts counts the occurrences of the critical, fatal and error messages.
+	errorCounts map[string]int
+}
+
+// ErrorCount is the number of occurrences of an error message in a log.
+type ErrorCount struct {
+	Message string
+	Count   int
 }
 
 // Importance returns an integer that indicates the importance of the log, based on the given Stats in s.
-// Larger numbers are more important.
+// Larger numbers are more important. Any critical error makes the log more important than the logs without one.
 func (s *Stats) Importance() int {
 	if s == nil {
 		return 0
 	}
-	return 1000*s.numFatals + 100*s.numErrors + 10*s.numWarnings
+	return 1000000*s.numCriticalErrors + 1000*s.numFatals + 100*s.numErrors + 10*s.numWarnings
+}
+
+// CriticalErrors returns the number of errors matching the critical error patterns.
+func (s *Stats) CriticalErrors() int {
+	return s.numCriticalErrors
+}
+
+// Fatals returns the number of fatal messages, not counting the critical and ignored ones.
+func (s *Stats) Fatals() int {
+	return s.numFatals
+}
+
+// Errors returns the number of error messages, not counting the critical and ignored ones.
+func (s *Stats) Errors() int {
+	return s.numErrors
+}
+
+// Warnings returns the number of warning messages, not counting the critical and ignored ones.
+func (s *Stats) Warnings() int {
+	return s.numWarnings
+}
+
+// TopErrors returns the n most frequent critical, fatal and error messages.
+func (s *Stats) TopErrors(n int) []ErrorCount {
+	out := make([]ErrorCount, 0, len(s.errorCounts))
+	for msg, count := range s.errorCounts {
+		out = append(out, ErrorCount{Message: msg, Count: count})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return out[i].Message < out[j].Message
+	})
+	if len(out) > n {
+		out = out[:n]
+	}
+	return out
 }
 
 // Process processes logStr based on the supplied config and returns the processed log along with statistics on it.
 func Process(config *config.BugReportConfig, logStr string) (string, *Stats) {
 	out := getTimeRange(logStr, config.StartTime, config.EndTime)
-	return out, getStats(config, out)
+	return out, GetStats(config, out)
 }
 
 // getTimeRange returns the log lines that fall inside the start to end time range, inclusive.
@@ -74,9 +123,9 @@ func getTimeRange(logStr string, start, end time.Time) string {
 	return sb.String()
 }
 
-// getStats returns statistics for the given log string.
-func getStats(config *config.BugReportConfig, logStr string) *Stats {
-	out := &Stats{}
+// GetStats returns statistics for the given log string.
+func GetStats(config *config.BugReportConfig, logStr string) *Stats {
+	out := &Stats{errorCounts: make(map[string]int)}
 	for _, l := range strings.Split(logStr, "\n") {
 		_, level, text, valid := processLogLine(l)
 		if !valid {
@@ -84,14 +133,22 @@ func getStats(config *config.BugReportConfig, logStr string) *Stats {
 		}
 		switch level {
 		case levelFatal, levelError, levelWarn:
-			if match.MatchesGlobs(text, config.IgnoredErrors) {
+			// MatchesGlobs matches everything when there is no pattern.
+			if len(config.CriticalErrors) != 0 && match.MatchesGlobs(text, config.CriticalErrors) {
+				out.numCriticalErrors++
+				out.errorCounts[text]++
+				continue
+			}
+			if len(config.IgnoredErrors) != 0 && match.MatchesGlobs(text, config.IgnoredErrors) {
 				continue
 			}
 			switch level {
 			case levelFatal:
 				out.numFatals++
+				out.errorCounts[text]++
 			case levelError:
 				out.numErrors++
+				out.errorCounts[text]++
 			case levelWarn:
 				out.numWarnings++
 			}
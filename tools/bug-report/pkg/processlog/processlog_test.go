@@ -16,11 +16,13 @@ package processlog
 
 import (
 	"path/filepath"
+	"reflect"
 	"testing"
 	"time"
 
 	"istio.io/istio/pilot/test/util"
 	"istio.io/istio/pkg/test/env"
+	"istio.io/istio/tools/bug-report/pkg/config"
 )
 
 func TestTimeRangeFilter(t *testing.T) {
@@ -83,3 +85,54 @@ func TestTimeRangeFilter(t *testing.T) {
 		})
 	}
 }
+
+func TestImportance(t *testing.T) {
+	logStr := `2020-06-29T23:37:27.285018Z	info	Version 1.9.0
+2020-06-29T23:37:27.285053Z	warn	cannot watch the config: timeout
+2020-06-29T23:37:27.285550Z	error	failed to connect to upstream
+2020-06-29T23:37:27.285885Z	error	failed to warm certificate: context deadline exceeded
+2020-06-29T23:37:27.286034Z	fatal	cannot bind to port 15090
+`
+	tests := []struct {
+		name   string
+		config *config.BugReportConfig
+		want   int
+	}{
+		{
+			name:   "no patterns",
+			config: &config.BugReportConfig{},
+			want:   1000 + 2*100 + 10,
+		},
+		{
+			name:   "ignored errors",
+			config: &config.BugReportConfig{IgnoredErrors: []string{"failed to connect*"}},
+			want:   1000 + 100 + 10,
+		},
+		{
+			name:   "critical errors",
+			config: &config.BugReportConfig{CriticalErrors: []string{"*certificate*"}},
+			want:   1000000 + 1000 + 100 + 10,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GetStats(tt.config, logStr).Importance(); got != tt.want {
+				t.Errorf("got importance %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTopErrors(t *testing.T) {
+	logStr := `2020-06-29T23:37:27.285053Z	warn	cannot watch the config: timeout
+2020-06-29T23:37:27.285550Z	error	failed to connect to upstream
+2020-06-29T23:37:27.285885Z	error	failed to warm certificate
+2020-06-29T23:37:27.286034Z	error	failed to connect to upstream
+2020-06-29T23:37:27.286035Z	fatal	cannot bind to port 15090
+`
+	got := GetStats(&config.BugReportConfig{}, logStr).TopErrors(2)
+	want := []ErrorCount{{"failed to connect to upstream", 2}, {"cannot bind to port 15090", 1}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
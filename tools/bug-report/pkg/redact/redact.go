@@ -0,0 +1,423 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package redact removes secrets and personal data from the content captured by bug-report before it is archived.
+// Values are replaced either by a placeholder or by a pseudonym derived from the value with a key generated for each
+// run, so that the same value is replaced by the same pseudonym in all the files of an archive, without being
+// recoverable from it.
+package redact
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"k8s.io/client-go/util/jsonpath"
+
+	"istio.io/istio/tools/bug-report/pkg/config"
+)
+
+const (
+	// SummaryFileName is the name of the redaction summary written in the output root dir.
+	SummaryFileName = "redaction.json"
+
+	// Names of the built-in rules.
+	JWT           = "jwt"
+	Authorization = "authorization"
+	PrivateKey    = "private-key"
+	Email         = "email"
+	IP            = "ip"
+
+	// binarySniffLen is the length of the prefix of a file checked for NUL bytes to detect binary files, like the
+	// core dumps of the proxies, which cannot be redacted without corrupting them.
+	binarySniffLen = 8000
+)
+
+// builtinRules are applied in order, before the rules of the config. Private keys and JWTs come first so that their
+// content is not partially matched by the other rules.
+var builtinRules = []*config.RedactionRule{
+	{Name: PrivateKey, Regex: `(?s)-----BEGIN [A-Z ]*PRIVATE KEY-----.*?-----END [A-Z ]*PRIVATE KEY-----`},
+	{Name: JWT, Regex: `eyJ[A-Za-z0-9_-]+\.eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`},
+	{Name: Authorization, Regex: `(?i)\b(?:bearer|basic)\s+([A-Za-z0-9._~+/-]{8,}=*)`},
+	{Name: Email, Regex: `[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}`, Pseudonymize: true},
+}
+
+var ipRule = &config.RedactionRule{
+	Name:         IP,
+	Regex:        `\b(?:(?:25[0-5]|2[0-4][0-9]|1?[0-9]?[0-9])\.){3}(?:25[0-5]|2[0-4][0-9]|1?[0-9]?[0-9])\b`,
+	Pseudonymize: true,
+}
+
+// keptIPs are not redacted, since they carry no information about the cluster.
+var keptIPs = map[string]bool{
+	"0.0.0.0":   true,
+	"127.0.0.1": true,
+}
+
+// rule is a compiled config.RedactionRule.
+type rule struct {
+	name         string
+	regex        *regexp.Regexp
+	jsonPath     *jsonpath.JSONPath
+	pseudonymize bool
+	// keep returns true for the matched values which are not redacted.
+	keep func(value string) bool
+}
+
+// RuleSummary is the number of values a rule redacted.
+type RuleSummary struct {
+	Name string `json:"name"`
+	// Matches is the number of redacted values.
+	Matches int `json:"matches"`
+	// Files is the number of files with redacted values.
+	Files int `json:"files"`
+	// Pseudonyms is the number of distinct values replaced by a pseudonym.
+	Pseudonyms int `json:"pseudonyms,omitempty"`
+}
+
+// Summary describes what was redacted from the archive, without the redacted values.
+type Summary struct {
+	// Files is the number of redacted files.
+	Files int `json:"files"`
+	// Rules are the rules applied, in order.
+	Rules []*RuleSummary `json:"rules"`
+	// Skipped are the binary files which were not redacted, relative to the redacted dir.
+	Skipped []string `json:"skipped,omitempty"`
+}
+
+// Matches returns the total number of redacted values.
+func (s *Summary) Matches() int {
+	n := 0
+	for _, r := range s.Rules {
+		n += r.Matches
+	}
+	return n
+}
+
+// Redactor redacts content with the built-in rules and the rules of a config.RedactionConfig. It is safe for
+// concurrent use.
+type Redactor struct {
+	rules []*rule
+	// key of the HMAC of the pseudonyms, generated for each Redactor.
+	key []byte
+
+	mu         sync.Mutex
+	summary    *Summary
+	byName     map[string]*RuleSummary
+	pseudonyms map[string]map[string]bool
+}
+
+// New returns a Redactor applying the rules of cfg, or an error if a rule is invalid.
+func New(cfg *config.RedactionConfig) (*Redactor, error) {
+	disabled := make(map[string]bool)
+	for _, name := range cfg.DisabledRules {
+		disabled[name] = true
+	}
+	var rules []*config.RedactionRule
+	for _, r := range builtinRules {
+		if !disabled[r.Name] {
+			rules = append(rules, r)
+		}
+	}
+	if cfg.RedactIPs {
+		rules = append(rules, ipRule)
+	}
+	rules = append(rules, cfg.Rules...)
+
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	r := &Redactor{
+		key:        key,
+		summary:    &Summary{},
+		byName:     make(map[string]*RuleSummary),
+		pseudonyms: make(map[string]map[string]bool),
+	}
+	for _, rr := range rules {
+		c, err := compile(rr)
+		if err != nil {
+			return nil, err
+		}
+		if _, f := r.byName[c.name]; f {
+			return nil, fmt.Errorf("duplicate redaction rule %s", c.name)
+		}
+		r.rules = append(r.rules, c)
+		rs := &RuleSummary{Name: c.name}
+		r.summary.Rules = append(r.summary.Rules, rs)
+		r.byName[c.name] = rs
+		r.pseudonyms[c.name] = make(map[string]bool)
+	}
+	return r, nil
+}
+
+func compile(r *config.RedactionRule) (*rule, error) {
+	if r.Name == "" {
+		return nil, fmt.Errorf("redaction rule without name")
+	}
+	if (r.Regex == "") == (r.JSONPath == "") {
+		return nil, fmt.Errorf("redaction rule %s must have either a regex or a jsonPath", r.Name)
+	}
+	out := &rule{name: r.Name, pseudonymize: r.Pseudonymize}
+	if r.Name == IP {
+		out.keep = func(value string) bool {
+			return keptIPs[value]
+		}
+	}
+	if r.Regex != "" {
+		re, err := regexp.Compile(r.Regex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex of redaction rule %s: %v", r.Name, err)
+		}
+		out.regex = re
+		return out, nil
+	}
+	path := r.JSONPath
+	if !strings.HasPrefix(path, "{") {
+		path = "{" + path + "}"
+	}
+	jp := jsonpath.New(r.Name).AllowMissingKeys(true)
+	if err := jp.Parse(path); err != nil {
+		return nil, fmt.Errorf("invalid jsonPath of redaction rule %s: %v", r.Name, err)
+	}
+	out.jsonPath = jp
+	return out, nil
+}
+
+// Redact returns the content with the values matching the rules replaced, and the number of values replaced by
+// each rule. The JSONPath rules are only applied if the content is JSON.
+func (r *Redactor) Redact(content []byte) ([]byte, map[string]int) {
+	counts := make(map[string]int)
+	var doc interface{}
+	parsed := false
+	for _, ru := range r.rules {
+		if ru.jsonPath == nil {
+			continue
+		}
+		if !parsed {
+			parsed = true
+			if err := json.Unmarshal(content, &doc); err != nil {
+				doc = nil
+			}
+		}
+		if doc == nil {
+			break
+		}
+		var n int
+		content, n = r.redactJSONPath(ru, doc, content)
+		counts[ru.name] += n
+	}
+	for _, ru := range r.rules {
+		if ru.regex == nil {
+			continue
+		}
+		var n int
+		content, n = r.redactRegex(ru, content)
+		counts[ru.name] += n
+	}
+	return content, counts
+}
+
+func (r *Redactor) redactRegex(ru *rule, content []byte) ([]byte, int) {
+	matches := ru.regex.FindAllSubmatchIndex(content, -1)
+	if len(matches) == 0 {
+		return content, 0
+	}
+	var out bytes.Buffer
+	last, n := 0, 0
+	for _, m := range matches {
+		start, end := m[0], m[1]
+		if len(m) > 2 {
+			if m[2] < 0 {
+				continue
+			}
+			start, end = m[2], m[3]
+		}
+		value := string(content[start:end])
+		if ru.keep != nil && ru.keep(value) {
+			continue
+		}
+		out.Write(content[last:start])
+		out.WriteString(r.replacement(ru, value))
+		last = end
+		n++
+	}
+	out.Write(content[last:])
+	return out.Bytes(), n
+}
+
+// redactJSONPath replaces the occurrences of the string values selected by the rule in the parsed doc, as JSON
+// strings in the content, which keeps the formatting of the content.
+func (r *Redactor) redactJSONPath(ru *rule, doc interface{}, content []byte) ([]byte, int) {
+	results, err := ru.jsonPath.FindResults(doc)
+	if err != nil {
+		return content, 0
+	}
+	values := make(map[string]bool)
+	for _, rs := range results {
+		for _, v := range rs {
+			collectStrings(v, values)
+		}
+	}
+	sorted := make([]string, 0, len(values))
+	for v := range values {
+		sorted = append(sorted, v)
+	}
+	sort.Strings(sorted)
+	n := 0
+	for _, v := range sorted {
+		if ru.keep != nil && ru.keep(v) {
+			continue
+		}
+		quoted := quote(v)
+		if c := bytes.Count(content, quoted); c > 0 {
+			content = bytes.ReplaceAll(content, quoted, quote(r.replacement(ru, v)))
+			n += c
+		}
+	}
+	return content, n
+}
+
+// collectStrings adds the non empty strings of the value, which is an unmarshalled JSON value, to out.
+func collectStrings(v reflect.Value, out map[string]bool) {
+	if !v.IsValid() {
+		return
+	}
+	switch val := v.Interface().(type) {
+	case string:
+		if val != "" {
+			out[val] = true
+		}
+	case map[string]interface{}:
+		for _, e := range val {
+			collectStrings(reflect.ValueOf(e), out)
+		}
+	case []interface{}:
+		for _, e := range val {
+			collectStrings(reflect.ValueOf(e), out)
+		}
+	}
+}
+
+// quote returns the value as a JSON string, as written by Envoy and kubectl, which do not escape HTML characters.
+func quote(v string) []byte {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(v)
+	return bytes.TrimSuffix(b.Bytes(), []byte("\n"))
+}
+
+// replacement returns the placeholder or the pseudonym replacing the value.
+func (r *Redactor) replacement(ru *rule, value string) string {
+	if !ru.pseudonymize {
+		return "[redacted:" + ru.name + "]"
+	}
+	mac := hmac.New(sha256.New, r.key)
+	mac.Write([]byte(value))
+	p := ru.name + "-" + hex.EncodeToString(mac.Sum(nil)[:6])
+	r.mu.Lock()
+	r.pseudonyms[ru.name][p] = true
+	r.mu.Unlock()
+	return p
+}
+
+// RedactFile redacts the file in place. Binary files are not redacted, and are recorded as skipped in the summary
+// with the given name.
+func (r *Redactor) RedactFile(path, name string) error {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	sniff := content
+	if len(sniff) > binarySniffLen {
+		sniff = sniff[:binarySniffLen]
+	}
+	if bytes.IndexByte(sniff, 0) >= 0 {
+		r.mu.Lock()
+		r.summary.Skipped = append(r.summary.Skipped, name)
+		r.mu.Unlock()
+		return nil
+	}
+
+	out, counts := r.Redact(content)
+	r.mu.Lock()
+	r.summary.Files++
+	for rn, n := range counts {
+		if n > 0 {
+			r.byName[rn].Matches += n
+			r.byName[rn].Files++
+		}
+	}
+	r.mu.Unlock()
+	if bytes.Equal(out, content) {
+		return nil
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, out, fi.Mode())
+}
+
+// RedactDir redacts the files in dir, except those for which skip returns true, and writes the summary in
+// SummaryFileName in dir.
+func (r *Redactor) RedactDir(dir string, skip func(path string) bool) error {
+	err := filepath.Walk(dir, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !fi.Mode().IsRegular() || skip != nil && skip(path) {
+			return nil
+		}
+		name, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		return r.RedactFile(path, name)
+	})
+	if err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(r.Summary(), "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, SummaryFileName), b, 0644)
+}
+
+// Summary returns the summary of the content redacted so far.
+func (r *Redactor) Summary() *Summary {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := &Summary{Files: r.summary.Files, Skipped: append([]string(nil), r.summary.Skipped...)}
+	for _, rs := range r.summary.Rules {
+		c := *rs
+		c.Pseudonyms = len(r.pseudonyms[rs.Name])
+		out.Rules = append(out.Rules, &c)
+	}
+	return out
+}